@@ -18,6 +18,8 @@ import (
 	"time"
 
 	"shelley.exe.dev/claudetool/bashkit"
+	"shelley.exe.dev/claudetool/sandbox"
+	"shelley.exe.dev/gitstate"
 	"shelley.exe.dev/llm"
 )
 
@@ -44,6 +46,8 @@ type BashTool struct {
 	// ConversationID is the ID of the conversation this tool belongs to.
 	// It is exposed to invoked commands via SHELLEY_CONVERSATION_ID.
 	ConversationID string
+	// Sandbox returns the sandbox settings for the next command, if set.
+	Sandbox func() sandbox.Settings
 }
 
 const (
@@ -93,7 +97,7 @@ func (b *BashTool) getWorkingDir() string {
 
 // isNoTrailerSet checks if user has disabled co-author trailer via git config.
 func (b *BashTool) isNoTrailerSet() bool {
-	out, err := exec.Command("git", "config", "--get", "shelley.no-trailer").Output()
+	out, err := gitstate.Command("", "config", "--get", "shelley.no-trailer").Output()
 	if err != nil {
 		return false
 	}
@@ -147,6 +151,8 @@ type bashInput struct {
 // BashDisplayData is the display data sent to the UI for bash tool results.
 type BashDisplayData struct {
 	WorkingDir string `json:"workingDir"`
+	// Sandbox describes how the command was confined, if it was.
+	Sandbox *sandbox.Report `json:"sandbox,omitempty"`
 }
 
 func (i *bashInput) timeout(t *Timeouts) time.Duration {
@@ -201,9 +207,10 @@ func (b *BashTool) Run(ctx context.Context, m json.RawMessage) llm.ToolOut {
 
 	display := BashDisplayData{WorkingDir: wd}
 
-	out, execErr := b.executeBash(ctx, req, timeout)
+	out, report, execErr := b.executeBash(ctx, req, timeout)
+	display.Sandbox = report
 	if execErr != nil {
-		return llm.ErrorToolOut(execErr)
+		return llm.ToolOut{Error: execErr, Display: display}
 	}
 	return llm.ToolOut{LLMContent: llm.TextContent(out), Display: display}
 }
@@ -215,8 +222,16 @@ const (
 	maxLineLength        = 200 // truncate displayed lines to this length
 )
 
-func (b *BashTool) makeBashCommand(ctx context.Context, command string, out io.Writer) *exec.Cmd {
-	cmd := exec.CommandContext(ctx, "bash", "--login", "-c", command)
+// makeBashCommand returns the command running command in bash. A login
+// shell sources the user's profile, which sandboxed commands skip: profile
+// hooks such as pyenv's write beneath the home directory, and hang or fail
+// when they cannot.
+func (b *BashTool) makeBashCommand(ctx context.Context, command string, out io.Writer, login bool) *exec.Cmd {
+	args := []string{"-c", command}
+	if login {
+		args = append([]string{"--login"}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "bash", args...)
 	// Use shared WorkingDir if available, then context, then Pwd fallback
 	cmd.Dir = b.getWorkingDir()
 	cmd.Stdin = nil
@@ -233,9 +248,10 @@ func (b *BashTool) makeBashCommand(ctx context.Context, command string, out io.W
 		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) // kill entire process group
 	}
 	cmd.WaitDelay = 15 * time.Second // prevent indefinite hangs when child processes keep pipes open
-	// Remove SHELLEY_CONVERSATION_ID so we control it explicitly below.
+	// Remove SHELLEY_CONVERSATION_ID so we control it explicitly below,
+	// and SHELLEY_SECRET_KEY, which would let commands decrypt stored secrets.
 	env := slices.DeleteFunc(os.Environ(), func(s string) bool {
-		return strings.HasPrefix(s, "SHELLEY_CONVERSATION_ID=")
+		return strings.HasPrefix(s, "SHELLEY_CONVERSATION_ID=") || strings.HasPrefix(s, "SHELLEY_SECRET_KEY=")
 	})
 	env = append(env, "SKETCH=1")          // signal that this has been run by Sketch, sometimes useful for scripts
 	env = append(env, "EDITOR=/bin/false") // interactive editors won't work
@@ -255,32 +271,51 @@ func cmdWait(cmd *exec.Cmd) error {
 	return err
 }
 
-func (b *BashTool) executeBash(ctx context.Context, req bashInput, timeout time.Duration) (string, error) {
+// executeBash runs a command, in the sandbox if it is enabled, returning
+// how it was sandboxed.
+func (b *BashTool) executeBash(ctx context.Context, req bashInput, timeout time.Duration) (string, *sandbox.Report, error) {
 	execCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	var settings sandbox.Settings
+	if b.Sandbox != nil {
+		settings = b.Sandbox()
+	}
 	output := new(bytes.Buffer)
-	cmd := b.makeBashCommand(execCtx, req.Command, output)
+	cmd := b.makeBashCommand(execCtx, req.Command, output, !settings.Enabled)
 	cmd.Env = append(cmd.Env, `GIT_SEQUENCE_EDITOR=echo "To do an interactive rebase, run it in a tmux session." && exit 1`)
+	var report *sandbox.Report
+	if settings.Enabled {
+		cleanup, r, err := sandbox.Apply(cmd, settings, sandbox.WritableRoots(cmd.Dir, settings.WritablePaths))
+		if err != nil {
+			return "", nil, fmt.Errorf("refusing to run the command unsandboxed: %w", err)
+		}
+		defer cleanup()
+		report = &r
+	}
 	if err := cmd.Start(); err != nil {
-		return "", fmt.Errorf("command failed: %w", err)
+		return "", report, fmt.Errorf("command failed: %w", err)
 	}
 
 	err := cmdWait(cmd)
 
 	out, formatErr := formatForegroundBashOutput(output.String())
 	if formatErr != nil {
-		return "", formatErr
+		return "", report, formatErr
 	}
 
 	if execCtx.Err() == context.DeadlineExceeded {
-		return "", fmt.Errorf("[command timed out after %s, showing output until timeout]\n%s", timeout, out)
+		return "", report, fmt.Errorf("[command timed out after %s, showing output until timeout]\n%s", timeout, out)
 	}
 	if err != nil {
-		return "", fmt.Errorf("[command failed: %w]\n%s", err, out)
+		// Tell the agent about the sandbox, which may be why the command failed.
+		if report != nil {
+			out += fmt.Sprintf("\n[sandboxed: writable %s; network %s]", strings.Join(report.Writable, ", "), report.Network)
+		}
+		return "", report, fmt.Errorf("[command failed: %w]\n%s", err, out)
 	}
 
-	return out, nil
+	return out, report, nil
 }
 
 // formatForegroundBashOutput formats the output of a foreground bash command for display to the agent.
@@ -3,10 +3,16 @@ package claudetool
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+
+	"shelley.exe.dev/claudetool/sandbox"
 )
 
 func TestBashSlowOk(t *testing.T) {
@@ -153,7 +159,7 @@ func TestExecuteBash(t *testing.T) {
 			Command: "echo 'Success'",
 		}
 
-		output, err := bashTool.executeBash(ctx, req, 5*time.Second)
+		output, _, err := bashTool.executeBash(ctx, req, 5*time.Second)
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -174,7 +180,7 @@ func TestExecuteBash(t *testing.T) {
 			Command: "echo $SHELLEY_CONVERSATION_ID",
 		}
 
-		output, err := bashWithConvID.executeBash(ctx, req, 5*time.Second)
+		output, _, err := bashWithConvID.executeBash(ctx, req, 5*time.Second)
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -191,7 +197,7 @@ func TestExecuteBash(t *testing.T) {
 			Command: "echo \"conv_id:$SHELLEY_CONVERSATION_ID:\"",
 		}
 
-		output, err := bashTool.executeBash(ctx, req, 5*time.Second)
+		output, _, err := bashTool.executeBash(ctx, req, 5*time.Second)
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -203,13 +209,31 @@ func TestExecuteBash(t *testing.T) {
 		}
 	})
 
+	// Test the master key is not passed on to commands
+	t.Run("SHELLEY_SECRET_KEY Not Inherited", func(t *testing.T) {
+		t.Setenv("SHELLEY_SECRET_KEY", "c2VjcmV0")
+		req := bashInput{
+			Command: "echo \"key:$SHELLEY_SECRET_KEY:\"",
+		}
+
+		output, _, err := bashTool.executeBash(ctx, req, 5*time.Second)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		want := "key::\n"
+		if output != want {
+			t.Errorf("Expected empty SHELLEY_SECRET_KEY, got %q", output)
+		}
+	})
+
 	// Test that bash runs as a login shell (sources user profile)
 	t.Run("Login Shell", func(t *testing.T) {
 		req := bashInput{
 			Command: "shopt login_shell | grep -q on && echo login",
 		}
 
-		output, err := bashTool.executeBash(ctx, req, 5*time.Second)
+		output, _, err := bashTool.executeBash(ctx, req, 5*time.Second)
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -226,7 +250,7 @@ func TestExecuteBash(t *testing.T) {
 			Command: "echo 'Error message' >&2 && echo 'Success'",
 		}
 
-		output, err := bashTool.executeBash(ctx, req, 5*time.Second)
+		output, _, err := bashTool.executeBash(ctx, req, 5*time.Second)
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -243,7 +267,7 @@ func TestExecuteBash(t *testing.T) {
 			Command: "echo 'Error message' >&2 && exit 1",
 		}
 
-		_, err := bashTool.executeBash(ctx, req, 5*time.Second)
+		_, _, err := bashTool.executeBash(ctx, req, 5*time.Second)
 		if err == nil {
 			t.Errorf("Expected error for failed command, got none")
 		} else if !strings.Contains(err.Error(), "Error message") {
@@ -258,7 +282,7 @@ func TestExecuteBash(t *testing.T) {
 		}
 
 		start := time.Now()
-		_, err := bashTool.executeBash(ctx, req, 100*time.Millisecond)
+		_, _, err := bashTool.executeBash(ctx, req, 100*time.Millisecond)
 		elapsed := time.Since(start)
 
 		// Command should time out after ~100ms, not wait for full 1 second
@@ -274,6 +298,56 @@ func TestExecuteBash(t *testing.T) {
 	})
 }
 
+func TestBashSandbox(t *testing.T) {
+	wd := t.TempDir()
+	// The package directory is outside the temp dirs the sandbox allows.
+	pkgDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	denied := filepath.Join(pkgDir, "sandbox-test-denied")
+	t.Cleanup(func() { os.Remove(denied) })
+
+	bash := &BashTool{
+		WorkingDir: NewMutableWorkingDir(wd),
+		Sandbox:    func() sandbox.Settings { return sandbox.Settings{Enabled: true} },
+	}
+	run := func(command string) (BashDisplayData, error) {
+		t.Helper()
+		input, _ := json.Marshal(bashInput{Command: command})
+		out := bash.Tool().Run(context.Background(), input)
+		if errors.Is(out.Error, sandbox.ErrUnsupported) {
+			t.Skip(out.Error)
+		}
+		display, _ := out.Display.(BashDisplayData)
+		return display, out.Error
+	}
+
+	display, err := run("echo hi > made-here")
+	if err != nil {
+		t.Fatalf("write in the working directory: %v", err)
+	}
+	if display.Sandbox == nil || display.Sandbox.Writable[0] != wd {
+		t.Errorf("display = %+v", display)
+	}
+	// Build tools need their caches.
+	if cache, err := os.UserCacheDir(); err == nil {
+		if _, err := os.Stat(cache); err == nil && !slices.Contains(display.Sandbox.Writable, cache) {
+			t.Errorf("cache directory %s is not writable: %+v", cache, display.Sandbox)
+		}
+	}
+	display, err = run("echo hi > " + denied)
+	if err == nil || !strings.Contains(err.Error(), "[sandboxed: writable "+wd) {
+		t.Errorf("write outside the sandbox: %v", err)
+	}
+	if display.Sandbox == nil {
+		t.Error("failed command has no sandbox report")
+	}
+	if _, err := os.Stat(denied); err == nil {
+		t.Error("file created outside the sandbox")
+	}
+}
+
 func TestBashTimeout(t *testing.T) {
 	// Test default timeout values
 	t.Run("Default Timeout Values", func(t *testing.T) {
@@ -65,3 +65,41 @@ func ExtractCommands(command string) ([]string, error) {
 
 	return commands, nil
 }
+
+// SimpleCommands parses a bash command and returns each simple command in it,
+// printed on a single line with its arguments. Pipelines, lists, and command
+// substitutions are broken up into their component commands.
+//
+// Examples:
+//
+//	"git status && git diff | less" → ["git status", "git diff", "less"]
+//	"echo $(date)" → ["echo $(date)", "date"]
+func SimpleCommands(command string) ([]string, error) {
+	r := strings.NewReader(command)
+	parser := syntax.NewParser()
+	file, err := parser.Parse(r, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bash command: %w", err)
+	}
+
+	printer := syntax.NewPrinter(syntax.SingleLine(true))
+	var commands []string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		callExpr, ok := node.(*syntax.CallExpr)
+		if !ok || len(callExpr.Args) == 0 {
+			return true
+		}
+		var words []string
+		for _, arg := range callExpr.Args {
+			var sb strings.Builder
+			if err := printer.Print(&sb, arg); err != nil {
+				return true
+			}
+			words = append(words, sb.String())
+		}
+		commands = append(commands, strings.Join(words, " "))
+		return true
+	})
+
+	return commands, nil
+}
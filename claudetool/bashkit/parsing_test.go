@@ -203,3 +203,33 @@ func TestExtractCommandsEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestSimpleCommands(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"git status", []string{"git status"}},
+		{"git status && git diff | less", []string{"git status", "git diff", "less"}},
+		{"git   log  --oneline", []string{"git log --oneline"}},
+		{"echo $(date)", []string{"echo $(date)", "date"}},
+		{"git commit -m 'a message'", []string{"git commit -m 'a message'"}},
+		{"git status; rm -rf build", []string{"git status", "rm -rf build"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := SimpleCommands(tt.input)
+			if err != nil {
+				t.Fatalf("SimpleCommands(%q) error: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("SimpleCommands(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := SimpleCommands("if then"); err == nil {
+		t.Error("expected parse error")
+	}
+}
@@ -234,20 +234,7 @@ func (b *BrowseTools) accessibilityQuery(name, role string) llm.ToolOut {
 
 	var nodes []*accessibility.Node
 	err = chromedp.Run(browserCtx, chromedp.ActionFunc(func(ctx context.Context) error {
-		// Get the document root node ID
-		doc, err := dom.GetDocument().WithDepth(0).Do(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to get document: %w", err)
-		}
-
-		params := accessibility.QueryAXTree().WithNodeID(doc.NodeID)
-		if name != "" {
-			params = params.WithAccessibleName(name)
-		}
-		if role != "" {
-			params = params.WithRole(role)
-		}
-		result, err := params.Do(ctx)
+		result, err := queryAXNodes(ctx, name, role)
 		if err != nil {
 			return err
 		}
@@ -290,6 +277,24 @@ func (b *BrowseTools) accessibilityQuery(name, role string) llm.ToolOut {
 	return b.maybeWriteToFile(result, "ax_query")
 }
 
+// queryAXNodes searches the whole document's accessibility tree for nodes
+// matching the accessible name and/or role. It must run inside chromedp.Run.
+func queryAXNodes(ctx context.Context, name, role string) ([]*accessibility.Node, error) {
+	doc, err := dom.GetDocument().WithDepth(0).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	params := accessibility.QueryAXTree().WithNodeID(doc.NodeID)
+	if name != "" {
+		params = params.WithAccessibleName(name)
+	}
+	if role != "" {
+		params = params.WithRole(role)
+	}
+	return params.Do(ctx)
+}
+
 func (b *BrowseTools) accessibilityNode(selector string) llm.ToolOut {
 	if selector == "" {
 		return llm.ErrorfToolOut("'selector' parameter is required for the node action")
@@ -61,8 +61,13 @@ type BrowseTools struct {
 	// Map to track screenshots by ID and their creation time
 	screenshots      map[string]time.Time
 	screenshotsMutex sync.Mutex
-	// Console logs storage
-	consoleLogs      []*runtime.EventConsoleAPICalled
+	// Named tabs other than the main tab, and the active tab's name (guarded by mux)
+	tabs      map[string]*browserTab
+	activeTab string
+	// Active tab's console and network logs. The pointer is swapped on tab
+	// switch while holding both consoleLogsMutex and networkMutex.
+	*tabState
+	mainState        *tabState
 	consoleLogsMutex sync.Mutex
 	maxConsoleLogs   int
 	// Idle timeout management
@@ -75,8 +80,6 @@ type BrowseTools struct {
 	downloadsMutex sync.Mutex
 	downloadCond   *sync.Cond
 	// Network monitoring
-	networkEnabled     bool
-	networkRequests    []*NetworkRequest
 	networkMutex       sync.Mutex
 	maxNetworkRequests int
 	// Profiling state
@@ -85,6 +88,11 @@ type BrowseTools struct {
 	traceEvents     []json.RawMessage
 	traceCompleteCh chan struct{}
 	traceMutex      sync.Mutex
+	// Session recording state
+	recordingActive bool
+	recordingName   string
+	recordedSteps   []RecordedStep
+	recordingMutex  sync.Mutex
 }
 
 // NewBrowseTools creates a new set of browser automation tools.
@@ -94,16 +102,19 @@ func NewBrowseTools(ctx context.Context, idleTimeout time.Duration, maxImageDime
 	if idleTimeout <= 0 {
 		idleTimeout = DefaultIdleTimeout
 	}
-	for _, dir := range []string{ScreenshotDir, DownloadDir, ConsoleLogsDir} {
+	for _, dir := range []string{ScreenshotDir, DownloadDir, ConsoleLogsDir, RecordingsDir} {
 		if err := os.MkdirAll(dir, 0o755); err != nil {
 			log.Printf("Failed to create directory %s: %v", dir, err)
 		}
 	}
 
+	mainState := newTabState()
 	bt := &BrowseTools{
 		ctx:               ctx,
 		screenshots:       make(map[string]time.Time),
-		consoleLogs:       make([]*runtime.EventConsoleAPICalled, 0),
+		tabs:              make(map[string]*browserTab),
+		tabState:          mainState,
+		mainState:         mainState,
 		maxConsoleLogs:    100,
 		maxImageDimension: maxImageDimension,
 		idleTimeout:       idleTimeout,
@@ -127,7 +138,7 @@ func (b *BrowseTools) GetBrowserContext() (context.Context, error) {
 			// Fall through to create a new browser
 		} else {
 			b.resetIdleTimerLocked()
-			return b.browserCtx, nil
+			return b.activeContextLocked(), nil
 		}
 	}
 
@@ -152,7 +163,10 @@ func (b *BrowseTools) GetBrowserContext() (context.Context, error) {
 
 	// Set up event listeners for console logs, downloads, network, and tracing.
 	// All listeners are registered once at browser startup and gated by enable flags.
-	chromedp.ListenTarget(browserCtx, b.handleBrowserEvent)
+	mainState := b.mainState
+	chromedp.ListenTarget(browserCtx, func(ev any) {
+		b.handleBrowserEvent(mainState, ev)
+	})
 
 	// Start the browser
 	if err := chromedp.Run(browserCtx); err != nil {
@@ -185,7 +199,7 @@ func (b *BrowseTools) GetBrowserContext() (context.Context, error) {
 
 	b.resetIdleTimerLocked()
 
-	return b.browserCtx, nil
+	return b.activeContextLocked(), nil
 }
 
 // resetIdleTimerLocked resets or starts the idle timer. Caller must hold b.mux.
@@ -216,6 +230,8 @@ func (b *BrowseTools) closeBrowserLocked() {
 		b.idleTimer = nil
 	}
 
+	b.closeTabsLocked()
+
 	if b.browserCtxCancel != nil {
 		b.browserCtxCancel()
 		b.browserCtxCancel = nil
@@ -238,35 +254,40 @@ func (b *BrowseTools) Close() {
 }
 
 // handleBrowserEvent is the unified event handler for all CDP events.
-func (b *BrowseTools) handleBrowserEvent(ev any) {
+// state is the log state of the tab the event came from.
+func (b *BrowseTools) handleBrowserEvent(state *tabState, ev any) {
 	switch e := ev.(type) {
 	case *runtime.EventConsoleAPICalled:
-		b.captureConsoleLog(e)
+		b.captureConsoleLog(state, e)
 	case *browser.EventDownloadWillBegin:
 		b.handleDownloadWillBegin(e)
 	case *browser.EventDownloadProgress:
 		b.handleDownloadProgress(e)
 	case *network.EventRequestWillBeSent:
+		b.trackInflightRequest(state, e.RequestID, true)
 		b.networkMutex.Lock()
-		enabled := b.networkEnabled
+		enabled := state.networkEnabled
 		b.networkMutex.Unlock()
 		if enabled {
-			b.captureNetworkRequest(e)
+			b.captureNetworkRequest(state, e)
 		}
 	case *network.EventResponseReceived:
 		b.networkMutex.Lock()
-		enabled := b.networkEnabled
+		enabled := state.networkEnabled
 		b.networkMutex.Unlock()
 		if enabled {
-			b.captureNetworkResponse(e)
+			b.captureNetworkResponse(state, e)
 		}
 	case *network.EventLoadingFinished:
+		b.trackInflightRequest(state, e.RequestID, false)
 		b.networkMutex.Lock()
-		enabled := b.networkEnabled
+		enabled := state.networkEnabled
 		b.networkMutex.Unlock()
 		if enabled {
-			b.captureNetworkFinished(e)
+			b.captureNetworkFinished(state, e)
 		}
+	case *network.EventLoadingFailed:
+		b.trackInflightRequest(state, e.RequestID, false)
 	case *tracing.EventDataCollected:
 		b.traceMutex.Lock()
 		if b.tracingActive {
@@ -520,7 +541,7 @@ func (b *BrowseTools) screenshotRun(ctx context.Context, m json.RawMessage) llm.
 		"selector": input.Selector,
 	}
 
-	description := fmt.Sprintf("Screenshot taken (saved as %s)", screenshotPath)
+	description := fmt.Sprintf("Screenshot taken (ID %s, saved as %s)", id, screenshotPath)
 	if resized {
 		description += " [resized]"
 	}
@@ -547,6 +568,7 @@ func (b *BrowseTools) GetTools() []*llm.Tool {
 		b.NetworkTool(),
 		b.AccessibilityTool(),
 		b.ProfileTool(),
+		b.RecordingTool(),
 	}
 }
 
@@ -559,9 +581,31 @@ func (b *BrowseTools) CombinedTool() *llm.Tool {
   Parameters: url (string, required), timeout (string, optional)
 
 - action: "eval"
-  Evaluate JavaScript in the browser context. Your go-to for interacting with content: clicking buttons, typing, getting content, scrolling, waiting for content/selector to be ready, etc.
+  Evaluate JavaScript in the browser context. Use for reading content, scrolling, and anything the structured actions below don't cover. Assigning .value directly does not update React-controlled inputs; prefer "type".
   Parameters: expression (string, required), timeout (string, optional), await (boolean, default true)
 
+Element actions target an element by CSS selector, or by accessible name and/or ARIA role (as in browser_accessibility). They wait for the element to appear.
+
+- action: "click"
+  Scroll an element into view and click its center with real mouse events.
+  Parameters: selector | name | role, timeout (string, optional)
+
+- action: "type"
+  Focus an element and type text with real input events (works with React-controlled inputs).
+  Parameters: selector | name | role, text (string, required), clear (boolean, default true), press_enter (boolean, optional), timeout (string, optional)
+
+- action: "select"
+  Choose an option in a <select> by value or visible label.
+  Parameters: selector | name | role, value (string, required), timeout (string, optional)
+
+- action: "wait_for"
+  Wait for an element to reach a state, or for the network to go idle.
+  Parameters: selector | name | role, state ("visible" (default), "attached", "hidden", or "network_idle" (default with no element)), timeout (string, optional)
+
+- action: "upload"
+  Set files on an <input type="file"> element.
+  Parameters: selector | name | role, files (array of paths, required), timeout (string, optional)
+
 - action: "resize"
   Resize the browser viewport to a specific width and height.
   Parameters: width (integer, required), height (integer, required), timeout (string, optional)
@@ -570,6 +614,27 @@ func (b *BrowseTools) CombinedTool() *llm.Tool {
   Take a screenshot of the page or a specific element.
   Parameters: selector (string, optional), timeout (string, optional)
 
+- action: "visual_diff"
+  Compare two screenshots pixel by pixel. Returns the mismatch percentage, the changed region's bounding box, and a diff image with changes in red.
+  Parameters: before (string, required), after (string, required) - each a screenshot ID or an absolute image path (e.g. a baseline in the repo), tolerance (integer 0-255, per-channel difference to ignore, default 0)
+
+Tabs: the browser starts with one tab named "main". Every action (and the browser_emulate, browser_network, browser_accessibility tools) applies to the active tab, and each tab keeps its own console and network logs.
+
+- action: "new_tab"
+  Open a named tab and make it active. Isolated tabs (the default) have their own cookies and storage, e.g. to keep an admin and a logged-out session side by side.
+  Parameters: tab (string, required), url (string, optional), isolated (boolean, default true), timeout (string, optional)
+
+- action: "switch_tab"
+  Make a named tab active.
+  Parameters: tab (string, required)
+
+- action: "list_tabs"
+  List open tabs with their URL and title; the active tab is marked with *.
+
+- action: "close_tab"
+  Close a named tab. The "main" tab can't be closed.
+  Parameters: tab (string, required)
+
 - action: "console_logs"
   Get recent browser console logs.
   Parameters: limit (integer, optional, default 100)
@@ -584,11 +649,11 @@ func (b *BrowseTools) CombinedTool() *llm.Tool {
 			"action": {
 				"type": "string",
 				"description": "The browser action to perform",
-				"enum": ["navigate", "eval", "resize", "screenshot", "console_logs", "clear_console_logs"]
+				"enum": ["navigate", "eval", "resize", "screenshot", "console_logs", "clear_console_logs", "click", "type", "select", "wait_for", "upload", "visual_diff", "new_tab", "switch_tab", "list_tabs", "close_tab"]
 			},
 			"url": {
 				"type": "string",
-				"description": "URL to navigate to (navigate action)"
+				"description": "URL to navigate to (navigate, new_tab actions)"
 			},
 			"expression": {
 				"type": "string",
@@ -612,7 +677,61 @@ func (b *BrowseTools) CombinedTool() *llm.Tool {
 			},
 			"selector": {
 				"type": "string",
-				"description": "CSS selector for element to screenshot (screenshot action)"
+				"description": "CSS selector for the target element (screenshot, click, type, select, wait_for, upload actions)"
+			},
+			"name": {
+				"type": "string",
+				"description": "Accessible name of the target element, instead of selector (click, type, select, wait_for, upload actions)"
+			},
+			"role": {
+				"type": "string",
+				"description": "ARIA role of the target element, instead of selector (click, type, select, wait_for, upload actions)"
+			},
+			"text": {
+				"type": "string",
+				"description": "Text to type (type action)"
+			},
+			"clear": {
+				"type": "boolean",
+				"description": "Clear existing contents before typing (type action, default true)"
+			},
+			"press_enter": {
+				"type": "boolean",
+				"description": "Press Enter after typing (type action)"
+			},
+			"value": {
+				"type": "string",
+				"description": "Option value or label to choose (select action)"
+			},
+			"state": {
+				"type": "string",
+				"description": "State to wait for (wait_for action)",
+				"enum": ["visible", "attached", "hidden", "network_idle"]
+			},
+			"files": {
+				"type": "array",
+				"items": {"type": "string"},
+				"description": "Absolute paths of files to upload (upload action)"
+			},
+			"before": {
+				"type": "string",
+				"description": "Screenshot ID or absolute image path of the baseline (visual_diff action)"
+			},
+			"after": {
+				"type": "string",
+				"description": "Screenshot ID or absolute image path to compare against the baseline (visual_diff action)"
+			},
+			"tolerance": {
+				"type": "integer",
+				"description": "Per-channel color difference (0-255) treated as unchanged (visual_diff action, default 0)"
+			},
+			"tab": {
+				"type": "string",
+				"description": "Tab name (new_tab, switch_tab, close_tab actions)"
+			},
+			"isolated": {
+				"type": "boolean",
+				"description": "Give the new tab its own cookies and storage (new_tab action, default true)"
 			},
 			"timeout": {
 				"type": "string",
@@ -655,15 +774,28 @@ func (b *BrowseTools) ReadImageTool() *llm.Tool {
 
 // combinedInput is the unified input for the combined browser tool.
 type combinedInput struct {
-	Action     string `json:"action"`
-	URL        string `json:"url,omitempty"`
-	Expression string `json:"expression,omitempty"`
-	Await      *bool  `json:"await,omitempty"`
-	Width      int    `json:"width,omitempty"`
-	Height     int    `json:"height,omitempty"`
-	Limit      int    `json:"limit,omitempty"`
-	Selector   string `json:"selector,omitempty"`
-	Timeout    string `json:"timeout,omitempty"`
+	Action     string   `json:"action"`
+	URL        string   `json:"url,omitempty"`
+	Expression string   `json:"expression,omitempty"`
+	Await      *bool    `json:"await,omitempty"`
+	Width      int      `json:"width,omitempty"`
+	Height     int      `json:"height,omitempty"`
+	Limit      int      `json:"limit,omitempty"`
+	Selector   string   `json:"selector,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	Role       string   `json:"role,omitempty"`
+	Text       string   `json:"text,omitempty"`
+	Clear      *bool    `json:"clear,omitempty"`
+	PressEnter bool     `json:"press_enter,omitempty"`
+	Value      string   `json:"value,omitempty"`
+	State      string   `json:"state,omitempty"`
+	Files      []string `json:"files,omitempty"`
+	Before     string   `json:"before,omitempty"`
+	After      string   `json:"after,omitempty"`
+	Tolerance  int      `json:"tolerance,omitempty"`
+	Tab        string   `json:"tab,omitempty"`
+	Isolated   *bool    `json:"isolated,omitempty"`
+	Timeout    string   `json:"timeout,omitempty"`
 }
 
 func (b *BrowseTools) combinedRun() func(context.Context, json.RawMessage) llm.ToolOut {
@@ -673,22 +805,49 @@ func (b *BrowseTools) combinedRun() func(context.Context, json.RawMessage) llm.T
 			return llm.ErrorfToolOut("invalid input: %w", err)
 		}
 
-		switch input.Action {
-		case "navigate":
-			return b.navigateRun(ctx, m)
-		case "eval":
-			return b.evalRun(ctx, m)
-		case "resize":
-			return b.resizeRun(ctx, m)
-		case "screenshot":
-			return b.screenshotRun(ctx, m)
-		case "console_logs":
-			return b.recentConsoleLogsRun(ctx, m)
-		case "clear_console_logs":
-			return b.clearConsoleLogsRun(ctx, m)
-		default:
-			return llm.ErrorfToolOut("unknown action: %q", input.Action)
-		}
+		out := b.runCombinedAction(ctx, input.Action, m)
+		b.recordStep(input.Action, m, out)
+		return out
+	}
+}
+
+// runCombinedAction dispatches a combined browser tool action to its handler.
+func (b *BrowseTools) runCombinedAction(ctx context.Context, action string, m json.RawMessage) llm.ToolOut {
+	switch action {
+	case "navigate":
+		return b.navigateRun(ctx, m)
+	case "eval":
+		return b.evalRun(ctx, m)
+	case "resize":
+		return b.resizeRun(ctx, m)
+	case "screenshot":
+		return b.screenshotRun(ctx, m)
+	case "console_logs":
+		return b.recentConsoleLogsRun(ctx, m)
+	case "clear_console_logs":
+		return b.clearConsoleLogsRun(ctx, m)
+	case "click":
+		return b.clickRun(ctx, m)
+	case "type":
+		return b.typeRun(ctx, m)
+	case "select":
+		return b.selectRun(ctx, m)
+	case "wait_for":
+		return b.waitForRun(ctx, m)
+	case "upload":
+		return b.uploadRun(ctx, m)
+	case "visual_diff":
+		return b.visualDiffRun(ctx, m)
+	case "new_tab":
+		return b.newTabRun(ctx, m)
+	case "switch_tab":
+		return b.switchTabRun(ctx, m)
+	case "list_tabs":
+		return b.listTabsRun(ctx, m)
+	case "close_tab":
+		return b.closeTabRun(ctx, m)
+	default:
+		return llm.ErrorfToolOut("unknown action: %q", action)
 	}
 }
 
@@ -799,16 +958,16 @@ func parseTimeout(timeout string) time.Duration {
 	return dur
 }
 
-// captureConsoleLog captures a console log event and stores it
-func (b *BrowseTools) captureConsoleLog(e *runtime.EventConsoleAPICalled) {
+// captureConsoleLog captures a console log event and stores it in the tab's buffer
+func (b *BrowseTools) captureConsoleLog(state *tabState, e *runtime.EventConsoleAPICalled) {
 	// Add to logs with mutex protection
 	b.consoleLogsMutex.Lock()
 	defer b.consoleLogsMutex.Unlock()
 
 	// Add the log and maintain max size
-	b.consoleLogs = append(b.consoleLogs, e)
-	if len(b.consoleLogs) > b.maxConsoleLogs {
-		b.consoleLogs = b.consoleLogs[len(b.consoleLogs)-b.maxConsoleLogs:]
+	state.consoleLogs = append(state.consoleLogs, e)
+	if len(state.consoleLogs) > b.maxConsoleLogs {
+		state.consoleLogs = state.consoleLogs[len(state.consoleLogs)-b.maxConsoleLogs:]
 	}
 }
 
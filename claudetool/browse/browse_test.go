@@ -50,7 +50,7 @@ func TestCombinedTool(t *testing.T) {
 	}
 
 	// Verify all actions are listed in the enum
-	expectedActions := []string{"navigate", "eval", "resize", "console_logs", "clear_console_logs", "screenshot"}
+	expectedActions := []string{"navigate", "eval", "resize", "console_logs", "clear_console_logs", "screenshot", "click", "type", "select", "wait_for", "upload", "visual_diff"}
 	for _, action := range expectedActions {
 		if !slices.Contains(schema.Properties["action"].Enum, action) {
 			t.Errorf("action %q not in enum", action)
@@ -85,10 +85,10 @@ func TestGetTools(t *testing.T) {
 	})
 
 	result := tools.GetTools()
-	if len(result) != 6 {
-		t.Fatalf("GetTools: expected 6 tools, got %d", len(result))
+	if len(result) != 7 {
+		t.Fatalf("GetTools: expected 7 tools, got %d", len(result))
 	}
-	expectedNames := []string{"browser", "read_image", "browser_emulate", "browser_network", "browser_accessibility", "browser_profile"}
+	expectedNames := []string{"browser", "read_image", "browser_emulate", "browser_network", "browser_accessibility", "browser_profile", "browser_recording"}
 	for i, name := range expectedNames {
 		if result[i].Name != name {
 			t.Errorf("expected tool %d name %q, got %q", i, name, result[i].Name)
@@ -596,10 +596,10 @@ func TestRegisterBrowserTools(t *testing.T) {
 	tools, cleanup := RegisterBrowserTools(ctx, 0)
 	t.Cleanup(cleanup)
 
-	if len(tools) != 6 {
-		t.Fatalf("RegisterBrowserTools: expected 6 tools, got %d", len(tools))
+	if len(tools) != 7 {
+		t.Fatalf("RegisterBrowserTools: expected 7 tools, got %d", len(tools))
 	}
-	expectedNames := []string{"browser", "read_image", "browser_emulate", "browser_network", "browser_accessibility", "browser_profile"}
+	expectedNames := []string{"browser", "read_image", "browser_emulate", "browser_network", "browser_accessibility", "browser_profile", "browser_recording"}
 	for i, name := range expectedNames {
 		if tools[i].Name != name {
 			t.Errorf("expected tool %d name %q, got %q", i, name, tools[i].Name)
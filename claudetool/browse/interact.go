@@ -0,0 +1,507 @@
+package browse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"shelley.exe.dev/llm"
+)
+
+// networkIdleWindow is how long there must be no in-flight requests before
+// the page is considered network idle.
+const networkIdleWindow = 500 * time.Millisecond
+
+// elementTarget identifies an element either by CSS selector or by
+// accessible name and/or role.
+type elementTarget struct {
+	Selector string `json:"selector,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Role     string `json:"role,omitempty"`
+}
+
+func (t elementTarget) empty() bool {
+	return t.Selector == "" && t.Name == "" && t.Role == ""
+}
+
+// String describes the target for tool output and error messages.
+func (t elementTarget) String() string {
+	if t.Selector != "" {
+		return fmt.Sprintf("%q", t.Selector)
+	}
+	s := "[" + t.Role + "]"
+	if t.Role == "" {
+		s = "[*]"
+	}
+	if t.Name != "" {
+		s += fmt.Sprintf(" %q", t.Name)
+	}
+	return s
+}
+
+// resolveElement waits for the target element to exist and returns its
+// backend node ID. It must run inside chromedp.Run.
+func resolveElement(ctx context.Context, t elementTarget) (cdp.BackendNodeID, error) {
+	if t.Selector != "" {
+		var nodes []*cdp.Node
+		if err := chromedp.Nodes(t.Selector, &nodes, chromedp.ByQuery).Do(ctx); err != nil {
+			return 0, fmt.Errorf("failed to find element %s: %w", t, err)
+		}
+		if len(nodes) == 0 {
+			return 0, fmt.Errorf("no element found for %s", t)
+		}
+		return nodes[0].BackendNodeID, nil
+	}
+
+	// The accessibility tree has no built-in wait, so poll until a match shows up.
+	for {
+		nodes, err := queryAXNodes(ctx, t.Name, t.Role)
+		if err != nil {
+			return 0, fmt.Errorf("failed to query accessibility tree: %w", err)
+		}
+		for _, n := range nodes {
+			if !n.Ignored && n.BackendDOMNodeID != 0 {
+				return n.BackendDOMNodeID, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("no element found for %s: %w", t, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// callOnNode calls a JavaScript function with the element bound to this and
+// returns its result by value.
+func callOnNode(ctx context.Context, id cdp.BackendNodeID, fn string, args ...any) (json.RawMessage, error) {
+	obj, err := dom.ResolveNode().WithBackendNodeID(id).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve node: %w", err)
+	}
+	defer runtime.ReleaseObject(obj.ObjectID).Do(ctx) //nolint:errcheck
+
+	callArgs := make([]*runtime.CallArgument, 0, len(args))
+	for _, a := range args {
+		v, err := json.Marshal(a)
+		if err != nil {
+			return nil, err
+		}
+		callArgs = append(callArgs, &runtime.CallArgument{Value: v})
+	}
+
+	res, exc, err := runtime.CallFunctionOn(fn).
+		WithObjectID(obj.ObjectID).
+		WithArguments(callArgs).
+		WithReturnByValue(true).
+		WithAwaitPromise(true).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exc != nil {
+		return nil, exc
+	}
+	return json.RawMessage(res.Value), nil
+}
+
+// clickablePoint returns the center of the element's first content quad.
+// It fails if the element is not rendered.
+func clickablePoint(ctx context.Context, id cdp.BackendNodeID) (x, y float64, err error) {
+	quads, err := dom.GetContentQuads().WithBackendNodeID(id).Do(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get element position: %w", err)
+	}
+	if len(quads) == 0 || len(quads[0]) < 8 {
+		return 0, 0, fmt.Errorf("element is not visible")
+	}
+	for i := 0; i < 8; i += 2 {
+		x += quads[0][i]
+		y += quads[0][i+1]
+	}
+	return x / 4, y / 4, nil
+}
+
+// clickNode scrolls the element into view and dispatches a real left click
+// at its center.
+func clickNode(ctx context.Context, id cdp.BackendNodeID) error {
+	if err := dom.ScrollIntoViewIfNeeded().WithBackendNodeID(id).Do(ctx); err != nil {
+		return fmt.Errorf("failed to scroll element into view: %w", err)
+	}
+	x, y, err := clickablePoint(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := input.DispatchMouseEvent(input.MouseMoved, x, y).Do(ctx); err != nil {
+		return err
+	}
+	if err := input.DispatchMouseEvent(input.MousePressed, x, y).
+		WithButton(input.Left).WithButtons(1).WithClickCount(1).Do(ctx); err != nil {
+		return err
+	}
+	return input.DispatchMouseEvent(input.MouseReleased, x, y).
+		WithButton(input.Left).WithClickCount(1).Do(ctx)
+}
+
+// pressKey dispatches a keyDown/keyUp pair for a named key.
+func pressKey(ctx context.Context, key, code string, keyCode int64, text string) error {
+	down := input.DispatchKeyEvent(input.KeyDown).
+		WithKey(key).WithCode(code).WithWindowsVirtualKeyCode(keyCode)
+	if text != "" {
+		down = down.WithText(text)
+	}
+	if err := down.Do(ctx); err != nil {
+		return err
+	}
+	return input.DispatchKeyEvent(input.KeyUp).
+		WithKey(key).WithCode(code).WithWindowsVirtualKeyCode(keyCode).Do(ctx)
+}
+
+type clickInput struct {
+	elementTarget
+	Timeout string `json:"timeout,omitempty"`
+}
+
+func (b *BrowseTools) clickRun(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var input clickInput
+	if err := json.Unmarshal(m, &input); err != nil {
+		return llm.ErrorfToolOut("invalid input: %w", err)
+	}
+	if input.empty() {
+		return llm.ErrorfToolOut("one of 'selector', 'name', or 'role' is required for the click action")
+	}
+
+	browserCtx, err := b.GetBrowserContext()
+	if err != nil {
+		return llm.ErrorToolOut(err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(browserCtx, parseTimeout(input.Timeout))
+	defer cancel()
+
+	err = chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := resolveElement(ctx, input.elementTarget)
+		if err != nil {
+			return err
+		}
+		return clickNode(ctx, id)
+	}))
+	if err != nil {
+		return llm.ErrorfToolOut("click %s: %w", input.elementTarget, err)
+	}
+
+	return b.toolOutWithDownloads(fmt.Sprintf("clicked %s", input.elementTarget))
+}
+
+type typeInput struct {
+	elementTarget
+	Text       string `json:"text"`
+	Clear      *bool  `json:"clear,omitempty"`
+	PressEnter bool   `json:"press_enter,omitempty"`
+	Timeout    string `json:"timeout,omitempty"`
+}
+
+// selectContentsJS selects the existing contents of an input, textarea, or
+// contenteditable element so the next key event replaces it.
+const selectContentsJS = `function() {
+	if (typeof this.select === "function") {
+		this.select();
+	} else {
+		const range = document.createRange();
+		range.selectNodeContents(this);
+		const sel = window.getSelection();
+		sel.removeAllRanges();
+		sel.addRange(range);
+	}
+	return true;
+}`
+
+func (b *BrowseTools) typeRun(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var in typeInput
+	if err := json.Unmarshal(m, &in); err != nil {
+		return llm.ErrorfToolOut("invalid input: %w", err)
+	}
+	if in.empty() {
+		return llm.ErrorfToolOut("one of 'selector', 'name', or 'role' is required for the type action")
+	}
+	clear := true
+	if in.Clear != nil {
+		clear = *in.Clear
+	}
+
+	browserCtx, err := b.GetBrowserContext()
+	if err != nil {
+		return llm.ErrorToolOut(err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(browserCtx, parseTimeout(in.Timeout))
+	defer cancel()
+
+	// Text goes in through Input.insertText and real key events rather than by
+	// assigning .value, so framework-controlled inputs (e.g. React) see the change.
+	err = chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := resolveElement(ctx, in.elementTarget)
+		if err != nil {
+			return err
+		}
+		if err := dom.ScrollIntoViewIfNeeded().WithBackendNodeID(id).Do(ctx); err != nil {
+			return fmt.Errorf("failed to scroll element into view: %w", err)
+		}
+		if err := dom.Focus().WithBackendNodeID(id).Do(ctx); err != nil {
+			return fmt.Errorf("failed to focus element: %w", err)
+		}
+		if clear {
+			if _, err := callOnNode(ctx, id, selectContentsJS); err != nil {
+				return fmt.Errorf("failed to select existing text: %w", err)
+			}
+			if err := pressKey(ctx, "Backspace", "Backspace", 8, ""); err != nil {
+				return err
+			}
+		}
+		if in.Text != "" {
+			if err := input.InsertText(in.Text).Do(ctx); err != nil {
+				return err
+			}
+		}
+		if in.PressEnter {
+			return pressKey(ctx, "Enter", "Enter", 13, "\r")
+		}
+		return nil
+	}))
+	if err != nil {
+		return llm.ErrorfToolOut("type into %s: %w", in.elementTarget, err)
+	}
+
+	return b.toolOutWithDownloads(fmt.Sprintf("typed %d characters into %s", len([]rune(in.Text)), in.elementTarget))
+}
+
+type selectInput struct {
+	elementTarget
+	Value   string `json:"value"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// selectOptionJS picks the option whose value or visible label matches and
+// fires the input and change events a user selection would.
+const selectOptionJS = `function(want) {
+	if (!(this instanceof HTMLSelectElement)) {
+		throw new Error("element is not a <select>");
+	}
+	const opt = Array.from(this.options).find(o => o.value === want) ||
+		Array.from(this.options).find(o => o.label.trim() === want || o.text.trim() === want);
+	if (!opt) {
+		throw new Error("no option with value or label " + JSON.stringify(want));
+	}
+	this.value = opt.value;
+	this.dispatchEvent(new Event("input", { bubbles: true }));
+	this.dispatchEvent(new Event("change", { bubbles: true }));
+	return opt.value;
+}`
+
+func (b *BrowseTools) selectRun(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var input selectInput
+	if err := json.Unmarshal(m, &input); err != nil {
+		return llm.ErrorfToolOut("invalid input: %w", err)
+	}
+	if input.empty() {
+		return llm.ErrorfToolOut("one of 'selector', 'name', or 'role' is required for the select action")
+	}
+
+	browserCtx, err := b.GetBrowserContext()
+	if err != nil {
+		return llm.ErrorToolOut(err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(browserCtx, parseTimeout(input.Timeout))
+	defer cancel()
+
+	var selected string
+	err = chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := resolveElement(ctx, input.elementTarget)
+		if err != nil {
+			return err
+		}
+		res, err := callOnNode(ctx, id, selectOptionJS, input.Value)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(res, &selected)
+	}))
+	if err != nil {
+		return llm.ErrorfToolOut("select in %s: %w", input.elementTarget, err)
+	}
+
+	return b.toolOutWithDownloads(fmt.Sprintf("selected %q in %s", selected, input.elementTarget))
+}
+
+type waitForInput struct {
+	elementTarget
+	State   string `json:"state,omitempty"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+func (b *BrowseTools) waitForRun(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var input waitForInput
+	if err := json.Unmarshal(m, &input); err != nil {
+		return llm.ErrorfToolOut("invalid input: %w", err)
+	}
+
+	state := input.State
+	if state == "" {
+		state = "visible"
+		if input.empty() {
+			state = "network_idle"
+		}
+	}
+	switch state {
+	case "network_idle":
+	case "visible", "attached", "hidden":
+		if input.empty() {
+			return llm.ErrorfToolOut("one of 'selector', 'name', or 'role' is required to wait for state %q", state)
+		}
+		if state == "hidden" && input.Selector == "" {
+			return llm.ErrorfToolOut("state \"hidden\" requires 'selector'")
+		}
+	default:
+		return llm.ErrorfToolOut("unknown state: %q (use visible, attached, hidden, or network_idle)", state)
+	}
+
+	browserCtx, err := b.GetBrowserContext()
+	if err != nil {
+		return llm.ErrorToolOut(err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(browserCtx, parseTimeout(input.Timeout))
+	defer cancel()
+
+	start := time.Now()
+	switch state {
+	case "network_idle":
+		err = b.waitNetworkIdle(timeoutCtx)
+	case "hidden":
+		err = chromedp.Run(timeoutCtx, chromedp.WaitNotVisible(input.Selector, chromedp.ByQuery))
+	case "visible":
+		if input.Selector != "" {
+			err = chromedp.Run(timeoutCtx, chromedp.WaitVisible(input.Selector, chromedp.ByQuery))
+			break
+		}
+		fallthrough
+	default: // attached, or visible by name/role
+		err = chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := resolveElement(ctx, input.elementTarget)
+			return err
+		}))
+	}
+	if err != nil {
+		if state == "network_idle" {
+			return llm.ErrorfToolOut("wait for network idle: %w", err)
+		}
+		return llm.ErrorfToolOut("wait for %s to be %s: %w", input.elementTarget, state, err)
+	}
+
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if state == "network_idle" {
+		return b.toolOutWithDownloads(fmt.Sprintf("network idle after %s", elapsed))
+	}
+	return b.toolOutWithDownloads(fmt.Sprintf("%s is %s after %s", input.elementTarget, state, elapsed))
+}
+
+// trackInflightRequest records the start or end of a network request for
+// network idle detection. It runs for every request, independent of the
+// browser_network tool's capture toggle.
+func (b *BrowseTools) trackInflightRequest(state *tabState, id network.RequestID, started bool) {
+	b.networkMutex.Lock()
+	defer b.networkMutex.Unlock()
+	if started {
+		state.inflightRequests[id] = struct{}{}
+	} else {
+		delete(state.inflightRequests, id)
+	}
+	state.lastNetworkActivity = time.Now()
+}
+
+// waitNetworkIdle blocks until no requests have been in flight for networkIdleWindow.
+func (b *BrowseTools) waitNetworkIdle(ctx context.Context) error {
+	for {
+		b.networkMutex.Lock()
+		inflight := len(b.inflightRequests)
+		quiet := time.Since(b.lastNetworkActivity)
+		b.networkMutex.Unlock()
+
+		if inflight == 0 && quiet >= networkIdleWindow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d request(s) still in flight: %w", inflight, ctx.Err())
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+type uploadInput struct {
+	elementTarget
+	Files   []string `json:"files"`
+	Timeout string   `json:"timeout,omitempty"`
+}
+
+func (b *BrowseTools) uploadRun(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var input uploadInput
+	if err := json.Unmarshal(m, &input); err != nil {
+		return llm.ErrorfToolOut("invalid input: %w", err)
+	}
+	if input.empty() {
+		return llm.ErrorfToolOut("one of 'selector', 'name', or 'role' is required for the upload action")
+	}
+	if len(input.Files) == 0 {
+		return llm.ErrorfToolOut("'files' is required for the upload action")
+	}
+
+	files := make([]string, 0, len(input.Files))
+	for _, f := range input.Files {
+		// Relative paths would resolve against the server's directory,
+		// not the conversation's.
+		if !filepath.IsAbs(f) {
+			return llm.ErrorfToolOut("file path must be absolute: %s", f)
+		}
+		info, err := os.Stat(f)
+		if err != nil {
+			return llm.ErrorfToolOut("file not found: %s", f)
+		}
+		if info.IsDir() {
+			return llm.ErrorfToolOut("%s is a directory", f)
+		}
+		files = append(files, filepath.Clean(f))
+	}
+
+	browserCtx, err := b.GetBrowserContext()
+	if err != nil {
+		return llm.ErrorToolOut(err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(browserCtx, parseTimeout(input.Timeout))
+	defer cancel()
+
+	err = chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := resolveElement(ctx, input.elementTarget)
+		if err != nil {
+			return err
+		}
+		return dom.SetFileInputFiles(files).WithBackendNodeID(id).Do(ctx)
+	}))
+	if err != nil {
+		return llm.ErrorfToolOut("upload to %s: %w", input.elementTarget, err)
+	}
+
+	return b.toolOutWithDownloads(fmt.Sprintf("set %d file(s) on %s", len(files), input.elementTarget))
+}
@@ -0,0 +1,188 @@
+package browse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestElementTargetString(t *testing.T) {
+	tests := []struct {
+		target elementTarget
+		want   string
+	}{
+		{elementTarget{Selector: "#go"}, `"#go"`},
+		{elementTarget{Role: "button", Name: "Go"}, `[button] "Go"`},
+		{elementTarget{Name: "Go"}, `[*] "Go"`},
+		{elementTarget{Role: "textbox"}, `[textbox]`},
+	}
+	for _, tt := range tests {
+		if got := tt.target.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestInteractionRunErrorPaths(t *testing.T) {
+	ctx := context.Background()
+	tools := NewBrowseTools(ctx, 0, 0)
+	t.Cleanup(func() {
+		tools.Close()
+	})
+
+	tool := tools.CombinedTool()
+
+	// These are all rejected before a browser is started.
+	inputs := []string{
+		`{"action": "click"}`,
+		`{"action": "type", "text": "hi"}`,
+		`{"action": "select", "value": "a"}`,
+		`{"action": "wait_for", "state": "visible"}`,
+		`{"action": "wait_for", "state": "hidden", "role": "dialog"}`,
+		`{"action": "wait_for", "state": "bogus", "selector": "#x"}`,
+		`{"action": "upload", "selector": "#f"}`,
+		`{"action": "upload", "selector": "#f", "files": ["/nonexistent/file.txt"]}`,
+		`{"action": "upload", "selector": "#f", "files": ["interact_test.go"]}`,
+		`{"action": "click", "selector": 123}`,
+	}
+	for _, in := range inputs {
+		if toolOut := tool.Run(ctx, []byte(in)); toolOut.Error == nil {
+			t.Errorf("expected error for %s", in)
+		}
+	}
+}
+
+func TestWaitNetworkIdle(t *testing.T) {
+	tools := NewBrowseTools(context.Background(), 0, 0)
+
+	// Nothing has happened yet, so the page is already idle.
+	if err := tools.waitNetworkIdle(context.Background()); err != nil {
+		t.Fatalf("expected idle, got %v", err)
+	}
+
+	tools.trackInflightRequest(tools.tabState, "1", true)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := tools.waitNetworkIdle(ctx); err == nil {
+		t.Fatal("expected timeout with a request in flight")
+	}
+
+	tools.trackInflightRequest(tools.tabState, "1", false)
+	start := time.Now()
+	if err := tools.waitNetworkIdle(context.Background()); err != nil {
+		t.Fatalf("expected idle, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < networkIdleWindow/2 {
+		t.Errorf("returned after %v, expected to wait for the idle window", elapsed)
+	}
+}
+
+const interactTestPage = `<!DOCTYPE html>
+<html>
+<body>
+<label for="msg">Message</label>
+<textarea id="msg">old text</textarea>
+<select id="color" aria-label="Color">
+  <option value="r">Red</option>
+  <option value="g">Green</option>
+</select>
+<input type="file" id="file" aria-label="Attachment">
+<button id="go" onclick="setTimeout(() => { const d = document.createElement('div'); d.id = 'done'; d.textContent = 'ok'; document.body.appendChild(d); }, 200)">Go</button>
+<form onsubmit="event.preventDefault(); document.title = 'submitted:' + this.q.value;"><input name="q" id="q"></form>
+<script>
+  window.inputEvents = 0;
+  document.getElementById('msg').addEventListener('input', () => window.inputEvents++);
+</script>
+</body>
+</html>`
+
+func TestInteractionActions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping browser interaction test in short mode")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(interactTestPage))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	tools := NewBrowseTools(ctx, 0, 0)
+	t.Cleanup(func() {
+		tools.Close()
+	})
+	tool := tools.CombinedTool()
+
+	toolOut := tool.Run(ctx, []byte(fmt.Sprintf(`{"action": "navigate", "url": %q}`, server.URL)))
+	if toolOut.Error != nil {
+		if strings.Contains(toolOut.Error.Error(), "failed to start browser") {
+			t.Skip("Browser automation not available in this environment")
+		}
+		t.Fatalf("Navigation error: %v", toolOut.Error)
+	}
+
+	run := func(in string) string {
+		t.Helper()
+		toolOut := tool.Run(ctx, []byte(in))
+		if toolOut.Error != nil {
+			t.Fatalf("%s: %v", in, toolOut.Error)
+		}
+		return toolOut.LLMContent[0].Text
+	}
+	eval := func(expr string) string {
+		t.Helper()
+		return run(fmt.Sprintf(`{"action": "eval", "expression": %q}`, expr))
+	}
+
+	run(`{"action": "type", "role": "textbox", "name": "Message", "text": "hello"}`)
+	if got := eval(`document.getElementById('msg').value`); !strings.Contains(got, `"hello"`) {
+		t.Errorf("textarea value = %s, want hello", got)
+	}
+	if got := eval(`window.inputEvents > 0`); !strings.Contains(got, "true") {
+		t.Errorf("expected input events to fire, got %s", got)
+	}
+
+	run(`{"action": "type", "selector": "#msg", "text": " world", "clear": false}`)
+	if got := eval(`document.getElementById('msg').value`); !strings.Contains(got, `"hello world"`) {
+		t.Errorf("textarea value = %s, want hello world", got)
+	}
+
+	run(`{"action": "type", "selector": "#q", "text": "query", "press_enter": true}`)
+	run(`{"action": "wait_for", "state": "network_idle"}`)
+	if got := eval(`document.title`); !strings.Contains(got, "submitted:query") {
+		t.Errorf("title = %s, want form submitted", got)
+	}
+
+	run(`{"action": "select", "selector": "#color", "value": "Green"}`)
+	if got := eval(`document.getElementById('color').value`); !strings.Contains(got, `"g"`) {
+		t.Errorf("select value = %s, want g", got)
+	}
+
+	run(`{"action": "click", "role": "button", "name": "Go"}`)
+	if got := run(`{"action": "wait_for", "selector": "#done"}`); !strings.Contains(got, "visible") {
+		t.Errorf("unexpected wait_for output: %s", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "upload.txt")
+	if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run(fmt.Sprintf(`{"action": "upload", "selector": "#file", "files": [%q]}`, path))
+	if got := eval(`document.getElementById('file').files[0].name`); !strings.Contains(got, "upload.txt") {
+		t.Errorf("uploaded file = %s, want upload.txt", got)
+	}
+
+	toolOut = tool.Run(ctx, []byte(`{"action": "click", "selector": "#missing", "timeout": "500ms"}`))
+	if toolOut.Error == nil {
+		t.Error("expected error clicking a missing element")
+	}
+}
@@ -35,7 +35,7 @@ func timeToSeconds(t time.Time) float64 {
 }
 
 // captureNetworkRequest handles a RequestWillBeSent event by creating a new NetworkRequest entry.
-func (b *BrowseTools) captureNetworkRequest(e *network.EventRequestWillBeSent) {
+func (b *BrowseTools) captureNetworkRequest(state *tabState, e *network.EventRequestWillBeSent) {
 	b.networkMutex.Lock()
 	defer b.networkMutex.Unlock()
 
@@ -46,29 +46,29 @@ func (b *BrowseTools) captureNetworkRequest(e *network.EventRequestWillBeSent) {
 		Type:      e.Type.String(),
 		StartTime: timeToSeconds(e.Timestamp.Time()),
 	}
-	b.networkRequests = append(b.networkRequests, req)
+	state.networkRequests = append(state.networkRequests, req)
 
 	maxReqs := b.maxNetworkRequests
 	if maxReqs <= 0 {
 		maxReqs = 200
 	}
-	if len(b.networkRequests) > maxReqs {
-		b.networkRequests = b.networkRequests[len(b.networkRequests)-maxReqs:]
+	if len(state.networkRequests) > maxReqs {
+		state.networkRequests = state.networkRequests[len(state.networkRequests)-maxReqs:]
 	}
 }
 
 // captureNetworkResponse handles a ResponseReceived event by updating the matching request
 // with status code, status text, MIME type, and resource type.
-func (b *BrowseTools) captureNetworkResponse(e *network.EventResponseReceived) {
+func (b *BrowseTools) captureNetworkResponse(state *tabState, e *network.EventResponseReceived) {
 	b.networkMutex.Lock()
 	defer b.networkMutex.Unlock()
 
-	for i := len(b.networkRequests) - 1; i >= 0; i-- {
-		if b.networkRequests[i].RequestID == string(e.RequestID) {
-			b.networkRequests[i].Status = e.Response.Status
-			b.networkRequests[i].StatusText = e.Response.StatusText
-			b.networkRequests[i].MimeType = e.Response.MimeType
-			b.networkRequests[i].Type = e.Type.String()
+	for i := len(state.networkRequests) - 1; i >= 0; i-- {
+		if state.networkRequests[i].RequestID == string(e.RequestID) {
+			state.networkRequests[i].Status = e.Response.Status
+			state.networkRequests[i].StatusText = e.Response.StatusText
+			state.networkRequests[i].MimeType = e.Response.MimeType
+			state.networkRequests[i].Type = e.Type.String()
 			break
 		}
 	}
@@ -76,14 +76,14 @@ func (b *BrowseTools) captureNetworkResponse(e *network.EventResponseReceived) {
 
 // captureNetworkFinished handles a LoadingFinished event by updating the matching request
 // with encoded data length and end timestamp.
-func (b *BrowseTools) captureNetworkFinished(e *network.EventLoadingFinished) {
+func (b *BrowseTools) captureNetworkFinished(state *tabState, e *network.EventLoadingFinished) {
 	b.networkMutex.Lock()
 	defer b.networkMutex.Unlock()
 
-	for i := len(b.networkRequests) - 1; i >= 0; i-- {
-		if b.networkRequests[i].RequestID == string(e.RequestID) {
-			b.networkRequests[i].Size = e.EncodedDataLength
-			b.networkRequests[i].EndTime = timeToSeconds(e.Timestamp.Time())
+	for i := len(state.networkRequests) - 1; i >= 0; i-- {
+		if state.networkRequests[i].RequestID == string(e.RequestID) {
+			state.networkRequests[i].Size = e.EncodedDataLength
+			state.networkRequests[i].EndTime = timeToSeconds(e.Timestamp.Time())
 			break
 		}
 	}
@@ -0,0 +1,647 @@
+package browse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/google/uuid"
+	"shelley.exe.dev/llm"
+)
+
+// RecordingsDir is the directory where recorded session logs and exported tests are stored
+const RecordingsDir = "/tmp/shelley-recordings"
+
+// RecordedStep is a single browser action captured while a recording is active.
+type RecordedStep struct {
+	Index      int             `json:"index"`
+	Time       time.Time       `json:"time"`
+	Action     string          `json:"action"`
+	Input      json.RawMessage `json:"input"`
+	Result     string          `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Screenshot string          `json:"screenshot,omitempty"`
+}
+
+// recordedActions are the browser tool actions captured in a recording.
+// Console log actions are left out because they don't affect the page.
+var recordedActions = map[string]bool{
+	"navigate":   true,
+	"eval":       true,
+	"resize":     true,
+	"screenshot": true,
+	"click":      true,
+	"type":       true,
+	"select":     true,
+	"wait_for":   true,
+	"upload":     true,
+	"assert":     true,
+	"new_tab":    true,
+	"switch_tab": true,
+	"close_tab":  true,
+}
+
+// screenshotAfter are the recorded actions that change the page, so a
+// screenshot is taken after them for the timeline.
+var screenshotAfter = map[string]bool{
+	"navigate": true,
+	"eval":     true,
+	"resize":   true,
+	"click":    true,
+	"type":     true,
+	"select":   true,
+	"upload":   true,
+}
+
+// recordingInput is the input for the browser_recording tool.
+// The embedded target's name field doubles as the recording name for start.
+type recordingInput struct {
+	Action string `json:"action"`
+	Path   string `json:"path,omitempty"`
+	elementTarget
+	Text    string `json:"text,omitempty"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// RecordingTool returns the browser_recording tool for recording browser sessions
+// and exporting them as Playwright tests.
+func (b *BrowseTools) RecordingTool() *llm.Tool {
+	description := `Record browser tool actions as a replayable session. Actions: help, start, stop, status, assert, export.`
+
+	schema := `{
+		"type": "object",
+		"properties": {
+			"action": {
+				"type": "string",
+				"description": "The recording action to perform",
+				"enum": ["help", "start", "stop", "status", "assert", "export"]
+			},
+			"name": {
+				"type": "string",
+				"description": "Recording name used as the test title (start action), or accessible name of the element to check (assert action)"
+			},
+			"path": {
+				"type": "string",
+				"description": "Absolute path to write the Playwright test to (export action, optional)"
+			},
+			"selector": {
+				"type": "string",
+				"description": "CSS selector of the element to check (assert action)"
+			},
+			"role": {
+				"type": "string",
+				"description": "ARIA role of the element to check, instead of selector (assert action)"
+			},
+			"text": {
+				"type": "string",
+				"description": "Text the element must contain; omit to assert visibility (assert action)"
+			},
+			"timeout": {
+				"type": "string",
+				"description": "Timeout as a Go duration string (assert action, default: 15s)"
+			}
+		},
+		"required": ["action"]
+	}`
+
+	return &llm.Tool{
+		Name:        "browser_recording",
+		Description: description,
+		InputSchema: json.RawMessage(schema),
+		Run:         b.recordingRun,
+	}
+}
+
+func (b *BrowseTools) recordingRun(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var input recordingInput
+	if err := json.Unmarshal(m, &input); err != nil {
+		return llm.ErrorfToolOut("invalid input: %w", err)
+	}
+
+	switch input.Action {
+	case "help":
+		return b.recordingHelp()
+	case "start":
+		return b.recordingStart(input.Name)
+	case "stop":
+		return b.recordingStop()
+	case "status":
+		return b.recordingStatus()
+	case "assert":
+		return b.recordingAssert(ctx, m)
+	case "export":
+		return b.recordingExport(input.Path)
+	default:
+		return llm.ErrorfToolOut("unknown action: %q — use \"help\" to see available actions", input.Action)
+	}
+}
+
+func (b *BrowseTools) recordingHelp() llm.ToolOut {
+	help := `browser_recording — Record browser sessions and export them as Playwright tests.
+
+Actions:
+  help    — Show this help message.
+
+  start   — Start recording. Every following "browser" tool action
+            (navigate, eval, resize, screenshot, click, type, select,
+            wait_for, upload) is logged with a screenshot of the result.
+            Parameters:
+              name (string, optional) — title for the exported test.
+
+  stop    — Stop recording, save the session log as JSON and show the
+            timeline.
+
+  status  — Show whether a recording is active and how many steps it has.
+
+  assert  — Check an element and record the check as a test assertion.
+            Parameters:
+              selector (string), or role and/or name — the element to check.
+              text (string, optional) — text the element must contain.
+                If omitted, the element must be visible.
+
+  export  — Write the current or most recent recording as a Playwright
+            test. Failed steps are left out as comments.
+            Parameters:
+              path (string, optional) — absolute path for the .spec.ts
+                file. Defaults to a file in ` + RecordingsDir + `.
+
+Typical workflow:
+  start → navigate/click/type → assert → stop → export path=<repo>/e2e/flow.spec.ts`
+
+	return llm.ToolOut{LLMContent: llm.TextContent(help)}
+}
+
+func (b *BrowseTools) recordingStart(name string) llm.ToolOut {
+	if name == "" {
+		name = "recorded browser session"
+	}
+
+	b.recordingMutex.Lock()
+	defer b.recordingMutex.Unlock()
+	if b.recordingActive {
+		return llm.ErrorfToolOut("a recording is already active (%d steps); stop it first", len(b.recordedSteps))
+	}
+	b.recordingActive = true
+	b.recordingName = name
+	b.recordedSteps = nil
+
+	return llm.ToolOut{LLMContent: llm.TextContent(fmt.Sprintf("Recording %q started.", name))}
+}
+
+func (b *BrowseTools) recordingStop() llm.ToolOut {
+	b.recordingMutex.Lock()
+	if !b.recordingActive {
+		b.recordingMutex.Unlock()
+		return llm.ErrorfToolOut("no recording is active")
+	}
+	b.recordingActive = false
+	name := b.recordingName
+	steps := append([]RecordedStep(nil), b.recordedSteps...)
+	b.recordingMutex.Unlock()
+
+	logData, err := json.MarshalIndent(map[string]any{
+		"name":  name,
+		"steps": steps,
+	}, "", "  ")
+	if err != nil {
+		return llm.ErrorfToolOut("failed to serialize recording: %w", err)
+	}
+	if err := os.MkdirAll(RecordingsDir, 0o755); err != nil {
+		return llm.ErrorfToolOut("failed to create recordings directory: %w", err)
+	}
+	logPath := filepath.Join(RecordingsDir, fmt.Sprintf("recording_%s.json", uuid.New().String()[:8]))
+	if err := os.WriteFile(logPath, logData, 0o644); err != nil {
+		return llm.ErrorfToolOut("failed to write recording: %w", err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Recording %q stopped with %d step(s). Session log: %s\n", name, len(steps), logPath)
+	for _, s := range steps {
+		sb.WriteString(formatStepLine(s))
+		sb.WriteByte('\n')
+	}
+	sb.WriteString(`Use action "export" to write a Playwright test.`)
+
+	timeline := make([]map[string]any, 0, len(steps))
+	for _, s := range steps {
+		entry := map[string]any{
+			"index":   s.Index,
+			"action":  s.Action,
+			"summary": stepSummary(s),
+		}
+		if s.Error != "" {
+			entry["error"] = s.Error
+		}
+		if s.Screenshot != "" {
+			entry["screenshot_url"] = "/api/read?path=" + url.QueryEscape(s.Screenshot)
+		}
+		timeline = append(timeline, entry)
+	}
+
+	return llm.ToolOut{
+		LLMContent: llm.TextContent(sb.String()),
+		Display: map[string]any{
+			"type":  "browser_recording",
+			"name":  name,
+			"path":  logPath,
+			"steps": timeline,
+		},
+	}
+}
+
+func (b *BrowseTools) recordingStatus() llm.ToolOut {
+	b.recordingMutex.Lock()
+	defer b.recordingMutex.Unlock()
+	if !b.recordingActive {
+		if len(b.recordedSteps) > 0 {
+			return llm.ToolOut{LLMContent: llm.TextContent(fmt.Sprintf(
+				"Not recording. Last recording %q has %d step(s) available for export.", b.recordingName, len(b.recordedSteps)))}
+		}
+		return llm.ToolOut{LLMContent: llm.TextContent("Not recording.")}
+	}
+	return llm.ToolOut{LLMContent: llm.TextContent(fmt.Sprintf(
+		"Recording %q is active with %d step(s).", b.recordingName, len(b.recordedSteps)))}
+}
+
+// assertTextJS reports whether the element's visible text or form value contains want.
+const assertTextJS = `function(want) {
+	const text = ("value" in this && typeof this.value === "string" && this.value !== "") ? this.value : this.innerText;
+	return { ok: (text || "").includes(want), text: (text || "").slice(0, 200) };
+}`
+
+func (b *BrowseTools) recordingAssert(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var input recordingInput
+	if err := json.Unmarshal(m, &input); err != nil {
+		return llm.ErrorfToolOut("invalid input: %w", err)
+	}
+	if input.empty() {
+		return llm.ErrorfToolOut("one of 'selector', 'name', or 'role' is required for the assert action")
+	}
+
+	out := b.runAssert(input)
+	b.recordStep("assert", m, out)
+	return out
+}
+
+func (b *BrowseTools) runAssert(input recordingInput) llm.ToolOut {
+	browserCtx, err := b.GetBrowserContext()
+	if err != nil {
+		return llm.ErrorToolOut(err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(browserCtx, parseTimeout(input.Timeout))
+	defer cancel()
+
+	// Poll so assertions behave like Playwright's auto-retrying expect.
+	var last string
+	err = chromedp.Run(timeoutCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := resolveElement(ctx, input.elementTarget)
+		if err != nil {
+			return err
+		}
+		for {
+			if input.Text == "" {
+				if _, _, err := clickablePoint(ctx, id); err == nil {
+					return nil
+				}
+			} else {
+				res, err := callOnNode(ctx, id, assertTextJS, input.Text)
+				if err != nil {
+					return err
+				}
+				var r struct {
+					OK   bool   `json:"ok"`
+					Text string `json:"text"`
+				}
+				if err := json.Unmarshal(res, &r); err != nil {
+					return err
+				}
+				if r.OK {
+					return nil
+				}
+				last = r.Text
+			}
+			select {
+			case <-ctx.Done():
+				if input.Text == "" {
+					return fmt.Errorf("element is not visible")
+				}
+				return fmt.Errorf("expected text %q, got %q", input.Text, last)
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	}))
+	if err != nil {
+		return llm.ErrorfToolOut("assertion failed for %s: %w", input.elementTarget, err)
+	}
+
+	if input.Text == "" {
+		return llm.ToolOut{LLMContent: llm.TextContent(fmt.Sprintf("assertion passed: %s is visible", input.elementTarget))}
+	}
+	return llm.ToolOut{LLMContent: llm.TextContent(fmt.Sprintf("assertion passed: %s contains %q", input.elementTarget, input.Text))}
+}
+
+func (b *BrowseTools) recordingExport(path string) llm.ToolOut {
+	b.recordingMutex.Lock()
+	name := b.recordingName
+	steps := append([]RecordedStep(nil), b.recordedSteps...)
+	b.recordingMutex.Unlock()
+
+	if len(steps) == 0 {
+		return llm.ErrorfToolOut("nothing to export; start a recording and use the browser tool first")
+	}
+
+	script := PlaywrightScript(name, steps)
+
+	if path == "" {
+		if err := os.MkdirAll(RecordingsDir, 0o755); err != nil {
+			return llm.ErrorfToolOut("failed to create recordings directory: %w", err)
+		}
+		path = filepath.Join(RecordingsDir, fmt.Sprintf("%s_%s.spec.ts", slugify(name), uuid.New().String()[:8]))
+	} else if !filepath.IsAbs(path) {
+		return llm.ErrorfToolOut("path must be absolute: %s", path)
+	}
+	if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+		return llm.ErrorfToolOut("failed to write Playwright test: %w", err)
+	}
+
+	return llm.ToolOut{LLMContent: llm.TextContent(fmt.Sprintf(
+		"Playwright test with %d step(s) written to: %s\n\n%s", len(steps), path, script))}
+}
+
+// recordStep appends a step to the active recording, if any. For actions
+// that change the page, a screenshot is captured for the timeline.
+func (b *BrowseTools) recordStep(action string, input json.RawMessage, out llm.ToolOut) {
+	if !recordedActions[action] {
+		return
+	}
+	b.recordingMutex.Lock()
+	active := b.recordingActive
+	b.recordingMutex.Unlock()
+	if !active {
+		return
+	}
+
+	step := RecordedStep{
+		Time:   time.Now(),
+		Action: action,
+		Input:  append(json.RawMessage(nil), input...),
+	}
+	if out.Error != nil {
+		step.Error = out.Error.Error()
+	} else if len(out.LLMContent) > 0 {
+		step.Result = out.LLMContent[0].Text
+	}
+
+	if action == "screenshot" {
+		if d, ok := out.Display.(map[string]any); ok {
+			step.Screenshot, _ = d["path"].(string)
+		}
+	} else if screenshotAfter[action] && out.Error == nil {
+		step.Screenshot = b.captureStepScreenshot()
+	}
+
+	b.recordingMutex.Lock()
+	defer b.recordingMutex.Unlock()
+	if !b.recordingActive {
+		return
+	}
+	step.Index = len(b.recordedSteps) + 1
+	b.recordedSteps = append(b.recordedSteps, step)
+}
+
+// captureStepScreenshot takes a viewport screenshot and returns its path, or
+// "" if the screenshot could not be taken.
+func (b *BrowseTools) captureStepScreenshot() string {
+	browserCtx, err := b.GetBrowserContext()
+	if err != nil {
+		return ""
+	}
+	timeoutCtx, cancel := context.WithTimeout(browserCtx, 5*time.Second)
+	defer cancel()
+
+	var buf []byte
+	if err := chromedp.Run(timeoutCtx, chromedp.CaptureScreenshot(&buf)); err != nil {
+		return ""
+	}
+	id := b.SaveScreenshot(buf)
+	if id == "" {
+		return ""
+	}
+	return GetScreenshotPath(id)
+}
+
+// stepInput is the union of fields used by recorded steps.
+type stepInput struct {
+	elementTarget
+	URL        string   `json:"url"`
+	Tab        string   `json:"tab"`
+	Expression string   `json:"expression"`
+	Width      int      `json:"width"`
+	Height     int      `json:"height"`
+	Text       string   `json:"text"`
+	Clear      *bool    `json:"clear"`
+	PressEnter bool     `json:"press_enter"`
+	Value      string   `json:"value"`
+	State      string   `json:"state"`
+	Files      []string `json:"files"`
+}
+
+// stepSummary describes a step in one line for the timeline.
+func stepSummary(s RecordedStep) string {
+	var in stepInput
+	_ = json.Unmarshal(s.Input, &in)
+	switch s.Action {
+	case "navigate":
+		return in.URL
+	case "eval":
+		return truncate(in.Expression, 80)
+	case "resize":
+		return fmt.Sprintf("%dx%d", in.Width, in.Height)
+	case "screenshot":
+		if in.Selector != "" {
+			return in.Selector
+		}
+		return "page"
+	case "type":
+		return fmt.Sprintf("%s ← %q", in.elementTarget, truncate(in.Text, 40))
+	case "select":
+		return fmt.Sprintf("%s = %q", in.elementTarget, in.Value)
+	case "wait_for":
+		if in.empty() {
+			return "network idle"
+		}
+		state := in.State
+		if state == "" {
+			state = "visible"
+		}
+		return fmt.Sprintf("%s %s", in.elementTarget, state)
+	case "upload":
+		return fmt.Sprintf("%s ← %s", in.elementTarget, strings.Join(in.Files, ", "))
+	case "new_tab", "switch_tab", "close_tab":
+		return in.Tab
+	case "assert":
+		if in.Text != "" {
+			return fmt.Sprintf("%s contains %q", in.elementTarget, in.Text)
+		}
+		return fmt.Sprintf("%s visible", in.elementTarget)
+	default:
+		return in.elementTarget.String()
+	}
+}
+
+func formatStepLine(s RecordedStep) string {
+	line := fmt.Sprintf("  %d. %s %s", s.Index, s.Action, stepSummary(s))
+	if s.Error != "" {
+		line += " — FAILED: " + s.Error
+	}
+	return line
+}
+
+// PlaywrightScript renders recorded steps as a Playwright test. Failed steps
+// are kept as comments so the script reflects what actually worked.
+func PlaywrightScript(name string, steps []RecordedStep) string {
+	var sb strings.Builder
+	sb.WriteString("import { test, expect } from \"@playwright/test\";\n\n")
+	fmt.Fprintf(&sb, "test(%s, async ({ page }) => {\n", jsString(name))
+	for _, s := range steps {
+		if s.Error != "" {
+			fmt.Fprintf(&sb, "  // step %d (%s) failed during recording: %s\n", s.Index, s.Action, firstLine(s.Error))
+			continue
+		}
+		for _, line := range playwrightLines(s) {
+			sb.WriteString("  ")
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+		}
+	}
+	sb.WriteString("});\n")
+	return sb.String()
+}
+
+func playwrightLines(s RecordedStep) []string {
+	var in stepInput
+	if err := json.Unmarshal(s.Input, &in); err != nil {
+		return []string{fmt.Sprintf("// step %d (%s): unreadable input", s.Index, s.Action)}
+	}
+	loc := playwrightLocator(in.elementTarget)
+
+	switch s.Action {
+	case "navigate":
+		return []string{fmt.Sprintf("await page.goto(%s);", jsString(in.URL))}
+	case "eval":
+		return []string{fmt.Sprintf("await page.evaluate(%s);", jsString(in.Expression))}
+	case "resize":
+		return []string{fmt.Sprintf("await page.setViewportSize({ width: %d, height: %d });", in.Width, in.Height)}
+	case "screenshot":
+		return []string{fmt.Sprintf("// step %d: screenshot taken during recording", s.Index)}
+	case "click":
+		return []string{fmt.Sprintf("await %s.click();", loc)}
+	case "type":
+		var lines []string
+		if in.Clear == nil || *in.Clear {
+			lines = append(lines, fmt.Sprintf("await %s.fill(%s);", loc, jsString(in.Text)))
+		} else {
+			lines = append(lines, fmt.Sprintf("await %s.pressSequentially(%s);", loc, jsString(in.Text)))
+		}
+		if in.PressEnter {
+			lines = append(lines, fmt.Sprintf("await %s.press(\"Enter\");", loc))
+		}
+		return lines
+	case "select":
+		return []string{fmt.Sprintf("await %s.selectOption(%s);", loc, jsString(in.Value))}
+	case "upload":
+		files := make([]string, len(in.Files))
+		for i, f := range in.Files {
+			files[i] = jsString(f)
+		}
+		return []string{fmt.Sprintf("await %s.setInputFiles([%s]);", loc, strings.Join(files, ", "))}
+	case "wait_for":
+		state := in.State
+		if state == "" {
+			state = "visible"
+			if in.empty() {
+				state = "network_idle"
+			}
+		}
+		if state == "network_idle" {
+			return []string{`await page.waitForLoadState("networkidle");`}
+		}
+		return []string{fmt.Sprintf("await %s.waitFor({ state: %s });", loc, jsString(state))}
+	case "assert":
+		if in.Text != "" {
+			return []string{fmt.Sprintf("await expect(%s).toContainText(%s);", loc, jsString(in.Text))}
+		}
+		return []string{fmt.Sprintf("await expect(%s).toBeVisible();", loc)}
+	case "new_tab", "switch_tab", "close_tab":
+		// Tabs aren't exported; flag where the recording moved between them.
+		lines := []string{fmt.Sprintf("// step %d: %s %s — multiple tabs are not exported; following steps ran in that tab", s.Index, s.Action, jsString(in.Tab))}
+		if s.Action == "new_tab" && in.URL != "" {
+			lines = append(lines, fmt.Sprintf("await page.goto(%s);", jsString(in.URL)))
+		}
+		return lines
+	default:
+		return []string{fmt.Sprintf("// step %d: unsupported action %q", s.Index, s.Action)}
+	}
+}
+
+// playwrightLocator converts an element target into a Playwright locator expression.
+func playwrightLocator(t elementTarget) string {
+	switch {
+	case t.Selector != "":
+		return fmt.Sprintf("page.locator(%s)", jsString(t.Selector))
+	case t.Role != "" && t.Name != "":
+		return fmt.Sprintf("page.getByRole(%s, { name: %s, exact: true })", jsString(t.Role), jsString(t.Name))
+	case t.Role != "":
+		return fmt.Sprintf("page.getByRole(%s).first()", jsString(t.Role))
+	default:
+		// An accessible name alone may come from a label or from text content.
+		return fmt.Sprintf("page.getByLabel(%[1]s, { exact: true }).or(page.getByText(%[1]s, { exact: true })).first()", jsString(t.Name))
+	}
+}
+
+// jsString quotes s as a JavaScript string literal.
+func jsString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify turns a recording name into a filename-safe slug.
+func slugify(name string) string {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.Trim(slug[:40], "-")
+	}
+	if slug == "" {
+		return "recording"
+	}
+	return slug
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,169 @@
+package browse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shelley.exe.dev/llm"
+)
+
+func TestPlaywrightScript(t *testing.T) {
+	step := func(i int, action, input string) RecordedStep {
+		return RecordedStep{Index: i, Action: action, Input: json.RawMessage(input)}
+	}
+	steps := []RecordedStep{
+		step(1, "navigate", `{"action":"navigate","url":"http://localhost:8000/"}`),
+		step(2, "type", `{"action":"type","role":"textbox","name":"Message","text":"hi \"there\"","press_enter":true}`),
+		step(3, "type", `{"action":"type","selector":"#q","text":"more","clear":false}`),
+		step(4, "click", `{"action":"click","selector":"button[data-testid=\"send\"]"}`),
+		step(5, "select", `{"action":"select","name":"Color","value":"Green"}`),
+		step(6, "wait_for", `{"action":"wait_for"}`),
+		step(7, "wait_for", `{"action":"wait_for","selector":"#spinner","state":"hidden"}`),
+		step(8, "upload", `{"action":"upload","selector":"#file","files":["/tmp/a.txt","/tmp/b.txt"]}`),
+		step(9, "eval", `{"action":"eval","expression":"window.scrollTo(0, 100)"}`),
+		step(10, "resize", `{"action":"resize","width":400,"height":800}`),
+		step(11, "assert", `{"action":"assert","selector":".reply","text":"Hello"}`),
+		step(12, "assert", `{"action":"assert","role":"alert"}`),
+		{Index: 13, Action: "click", Input: json.RawMessage(`{"action":"click","selector":"#gone"}`), Error: "click \"#gone\": timeout\nmore detail"},
+	}
+
+	got := PlaywrightScript("send a <message>", steps)
+	want := `import { test, expect } from "@playwright/test";
+
+test("send a <message>", async ({ page }) => {
+  await page.goto("http://localhost:8000/");
+  await page.getByRole("textbox", { name: "Message", exact: true }).fill("hi \"there\"");
+  await page.getByRole("textbox", { name: "Message", exact: true }).press("Enter");
+  await page.locator("#q").pressSequentially("more");
+  await page.locator("button[data-testid=\"send\"]").click();
+  await page.getByLabel("Color", { exact: true }).or(page.getByText("Color", { exact: true })).first().selectOption("Green");
+  await page.waitForLoadState("networkidle");
+  await page.locator("#spinner").waitFor({ state: "hidden" });
+  await page.locator("#file").setInputFiles(["/tmp/a.txt", "/tmp/b.txt"]);
+  await page.evaluate("window.scrollTo(0, 100)");
+  await page.setViewportSize({ width: 400, height: 800 });
+  await expect(page.locator(".reply")).toContainText("Hello");
+  await expect(page.getByRole("alert").first()).toBeVisible();
+  // step 13 (click) failed during recording: click "#gone": timeout
+});
+`
+	if got != want {
+		t.Errorf("PlaywrightScript mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRecordingLifecycle(t *testing.T) {
+	tools := NewBrowseTools(context.Background(), 0, 0)
+	t.Cleanup(func() {
+		tools.Close()
+	})
+	tool := tools.RecordingTool()
+	ctx := context.Background()
+
+	// Steps outside a recording are not captured.
+	tools.recordStep("click", json.RawMessage(`{"action":"click","selector":"#a"}`), llm.ErrorToolOut(errors.New("boom")))
+
+	if out := tool.Run(ctx, []byte(`{"action": "export"}`)); out.Error == nil {
+		t.Error("expected export with no steps to fail")
+	}
+	if out := tool.Run(ctx, []byte(`{"action": "stop"}`)); out.Error == nil {
+		t.Error("expected stop without a recording to fail")
+	}
+
+	if out := tool.Run(ctx, []byte(`{"action": "start", "name": "login flow"}`)); out.Error != nil {
+		t.Fatalf("start: %v", out.Error)
+	}
+	if out := tool.Run(ctx, []byte(`{"action": "start"}`)); out.Error == nil {
+		t.Error("expected a second start to fail")
+	}
+
+	// Failed steps and non-page actions don't need a browser to record.
+	tools.recordStep("click", json.RawMessage(`{"action":"click","selector":"#a"}`), llm.ErrorToolOut(errors.New("boom")))
+	tools.recordStep("console_logs", json.RawMessage(`{"action":"console_logs"}`), llm.ToolOut{LLMContent: llm.TextContent("none")})
+	tools.recordStep("screenshot", json.RawMessage(`{"action":"screenshot"}`), llm.ToolOut{
+		LLMContent: llm.TextContent("Screenshot taken"),
+		Display:    map[string]any{"path": "/tmp/shot.png"},
+	})
+	tools.recordStep("wait_for", json.RawMessage(`{"action":"wait_for","selector":"#done"}`), llm.ToolOut{LLMContent: llm.TextContent("ok")})
+
+	out := tool.Run(ctx, []byte(`{"action": "status"}`))
+	if out.Error != nil || !strings.Contains(out.LLMContent[0].Text, "3 step(s)") {
+		t.Fatalf("status = %v / %v, want 3 steps", out.LLMContent, out.Error)
+	}
+
+	out = tool.Run(ctx, []byte(`{"action": "stop"}`))
+	if out.Error != nil {
+		t.Fatalf("stop: %v", out.Error)
+	}
+	display, ok := out.Display.(map[string]any)
+	if !ok || display["type"] != "browser_recording" {
+		t.Fatalf("unexpected display: %#v", out.Display)
+	}
+	timeline := display["steps"].([]map[string]any)
+	if len(timeline) != 3 {
+		t.Fatalf("expected 3 timeline entries, got %d", len(timeline))
+	}
+	if timeline[0]["error"] != "boom" {
+		t.Errorf("first step error = %v, want boom", timeline[0]["error"])
+	}
+	if url, _ := timeline[1]["screenshot_url"].(string); !strings.Contains(url, "shot.png") {
+		t.Errorf("screenshot step url = %q", url)
+	}
+	logPath := display["path"].(string)
+	defer os.Remove(logPath)
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("reading session log: %v", err)
+	}
+	if !strings.Contains(string(data), `"login flow"`) {
+		t.Errorf("session log missing recording name: %s", data)
+	}
+
+	// Export still works after stop, and rejects relative paths.
+	if out := tool.Run(ctx, []byte(`{"action": "export", "path": "flow.spec.ts"}`)); out.Error == nil {
+		t.Error("expected relative export path to fail")
+	}
+	specPath := filepath.Join(t.TempDir(), "flow.spec.ts")
+	out = tool.Run(ctx, []byte(`{"action": "export", "path": "`+specPath+`"}`))
+	if out.Error != nil {
+		t.Fatalf("export: %v", out.Error)
+	}
+	script, err := os.ReadFile(specPath)
+	if err != nil {
+		t.Fatalf("reading exported test: %v", err)
+	}
+	for _, want := range []string{`test("login flow"`, "// step 1 (click) failed", `await page.locator("#done").waitFor({ state: "visible" });`} {
+		if !strings.Contains(string(script), want) {
+			t.Errorf("exported test missing %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestRecordingAssertRequiresTarget(t *testing.T) {
+	tools := NewBrowseTools(context.Background(), 0, 0)
+	t.Cleanup(func() {
+		tools.Close()
+	})
+	out := tools.RecordingTool().Run(context.Background(), []byte(`{"action": "assert", "text": "hi"}`))
+	if out.Error == nil {
+		t.Error("expected assert without a target to fail")
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	for name, want := range map[string]string{
+		"Login Flow": "login-flow",
+		"!!!":        "recording",
+		"a really long recording name that goes on and on": "a-really-long-recording-name-that-goes-o",
+		"abcdefghijklmnopqrstuvwxyzabcdefghijklm cut here": "abcdefghijklmnopqrstuvwxyzabcdefghijklm",
+	} {
+		if got := slugify(name); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
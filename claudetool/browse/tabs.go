@@ -0,0 +1,308 @@
+package browse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"shelley.exe.dev/llm"
+)
+
+// MainTabName is the name of the tab the browser starts with. It can't be closed.
+const MainTabName = "main"
+
+// tabState holds a tab's console and network logs. consoleLogs is guarded by
+// BrowseTools.consoleLogsMutex and the network fields by BrowseTools.networkMutex.
+type tabState struct {
+	consoleLogs     []*runtime.EventConsoleAPICalled
+	networkEnabled  bool
+	networkRequests []*NetworkRequest
+	// In-flight request tracking for network idle waits
+	inflightRequests    map[network.RequestID]struct{}
+	lastNetworkActivity time.Time
+}
+
+func newTabState() *tabState {
+	return &tabState{
+		consoleLogs:      make([]*runtime.EventConsoleAPICalled, 0),
+		inflightRequests: make(map[network.RequestID]struct{}),
+	}
+}
+
+// browserTab is a named tab opened with the new_tab action. Emulation
+// settings apply per tab; isolated tabs also get their own cookies and storage.
+type browserTab struct {
+	name     string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	isolated bool
+	state    *tabState
+}
+
+// activeContextLocked returns the context of the active tab. Caller must hold b.mux.
+func (b *BrowseTools) activeContextLocked() context.Context {
+	if tab, ok := b.tabs[b.activeTab]; ok {
+		if tab.ctx.Err() == nil {
+			return tab.ctx
+		}
+		// The page closed itself (e.g. window.close()); fall back to main.
+		log.Printf("Browser tab %q is gone, switching to %q", tab.name, MainTabName)
+		b.removeTabLocked(tab)
+	}
+	return b.browserCtx
+}
+
+// setActiveTabLocked makes name the active tab and points the console and
+// network logs at its buffers. Caller must hold b.mux.
+func (b *BrowseTools) setActiveTabLocked(name string) {
+	state := b.mainState
+	if tab, ok := b.tabs[name]; ok {
+		state = tab.state
+	} else {
+		name = MainTabName
+	}
+	b.consoleLogsMutex.Lock()
+	b.networkMutex.Lock()
+	b.tabState = state
+	b.networkMutex.Unlock()
+	b.consoleLogsMutex.Unlock()
+	b.activeTab = name
+}
+
+// removeTabLocked closes a tab and forgets it. Caller must hold b.mux.
+func (b *BrowseTools) removeTabLocked(tab *browserTab) {
+	tab.cancel()
+	delete(b.tabs, tab.name)
+	if b.activeTab == tab.name {
+		b.setActiveTabLocked(MainTabName)
+	}
+}
+
+// closeTabsLocked closes every tab except main. Caller must hold b.mux.
+func (b *BrowseTools) closeTabsLocked() {
+	for _, tab := range b.tabs {
+		b.removeTabLocked(tab)
+	}
+	b.setActiveTabLocked(MainTabName)
+}
+
+type newTabInput struct {
+	Tab      string `json:"tab"`
+	URL      string `json:"url,omitempty"`
+	Isolated *bool  `json:"isolated,omitempty"`
+	Timeout  string `json:"timeout,omitempty"`
+}
+
+func (b *BrowseTools) newTabRun(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var input newTabInput
+	if err := json.Unmarshal(m, &input); err != nil {
+		return llm.ErrorfToolOut("invalid input: %w", err)
+	}
+	if input.Tab == "" {
+		return llm.ErrorfToolOut("'tab' is required for the new_tab action")
+	}
+	isolated := true
+	if input.Isolated != nil {
+		isolated = *input.Isolated
+	}
+
+	// Make sure the browser is running before opening a tab in it.
+	if _, err := b.GetBrowserContext(); err != nil {
+		return llm.ErrorToolOut(err)
+	}
+	if err := b.openTab(input.Tab, isolated); err != nil {
+		return llm.ErrorToolOut(err)
+	}
+
+	msg := fmt.Sprintf("Opened tab %q", input.Tab)
+	if isolated {
+		msg += " with its own cookies and storage"
+	} else {
+		msg += fmt.Sprintf(" sharing cookies with %q", MainTabName)
+	}
+	msg += "; it is now the active tab."
+
+	if input.URL != "" {
+		navInput, _ := json.Marshal(navigateInput{URL: input.URL, Timeout: input.Timeout})
+		if out := b.navigateRun(ctx, navInput); out.Error != nil {
+			return llm.ErrorfToolOut("%s Navigation failed: %w", msg, out.Error)
+		}
+		msg += fmt.Sprintf(" Navigated to %s.", input.URL)
+	}
+	return b.toolOutWithDownloads(msg)
+}
+
+// openTab creates a named tab and makes it active.
+func (b *BrowseTools) openTab(name string, isolated bool) error {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if name == MainTabName {
+		return fmt.Errorf("tab %q already exists", name)
+	}
+	if _, ok := b.tabs[name]; ok {
+		return fmt.Errorf("tab %q already exists; use switch_tab", name)
+	}
+	if b.browserCtx == nil {
+		return fmt.Errorf("browser is not running")
+	}
+
+	var opts []chromedp.ContextOption
+	if isolated {
+		opts = append(opts, chromedp.WithNewBrowserContext())
+	}
+	tabCtx, cancel := chromedp.NewContext(b.browserCtx, opts...)
+
+	state := newTabState()
+	chromedp.ListenTarget(tabCtx, func(ev any) {
+		b.handleBrowserEvent(state, ev)
+	})
+
+	if err := chromedp.Run(tabCtx, chromedp.EmulateViewport(1280, 720)); err != nil {
+		cancel()
+		return fmt.Errorf("failed to open tab %q: %w", name, err)
+	}
+
+	// Download behavior is per browser context, so isolated tabs need their own.
+	if c := chromedp.FromContext(tabCtx); isolated && c.BrowserContextID != "" {
+		err := browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorAllowAndName).
+			WithBrowserContextID(c.BrowserContextID).
+			WithDownloadPath(DownloadDir).
+			WithEventsEnabled(true).
+			Do(cdp.WithExecutor(tabCtx, c.Browser))
+		if err != nil {
+			log.Printf("Failed to configure downloads for tab %q: %v", name, err)
+		}
+	}
+
+	b.tabs[name] = &browserTab{
+		name:     name,
+		ctx:      tabCtx,
+		cancel:   cancel,
+		isolated: isolated,
+		state:    state,
+	}
+	b.setActiveTabLocked(name)
+	return nil
+}
+
+type tabInput struct {
+	Tab string `json:"tab"`
+}
+
+func (b *BrowseTools) switchTabRun(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var input tabInput
+	if err := json.Unmarshal(m, &input); err != nil {
+		return llm.ErrorfToolOut("invalid input: %w", err)
+	}
+	if input.Tab == "" {
+		return llm.ErrorfToolOut("'tab' is required for the switch_tab action")
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if input.Tab != MainTabName {
+		tab, ok := b.tabs[input.Tab]
+		if !ok {
+			return llm.ErrorfToolOut("no tab named %q; use list_tabs", input.Tab)
+		}
+		if tab.ctx.Err() != nil {
+			b.removeTabLocked(tab)
+			return llm.ErrorfToolOut("tab %q was closed", input.Tab)
+		}
+	}
+	b.setActiveTabLocked(input.Tab)
+
+	return llm.ToolOut{LLMContent: llm.TextContent(fmt.Sprintf("Switched to tab %q.", input.Tab))}
+}
+
+func (b *BrowseTools) closeTabRun(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var input tabInput
+	if err := json.Unmarshal(m, &input); err != nil {
+		return llm.ErrorfToolOut("invalid input: %w", err)
+	}
+	if input.Tab == "" {
+		return llm.ErrorfToolOut("'tab' is required for the close_tab action")
+	}
+	if input.Tab == MainTabName {
+		return llm.ErrorfToolOut("the %q tab can't be closed", MainTabName)
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	tab, ok := b.tabs[input.Tab]
+	if !ok {
+		return llm.ErrorfToolOut("no tab named %q; use list_tabs", input.Tab)
+	}
+	b.removeTabLocked(tab)
+
+	return llm.ToolOut{LLMContent: llm.TextContent(fmt.Sprintf("Closed tab %q. Active tab: %q.", input.Tab, b.activeTab))}
+}
+
+// tabInfo describes a tab for list_tabs.
+type tabInfo struct {
+	name     string
+	ctx      context.Context
+	isolated bool
+	active   bool
+}
+
+func (b *BrowseTools) listTabsRun(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	b.mux.Lock()
+	if b.browserCtx == nil {
+		b.mux.Unlock()
+		return llm.ToolOut{LLMContent: llm.TextContent("Browser is not running. Tabs: main (active)")}
+	}
+	active := b.activeTab
+	if active == "" {
+		active = MainTabName
+	}
+	infos := []tabInfo{{name: MainTabName, ctx: b.browserCtx, active: active == MainTabName}}
+	names := make([]string, 0, len(b.tabs))
+	for name := range b.tabs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tab := b.tabs[name]
+		infos = append(infos, tabInfo{name: name, ctx: tab.ctx, isolated: tab.isolated, active: active == name})
+	}
+	b.mux.Unlock()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d tab(s):\n", len(infos))
+	for _, info := range infos {
+		var location, title string
+		timeoutCtx, cancel := context.WithTimeout(info.ctx, 2*time.Second)
+		err := chromedp.Run(timeoutCtx, chromedp.Location(&location), chromedp.Title(&title))
+		cancel()
+
+		marker := " "
+		if info.active {
+			marker = "*"
+		}
+		fmt.Fprintf(&sb, "%s %s", marker, info.name)
+		if info.isolated {
+			sb.WriteString(" [isolated]")
+		}
+		if err != nil {
+			fmt.Fprintf(&sb, " (unavailable: %v)", err)
+		} else {
+			fmt.Fprintf(&sb, " %s", location)
+			if title != "" {
+				fmt.Fprintf(&sb, " %q", title)
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return llm.ToolOut{LLMContent: llm.TextContent(sb.String())}
+}
@@ -0,0 +1,169 @@
+package browse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+func consoleEvent(msg string) *runtime.EventConsoleAPICalled {
+	return &runtime.EventConsoleAPICalled{
+		Type: runtime.APITypeLog,
+		Args: []*runtime.RemoteObject{{Type: runtime.TypeString, Value: jsontext.Value(fmt.Sprintf("%q", msg))}},
+	}
+}
+
+// TestTabStateSwitching checks that console logs follow the active tab
+// without starting a real browser.
+func TestTabStateSwitching(t *testing.T) {
+	ctx := context.Background()
+	tools := NewBrowseTools(ctx, 0, 0)
+	t.Cleanup(func() {
+		tools.Close()
+	})
+
+	// Mock the browser and an extra tab.
+	tabCtx, cancelTab := context.WithCancel(ctx)
+	tools.mux.Lock()
+	tools.browserCtx = ctx
+	tools.tabs["admin"] = &browserTab{name: "admin", ctx: tabCtx, cancel: cancelTab, isolated: true, state: newTabState()}
+	adminState := tools.tabs["admin"].state
+	tools.mux.Unlock()
+
+	tools.handleBrowserEvent(tools.mainState, consoleEvent("from main"))
+	tools.handleBrowserEvent(adminState, consoleEvent("from admin"))
+
+	tool := tools.CombinedTool()
+	logs := func() string {
+		t.Helper()
+		out := tool.Run(ctx, []byte(`{"action": "console_logs"}`))
+		if out.Error != nil {
+			t.Fatalf("console_logs: %v", out.Error)
+		}
+		return out.LLMContent[0].Text
+	}
+
+	if got := logs(); !strings.Contains(got, "from main") || strings.Contains(got, "from admin") {
+		t.Errorf("main tab logs = %s", got)
+	}
+
+	if out := tool.Run(ctx, []byte(`{"action": "switch_tab", "tab": "admin"}`)); out.Error != nil {
+		t.Fatalf("switch_tab: %v", out.Error)
+	}
+	if got, _ := tools.GetBrowserContext(); got != tabCtx {
+		t.Error("expected the admin tab's context to be active")
+	}
+	if got := logs(); !strings.Contains(got, "from admin") || strings.Contains(got, "from main") {
+		t.Errorf("admin tab logs = %s", got)
+	}
+
+	// A tab whose page went away falls back to main.
+	cancelTab()
+	if got, _ := tools.GetBrowserContext(); got != ctx {
+		t.Error("expected fallback to the main tab after the admin tab closed")
+	}
+	if got := logs(); !strings.Contains(got, "from main") {
+		t.Errorf("logs after fallback = %s", got)
+	}
+
+	for _, in := range []string{
+		`{"action": "switch_tab", "tab": "missing"}`,
+		`{"action": "switch_tab"}`,
+		`{"action": "close_tab", "tab": "main"}`,
+		`{"action": "close_tab", "tab": "missing"}`,
+		`{"action": "new_tab"}`,
+	} {
+		if out := tool.Run(ctx, []byte(in)); out.Error == nil {
+			t.Errorf("expected error for %s", in)
+		}
+	}
+}
+
+func TestListTabsWithoutBrowser(t *testing.T) {
+	tools := NewBrowseTools(context.Background(), 0, 0)
+	out := tools.CombinedTool().Run(context.Background(), []byte(`{"action": "list_tabs"}`))
+	if out.Error != nil {
+		t.Fatalf("list_tabs: %v", out.Error)
+	}
+	if !strings.Contains(out.LLMContent[0].Text, "main (active)") {
+		t.Errorf("unexpected output: %s", out.LLMContent[0].Text)
+	}
+}
+
+func TestNamedTabs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping browser tab test in short mode")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "admin"})
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>%s</title></head><body><script>console.log("loaded %s")</script></body></html>`, r.URL.Path, r.URL.Path)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	tools := NewBrowseTools(ctx, 0, 0)
+	t.Cleanup(func() {
+		tools.Close()
+	})
+	tool := tools.CombinedTool()
+
+	toolOut := tool.Run(ctx, []byte(fmt.Sprintf(`{"action": "navigate", "url": %q}`, server.URL+"/login")))
+	if toolOut.Error != nil {
+		if strings.Contains(toolOut.Error.Error(), "failed to start browser") {
+			t.Skip("Browser automation not available in this environment")
+		}
+		t.Fatalf("Navigation error: %v", toolOut.Error)
+	}
+
+	run := func(in string) string {
+		t.Helper()
+		out := tool.Run(ctx, []byte(in))
+		if out.Error != nil {
+			t.Fatalf("%s: %v", in, out.Error)
+		}
+		return out.LLMContent[0].Text
+	}
+
+	run(fmt.Sprintf(`{"action": "new_tab", "tab": "anon", "url": %q}`, server.URL+"/anon"))
+	if got := run(`{"action": "eval", "expression": "document.cookie"}`); strings.Contains(got, "session") {
+		t.Errorf("isolated tab should not see main's cookie, got %s", got)
+	}
+	if got := run(`{"action": "console_logs"}`); !strings.Contains(got, "loaded /anon") || strings.Contains(got, "loaded /login") {
+		t.Errorf("isolated tab console logs = %s", got)
+	}
+
+	run(fmt.Sprintf(`{"action": "new_tab", "tab": "shared", "isolated": false, "url": %q}`, server.URL+"/shared"))
+	if got := run(`{"action": "eval", "expression": "document.cookie"}`); !strings.Contains(got, "session=admin") {
+		t.Errorf("shared tab should see main's cookie, got %s", got)
+	}
+
+	list := run(`{"action": "list_tabs"}`)
+	for _, want := range []string{"3 tab(s)", "main", "anon [isolated]", "* shared", "/shared"} {
+		if !strings.Contains(list, want) {
+			t.Errorf("list_tabs missing %q:\n%s", want, list)
+		}
+	}
+
+	run(`{"action": "switch_tab", "tab": "main"}`)
+	if got := run(`{"action": "eval", "expression": "location.pathname"}`); !strings.Contains(got, "/login") {
+		t.Errorf("main tab location = %s", got)
+	}
+
+	run(`{"action": "close_tab", "tab": "anon"}`)
+	if got := run(`{"action": "list_tabs"}`); strings.Contains(got, "anon") {
+		t.Errorf("closed tab still listed:\n%s", got)
+	}
+}
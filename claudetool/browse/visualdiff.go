@@ -0,0 +1,201 @@
+package browse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // decode JPEG baselines
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"shelley.exe.dev/llm"
+)
+
+// visualDiffInput is the input for the visual_diff action.
+type visualDiffInput struct {
+	Before    string `json:"before"`
+	After     string `json:"after"`
+	Tolerance int    `json:"tolerance,omitempty"`
+}
+
+// diffResult summarizes a pixel comparison.
+type diffResult struct {
+	Width, Height int
+	Changed       int
+	// Bounds is the bounding box of changed pixels; empty if nothing changed.
+	Bounds image.Rectangle
+}
+
+// MismatchPercent is the share of compared pixels that differ.
+func (r diffResult) MismatchPercent() float64 {
+	total := r.Width * r.Height
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(r.Changed) / float64(total)
+}
+
+var (
+	diffChangedColor = color.RGBA{R: 255, A: 255}
+	diffBoxColor     = color.RGBA{R: 255, G: 0, B: 255, A: 255}
+)
+
+// resolveImageRef returns the file path for a screenshot ID or an absolute image path.
+func resolveImageRef(ref string) (string, error) {
+	if ref == "" {
+		return "", fmt.Errorf("screenshot ID or image path is required")
+	}
+	if filepath.Base(ref) == ref {
+		path := GetScreenshotPath(ref)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	if !filepath.IsAbs(ref) {
+		return "", fmt.Errorf("%q is not a known screenshot ID or an absolute path", ref)
+	}
+	if _, err := os.Stat(ref); err != nil {
+		return "", fmt.Errorf("image file not found: %s", ref)
+	}
+	return ref, nil
+}
+
+func loadImage(ref string) (image.Image, string, error) {
+	path, err := resolveImageRef(ref)
+	if err != nil {
+		return nil, "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return img, path, nil
+}
+
+// diffImages compares two images pixel by pixel. Two pixels match when every
+// channel differs by at most tolerance (0-255). Pixels present in only one
+// image count as changed. The returned image is the after image faded to
+// grayscale with changed pixels in red and the changed region outlined.
+func diffImages(before, after image.Image, tolerance int) (*image.RGBA, diffResult) {
+	bb, ab := before.Bounds(), after.Bounds()
+	w := max(bb.Dx(), ab.Dx())
+	h := max(bb.Dy(), ab.Dy())
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	res := diffResult{Width: w, Height: h}
+
+	inside := func(r image.Rectangle, x, y int) bool {
+		return x < r.Dx() && y < r.Dy()
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			inB, inA := inside(bb, x, y), inside(ab, x, y)
+			changed := inB != inA
+			if inB && inA {
+				changed = !pixelsMatch(before.At(bb.Min.X+x, bb.Min.Y+y), after.At(ab.Min.X+x, ab.Min.Y+y), tolerance)
+			}
+			if changed {
+				res.Changed++
+				res.Bounds = res.Bounds.Union(image.Rect(x, y, x+1, y+1))
+				out.SetRGBA(x, y, diffChangedColor)
+				continue
+			}
+			// Fade unchanged pixels so the changes stand out.
+			g := color.GrayModel.Convert(after.At(ab.Min.X+x, ab.Min.Y+y)).(color.Gray).Y
+			v := 255 - (255-g)/4
+			out.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+
+	if !res.Bounds.Empty() {
+		drawRect(out, res.Bounds.Inset(-2).Intersect(out.Bounds()), diffBoxColor)
+	}
+	return out, res
+}
+
+func pixelsMatch(a, b color.Color, tolerance int) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	t := uint32(tolerance) * 0x101
+	within := func(x, y uint32) bool {
+		if x > y {
+			return x-y <= t
+		}
+		return y-x <= t
+	}
+	return within(ar, br) && within(ag, bg) && within(ab, bb) && within(aa, ba)
+}
+
+// drawRect outlines r with a 2px border.
+func drawRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	for x := r.Min.X; x < r.Max.X; x++ {
+		for _, y := range []int{r.Min.Y, r.Min.Y + 1, r.Max.Y - 2, r.Max.Y - 1} {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for _, x := range []int{r.Min.X, r.Min.X + 1, r.Max.X - 2, r.Max.X - 1} {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func (b *BrowseTools) visualDiffRun(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var input visualDiffInput
+	if err := json.Unmarshal(m, &input); err != nil {
+		return llm.ErrorfToolOut("invalid input: %w", err)
+	}
+	if input.Tolerance < 0 || input.Tolerance > 255 {
+		return llm.ErrorfToolOut("tolerance must be between 0 and 255")
+	}
+
+	before, beforePath, err := loadImage(input.Before)
+	if err != nil {
+		return llm.ErrorfToolOut("before: %w", err)
+	}
+	after, afterPath, err := loadImage(input.After)
+	if err != nil {
+		return llm.ErrorfToolOut("after: %w", err)
+	}
+
+	diff, res := diffImages(before, after, input.Tolerance)
+
+	summary := fmt.Sprintf("Compared %s (%dx%d) with %s (%dx%d).\n",
+		beforePath, before.Bounds().Dx(), before.Bounds().Dy(),
+		afterPath, after.Bounds().Dx(), after.Bounds().Dy())
+	if res.Changed == 0 {
+		return llm.ToolOut{LLMContent: llm.TextContent(summary + "No visual differences.")}
+	}
+	summary += fmt.Sprintf("Mismatch: %.3f%% (%d of %d pixels)\nChanged region: x=%d y=%d width=%d height=%d",
+		res.MismatchPercent(), res.Changed, res.Width*res.Height,
+		res.Bounds.Min.X, res.Bounds.Min.Y, res.Bounds.Dx(), res.Bounds.Dy())
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, diff); err != nil {
+		return llm.ErrorfToolOut("failed to encode diff image: %w", err)
+	}
+	id := b.SaveScreenshot(buf.Bytes())
+	if id == "" {
+		return llm.ErrorToolOut(fmt.Errorf("failed to save diff image"))
+	}
+	diffPath := GetScreenshotPath(id)
+	summary += fmt.Sprintf("\nDiff image (changes in red, region outlined) saved as %s (ID %s)", diffPath, id)
+
+	// Hand the diff to the model the same way read_image does.
+	pathInput, _ := json.Marshal(readImageInput{Path: diffPath})
+	out := b.readImageRun(ctx, pathInput)
+	if out.Error != nil {
+		return out
+	}
+	out.LLMContent[0].Text = summary
+	return out
+}
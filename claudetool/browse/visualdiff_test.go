@@ -0,0 +1,160 @@
+package browse
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func solidImage(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func writePNG(t *testing.T, img image.Image) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "img.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiffImages(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+
+	t.Run("identical", func(t *testing.T) {
+		_, res := diffImages(solidImage(10, 10, white), solidImage(10, 10, white), 0)
+		if res.Changed != 0 || !res.Bounds.Empty() {
+			t.Errorf("expected no changes, got %+v", res)
+		}
+	})
+
+	t.Run("changed region", func(t *testing.T) {
+		after := solidImage(10, 10, white)
+		for y := 2; y < 4; y++ {
+			for x := 5; x < 8; x++ {
+				after.Set(x, y, color.RGBA{0, 0, 0, 255})
+			}
+		}
+		diff, res := diffImages(solidImage(10, 10, white), after, 0)
+		if res.Changed != 6 {
+			t.Errorf("changed = %d, want 6", res.Changed)
+		}
+		if want := image.Rect(5, 2, 8, 4); res.Bounds != want {
+			t.Errorf("bounds = %v, want %v", res.Bounds, want)
+		}
+		if got := res.MismatchPercent(); got != 6 {
+			t.Errorf("mismatch = %v%%, want 6%%", got)
+		}
+		if got := diff.RGBAAt(6, 3); got != diffChangedColor {
+			t.Errorf("changed pixel color = %v, want %v", got, diffChangedColor)
+		}
+	})
+
+	t.Run("tolerance", func(t *testing.T) {
+		_, res := diffImages(solidImage(4, 4, white), solidImage(4, 4, color.RGBA{250, 250, 250, 255}), 10)
+		if res.Changed != 0 {
+			t.Errorf("expected differences within tolerance to be ignored, got %d", res.Changed)
+		}
+		_, res = diffImages(solidImage(4, 4, white), solidImage(4, 4, color.RGBA{250, 250, 250, 255}), 2)
+		if res.Changed != 16 {
+			t.Errorf("expected all pixels changed beyond tolerance, got %d", res.Changed)
+		}
+	})
+
+	t.Run("size mismatch", func(t *testing.T) {
+		_, res := diffImages(solidImage(10, 10, white), solidImage(10, 12, white), 0)
+		if res.Width != 10 || res.Height != 12 {
+			t.Errorf("compared area = %dx%d, want 10x12", res.Width, res.Height)
+		}
+		if res.Changed != 20 {
+			t.Errorf("changed = %d, want 20 extra pixels", res.Changed)
+		}
+	})
+}
+
+func TestResolveImageRef(t *testing.T) {
+	tools := NewBrowseTools(context.Background(), 0, 0)
+	id := tools.SaveScreenshot([]byte("not really a png"))
+	if id == "" {
+		t.Skip("cannot write to screenshot directory")
+	}
+	defer os.Remove(GetScreenshotPath(id))
+
+	if got, err := resolveImageRef(id); err != nil || got != GetScreenshotPath(id) {
+		t.Errorf("resolveImageRef(id) = %q, %v", got, err)
+	}
+	for _, ref := range []string{"", "no-such-id", "relative/path.png", "/no/such/file.png"} {
+		if _, err := resolveImageRef(ref); err == nil {
+			t.Errorf("resolveImageRef(%q) should fail", ref)
+		}
+	}
+}
+
+func TestVisualDiffAction(t *testing.T) {
+	tools := NewBrowseTools(context.Background(), 0, 0)
+	t.Cleanup(func() {
+		tools.Close()
+	})
+	tool := tools.CombinedTool()
+	ctx := context.Background()
+
+	white := color.RGBA{255, 255, 255, 255}
+	baseline := writePNG(t, solidImage(20, 20, white))
+	same := writePNG(t, solidImage(20, 20, white))
+	changed := solidImage(20, 20, white)
+	changed.Set(3, 4, color.RGBA{0, 0, 255, 255})
+	changedPath := writePNG(t, changed)
+
+	out := tool.Run(ctx, []byte(fmt.Sprintf(`{"action": "visual_diff", "before": %q, "after": %q}`, baseline, same)))
+	if out.Error != nil {
+		t.Fatalf("visual_diff: %v", out.Error)
+	}
+	if len(out.LLMContent) != 1 || !strings.Contains(out.LLMContent[0].Text, "No visual differences") {
+		t.Errorf("expected no differences, got %v", out.LLMContent)
+	}
+
+	out = tool.Run(ctx, []byte(fmt.Sprintf(`{"action": "visual_diff", "before": %q, "after": %q}`, baseline, changedPath)))
+	if out.Error != nil {
+		t.Fatalf("visual_diff: %v", out.Error)
+	}
+	if len(out.LLMContent) != 2 {
+		t.Fatalf("expected text and image content, got %d items", len(out.LLMContent))
+	}
+	text := out.LLMContent[0].Text
+	for _, want := range []string{"Mismatch: 0.250% (1 of 400 pixels)", "x=3 y=4 width=1 height=1", ScreenshotDir} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+	if out.LLMContent[1].MediaType != "image/png" || out.LLMContent[1].Data == "" {
+		t.Errorf("expected a PNG diff image, got media type %q", out.LLMContent[1].MediaType)
+	}
+
+	for _, in := range []string{
+		`{"action": "visual_diff", "after": "x"}`,
+		fmt.Sprintf(`{"action": "visual_diff", "before": %q, "after": "missing-id"}`, baseline),
+		fmt.Sprintf(`{"action": "visual_diff", "before": %q, "after": %q, "tolerance": 300}`, baseline, same),
+	} {
+		if out := tool.Run(ctx, []byte(in)); out.Error == nil {
+			t.Errorf("expected error for %s", in)
+		}
+	}
+}
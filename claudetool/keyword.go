@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"shelley.exe.dev/gitstate"
 	"shelley.exe.dev/llm"
 )
 
@@ -95,8 +96,7 @@ var keywordSystemPrompt string
 
 // FindRepoRoot attempts to find the git repository root from the current directory
 func FindRepoRoot(wd string) (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	cmd.Dir = wd
+	cmd := gitstate.Command(wd, "rev-parse", "--show-toplevel")
 	out, err := cmd.Output()
 	// todo: cwd here and throughout
 	if err != nil {
@@ -34,7 +34,10 @@ Unlike subagents, this is a single request/response with no conversation history
 Use this for simple LLM tasks like summarization, extraction, classification, or reformatting.
 
 The prompt is read from a file (to handle large inputs cleanly).
-Short results are returned inline; long results are written to a file.`
+Short results are returned inline; long results are written to a file.
+
+For structured extraction, pass a JSON schema as "response_schema". The model is then
+constrained to reply with a single JSON object matching the schema, and the reply is validated.`
 
 	if len(t.AvailableModels) > 0 {
 		base += "\n\nAvailable models (use the \"model\" parameter to override the default):"
@@ -81,16 +84,21 @@ func (t *LLMOneShotTool) llmOneShotInputSchema() string {
     "system_prompt": {
       "type": "string",
       "description": "Optional system prompt to include."
+    },
+    "response_schema": {
+      "type": "object",
+      "description": "Optional JSON schema (with type \"object\" and \"properties\") that the response must conform to. The result is returned as JSON."
     }%s
   }
 }`, modelProp)
 }
 
 type llmOneShotInput struct {
-	PromptFile   string `json:"prompt_file"`
-	OutputFile   string `json:"output_file,omitempty"`
-	Model        string `json:"model,omitempty"`
-	SystemPrompt string `json:"system_prompt,omitempty"`
+	PromptFile     string          `json:"prompt_file"`
+	OutputFile     string          `json:"output_file,omitempty"`
+	Model          string          `json:"model,omitempty"`
+	SystemPrompt   string          `json:"system_prompt,omitempty"`
+	ResponseSchema json.RawMessage `json:"response_schema,omitempty"`
 }
 
 // Tool returns an llm.Tool for the LLM one-shot functionality.
@@ -112,6 +120,11 @@ func (t *LLMOneShotTool) Run(ctx context.Context, m json.RawMessage) llm.ToolOut
 	if req.PromptFile == "" {
 		return llm.ErrorfToolOut("prompt_file is required")
 	}
+	if len(req.ResponseSchema) > 0 {
+		if err := llm.CheckSchema(req.ResponseSchema); err != nil {
+			return llm.ErrorfToolOut("invalid response_schema: %w", err)
+		}
+	}
 
 	// Resolve paths relative to working directory
 	wd := t.WorkingDir.Get()
@@ -174,20 +187,23 @@ func (t *LLMOneShotTool) Run(ctx context.Context, m json.RawMessage) llm.ToolOut
 		llmReq.System = []llm.SystemContent{{Text: req.SystemPrompt}}
 	}
 
-	// Send the request
-	resp, err := svc.Do(ctx, llmReq)
-	if err != nil {
-		return llm.ErrorfToolOut("LLM request failed: %w", err)
-	}
-
-	// Extract text from the response
-	var result strings.Builder
-	for _, content := range resp.Content {
-		if content.Type == llm.ContentTypeText {
-			result.WriteString(content.Text)
+	var resp *llm.Response
+	var resultText string
+	if len(req.ResponseSchema) > 0 {
+		llmReq.ResponseSchema = &llm.ResponseSchema{Schema: req.ResponseSchema}
+		var out json.RawMessage
+		out, resp, err = llm.DoJSON[json.RawMessage](ctx, svc, llmReq)
+		if err != nil {
+			return llm.ErrorfToolOut("LLM request failed: %w", err)
+		}
+		resultText = string(out)
+	} else {
+		resp, err = svc.Do(ctx, llmReq)
+		if err != nil {
+			return llm.ErrorfToolOut("LLM request failed: %w", err)
 		}
+		resultText = llm.ResponseText(resp)
 	}
-	resultText := result.String()
 
 	// Determine where to put the result
 	outputPath := req.OutputFile
@@ -379,3 +379,87 @@ func TestLLMOneShotSystemPrompt(t *testing.T) {
 		t.Errorf("expected system prompt, got: %+v", capturedReq.System)
 	}
 }
+
+func TestLLMOneShotResponseSchema(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "prompt.txt"), []byte("Extract the name."), 0o644)
+
+	schema := json.RawMessage(`{"type":"object","required":["name"],"properties":{"name":{"type":"string"}}}`)
+	var gotReq *llm.Request
+	provider := &oneShotMockProvider{
+		services: map[string]llm.Service{
+			"test-model": &oneShotMockService{
+				response: "```json\n{\"name\":\"Ada\"}\n```",
+				onDo:     func(r *llm.Request) { gotReq = r },
+			},
+		},
+	}
+
+	tool := &LLMOneShotTool{
+		LLMProvider:     provider,
+		ModelID:         "test-model",
+		WorkingDir:      NewMutableWorkingDir(dir),
+		AvailableModels: []AvailableModel{{ID: "test-model"}},
+	}
+
+	input, _ := json.Marshal(llmOneShotInput{PromptFile: "prompt.txt", ResponseSchema: schema})
+	result := tool.Run(context.Background(), input)
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if gotReq.ResponseSchema == nil || string(gotReq.ResponseSchema.Schema) != string(schema) {
+		t.Errorf("expected response schema to be passed to the LLM, got: %+v", gotReq.ResponseSchema)
+	}
+	text := result.LLMContent[0].Text
+	if !strings.HasPrefix(text, `{"name":"Ada"}`) {
+		t.Errorf("expected result to start with the unfenced JSON, got: %s", text)
+	}
+}
+
+func TestLLMOneShotResponseSchemaMismatch(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "prompt.txt"), []byte("Extract the name."), 0o644)
+
+	provider := &oneShotMockProvider{
+		services: map[string]llm.Service{
+			"test-model": &oneShotMockService{response: `{"title":"Ada"}`},
+		},
+	}
+
+	tool := &LLMOneShotTool{
+		LLMProvider:     provider,
+		ModelID:         "test-model",
+		WorkingDir:      NewMutableWorkingDir(dir),
+		AvailableModels: []AvailableModel{{ID: "test-model"}},
+	}
+
+	schema := json.RawMessage(`{"type":"object","required":["name"],"properties":{"name":{"type":"string"}}}`)
+	input, _ := json.Marshal(llmOneShotInput{PromptFile: "prompt.txt", ResponseSchema: schema})
+	result := tool.Run(context.Background(), input)
+
+	if result.Error == nil {
+		t.Fatal("expected error for reply that does not match the schema")
+	}
+	if !strings.Contains(result.Error.Error(), "missing required property") {
+		t.Errorf("expected schema validation error, got: %v", result.Error)
+	}
+}
+
+func TestLLMOneShotInvalidResponseSchema(t *testing.T) {
+	tool := &LLMOneShotTool{
+		LLMProvider: &oneShotMockProvider{},
+		ModelID:     "test-model",
+		WorkingDir:  NewMutableWorkingDir(t.TempDir()),
+	}
+
+	input, _ := json.Marshal(llmOneShotInput{PromptFile: "prompt.txt", ResponseSchema: json.RawMessage(`{"type":"string"}`)})
+	result := tool.Run(context.Background(), input)
+
+	if result.Error == nil {
+		t.Fatal("expected error for invalid response_schema")
+	}
+	if !strings.Contains(result.Error.Error(), "invalid response_schema") {
+		t.Errorf("expected invalid response_schema error, got: %v", result.Error)
+	}
+}
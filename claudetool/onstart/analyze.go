@@ -9,12 +9,13 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
 
 	"golang.org/x/sync/errgroup"
+
+	"shelley.exe.dev/gitstate"
 )
 
 // Codebase contains metadata about the codebase.
@@ -42,8 +43,7 @@ func AnalyzeCodebase(ctx context.Context, repoPath string) (*Codebase, error) {
 	// TODO: do a filesystem walk instead?
 	// There's a balance: git ls-files skips node_modules etc,
 	// but some guidance files might be locally .gitignored.
-	cmd := exec.Command("git", "ls-files", "-z")
-	cmd.Dir = repoPath
+	cmd := gitstate.Command(repoPath, "ls-files", "-z")
 
 	r, w := io.Pipe() // stream and scan rather than buffer
 	cmd.Stdout = w
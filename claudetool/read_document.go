@@ -0,0 +1,106 @@
+package claudetool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/llm/docutil"
+)
+
+// ReadDocumentTool attaches a PDF or text document to the conversation.
+// Providers with native document support receive the document itself;
+// others receive its extracted text.
+type ReadDocumentTool struct {
+	// WorkingDir is the shared mutable working directory.
+	WorkingDir *MutableWorkingDir
+}
+
+// readDocumentMaxSize matches the upload size limit.
+const readDocumentMaxSize = 10 * 1024 * 1024
+
+const (
+	readDocumentName        = "read_document"
+	readDocumentDescription = `Read a PDF or plain text document (such as an uploaded design spec or datasheet) and attach it for you to read.
+
+Use this for files the user uploads or references that are PDFs; you see the document's pages directly where the model supports it,
+and its extracted text otherwise. For source code and other text files, prefer reading them with bash.`
+	readDocumentInputSchema = `{
+  "type": "object",
+  "required": ["path"],
+  "properties": {
+    "path": {
+      "type": "string",
+      "description": "Path to the document. Relative paths are resolved from the working directory."
+    }
+  }
+}`
+)
+
+type readDocumentInput struct {
+	Path string `json:"path"`
+}
+
+// Tool returns an llm.Tool for reading documents.
+func (t *ReadDocumentTool) Tool() *llm.Tool {
+	return &llm.Tool{
+		Name:        readDocumentName,
+		Description: readDocumentDescription,
+		InputSchema: llm.MustSchema(readDocumentInputSchema),
+		Run:         t.Run,
+	}
+}
+
+// Run executes the read_document tool.
+func (t *ReadDocumentTool) Run(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var req readDocumentInput
+	if err := json.Unmarshal(m, &req); err != nil {
+		return llm.ErrorfToolOut("failed to parse read_document input: %w", err)
+	}
+	if req.Path == "" {
+		return llm.ErrorfToolOut("path is required")
+	}
+
+	path := req.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(t.WorkingDir.Get(), path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return llm.ErrorfToolOut("document not found: %s", path)
+		}
+		return llm.ErrorfToolOut("failed to stat document: %w", err)
+	}
+	if info.IsDir() {
+		return llm.ErrorfToolOut("path is a directory: %s", path)
+	}
+	if info.Size() > readDocumentMaxSize {
+		return llm.ErrorfToolOut("document is too large (%d bytes, max %d)", info.Size(), readDocumentMaxSize)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return llm.ErrorfToolOut("failed to read document: %w", err)
+	}
+
+	var mediaType string
+	switch {
+	case docutil.IsPDF(data):
+		mediaType = llm.MediaTypePDF
+	case utf8.Valid(data):
+		mediaType = llm.MediaTypePlainText
+	default:
+		return llm.ErrorfToolOut("unsupported document type: only PDF and UTF-8 text documents can be read")
+	}
+
+	return llm.ToolOut{LLMContent: []llm.Content{
+		llm.StringContent(fmt.Sprintf("Document from %s (type: %s, %d bytes)", path, mediaType, len(data))),
+		llm.DocumentContent(mediaType, filepath.Base(path), data),
+	}}
+}
@@ -0,0 +1,63 @@
+package claudetool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shelley.exe.dev/llm"
+)
+
+func TestReadDocumentTool(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "spec.pdf"), []byte("%PDF-1.4\n%%EOF\n"), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("hello"), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "blob.bin"), []byte{0xff, 0xfe, 0x00, 0x81}, 0o644)
+
+	tool := &ReadDocumentTool{WorkingDir: NewMutableWorkingDir(tmpDir)}
+
+	t.Run("pdf", func(t *testing.T) {
+		input, _ := json.Marshal(readDocumentInput{Path: "spec.pdf"})
+		result := tool.Run(context.Background(), input)
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+		if len(result.LLMContent) != 2 {
+			t.Fatalf("expected description and document, got %d contents", len(result.LLMContent))
+		}
+		doc := result.LLMContent[1]
+		if !doc.IsDocument() || doc.MediaType != llm.MediaTypePDF || doc.Title != "spec.pdf" {
+			t.Errorf("expected PDF document titled spec.pdf, got %+v", doc)
+		}
+	})
+
+	t.Run("plain text", func(t *testing.T) {
+		input, _ := json.Marshal(readDocumentInput{Path: filepath.Join(tmpDir, "notes.txt")})
+		result := tool.Run(context.Background(), input)
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+		if got := result.LLMContent[1].MediaType; got != llm.MediaTypePlainText {
+			t.Errorf("expected media type %q, got %q", llm.MediaTypePlainText, got)
+		}
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		input, _ := json.Marshal(readDocumentInput{Path: "blob.bin"})
+		result := tool.Run(context.Background(), input)
+		if result.Error == nil || !strings.Contains(result.Error.Error(), "unsupported document type") {
+			t.Errorf("expected unsupported document type error, got %v", result.Error)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		input, _ := json.Marshal(readDocumentInput{Path: "nope.pdf"})
+		result := tool.Run(context.Background(), input)
+		if result.Error == nil || !strings.Contains(result.Error.Error(), "document not found") {
+			t.Errorf("expected not found error, got %v", result.Error)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cgroup is a cgroup v2 created for one command.
+type cgroup struct {
+	dir string
+	fd  int
+}
+
+var cgroupSeq atomic.Int64
+
+// cgroupParent finds a cgroup the server may create children in, with the
+// memory and pids controllers enabled for them: its own cgroup or, as under a
+// systemd user session, the cgroup containing it.
+var cgroupParent = sync.OnceValues(func() (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return "", errors.New("cgroups v2 is not mounted")
+	}
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	var own string
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			own = filepath.Join(cgroupRoot, path)
+		}
+	}
+	if own == "" {
+		return "", errors.New("not in a cgroup v2 hierarchy")
+	}
+	for _, dir := range []string{own, filepath.Dir(own)} {
+		controllers, err := os.ReadFile(filepath.Join(dir, "cgroup.subtree_control"))
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(string(controllers))
+		if slices.Contains(fields, "memory") && slices.Contains(fields, "pids") && syscall.Access(dir, 2 /* W_OK */) == nil {
+			return dir, nil
+		}
+	}
+	return "", errors.New("no delegated cgroup with the memory and pids controllers")
+})
+
+// newCgroup creates a cgroup with the limits in s.
+func newCgroup(s Settings) (*cgroup, error) {
+	parent, err := cgroupParent()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(parent, fmt.Sprintf("shelley-bash-%d-%d", os.Getpid(), cgroupSeq.Add(1)))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, err
+	}
+	cg := &cgroup{dir: dir, fd: -1}
+	limits := map[string]string{}
+	if s.MemoryMB > 0 {
+		limits["memory.max"] = strconv.FormatInt(int64(s.MemoryMB)<<20, 10)
+		limits["memory.swap.max"] = "0"
+	}
+	if s.MaxProcesses > 0 {
+		limits["pids.max"] = strconv.Itoa(s.MaxProcesses)
+	}
+	for file, value := range limits {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0o644); err != nil && file != "memory.swap.max" {
+			cg.remove()
+			return nil, err
+		}
+	}
+	cg.fd, err = syscall.Open(dir, syscall.O_DIRECTORY|syscall.O_RDONLY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		cg.remove()
+		return nil, err
+	}
+	return cg, nil
+}
+
+// remove kills anything left in the cgroup and deletes it.
+func (cg *cgroup) remove() {
+	if cg.fd >= 0 {
+		syscall.Close(cg.fd)
+		cg.fd = -1
+	}
+	os.WriteFile(filepath.Join(cg.dir, "cgroup.kill"), []byte("1"), 0o644)
+	// Killed processes take a moment to leave the cgroup.
+	for range 50 {
+		if err := os.Remove(cg.dir); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
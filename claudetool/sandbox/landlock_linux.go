@@ -0,0 +1,195 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// Landlock system calls and flags, from linux/landlock.h. The system call
+// numbers are the same on every architecture.
+const (
+	sysLandlockCreateRuleset = 444
+	sysLandlockAddRule       = 445
+	sysLandlockRestrictSelf  = 446
+
+	landlockCreateRulesetVersion = 1 << 0
+	landlockRulePathBeneath      = 1
+	landlockRuleNetPort          = 2
+
+	accessFSWriteFile  = 1 << 1
+	accessFSRemoveDir  = 1 << 4
+	accessFSRemoveFile = 1 << 5
+	accessFSMakeChar   = 1 << 6
+	accessFSMakeDir    = 1 << 7
+	accessFSMakeReg    = 1 << 8
+	accessFSMakeSock   = 1 << 9
+	accessFSMakeFifo   = 1 << 10
+	accessFSMakeBlock  = 1 << 11
+	accessFSMakeSym    = 1 << 12
+	accessFSRefer      = 1 << 13 // ABI 2
+	accessFSTruncate   = 1 << 14 // ABI 3
+
+	accessNetBindTCP    = 1 << 0 // ABI 4
+	accessNetConnectTCP = 1 << 1 // ABI 4
+
+	prSetNoNewPrivs      = 38
+	prCapAmbient         = 47
+	prCapAmbientClearAll = 4
+	capSysAdmin          = 21
+	// oPath is O_PATH, which package syscall lacks. This is its value on
+	// the common architectures.
+	oPath = 0o10000000
+)
+
+type landlockRulesetAttr struct {
+	handledAccessFS  uint64
+	handledAccessNet uint64
+}
+
+// landlockPathBeneathAttr is packed in the kernel, so only its first 12
+// bytes are read.
+type landlockPathBeneathAttr struct {
+	allowedAccess uint64
+	parentFd      int32
+}
+
+type landlockNetPortAttr struct {
+	allowedAccess uint64
+	port          uint64
+}
+
+// landlockABI returns the Landlock ABI version the kernel supports, or 0.
+func landlockABI() int {
+	v, _, errno := syscall.Syscall(sysLandlockCreateRuleset, 0, 0, landlockCreateRulesetVersion)
+	if errno != 0 {
+		return 0
+	}
+	return int(v)
+}
+
+// restrictSelf confines the calling thread, and whatever it execs, to
+// writing beneath p.Writable, and keeps it from TCP or, through
+// p.PortRuleset, from the server's ports. Reading is not restricted.
+func restrictSelf(p policy) error {
+	abi := landlockABI()
+	if abi < 1 {
+		return fmt.Errorf("Landlock is not enabled in this kernel")
+	}
+	write := uint64(accessFSWriteFile | accessFSRemoveDir | accessFSRemoveFile | accessFSMakeChar |
+		accessFSMakeDir | accessFSMakeReg | accessFSMakeSock | accessFSMakeFifo | accessFSMakeBlock | accessFSMakeSym)
+	fileWrite := uint64(accessFSWriteFile)
+	if abi >= 2 {
+		write |= accessFSRefer
+	}
+	if abi >= 3 {
+		write |= accessFSTruncate
+		fileWrite |= accessFSTruncate
+	}
+
+	attr := landlockRulesetAttr{handledAccessFS: write}
+	size := unsafe.Sizeof(attr.handledAccessFS)
+	if p.BlockTCP {
+		attr.handledAccessNet = accessNetBindTCP | accessNetConnectTCP
+		size = unsafe.Sizeof(attr)
+	}
+	fd, _, errno := syscall.Syscall(sysLandlockCreateRuleset, uintptr(unsafe.Pointer(&attr)), size, 0)
+	if errno != 0 {
+		return fmt.Errorf("create Landlock ruleset: %w", errno)
+	}
+	defer syscall.Close(int(fd))
+
+	for _, dir := range p.Writable {
+		if err := addPathRule(int(fd), dir, write, fileWrite); err != nil {
+			return err
+		}
+	}
+	// Commands need to write to /dev/null and the like, but not create
+	// devices.
+	if !slices.Contains(p.Writable, "/dev") {
+		if err := addPathRule(int(fd), "/dev", fileWrite, fileWrite); err != nil {
+			return err
+		}
+	}
+
+	if _, _, errno := syscall.Syscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+		return fmt.Errorf("set no_new_privs: %w", errno)
+	}
+	if _, _, errno := syscall.Syscall(sysLandlockRestrictSelf, fd, 0, 0); errno != 0 {
+		return fmt.Errorf("enforce Landlock ruleset: %w", errno)
+	}
+	// The port ruleset is a second layer, which further restricts the
+	// first.
+	if p.PortRuleset > 0 {
+		defer syscall.Close(p.PortRuleset)
+		if _, _, errno := syscall.Syscall(sysLandlockRestrictSelf, uintptr(p.PortRuleset), 0, 0); errno != 0 {
+			return fmt.Errorf("enforce Landlock port ruleset: %w", errno)
+		}
+	}
+	return nil
+}
+
+var (
+	portRulesetsMu sync.Mutex
+	portRulesets   = make(map[string]*os.File)
+)
+
+// portRuleset returns a Landlock ruleset that allows connecting to any TCP
+// port but those in deny. Landlock rules name single ports rather than
+// ranges, so the ruleset takes a system call for each of the other 65,534
+// or so ports. It is built once for each set of ports, in the server, and
+// passed to every helper to enforce.
+func portRuleset(deny []int) (*os.File, error) {
+	key := fmt.Sprint(deny)
+	portRulesetsMu.Lock()
+	defer portRulesetsMu.Unlock()
+	if f, ok := portRulesets[key]; ok {
+		return f, nil
+	}
+
+	attr := landlockRulesetAttr{handledAccessNet: accessNetConnectTCP}
+	fd, _, errno := syscall.Syscall(sysLandlockCreateRuleset, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return nil, fmt.Errorf("create Landlock port ruleset: %w", errno)
+	}
+	f := os.NewFile(fd, "landlock-ports")
+	for port := 1; port <= 65535; port++ {
+		if slices.Contains(deny, port) {
+			continue
+		}
+		rule := landlockNetPortAttr{allowedAccess: accessNetConnectTCP, port: uint64(port)}
+		if _, _, errno := syscall.Syscall6(sysLandlockAddRule, fd, landlockRuleNetPort, uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+			f.Close()
+			return nil, fmt.Errorf("allow connecting to port %d: %w", port, errno)
+		}
+	}
+	portRulesets[key] = f
+	return f, nil
+}
+
+// addPathRule allows access beneath path. Files only take the file rights.
+func addPathRule(rulesetFd int, path string, access, fileAccess uint64) error {
+	fd, err := syscall.Open(path, oPath|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer syscall.Close(fd)
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fd, &st); err != nil {
+		return fmt.Errorf("stat %s: %w", path, err)
+	}
+	if st.Mode&syscall.S_IFMT != syscall.S_IFDIR {
+		access = fileAccess
+	}
+	rule := landlockPathBeneathAttr{allowedAccess: access, parentFd: int32(fd)}
+	if _, _, errno := syscall.Syscall6(sysLandlockAddRule, uintptr(rulesetFd), landlockRulePathBeneath, uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("allow writes to %s: %w", path, errno)
+	}
+	return nil
+}
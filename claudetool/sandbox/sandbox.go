@@ -0,0 +1,189 @@
+// Package sandbox confines the shell commands run by the bash tool using
+// kernel features open to unprivileged users: Landlock limits where commands
+// may write, user and network namespaces cut them off from the network, and
+// cgroups v2 (or rlimits, where no cgroup is delegated) bound their resources.
+//
+// Confinement is applied in a helper process: Apply rewrites a command to run
+// the current executable, which this package's init function turns into the
+// helper, restricting itself before it execs the real command. Any binary
+// that runs sandboxed commands must therefore link this package.
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"shelley.exe.dev/gitstate"
+)
+
+// Settings configure the sandbox for a conversation's bash commands.
+type Settings struct {
+	Enabled bool `json:"enabled"`
+	// BlockNetwork runs commands without network access.
+	BlockNetwork bool `json:"block_network,omitempty"`
+	// WritablePaths are directories commands may write to in addition to
+	// the repository root, the temp directories and the user's standard
+	// caches (see WritableRoots).
+	WritablePaths []string `json:"writable_paths,omitempty"`
+	// CPUSeconds limits the CPU time of each process.
+	CPUSeconds int `json:"cpu_seconds,omitempty"`
+	// MemoryMB limits the memory of a command and its children.
+	MemoryMB int `json:"memory_mb,omitempty"`
+	// MaxProcesses limits the number of processes a command may run at once.
+	MaxProcesses int `json:"max_processes,omitempty"`
+}
+
+// Validate checks that the settings make sense.
+func (s Settings) Validate() error {
+	if s.CPUSeconds < 0 || s.MemoryMB < 0 || s.MaxProcesses < 0 {
+		return errors.New("sandbox limits must not be negative")
+	}
+	for _, p := range s.WritablePaths {
+		if !filepath.IsAbs(p) {
+			return fmt.Errorf("sandbox writable path %q is not absolute", p)
+		}
+	}
+	return nil
+}
+
+// Report describes how a command was confined. It is shown with the
+// command's output.
+type Report struct {
+	// Filesystem names the mechanism confining writes, e.g. "landlock v4".
+	Filesystem string `json:"filesystem"`
+	// Writable lists the directories the command could write to.
+	Writable []string `json:"writable"`
+	// Network is "allowed", "blocked", or "tcp blocked".
+	Network string `json:"network"`
+	// Limits describes the resource limits applied.
+	Limits []string `json:"limits,omitempty"`
+	// Warnings describes requested restrictions that could not be applied
+	// as asked.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ErrUnsupported is returned by Apply when the system cannot confine writes,
+// in which case commands are refused rather than run unconfined.
+var ErrUnsupported = errors.New("sandbox is not supported on this system")
+
+var (
+	protectedMu      sync.Mutex
+	protectedSockets []string
+	protectedPorts   []int
+	protectedFiles   []string
+)
+
+// ProtectServer keeps sandboxed commands away from the server running them,
+// which listens on the Unix socket socketPath and the TCP port, either of
+// which may be empty or 0, and keeps its database and secrets in files. The
+// socket trusts its clients and authentication is often off, so a command
+// that reached the server could turn its own sandbox off or open an
+// unconfined shell; one that could rewrite the database could do the same
+// through its settings, and the master key decrypts the stored API keys.
+// Apply hides the socket and the files in a mount namespace and denies
+// connecting to the port, and refuses commands when it cannot.
+func ProtectServer(socketPath string, port int, files ...string) {
+	protectedMu.Lock()
+	defer protectedMu.Unlock()
+	if socketPath != "" {
+		protectedSockets = append(protectedSockets, absPath(socketPath))
+	}
+	if port > 0 {
+		protectedPorts = append(protectedPorts, port)
+	}
+	for _, f := range files {
+		protectedFiles = append(protectedFiles, absPath(f))
+	}
+}
+
+// absPath makes path absolute, since commands run in other directories.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// protected returns the sockets, ports and files passed to ProtectServer.
+func protected() (sockets []string, ports []int, files []string) {
+	protectedMu.Lock()
+	defer protectedMu.Unlock()
+	return slices.Clone(protectedSockets), slices.Clone(protectedPorts), slices.Clone(protectedFiles)
+}
+
+// beneath reports whether path is one of dirs or inside one of them.
+func beneath(path string, dirs []string) bool {
+	return slices.ContainsFunc(dirs, func(dir string) bool {
+		rel, err := filepath.Rel(dir, path)
+		return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+	})
+}
+
+// WritableRoots returns the directories a sandboxed command run in dir may
+// write to: the git repository containing dir (including a worktree's shared
+// git directory) or dir itself outside a repository, the temp directories,
+// the user's cache directory (~/.cache, holding go build's cache among
+// others), Go's module cache and npm's cache, and extra. The caches are
+// shared with the user's unsandboxed builds, so commands can affect what
+// those use; list no others in extra where that matters. Commands can so
+// change the repository's configuration and hooks, which is why Shelley runs
+// its own git commands with gitstate.Command.
+func WritableRoots(dir string, extra []string) []string {
+	roots := []string{dir}
+	out, err := gitstate.Command(dir, "rev-parse", "--path-format=absolute", "--show-toplevel", "--git-common-dir").Output()
+	if err == nil {
+		if lines := strings.Fields(string(out)); len(lines) == 2 {
+			roots = lines
+		}
+	}
+	roots = append(roots, os.TempDir(), "/tmp", "/var/tmp", "/dev/shm")
+	roots = append(roots, cacheDirs()...)
+	roots = append(roots, extra...)
+
+	var unique []string
+	seen := make(map[string]bool)
+	for _, r := range roots {
+		r = filepath.Clean(r)
+		if !seen[r] {
+			seen[r] = true
+			unique = append(unique, r)
+		}
+	}
+	return unique
+}
+
+// cacheDirs returns the caches that build tools write to beneath the home
+// directory, honoring the variables that move them.
+func cacheDirs() []string {
+	var dirs []string
+	if dir, err := os.UserCacheDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	if dir := os.Getenv("GOCACHE"); filepath.IsAbs(dir) {
+		dirs = append(dirs, dir)
+	}
+	home, _ := os.UserHomeDir()
+	modCache := os.Getenv("GOMODCACHE")
+	if modCache == "" {
+		if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+			modCache = filepath.Join(gopath[0], "pkg", "mod")
+		} else if home != "" {
+			modCache = filepath.Join(home, "go", "pkg", "mod")
+		}
+	}
+	npmCache := os.Getenv("npm_config_cache")
+	if npmCache == "" && home != "" {
+		npmCache = filepath.Join(home, ".npm")
+	}
+	for _, dir := range []string{modCache, npmCache} {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
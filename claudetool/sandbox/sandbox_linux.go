@@ -0,0 +1,247 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"syscall"
+)
+
+// policyEnv carries the helper's policy. Its presence turns a process started
+// from the current executable into the helper.
+const policyEnv = "SHELLEY_SANDBOX_POLICY"
+
+// policy is what the helper applies to itself before exec'ing the command.
+type policy struct {
+	Writable     []string `json:"writable"`
+	CPUSeconds   uint64   `json:"cpu_seconds,omitempty"`
+	AddressSpace uint64   `json:"address_space,omitempty"`
+	BlockTCP     bool     `json:"block_tcp,omitempty"`
+	// Hide lists files to cover with /dev/null, in a mount namespace of the
+	// helper's own.
+	Hide []string `json:"hide,omitempty"`
+	// PortRuleset is the descriptor of an inherited Landlock ruleset,
+	// from portRuleset, that keeps the command from the server's TCP ports.
+	PortRuleset int `json:"port_ruleset,omitempty"`
+}
+
+func init() {
+	if encoded, ok := os.LookupEnv(policyEnv); ok {
+		runHelper(encoded)
+	}
+}
+
+// runHelper confines the current process according to the encoded policy and
+// execs os.Args[1] with arguments os.Args[2:]. It does not return.
+func runHelper(encoded string) {
+	// Landlock and no_new_privs apply to the calling thread, which must be
+	// the one that execs.
+	runtime.LockOSThread()
+	os.Unsetenv(policyEnv)
+	err := enterSandbox(encoded)
+	fmt.Fprintf(os.Stderr, "shelley sandbox: %v\n", err)
+	os.Exit(126)
+}
+
+func enterSandbox(encoded string) error {
+	var p policy
+	if err := json.Unmarshal([]byte(encoded), &p); err != nil {
+		return fmt.Errorf("invalid policy: %w", err)
+	}
+	if len(os.Args) < 3 {
+		return errors.New("no command to run")
+	}
+	if p.CPUSeconds > 0 {
+		if err := syscall.Setrlimit(syscall.RLIMIT_CPU, &syscall.Rlimit{Cur: p.CPUSeconds, Max: p.CPUSeconds}); err != nil {
+			return fmt.Errorf("limit cpu time: %w", err)
+		}
+	}
+	if p.AddressSpace > 0 {
+		if err := syscall.Setrlimit(syscall.RLIMIT_AS, &syscall.Rlimit{Cur: p.AddressSpace, Max: p.AddressSpace}); err != nil {
+			return fmt.Errorf("limit memory: %w", err)
+		}
+	}
+	if len(p.Hide) > 0 {
+		if err := hide(p.Hide); err != nil {
+			return err
+		}
+	}
+	if err := restrictSelf(p); err != nil {
+		return err
+	}
+	return syscall.Exec(os.Args[1], os.Args[2:], os.Environ())
+}
+
+// hide bind-mounts /dev/null over paths, then drops the ambient capability
+// that allowed it. Landlock keeps the command from changing mounts.
+func hide(paths []string) error {
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	for _, path := range paths {
+		err := syscall.Mount("/dev/null", path, "", syscall.MS_BIND, "")
+		if err != nil && !errors.Is(err, syscall.ENOENT) {
+			return fmt.Errorf("hide %s: %w", path, err)
+		}
+	}
+	if _, _, errno := syscall.Syscall6(syscall.SYS_PRCTL, prCapAmbient, prCapAmbientClearAll, 0, 0, 0, 0); errno != 0 {
+		return fmt.Errorf("drop capabilities: %w", errno)
+	}
+	return nil
+}
+
+// Apply rewrites cmd, which must not have been started, to run confined by
+// s, able to write only beneath the writable directories. cleanup must be
+// called once the command has finished.
+func Apply(cmd *exec.Cmd, s Settings, writable []string) (cleanup func(), report Report, err error) {
+	abi := landlockABI()
+	if abi < 1 {
+		return nil, Report{}, fmt.Errorf("%w: Landlock is not enabled in this kernel", ErrUnsupported)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, Report{}, fmt.Errorf("find executable: %w", err)
+	}
+	if cmd.Path == "" || cmd.Err != nil {
+		return nil, Report{}, fmt.Errorf("command not found: %v", cmd.Err)
+	}
+
+	report = Report{Filesystem: fmt.Sprintf("landlock v%d", abi), Network: "allowed"}
+	var p policy
+	for _, dir := range writable {
+		if _, err := os.Stat(dir); err == nil {
+			p.Writable = append(p.Writable, dir)
+		}
+	}
+	report.Writable = p.Writable
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	attr := cmd.SysProcAttr
+	// newUserNamespace maps the user to itself, so file ownership is
+	// unchanged.
+	newUserNamespace := func(flags uintptr) {
+		attr.Cloneflags |= syscall.CLONE_NEWUSER | flags
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	}
+	sockets, ports, files := protected()
+	if s.BlockNetwork {
+		switch {
+		case userNamespacesAvailable():
+			// A new network namespace has only a loopback device, so the
+			// server's port is out of reach too.
+			newUserNamespace(syscall.CLONE_NEWNET)
+			ports = nil
+			report.Network = "blocked"
+		case abi >= 4:
+			p.BlockTCP = true
+			report.Network = "tcp blocked"
+			report.Warnings = append(report.Warnings, "user namespaces are unavailable, so only TCP is blocked")
+		default:
+			return nil, Report{}, fmt.Errorf("%w: cannot block the network without user namespaces", ErrUnsupported)
+		}
+	}
+	if len(ports) > 0 && !p.BlockTCP {
+		if abi < 4 {
+			return nil, Report{}, fmt.Errorf("%w: cannot keep commands from the server's port without Landlock v4; block the network", ErrUnsupported)
+		}
+		ruleset, err := portRuleset(ports)
+		if err != nil {
+			return nil, Report{}, err
+		}
+		p.PortRuleset = 3 + len(cmd.ExtraFiles)
+		cmd.ExtraFiles = append(cmd.ExtraFiles, ruleset)
+	}
+	for _, path := range sockets {
+		if _, err := os.Lstat(path); err == nil {
+			p.Hide = append(p.Hide, path)
+		}
+	}
+	if len(p.Hide) > 0 && !userNamespacesAvailable() {
+		return nil, Report{}, fmt.Errorf("%w: cannot hide the server's socket without user namespaces; serve with -socket none", ErrUnsupported)
+	}
+	for _, path := range files {
+		if _, err := os.Lstat(path); err != nil {
+			continue
+		}
+		switch {
+		case userNamespacesAvailable():
+			p.Hide = append(p.Hide, path)
+		case beneath(path, p.Writable):
+			return nil, Report{}, fmt.Errorf("%w: cannot hide %s, which commands could write, without user namespaces", ErrUnsupported, path)
+		default:
+			report.Warnings = append(report.Warnings, fmt.Sprintf("user namespaces are unavailable, so %s is readable", path))
+		}
+	}
+	if len(p.Hide) > 0 {
+		// The helper needs CAP_SYS_ADMIN in its user namespace to mount,
+		// and must keep it across exec.
+		newUserNamespace(syscall.CLONE_NEWNS)
+		attr.AmbientCaps = append(attr.AmbientCaps, capSysAdmin)
+	}
+
+	if s.CPUSeconds > 0 {
+		p.CPUSeconds = uint64(s.CPUSeconds)
+		report.Limits = append(report.Limits, fmt.Sprintf("cpu time %ds per process", s.CPUSeconds))
+	}
+	cleanup = func() {}
+	if s.MemoryMB > 0 || s.MaxProcesses > 0 {
+		cg, err := newCgroup(s)
+		if err == nil {
+			attr.UseCgroupFD = true
+			attr.CgroupFD = cg.fd
+			cleanup = cg.remove
+			if s.MemoryMB > 0 {
+				report.Limits = append(report.Limits, fmt.Sprintf("memory %d MB", s.MemoryMB))
+			}
+			if s.MaxProcesses > 0 {
+				report.Limits = append(report.Limits, fmt.Sprintf("%d processes", s.MaxProcesses))
+			}
+		} else {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("no cgroup for limits: %v", err))
+			if s.MemoryMB > 0 {
+				p.AddressSpace = uint64(s.MemoryMB) << 20
+				report.Limits = append(report.Limits, fmt.Sprintf("address space %d MB per process", s.MemoryMB))
+			}
+			if s.MaxProcesses > 0 {
+				report.Warnings = append(report.Warnings, "process limit not enforced")
+			}
+		}
+	}
+
+	encoded, err := json.Marshal(p)
+	if err != nil {
+		cleanup()
+		return nil, Report{}, err
+	}
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(env, policyEnv+"="+string(encoded))
+	cmd.Args = append([]string{self, cmd.Path}, cmd.Args...)
+	cmd.Path = self
+	return cleanup, report, nil
+}
+
+// userNamespacesAvailable reports whether unprivileged user namespaces can
+// be created, by trying once.
+var userNamespacesAvailable = sync.OnceValue(func() bool {
+	path, err := exec.LookPath("true")
+	if err != nil {
+		return false
+	}
+	cmd := exec.Command(path)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+	}
+	return cmd.Run() == nil
+})
@@ -0,0 +1,247 @@
+package sandbox
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"shelley.exe.dev/gitstate"
+)
+
+// run runs a bash script confined by s, allowed to write only to writable.
+func run(t *testing.T, s Settings, writable []string, script string) (string, Report, error) {
+	t.Helper()
+	if landlockABI() < 1 {
+		t.Skip("Landlock is not enabled in this kernel")
+	}
+	cmd := exec.Command("bash", "-c", script)
+	cleanup, report, err := Apply(cmd, s, writable)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	defer cleanup()
+	out, err := cmd.CombinedOutput()
+	return string(out), report, err
+}
+
+func TestWrites(t *testing.T) {
+	allowed, denied := t.TempDir(), t.TempDir()
+	out, report, err := run(t, Settings{Enabled: true}, []string{allowed},
+		"echo ok > "+allowed+"/file && mkdir "+allowed+"/dir && cat "+allowed+"/file >/dev/null && echo nope > "+denied+"/file")
+	if err == nil {
+		t.Fatalf("write outside the sandbox succeeded: %s", out)
+	}
+	if !strings.Contains(out, "Permission denied") {
+		t.Errorf("unexpected output: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(allowed, "dir")); err != nil {
+		t.Errorf("write inside the sandbox failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(denied, "file")); err == nil {
+		t.Error("file created outside the sandbox")
+	}
+	if len(report.Writable) != 1 || report.Writable[0] != allowed || report.Network != "allowed" {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestBlockNetwork(t *testing.T) {
+	if !userNamespacesAvailable() {
+		t.Skip("user namespaces are unavailable")
+	}
+	out, report, err := run(t, Settings{Enabled: true, BlockNetwork: true}, nil, "tail -n +3 /proc/net/dev | cut -d: -f1")
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	if strings.TrimSpace(out) != "lo" || report.Network != "blocked" {
+		t.Errorf("interfaces %q, report %+v", out, report)
+	}
+}
+
+// TestDialHelper dials $SANDBOX_TEST_DIAL when run as a sandboxed command
+// by TestProtectServer.
+func TestDialHelper(t *testing.T) {
+	addr := os.Getenv("SANDBOX_TEST_DIAL")
+	if addr == "" {
+		t.Skip("not run as a sandboxed command")
+	}
+	network, address, _ := strings.Cut(addr, ":")
+	conn, err := net.DialTimeout(network, address, time.Second)
+	if err != nil {
+		fmt.Println("unreachable:", err)
+		return
+	}
+	conn.Close()
+	fmt.Println("reached")
+}
+
+func TestProtectServer(t *testing.T) {
+	if landlockABI() < 4 || !userNamespacesAvailable() {
+		t.Skip("needs Landlock v4 and user namespaces")
+	}
+	socketPath := filepath.Join(t.TempDir(), "shelley.sock")
+	unixListener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unixListener.Close()
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcpListener.Close()
+	port := tcpListener.Addr().(*net.TCPAddr).Port
+	other, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	defer func(sockets []string, ports []int, files []string) {
+		protectedSockets, protectedPorts, protectedFiles = sockets, ports, files
+	}(protectedSockets, protectedPorts, protectedFiles)
+	protectedSockets, protectedPorts, protectedFiles = nil, nil, nil
+	ProtectServer(socketPath, port)
+
+	dial := func(addr string) string {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestDialHelper$")
+		cmd.Env = append(os.Environ(), "SANDBOX_TEST_DIAL="+addr)
+		cleanup, _, err := Apply(cmd, Settings{Enabled: true}, nil)
+		if err != nil {
+			t.Fatalf("Apply: %v", err)
+		}
+		defer cleanup()
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v: %s", err, out)
+		}
+		return string(out)
+	}
+	if out := dial("unix:" + socketPath); !strings.Contains(out, "unreachable") {
+		t.Errorf("sandboxed command reached the server's socket: %s", out)
+	}
+	if out := dial("tcp:" + tcpListener.Addr().String()); !strings.Contains(out, "unreachable") {
+		t.Errorf("sandboxed command reached the server's port: %s", out)
+	}
+	if out := dial("tcp:" + other.Addr().String()); !strings.Contains(out, "reached") || strings.Contains(out, "unreachable") {
+		t.Errorf("sandboxed command could not reach another port: %s", out)
+	}
+}
+
+func TestProtectServerFiles(t *testing.T) {
+	if !userNamespacesAvailable() {
+		t.Skip("user namespaces are unavailable")
+	}
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "shelley.db")
+	for _, path := range []string{dbPath, dbPath + "-wal"} {
+		if err := os.WriteFile(path, []byte("secret\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer func(sockets []string, ports []int, files []string) {
+		protectedSockets, protectedPorts, protectedFiles = sockets, ports, files
+	}(protectedSockets, protectedPorts, protectedFiles)
+	protectedSockets, protectedPorts, protectedFiles = nil, nil, nil
+	ProtectServer("", 0, dbPath, dbPath+"-wal", dbPath+"-shm")
+
+	out, _, err := run(t, Settings{Enabled: true}, []string{dir},
+		"cd "+dir+" && cat shelley.db shelley.db-wal; echo changed > shelley.db; echo changed > shelley.db-wal; rm -f shelley.db")
+	if strings.Contains(out, "secret") {
+		t.Errorf("sandboxed command read the server's files: %s (%v)", out, err)
+	}
+	for _, path := range []string{dbPath, dbPath + "-wal"} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != "secret\n" {
+			t.Errorf("%s = %q, %v after a sandboxed command; want it unchanged", path, data, err)
+		}
+	}
+}
+
+func TestPortRulesetCached(t *testing.T) {
+	if landlockABI() < 4 {
+		t.Skip("needs Landlock v4")
+	}
+	first, err := portRuleset([]int{1234})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := portRuleset([]int{1234})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("port ruleset built again for the same ports")
+	}
+}
+
+// TestGitConfig checks that a sandboxed command cannot escape by naming a
+// program in the repository's configuration for the server's git commands
+// to run.
+func TestGitConfig(t *testing.T) {
+	repo := t.TempDir()
+	marker := filepath.Join(t.TempDir(), "ran")
+	for _, args := range [][]string{
+		{"init"},
+		{"-c", "user.email=test@test.com", "-c", "user.name=Test", "commit", "--allow-empty", "-m", "initial"},
+	} {
+		if out, err := gitstate.Command(repo, args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repo, "file.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err := run(t, Settings{Enabled: true}, WritableRoots(repo, nil),
+		"cd "+repo+" && git add file.txt && git config core.fsmonitor 'touch "+marker+"; false'")
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+
+	state := gitstate.GetGitState(repo)
+	if _, err := state.ChangedFiles("HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := gitstate.Command(repo, "diff", "HEAD", "--numstat").CombinedOutput(); err != nil {
+		t.Fatalf("git diff: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("the server's git commands ran the fsmonitor set by a sandboxed command")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	out, report, err := run(t, Settings{Enabled: true, CPUSeconds: 7, MemoryMB: 512}, nil, "ulimit -t; ulimit -v")
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	lines := strings.Fields(out)
+	if len(lines) != 2 || lines[0] != "7" {
+		t.Fatalf("ulimit output %q", out)
+	}
+	// Without a delegated cgroup, memory falls back to an address space limit.
+	if _, err := cgroupParent(); err != nil && lines[1] != "524288" {
+		t.Errorf("address space limit %s, want 524288 KB", lines[1])
+	}
+	if len(report.Limits) != 2 {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Settings{Enabled: true, WritablePaths: []string{"relative"}}).Validate(); err == nil {
+		t.Error("relative writable path accepted")
+	}
+	if err := (Settings{MemoryMB: -1}).Validate(); err == nil {
+		t.Error("negative limit accepted")
+	}
+	if err := (Settings{Enabled: true, WritablePaths: []string{"/opt/cache"}, CPUSeconds: 60}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
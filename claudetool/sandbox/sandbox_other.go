@@ -0,0 +1,13 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Apply is unavailable outside Linux.
+func Apply(cmd *exec.Cmd, s Settings, writable []string) (cleanup func(), report Report, err error) {
+	return nil, Report{}, fmt.Errorf("%w: sandboxing needs Linux", ErrUnsupported)
+}
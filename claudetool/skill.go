@@ -0,0 +1,376 @@
+package claudetool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"mvdan.cc/sh/v3/syntax"
+	"shelley.exe.dev/claudetool/bashkit"
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/skills"
+)
+
+const (
+	activateSkillName        = "activate_skill"
+	activateSkillDescription = `Activate an Agent Skill, loading its full instructions into context.
+
+Call this when a task matches a skill's description in <available_skills>, before doing the work.
+If the skill declares allowed-tools, only those tools (plus this one) are available while it is active.
+Scripts bundled in the skill's scripts/ directory become callable tools while it is active.
+
+Activating another skill replaces the active one. Set deactivate to true when the skill's workflow is done
+to restore the full toolset.
+`
+	activateSkillInputSchema = `{
+  "type": "object",
+  "properties": {
+    "name": {
+      "type": "string",
+      "description": "Name of the skill to activate"
+    },
+    "deactivate": {
+      "type": "boolean",
+      "description": "Deactivate the active skill instead of activating one"
+    }
+  }
+}`
+
+	skillScriptInputSchema = `{
+  "type": "object",
+  "properties": {
+    "args": {
+      "type": "array",
+      "items": {"type": "string"},
+      "description": "Arguments passed to the script"
+    },
+    "slow_ok": {
+      "type": "boolean",
+      "description": "Use extended timeout for scripts that may take a while (builds, uploads)"
+    }
+  }
+}`
+)
+
+// toolAliases maps tool names used by other agents in allowed-tools to Shelley's tools.
+var toolAliases = map[string]string{
+	"edit":      PatchName,
+	"multiedit": PatchName,
+	"write":     PatchName,
+}
+
+// activeSkill is the skill activated with activate_skill.
+type activeSkill struct {
+	skill   skills.Skill
+	perms   []skills.ToolPermission
+	scripts []*llm.Tool
+}
+
+// allowsTool reports whether the skill's allowed-tools permits the named tool.
+// A skill without allowed-tools permits every tool.
+func (a *activeSkill) allowsTool(name string) bool {
+	if len(a.perms) == 0 || name == activateSkillName {
+		return true
+	}
+	for _, p := range a.perms {
+		if shelleyToolName(p.Tool) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the tools available while the skill is active.
+func (a *activeSkill) filter(tools []*llm.Tool) []*llm.Tool {
+	var out []*llm.Tool
+	for _, t := range tools {
+		if a.allowsTool(t.Name) {
+			out = append(out, t)
+		}
+	}
+	return append(out, a.scripts...)
+}
+
+// checkBash enforces Bash(pattern) entries of allowed-tools. Every simple
+// command in the script must match one of the patterns. Like bashkit.Check,
+// this is NOT a security barrier.
+func (a *activeSkill) checkBash(command string) error {
+	var patterns []string
+	for _, p := range a.perms {
+		if shelleyToolName(p.Tool) != bashName {
+			continue
+		}
+		if p.Pattern == "" {
+			return nil
+		}
+		patterns = append(patterns, p.Pattern)
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	cmds, err := bashkit.SimpleCommands(command)
+	if err != nil {
+		return fmt.Errorf("skill %q restricts bash commands, and this one could not be checked: %w", a.skill.Name, err)
+	}
+	for _, cmd := range cmds {
+		if !matchesAnyPattern(patterns, cmd) {
+			return fmt.Errorf("skill %q only allows bash commands matching %s; %q is not allowed (deactivate the skill to run other commands)",
+				a.skill.Name, strings.Join(patterns, ", "), cmd)
+		}
+	}
+	return nil
+}
+
+// shelleyToolName maps an allowed-tools entry to a Shelley tool name.
+func shelleyToolName(name string) string {
+	name = strings.ToLower(name)
+	if alias, ok := toolAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// matchesAnyPattern reports whether cmd matches one of the allowed-tools
+// command patterns. "git:*" matches git and any git subcommand, a trailing
+// "*" matches any suffix, and anything else must match exactly.
+func matchesAnyPattern(patterns []string, cmd string) bool {
+	for _, pattern := range patterns {
+		switch {
+		case strings.HasSuffix(pattern, ":*"):
+			prefix := strings.TrimSuffix(pattern, ":*")
+			if cmd == prefix || strings.HasPrefix(cmd, prefix+" ") {
+				return true
+			}
+		case strings.HasSuffix(pattern, "*"):
+			if strings.HasPrefix(cmd, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		case cmd == pattern:
+			return true
+		}
+	}
+	return false
+}
+
+// SkillTool activates Agent Skills on demand.
+type SkillTool struct {
+	// Bash runs the scripts bundled with skills.
+	Bash *BashTool
+
+	mu     sync.Mutex
+	skills []skills.Skill
+	tool   *llm.Tool // rebuilt when skills change
+	active *activeSkill
+}
+
+// NewSkillTool returns a SkillTool that can activate the given skills.
+func NewSkillTool(list []skills.Skill, bash *BashTool) *SkillTool {
+	return &SkillTool{Bash: bash, skills: list}
+}
+
+// SetSkills replaces the skills that can be activated, e.g. after skill files
+// change on disk. An active skill stays active.
+func (s *SkillTool) SetSkills(list []skills.Skill) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skills = list
+	s.tool = nil
+}
+
+// Skills returns the skills that can be activated.
+func (s *SkillTool) Skills() []skills.Skill {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.skills
+}
+
+type activateSkillInput struct {
+	Name       string `json:"name"`
+	Deactivate bool   `json:"deactivate,omitempty"`
+}
+
+type skillScriptInput struct {
+	Args   []string `json:"args,omitempty"`
+	SlowOK bool     `json:"slow_ok,omitempty"`
+}
+
+// Tool returns an llm.Tool for activating skills, or nil if there are none.
+// Its description names the current skills, since skills added after the
+// system prompt was written are not listed there.
+func (s *SkillTool) Tool() *llm.Tool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.skills) == 0 {
+		return nil
+	}
+	if s.tool == nil {
+		s.tool = &llm.Tool{
+			Name:        activateSkillName,
+			Description: activateSkillDescription + "\nAvailable skills: " + strings.Join(skillNames(s.skills), ", "),
+			InputSchema: llm.MustSchema(activateSkillInputSchema),
+			Run:         s.Run,
+		}
+	}
+	return s.tool
+}
+
+func skillNames(list []skills.Skill) []string {
+	names := make([]string, len(list))
+	for i, sk := range list {
+		names[i] = sk.Name
+	}
+	return names
+}
+
+// current returns the active skill, or nil.
+func (s *SkillTool) current() *activeSkill {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// CheckBash applies the active skill's bash restrictions to command.
+// It is meant to be used as the bash tool's CheckPermission.
+func (s *SkillTool) CheckBash(command string) error {
+	if active := s.current(); active != nil {
+		return active.checkBash(command)
+	}
+	return nil
+}
+
+// Run executes the activate_skill tool.
+func (s *SkillTool) Run(ctx context.Context, m json.RawMessage) llm.ToolOut {
+	var req activateSkillInput
+	if err := json.Unmarshal(m, &req); err != nil {
+		return llm.ErrorfToolOut("failed to parse activate_skill input: %w", err)
+	}
+
+	if req.Deactivate {
+		s.mu.Lock()
+		prev := s.active
+		s.active = nil
+		s.mu.Unlock()
+		if prev == nil {
+			return llm.ToolOut{LLMContent: llm.TextContent("No skill is active.")}
+		}
+		return llm.ToolOut{LLMContent: llm.TextContent(fmt.Sprintf("Deactivated skill %q. All tools are available again.", prev.skill.Name))}
+	}
+
+	if req.Name == "" {
+		return llm.ErrorfToolOut("name is required")
+	}
+	skill, ok := s.find(req.Name)
+	if !ok {
+		return llm.ErrorfToolOut("unknown skill %q; available skills: %s", req.Name, strings.Join(skillNames(s.Skills()), ", "))
+	}
+
+	body, err := skill.Body()
+	if err != nil {
+		return llm.ErrorfToolOut("failed to read %s: %w", skill.Path, err)
+	}
+
+	active := &activeSkill{skill: skill, perms: skill.Permissions()}
+	var scriptLines []string
+	for _, script := range skill.Scripts() {
+		tool := s.scriptTool(skill, script)
+		active.scripts = append(active.scripts, tool)
+		scriptLines = append(scriptLines, fmt.Sprintf("- %s runs %s", tool.Name, filepath.Join(skills.ScriptsDir, script.Name)))
+	}
+
+	s.mu.Lock()
+	s.active = active
+	s.mu.Unlock()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<skill name=%q dir=%q>\n%s\n</skill>\n", skill.Name, skill.Dir(), body)
+	fmt.Fprintf(&sb, "\nSkill %q is active. Relative paths in its instructions are relative to %s.\n", skill.Name, skill.Dir())
+	if len(active.perms) > 0 {
+		perms := make([]string, len(active.perms))
+		for i, p := range active.perms {
+			perms[i] = p.String()
+		}
+		fmt.Fprintf(&sb, "Allowed tools while active: %s.\n", strings.Join(perms, ", "))
+	}
+	if len(scriptLines) > 0 {
+		fmt.Fprintf(&sb, "Bundled scripts are available as tools:\n%s\n", strings.Join(scriptLines, "\n"))
+	}
+	sb.WriteString("Call activate_skill with deactivate=true when you are done with this skill.")
+
+	return llm.ToolOut{LLMContent: llm.TextContent(sb.String())}
+}
+
+func (s *SkillTool) find(name string) (skills.Skill, bool) {
+	for _, sk := range s.Skills() {
+		if sk.Name == name {
+			return sk, true
+		}
+	}
+	return skills.Skill{}, false
+}
+
+// scriptToolName derives a tool name like "release_bump_version" from a
+// skill and script name. Tool names are limited to [a-zA-Z0-9_-]{1,64}.
+func scriptToolName(skillName, scriptName string) string {
+	base := strings.TrimSuffix(scriptName, filepath.Ext(scriptName))
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, skillName+"_"+base)
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// scriptTool returns a tool that runs a script bundled with skill.
+// The script runs in the working directory with SKILL_DIR set to the skill's directory.
+func (s *SkillTool) scriptTool(skill skills.Skill, script skills.Script) *llm.Tool {
+	return &llm.Tool{
+		Name: scriptToolName(skill.Name, script.Name),
+		Description: fmt.Sprintf("Run %s, bundled with the %q skill. See the skill's instructions for its usage.",
+			filepath.Join(skill.Dir(), skills.ScriptsDir, script.Name), skill.Name),
+		InputSchema: llm.MustSchema(skillScriptInputSchema),
+		Run: func(ctx context.Context, m json.RawMessage) llm.ToolOut {
+			var req skillScriptInput
+			if err := json.Unmarshal(m, &req); err != nil {
+				return llm.ErrorfToolOut("failed to parse script input: %w", err)
+			}
+			command, err := scriptCommandLine(skill.Dir(), append(script.Command(), req.Args...))
+			if err != nil {
+				return llm.ErrorToolOut(err)
+			}
+			in := bashInput{Command: command, SlowOK: req.SlowOK}
+			display := BashDisplayData{WorkingDir: s.Bash.getWorkingDir()}
+			out, report, err := s.Bash.executeBash(ctx, in, in.timeout(s.Bash.Timeouts))
+			display.Sandbox = report
+			if err != nil {
+				return llm.ToolOut{Error: err, Display: display}
+			}
+			return llm.ToolOut{LLMContent: llm.TextContent(out), Display: display}
+		},
+	}
+}
+
+// scriptCommandLine quotes words into a bash command line that runs them with SKILL_DIR set.
+func scriptCommandLine(skillDir string, words []string) (string, error) {
+	quoted := make([]string, 0, len(words)+1)
+	dir, err := syntax.Quote(skillDir, syntax.LangBash)
+	if err != nil {
+		return "", fmt.Errorf("cannot quote skill directory: %w", err)
+	}
+	quoted = append(quoted, "SKILL_DIR="+dir)
+	for _, w := range words {
+		q, err := syntax.Quote(w, syntax.LangBash)
+		if err != nil {
+			return "", fmt.Errorf("cannot quote argument %q: %w", w, err)
+		}
+		quoted = append(quoted, q)
+	}
+	return strings.Join(quoted, " "), nil
+}
@@ -0,0 +1,222 @@
+package claudetool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"shelley.exe.dev/skills"
+)
+
+// writeSkill creates a skill directory with the given allowed-tools and scripts.
+func writeSkill(t *testing.T, name, allowedTools string, scripts map[string]string) skills.Skill {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), name)
+	if err := os.MkdirAll(filepath.Join(dir, skills.ScriptsDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\nname: " + name + "\ndescription: Test skill.\n"
+	if allowedTools != "" {
+		content += "allowed-tools: " + allowedTools + "\n"
+	}
+	content += "---\n\n# " + name + "\n\nFollow the checklist.\n"
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for file, body := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, skills.ScriptsDir, file), []byte(body), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	skill, err := skills.Parse(filepath.Join(dir, "SKILL.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return skill
+}
+
+func toolNames(ts *ToolSet) []string {
+	var names []string
+	for _, tool := range ts.Tools() {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestSkillActivation(t *testing.T) {
+	release := writeSkill(t, "release", "Bash(git:*) Read", map[string]string{
+		"bump-version.sh": "#!/bin/sh\necho \"bumped to $1 in $SKILL_DIR\"\n",
+	})
+	notes := writeSkill(t, "notes", "", nil)
+
+	workDir := t.TempDir()
+	var denied []string
+	ts := NewToolSet(context.Background(), ToolSetConfig{
+		LLMProvider: &mockLLMProvider{},
+		ModelID:     "test-model",
+		WorkingDir:  workDir,
+		Skills:      []skills.Skill{release, notes},
+		OnPermissionDenied: func(toolName, input string, err error) {
+			denied = append(denied, toolName+": "+input)
+		},
+		IsApproved: func(toolName, input string) bool {
+			return toolName == bashName && input == "touch approved"
+		},
+	})
+	defer ts.Cleanup()
+
+	activate := findTool(ts, activateSkillName)
+	if activate == nil {
+		t.Fatal("expected activate_skill tool")
+	}
+	allTools := len(ts.Tools())
+
+	run := func(name string, input any) (string, error) {
+		t.Helper()
+		tool := findTool(ts, name)
+		if tool == nil {
+			t.Fatalf("tool %q not available; have %v", name, toolNames(ts))
+		}
+		m, _ := json.Marshal(input)
+		out := tool.Run(context.Background(), m)
+		if out.Error != nil {
+			return "", out.Error
+		}
+		return out.LLMContent[0].Text, nil
+	}
+
+	t.Run("unknown skill", func(t *testing.T) {
+		_, err := run(activateSkillName, activateSkillInput{Name: "missing"})
+		if err == nil || !strings.Contains(err.Error(), "release, notes") {
+			t.Errorf("expected error listing skills, got %v", err)
+		}
+	})
+
+	t.Run("activate restricts tools", func(t *testing.T) {
+		out, err := run(activateSkillName, activateSkillInput{Name: "release"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"# release", "Follow the checklist.", "Bash(git:*), Read", "release_bump_version runs scripts/bump-version.sh"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("activation output missing %q:\n%s", want, out)
+			}
+		}
+
+		got := strings.Join(toolNames(ts), ",")
+		if want := "bash,activate_skill,release_bump_version"; got != want {
+			t.Errorf("tools while active = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("bash limited to patterns", func(t *testing.T) {
+		if _, err := run(bashName, bashInput{Command: "git --version"}); err != nil {
+			t.Errorf("expected git to be allowed: %v", err)
+		}
+		_, err := run(bashName, bashInput{Command: "git --version && touch nope"})
+		if err == nil || !strings.Contains(err.Error(), `"touch nope" is not allowed`) {
+			t.Errorf("expected touch to be rejected, got %v", err)
+		}
+		if want := []string{"bash: git --version && touch nope"}; !slices.Equal(denied, want) {
+			t.Errorf("OnPermissionDenied calls = %q, want %q", denied, want)
+		}
+		if _, err := run(bashName, bashInput{Command: "touch approved"}); err != nil {
+			t.Errorf("approved command rejected: %v", err)
+		}
+	})
+
+	t.Run("script tool", func(t *testing.T) {
+		out, err := run("release_bump_version", skillScriptInput{Args: []string{"1.2.3"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "bumped to 1.2.3 in " + release.Dir(); strings.TrimSpace(out) != want {
+			t.Errorf("script output = %q, want %q", out, want)
+		}
+	})
+
+	t.Run("deactivate restores tools", func(t *testing.T) {
+		out, err := run(activateSkillName, activateSkillInput{Deactivate: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, `Deactivated skill "release"`) {
+			t.Errorf("unexpected output: %s", out)
+		}
+		if got := len(ts.Tools()); got != allTools {
+			t.Errorf("len(Tools()) = %d after deactivation, want %d", got, allTools)
+		}
+		if _, err := run(bashName, bashInput{Command: "touch ok"}); err != nil {
+			t.Errorf("expected bash to be unrestricted: %v", err)
+		}
+	})
+
+	t.Run("skill without allowed-tools keeps all tools", func(t *testing.T) {
+		if _, err := run(activateSkillName, activateSkillInput{Name: "notes"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := len(ts.Tools()); got != allTools {
+			t.Errorf("len(Tools()) = %d, want %d", got, allTools)
+		}
+	})
+}
+
+func TestToolSet_SetSkills(t *testing.T) {
+	ts := NewToolSet(context.Background(), ToolSetConfig{
+		LLMProvider: &mockLLMProvider{},
+		ModelID:     "test-model",
+		WorkingDir:  t.TempDir(),
+	})
+	defer ts.Cleanup()
+	if findTool(ts, activateSkillName) != nil {
+		t.Error("activate_skill should not be offered without skills")
+	}
+
+	// Skills installed while the conversation runs make the tool appear.
+	ts.SetSkills([]skills.Skill{writeSkill(t, "late", "", nil)})
+	tool := findTool(ts, activateSkillName)
+	if tool == nil {
+		t.Fatal("expected activate_skill after SetSkills")
+	}
+	if !strings.Contains(tool.Description, "Available skills: late") {
+		t.Errorf("description does not list the new skill: %s", tool.Description)
+	}
+
+	ts.SetSkills(nil)
+	if findTool(ts, activateSkillName) != nil {
+		t.Error("activate_skill should be withdrawn when skills are removed")
+	}
+}
+
+func TestMatchesAnyPattern(t *testing.T) {
+	tests := []struct {
+		pattern, cmd string
+		want         bool
+	}{
+		{"git:*", "git", true},
+		{"git:*", "git status", true},
+		{"git:*", "gitk", false},
+		{"npm run*", "npm run build", true},
+		{"npm run*", "npm install", false},
+		{"make release", "make release", true},
+		{"make release", "make release-notes", false},
+	}
+	for _, tt := range tests {
+		if got := matchesAnyPattern([]string{tt.pattern}, tt.cmd); got != tt.want {
+			t.Errorf("matchesAnyPattern(%q, %q) = %v, want %v", tt.pattern, tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestScriptToolName(t *testing.T) {
+	if got := scriptToolName("release", "bump-version.sh"); got != "release_bump_version" {
+		t.Errorf("got %q", got)
+	}
+	if got := scriptToolName(strings.Repeat("a", 60), "script.py"); len(got) != 64 {
+		t.Errorf("expected name truncated to 64 characters, got %d", len(got))
+	}
+}
@@ -2,12 +2,16 @@ package claudetool
 
 import (
 	"context"
+	"log/slog"
 	"os"
-	"strings"
+	"slices"
 	"sync"
 
 	"shelley.exe.dev/claudetool/browse"
+	"shelley.exe.dev/claudetool/sandbox"
 	"shelley.exe.dev/llm"
+	"shelley.exe.dev/redact"
+	"shelley.exe.dev/skills"
 )
 
 // WorkingDir is a thread-safe mutable working directory.
@@ -46,7 +50,7 @@ type ToolSetConfig struct {
 	// EnableBrowser enables browser tools.
 	EnableBrowser bool
 	// ModelID is the model being used for this conversation.
-	// Used to determine tool configuration (e.g., simplified patch schema for weaker models).
+	// Its capabilities determine tool configuration (e.g., simplified patch schema for weaker models).
 	ModelID string
 	// OnWorkingDirChange is called when the working directory changes.
 	// This can be used to persist the change to a database.
@@ -72,6 +76,41 @@ type ToolSetConfig struct {
 	// AvailableModels is the list of models the subagent can choose from.
 	// If nil, the list is built from LLMProvider.GetAvailableModels().
 	AvailableModels []AvailableModel
+	// ServerTools lists provider-executed tools to offer the model (see llm.ServerToolTypes).
+	// Providers that do not support a given server tool omit it.
+	ServerTools []string
+	// Skills are the Agent Skills the activate_skill tool can load.
+	// The tool is offered only while there are skills (see ToolSet.SetSkills).
+	Skills []skills.Skill
+	// OnPermissionDenied is called when a permission check refuses a tool call,
+	// so the user can be told the agent needs their approval.
+	OnPermissionDenied func(toolName, input string, err error)
+	// IsApproved reports whether the user has approved a tool call that a
+	// permission check would otherwise refuse. Each approval allows one call.
+	IsApproved func(toolName, input string) bool
+	// Sandbox returns the sandbox settings for bash commands. It is called
+	// for each command, so changes apply to the next one.
+	Sandbox func() sandbox.Settings
+	// Redactor removes secrets from tool results before the loop records
+	// them or sends them to the LLM. If nil, nothing is redacted.
+	Redactor *redact.Redactor
+}
+
+// CapabilitiesProvider is implemented by LLM service providers that can
+// describe their models' capabilities.
+type CapabilitiesProvider interface {
+	ModelCapabilities(modelID string) (llm.Capabilities, bool)
+}
+
+// modelCapabilities returns the capabilities of the configured model,
+// falling back to llm.DefaultCapabilities if the provider cannot describe it.
+func modelCapabilities(cfg ToolSetConfig) llm.Capabilities {
+	if cp, ok := cfg.LLMProvider.(CapabilitiesProvider); ok {
+		if caps, ok := cp.ModelCapabilities(cfg.ModelID); ok {
+			return caps
+		}
+	}
+	return llm.DefaultCapabilities
 }
 
 // ToolSet holds a set of tools for a single conversation.
@@ -80,11 +119,32 @@ type ToolSet struct {
 	tools   []*llm.Tool
 	cleanup func()
 	wd      *MutableWorkingDir
+	caps    llm.Capabilities
+	skill   *SkillTool
 }
 
-// Tools returns the tools in this set.
+// Tools returns the tools currently available. activate_skill is included
+// while there are skills to activate. While a skill is active, the tools are
+// narrowed to its allowed-tools and extended with its scripts.
 func (ts *ToolSet) Tools() []*llm.Tool {
-	return ts.tools
+	if ts.skill == nil {
+		return ts.tools
+	}
+	tools := ts.tools
+	if tool := ts.skill.Tool(); tool != nil && (ts.caps.MaxTools == 0 || len(tools) < ts.caps.MaxTools) {
+		tools = append(slices.Clip(tools), tool)
+	}
+	if active := ts.skill.current(); active != nil {
+		return active.filter(tools)
+	}
+	return tools
+}
+
+// SetSkills replaces the skills activate_skill can load.
+func (ts *ToolSet) SetSkills(list []skills.Skill) {
+	if ts.skill != nil {
+		ts.skill.SetSkills(list)
+	}
 }
 
 // Cleanup releases resources held by the tools (e.g., browser).
@@ -99,13 +159,13 @@ func (ts *ToolSet) WorkingDir() *MutableWorkingDir {
 	return ts.wd
 }
 
-// NewToolSet creates a new set of tools for a conversation.
-// isStrongModel returns true for models that can handle complex tool schemas.
-func isStrongModel(modelID string) bool {
-	lower := strings.ToLower(modelID)
-	return strings.Contains(lower, "sonnet") || strings.Contains(lower, "opus")
+// Capabilities returns the model capabilities the tools were chosen for.
+func (ts *ToolSet) Capabilities() llm.Capabilities {
+	return ts.caps
 }
 
+// NewToolSet creates a new set of tools for a conversation.
+// The tools offered depend on the capabilities of cfg.ModelID.
 func NewToolSet(ctx context.Context, cfg ToolSetConfig) *ToolSet {
 	workingDir := cfg.WorkingDir
 	if workingDir == "" {
@@ -117,21 +177,42 @@ func NewToolSet(ctx context.Context, cfg ToolSetConfig) *ToolSet {
 	}
 	wd := NewMutableWorkingDir(workingDir)
 
+	caps := modelCapabilities(cfg)
+	if !caps.Tools {
+		return &ToolSet{wd: wd, caps: caps}
+	}
+
 	bashTool := &BashTool{
 		WorkingDir:       wd,
 		LLMProvider:      cfg.LLMProvider,
 		EnableJITInstall: cfg.EnableJITInstall,
 		ConversationID:   cfg.ConversationID,
+		Sandbox:          cfg.Sandbox,
 	}
 
-	// Use simplified patch schema for weaker models, full schema for sonnet/opus
-	simplified := !isStrongModel(cfg.ModelID)
 	patchTool := &PatchTool{
-		Simplified:       simplified,
+		Simplified:       caps.SimplifiedPatch,
 		WorkingDir:       wd,
 		ClipboardEnabled: true,
 	}
 
+	// Skills can appear later, so the skill tool always exists; Tools only
+	// offers it while there are skills to activate.
+	skillTool := NewSkillTool(cfg.Skills, bashTool)
+	bashTool.CheckPermission = skillTool.CheckBash
+	if cfg.OnPermissionDenied != nil || cfg.IsApproved != nil {
+		bashTool.CheckPermission = func(command string) error {
+			err := skillTool.CheckBash(command)
+			if err == nil || (cfg.IsApproved != nil && cfg.IsApproved("bash", command)) {
+				return nil
+			}
+			if cfg.OnPermissionDenied != nil {
+				cfg.OnPermissionDenied("bash", command, err)
+			}
+			return err
+		}
+	}
+
 	keywordTool := NewKeywordToolWithWorkingDir(cfg.LLMProvider, wd)
 
 	changeDirTool := &ChangeDirTool{
@@ -141,12 +222,15 @@ func NewToolSet(ctx context.Context, cfg ToolSetConfig) *ToolSet {
 
 	outputIframeTool := &OutputIframeTool{WorkingDir: wd}
 
+	readDocumentTool := &ReadDocumentTool{WorkingDir: wd}
+
 	tools := []*llm.Tool{
 		bashTool.Tool(),
 		patchTool.Tool(),
 		keywordTool.Tool(),
 		changeDirTool.Tool(),
 		outputIframeTool.Tool(),
+		readDocumentTool.Tool(),
 	}
 
 	// Build the available models list (shared by subagent and llm_one_shot tools).
@@ -183,11 +267,18 @@ func NewToolSet(ctx context.Context, cfg ToolSetConfig) *ToolSet {
 		tools = append(tools, llmOneShotTool.Tool())
 	}
 
+	for _, t := range cfg.ServerTools {
+		if llm.IsServerToolType(t) {
+			tools = append(tools, llm.ServerTool(t))
+		}
+	}
+
+	// Browser tools are built around screenshots, so they need image support.
 	var cleanup func()
-	if cfg.EnableBrowser {
-		// Get max image dimension from the LLM service
-		maxImageDimension := 0
-		if cfg.LLMProvider != nil && cfg.ModelID != "" {
+	if cfg.EnableBrowser && caps.Images {
+		// Get max image dimension from the model capabilities or the LLM service
+		maxImageDimension := caps.MaxImageDimension
+		if maxImageDimension == 0 && cfg.LLMProvider != nil && cfg.ModelID != "" {
 			if svc, err := cfg.LLMProvider.GetService(cfg.ModelID); err == nil {
 				maxImageDimension = svc.MaxImageDimension()
 			}
@@ -199,9 +290,27 @@ func NewToolSet(ctx context.Context, cfg ToolSetConfig) *ToolSet {
 		cleanup = browserCleanup
 	}
 
+	// Tools are in priority order, so trimming drops the least essential ones.
+	// Room is kept for activate_skill, which Tools adds, while there are skills.
+	if limit := caps.MaxTools; limit > 0 {
+		if len(cfg.Skills) > 0 && limit > 1 {
+			limit--
+		}
+		if len(tools) > limit {
+			var dropped []string
+			for _, t := range tools[limit:] {
+				dropped = append(dropped, t.Name)
+			}
+			slog.WarnContext(ctx, "dropping tools beyond the model's limit", "model", cfg.ModelID, "max_tools", caps.MaxTools, "dropped", dropped)
+			tools = tools[:limit]
+		}
+	}
+
 	return &ToolSet{
 		tools:   tools,
 		cleanup: cleanup,
 		wd:      wd,
+		caps:    caps,
+		skill:   skillTool,
 	}
 }
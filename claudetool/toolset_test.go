@@ -4,29 +4,142 @@ import (
 	"context"
 	"os"
 	"testing"
+
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/skills"
 )
 
-func TestIsStrongModel(t *testing.T) {
-	tests := []struct {
-		modelID  string
-		expected bool
-	}{
-		{"claude-3-sonnet-20240229", true},
-		{"claude-3-opus-20240229", true},
-		{"claude-3-haiku-20240307", false},
-		{"Sonnet Model", true},
-		{"OPUS Model", true},
-		{"haiku model", false},
-		{"other-model", false},
-		{"", false},
+// capsLLMProvider is a mockLLMProvider that describes its models' capabilities.
+type capsLLMProvider struct {
+	mockLLMProvider
+	caps map[string]llm.Capabilities
+}
+
+func (p *capsLLMProvider) ModelCapabilities(modelID string) (llm.Capabilities, bool) {
+	c, ok := p.caps[modelID]
+	return c, ok
+}
+
+func findTool(ts *ToolSet, name string) *llm.Tool {
+	for _, tool := range ts.Tools() {
+		if tool.Name == name {
+			return tool
+		}
+	}
+	return nil
+}
+
+func TestNewToolSet_Capabilities(t *testing.T) {
+	full := llm.Capabilities{Tools: true, Images: true, ParallelToolCalls: true}
+	simplified := full
+	simplified.SimplifiedPatch = true
+	textOnly := full
+	textOnly.Images = false
+	limited := full
+	limited.MaxTools = 2
+	provider := &capsLLMProvider{caps: map[string]llm.Capabilities{
+		"strong":    full,
+		"weak":      simplified,
+		"text-only": textOnly,
+		"limited":   limited,
+		"no-tools":  {},
+	}}
+	newToolSet := func(modelID string) *ToolSet {
+		return NewToolSet(context.Background(), ToolSetConfig{
+			LLMProvider:   provider,
+			ModelID:       modelID,
+			WorkingDir:    "/test",
+			EnableBrowser: true,
+		})
 	}
 
-	for _, test := range tests {
-		result := isStrongModel(test.modelID)
-		if result != test.expected {
-			t.Errorf("isStrongModel(%q) = %v, expected %v", test.modelID, result, test.expected)
+	patchSchema := func(ts *ToolSet) string {
+		patch := findTool(ts, PatchName)
+		if patch == nil {
+			t.Fatal("expected patch tool")
 		}
+		return string(patch.InputSchema)
 	}
+	simplifiedSchema := string(llm.MustSchema(PatchStandardSimplifiedSchema))
+
+	t.Run("strong model gets full patch schema", func(t *testing.T) {
+		ts := newToolSet("strong")
+		defer ts.Cleanup()
+		if patchSchema(ts) == simplifiedSchema {
+			t.Error("expected full patch schema")
+		}
+		if findTool(ts, "browser") == nil {
+			t.Error("expected browser tool for image-capable model")
+		}
+		if !ts.Capabilities().ParallelToolCalls {
+			t.Error("expected capabilities to be exposed on the tool set")
+		}
+	})
+
+	t.Run("weak model gets simplified patch schema", func(t *testing.T) {
+		ts := newToolSet("weak")
+		defer ts.Cleanup()
+		if patchSchema(ts) != simplifiedSchema {
+			t.Error("expected simplified patch schema")
+		}
+	})
+
+	t.Run("unknown model uses defaults", func(t *testing.T) {
+		ts := newToolSet("unknown")
+		defer ts.Cleanup()
+		if ts.Capabilities() != llm.DefaultCapabilities {
+			t.Errorf("Capabilities() = %+v, want defaults", ts.Capabilities())
+		}
+		if patchSchema(ts) != simplifiedSchema {
+			t.Error("expected simplified patch schema by default")
+		}
+	})
+
+	t.Run("text-only model gets no browser tools", func(t *testing.T) {
+		ts := newToolSet("text-only")
+		defer ts.Cleanup()
+		if findTool(ts, "browser") != nil || findTool(ts, "read_image") != nil {
+			t.Error("expected no browser tools for text-only model")
+		}
+	})
+
+	t.Run("max tools", func(t *testing.T) {
+		ts := newToolSet("limited")
+		defer ts.Cleanup()
+		if got := len(ts.Tools()); got != 2 {
+			t.Errorf("len(Tools()) = %d, want 2", got)
+		}
+		if findTool(ts, "bash") == nil {
+			t.Error("expected core tools to be kept")
+		}
+	})
+
+	t.Run("limited model keeps room for activate_skill", func(t *testing.T) {
+		ts := NewToolSet(context.Background(), ToolSetConfig{
+			LLMProvider: provider,
+			ModelID:     "limited",
+			WorkingDir:  "/test",
+			Skills:      []skills.Skill{writeSkill(t, "notes", "", nil)},
+		})
+		defer ts.Cleanup()
+		if got := len(ts.Tools()); got != 2 {
+			t.Errorf("len(Tools()) = %d, want 2", got)
+		}
+		if findTool(ts, "bash") == nil || findTool(ts, activateSkillName) == nil {
+			t.Errorf("Tools() = %v, want bash and %s", toolNames(ts), activateSkillName)
+		}
+	})
+
+	t.Run("model without tool support", func(t *testing.T) {
+		ts := newToolSet("no-tools")
+		defer ts.Cleanup()
+		if got := len(ts.Tools()); got != 0 {
+			t.Errorf("len(Tools()) = %d, want 0", got)
+		}
+		if ts.WorkingDir() == nil {
+			t.Error("expected working directory to be initialized")
+		}
+	})
 }
 
 func TestNewToolSet(t *testing.T) {
@@ -76,6 +189,27 @@ func TestToolSet_Tools(t *testing.T) {
 	}
 }
 
+func TestNewToolSet_ServerTools(t *testing.T) {
+	cfg := ToolSetConfig{
+		LLMProvider: &mockLLMProvider{},
+		ModelID:     "test-model",
+		WorkingDir:  "/test",
+		ServerTools: []string{"web_search", "not_a_server_tool"},
+	}
+
+	ts := NewToolSet(context.Background(), cfg)
+
+	var serverTools []string
+	for _, tool := range ts.Tools() {
+		if tool.IsServerTool() {
+			serverTools = append(serverTools, tool.Name)
+		}
+	}
+	if len(serverTools) != 1 || serverTools[0] != "web_search" {
+		t.Errorf("server tools = %v, want [web_search]", serverTools)
+	}
+}
+
 func TestToolSet_WorkingDir(t *testing.T) {
 	provider := &mockLLMProvider{}
 
@@ -1,20 +1,19 @@
 // Package client implements the Shelley CLI client.
-// It communicates with a running Shelley server over a Unix socket or HTTP.
+// It communicates with a running Shelley server over a Unix socket or HTTP
+// using the shelleyapi package.
 package client
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
-	"net"
-	"net/http"
-	"net/url"
 	"os"
-	"path/filepath"
 	"strings"
+
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/shelleyapi"
 )
 
 // ANSI color codes
@@ -30,35 +29,13 @@ const (
 
 // DefaultSocketPath returns the default Unix socket path (~/.config/shelley/shelley.sock).
 func DefaultSocketPath() string {
-	configDir := os.Getenv("XDG_CONFIG_HOME")
-	if configDir == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			home = "/tmp"
-		}
-		configDir = filepath.Join(home, ".config")
-	}
-	return filepath.Join(configDir, "shelley", "shelley.sock")
+	return shelleyapi.DefaultSocketPath()
 }
 
 func defaultClientURL() string {
 	return "unix://" + DefaultSocketPath()
 }
 
-func parseClientURL(rawURL string) (scheme, address string, err error) {
-	if strings.HasPrefix(rawURL, "unix://") {
-		sockPath := strings.TrimPrefix(rawURL, "unix://")
-		if sockPath == "" {
-			return "", "", fmt.Errorf("unix:// URL must include a socket path")
-		}
-		return "unix", sockPath, nil
-	}
-	if strings.HasPrefix(rawURL, "http://") || strings.HasPrefix(rawURL, "https://") {
-		return strings.SplitN(rawURL, "://", 2)[0], rawURL, nil
-	}
-	return "", "", fmt.Errorf("unsupported URL scheme: %s (use unix://, http://, or https://)", rawURL)
-}
-
 type multiFlag []string
 
 func (f *multiFlag) String() string { return strings.Join(*f, ", ") }
@@ -117,50 +94,14 @@ func (o *outputConfig) magenta(s string) string {
 }
 
 type clientConfig struct {
-	serverURL string
-	headers   map[string]string
-	output    outputConfig
-}
-
-func (cc *clientConfig) newHTTPClient() (*http.Client, string, error) {
-	scheme, address, err := parseClientURL(cc.serverURL)
-	if err != nil {
-		return nil, "", err
-	}
-
-	switch scheme {
-	case "unix":
-		transport := &http.Transport{
-			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
-				return net.Dial("unix", address)
-			},
-		}
-		return &http.Client{Transport: transport}, "http://localhost", nil
-	case "http", "https":
-		return &http.Client{}, address, nil
-	default:
-		return nil, "", fmt.Errorf("unsupported scheme: %s", scheme)
-	}
+	api    *shelleyapi.Client
+	output outputConfig
 }
 
-func (cc *clientConfig) newRequest(method, url string, body *strings.Reader) (*http.Request, error) {
-	var req *http.Request
-	var err error
-	if body != nil {
-		req, err = http.NewRequest(method, url, body)
-	} else {
-		req, err = http.NewRequest(method, url, nil)
-	}
-	if err != nil {
-		return nil, err
-	}
-	if method == http.MethodPost {
-		req.Header.Set("X-Shelley-Request", "1")
-	}
-	for k, v := range cc.headers {
-		req.Header.Set(k, v)
-	}
-	return req, nil
+// fatalf prints an error to stderr and exits.
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+	os.Exit(1)
 }
 
 // isColorEnabled checks if color output should be enabled
@@ -186,6 +127,7 @@ func Run(args []string) {
 	noColorFlag := fs.Bool("no-color", false, "Disable colored output")
 	var headerFlags multiFlag
 	fs.Var(&headerFlags, "H", `Extra HTTP header ("Name: Value", can be repeated)`)
+	tokenFlag := fs.String("token", "", "API token for servers with authentication enabled (default $SHELLEY_TOKEN)")
 	fs.Usage = func() {
 		fmt.Fprintf(fs.Output(), "Shelley CLI client\n\n")
 		fmt.Fprintf(fs.Output(), "Usage: shelley client [flags] <command> [args...]\n\n")
@@ -193,29 +135,39 @@ func Run(args []string) {
 		fs.PrintDefaults()
 		fmt.Fprintf(fs.Output(), "\nCommands:\n")
 		fmt.Fprintf(fs.Output(), "  chat       Send a message and wait for response (default)\n")
+		fmt.Fprintf(fs.Output(), "  repl       Interactive session\n")
 		fmt.Fprintf(fs.Output(), "  read       Read conversation messages\n")
 		fmt.Fprintf(fs.Output(), "  list       List conversations\n")
 		fmt.Fprintf(fs.Output(), "  archive    Archive a conversation\n")
 		fmt.Fprintf(fs.Output(), "  unarchive  Unarchive a conversation\n")
 		fmt.Fprintf(fs.Output(), "  delete     Delete a conversation\n")
 		fmt.Fprintf(fs.Output(), "  models     List available models\n")
+		fmt.Fprintf(fs.Output(), "  usage      Report token usage and cost\n")
 		fmt.Fprintf(fs.Output(), "  help       Print detailed help\n")
 	}
 	fs.Parse(args)
 
-	headers := make(map[string]string)
+	api, err := shelleyapi.New(*urlFlag)
+	if err != nil {
+		fatalf("%v", err)
+	}
 	for _, h := range headerFlags {
-		parts := strings.SplitN(h, ":", 2)
-		if len(parts) != 2 {
-			fmt.Fprintf(os.Stderr, "Error: invalid header %q (expected \"Name: Value\")\n", h)
-			os.Exit(1)
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			fatalf("invalid header %q (expected \"Name: Value\")", h)
 		}
-		headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		api.SetHeader(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	token := *tokenFlag
+	if token == "" {
+		token = os.Getenv("SHELLEY_TOKEN")
+	}
+	if token != "" {
+		api.SetToken(token)
 	}
 
 	cc := &clientConfig{
-		serverURL: *urlFlag,
-		headers:   headers,
+		api: api,
 		output: outputConfig{
 			jsonMode: *jsonFlag,
 			color:    isColorEnabled(*noColorFlag),
@@ -232,6 +184,8 @@ func Run(args []string) {
 	switch subArgs[0] {
 	case "chat":
 		cmdChat(cc, subArgs[1:])
+	case "repl":
+		cmdRepl(cc, subArgs[1:])
 	case "read":
 		cmdRead(cc, subArgs[1:])
 	case "list":
@@ -244,6 +198,8 @@ func Run(args []string) {
 		cmdDelete(cc, subArgs[1:])
 	case "models":
 		cmdModels(cc, subArgs[1:])
+	case "usage":
+		cmdUsage(cc, subArgs[1:])
 	case "help":
 		cmdHelp()
 	default:
@@ -260,6 +216,7 @@ func cmdChat(cc *clientConfig, args []string) {
 	model := fs.String("model", "", "Model to use (server default if empty)")
 	cwd := fs.String("cwd", "", "Working directory for the conversation")
 	immediate := fs.Bool("immediate", false, "Return immediately with conversation ID (don't wait for response)")
+	interactive := fs.Bool("i", false, "Keep the session open for more messages (same as repl)")
 	fs.Parse(args)
 
 	// Handle prompt from stdin
@@ -267,8 +224,7 @@ func cmdChat(cc *clientConfig, args []string) {
 	if promptText == "-" {
 		data, err := io.ReadAll(os.Stdin)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
-			os.Exit(1)
+			fatalf("reading stdin: %v", err)
 		}
 		promptText = string(data)
 	} else if promptText == "" && fs.NArg() > 0 {
@@ -276,76 +232,27 @@ func cmdChat(cc *clientConfig, args []string) {
 		promptText = strings.Join(fs.Args(), " ")
 	}
 
-	if promptText == "" {
-		fmt.Fprintf(os.Stderr, "Error: message required (-p PROMPT or pass as argument)\n")
-		os.Exit(1)
-	}
-
-	client, baseURL, err := cc.newHTTPClient()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	reqBody := map[string]string{"message": promptText}
-	if *model != "" {
-		reqBody["model"] = *model
-	}
-	if *cwd != "" {
-		reqBody["cwd"] = *cwd
-	}
-
-	bodyBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	var apiURL string
-	if *convID != "" {
-		apiURL = baseURL + "/api/conversation/" + *convID + "/chat"
-	} else {
-		apiURL = baseURL + "/api/conversations/new"
-	}
-
-	req, err := cc.newRequest("POST", apiURL, strings.NewReader(string(bodyBytes)))
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating request: %v\n", err)
-		os.Exit(1)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-		var errBody map[string]any
-		if json.NewDecoder(resp.Body).Decode(&errBody) == nil {
-			fmt.Fprintf(os.Stderr, "Error (HTTP %d): %v\n", resp.StatusCode, errBody)
-		} else {
-			fmt.Fprintf(os.Stderr, "Error: HTTP %d\n", resp.StatusCode)
-		}
-		os.Exit(1)
+	if *interactive {
+		runRepl(cc, *convID, *model, *cwd, promptText)
+		return
 	}
-
-	var respBody map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing response: %v\n", err)
-		os.Exit(1)
+	if promptText == "" {
+		fatalf("message required (-p PROMPT or pass as argument)")
 	}
 
-	cid, _ := respBody["conversation_id"].(string)
+	ctx := context.Background()
+	req := shelleyapi.ChatRequest{Message: promptText, Model: *model, Cwd: *cwd}
+	cid := *convID
 	if cid == "" {
-		cid = *convID // when continuing, the chat endpoint doesn't echo the ID back
-	}
-
-	// Only print conversation ID for new conversations
-	if *convID == "" {
+		resp, err := cc.api.NewConversation(ctx, req)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		cid = resp.ConversationID
+		// Only print conversation ID for new conversations
 		fmt.Fprintf(os.Stderr, "Conversation ID: %s\n", cid)
+	} else if err := cc.api.Chat(ctx, cid, req); err != nil {
+		fatalf("%v", err)
 	}
 
 	if *immediate {
@@ -354,84 +261,54 @@ func cmdChat(cc *clientConfig, args []string) {
 	}
 
 	// Stream the response to stdout
-	streamConversation(cc, client, baseURL, cid, true)
+	followConversation(cc, cid, true)
 }
 
-// streamConversation streams messages from a conversation until the turn ends.
-// If onlyNewAgentMessages is true, it skips user messages and only shows the latest agent turn.
-func streamConversation(cc *clientConfig, client *http.Client, baseURL, conversationID string, onlyNewAgentMessages bool) {
-	req, err := cc.newRequest("GET", baseURL+"/api/conversation/"+conversationID+"/stream", nil)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating request: %v\n", err)
-		os.Exit(1)
-	}
-	req.Header.Set("Accept", "text/event-stream")
-
-	resp, err := client.Do(req)
+// followConversation prints a conversation's messages until the agent's turn
+// ends. If onlyNewAgentMessages is true, it skips everything up to and
+// including the last user message.
+func followConversation(cc *clientConfig, conversationID string, onlyNewAgentMessages bool) {
+	ctx := context.Background()
+	snapshot, err := cc.api.GetConversation(ctx, conversationID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Fprintf(os.Stderr, "Error: HTTP %d\n", resp.StatusCode)
-		os.Exit(1)
+		fatalf("%v", err)
 	}
 
-	scanner := bufio.NewScanner(resp.Body)
-	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
-
-	seenSeqIDs := make(map[int64]bool)
-	var lastUserSeqID int64
-	firstBatch := true
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if !strings.HasPrefix(line, "data: ") {
-			continue
-		}
-		data := strings.TrimPrefix(line, "data: ")
-
-		var sr streamResponseWire
-		if err := json.Unmarshal([]byte(data), &sr); err != nil {
-			continue
+	var after int64 // print messages with a later sequence ID
+	if onlyNewAgentMessages {
+		for _, msg := range snapshot.Messages {
+			if msg.Type == "user" {
+				after = msg.SequenceID
+			}
 		}
-
-		if sr.Heartbeat || len(sr.Messages) == 0 {
+	}
+	done := false
+	for _, msg := range snapshot.Messages {
+		if msg.SequenceID <= after {
 			continue
 		}
+		printStreamedMessage(cc, msg)
+		after = msg.SequenceID
+		done = msg.EndsTurn()
+	}
+	if done {
+		return
+	}
 
-		// On first batch, find the last user message seq ID if we're filtering
-		if firstBatch && onlyNewAgentMessages {
-			for _, msg := range sr.Messages {
-				if msg.Type == "user" {
-					lastUserSeqID = msg.SequenceID
-				}
-			}
-			firstBatch = false
+	stream := cc.api.Stream(ctx, conversationID, snapshot.LastEventID)
+	defer stream.Close()
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			fatalf("reading stream: %v", err)
 		}
-
-		for _, msg := range sr.Messages {
-			if seenSeqIDs[msg.SequenceID] {
-				continue
-			}
-			seenSeqIDs[msg.SequenceID] = true
-
-			// Skip messages before and including the last user message when filtering
-			if onlyNewAgentMessages && msg.SequenceID <= lastUserSeqID {
+		for _, msg := range event.Data.Messages {
+			if msg.SequenceID <= after {
 				continue
 			}
-
-			if cc.output.jsonMode {
-				event := simplifyMessage(msg)
-				json.NewEncoder(cc.output.writer).Encode(event)
-			} else {
-				printMessageText(cc, msg)
-			}
-
-			endOfTurn := msg.EndOfTurn != nil && *msg.EndOfTurn
-			if (msg.Type == "agent" || msg.Type == "error") && endOfTurn {
+			printStreamedMessage(cc, msg)
+			after = msg.SequenceID
+			if msg.EndsTurn() {
 				if !cc.output.jsonMode {
 					fmt.Fprintln(cc.output.writer) // Final newline
 				}
@@ -439,43 +316,52 @@ func streamConversation(cc *clientConfig, client *http.Client, baseURL, conversa
 			}
 		}
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading stream: %v\n", err)
-		os.Exit(1)
+func printStreamedMessage(cc *clientConfig, msg shelleyapi.APIMessage) {
+	if cc.output.jsonMode {
+		json.NewEncoder(cc.output.writer).Encode(simplifyMessage(msg))
+	} else {
+		printMessageText(cc, msg)
 	}
 }
 
-// printMessageText prints a message in human-readable text format
-func printMessageText(cc *clientConfig, msg messageWire) {
-	if msg.LlmData == nil {
-		return
+// toolResultText joins the text of a tool result.
+func toolResultText(c llm.Content) string {
+	var texts []string
+	for _, r := range c.ToolResult {
+		if r.Text != "" {
+			texts = append(texts, r.Text)
+		}
 	}
+	return strings.Join(texts, "\n")
+}
 
-	var llmMsg llmMessageWire
-	if err := json.Unmarshal([]byte(*msg.LlmData), &llmMsg); err != nil {
+// printMessageText prints a message in human-readable text format
+func printMessageText(cc *clientConfig, msg shelleyapi.APIMessage) {
+	llmMsg, err := msg.LLMMessage()
+	if err != nil || llmMsg == nil {
 		return
 	}
 
 	for _, c := range llmMsg.Content {
 		switch c.Type {
-		case contentTypeThinking:
+		case llm.ContentTypeThinking:
 			if c.Thinking != "" {
 				// Print thinking in dim color
 				fmt.Fprint(cc.output.writer, cc.output.dim(c.Thinking))
 			}
-		case contentTypeText:
+		case llm.ContentTypeText:
 			if c.Text != "" {
 				fmt.Fprint(cc.output.writer, c.Text)
 			}
-		case contentTypeToolUse:
+		case llm.ContentTypeToolUse:
 			if c.ToolName != "" {
 				fmt.Fprintf(cc.output.writer, "\n%s\n", cc.output.cyan("["+c.ToolName+"]"))
 			}
-		case contentTypeToolResult:
+		case llm.ContentTypeToolResult:
 			// Tool results can be verbose, show abbreviated
-			if c.Text != "" {
-				text := c.Text
+			if text := toolResultText(c); text != "" {
 				if len(text) > 500 {
 					text = text[:500] + "..."
 				}
@@ -496,45 +382,20 @@ func cmdRead(cc *clientConfig, args []string) {
 	}
 	conversationID := fs.Arg(0)
 
-	client, baseURL, err := cc.newHTTPClient()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
 	if *follow {
-		streamConversation(cc, client, baseURL, conversationID, false)
+		followConversation(cc, conversationID, false)
 	} else {
-		readSnapshot(cc, client, baseURL, conversationID)
+		readSnapshot(cc, conversationID)
 	}
 }
 
-func readSnapshot(cc *clientConfig, client *http.Client, baseURL, conversationID string) {
-	req, err := cc.newRequest("GET", baseURL+"/api/conversation/"+conversationID, nil)
+func readSnapshot(cc *clientConfig, conversationID string) {
+	snapshot, err := cc.api.GetConversation(context.Background(), conversationID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating request: %v\n", err)
-		os.Exit(1)
+		fatalf("%v", err)
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Fprintf(os.Stderr, "Error: HTTP %d\n", resp.StatusCode)
-		os.Exit(1)
-	}
-
-	var sr streamResponseWire
-	if err := json.NewDecoder(resp.Body).Decode(&sr); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing response: %v\n", err)
-		os.Exit(1)
-	}
-
-	for _, msg := range sr.Messages {
+	for _, msg := range snapshot.Messages {
 		if cc.output.jsonMode {
 			json.NewEncoder(cc.output.writer).Encode(simplifyMessage(msg))
 		} else {
@@ -544,7 +405,7 @@ func readSnapshot(cc *clientConfig, client *http.Client, baseURL, conversationID
 }
 
 // printMessageForRead prints a message with role prefix for read command
-func printMessageForRead(cc *clientConfig, msg messageWire) {
+func printMessageForRead(cc *clientConfig, msg shelleyapi.APIMessage) {
 	var prefix string
 	switch msg.Type {
 	case "user":
@@ -557,27 +418,23 @@ func printMessageForRead(cc *clientConfig, msg messageWire) {
 		return
 	}
 
-	if msg.LlmData == nil {
-		return
-	}
-
-	var llmMsg llmMessageWire
-	if err := json.Unmarshal([]byte(*msg.LlmData), &llmMsg); err != nil {
+	llmMsg, err := msg.LLMMessage()
+	if err != nil || llmMsg == nil {
 		return
 	}
 
 	var texts []string
 	for _, c := range llmMsg.Content {
 		switch c.Type {
-		case contentTypeText:
+		case llm.ContentTypeText:
 			if c.Text != "" {
 				texts = append(texts, c.Text)
 			}
-		case contentTypeThinking:
+		case llm.ContentTypeThinking:
 			if c.Thinking != "" {
 				texts = append(texts, cc.output.dim("[thinking] "+c.Thinking))
 			}
-		case contentTypeToolUse:
+		case llm.ContentTypeToolUse:
 			if c.ToolName != "" {
 				texts = append(texts, cc.output.yellow("[tool: "+c.ToolName+"]"))
 			}
@@ -596,44 +453,23 @@ func cmdList(cc *clientConfig, args []string) {
 	query := fs.String("q", "", "Search query")
 	fs.Parse(args)
 
-	client, baseURL, err := cc.newHTTPClient()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	endpoint := "/api/conversations"
+	ctx := context.Background()
+	opts := shelleyapi.ListOptions{Limit: *limit, Query: *query}
+	var conversations []shelleyapi.ConversationWithState
 	if *archived {
-		endpoint = "/api/conversations/archived"
-	}
-
-	params := fmt.Sprintf("?limit=%d", *limit)
-	if *query != "" {
-		params += "&q=" + url.QueryEscape(*query)
-	}
-
-	req, err := cc.newRequest("GET", baseURL+endpoint+params, nil)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating request: %v\n", err)
-		os.Exit(1)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Fprintf(os.Stderr, "Error: HTTP %d\n", resp.StatusCode)
-		os.Exit(1)
-	}
-
-	var conversations []conversationWire
-	if err := json.NewDecoder(resp.Body).Decode(&conversations); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing response: %v\n", err)
-		os.Exit(1)
+		archivedConversations, err := cc.api.ListArchivedConversations(ctx, opts)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		for _, c := range archivedConversations {
+			conversations = append(conversations, shelleyapi.ConversationWithState{Conversation: c})
+		}
+	} else {
+		var err error
+		conversations, err = cc.api.ListConversations(ctx, opts)
+		if err != nil {
+			fatalf("%v", err)
+		}
 	}
 
 	if cc.output.jsonMode {
@@ -664,118 +500,43 @@ func cmdList(cc *clientConfig, args []string) {
 	}
 }
 
-func cmdArchive(cc *clientConfig, args []string) {
-	fs := flag.NewFlagSet("client archive", flag.ExitOnError)
+// conversationArg parses a command that takes only a conversation ID.
+func conversationArg(name string, args []string) string {
+	fs := flag.NewFlagSet("client "+name, flag.ExitOnError)
 	fs.Parse(args)
 
 	if fs.NArg() == 0 {
-		fmt.Fprintf(os.Stderr, "Usage: shelley client archive CONVERSATION_ID\n")
+		fmt.Fprintf(os.Stderr, "Usage: shelley client %s CONVERSATION_ID\n", name)
 		os.Exit(1)
 	}
-	conversationID := fs.Arg(0)
-
-	client, baseURL, err := cc.newHTTPClient()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	req, err := cc.newRequest("POST", baseURL+"/api/conversation/"+conversationID+"/archive", nil)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating request: %v\n", err)
-		os.Exit(1)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
+	return fs.Arg(0)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		fmt.Fprintf(os.Stderr, "Error: HTTP %d\n", resp.StatusCode)
-		os.Exit(1)
+func cmdArchive(cc *clientConfig, args []string) {
+	conversationID := conversationArg("archive", args)
+	if _, err := cc.api.ArchiveConversation(context.Background(), conversationID); err != nil {
+		fatalf("%v", err)
 	}
-
 	if !cc.output.jsonMode {
 		fmt.Fprintf(os.Stderr, "Archived %s\n", conversationID)
 	}
 }
 
 func cmdUnarchive(cc *clientConfig, args []string) {
-	fs := flag.NewFlagSet("client unarchive", flag.ExitOnError)
-	fs.Parse(args)
-
-	if fs.NArg() == 0 {
-		fmt.Fprintf(os.Stderr, "Usage: shelley client unarchive CONVERSATION_ID\n")
-		os.Exit(1)
+	conversationID := conversationArg("unarchive", args)
+	if _, err := cc.api.UnarchiveConversation(context.Background(), conversationID); err != nil {
+		fatalf("%v", err)
 	}
-	conversationID := fs.Arg(0)
-
-	client, baseURL, err := cc.newHTTPClient()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	req, err := cc.newRequest("POST", baseURL+"/api/conversation/"+conversationID+"/unarchive", nil)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating request: %v\n", err)
-		os.Exit(1)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Fprintf(os.Stderr, "Error: HTTP %d\n", resp.StatusCode)
-		os.Exit(1)
-	}
-
 	if !cc.output.jsonMode {
 		fmt.Fprintf(os.Stderr, "Unarchived %s\n", conversationID)
 	}
 }
 
 func cmdDelete(cc *clientConfig, args []string) {
-	fs := flag.NewFlagSet("client delete", flag.ExitOnError)
-	fs.Parse(args)
-
-	if fs.NArg() == 0 {
-		fmt.Fprintf(os.Stderr, "Usage: shelley client delete CONVERSATION_ID\n")
-		os.Exit(1)
-	}
-	conversationID := fs.Arg(0)
-
-	client, baseURL, err := cc.newHTTPClient()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	req, err := cc.newRequest("POST", baseURL+"/api/conversation/"+conversationID+"/delete", nil)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating request: %v\n", err)
-		os.Exit(1)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	conversationID := conversationArg("delete", args)
+	if err := cc.api.DeleteConversation(context.Background(), conversationID); err != nil {
+		fatalf("%v", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		fmt.Fprintf(os.Stderr, "Error: HTTP %d\n", resp.StatusCode)
-		os.Exit(1)
-	}
-
 	if !cc.output.jsonMode {
 		fmt.Fprintf(os.Stderr, "Deleted %s\n", conversationID)
 	}
@@ -785,34 +546,9 @@ func cmdModels(cc *clientConfig, args []string) {
 	fs := flag.NewFlagSet("client models", flag.ExitOnError)
 	fs.Parse(args)
 
-	client, baseURL, err := cc.newHTTPClient()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	req, err := cc.newRequest("GET", baseURL+"/api/models", nil)
+	models, err := cc.api.Models(context.Background())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating request: %v\n", err)
-		os.Exit(1)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Fprintf(os.Stderr, "Error: HTTP %d\n", resp.StatusCode)
-		os.Exit(1)
-	}
-
-	var models []modelWire
-	if err := json.NewDecoder(resp.Body).Decode(&models); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing response: %v\n", err)
-		os.Exit(1)
+		fatalf("%v", err)
 	}
 
 	if cc.output.jsonMode {
@@ -830,53 +566,6 @@ func cmdModels(cc *clientConfig, args []string) {
 	}
 }
 
-// --- Wire types for JSON parsing ---
-
-type streamResponseWire struct {
-	Messages  []messageWire `json:"messages"`
-	Heartbeat bool          `json:"heartbeat"`
-}
-
-type messageWire struct {
-	SequenceID int64   `json:"sequence_id"`
-	Type       string  `json:"type"`
-	LlmData    *string `json:"llm_data,omitempty"`
-	EndOfTurn  *bool   `json:"end_of_turn,omitempty"`
-}
-
-type llmMessageWire struct {
-	Content []llmContentWire `json:"Content"`
-}
-
-type llmContentWire struct {
-	Type     int    `json:"Type"`
-	Text     string `json:"Text,omitempty"`
-	Thinking string `json:"Thinking,omitempty"`
-	ToolName string `json:"ToolName,omitempty"`
-}
-
-type conversationWire struct {
-	ConversationID string  `json:"conversation_id"`
-	Slug           *string `json:"slug"`
-	CreatedAt      string  `json:"created_at"`
-	UpdatedAt      string  `json:"updated_at"`
-	Working        bool    `json:"working"`
-	Model          *string `json:"model"`
-}
-
-type modelWire struct {
-	ID    string `json:"id"`
-	Ready bool   `json:"ready"`
-}
-
-// Content type constants matching llm.ContentType iota values from llm/llm.go.
-const (
-	contentTypeThinking   = 1
-	contentTypeText       = 2
-	contentTypeToolUse    = 5
-	contentTypeToolResult = 6
-)
-
 // streamEvent is the simplified output format for JSON mode.
 type streamEvent struct {
 	SequenceID int64  `json:"sequence_id"`
@@ -887,7 +576,7 @@ type streamEvent struct {
 	EndOfTurn  bool   `json:"end_of_turn"`
 }
 
-func simplifyMessage(msg messageWire) streamEvent {
+func simplifyMessage(msg shelleyapi.APIMessage) streamEvent {
 	event := streamEvent{
 		SequenceID: msg.SequenceID,
 		Type:       msg.Type,
@@ -897,12 +586,8 @@ func simplifyMessage(msg messageWire) streamEvent {
 		event.EndOfTurn = *msg.EndOfTurn
 	}
 
-	if msg.LlmData == nil {
-		return event
-	}
-
-	var llmMsg llmMessageWire
-	if err := json.Unmarshal([]byte(*msg.LlmData), &llmMsg); err != nil {
+	llmMsg, err := msg.LLMMessage()
+	if err != nil || llmMsg == nil {
 		return event
 	}
 
@@ -910,21 +595,21 @@ func simplifyMessage(msg messageWire) streamEvent {
 	var thinkingTexts []string
 	for _, c := range llmMsg.Content {
 		switch c.Type {
-		case contentTypeThinking:
+		case llm.ContentTypeThinking:
 			if c.Thinking != "" {
 				thinkingTexts = append(thinkingTexts, c.Thinking)
 			}
-		case contentTypeText:
+		case llm.ContentTypeText:
 			if c.Text != "" {
 				texts = append(texts, c.Text)
 			}
-		case contentTypeToolUse:
+		case llm.ContentTypeToolUse:
 			if event.ToolName == "" && c.ToolName != "" {
 				event.ToolName = c.ToolName
 			}
-		case contentTypeToolResult:
-			if c.Text != "" {
-				texts = append(texts, c.Text)
+		case llm.ContentTypeToolResult:
+			if text := toolResultText(c); text != "" {
+				texts = append(texts, text)
 			}
 		}
 	}
@@ -945,6 +630,8 @@ Global Flags:
   -json          Output JSON instead of text
   -no-color      Disable colored output
   -H HEADER      Extra HTTP header "Name: Value" (repeatable)
+  -token TOKEN   API token, for servers with authentication enabled
+                 (default: $SHELLEY_TOKEN). The Unix socket needs none.
 
 Commands:
   chat [-p] PROMPT [-c ID] [-model MODEL] [--immediate]
@@ -953,6 +640,12 @@ Commands:
       Creates a new conversation unless -c is given.
       With --immediate, prints ID and exits without waiting.
       Use -p - to read prompt from stdin.
+      With -i, stays open for more messages like repl.
+
+  repl [-c ID] [-model MODEL] [-cwd DIR] [PROMPT]
+      Interactive session with line editing, history, streamed replies
+      and slash commands (/model, /cwd, /cancel, /distill, /fork, /diff).
+      Type /help inside it for details. Ctrl-C cancels the agent's turn.
 
   read [-f] CONVERSATION_ID
       Read messages from a conversation.
@@ -973,6 +666,12 @@ Commands:
   models
       List available models and their status.
 
+  usage [-since T] [-until T] [-by DIMS] [-model M] [-repo DIR] [-kind K] [-csv]
+      Report token usage, cost, cache hit ratio, turn latency and tool
+      calls, grouped by any of day, model, conversation, cwd, repo, kind
+      (turn or subagent) and tool. -by defaults to day; T is a date, an
+      RFC 3339 time or an age like 7d. -csv prints CSV for spreadsheets.
+
   help
       Print this help text.
 
@@ -995,5 +694,17 @@ Examples:
 
   # List models
   shelley client models
+
+  # What subagents cost in a repository over the last week, per model
+  shelley client usage -since 7d -kind subagent -repo ~/src/app -by model
+
+  # Interactive session, e.g. over SSH
+  shelley client repl
+
+  # Talk to a remote server with authentication enabled
+  SHELLEY_TOKEN=shelley_... shelley client -url https://host:9000 list
+
+Go programs can use the shelley.exe.dev/shelleyapi package instead of
+parsing this command's output.
 `, DefaultSocketPath())
 }
@@ -0,0 +1,844 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/diff"
+	"golang.org/x/term"
+
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/shelleyapi"
+)
+
+// collapsedLines is how many lines of a tool result the REPL shows before
+// hiding the rest behind /expand.
+const collapsedLines = 4
+
+// maxHistory is how many input lines the REPL remembers across sessions.
+const maxHistory = 1000
+
+const replHelp = `Type a message and press Enter to send it. End a line with \ or paste
+several lines to write a multi-line message, or put it between lines of """.
+Ctrl-C while the agent works cancels its turn; press it again to stop waiting.
+Ctrl-D exits.
+
+Commands:
+  /new              Start a new conversation
+  /model [MODEL]    List models, or pick one for new conversations
+  /cwd [DIR]        Show or set the working directory for new conversations
+  /cancel           Cancel the agent's current turn
+  /distill [MODEL]  Continue in a new conversation from a summary of this one
+  /fork             Continue in a copy of this conversation
+  /diff [ID]        List git changes, or show one of them
+  /expand [N]       Show the whole of collapsed output N (default: the last)
+  /id               Print the conversation ID
+  /help             Show this help
+  /quit             Exit
+Start a message with // to send it with a single leading slash.
+`
+
+// errInterrupted is returned by lineReader.ReadLine when the user presses
+// Ctrl-C at the prompt.
+var errInterrupted = errors.New("interrupted")
+
+// lineReader reads input lines. pasted reports that the line was pasted
+// and more lines of the same paste may follow.
+type lineReader interface {
+	ReadLine(prompt string) (line string, pasted bool, err error)
+}
+
+// repl is an interactive session with one conversation at a time.
+type repl struct {
+	cc *clientConfig
+	in lineReader
+	w  *lineWriter
+
+	conversationID string
+	lastEventID    int64
+	// model and cwd are used for new conversations; convModel and convCwd
+	// are the current conversation's.
+	model, cwd         string
+	convModel, convCwd string
+
+	// collapsed holds output hidden from view, for /expand.
+	collapsed []string
+	// toolNames maps tool use IDs to tool names, to label results.
+	toolNames map[string]string
+}
+
+func cmdRepl(cc *clientConfig, args []string) {
+	fs := flag.NewFlagSet("client repl", flag.ExitOnError)
+	convID := fs.String("c", "", "Conversation ID to continue (creates new if omitted)")
+	model := fs.String("model", "", "Model for new conversations (server default if empty)")
+	cwd := fs.String("cwd", "", "Working directory for new conversations")
+	fs.Parse(args)
+	runRepl(cc, *convID, *model, *cwd, strings.Join(fs.Args(), " "))
+}
+
+// runRepl runs an interactive session, first sending prompt if it is set.
+func runRepl(cc *clientConfig, conversationID, model, cwd, prompt string) {
+	if cc.output.jsonMode {
+		fatalf("the interactive client does not support -json")
+	}
+	r := &repl{
+		cc:        cc,
+		w:         &lineWriter{w: cc.output.writer, atLineStart: true},
+		model:     model,
+		cwd:       cwd,
+		toolNames: make(map[string]string),
+	}
+	r.cc.output.writer = r.w
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		tr := newTermReader(fd, loadHistory(historyPath()))
+		defer tr.Close()
+		r.in = tr
+	} else {
+		r.in = &plainReader{r: bufio.NewReader(os.Stdin)}
+	}
+
+	if conversationID != "" {
+		if err := r.attach(conversationID); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	if prompt != "" {
+		r.send(prompt)
+	}
+	r.run()
+}
+
+func (r *repl) run() {
+	for {
+		text, err := r.readMessage()
+		if errors.Is(err, errInterrupted) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "//") {
+			text = text[1:]
+		} else if strings.HasPrefix(text, "/") {
+			if quit := r.command(text); quit {
+				return
+			}
+			continue
+		}
+		r.send(text)
+	}
+}
+
+func (r *repl) prompt() string {
+	return r.cc.output.green("> ")
+}
+
+// readMessage reads one message, which may span several lines.
+func (r *repl) readMessage() (string, error) {
+	var lines []string
+	fenced := false
+	prompt := r.prompt()
+	for {
+		line, pasted, err := r.in.ReadLine(prompt)
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(lines) > 0 {
+				return strings.Join(lines, "\n"), nil
+			}
+			return "", err
+		}
+		prompt = r.cc.output.dim(". ")
+		switch {
+		case strings.TrimSpace(line) == `"""`:
+			if fenced {
+				return strings.Join(lines, "\n"), nil
+			}
+			if len(lines) == 0 {
+				fenced = true
+				continue
+			}
+			lines = append(lines, line)
+		case fenced || pasted:
+			lines = append(lines, line)
+		case strings.HasSuffix(line, `\`):
+			lines = append(lines, strings.TrimSuffix(line, `\`))
+		default:
+			lines = append(lines, line)
+			return strings.Join(lines, "\n"), nil
+		}
+	}
+}
+
+func (r *repl) printf(format string, args ...any) {
+	r.w.ensureNewline()
+	fmt.Fprintf(r.w, format, args...)
+}
+
+func (r *repl) errorf(format string, args ...any) {
+	r.printf("%s\n", r.cc.output.red("Error: "+fmt.Sprintf(format, args...)))
+}
+
+// attach switches to an existing conversation.
+func (r *repl) attach(conversationID string) error {
+	snapshot, err := r.cc.api.GetConversation(context.Background(), conversationID)
+	if err != nil {
+		return err
+	}
+	r.conversationID = conversationID
+	r.lastEventID = snapshot.LastEventID
+	r.updateConversation(snapshot.Conversation.Model, snapshot.Conversation.Cwd)
+	name := conversationID
+	if snapshot.Conversation.Slug != nil {
+		name = *snapshot.Conversation.Slug + " (" + conversationID + ")"
+	}
+	r.printf("%s\n", r.cc.output.dim(fmt.Sprintf("Conversation %s, %d messages", name, len(snapshot.Messages))))
+	return nil
+}
+
+func (r *repl) updateConversation(model, cwd *string) {
+	if model != nil {
+		r.convModel = *model
+	}
+	if cwd != nil {
+		r.convCwd = *cwd
+	}
+}
+
+// send sends a message and shows the agent's work until its turn ends.
+func (r *repl) send(text string) {
+	ctx := context.Background()
+	req := shelleyapi.ChatRequest{Message: text}
+	if r.conversationID == "" {
+		req.Model = r.model
+		req.Cwd = r.cwd
+		resp, err := r.cc.api.NewConversation(ctx, req)
+		if err != nil {
+			r.errorf("%v", err)
+			return
+		}
+		r.conversationID = resp.ConversationID
+		r.lastEventID = 0
+		r.printf("%s\n", r.cc.output.dim("Conversation "+resp.ConversationID))
+	} else if err := r.cc.api.Chat(ctx, r.conversationID, req); err != nil {
+		r.errorf("%v", err)
+		return
+	}
+
+	sawUserMessage := false
+	r.follow(text, true, func(event *shelleyapi.Event) bool {
+		for _, msg := range event.Data.Messages {
+			if msg.Type == "user" {
+				sawUserMessage = true
+			}
+			if sawUserMessage && msg.EndsTurn() {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// follow prints the conversation's events until done reports true. The first
+// user message with the text sent is not repeated. If cancellable, Ctrl-C
+// cancels the agent's turn, and a second Ctrl-C stops following.
+func (r *repl) follow(sent string, cancellable bool, done func(*shelleyapi.Event) bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Cancelling ctx stops the stream.
+	stream := r.cc.api.Stream(ctx, r.conversationID, r.lastEventID)
+
+	type result struct {
+		event *shelleyapi.Event
+		err   error
+	}
+	results := make(chan result)
+	go func() {
+		for {
+			event, err := stream.Next()
+			select {
+			case results <- result{event, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	streamedText, streamedThinking := false, false
+	cancelled := false
+	for {
+		select {
+		case <-interrupts:
+			if !cancellable || cancelled {
+				r.printf("%s\n", r.cc.output.dim("Stopped waiting; the agent may still be working."))
+				return
+			}
+			cancelled = true
+			r.printf("%s\n", r.cc.output.dim("Cancelling... (Ctrl-C again to stop waiting)"))
+			if err := r.cc.api.CancelConversation(context.Background(), r.conversationID); err != nil {
+				r.errorf("cancel: %v", err)
+			}
+		case res := <-results:
+			if res.err != nil {
+				r.errorf("reading stream: %v", res.err)
+				return
+			}
+			event := res.event
+			r.lastEventID = stream.LastEventID()
+			data := event.Data
+			r.updateConversation(data.Conversation.Model, data.Conversation.Cwd)
+			switch {
+			case data.StreamingThinking != "":
+				streamedThinking = true
+				fmt.Fprint(r.w, r.cc.output.dim(data.StreamingThinking))
+			case data.StreamingText != "":
+				if streamedThinking && !streamedText {
+					r.w.ensureNewline()
+				}
+				streamedText = true
+				fmt.Fprint(r.w, data.StreamingText)
+			}
+			for _, msg := range data.Messages {
+				if sent != "" && msg.Type == "user" && messageText(msg) == sent {
+					sent = ""
+					continue
+				}
+				r.printMessage(msg, streamedText, streamedThinking)
+				if msg.Type == "agent" {
+					streamedText, streamedThinking = false, false
+				}
+			}
+			if done(event) {
+				r.w.ensureNewline()
+				return
+			}
+		}
+	}
+}
+
+// printMessage renders a message. Text and thinking that were already
+// streamed as deltas are not printed again.
+func (r *repl) printMessage(msg shelleyapi.APIMessage, streamedText, streamedThinking bool) {
+	switch msg.Type {
+	case "user", "agent", "tool", "error":
+	default:
+		// System prompts and the like are not part of the dialogue.
+		return
+	}
+	out := &r.cc.output
+	llmMsg, err := msg.LLMMessage()
+	if err != nil || llmMsg == nil {
+		return
+	}
+	for _, c := range llmMsg.Content {
+		switch c.Type {
+		case llm.ContentTypeThinking:
+			if c.Thinking != "" && !streamedThinking {
+				fmt.Fprint(r.w, out.dim(c.Thinking))
+				r.w.ensureNewline()
+			}
+		case llm.ContentTypeText:
+			if c.Text == "" {
+				continue
+			}
+			switch {
+			case msg.Type == "error":
+				r.printf("%s\n", out.red(c.Text))
+			case msg.Type == "user":
+				if isDistilledMessage(msg) {
+					r.printf("%s\n", out.green("> ")+r.collapse(c.Text))
+				} else {
+					r.printf("%s\n", out.green("> "+c.Text))
+				}
+			case !streamedText:
+				r.w.ensureNewline()
+				fmt.Fprint(r.w, c.Text)
+			}
+		case llm.ContentTypeToolUse:
+			r.toolNames[c.ID] = c.ToolName
+			r.printf("%s %s\n", out.cyan("▸ "+c.ToolName), out.dim(toolInputSummary(c.ToolInput)))
+		case llm.ContentTypeToolResult:
+			text := toolResultText(c)
+			if text == "" {
+				continue
+			}
+			if c.ToolError {
+				r.printf("%s\n", out.red(indent(r.collapse(text))))
+			} else {
+				r.printf("%s\n", out.dim(indent(r.collapse(text))))
+			}
+		}
+	}
+}
+
+// messageText joins the text of a message.
+func messageText(msg shelleyapi.APIMessage) string {
+	llmMsg, err := msg.LLMMessage()
+	if err != nil || llmMsg == nil {
+		return ""
+	}
+	var texts []string
+	for _, c := range llmMsg.Content {
+		if c.Type == llm.ContentTypeText {
+			texts = append(texts, c.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func isDistilledMessage(msg shelleyapi.APIMessage) bool {
+	if msg.UserData == nil {
+		return false
+	}
+	var userData map[string]string
+	json.Unmarshal([]byte(*msg.UserData), &userData)
+	return userData["distilled"] == "true"
+}
+
+// collapse shortens text to its first few lines. The whole text can be
+// shown with /expand.
+func (r *repl) collapse(text string) string {
+	text = strings.TrimRight(text, "\n")
+	lines := strings.Split(text, "\n")
+	if len(lines) <= collapsedLines {
+		return text
+	}
+	r.collapsed = append(r.collapsed, text)
+	return fmt.Sprintf("%s\n... %d more lines (/expand %d)",
+		strings.Join(lines[:collapsedLines], "\n"), len(lines)-collapsedLines, len(r.collapsed))
+}
+
+func indent(text string) string {
+	return "  " + strings.ReplaceAll(text, "\n", "\n  ")
+}
+
+// toolInputSummary describes a tool call on one line: the command for
+// tools that run one, else the compacted input.
+func toolInputSummary(input json.RawMessage) string {
+	var fields map[string]any
+	if json.Unmarshal(input, &fields) == nil {
+		for _, key := range []string{"command", "path", "url"} {
+			if s, ok := fields[key].(string); ok {
+				return truncateLine(s, 100)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if json.Compact(&buf, input) != nil {
+		return ""
+	}
+	return truncateLine(buf.String(), 100)
+}
+
+func truncateLine(s string, n int) string {
+	s, _, cut := strings.Cut(s, "\n")
+	if len(s) > n {
+		s, cut = s[:n], true
+	}
+	if cut {
+		s += "..."
+	}
+	return s
+}
+
+// command runs a slash command and reports whether to exit.
+func (r *repl) command(line string) (quit bool) {
+	ctx := context.Background()
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "/quit", "/exit":
+		return true
+	case "/help":
+		r.printf("%s", replHelp)
+	case "/id":
+		if r.conversationID == "" {
+			r.printf("No conversation yet\n")
+		} else {
+			r.printf("%s\n", r.conversationID)
+		}
+	case "/new":
+		r.conversationID = ""
+		r.lastEventID = 0
+		r.convModel, r.convCwd = "", ""
+		r.printf("%s\n", r.cc.output.dim("The next message starts a new conversation."))
+	case "/model":
+		r.modelCommand(ctx, arg)
+	case "/cwd":
+		r.cwdCommand(ctx, arg)
+	case "/cancel":
+		if !r.requireConversation() {
+			break
+		}
+		if err := r.cc.api.CancelConversation(ctx, r.conversationID); err != nil {
+			r.errorf("%v", err)
+		}
+	case "/distill":
+		r.distillCommand(ctx, arg)
+	case "/fork":
+		if !r.requireConversation() {
+			break
+		}
+		fork, err := r.cc.api.ForkConversation(ctx, r.conversationID, shelleyapi.ForkConversationRequest{})
+		if err != nil {
+			r.errorf("%v", err)
+			break
+		}
+		if err := r.attach(fork.ConversationID); err != nil {
+			r.errorf("%v", err)
+		}
+	case "/diff":
+		r.diffCommand(ctx, arg)
+	case "/expand":
+		n := len(r.collapsed)
+		if arg != "" {
+			var err error
+			if n, err = strconv.Atoi(arg); err != nil {
+				r.errorf("usage: /expand [N]")
+				break
+			}
+		}
+		if n < 1 || n > len(r.collapsed) {
+			r.errorf("nothing to expand")
+			break
+		}
+		r.printf("%s\n", r.collapsed[n-1])
+	default:
+		r.errorf("unknown command %s (try /help)", name)
+	}
+	return false
+}
+
+func (r *repl) requireConversation() bool {
+	if r.conversationID == "" {
+		r.errorf("no conversation yet")
+		return false
+	}
+	return true
+}
+
+func (r *repl) modelCommand(ctx context.Context, arg string) {
+	models, err := r.cc.api.Models(ctx)
+	if err != nil {
+		r.errorf("%v", err)
+		return
+	}
+	if arg == "" {
+		current := r.model
+		if r.conversationID != "" && r.convModel != "" {
+			current = r.convModel
+		}
+		for _, m := range models {
+			marker := "  "
+			if m.ID == current {
+				marker = "* "
+			}
+			status := ""
+			if !m.Ready {
+				status = r.cc.output.red(" (not ready)")
+			}
+			r.printf("%s%s%s\n", marker, r.cc.output.cyan(m.ID), status)
+		}
+		return
+	}
+	for _, m := range models {
+		if m.ID == arg {
+			r.model = arg
+			if r.conversationID != "" && r.convModel != arg {
+				r.printf("%s\n", r.cc.output.dim("New conversations will use "+arg+". This one keeps its model; /new or /distill to switch."))
+			} else {
+				r.printf("%s\n", r.cc.output.dim("Using "+arg))
+			}
+			return
+		}
+	}
+	r.errorf("unknown model %s", arg)
+}
+
+func (r *repl) cwdCommand(ctx context.Context, arg string) {
+	if arg == "" {
+		switch {
+		case r.conversationID != "" && r.convCwd != "":
+			r.printf("%s\n", r.convCwd)
+		case r.cwd != "":
+			r.printf("%s\n", r.cwd)
+		default:
+			r.printf("%s\n", r.cc.output.dim("(server default)"))
+		}
+		return
+	}
+	if err := r.cc.api.ValidateCwd(ctx, arg); err != nil {
+		r.errorf("%v", err)
+		return
+	}
+	r.cwd = arg
+	if r.conversationID != "" {
+		r.printf("%s\n", r.cc.output.dim("New conversations will start in "+arg+"."))
+	}
+}
+
+func (r *repl) distillCommand(ctx context.Context, model string) {
+	if !r.requireConversation() {
+		return
+	}
+	resp, err := r.cc.api.DistillConversation(ctx, shelleyapi.DistillConversationRequest{
+		SourceConversationID: r.conversationID,
+		Model:                model,
+	})
+	if err != nil {
+		r.errorf("%v", err)
+		return
+	}
+	r.conversationID = resp.ConversationID
+	r.lastEventID = 0
+	r.printf("%s\n", r.cc.output.dim("Distilling into conversation "+resp.ConversationID+"..."))
+	r.follow("", false, func(event *shelleyapi.Event) bool {
+		return event.Job != nil && (event.Job.Status == "succeeded" || event.Job.Status == "failed")
+	})
+}
+
+func (r *repl) diffCommand(ctx context.Context, diffID string) {
+	cwd := r.cwd
+	if r.conversationID != "" && r.convCwd != "" {
+		cwd = r.convCwd
+	}
+	out := &r.cc.output
+	if diffID == "" {
+		diffs, err := r.cc.api.GitDiffs(ctx, cwd)
+		if err != nil {
+			r.errorf("%v", err)
+			return
+		}
+		for _, d := range diffs.Diffs {
+			id := d.ID
+			if len(id) > 12 {
+				id = id[:12]
+			}
+			r.printf("%s  %s  %s %s\n", out.cyan(fmt.Sprintf("%-12s", id)), truncateLine(d.Message, 60),
+				out.green(fmt.Sprintf("+%d", d.Additions)), out.red(fmt.Sprintf("-%d", d.Deletions)))
+		}
+		return
+	}
+	files, err := r.cc.api.GitDiffFiles(ctx, cwd, diffID)
+	if err != nil {
+		r.errorf("%v", err)
+		return
+	}
+	for _, f := range files {
+		if f.IsGenerated {
+			r.printf("%s\n", out.dim(f.Path+" (generated)"))
+			continue
+		}
+		fileDiff, err := r.cc.api.GitFileDiff(ctx, cwd, diffID, f.Path)
+		if err != nil {
+			r.errorf("%s: %v", f.Path, err)
+			continue
+		}
+		var buf bytes.Buffer
+		diff.Text("a/"+f.Path, "b/"+f.Path, fileDiff.OldContent, fileDiff.NewContent, &buf)
+		r.w.ensureNewline()
+		for _, line := range strings.SplitAfter(buf.String(), "\n") {
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				fmt.Fprint(r.w, out.yellow(line))
+			case strings.HasPrefix(line, "+"):
+				fmt.Fprint(r.w, out.green(line))
+			case strings.HasPrefix(line, "-"):
+				fmt.Fprint(r.w, out.red(line))
+			case strings.HasPrefix(line, "@@"):
+				fmt.Fprint(r.w, out.magenta(line))
+			default:
+				fmt.Fprint(r.w, line)
+			}
+		}
+	}
+}
+
+// lineWriter tracks whether output ends at the start of a line, so messages
+// can begin on a fresh line after streamed text.
+type lineWriter struct {
+	w           io.Writer
+	atLineStart bool
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		lw.atLineStart = p[len(p)-1] == '\n'
+	}
+	return lw.w.Write(p)
+}
+
+func (lw *lineWriter) ensureNewline() {
+	if !lw.atLineStart {
+		lw.Write([]byte("\n"))
+	}
+}
+
+// plainReader reads lines from a pipe or file.
+type plainReader struct {
+	r *bufio.Reader
+}
+
+func (p *plainReader) ReadLine(prompt string) (string, bool, error) {
+	line, err := p.r.ReadString('\n')
+	if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+		return "", false, err
+	}
+	return strings.TrimRight(line, "\r\n"), false, nil
+}
+
+// termReader reads lines from a terminal with line editing and history.
+// The terminal is in raw mode only while reading, so Ctrl-C sends SIGINT
+// while the agent works.
+type termReader struct {
+	fd      int
+	in      *interruptReader
+	history term.History
+	t       *term.Terminal
+}
+
+func newTermReader(fd int, history term.History) *termReader {
+	tr := &termReader{fd: fd, in: &interruptReader{r: os.Stdin}, history: history}
+	tr.reset()
+	return tr
+}
+
+func (tr *termReader) reset() {
+	tr.t = term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{tr.in, os.Stdout}, "")
+	tr.t.History = tr.history
+}
+
+func (tr *termReader) ReadLine(prompt string) (string, bool, error) {
+	state, err := term.MakeRaw(tr.fd)
+	if err != nil {
+		return "", false, err
+	}
+	defer term.Restore(tr.fd, state)
+	if width, height, err := term.GetSize(tr.fd); err == nil {
+		tr.t.SetSize(width, height)
+	}
+	tr.t.SetBracketedPasteMode(true)
+	defer tr.t.SetBracketedPasteMode(false)
+	tr.t.SetPrompt(prompt)
+
+	tr.in.interrupted = false
+	line, err := tr.t.ReadLine()
+	switch {
+	case errors.Is(err, term.ErrPasteIndicator):
+		return line, true, nil
+	case errors.Is(err, io.EOF) && tr.in.interrupted:
+		// term.Terminal reports Ctrl-C as EOF and keeps the line; start
+		// afresh instead.
+		os.Stdout.WriteString("^C\r\n")
+		tr.reset()
+		return "", false, errInterrupted
+	case err != nil:
+		os.Stdout.WriteString("\r\n")
+		return "", false, err
+	}
+	return line, false, nil
+}
+
+func (tr *termReader) Close() error {
+	if h, ok := tr.history.(*fileHistory); ok {
+		return h.Close()
+	}
+	return nil
+}
+
+// interruptReader notes when Ctrl-C is typed in raw mode.
+type interruptReader struct {
+	r           io.Reader
+	interrupted bool
+}
+
+func (ir *interruptReader) Read(p []byte) (int, error) {
+	n, err := ir.r.Read(p)
+	if bytes.IndexByte(p[:n], 3) >= 0 {
+		ir.interrupted = true
+	}
+	return n, err
+}
+
+func historyPath() string {
+	return filepath.Join(filepath.Dir(DefaultSocketPath()), "client_history")
+}
+
+// fileHistory is a term.History that appends each line to a file, so it
+// carries over to later sessions.
+type fileHistory struct {
+	entries []string // oldest first
+	f       *os.File
+}
+
+// loadHistory reads the history file at path. If the file cannot be
+// opened, history is kept only for this session.
+func loadHistory(path string) *fileHistory {
+	h := &fileHistory{}
+	if data, err := os.ReadFile(path); err == nil {
+		for line := range strings.Lines(string(data)) {
+			if line = strings.TrimRight(line, "\n"); line != "" {
+				h.entries = append(h.entries, line)
+			}
+		}
+		if len(h.entries) > maxHistory {
+			h.entries = h.entries[len(h.entries)-maxHistory:]
+			// Rewrite the file so it doesn't grow forever.
+			os.WriteFile(path, []byte(strings.Join(h.entries, "\n")+"\n"), 0o600)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err == nil {
+		h.f, _ = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	}
+	return h
+}
+
+func (h *fileHistory) Add(entry string) {
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[1:]
+	}
+	if h.f != nil {
+		h.f.WriteString(entry + "\n")
+	}
+}
+
+func (h *fileHistory) Len() int { return len(h.entries) }
+
+func (h *fileHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
+
+func (h *fileHistory) Close() error {
+	if h.f == nil {
+		return nil
+	}
+	return h.f.Close()
+}
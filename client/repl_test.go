@@ -0,0 +1,141 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type scriptedLine struct {
+	text   string
+	pasted bool
+}
+
+// scriptedReader replays lines as if typed.
+type scriptedReader struct {
+	lines []scriptedLine
+}
+
+func (s *scriptedReader) ReadLine(prompt string) (string, bool, error) {
+	if len(s.lines) == 0 {
+		return "", false, io.EOF
+	}
+	l := s.lines[0]
+	s.lines = s.lines[1:]
+	return l.text, l.pasted, nil
+}
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []scriptedLine
+		want  []string
+	}{
+		{
+			name:  "single lines",
+			lines: []scriptedLine{{text: "hello"}, {text: "/help"}},
+			want:  []string{"hello", "/help"},
+		},
+		{
+			name:  "backslash continuation",
+			lines: []scriptedLine{{text: `first\`}, {text: "second"}},
+			want:  []string{"first\nsecond"},
+		},
+		{
+			name:  "fenced",
+			lines: []scriptedLine{{text: `"""`}, {text: "a"}, {text: ""}, {text: `b\`}, {text: `"""`}},
+			want:  []string{"a\n\nb\\"},
+		},
+		{
+			name:  "pasted",
+			lines: []scriptedLine{{text: "one", pasted: true}, {text: "two", pasted: true}, {text: "three"}},
+			want:  []string{"one\ntwo\nthree"},
+		},
+		{
+			name:  "unterminated at EOF",
+			lines: []scriptedLine{{text: `"""`}, {text: "left open"}},
+			want:  []string{"left open"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &repl{cc: &clientConfig{}, in: &scriptedReader{lines: tt.lines}}
+			var got []string
+			for {
+				msg, err := r.readMessage()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, msg)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	r := &repl{}
+	if got := r.collapse("a\nb\n"); got != "a\nb" {
+		t.Errorf("short text changed: %q", got)
+	}
+	long := "1\n2\n3\n4\n5\n6"
+	got := r.collapse(long)
+	if want := "1\n2\n3\n4\n... 2 more lines (/expand 1)"; got != want {
+		t.Errorf("collapse = %q, want %q", got, want)
+	}
+	if len(r.collapsed) != 1 || r.collapsed[0] != long {
+		t.Errorf("collapsed text not kept: %q", r.collapsed)
+	}
+}
+
+func TestToolInputSummary(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"command": "ls -la"}`, "ls -la"},
+		{`{"command": "cat <<EOF\nhello\nEOF"}`, "cat <<EOF..."},
+		{`{"path": "/tmp/x", "patches": []}`, "/tmp/x"},
+		{`{"a": 1}`, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		if got := toolInputSummary(json.RawMessage(tt.input)); got != tt.want {
+			t.Errorf("toolInputSummary(%s) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFileHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "client_history")
+	h := loadHistory(path)
+	h.Add("first")
+	h.Add("second")
+	h.Add("second")
+	h.Close()
+
+	h = loadHistory(path)
+	defer h.Close()
+	if h.Len() != 2 || h.At(0) != "second" || h.At(1) != "first" {
+		t.Errorf("unexpected history %q", h.entries)
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var buf bytes.Buffer
+	lw := &lineWriter{w: &buf, atLineStart: true}
+	lw.ensureNewline()
+	lw.Write([]byte("streamed"))
+	lw.ensureNewline()
+	lw.ensureNewline()
+	if buf.String() != "streamed\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"shelley.exe.dev/shelleyapi"
+)
+
+// parseUsageTime parses a -since or -until flag: a date (UTC; for -until,
+// the whole day is included), an RFC 3339 time, or a number of days or a
+// duration before now, such as 7d or 36h.
+func parseUsageTime(s string, endOfDay bool, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want YYYY-MM-DD, RFC 3339, or an age like 7d or 36h)", s)
+}
+
+func cmdUsage(cc *clientConfig, args []string) {
+	fs := flag.NewFlagSet("client usage", flag.ExitOnError)
+	since := fs.String("since", "", "Start of the period: YYYY-MM-DD, RFC 3339, or an age like 7d (default: 7 days before -until)")
+	until := fs.String("until", "", "End of the period, inclusive for dates (default now)")
+	by := fs.String("by", "day", "Comma-separated dimensions: day, model, conversation, cwd, repo, kind, tool (empty for totals)")
+	model := fs.String("model", "", "Only usage by this model")
+	cwd := fs.String("cwd", "", "Only usage in conversations with this working directory")
+	repo := fs.String("repo", "", "Only usage in this git repository (its main worktree root)")
+	kind := fs.String("kind", "", "Only turn or subagent usage")
+	conversationID := fs.String("c", "", "Only usage in this conversation")
+	csvFlag := fs.Bool("csv", false, "Print CSV")
+	fs.Parse(args)
+
+	now := time.Now()
+	opts := shelleyapi.UsageOptions{
+		Model:          *model,
+		Cwd:            *cwd,
+		Repo:           *repo,
+		Kind:           *kind,
+		ConversationID: *conversationID,
+	}
+	var err error
+	if opts.Since, err = parseUsageTime(*since, false, now); err != nil {
+		fatalf("-since: %v", err)
+	}
+	if opts.Until, err = parseUsageTime(*until, true, now); err != nil {
+		fatalf("-until: %v", err)
+	}
+	for _, dim := range strings.Split(*by, ",") {
+		if dim = strings.TrimSpace(dim); dim != "" {
+			opts.GroupBy = append(opts.GroupBy, dim)
+		}
+	}
+
+	ctx := context.Background()
+	if *csvFlag {
+		body, err := cc.api.UsageCSV(ctx, opts)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		defer body.Close()
+		if _, err := io.Copy(cc.output.writer, body); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+
+	usage, err := cc.api.Usage(ctx, opts)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if cc.output.jsonMode {
+		json.NewEncoder(cc.output.writer).Encode(usage)
+		return
+	}
+	printUsage(cc, usage)
+}
+
+func printUsage(cc *clientConfig, usage *shelleyapi.UsageResponse) {
+	fmt.Fprintf(cc.output.writer, "%s\n", cc.output.dim(fmt.Sprintf("%s to %s (UTC)",
+		usage.Since.Format(time.DateTime), usage.Until.Format(time.DateTime))))
+
+	tw := tabwriter.NewWriter(cc.output.writer, 0, 4, 2, ' ', 0)
+	var header []string
+	for _, dim := range usage.GroupBy {
+		header = append(header, strings.ToUpper(dim))
+	}
+	header = append(header, "COST", "INPUT", "OUTPUT", "CACHE HIT", "TURNS", "AVG LATENCY", "AVG COST", "TOOL CALLS")
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	printRow := func(row shelleyapi.UsageRow, dims []string) {
+		var fields []string
+		for _, dim := range dims {
+			fields = append(fields, usageDimension(row, dim))
+		}
+		fields = append(fields,
+			formatCost(row.CostUSD),
+			strconv.FormatUint(row.InputTokens+row.CacheCreationInputTokens+row.CacheReadInputTokens, 10),
+			strconv.FormatUint(row.OutputTokens, 10),
+			fmt.Sprintf("%.0f%%", row.CacheHitRatio*100),
+			strconv.FormatInt(row.Turns, 10),
+			(time.Duration(row.AvgTurnLatencySeconds * float64(time.Second))).Round(time.Second).String(),
+			formatCost(row.AvgTurnCostUSD),
+			strconv.FormatInt(row.ToolCalls, 10),
+		)
+		fmt.Fprintln(tw, strings.Join(fields, "\t"))
+	}
+	if len(usage.GroupBy) == 0 {
+		printRow(usage.Total, nil)
+	} else {
+		for _, row := range usage.Rows {
+			printRow(row, usage.GroupBy)
+		}
+		fmt.Fprint(tw, "TOTAL"+strings.Repeat("\t", len(usage.GroupBy)))
+		printRow(usage.Total, nil)
+	}
+	tw.Flush()
+}
+
+// formatCost shows cents, or more digits for amounts under a dollar.
+func formatCost(usd float64) string {
+	if usd < 1 {
+		return fmt.Sprintf("$%.4f", usd)
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+func usageDimension(row shelleyapi.UsageRow, dim string) string {
+	var v string
+	switch dim {
+	case shelleyapi.UsageByDay:
+		v = row.Day
+	case shelleyapi.UsageByModel:
+		v = row.Model
+	case shelleyapi.UsageByConversation:
+		v = row.ConversationID
+	case shelleyapi.UsageByCwd:
+		v = row.Cwd
+	case shelleyapi.UsageByRepo:
+		v = row.Repo
+	case shelleyapi.UsageByKind:
+		v = row.Kind
+	case shelleyapi.UsageByTool:
+		v = row.Tool
+	}
+	if v == "" {
+		return "-"
+	}
+	return v
+}
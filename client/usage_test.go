@@ -0,0 +1,35 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseUsageTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in       string
+		endOfDay bool
+		want     time.Time
+	}{
+		{"", false, time.Time{}},
+		{"2026-03-01", false, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"2026-03-01", true, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{"2026-03-01T08:30:00Z", true, time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{"7d", false, time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)},
+		{"36h", false, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseUsageTime(tt.in, tt.endOfDay, now)
+		if err != nil {
+			t.Errorf("parseUsageTime(%q): %v", tt.in, err)
+		} else if !got.Equal(tt.want) {
+			t.Errorf("parseUsageTime(%q, %v) = %v, want %v", tt.in, tt.endOfDay, got, tt.want)
+		}
+	}
+	for _, in := range []string{"yesterday", "-3d", "3w"} {
+		if _, err := parseUsageTime(in, false, now); err == nil {
+			t.Errorf("parseUsageTime(%q) succeeded", in)
+		}
+	}
+}
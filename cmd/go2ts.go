@@ -150,4 +150,6 @@ type notificationEventForTS struct {
 	ConversationID string                  `json:"conversation_id"`
 	Timestamp      string                  `json:"timestamp"`
 	Payload        any                     `json:"payload,omitempty"`
+	Cwd            string                  `json:"cwd,omitempty"`
+	Subagent       bool                    `json:"subagent,omitempty"`
 }
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/term"
+	"shelley.exe.dev/server"
+)
+
+// runAuth manages built-in authentication directly in the database, so it
+// works whether or not the server is running.
+func runAuth(global GlobalConfig, args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [global-flags] auth <subcommand> [flags]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Subcommands:\n")
+		fmt.Fprintf(os.Stderr, "  set-password                  Set the admin password and turn authentication on\n")
+		fmt.Fprintf(os.Stderr, "  disable                       Remove the admin password and turn authentication off\n")
+		fmt.Fprintf(os.Stderr, "  create-token -name N [flags]  Create an API token (printed once)\n")
+		fmt.Fprintf(os.Stderr, "  list-tokens                   List API tokens\n")
+		fmt.Fprintf(os.Stderr, "  revoke-token <token-id>       Revoke an API token\n")
+	}
+	if len(args) == 0 {
+		usage()
+		os.Exit(1)
+	}
+
+	logger := setupLogging(global.Debug)
+	database := setupDatabase(global.DBPath, logger)
+	defer database.Close()
+	ctx := context.Background()
+
+	switch args[0] {
+	case "set-password":
+		password, err := readPassword()
+		if err != nil {
+			fatalf("Error reading password: %v", err)
+		}
+		if password == "" {
+			fatalf("Password must not be empty (use 'auth disable' to turn authentication off)")
+		}
+		if err := server.SetAdminPassword(ctx, database, password); err != nil {
+			fatalf("Error setting password: %v", err)
+		}
+		fmt.Println("Admin password set. Authentication is required on TCP connections.")
+
+	case "disable":
+		if err := server.SetAdminPassword(ctx, database, ""); err != nil {
+			fatalf("Error disabling authentication: %v", err)
+		}
+		fmt.Println("Authentication disabled.")
+
+	case "create-token":
+		fs := flag.NewFlagSet("auth create-token", flag.ExitOnError)
+		name := fs.String("name", "", "Name to identify the token by")
+		scope := fs.String("scope", "chat", "Token scope: read, chat or admin")
+		expires := fs.String("expires", "90d", `Lifetime, e.g. "30d", "12h" or "never"`)
+		fs.Parse(args[1:])
+		if *name == "" {
+			fatalf("-name is required")
+		}
+		authScope, err := server.ParseAuthScope(*scope)
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		ttl, err := server.ParseTokenTTL(*expires)
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		secret, token, err := server.CreateAPIToken(ctx, database, *name, authScope, ttl)
+		if err != nil {
+			fatalf("Error creating token: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Created %s token %s (%s). It will not be shown again.\n", token.Scope, token.TokenID, token.Name)
+		fmt.Println(secret)
+
+	case "list-tokens":
+		tokens, err := database.ListAPITokens(ctx)
+		if err != nil {
+			fatalf("Error listing tokens: %v", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tSCOPE\tEXPIRES\tLAST USED")
+		for _, t := range tokens {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", t.TokenID, t.Name, t.Scope, formatTime(t.ExpiresAt, "never"), formatTime(t.LastUsedAt, "-"))
+		}
+		tw.Flush()
+
+	case "revoke-token":
+		if len(args) != 2 {
+			fatalf("Usage: %s auth revoke-token <token-id>", os.Args[0])
+		}
+		found, err := database.DeleteAPIToken(ctx, args[1])
+		if err != nil {
+			fatalf("Error revoking token: %v", err)
+		}
+		if !found {
+			fatalf("No token %s", args[1])
+		}
+		fmt.Printf("Revoked token %s.\n", args[1])
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown auth subcommand: %s\n", args[0])
+		usage()
+		os.Exit(1)
+	}
+}
+
+// readPassword prompts for a password without echo on a terminal, and
+// otherwise reads the first line of stdin.
+func readPassword() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	fmt.Fprint(os.Stderr, "New admin password: ")
+	first, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Repeat password: ")
+	second, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if string(first) != string(second) {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	return string(first), nil
+}
+
+func formatTime(t *time.Time, zero string) string {
+	if t == nil {
+		return zero
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
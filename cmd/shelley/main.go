@@ -10,14 +10,19 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"shelley.exe.dev/claudetool"
+	"shelley.exe.dev/claudetool/sandbox"
 	"shelley.exe.dev/client"
 	"shelley.exe.dev/db"
+	"shelley.exe.dev/llm"
 	"shelley.exe.dev/models"
+	"shelley.exe.dev/redact"
 	"shelley.exe.dev/server"
 	_ "shelley.exe.dev/server/notifications/channels" // register channel types
 	"shelley.exe.dev/templates"
+	"shelley.exe.dev/tracing"
 	"shelley.exe.dev/version"
 )
 
@@ -29,6 +34,8 @@ type GlobalConfig struct {
 	ConfigPath      string
 	TerminalURL     string
 	DefaultModel    string
+	SecretKeyFile   string
+	TraceFile       string
 }
 
 func main() {
@@ -41,6 +48,8 @@ func main() {
 	flag.BoolVar(&global.PredictableOnly, "predictable-only", false, "Use only the predictable service, ignoring all other models")
 	flag.StringVar(&global.ConfigPath, "config", "", "Path to shelley.json configuration file (optional)")
 	flag.StringVar(&global.DefaultModel, "default-model", defaultModelID, "Default model for web UI")
+	flag.StringVar(&global.TraceFile, "trace-file", "", "Append OpenTelemetry spans to this file as JSON lines (spans are also sent over OTLP if $OTEL_EXPORTER_OTLP_ENDPOINT is set)")
+	flag.StringVar(&global.SecretKeyFile, "secret-key-file", "", "Path to the master key encrypting stored API keys (default: $"+secretKeyEnv+", the OS keyring, or a file in the user config directory)")
 
 	// Custom usage function
 	flag.Usage = func() {
@@ -49,7 +58,10 @@ func main() {
 		flag.PrintDefaults()
 		fmt.Fprintf(flag.CommandLine.Output(), "\nCommands:\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "  serve [flags]                 Start the web server\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  run -p PROMPT [flags]         Run one prompt headlessly and print a JSON result\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "  client [flags] <subcommand>   CLI client (chat, read, list, archive) (experimental)\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  auth <subcommand>             Manage the admin password and API tokens\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  secrets <subcommand>          Inspect and rotate the keys encrypting stored API keys\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "  unpack-template <name> <dir>  Unpack a project template to a directory\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "  version                       Print version information as JSON\n")
 		fmt.Fprintf(flag.CommandLine.Output(), "\nUse '%s <command> -h' for command-specific help\n", os.Args[0])
@@ -68,8 +80,14 @@ func main() {
 	switch command {
 	case "serve":
 		runServe(global, args[1:])
+	case "run":
+		runRun(global, args[1:])
 	case "client":
 		client.Run(args[1:])
+	case "auth":
+		runAuth(global, args[1:])
+	case "secrets":
+		runSecrets(global, args[1:])
 	case "unpack-template":
 		runUnpackTemplate(args[1:])
 	case "version":
@@ -91,9 +109,11 @@ func runServe(global GlobalConfig, args []string) {
 	fs.Parse(args)
 
 	logger := setupLogging(global.Debug)
+	defer setupTracing(global.TraceFile, logger)()
 
 	database := setupDatabase(global.DBPath, logger)
 	defer database.Close()
+	unlockSecrets(database, global.SecretKeyFile, logger)
 
 	// Set the database path for system prompt generation
 	server.DBPath = global.DBPath
@@ -108,7 +128,8 @@ func runServe(global GlobalConfig, args []string) {
 	availableModels := llmManager.GetAvailableModels()
 	logger.Info("Available models", "models", strings.Join(availableModels, ", "))
 
-	toolSetConfig := setupToolSetConfig(llmManager, llmManager)
+	toolSetConfig := setupToolSetConfig(llmManager, llmManager, llmConfig.ServerTools)
+	toolSetConfig.Redactor = llmConfig.Redactor
 
 	// Create server
 	svr := server.NewServer(database, llmManager, toolSetConfig, logger, global.PredictableOnly, llmConfig.TerminalURL, llmConfig.DefaultModel, *requireHeader, llmConfig.Links, llmConfig.UpdateSource, llmConfig.SystemPrompt)
@@ -155,6 +176,23 @@ func runServe(global GlobalConfig, args []string) {
 	}
 }
 
+// setupTracing starts exporting spans, if configured, and returns a function
+// that flushes them.
+func setupTracing(traceFile string, logger *slog.Logger) func() {
+	shutdown, err := tracing.Setup(context.Background(), tracing.ConfigFromEnv(traceFile))
+	if err != nil {
+		logger.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			logger.Warn("Failed to flush traces", "error", err)
+		}
+	}
+}
+
 func setupLogging(debug bool) *slog.Logger {
 	logLevel := slog.LevelInfo
 	if debug {
@@ -180,6 +218,8 @@ func setupDatabase(dbPath string, logger *slog.Logger) *db.DB {
 		os.Exit(1)
 	}
 	logger.Debug("Database migrations completed successfully")
+	// Sandboxed commands could otherwise rewrite the settings governing them.
+	sandbox.ProtectServer("", 0, dbPath, dbPath+"-wal", dbPath+"-shm")
 	return database
 }
 
@@ -256,7 +296,7 @@ func runVersion() {
 	}
 }
 
-func setupToolSetConfig(llmProvider claudetool.LLMServiceProvider, llmManager server.LLMProvider) claudetool.ToolSetConfig {
+func setupToolSetConfig(llmProvider claudetool.LLMServiceProvider, llmManager server.LLMProvider, serverTools []string) claudetool.ToolSetConfig {
 	wd, err := os.Getwd()
 	if err != nil {
 		// Fallback to "/" if we can't get working directory
@@ -279,6 +319,7 @@ func setupToolSetConfig(llmProvider claudetool.LLMServiceProvider, llmManager se
 		EnableJITInstall: claudetool.EnableBashToolJITInstall,
 		EnableBrowser:    true,
 		AvailableModels:  availableModels,
+		ServerTools:      serverTools,
 	}
 }
 
@@ -294,6 +335,8 @@ func buildLLMConfig(logger *slog.Logger, configPath, terminalURL, defaultModel s
 		DB:              database,
 		Logger:          logger,
 	}
+	// Built-in secret detectors are on unless the config file turns them off.
+	llmCfg.Redactor, _ = redact.New(redact.Config{})
 
 	if configPath != "" {
 		data, err := os.ReadFile(configPath)
@@ -312,6 +355,9 @@ func buildLLMConfig(logger *slog.Logger, configPath, terminalURL, defaultModel s
 			NotificationChannels []map[string]any           `json:"notification_channels"`
 			UpdateSource         *server.UpdateSourceConfig `json:"update_source"`
 			SystemPrompt         string                     `json:"system_prompt"`
+			ServerTools          []string                   `json:"server_tools"`
+			Pricing              map[string]llm.Pricing     `json:"pricing"`
+			Redaction            redact.Config              `json:"redaction"`
 		}
 		if err := json.Unmarshal(data, &cfg); err != nil {
 			logger.Warn("Failed to parse config file", "path", configPath, "error", err)
@@ -366,6 +412,33 @@ func buildLLMConfig(logger *slog.Logger, configPath, terminalURL, defaultModel s
 			logger.Info("Update source configured", "owner", cfg.UpdateSource.Owner, "repo", cfg.UpdateSource.Repo, "branch", cfg.UpdateSource.Branch)
 		}
 
+		for _, t := range cfg.ServerTools {
+			if !llm.IsServerToolType(t) {
+				logger.Warn("Ignoring unknown server tool in config", "tool", t, "known", strings.Join(llm.ServerToolTypes, ", "))
+				continue
+			}
+			llmCfg.ServerTools = append(llmCfg.ServerTools, t)
+		}
+		if len(llmCfg.ServerTools) > 0 {
+			logger.Info("Server tools enabled", "tools", strings.Join(llmCfg.ServerTools, ", "))
+		}
+
+		if len(cfg.Pricing) > 0 {
+			llmCfg.Pricing = cfg.Pricing
+			logger.Info("Model pricing overrides configured", "count", len(cfg.Pricing))
+		}
+
+		if redactor, err := redact.New(cfg.Redaction); err != nil {
+			logger.Warn("Ignoring invalid redaction config; using built-in detectors", "error", err)
+		} else {
+			llmCfg.Redactor = redactor
+			if cfg.Redaction.Disabled {
+				logger.Info("Secret redaction disabled")
+			} else if len(cfg.Redaction.Patterns) > 0 {
+				logger.Info("Custom redaction patterns configured", "count", len(cfg.Redaction.Patterns))
+			}
+		}
+
 		if cfg.SystemPrompt != "" {
 			llmCfg.SystemPrompt = cfg.SystemPrompt
 			logger.Info("Custom system prompt configured")
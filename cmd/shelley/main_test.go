@@ -181,7 +181,7 @@ func TestSystemdListenerIntegration(t *testing.T) {
 	// Spawn shelley with the file descriptor as fd 3
 	// Note: We don't set LISTEN_PID here because we don't know the child PID yet.
 	// The systemdListener function handles missing LISTEN_PID gracefully.
-	cmd = exec.Command(binary, "-db", dbPath, "serve", "-systemd-activation")
+	cmd = exec.Command(binary, "-db", dbPath, "-secret-key-file", filepath.Join(tempDir, "secret.key"), "serve", "-systemd-activation")
 	// Build environment without LISTEN_PID (will be inherited from parent otherwise)
 	// and add LISTEN_FDS=1
 	env := make([]string, 0, len(os.Environ()))
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"shelley.exe.dev/gitstate"
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/server"
+	"shelley.exe.dev/shelleyapi"
+)
+
+// Exit codes of "shelley run".
+const (
+	runExitSuccess   = 0
+	runExitError     = 1 // setup failure, or the model or agent loop failed
+	runExitBudget    = 2 // the run hit -max-cost, -max-turns or -timeout
+	runExitToolError = 3 // the agent ended its turn after a failed tool call
+)
+
+// maxToolOutput is how much of each tool result the JSON result includes.
+const maxToolOutput = 4096
+
+type runOptions struct {
+	Prompt   string
+	Cwd      string
+	Model    string
+	MaxCost  float64
+	MaxTurns int
+	Timeout  time.Duration
+	// Progress, if set, receives a line per tool call.
+	Progress io.Writer
+}
+
+// runResult is what "shelley run" prints.
+type runResult struct {
+	Status          string                `json:"status"` // success, error, budget_exceeded or tool_error
+	ConversationID  string                `json:"conversation_id,omitempty"`
+	Model           string                `json:"model,omitempty"`
+	FinalMessage    string                `json:"final_message"`
+	Error           string                `json:"error,omitempty"`
+	ToolCalls       []runToolCall         `json:"tool_calls"`
+	Turns           int                   `json:"turns"`
+	Usage           llm.Usage             `json:"usage"`
+	Git             *runGitInfo           `json:"git,omitempty"`
+	FilesChanged    []gitstate.FileChange `json:"files_changed"`
+	DurationSeconds float64               `json:"duration_seconds"`
+}
+
+type runToolCall struct {
+	ID     string          `json:"id"`
+	Name   string          `json:"name"`
+	Input  json.RawMessage `json:"input"`
+	Output string          `json:"output,omitempty"`
+	Error  bool            `json:"error,omitempty"`
+}
+
+type runGitInfo struct {
+	Worktree string `json:"worktree"`
+	Branch   string `json:"branch,omitempty"`
+	Before   string `json:"before,omitempty"`
+	After    string `json:"after,omitempty"`
+}
+
+func (r *runResult) exitCode() int {
+	switch r.Status {
+	case "success":
+		return runExitSuccess
+	case "budget_exceeded":
+		return runExitBudget
+	case "tool_error":
+		return runExitToolError
+	}
+	return runExitError
+}
+
+// runRun runs one prompt to completion with an in-process server and prints
+// the result as JSON.
+func runRun(global GlobalConfig, args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	prompt := fs.String("p", "", "Prompt to run (use '-' to read from stdin)")
+	cwd := fs.String("cwd", ".", "Working directory for the agent")
+	model := fs.String("model", "", "Model to use (default: the global -model)")
+	maxCost := fs.Float64("max-cost", 0, "Stop once the run has cost this many US dollars (0 for no limit)")
+	maxTurns := fs.Int("max-turns", 0, "Stop after this many model responses (0 for no limit)")
+	timeout := fs.Duration("timeout", 30*time.Minute, "Stop after this long")
+	quiet := fs.Bool("q", false, "Don't report tool calls on stderr")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [global-flags] run -p PROMPT [flags]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Runs a prompt to completion without starting a server and prints a JSON\n")
+		fmt.Fprintf(fs.Output(), "result. Unless the global -db flag is given, a throwaway database is used.\n\n")
+		fmt.Fprintf(fs.Output(), "Exit status: 0 on success, 1 if the model or agent failed, 2 if a budget\n")
+		fmt.Fprintf(fs.Output(), "(-max-cost, -max-turns, -timeout) ran out, 3 if the last tool call failed.\n\n")
+		fmt.Fprintf(fs.Output(), "Flags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	promptText := *prompt
+	if promptText == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: reading stdin: %v\n", err)
+			os.Exit(runExitError)
+		}
+		promptText = string(data)
+	} else if promptText == "" {
+		promptText = strings.Join(fs.Args(), " ")
+	}
+	if strings.TrimSpace(promptText) == "" {
+		fs.Usage()
+		os.Exit(runExitError)
+	}
+	absCwd, err := filepath.Abs(*cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(runExitError)
+	}
+	opts := runOptions{
+		Prompt:   promptText,
+		Cwd:      absCwd,
+		Model:    *model,
+		MaxCost:  *maxCost,
+		MaxTurns: *maxTurns,
+		Timeout:  *timeout,
+	}
+	if opts.Model == "" {
+		opts.Model = global.Model
+	}
+	if !*quiet {
+		opts.Progress = os.Stderr
+	}
+
+	logLevel := slog.LevelWarn
+	if global.Debug {
+		logLevel = slog.LevelDebug
+	}
+	// stdout is reserved for the result.
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+	slog.SetDefault(logger)
+
+	dbSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "db" {
+			dbSet = true
+		}
+	})
+	dbPath, tmpDir := global.DBPath, ""
+	if !dbSet {
+		tmpDir, err = os.MkdirTemp("", "shelley-run-")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(runExitError)
+		}
+		dbPath = filepath.Join(tmpDir, "shelley.db")
+	}
+
+	result := headlessRun(global, dbPath, dbSet, logger, opts)
+	if tmpDir != "" {
+		os.RemoveAll(tmpDir)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(result)
+	os.Exit(result.exitCode())
+}
+
+// headlessRun starts a server on an in-memory listener and runs opts
+// against it. Secrets in the database are only unlocked if it is the
+// user's, not a throwaway one.
+func headlessRun(global GlobalConfig, dbPath string, unlock bool, logger *slog.Logger, opts runOptions) *runResult {
+	defer setupTracing(global.TraceFile, logger)()
+	database := setupDatabase(dbPath, logger)
+	defer database.Close()
+	if unlock {
+		unlockSecrets(database, global.SecretKeyFile, logger)
+	}
+	server.DBPath = dbPath
+
+	llmConfig := buildLLMConfig(logger, global.ConfigPath, global.TerminalURL, global.DefaultModel, database)
+	llmManager := server.NewLLMServiceManager(llmConfig)
+	toolSetConfig := setupToolSetConfig(llmManager, llmManager, llmConfig.ServerTools)
+	toolSetConfig.Redactor = llmConfig.Redactor
+	svr := server.NewServer(database, llmManager, toolSetConfig, logger, global.PredictableOnly, llmConfig.TerminalURL, llmConfig.DefaultModel, "", llmConfig.Links, nil, llmConfig.SystemPrompt)
+
+	mux := http.NewServeMux()
+	svr.RegisterRoutes(mux)
+	listener := newPipeListener()
+	httpServer := &http.Server{Handler: mux}
+	go httpServer.Serve(listener)
+	defer httpServer.Close()
+
+	api := shelleyapi.NewWithHTTPClient("http://shelley-run", &http.Client{
+		Transport: &http.Transport{DialContext: listener.DialContext},
+	})
+	return runConversation(context.Background(), api, opts)
+}
+
+// runConversation starts a conversation with opts.Prompt and follows it
+// until the agent's turn ends or a budget runs out.
+func runConversation(ctx context.Context, api *shelleyapi.Client, opts runOptions) *runResult {
+	start := time.Now()
+	result := &runResult{Model: opts.Model, ToolCalls: []runToolCall{}, FilesChanged: []gitstate.FileChange{}}
+	defer func() {
+		result.DurationSeconds = time.Since(start).Seconds()
+	}()
+
+	gitBefore := gitstate.GetGitState(opts.Cwd)
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	resp, err := api.NewConversation(ctx, shelleyapi.ChatRequest{Message: opts.Prompt, Model: opts.Model, Cwd: opts.Cwd})
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	result.ConversationID = resp.ConversationID
+
+	result.Status, result.Error = followRun(ctx, api, opts, result)
+	if result.Status == "budget_exceeded" {
+		// Stop the agent; the turn is over for this run either way.
+		if err := api.CancelConversation(context.WithoutCancel(ctx), result.ConversationID); err != nil && opts.Progress != nil {
+			fmt.Fprintf(opts.Progress, "cancelling: %v\n", err)
+		}
+	}
+	if result.Status == "success" && len(result.ToolCalls) > 0 && result.ToolCalls[len(result.ToolCalls)-1].Error {
+		result.Status = "tool_error"
+		result.Error = "the last tool call failed"
+	}
+
+	if gitBefore.IsRepo {
+		gitAfter := gitstate.GetGitState(opts.Cwd)
+		result.Git = &runGitInfo{
+			Worktree: gitAfter.Worktree,
+			Branch:   gitAfter.Branch,
+			Before:   gitBefore.Commit,
+			After:    gitAfter.Commit,
+		}
+		if changes, err := gitAfter.ChangedFiles(gitBefore.Commit); err == nil && changes != nil {
+			result.FilesChanged = changes
+		}
+	}
+	return result
+}
+
+// followRun records the conversation's messages into result and returns the
+// run's status and error.
+func followRun(ctx context.Context, api *shelleyapi.Client, opts runOptions, result *runResult) (status, errMsg string) {
+	stream := api.Stream(ctx, result.ConversationID, 0)
+	defer stream.Close()
+
+	seen := make(map[string]bool)
+	toolCalls := make(map[string]int) // tool use ID -> index in result.ToolCalls
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "budget_exceeded", fmt.Sprintf("timed out after %s", opts.Timeout)
+			}
+			return "error", fmt.Sprintf("reading stream: %v", err)
+		}
+		if model := event.Data.Conversation.Model; model != nil && *model != "" {
+			result.Model = *model
+		}
+		for _, msg := range event.Data.Messages {
+			if seen[msg.MessageID] {
+				continue
+			}
+			seen[msg.MessageID] = true
+			llmMsg, err := msg.LLMMessage()
+			if err != nil || llmMsg == nil {
+				continue
+			}
+
+			if msg.Type == "agent" {
+				result.Turns++
+				if msg.UsageData != nil {
+					var usage llm.Usage
+					if json.Unmarshal([]byte(*msg.UsageData), &usage) == nil {
+						result.Usage.Add(usage)
+					}
+				}
+			}
+			var texts []string
+			for _, c := range llmMsg.Content {
+				switch c.Type {
+				case llm.ContentTypeText:
+					if c.Text != "" {
+						texts = append(texts, c.Text)
+					}
+				case llm.ContentTypeToolUse:
+					toolCalls[c.ID] = len(result.ToolCalls)
+					result.ToolCalls = append(result.ToolCalls, runToolCall{ID: c.ID, Name: c.ToolName, Input: c.ToolInput})
+					if opts.Progress != nil {
+						fmt.Fprintf(opts.Progress, "[%s] %s\n", c.ToolName, summarizeToolInput(c.ToolInput))
+					}
+				case llm.ContentTypeToolResult:
+					i, ok := toolCalls[c.ToolUseID]
+					if !ok {
+						continue
+					}
+					var output []string
+					for _, r := range c.ToolResult {
+						if r.Text != "" {
+							output = append(output, r.Text)
+						}
+					}
+					text := strings.Join(output, "\n")
+					if len(text) > maxToolOutput {
+						text = cutUTF8(text, maxToolOutput) + "\n[truncated]"
+					}
+					result.ToolCalls[i].Output = text
+					result.ToolCalls[i].Error = c.ToolError
+					if c.ToolError && opts.Progress != nil {
+						fmt.Fprintf(opts.Progress, "[%s] failed\n", result.ToolCalls[i].Name)
+					}
+				}
+			}
+
+			switch msg.Type {
+			case "error":
+				return "error", strings.Join(texts, "\n")
+			case "agent":
+				if len(texts) > 0 {
+					result.FinalMessage = strings.Join(texts, "\n")
+				}
+				if msg.EndsTurn() {
+					return "success", ""
+				}
+				if opts.MaxCost > 0 && result.Usage.CostUSD >= opts.MaxCost {
+					return "budget_exceeded", fmt.Sprintf("cost $%.4f reached the limit of $%.4f", result.Usage.CostUSD, opts.MaxCost)
+				}
+				if opts.MaxTurns > 0 && result.Turns >= opts.MaxTurns {
+					return "budget_exceeded", fmt.Sprintf("reached the limit of %d turns", opts.MaxTurns)
+				}
+			}
+		}
+	}
+}
+
+// summarizeToolInput shortens a tool call's input to one line for progress
+// output.
+func summarizeToolInput(input json.RawMessage) string {
+	var fields map[string]any
+	s := string(input)
+	if json.Unmarshal(input, &fields) == nil {
+		if command, ok := fields["command"].(string); ok {
+			s = command
+		}
+	}
+	s, _, _ = strings.Cut(s, "\n")
+	if len(s) > 100 {
+		s = cutUTF8(s, 100) + "..."
+	}
+	return s
+}
+
+// cutUTF8 returns the longest prefix of s of at most n bytes that does not
+// split a UTF-8 character.
+func cutUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// pipeListener is a net.Listener whose connections are made in-process by
+// DialContext, so the server needs no port or socket.
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr { return pipeAddr{} }
+
+func (l *pipeListener) DialContext(ctx context.Context, _, _ string) (net.Conn, error) {
+	client, srv := net.Pipe()
+	select {
+	case l.conns <- srv:
+		return client, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "shelley-run" }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHeadlessRun(t *testing.T) {
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.email=test@example.com", "-c", "user.name=Test", "commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	global := GlobalConfig{Model: "predictable", PredictableOnly: true, DefaultModel: "predictable"}
+	logger := slog.New(slog.DiscardHandler)
+	run := func(opts runOptions) *runResult {
+		t.Helper()
+		opts.Cwd, opts.Model, opts.Timeout = repo, "predictable", time.Minute
+		dbPath := filepath.Join(t.TempDir(), "shelley.db")
+		return headlessRun(global, dbPath, false, logger, opts)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		result := run(runOptions{Prompt: "bash: echo hi > new.txt"})
+		if result.Status != "success" || result.exitCode() != runExitSuccess {
+			t.Fatalf("Expected success, got %q: %s", result.Status, result.Error)
+		}
+		if len(result.ToolCalls) != 1 || result.ToolCalls[0].Name != "bash" || result.ToolCalls[0].Error {
+			t.Errorf("Unexpected tool calls %+v", result.ToolCalls)
+		}
+		if result.FinalMessage == "" || result.Turns != 2 {
+			t.Errorf("Unexpected final message %q after %d turns", result.FinalMessage, result.Turns)
+		}
+		if len(result.FilesChanged) != 1 || result.FilesChanged[0].Path != "new.txt" || result.FilesChanged[0].Status != "added" {
+			t.Errorf("Unexpected files changed %+v", result.FilesChanged)
+		}
+		if result.Git == nil || result.Git.Before == "" {
+			t.Errorf("Expected git info, got %+v", result.Git)
+		}
+	})
+
+	t.Run("max turns", func(t *testing.T) {
+		result := run(runOptions{Prompt: "bash: sleep 30", MaxTurns: 1})
+		if result.Status != "budget_exceeded" || result.exitCode() != runExitBudget {
+			t.Errorf("Expected budget_exceeded, got %q: %s", result.Status, result.Error)
+		}
+	})
+
+	t.Run("tool error", func(t *testing.T) {
+		result := run(runOptions{Prompt: "bash: exit 1"})
+		if result.Status != "tool_error" || result.exitCode() != runExitToolError {
+			t.Errorf("Expected tool_error, got %q: %s", result.Status, result.Error)
+		}
+	})
+}
+
+func TestCutUTF8(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 3, "hel"},
+		{"aé", 2, "a"},
+		{"aé", 3, "aé"},
+		{"a🎉b", 4, "a"},
+	} {
+		if got := cutUTF8(tc.s, tc.n); got != tc.want {
+			t.Errorf("cutUTF8(%q, %d) = %q, want %q", tc.s, tc.n, got, tc.want)
+		}
+	}
+}
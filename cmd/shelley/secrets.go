@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"shelley.exe.dev/claudetool/sandbox"
+	"shelley.exe.dev/db"
+)
+
+// The master key encrypts the data keys that encrypt provider API keys and
+// OAuth tokens in the database. It is looked for, in order, in
+// $SHELLEY_SECRET_KEY, the -secret-key-file file, the OS keyring and the
+// default key file. If there is none, one is created in the keyring or,
+// failing that, the default key file. A keyring that cannot be read is not
+// taken to have no key, lest its key be replaced and the secrets lost.
+const (
+	secretKeyEnv   = "SHELLEY_SECRET_KEY"
+	keyringService = "shelley"
+	keyringAccount = "master-key"
+)
+
+// masterKeySource is where the master key is kept.
+type masterKeySource struct {
+	kind string // "env", "file" or "keyring"
+	path string // for "file"
+}
+
+func (s masterKeySource) String() string {
+	switch s.kind {
+	case "env":
+		return "$" + secretKeyEnv
+	case "file":
+		return s.path
+	default:
+		return "OS keyring"
+	}
+}
+
+func defaultKeyFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "shelley", "secret.key"), nil
+}
+
+func decodeMasterKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != db.MasterKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, base64-encoded", db.MasterKeySize)
+	}
+	return key, nil
+}
+
+// loadMasterKey finds the master key, creating one if there is none.
+// keyFile is the -secret-key-file flag.
+func loadMasterKey(keyFile string) ([]byte, masterKeySource, error) {
+	if encoded := os.Getenv(secretKeyEnv); encoded != "" {
+		key, err := decodeMasterKey(encoded)
+		return key, masterKeySource{kind: "env"}, err
+	}
+	if keyFile != "" {
+		return loadOrCreateKeyFile(keyFile)
+	}
+	encoded, keyringErr := keyringGet()
+	if keyringErr == nil {
+		key, err := decodeMasterKey(encoded)
+		return key, masterKeySource{kind: "keyring"}, err
+	}
+	path, err := defaultKeyFile()
+	if err != nil {
+		return nil, masterKeySource{}, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return loadOrCreateKeyFile(path)
+	}
+	if !errors.Is(keyringErr, errNoKeyringItem) {
+		return nil, masterKeySource{kind: "keyring"}, fmt.Errorf("%w; unlock the keyring, or give the key in $%s or -secret-key-file", keyringErr, secretKeyEnv)
+	}
+	key := db.GenerateMasterKey()
+	if keyringAdd(base64.StdEncoding.EncodeToString(key)) == nil {
+		return key, masterKeySource{kind: "keyring"}, nil
+	}
+	return loadOrCreateKeyFile(path)
+}
+
+func loadOrCreateKeyFile(path string) ([]byte, masterKeySource, error) {
+	source := masterKeySource{kind: "file", path: path}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := decodeMasterKey(string(data))
+		if err != nil {
+			return nil, source, fmt.Errorf("%s: %w", path, err)
+		}
+		return key, source, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, source, err
+	}
+	key := db.GenerateMasterKey()
+	if err := writeKeyFile(path, key); err != nil {
+		return nil, source, err
+	}
+	return key, source, nil
+}
+
+func writeKeyFile(path string, key []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600)
+}
+
+// keyringCommand runs a keyring tool, giving up if it waits on a prompt.
+func keyringCommand(stdin string, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// errNoKeyringItem is returned by keyringGet when the keyring was read and
+// holds no master key, or there is no keyring tool.
+var errNoKeyringItem = errors.New("no master key in the OS keyring")
+
+// keyringGet reads the master key from the macOS keychain or, elsewhere, the
+// Secret Service via secret-tool.
+func keyringGet() (string, error) {
+	var out string
+	var err error
+	if runtime.GOOS == "darwin" {
+		out, err = keyringCommand("", "security", "find-generic-password", "-s", keyringService, "-a", keyringAccount, "-w")
+	} else {
+		out, err = keyringCommand("", "secret-tool", "lookup", "service", keyringService, "account", keyringAccount)
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return out, nil
+	case errors.Is(err, exec.ErrNotFound):
+		return "", errNoKeyringItem
+	case !errors.As(err, &exitErr):
+		return "", fmt.Errorf("read the OS keyring: %w", err)
+	case runtime.GOOS == "darwin" && exitErr.ExitCode() == 44:
+		// errSecItemNotFound
+		return "", errNoKeyringItem
+	case runtime.GOOS != "darwin" && exitErr.ExitCode() == 1 && len(bytes.TrimSpace(exitErr.Stderr)) == 0:
+		// secret-tool fails silently when nothing matches, and says why
+		// when it cannot reach the Secret Service.
+		return "", errNoKeyringItem
+	}
+	return "", fmt.Errorf("read the OS keyring: %w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+}
+
+// keyringAdd stores a new master key in the OS keyring, failing rather than
+// replacing one that is there.
+func keyringAdd(encoded string) error {
+	if runtime.GOOS == "darwin" {
+		_, err := keyringCommand("", "security", "add-generic-password", "-s", keyringService, "-a", keyringAccount, "-w", encoded)
+		return err
+	}
+	// secret-tool always replaces, so make sure there is nothing to replace.
+	if _, err := keyringGet(); !errors.Is(err, errNoKeyringItem) {
+		return errors.New("the OS keyring may already hold a master key")
+	}
+	return keyringSet(encoded)
+}
+
+// keyringSet stores the master key in the OS keyring, replacing any there.
+func keyringSet(encoded string) error {
+	if runtime.GOOS == "darwin" {
+		_, err := keyringCommand("", "security", "add-generic-password", "-U", "-s", keyringService, "-a", keyringAccount, "-w", encoded)
+		return err
+	}
+	_, err := keyringCommand(encoded, "secret-tool", "store", "--label=Shelley master key", "service", keyringService, "account", keyringAccount)
+	return err
+}
+
+// unlockSecrets loads the master key and turns on encryption of stored
+// secrets, encrypting any left in plaintext.
+func unlockSecrets(database *db.DB, keyFile string, logger *slog.Logger) {
+	key, source, err := loadMasterKey(keyFile)
+	if err != nil {
+		logger.Error("Failed to load the master key for stored secrets", "source", source.String(), "error", err)
+		os.Exit(1)
+	}
+	if source.kind == "file" {
+		sandbox.ProtectServer("", 0, source.path)
+	}
+	n, err := database.UnlockSecrets(context.Background(), key)
+	if err != nil {
+		logger.Error("Failed to unlock stored secrets", "source", source.String(), "error", err)
+		os.Exit(1)
+	}
+	if n > 0 {
+		logger.Info("Encrypted stored secrets", "count", n)
+	}
+	logger.Debug("Stored secrets unlocked", "source", source.String())
+}
+
+// runSecrets reports on and rotates the keys encrypting stored secrets.
+func runSecrets(global GlobalConfig, args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [global-flags] secrets <subcommand> [flags]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Subcommands:\n")
+		fmt.Fprintf(os.Stderr, "  status                Show where the master key is and how secrets are stored\n")
+		fmt.Fprintf(os.Stderr, "  rotate [-master]      Re-encrypt secrets with a new data key (and master key)\n")
+	}
+	if len(args) == 0 {
+		usage()
+		os.Exit(1)
+	}
+
+	logger := setupLogging(global.Debug)
+	database := setupDatabase(global.DBPath, logger)
+	defer database.Close()
+	ctx := context.Background()
+
+	key, source, err := loadMasterKey(global.SecretKeyFile)
+	if err != nil {
+		fatalf("Error loading master key from %s: %v", source, err)
+	}
+	if _, err := database.UnlockSecrets(ctx, key); err != nil {
+		fatalf("Error unlocking secrets with the master key from %s: %v", source, err)
+	}
+
+	switch args[0] {
+	case "status":
+		status, err := database.SecretsStatus(ctx)
+		if err != nil {
+			fatalf("Error reading secrets: %v", err)
+		}
+		fmt.Printf("Master key:  %s\n", source)
+		fmt.Printf("Data key:    %s (created %s)\n", status.KeyID, formatTime(&status.KeyCreatedAt, "-"))
+		fmt.Printf("Encrypted:   %d\n", status.Encrypted)
+		fmt.Printf("Plaintext:   %d\n", status.Plaintext)
+
+	case "rotate":
+		fs := flag.NewFlagSet("secrets rotate", flag.ExitOnError)
+		newMaster := fs.Bool("master", false, "Also replace the master key")
+		fs.Parse(args[1:])
+		if !*newMaster {
+			if err := database.RotateSecrets(ctx, nil); err != nil {
+				fatalf("Error rotating data key: %v", err)
+			}
+			fmt.Println("Secrets re-encrypted with a new data key.")
+			return
+		}
+		rotateMasterKey(ctx, database, source)
+
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+// rotateMasterKey re-encrypts secrets under a new master key and saves it
+// where the old one was, arranging never to lose the only copy.
+func rotateMasterKey(ctx context.Context, database *db.DB, source masterKeySource) {
+	key := db.GenerateMasterKey()
+	encoded := base64.StdEncoding.EncodeToString(key)
+	switch source.kind {
+	case "file":
+		pending := source.path + ".new"
+		if err := writeKeyFile(pending, key); err != nil {
+			fatalf("Error writing new master key: %v", err)
+		}
+		if err := database.RotateSecrets(ctx, key); err != nil {
+			os.Remove(pending)
+			fatalf("Error rotating keys: %v", err)
+		}
+		if err := os.Rename(pending, source.path); err != nil {
+			fatalf("Secrets now use the new master key in %s, but it could not be moved to %s: %v", pending, source.path, err)
+		}
+	case "keyring":
+		if err := database.RotateSecrets(ctx, key); err != nil {
+			fatalf("Error rotating keys: %v", err)
+		}
+		if err := keyringSet(encoded); err != nil {
+			fatalf("Secrets now use a new master key, but it could not be saved to the keyring (%v). Save it yourself:\n%s", err, encoded)
+		}
+	case "env":
+		if err := database.RotateSecrets(ctx, key); err != nil {
+			fatalf("Error rotating keys: %v", err)
+		}
+		fmt.Printf("New master key; set %s to it before restarting the server:\n%s\n", secretKeyEnv, encoded)
+	}
+	fmt.Printf("Secrets re-encrypted with a new master key in %s. Restart any running server.\n", source)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeSecretTool puts a secret-tool on $PATH that runs lookup, and logs
+// the commands it is given.
+func fakeSecretTool(t *testing.T, lookup string) (log string) {
+	t.Helper()
+	if runtime.GOOS == "darwin" {
+		t.Skip("uses secret-tool")
+	}
+	dir := t.TempDir()
+	log = filepath.Join(dir, "log")
+	script := "#!/bin/sh\necho \"$1\" >> " + log + "\ncase \"$1\" in\nlookup) " + lookup + " ;;\nstore) cat > /dev/null ;;\nesac\n"
+	if err := os.WriteFile(filepath.Join(dir, "secret-tool"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(secretKeyEnv, "")
+	return log
+}
+
+func TestLoadMasterKeyUnreadableKeyring(t *testing.T) {
+	log := fakeSecretTool(t, "echo 'Cannot autolaunch D-Bus without X11 $DISPLAY' >&2; exit 1")
+	if _, _, err := loadMasterKey(""); err == nil || !strings.Contains(err.Error(), "D-Bus") {
+		t.Fatalf("loadMasterKey error = %v, want the keyring's", err)
+	}
+	data, _ := os.ReadFile(log)
+	if strings.Contains(string(data), "store") {
+		t.Error("a new master key was stored over the one in the keyring")
+	}
+	if path, _ := defaultKeyFile(); fileExists(path) {
+		t.Error("a new master key file was created")
+	}
+}
+
+func TestLoadMasterKeyEmptyKeyring(t *testing.T) {
+	log := fakeSecretTool(t, "exit 1")
+	_, source, err := loadMasterKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(log)
+	if source.kind != "keyring" || !strings.Contains(string(data), "store") {
+		t.Errorf("master key in %s, secret-tool called with %q", source, data)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
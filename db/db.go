@@ -18,6 +18,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	"shelley.exe.dev/db/generated"
@@ -41,6 +43,8 @@ func generateConversationID() (string, error) {
 // DB wraps the database connection pool and provides high-level operations
 type DB struct {
 	pool *Pool
+	// secrets encrypts secret columns once UnlockSecrets has been called.
+	secrets atomic.Pointer[secretBox]
 }
 
 // Config holds database configuration
@@ -375,6 +379,18 @@ func (db *DB) UpdateConversationModel(ctx context.Context, conversationID, model
 	})
 }
 
+// UpdateConversationSandbox sets a conversation's JSON-encoded sandbox
+// settings. A nil value turns the sandbox off.
+func (db *DB) UpdateConversationSandbox(ctx context.Context, conversationID string, sandbox *string) error {
+	return db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		return q.UpdateConversationSandbox(ctx, generated.UpdateConversationSandboxParams{
+			Sandbox:        sandbox,
+			ConversationID: conversationID,
+		})
+	})
+}
+
 // Message methods (moved from MessageService)
 
 // MessageType represents the type of message
@@ -664,7 +680,8 @@ func (db *DB) DeleteConversation(ctx context.Context, conversationID string) err
 	})
 }
 
-// CreateSubagentConversation creates a new subagent conversation with a parent
+// CreateSubagentConversation creates a new subagent conversation with a parent.
+// The subagent inherits its parent's sandbox settings.
 func (db *DB) CreateSubagentConversation(ctx context.Context, slug, parentID string, cwd *string) (*generated.Conversation, error) {
 	conversationID, err := generateConversationID()
 	if err != nil {
@@ -679,7 +696,83 @@ func (db *DB) CreateSubagentConversation(ctx context.Context, slug, parentID str
 			Cwd:                  cwd,
 			ParentConversationID: &parentID,
 		})
-		return err
+		if err != nil {
+			return err
+		}
+		parent, err := q.GetConversation(ctx, parentID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && parent.Sandbox == nil) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := q.UpdateConversationSandbox(ctx, generated.UpdateConversationSandboxParams{
+			Sandbox:        parent.Sandbox,
+			ConversationID: conversationID,
+		}); err != nil {
+			return err
+		}
+		conversation.Sandbox = parent.Sandbox
+		return nil
+	})
+	return &conversation, err
+}
+
+// ForkConversation copies a conversation's settings and its messages up to
+// and including upToSequenceID (all of them if it is 0) into a new
+// conversation. The copy has no slug.
+func (db *DB) ForkConversation(ctx context.Context, sourceID string, upToSequenceID int64) (*generated.Conversation, error) {
+	conversationID, err := generateConversationID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate conversation ID: %w", err)
+	}
+	var conversation generated.Conversation
+	err = db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		source, err := q.GetConversation(ctx, sourceID)
+		if err != nil {
+			return err
+		}
+		conversation, err = q.CreateConversation(ctx, generated.CreateConversationParams{
+			ConversationID: conversationID,
+			UserInitiated:  true,
+			Cwd:            source.Cwd,
+			Model:          source.Model,
+		})
+		if err != nil {
+			return err
+		}
+		if source.Sandbox != nil {
+			if err := q.UpdateConversationSandbox(ctx, generated.UpdateConversationSandboxParams{
+				Sandbox:        source.Sandbox,
+				ConversationID: conversationID,
+			}); err != nil {
+				return err
+			}
+			conversation.Sandbox = source.Sandbox
+		}
+		messages, err := q.ListMessages(ctx, sourceID)
+		if err != nil {
+			return err
+		}
+		for _, m := range messages {
+			if upToSequenceID > 0 && m.SequenceID > upToSequenceID {
+				break
+			}
+			if _, err := q.CreateMessage(ctx, generated.CreateMessageParams{
+				MessageID:           uuid.New().String(),
+				ConversationID:      conversationID,
+				SequenceID:          m.SequenceID,
+				Type:                m.Type,
+				LlmData:             m.LlmData,
+				UserData:            m.UserData,
+				UsageData:           m.UsageData,
+				DisplayData:         m.DisplayData,
+				ExcludedFromContext: m.ExcludedFromContext,
+			}); err != nil {
+				return fmt.Errorf("failed to copy message %s: %w", m.MessageID, err)
+			}
+		}
+		return nil
 	})
 	return &conversation, err
 }
@@ -947,7 +1040,15 @@ func (db *DB) GetModels(ctx context.Context) ([]generated.Model, error) {
 		models, err = q.GetModels(ctx)
 		return err
 	})
-	return models, err
+	if err != nil {
+		return nil, err
+	}
+	for i := range models {
+		if models[i].ApiKey, err = db.decryptSecret(ctx, secretModelAPIKey, models[i].ModelID, models[i].ApiKey); err != nil {
+			return nil, fmt.Errorf("model %s: %w", models[i].ModelID, err)
+		}
+	}
+	return models, nil
 }
 
 // GetModel returns a model by ID
@@ -962,13 +1063,22 @@ func (db *DB) GetModel(ctx context.Context, modelID string) (*generated.Model, e
 	if err != nil {
 		return nil, err
 	}
+	if model.ApiKey, err = db.decryptSecret(ctx, secretModelAPIKey, model.ModelID, model.ApiKey); err != nil {
+		return nil, err
+	}
 	return &model, nil
 }
 
-// CreateModel creates a new model
+// CreateModel creates a new model. Its API key is encrypted once secrets
+// are unlocked.
 func (db *DB) CreateModel(ctx context.Context, params generated.CreateModelParams) (*generated.Model, error) {
 	var model generated.Model
-	err := db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+	apiKey := params.ApiKey
+	var err error
+	if params.ApiKey, err = db.encryptSecret(ctx, secretModelAPIKey, params.ModelID, apiKey); err != nil {
+		return nil, err
+	}
+	err = db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
 		q := generated.New(tx.Conn())
 		var err error
 		model, err = q.CreateModel(ctx, params)
@@ -977,13 +1087,19 @@ func (db *DB) CreateModel(ctx context.Context, params generated.CreateModelParam
 	if err != nil {
 		return nil, err
 	}
+	model.ApiKey = apiKey
 	return &model, nil
 }
 
 // UpdateModel updates a model
 func (db *DB) UpdateModel(ctx context.Context, params generated.UpdateModelParams) (*generated.Model, error) {
 	var model generated.Model
-	err := db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+	apiKey := params.ApiKey
+	var err error
+	if params.ApiKey, err = db.encryptSecret(ctx, secretModelAPIKey, params.ModelID, apiKey); err != nil {
+		return nil, err
+	}
+	err = db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
 		q := generated.New(tx.Conn())
 		var err error
 		model, err = q.UpdateModel(ctx, params)
@@ -992,6 +1108,7 @@ func (db *DB) UpdateModel(ctx context.Context, params generated.UpdateModelParam
 	if err != nil {
 		return nil, err
 	}
+	model.ApiKey = apiKey
 	return &model, nil
 }
 
@@ -1074,6 +1191,65 @@ func (db *DB) DeleteNotificationChannel(ctx context.Context, channelID string) e
 	})
 }
 
+// CreateNotificationDelivery queues an event for delivery to a notification channel.
+func (db *DB) CreateNotificationDelivery(ctx context.Context, params generated.CreateNotificationDeliveryParams) (*generated.NotificationDelivery, error) {
+	var d generated.NotificationDelivery
+	err := db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		var err error
+		d, err = q.CreateNotificationDelivery(ctx, params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetPendingNotificationDeliveries returns up to limit pending deliveries,
+// oldest first.
+func (db *DB) GetPendingNotificationDeliveries(ctx context.Context, limit int64) ([]generated.NotificationDelivery, error) {
+	var deliveries []generated.NotificationDelivery
+	err := db.pool.Rx(ctx, func(ctx context.Context, rx *Rx) error {
+		q := generated.New(rx.Conn())
+		var err error
+		deliveries, err = q.GetPendingNotificationDeliveries(ctx, limit)
+		return err
+	})
+	return deliveries, err
+}
+
+func (db *DB) UpdateNotificationDelivery(ctx context.Context, params generated.UpdateNotificationDeliveryParams) error {
+	return db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		return q.UpdateNotificationDelivery(ctx, params)
+	})
+}
+
+// ListNotificationDeliveries returns a channel's most recent deliveries, newest first.
+func (db *DB) ListNotificationDeliveries(ctx context.Context, channelID string, limit int64) ([]generated.NotificationDelivery, error) {
+	var deliveries []generated.NotificationDelivery
+	err := db.pool.Rx(ctx, func(ctx context.Context, rx *Rx) error {
+		q := generated.New(rx.Conn())
+		var err error
+		deliveries, err = q.ListNotificationDeliveries(ctx, generated.ListNotificationDeliveriesParams{
+			ChannelID: channelID,
+			Limit:     limit,
+		})
+		return err
+	})
+	return deliveries, err
+}
+
+// DeleteFinishedNotificationDeliveries removes sent and failed deliveries last
+// updated before age, an SQLite datetime modifier such as "-7 days".
+func (db *DB) DeleteFinishedNotificationDeliveries(ctx context.Context, age string) error {
+	return db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		return q.DeleteFinishedNotificationDeliveries(ctx, age)
+	})
+}
+
 // GetSetting retrieves a setting value by key
 // Returns empty string and nil error if the setting doesn't exist
 func (db *DB) GetSetting(ctx context.Context, key string) (string, error) {
@@ -1133,13 +1309,27 @@ func (db *DB) GetOAuthCredentials(ctx context.Context, provider string) (*genera
 	if err != nil {
 		return nil, err
 	}
+	if cred.AccessToken, err = db.decryptSecret(ctx, secretOAuthAccessToken, cred.Provider, cred.AccessToken); err != nil {
+		return nil, err
+	}
+	if cred.RefreshToken, err = db.decryptSecret(ctx, secretOAuthRefreshToken, cred.Provider, cred.RefreshToken); err != nil {
+		return nil, err
+	}
 	return &cred, nil
 }
 
 // UpsertOAuthCredentials creates or updates OAuth credentials for a provider
 func (db *DB) UpsertOAuthCredentials(ctx context.Context, params generated.UpsertOAuthCredentialsParams) (*generated.OauthCredential, error) {
 	var cred generated.OauthCredential
-	err := db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+	accessToken, refreshToken := params.AccessToken, params.RefreshToken
+	var err error
+	if params.AccessToken, err = db.encryptSecret(ctx, secretOAuthAccessToken, params.Provider, accessToken); err != nil {
+		return nil, err
+	}
+	if params.RefreshToken, err = db.encryptSecret(ctx, secretOAuthRefreshToken, params.Provider, refreshToken); err != nil {
+		return nil, err
+	}
+	err = db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
 		q := generated.New(tx.Conn())
 		var err error
 		cred, err = q.UpsertOAuthCredentials(ctx, params)
@@ -1148,6 +1338,7 @@ func (db *DB) UpsertOAuthCredentials(ctx context.Context, params generated.Upser
 	if err != nil {
 		return nil, err
 	}
+	cred.AccessToken, cred.RefreshToken = accessToken, refreshToken
 	return &cred, nil
 }
 
@@ -1218,3 +1409,144 @@ func (db *DB) ListConversationEventsSince(ctx context.Context, conversationID st
 	})
 	return events, err
 }
+
+// CreateAPIToken stores a new API token.
+func (db *DB) CreateAPIToken(ctx context.Context, params generated.CreateAPITokenParams) (*generated.ApiToken, error) {
+	var token generated.ApiToken
+	err := db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		var err error
+		token, err = q.CreateAPIToken(ctx, params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetAPITokenByHash returns the unexpired API token with the given hash.
+// Returns nil and no error if there is none.
+func (db *DB) GetAPITokenByHash(ctx context.Context, tokenHash string) (*generated.ApiToken, error) {
+	var token generated.ApiToken
+	err := db.pool.Rx(ctx, func(ctx context.Context, rx *Rx) error {
+		q := generated.New(rx.Conn())
+		var err error
+		token, err = q.GetAPITokenByHash(ctx, tokenHash)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListAPITokens returns all API tokens, including expired ones, oldest first.
+func (db *DB) ListAPITokens(ctx context.Context) ([]generated.ApiToken, error) {
+	var tokens []generated.ApiToken
+	err := db.pool.Rx(ctx, func(ctx context.Context, rx *Rx) error {
+		q := generated.New(rx.Conn())
+		var err error
+		tokens, err = q.ListAPITokens(ctx)
+		return err
+	})
+	return tokens, err
+}
+
+// DeleteAPIToken revokes an API token. It reports whether the token existed.
+func (db *DB) DeleteAPIToken(ctx context.Context, tokenID string) (bool, error) {
+	var n int64
+	err := db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		var err error
+		n, err = q.DeleteAPIToken(ctx, tokenID)
+		return err
+	})
+	return n > 0, err
+}
+
+// TouchAPIToken records that an API token was just used.
+func (db *DB) TouchAPIToken(ctx context.Context, tokenID string) error {
+	return db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		return q.TouchAPIToken(ctx, tokenID)
+	})
+}
+
+// CreateAuthSession stores a login session that expires after expiresIn, an
+// SQLite datetime modifier such as "+30 days".
+func (db *DB) CreateAuthSession(ctx context.Context, sessionHash, expiresIn string) error {
+	return db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		return q.CreateAuthSession(ctx, generated.CreateAuthSessionParams{
+			SessionHash: sessionHash,
+			ExpiresIn:   expiresIn,
+		})
+	})
+}
+
+// AuthSessionExists reports whether an unexpired login session has the given hash.
+func (db *DB) AuthSessionExists(ctx context.Context, sessionHash string) (bool, error) {
+	err := db.pool.Rx(ctx, func(ctx context.Context, rx *Rx) error {
+		q := generated.New(rx.Conn())
+		_, err := q.GetAuthSession(ctx, sessionHash)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteAuthSession ends a login session.
+func (db *DB) DeleteAuthSession(ctx context.Context, sessionHash string) error {
+	return db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		return q.DeleteAuthSession(ctx, sessionHash)
+	})
+}
+
+// DeleteAllAuthSessions ends every login session, e.g. after a password change.
+func (db *DB) DeleteAllAuthSessions(ctx context.Context) error {
+	return db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		return q.DeleteAllAuthSessions(ctx)
+	})
+}
+
+// DeleteExpiredAuthSessions removes login sessions that have expired.
+func (db *DB) DeleteExpiredAuthSessions(ctx context.Context) error {
+	return db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		return q.DeleteExpiredAuthSessions(ctx)
+	})
+}
+
+// UseReplyToken records that the notification reply token tokenID, which
+// expires at the Unix time expires, has been used. It reports false if it
+// was used before. Records of expired tokens are removed on the way.
+func (db *DB) UseReplyToken(ctx context.Context, tokenID string, expires int64) (bool, error) {
+	var n int64
+	err := db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		if err := q.DeleteExpiredReplyTokens(ctx, time.Now().Unix()); err != nil {
+			return err
+		}
+		var err error
+		n, err = q.InsertUsedReplyToken(ctx, generated.InsertUsedReplyTokenParams{TokenID: tokenID, ExpiresAt: expires})
+		return err
+	})
+	return n > 0, err
+}
+
+// ReleaseReplyToken undoes UseReplyToken, for a reply that could not be
+// carried out, so that the token can be used again.
+func (db *DB) ReleaseReplyToken(ctx context.Context, tokenID string) error {
+	return db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		return q.DeleteUsedReplyToken(ctx, tokenID)
+	})
+}
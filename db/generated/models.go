@@ -8,6 +8,22 @@ import (
 	"time"
 )
 
+type ApiToken struct {
+	TokenID    string     `json:"token_id"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"token_hash"`
+	Scope      string     `json:"scope"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type AuthSession struct {
+	SessionHash string    `json:"session_hash"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 type Conversation struct {
 	ConversationID       string    `json:"conversation_id"`
 	Slug                 *string   `json:"slug"`
@@ -18,6 +34,7 @@ type Conversation struct {
 	Archived             bool      `json:"archived"`
 	ParentConversationID *string   `json:"parent_conversation_id"`
 	Model                *string   `json:"model"`
+	Sandbox              *string   `json:"sandbox"`
 }
 
 type ConversationEvent struct {
@@ -104,6 +121,8 @@ type Model struct {
 	Tags         string    `json:"tags"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+	Pricing      *string   `json:"pricing"`
+	Capabilities *string   `json:"capabilities"`
 }
 
 type NotificationChannel struct {
@@ -116,6 +135,20 @@ type NotificationChannel struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+type NotificationDelivery struct {
+	DeliveryID     int64     `json:"delivery_id"`
+	ChannelID      string    `json:"channel_id"`
+	EventType      string    `json:"event_type"`
+	ConversationID *string   `json:"conversation_id"`
+	EventJson      string    `json:"event_json"`
+	Status         string    `json:"status"`
+	Attempts       int64     `json:"attempts"`
+	LastError      *string   `json:"last_error"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
 type OauthCredential struct {
 	Provider     string    `json:"provider"`
 	AccessToken  string    `json:"access_token"`
@@ -126,6 +159,12 @@ type OauthCredential struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+type SecretKey struct {
+	KeyID      string    `json:"key_id"`
+	WrappedKey string    `json:"wrapped_key"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 type Setting struct {
 	Key       string    `json:"key"`
 	Value     string    `json:"value"`
@@ -166,3 +205,8 @@ type TurnMetric struct {
 	CreatedAt                time.Time `json:"created_at"`
 	UpdatedAt                time.Time `json:"updated_at"`
 }
+
+type UsedReplyToken struct {
+	TokenID   string `json:"token_id"`
+	ExpiresAt int64  `json:"expires_at"`
+}
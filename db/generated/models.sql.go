@@ -10,20 +10,22 @@ import (
 )
 
 const createModel = `-- name: CreateModel :one
-INSERT INTO models (model_id, display_name, provider_type, endpoint, api_key, model_name, max_tokens, tags)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-RETURNING model_id, display_name, provider_type, endpoint, api_key, model_name, max_tokens, tags, created_at, updated_at
+INSERT INTO models (model_id, display_name, provider_type, endpoint, api_key, model_name, max_tokens, tags, pricing, capabilities)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING model_id, display_name, provider_type, endpoint, api_key, model_name, max_tokens, tags, created_at, updated_at, pricing, capabilities
 `
 
 type CreateModelParams struct {
-	ModelID      string `json:"model_id"`
-	DisplayName  string `json:"display_name"`
-	ProviderType string `json:"provider_type"`
-	Endpoint     string `json:"endpoint"`
-	ApiKey       string `json:"api_key"`
-	ModelName    string `json:"model_name"`
-	MaxTokens    int64  `json:"max_tokens"`
-	Tags         string `json:"tags"`
+	ModelID      string  `json:"model_id"`
+	DisplayName  string  `json:"display_name"`
+	ProviderType string  `json:"provider_type"`
+	Endpoint     string  `json:"endpoint"`
+	ApiKey       string  `json:"api_key"`
+	ModelName    string  `json:"model_name"`
+	MaxTokens    int64   `json:"max_tokens"`
+	Tags         string  `json:"tags"`
+	Pricing      *string `json:"pricing"`
+	Capabilities *string `json:"capabilities"`
 }
 
 func (q *Queries) CreateModel(ctx context.Context, arg CreateModelParams) (Model, error) {
@@ -36,6 +38,8 @@ func (q *Queries) CreateModel(ctx context.Context, arg CreateModelParams) (Model
 		arg.ModelName,
 		arg.MaxTokens,
 		arg.Tags,
+		arg.Pricing,
+		arg.Capabilities,
 	)
 	var i Model
 	err := row.Scan(
@@ -49,6 +53,8 @@ func (q *Queries) CreateModel(ctx context.Context, arg CreateModelParams) (Model
 		&i.Tags,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Pricing,
+		&i.Capabilities,
 	)
 	return i, err
 }
@@ -63,7 +69,7 @@ func (q *Queries) DeleteModel(ctx context.Context, modelID string) error {
 }
 
 const getModel = `-- name: GetModel :one
-SELECT model_id, display_name, provider_type, endpoint, api_key, model_name, max_tokens, tags, created_at, updated_at FROM models WHERE model_id = ?
+SELECT model_id, display_name, provider_type, endpoint, api_key, model_name, max_tokens, tags, created_at, updated_at, pricing, capabilities FROM models WHERE model_id = ?
 `
 
 func (q *Queries) GetModel(ctx context.Context, modelID string) (Model, error) {
@@ -80,12 +86,14 @@ func (q *Queries) GetModel(ctx context.Context, modelID string) (Model, error) {
 		&i.Tags,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Pricing,
+		&i.Capabilities,
 	)
 	return i, err
 }
 
 const getModels = `-- name: GetModels :many
-SELECT model_id, display_name, provider_type, endpoint, api_key, model_name, max_tokens, tags, created_at, updated_at FROM models ORDER BY created_at ASC
+SELECT model_id, display_name, provider_type, endpoint, api_key, model_name, max_tokens, tags, created_at, updated_at, pricing, capabilities FROM models ORDER BY created_at ASC
 `
 
 func (q *Queries) GetModels(ctx context.Context) ([]Model, error) {
@@ -108,6 +116,8 @@ func (q *Queries) GetModels(ctx context.Context) ([]Model, error) {
 			&i.Tags,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Pricing,
+			&i.Capabilities,
 		); err != nil {
 			return nil, err
 		}
@@ -131,20 +141,24 @@ SET display_name = ?,
     model_name = ?,
     max_tokens = ?,
     tags = ?,
+    pricing = ?,
+    capabilities = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE model_id = ?
-RETURNING model_id, display_name, provider_type, endpoint, api_key, model_name, max_tokens, tags, created_at, updated_at
+RETURNING model_id, display_name, provider_type, endpoint, api_key, model_name, max_tokens, tags, created_at, updated_at, pricing, capabilities
 `
 
 type UpdateModelParams struct {
-	DisplayName  string `json:"display_name"`
-	ProviderType string `json:"provider_type"`
-	Endpoint     string `json:"endpoint"`
-	ApiKey       string `json:"api_key"`
-	ModelName    string `json:"model_name"`
-	MaxTokens    int64  `json:"max_tokens"`
-	Tags         string `json:"tags"`
-	ModelID      string `json:"model_id"`
+	DisplayName  string  `json:"display_name"`
+	ProviderType string  `json:"provider_type"`
+	Endpoint     string  `json:"endpoint"`
+	ApiKey       string  `json:"api_key"`
+	ModelName    string  `json:"model_name"`
+	MaxTokens    int64   `json:"max_tokens"`
+	Tags         string  `json:"tags"`
+	Pricing      *string `json:"pricing"`
+	Capabilities *string `json:"capabilities"`
+	ModelID      string  `json:"model_id"`
 }
 
 func (q *Queries) UpdateModel(ctx context.Context, arg UpdateModelParams) (Model, error) {
@@ -156,6 +170,8 @@ func (q *Queries) UpdateModel(ctx context.Context, arg UpdateModelParams) (Model
 		arg.ModelName,
 		arg.MaxTokens,
 		arg.Tags,
+		arg.Pricing,
+		arg.Capabilities,
 		arg.ModelID,
 	)
 	var i Model
@@ -170,6 +186,22 @@ func (q *Queries) UpdateModel(ctx context.Context, arg UpdateModelParams) (Model
 		&i.Tags,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Pricing,
+		&i.Capabilities,
 	)
 	return i, err
 }
+
+const updateModelAPIKey = `-- name: UpdateModelAPIKey :exec
+UPDATE models SET api_key = ? WHERE model_id = ?
+`
+
+type UpdateModelAPIKeyParams struct {
+	ApiKey  string `json:"api_key"`
+	ModelID string `json:"model_id"`
+}
+
+func (q *Queries) UpdateModelAPIKey(ctx context.Context, arg UpdateModelAPIKeyParams) error {
+	_, err := q.db.ExecContext(ctx, updateModelAPIKey, arg.ApiKey, arg.ModelID)
+	return err
+}
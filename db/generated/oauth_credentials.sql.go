@@ -72,6 +72,21 @@ func (q *Queries) GetOAuthCredentials(ctx context.Context, provider string) (Oau
 	return i, err
 }
 
+const updateOAuthTokens = `-- name: UpdateOAuthTokens :exec
+UPDATE oauth_credentials SET access_token = ?, refresh_token = ? WHERE provider = ?
+`
+
+type UpdateOAuthTokensParams struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	Provider     string `json:"provider"`
+}
+
+func (q *Queries) UpdateOAuthTokens(ctx context.Context, arg UpdateOAuthTokensParams) error {
+	_, err := q.db.ExecContext(ctx, updateOAuthTokens, arg.AccessToken, arg.RefreshToken, arg.Provider)
+	return err
+}
+
 const upsertOAuthCredentials = `-- name: UpsertOAuthCredentials :one
 INSERT INTO oauth_credentials (provider, access_token, refresh_token, account_id, expires_at)
 VALUES (?, ?, ?, ?, ?)
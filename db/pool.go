@@ -8,8 +8,14 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"shelley.exe.dev/metrics"
 )
 
+// transactionDuration includes the wait for a connection, so it shows both
+// slow queries and contention for the single writer.
+var transactionDuration = metrics.NewHistogram("shelley_db_transaction_duration_seconds", "Time to run a database transaction, including waiting for a connection, by type (tx for writes, rx for reads).", metrics.DurationBuckets, "type")
+
 // Pool is an SQLite connection pool.
 //
 // We deliberately minimize our use of database/sql machinery because
@@ -150,6 +156,8 @@ func (p *Pool) Exec(ctx context.Context, query string, args ...interface{}) erro
 
 func (p *Pool) Tx(ctx context.Context, fn func(ctx context.Context, tx *Tx) error) error {
 	checkNoTx(ctx, "Tx")
+	start := time.Now()
+	defer func() { transactionDuration.Observe(time.Since(start).Seconds(), "tx") }()
 	var conn *sql.Conn
 	select {
 	case <-ctx.Done():
@@ -198,6 +206,8 @@ func (p *Pool) Tx(ctx context.Context, fn func(ctx context.Context, tx *Tx) erro
 
 func (p *Pool) Rx(ctx context.Context, fn func(ctx context.Context, rx *Rx) error) error {
 	checkNoTx(ctx, "Rx")
+	start := time.Now()
+	defer func() { transactionDuration.Observe(time.Since(start).Seconds(), "rx") }()
 	var conn *sql.Conn
 	select {
 	case <-ctx.Done():
@@ -0,0 +1,41 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (token_id, name, token_hash, scope, expires_at)
+VALUES (
+    sqlc.arg(token_id),
+    sqlc.arg(name),
+    sqlc.arg(token_hash),
+    sqlc.arg(scope),
+    -- datetime() is NULL when expires_in is, so the token never expires.
+    datetime('now', CAST(sqlc.narg(expires_in) AS TEXT))
+)
+RETURNING *;
+
+-- name: GetAPITokenByHash :one
+SELECT * FROM api_tokens
+WHERE token_hash = ? AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP);
+
+-- name: ListAPITokens :many
+SELECT * FROM api_tokens ORDER BY created_at ASC, token_id ASC;
+
+-- name: DeleteAPIToken :execrows
+DELETE FROM api_tokens WHERE token_id = ?;
+
+-- name: TouchAPIToken :exec
+UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE token_id = ?;
+
+-- name: CreateAuthSession :exec
+INSERT INTO auth_sessions (session_hash, expires_at)
+VALUES (sqlc.arg(session_hash), datetime('now', CAST(sqlc.arg(expires_in) AS TEXT)));
+
+-- name: GetAuthSession :one
+SELECT * FROM auth_sessions
+WHERE session_hash = ? AND expires_at > CURRENT_TIMESTAMP;
+
+-- name: DeleteAuthSession :exec
+DELETE FROM auth_sessions WHERE session_hash = ?;
+
+-- name: DeleteAllAuthSessions :exec
+DELETE FROM auth_sessions;
+
+-- name: DeleteExpiredAuthSessions :exec
+DELETE FROM auth_sessions WHERE expires_at <= CURRENT_TIMESTAMP;
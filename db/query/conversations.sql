@@ -109,6 +109,11 @@ FROM conversations
 WHERE parent_conversation_id IS NOT NULL
 GROUP BY parent_conversation_id;
 
+-- name: UpdateConversationSandbox :exec
+UPDATE conversations
+SET sandbox = ?
+WHERE conversation_id = ?;
+
 -- name: UpdateConversationModel :exec
 UPDATE conversations
 SET model = ?
@@ -5,8 +5,8 @@ SELECT * FROM models ORDER BY created_at ASC;
 SELECT * FROM models WHERE model_id = ?;
 
 -- name: CreateModel :one
-INSERT INTO models (model_id, display_name, provider_type, endpoint, api_key, model_name, max_tokens, tags)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+INSERT INTO models (model_id, display_name, provider_type, endpoint, api_key, model_name, max_tokens, tags, pricing, capabilities)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: UpdateModel :one
@@ -18,9 +18,14 @@ SET display_name = ?,
     model_name = ?,
     max_tokens = ?,
     tags = ?,
+    pricing = ?,
+    capabilities = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE model_id = ?
 RETURNING *;
 
 -- name: DeleteModel :exec
 DELETE FROM models WHERE model_id = ?;
+
+-- name: UpdateModelAPIKey :exec
+UPDATE models SET api_key = ? WHERE model_id = ?;
@@ -0,0 +1,35 @@
+-- name: CreateNotificationDelivery :one
+INSERT INTO notification_deliveries (channel_id, event_type, conversation_id, event_json, next_attempt_at)
+VALUES (
+    sqlc.arg(channel_id),
+    sqlc.arg(event_type),
+    sqlc.narg(conversation_id),
+    sqlc.arg(event_json),
+    datetime('now', CAST(sqlc.arg(delay) AS TEXT))
+)
+RETURNING *;
+
+-- name: GetPendingNotificationDeliveries :many
+SELECT * FROM notification_deliveries
+WHERE status = 'pending'
+ORDER BY delivery_id ASC
+LIMIT ?;
+
+-- name: UpdateNotificationDelivery :exec
+UPDATE notification_deliveries
+SET status = sqlc.arg(status),
+    attempts = sqlc.arg(attempts),
+    last_error = sqlc.narg(last_error),
+    next_attempt_at = datetime('now', CAST(sqlc.arg(delay) AS TEXT)),
+    updated_at = CURRENT_TIMESTAMP
+WHERE delivery_id = sqlc.arg(delivery_id);
+
+-- name: ListNotificationDeliveries :many
+SELECT * FROM notification_deliveries
+WHERE channel_id = ?
+ORDER BY delivery_id DESC
+LIMIT ?;
+
+-- name: DeleteFinishedNotificationDeliveries :exec
+DELETE FROM notification_deliveries
+WHERE status != 'pending' AND updated_at < datetime('now', CAST(sqlc.arg(age) AS TEXT));
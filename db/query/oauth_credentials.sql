@@ -17,3 +17,6 @@ DELETE FROM oauth_credentials WHERE provider = ?;
 
 -- name: GetAllOAuthCredentials :many
 SELECT * FROM oauth_credentials;
+
+-- name: UpdateOAuthTokens :exec
+UPDATE oauth_credentials SET access_token = ?, refresh_token = ? WHERE provider = ?;
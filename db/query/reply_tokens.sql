@@ -0,0 +1,9 @@
+-- name: InsertUsedReplyToken :execrows
+INSERT INTO used_reply_tokens (token_id, expires_at) VALUES (?, ?)
+ON CONFLICT (token_id) DO NOTHING;
+
+-- name: DeleteExpiredReplyTokens :exec
+DELETE FROM used_reply_tokens WHERE expires_at <= ?;
+
+-- name: DeleteUsedReplyToken :exec
+DELETE FROM used_reply_tokens WHERE token_id = ?;
@@ -0,0 +1,8 @@
+-- name: ListSecretKeys :many
+SELECT * FROM secret_keys ORDER BY created_at, rowid;
+
+-- name: InsertSecretKey :exec
+INSERT INTO secret_keys (key_id, wrapped_key) VALUES (?, ?);
+
+-- name: DeleteOldSecretKeys :exec
+DELETE FROM secret_keys WHERE key_id != ? AND key_id != ?;
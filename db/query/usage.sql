@@ -0,0 +1,51 @@
+-- name: ListUsageMessages :many
+SELECT
+    m.conversation_id,
+    c.parent_conversation_id,
+    c.cwd,
+    COALESCE(c.model, json_extract(m.usage_data, '$.model')) AS model,
+    m.created_at,
+    m.usage_data
+FROM messages m
+JOIN conversations c ON c.conversation_id = m.conversation_id
+WHERE m.type = 'agent'
+  AND m.usage_data IS NOT NULL
+  AND m.created_at >= sqlc.arg(since)
+  AND m.created_at < sqlc.arg(until)
+ORDER BY m.created_at ASC;
+
+-- name: ListUsageToolCalls :many
+SELECT
+    m.conversation_id,
+    c.parent_conversation_id,
+    c.cwd,
+    COALESCE(c.model, json_extract(m.usage_data, '$.model')) AS model,
+    m.created_at,
+    CAST(json_extract(t.value, '$.ToolName') AS TEXT) AS tool_name
+FROM messages m
+JOIN conversations c ON c.conversation_id = m.conversation_id,
+    json_each(m.llm_data, '$.Content') t
+WHERE m.type = 'agent'
+  AND m.created_at >= sqlc.arg(since)
+  AND m.created_at < sqlc.arg(until)
+  AND json_extract(t.value, '$.ToolName') <> ''
+ORDER BY m.created_at ASC;
+
+-- name: ListUsageJobRuns :many
+SELECT
+    j.conversation_id,
+    c.parent_conversation_id,
+    c.cwd,
+    COALESCE(c.model, j.model_id) AS model,
+    j.kind,
+    j.created_at,
+    j.started_at,
+    j.finished_at
+FROM job_runs j
+JOIN conversations c ON c.conversation_id = j.conversation_id
+WHERE j.kind IN ('turn', 'subagent')
+  AND j.started_at IS NOT NULL
+  AND j.finished_at IS NOT NULL
+  AND j.created_at >= sqlc.arg(since)
+  AND j.created_at < sqlc.arg(until)
+ORDER BY j.created_at ASC;
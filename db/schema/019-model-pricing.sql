@@ -0,0 +1,3 @@
+-- Add pricing to custom models
+-- JSON-encoded llm.Pricing (USD per million tokens); NULL when the price is unknown
+ALTER TABLE models ADD COLUMN pricing TEXT;
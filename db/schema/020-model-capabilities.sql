@@ -0,0 +1,3 @@
+-- Add capabilities to custom models
+-- JSON-encoded llm.Capabilities; NULL means the provider defaults
+ALTER TABLE models ADD COLUMN capabilities TEXT;
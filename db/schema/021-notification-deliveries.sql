@@ -0,0 +1,20 @@
+-- Notification outbox and delivery log
+-- Each row is one event queued for one notification channel. Pending rows are
+-- retried with backoff until they are sent or fail permanently.
+
+CREATE TABLE notification_deliveries (
+    delivery_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    channel_id TEXT NOT NULL REFERENCES notification_channels(channel_id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    conversation_id TEXT,
+    event_json TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notification_deliveries_due ON notification_deliveries (status, next_attempt_at);
+CREATE INDEX idx_notification_deliveries_channel ON notification_deliveries (channel_id, delivery_id DESC);
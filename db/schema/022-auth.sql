@@ -0,0 +1,19 @@
+-- Built-in authentication
+-- API tokens and login sessions are stored as SHA-256 hashes of the secret
+-- the client holds, so a copy of the database does not grant access.
+
+CREATE TABLE api_tokens (
+    token_id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    scope TEXT NOT NULL CHECK (scope IN ('read', 'chat', 'admin')),
+    expires_at DATETIME,
+    last_used_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE auth_sessions (
+    session_hash TEXT PRIMARY KEY,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+-- Per-conversation bash sandbox settings
+-- JSON-encoded sandbox.Settings; NULL means commands run unconfined
+ALTER TABLE conversations ADD COLUMN sandbox TEXT;
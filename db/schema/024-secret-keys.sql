@@ -0,0 +1,10 @@
+-- Data keys that encrypt secret columns: models.api_key and the tokens in
+-- oauth_credentials. Each is stored encrypted ("wrapped") with a master key
+-- kept outside the database, in the OS keyring, a key file or the
+-- environment. Encrypted values are "enc:v1:<key_id>:<base64>".
+
+CREATE TABLE secret_keys (
+    key_id TEXT PRIMARY KEY,
+    wrapped_key TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,8 @@
+-- Notification reply tokens that have been used. A token answers only once;
+-- its row is kept until the token would have expired anyway. expires_at is
+-- in Unix seconds, as in the token.
+
+CREATE TABLE used_reply_tokens (
+    token_id TEXT PRIMARY KEY,
+    expires_at INTEGER NOT NULL
+);
@@ -0,0 +1,436 @@
+package db
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"shelley.exe.dev/db/generated"
+)
+
+// Secret columns (models.api_key and the oauth_credentials tokens) are
+// encrypted with envelope encryption: a random data key, stored in
+// secret_keys wrapped by a master key that never touches the database,
+// encrypts the values with AES-256-GCM. Until UnlockSecrets is called,
+// values are stored and returned as they are.
+
+// MasterKeySize is the size in bytes of the master key.
+const MasterKeySize = 32
+
+// Encrypted values look like "enc:v2:<key_id>:<base64>" and are bound to
+// their column and row. Version 1 values, bound only to their column, are
+// still read, and re-encrypted by UnlockSecrets.
+const (
+	encryptedPrefix   = "enc:v2:"
+	encryptedPrefixV1 = "enc:v1:"
+)
+
+// Additional data binding each encrypted value to its column; the row's
+// model ID or provider follows.
+const (
+	secretModelAPIKey           = "models.api_key"
+	secretOAuthAccessToken      = "oauth_credentials.access_token"
+	secretOAuthRefreshToken     = "oauth_credentials.refresh_token"
+	secretKeyWrapAdditionalData = "secret_keys.wrapped_key:"
+)
+
+// ErrWrongMasterKey is returned when the master key cannot unwrap the
+// database's data keys.
+var ErrWrongMasterKey = errors.New("master key does not match this database")
+
+// ErrSecretsLocked is returned when an encrypted value is read before
+// UnlockSecrets.
+var ErrSecretsLocked = errors.New("stored secrets are encrypted and no master key was provided")
+
+// secretBox holds the keys for encrypting secret columns.
+type secretBox struct {
+	mu      sync.Mutex
+	master  cipher.AEAD
+	keys    map[string]cipher.AEAD // data keys by ID
+	current string                 // ID of the data key used for new values
+}
+
+// SecretsStatus describes how secrets are stored.
+type SecretsStatus struct {
+	// KeyID and KeyCreatedAt identify the current data key; they are empty
+	// before the first UnlockSecrets.
+	KeyID        string
+	KeyCreatedAt time.Time
+	// Encrypted and Plaintext count the non-empty secret values.
+	Encrypted int
+	Plaintext int
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) string {
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, additionalData))
+}
+
+func unseal(aead cipher.AEAD, sealed string, additionalData []byte) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return nil, errors.New("malformed encrypted value")
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], additionalData)
+}
+
+// newDataKey generates a data key and returns its ID and wrapped form.
+func newDataKey(master cipher.AEAD) (string, string, cipher.AEAD, error) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	keyID := strings.ToLower(rand.Text()[:10])
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return keyID, seal(master, key, []byte(secretKeyWrapAdditionalData+keyID)), aead, nil
+}
+
+// unwrap decrypts a stored data key.
+func unwrap(master cipher.AEAD, k generated.SecretKey) (cipher.AEAD, error) {
+	key, err := unseal(master, k.WrappedKey, []byte(secretKeyWrapAdditionalData+k.KeyID))
+	if err != nil {
+		return nil, ErrWrongMasterKey
+	}
+	return newAEAD(key)
+}
+
+// GenerateMasterKey returns a new random master key.
+func GenerateMasterKey() []byte {
+	key := make([]byte, MasterKeySize)
+	rand.Read(key)
+	return key
+}
+
+// UnlockSecrets turns on encryption of secret columns with masterKey,
+// creating the database's data key on first use, and encrypts any values
+// still stored in plaintext or in the version 1 format. It returns the
+// number of values it encrypted.
+func (db *DB) UnlockSecrets(ctx context.Context, masterKey []byte) (int, error) {
+	if len(masterKey) != MasterKeySize {
+		return 0, fmt.Errorf("master key must be %d bytes, got %d", MasterKeySize, len(masterKey))
+	}
+	master, err := newAEAD(masterKey)
+	if err != nil {
+		return 0, err
+	}
+	box := &secretBox{master: master, keys: map[string]cipher.AEAD{}}
+	var encrypted int
+	err = db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		keys, err := q.ListSecretKeys(ctx)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			aead, err := unwrap(master, k)
+			if err != nil {
+				return err
+			}
+			box.keys[k.KeyID] = aead
+			box.current = k.KeyID
+		}
+		if box.current == "" {
+			keyID, wrapped, aead, err := newDataKey(master)
+			if err != nil {
+				return err
+			}
+			if err := q.InsertSecretKey(ctx, generated.InsertSecretKeyParams{KeyID: keyID, WrappedKey: wrapped}); err != nil {
+				return err
+			}
+			box.keys[keyID] = aead
+			box.current = keyID
+		}
+		encrypted, err = box.reencrypt(ctx, q, func(value string) bool {
+			return !isEncrypted(value) || strings.HasPrefix(value, encryptedPrefixV1)
+		})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	db.secrets.Store(box)
+	return encrypted, nil
+}
+
+// RotateSecrets re-encrypts every secret with a new data key. The key it
+// replaces is kept until the next rotation, and older ones are deleted:
+// other processes using the database may still hold the replaced key, and
+// they switch to the new one before they next encrypt a value. If
+// newMasterKey is non-nil, the new data key is wrapped with it instead of
+// the current master key, which the caller must then replace; all old keys
+// are deleted, and other processes must be restarted with the new master
+// key.
+func (db *DB) RotateSecrets(ctx context.Context, newMasterKey []byte) error {
+	box := db.secrets.Load()
+	if box == nil {
+		return ErrSecretsLocked
+	}
+	master := box.master
+	if newMasterKey != nil {
+		if len(newMasterKey) != MasterKeySize {
+			return fmt.Errorf("master key must be %d bytes, got %d", MasterKeySize, len(newMasterKey))
+		}
+		var err error
+		if master, err = newAEAD(newMasterKey); err != nil {
+			return err
+		}
+	}
+	keyID, wrapped, aead, err := newDataKey(master)
+	if err != nil {
+		return err
+	}
+
+	box.mu.Lock()
+	defer box.mu.Unlock()
+	// Values are decrypted with the old keys and encrypted with the new one.
+	keys := maps.Clone(box.keys)
+	keys[keyID] = aead
+	rotated := &secretBox{master: master, keys: keys, current: keyID}
+	err = db.pool.Tx(ctx, func(ctx context.Context, tx *Tx) error {
+		q := generated.New(tx.Conn())
+		if err := q.InsertSecretKey(ctx, generated.InsertSecretKeyParams{KeyID: keyID, WrappedKey: wrapped}); err != nil {
+			return err
+		}
+		if _, err := rotated.reencrypt(ctx, q, func(string) bool { return true }); err != nil {
+			return err
+		}
+		retired := box.current
+		if newMasterKey != nil {
+			retired = keyID
+		}
+		return q.DeleteOldSecretKeys(ctx, generated.DeleteOldSecretKeysParams{KeyID: keyID, KeyID_2: retired})
+	})
+	if err != nil {
+		return err
+	}
+	if newMasterKey != nil {
+		rotated.keys = map[string]cipher.AEAD{keyID: aead}
+	}
+	db.secrets.Store(rotated)
+	return nil
+}
+
+// SecretsStatus reports the current data key and how many secrets are
+// encrypted.
+func (db *DB) SecretsStatus(ctx context.Context) (SecretsStatus, error) {
+	var status SecretsStatus
+	err := db.pool.Rx(ctx, func(ctx context.Context, rx *Rx) error {
+		q := generated.New(rx.Conn())
+		keys, err := q.ListSecretKeys(ctx)
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			status.KeyID = keys[len(keys)-1].KeyID
+			status.KeyCreatedAt = keys[len(keys)-1].CreatedAt
+		}
+		count := func(value string) {
+			switch {
+			case value == "":
+			case isEncrypted(value):
+				status.Encrypted++
+			default:
+				status.Plaintext++
+			}
+		}
+		models, err := q.GetModels(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range models {
+			count(m.ApiKey)
+		}
+		creds, err := q.GetAllOAuthCredentials(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range creds {
+			count(c.AccessToken)
+			count(c.RefreshToken)
+		}
+		return nil
+	})
+	return status, err
+}
+
+// reencrypt rewrites the secret values that need it with the current data
+// key, returning how many it rewrote.
+func (b *secretBox) reencrypt(ctx context.Context, q *generated.Queries, needs func(string) bool) (int, error) {
+	var n int
+	rewrite := func(value, column, id string) (string, bool, error) {
+		if value == "" || !needs(value) {
+			return value, false, nil
+		}
+		plain, err := b.decryptLocked(column, id, value)
+		if err != nil {
+			return "", false, err
+		}
+		n++
+		return b.encryptLocked(column, id, plain), true, nil
+	}
+
+	models, err := q.GetModels(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range models {
+		apiKey, changed, err := rewrite(m.ApiKey, secretModelAPIKey, m.ModelID)
+		if err != nil {
+			return 0, fmt.Errorf("model %s: %w", m.ModelID, err)
+		}
+		if changed {
+			if err := q.UpdateModelAPIKey(ctx, generated.UpdateModelAPIKeyParams{ApiKey: apiKey, ModelID: m.ModelID}); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	creds, err := q.GetAllOAuthCredentials(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range creds {
+		access, accessChanged, err := rewrite(c.AccessToken, secretOAuthAccessToken, c.Provider)
+		if err != nil {
+			return 0, fmt.Errorf("%s credentials: %w", c.Provider, err)
+		}
+		refresh, refreshChanged, err := rewrite(c.RefreshToken, secretOAuthRefreshToken, c.Provider)
+		if err != nil {
+			return 0, fmt.Errorf("%s credentials: %w", c.Provider, err)
+		}
+		if accessChanged || refreshChanged {
+			if err := q.UpdateOAuthTokens(ctx, generated.UpdateOAuthTokensParams{AccessToken: access, RefreshToken: refresh, Provider: c.Provider}); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix) || strings.HasPrefix(value, encryptedPrefixV1)
+}
+
+// parseEncrypted splits an encrypted value for the row id of column into
+// its data key ID, sealed value and additional data.
+func parseEncrypted(column, id, value string) (keyID, sealed string, additionalData []byte, err error) {
+	rest, ok := strings.CutPrefix(value, encryptedPrefix)
+	additionalData = []byte(column + ":" + id)
+	if !ok {
+		rest = strings.TrimPrefix(value, encryptedPrefixV1)
+		additionalData = []byte(column)
+	}
+	keyID, sealed, ok = strings.Cut(rest, ":")
+	if !ok {
+		return "", "", nil, errors.New("malformed encrypted value")
+	}
+	return keyID, sealed, additionalData, nil
+}
+
+func (b *secretBox) encryptLocked(column, id, plaintext string) string {
+	return encryptedPrefix + b.current + ":" + seal(b.keys[b.current], []byte(plaintext), []byte(column+":"+id))
+}
+
+func (b *secretBox) decryptLocked(column, id, value string) (string, error) {
+	if !isEncrypted(value) {
+		return value, nil
+	}
+	keyID, sealed, additionalData, err := parseEncrypted(column, id, value)
+	if err != nil {
+		return "", err
+	}
+	aead := b.keys[keyID]
+	if aead == nil {
+		return "", fmt.Errorf("unknown data key %q", keyID)
+	}
+	plain, err := unseal(aead, sealed, additionalData)
+	if err != nil {
+		return "", fmt.Errorf("decrypt %s: %w", column, err)
+	}
+	return string(plain), nil
+}
+
+// refreshLocked loads the data keys other processes have added, such as
+// `shelley secrets rotate`, and makes the newest one current.
+func (db *DB) refreshLocked(ctx context.Context, b *secretBox) error {
+	var keys []generated.SecretKey
+	err := db.pool.Rx(ctx, func(ctx context.Context, rx *Rx) error {
+		var err error
+		keys, err = generated.New(rx.Conn()).ListSecretKeys(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if b.keys[k.KeyID] != nil {
+			continue
+		}
+		aead, err := unwrap(b.master, k)
+		if err != nil {
+			return fmt.Errorf("%w: the master key was rotated, restart with the new one", err)
+		}
+		b.keys[k.KeyID] = aead
+	}
+	if len(keys) > 0 {
+		b.current = keys[len(keys)-1].KeyID
+	}
+	return nil
+}
+
+// encryptSecret encrypts a value for the row id of column, if secrets are
+// unlocked, with the newest data key.
+func (db *DB) encryptSecret(ctx context.Context, column, id, plaintext string) (string, error) {
+	box := db.secrets.Load()
+	if box == nil || plaintext == "" {
+		return plaintext, nil
+	}
+	box.mu.Lock()
+	defer box.mu.Unlock()
+	if err := db.refreshLocked(ctx, box); err != nil {
+		return "", err
+	}
+	return box.encryptLocked(column, id, plaintext), nil
+}
+
+// decryptSecret decrypts a value read from the row id of column. Plaintext
+// values are returned as they are.
+func (db *DB) decryptSecret(ctx context.Context, column, id, value string) (string, error) {
+	if !isEncrypted(value) {
+		return value, nil
+	}
+	box := db.secrets.Load()
+	if box == nil {
+		return "", ErrSecretsLocked
+	}
+	box.mu.Lock()
+	defer box.mu.Unlock()
+	keyID, _, _, err := parseEncrypted(column, id, value)
+	if err != nil {
+		return "", err
+	}
+	if box.keys[keyID] == nil {
+		if err := db.refreshLocked(ctx, box); err != nil {
+			return "", err
+		}
+	}
+	return box.decryptLocked(column, id, value)
+}
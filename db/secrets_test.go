@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"shelley.exe.dev/db/generated"
+)
+
+// rawSecrets returns the stored model API key and OAuth access token.
+func rawSecrets(t *testing.T, db *DB) (string, string) {
+	t.Helper()
+	var apiKey, accessToken string
+	err := db.Queries(context.Background(), func(q *generated.Queries) error {
+		model, err := q.GetModel(context.Background(), "m1")
+		if err != nil {
+			return err
+		}
+		cred, err := q.GetOAuthCredentials(context.Background(), "codex")
+		if err != nil {
+			return err
+		}
+		apiKey, accessToken = model.ApiKey, cred.AccessToken
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return apiKey, accessToken
+}
+
+func TestSecretEncryption(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	// Secrets written before unlocking are stored as they are.
+	if _, err := db.CreateModel(ctx, generated.CreateModelParams{
+		ModelID: "m1", DisplayName: "M", ProviderType: "anthropic", Endpoint: "https://example.com",
+		ApiKey: "sk-test-key", ModelName: "m", MaxTokens: 1000,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.UpsertOAuthCredentials(ctx, generated.UpsertOAuthCredentialsParams{
+		Provider: "codex", AccessToken: "access", RefreshToken: "refresh", ExpiresAt: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	master := GenerateMasterKey()
+	n, err := db.UnlockSecrets(ctx, master)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("encrypted %d existing values, want 3", n)
+	}
+	apiKey, accessToken := rawSecrets(t, db)
+	if !isEncrypted(apiKey) || !isEncrypted(accessToken) || strings.Contains(apiKey, "sk-test-key") {
+		t.Fatalf("stored values not encrypted: %q, %q", apiKey, accessToken)
+	}
+	model, err := db.GetModel(ctx, "m1")
+	if err != nil || model.ApiKey != "sk-test-key" {
+		t.Fatalf("GetModel = %v, %v", model, err)
+	}
+	cred, err := db.GetOAuthCredentials(ctx, "codex")
+	if err != nil || cred.AccessToken != "access" || cred.RefreshToken != "refresh" {
+		t.Fatalf("GetOAuthCredentials = %v, %v", cred, err)
+	}
+
+	// Unlocking again encrypts nothing new.
+	if n, err := db.UnlockSecrets(ctx, master); err != nil || n != 0 {
+		t.Errorf("second unlock = %d, %v", n, err)
+	}
+	if _, err := db.UnlockSecrets(ctx, GenerateMasterKey()); !errors.Is(err, ErrWrongMasterKey) {
+		t.Errorf("unlock with another key: %v", err)
+	}
+
+	// Rotating to a new master key re-encrypts everything.
+	newMaster := GenerateMasterKey()
+	if err := db.RotateSecrets(ctx, newMaster); err != nil {
+		t.Fatal(err)
+	}
+	rotatedKey, _ := rawSecrets(t, db)
+	if rotatedKey == apiKey {
+		t.Error("API key not re-encrypted")
+	}
+	if models, err := db.GetModels(ctx); err != nil || models[0].ApiKey != "sk-test-key" {
+		t.Errorf("GetModels after rotation = %v, %v", models, err)
+	}
+	if _, err := db.UnlockSecrets(ctx, master); !errors.Is(err, ErrWrongMasterKey) {
+		t.Errorf("old master key still works: %v", err)
+	}
+	if n, err := db.UnlockSecrets(ctx, newMaster); err != nil || n != 0 {
+		t.Errorf("unlock with new master key = %d, %v", n, err)
+	}
+	status, err := db.SecretsStatus(ctx)
+	if err != nil || status.Encrypted != 3 || status.Plaintext != 0 || status.KeyID == "" {
+		t.Errorf("SecretsStatus = %+v, %v", status, err)
+	}
+
+	// A process without the key cannot read encrypted values.
+	locked := &DB{pool: db.pool}
+	if _, err := locked.GetModel(ctx, "m1"); !errors.Is(err, ErrSecretsLocked) {
+		t.Errorf("GetModel without key: %v", err)
+	}
+}
+
+func TestRotateSecretsWhileInUse(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+	if _, err := db.CreateModel(ctx, generated.CreateModelParams{
+		ModelID: "m1", DisplayName: "M", ProviderType: "anthropic", Endpoint: "https://example.com",
+		ApiKey: "sk-test-key", ModelName: "m", MaxTokens: 1000,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	master := GenerateMasterKey()
+	if _, err := db.UnlockSecrets(ctx, master); err != nil {
+		t.Fatal(err)
+	}
+
+	// A running server keeps its keys while the CLI rotates them.
+	cli := &DB{pool: db.pool}
+	if _, err := cli.UnlockSecrets(ctx, master); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.RotateSecrets(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if model, err := db.GetModel(ctx, "m1"); err != nil || model.ApiKey != "sk-test-key" {
+		t.Fatalf("server GetModel after rotation = %v, %v", model, err)
+	}
+	if _, err := db.UpsertOAuthCredentials(ctx, generated.UpsertOAuthCredentialsParams{
+		Provider: "codex", AccessToken: "access", RefreshToken: "refresh", ExpiresAt: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Values the server writes use the new key, so the next rotation can
+	// delete the old one.
+	if err := cli.RotateSecrets(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	restarted := &DB{pool: db.pool}
+	if _, err := restarted.UnlockSecrets(ctx, master); err != nil {
+		t.Fatal(err)
+	}
+	if cred, err := restarted.GetOAuthCredentials(ctx, "codex"); err != nil || cred.AccessToken != "access" {
+		t.Errorf("GetOAuthCredentials after rotations = %v, %v", cred, err)
+	}
+
+	// After a master key rotation the server cannot write with its old key.
+	if err := cli.RotateSecrets(ctx, GenerateMasterKey()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.UpsertOAuthCredentials(ctx, generated.UpsertOAuthCredentialsParams{
+		Provider: "codex", AccessToken: "access2", RefreshToken: "refresh2", ExpiresAt: 1,
+	}); !errors.Is(err, ErrWrongMasterKey) {
+		t.Errorf("write after master key rotation: %v", err)
+	}
+}
+
+func TestSecretsBoundToRow(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+	for _, id := range []string{"m1", "m2"} {
+		if _, err := db.CreateModel(ctx, generated.CreateModelParams{
+			ModelID: id, DisplayName: id, ProviderType: "anthropic", Endpoint: "https://example.com",
+			ApiKey: "key-" + id, ModelName: "m", MaxTokens: 1000,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	master := GenerateMasterKey()
+	if _, err := db.UnlockSecrets(ctx, master); err != nil {
+		t.Fatal(err)
+	}
+
+	// A value copied to another row does not decrypt.
+	err := db.QueriesTx(ctx, func(q *generated.Queries) error {
+		m1, err := q.GetModel(ctx, "m1")
+		if err != nil {
+			return err
+		}
+		return q.UpdateModelAPIKey(ctx, generated.UpdateModelAPIKeyParams{ApiKey: m1.ApiKey, ModelID: "m2"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.GetModel(ctx, "m2"); err == nil {
+		t.Error("API key copied from another model decrypted")
+	}
+
+	// Values in the version 1 format, bound only to their column, are
+	// still read and upgraded by UnlockSecrets.
+	box := db.secrets.Load()
+	v1 := encryptedPrefixV1 + box.current + ":" + seal(box.keys[box.current], []byte("key-m2"), []byte(secretModelAPIKey))
+	err = db.QueriesTx(ctx, func(q *generated.Queries) error {
+		return q.UpdateModelAPIKey(ctx, generated.UpdateModelAPIKeyParams{ApiKey: v1, ModelID: "m2"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model, err := db.GetModel(ctx, "m2"); err != nil || model.ApiKey != "key-m2" {
+		t.Fatalf("GetModel of a version 1 value = %v, %v", model, err)
+	}
+	if n, err := db.UnlockSecrets(ctx, master); err != nil || n != 1 {
+		t.Errorf("UnlockSecrets upgraded %d values: %v", n, err)
+	}
+}
@@ -0,0 +1,91 @@
+package gitstate
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// execConfigPattern matches the configuration keys with which a repository
+// can make git run a program. Commands Shelley runs may write to the
+// repository's configuration, so Command does not trust these keys there.
+// Aliases cannot replace the built-in commands Shelley runs, but are cleared
+// all the same.
+const execConfigPattern = `^(core\.(fsmonitor|hookspath|sshcommand|askpass|gitproxy|pager|editor|alternaterefscommand)` +
+	`|sequence\.editor|pager\..*|alias\..*|diff\.external|diff\..*\.(textconv|command)|merge\..*\.driver` +
+	`|filter\..*\.(clean|smudge|process|required)|credential\.(.*\.)?helper|gpg\.(.*\.)?program` +
+	`|remote\..*\.(uploadpack|receivepack|vcs))$`
+
+// Command returns a git command running args in dir that will not run
+// programs named by the repository itself: hooks and the fsmonitor are off,
+// and any key matching execConfigPattern set in the repository's own
+// configuration is reset to its value from the user and system
+// configuration, or cleared, so that git fails rather than runs the
+// repository's program where it would have needed one. Shelley runs git this way because sandboxed
+// commands may write to the repository, including its .git directory, while
+// Shelley's own git commands run unconfined.
+func Command(dir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), configEnv(safeConfig(dir))...)
+	return cmd
+}
+
+// safeConfig returns the configuration that Command overrides for the
+// repository in dir, in order.
+func safeConfig(dir string) [][2]string {
+	config := [][2]string{
+		{"core.fsmonitor", "false"},
+		{"core.hooksPath", "/dev/null"},
+		{"protocol.ext.allow", "never"},
+	}
+
+	// Reading configuration does not run anything the repository names.
+	cmd := exec.Command("git", "config", "--show-scope", "-z", "--get-regexp", execConfigPattern)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		// No such keys, or not a repository.
+		return config
+	}
+	type entry struct{ scope, key, value string }
+	var entries []entry
+	untrusted := make(map[string]bool)
+	var keys []string
+	fields := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		key, value, _ := strings.Cut(fields[i+1], "\n")
+		e := entry{scope: fields[i], key: key, value: value}
+		entries = append(entries, e)
+		if (e.scope == "local" || e.scope == "worktree") && !untrusted[key] {
+			untrusted[key] = true
+			keys = append(keys, key)
+		}
+	}
+	// An empty value clears a key, and resets a list such as
+	// credential.helper, which the trusted values then rebuild.
+	for _, key := range keys {
+		config = append(config, [2]string{key, ""})
+		for _, e := range entries {
+			if e.key == key && e.scope != "local" && e.scope != "worktree" {
+				config = append(config, [2]string{key, e.value})
+			}
+		}
+	}
+	return config
+}
+
+// configEnv returns the environment that passes config to git, after any
+// configuration already passed in the environment.
+func configEnv(config [][2]string) []string {
+	n, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+	var env []string
+	for _, kv := range config {
+		env = append(env,
+			"GIT_CONFIG_KEY_"+strconv.Itoa(n)+"="+kv[0],
+			"GIT_CONFIG_VALUE_"+strconv.Itoa(n)+"="+kv[1])
+		n++
+	}
+	return append(env, "GIT_CONFIG_COUNT="+strconv.Itoa(n))
+}
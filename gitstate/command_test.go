@@ -0,0 +1,74 @@
+package gitstate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommandIgnoresRepositoryPrograms(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(t.TempDir(), "ran")
+	globalMarker := filepath.Join(t.TempDir(), "global-ran")
+	global := filepath.Join(t.TempDir(), "gitconfig")
+	if err := os.WriteFile(global, []byte("[credential]\n\thelper = \"!touch "+globalMarker+"\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", global)
+	t.Setenv("GIT_TERMINAL_PROMPT", "0")
+	runGit(t, dir, "init")
+	runGit(t, dir, "config", "user.email", "test@test.com")
+	runGit(t, dir, "config", "user.name", "Test")
+	if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "initial")
+
+	// Programs a repository can have the server's git commands run.
+	runGit(t, dir, "config", "core.fsmonitor", "touch "+marker+"; false")
+	runGit(t, dir, "config", "filter.evil.clean", "touch "+marker+"; cat")
+	runGit(t, dir, "config", "filter.evil.required", "true")
+	runGit(t, dir, "config", "diff.external", "touch "+marker)
+	runGit(t, dir, "config", "credential.helper", "!touch "+marker)
+	if err := os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte("* filter=evil\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hooks := filepath.Join(dir, ".git", "hooks")
+	if err := os.WriteFile(filepath.Join(hooks, "post-checkout"), []byte("#!/bin/sh\ntouch "+marker+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	state := GetGitState(dir)
+	if _, err := state.ChangedFiles("HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"diff", "HEAD", "--numstat"},
+		{"diff", "--name-status", "HEAD"},
+		{"log", "-1", "--format=%h%x00%s"},
+		{"worktree", "add", "-b", "other", filepath.Join(t.TempDir(), "wt"), "HEAD"},
+	} {
+		if out, err := Command(dir, args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("git ran a program named by the repository")
+	}
+
+	// The user's own credential helper still runs.
+	fill := Command(dir, "credential", "fill")
+	fill.Stdin = strings.NewReader("protocol=https\nhost=example.com\n\n")
+	fill.Run()
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("git ran the repository's credential helper")
+	}
+	if _, err := os.Stat(globalMarker); err != nil {
+		t.Error("git did not run the user's credential helper")
+	}
+}
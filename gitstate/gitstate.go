@@ -3,7 +3,7 @@ package gitstate
 
 import (
 	"os"
-	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -33,10 +33,7 @@ func GetGitState(dir string) *GitState {
 	state := &GitState{}
 
 	// Get the worktree root (this works for both regular repos and worktrees)
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	if dir != "" {
-		cmd.Dir = dir
-	}
+	cmd := Command(dir, "rev-parse", "--show-toplevel")
 	output, err := cmd.Output()
 	if err != nil {
 		// Not in a git repository
@@ -46,20 +43,14 @@ func GetGitState(dir string) *GitState {
 	state.Worktree = strings.TrimSpace(string(output))
 
 	// Get the current commit hash (short form)
-	cmd = exec.Command("git", "rev-parse", "--short", "HEAD")
-	if dir != "" {
-		cmd.Dir = dir
-	}
+	cmd = Command(dir, "rev-parse", "--short", "HEAD")
 	output, err = cmd.Output()
 	if err == nil {
 		state.Commit = strings.TrimSpace(string(output))
 	}
 
 	// Get the commit subject line
-	cmd = exec.Command("git", "log", "-1", "--format=%s")
-	if dir != "" {
-		cmd.Dir = dir
-	}
+	cmd = Command(dir, "log", "-1", "--format=%s")
 	output, err = cmd.Output()
 	if err == nil {
 		state.Subject = strings.TrimSpace(string(output))
@@ -67,10 +58,7 @@ func GetGitState(dir string) *GitState {
 
 	// Get the current branch name
 	// First try symbolic-ref for normal branches
-	cmd = exec.Command("git", "symbolic-ref", "--short", "HEAD")
-	if dir != "" {
-		cmd.Dir = dir
-	}
+	cmd = Command(dir, "symbolic-ref", "--short", "HEAD")
 	output, err = cmd.Output()
 	if err == nil {
 		state.Branch = strings.TrimSpace(string(output))
@@ -80,6 +68,23 @@ func GetGitState(dir string) *GitState {
 	return state
 }
 
+// RepoRoot returns the root of the main worktree of the repository
+// containing dir, so that a repository's linked worktrees share one root.
+// It returns "" if dir is not in a git repository.
+func RepoRoot(dir string) string {
+	cmd := Command(dir, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	commonDir := strings.TrimSpace(string(output))
+	if filepath.Base(commonDir) != ".git" {
+		// A bare repository has no worktree of its own.
+		return commonDir
+	}
+	return filepath.Dir(commonDir)
+}
+
 // Equal reports whether g and other represent the same git state.
 func (g *GitState) Equal(other *GitState) bool {
 	if g == nil && other == nil {
@@ -121,3 +126,57 @@ func (g *GitState) String() string {
 	}
 	return worktreePath + " (detached) now at " + g.Commit + " \"" + subject + "\""
 }
+
+// emptyTree is git's hash of the empty tree, the base for a repository
+// without commits.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// FileChange is a file that differs from a base commit.
+type FileChange struct {
+	// Path is relative to the worktree root.
+	Path string `json:"path"`
+	// Status is "added", "modified" or "deleted".
+	Status string `json:"status"`
+}
+
+// ChangedFiles lists the files in the worktree of g that differ from the
+// commit base, counting both commits made since and uncommitted changes,
+// including untracked files that are not ignored. An empty base means the
+// repository had no commits.
+func (g *GitState) ChangedFiles(base string) ([]FileChange, error) {
+	if g == nil || !g.IsRepo {
+		return nil, nil
+	}
+	if base == "" {
+		base = emptyTree
+	}
+	cmd := Command(g.Worktree, "diff", "--name-status", "--no-renames", "-z", base, "--")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var changes []FileChange
+	fields := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status := "modified"
+		switch fields[i] {
+		case "A":
+			status = "added"
+		case "D":
+			status = "deleted"
+		}
+		changes = append(changes, FileChange{Path: fields[i+1], Status: status})
+	}
+
+	cmd = Command(g.Worktree, "ls-files", "--others", "--exclude-standard", "--full-name", "-z")
+	output, err = cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range strings.Split(string(output), "\x00") {
+		if path != "" {
+			changes = append(changes, FileChange{Path: path, Status: "added"})
+		}
+	}
+	return changes, nil
+}
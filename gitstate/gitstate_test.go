@@ -126,6 +126,34 @@ func TestGetGitState_Worktree(t *testing.T) {
 	}
 }
 
+func TestRepoRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	mainRepo := filepath.Join(tmpDir, "main")
+	worktreeDir := filepath.Join(tmpDir, "worktree")
+	subDir := filepath.Join(mainRepo, "sub")
+	if err := os.MkdirAll(subDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, mainRepo, "init")
+	runGit(t, mainRepo, "config", "user.email", "test@test.com")
+	runGit(t, mainRepo, "config", "user.name", "Test")
+	runGit(t, mainRepo, "commit", "--allow-empty", "-m", "initial")
+	runGit(t, mainRepo, "worktree", "add", "-b", "feature", worktreeDir)
+
+	resolvedMainRepo, err := filepath.EvalSymlinks(mainRepo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{mainRepo, subDir, worktreeDir} {
+		if got := RepoRoot(dir); got != resolvedMainRepo {
+			t.Errorf("RepoRoot(%q) = %q, want %q", dir, got, resolvedMainRepo)
+		}
+	}
+	if got := RepoRoot(tmpDir); got != "" {
+		t.Errorf("RepoRoot of a non-repo = %q, want empty", got)
+	}
+}
+
 func TestGetGitState_DetachedHead(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -236,6 +264,72 @@ func TestTildeReplace(t *testing.T) {
 	}
 }
 
+func TestChangedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	runGit(t, tmpDir, "init")
+	runGit(t, tmpDir, "config", "user.email", "test@test.com")
+	runGit(t, tmpDir, "config", "user.name", "Test")
+
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("keep.txt", "keep")
+	write("edit.txt", "before")
+	write("remove.txt", "remove")
+	write(".gitignore", "*.log\n")
+	runGit(t, tmpDir, "add", ".")
+	runGit(t, tmpDir, "commit", "-m", "initial")
+	base := GetGitState(tmpDir).Commit
+
+	// One change committed, the others left in the worktree.
+	write("committed.txt", "new")
+	runGit(t, tmpDir, "add", "committed.txt")
+	runGit(t, tmpDir, "commit", "-m", "second")
+	write("edit.txt", "after")
+	runGit(t, tmpDir, "rm", "-q", "remove.txt")
+	write("sub/untracked.txt", "untracked")
+	write("ignored.log", "ignored")
+
+	changes, err := GetGitState(filepath.Join(tmpDir, "sub")).ChangedFiles(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, c := range changes {
+		got[c.Path] = c.Status
+	}
+	want := map[string]string{
+		"committed.txt":     "added",
+		"edit.txt":          "modified",
+		"remove.txt":        "deleted",
+		"sub/untracked.txt": "added",
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	for path, status := range want {
+		if got[path] != status {
+			t.Errorf("%s: expected %q, got %q", path, status, got[path])
+		}
+	}
+
+	// Without a base, everything in the repository counts as added.
+	changes, err = GetGitState(tmpDir).ChangedFiles("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 5 {
+		t.Errorf("expected 5 files relative to the empty tree, got %v", changes)
+	}
+}
+
 func runGit(t *testing.T, dir string, args ...string) {
 	t.Helper()
 	// For commits, use --no-verify to skip hooks
@@ -114,6 +114,7 @@ type Service struct {
 	ThinkingLevel       llm.ThinkingLevel // thinking level (ThinkingLevelOff disables, default is ThinkingLevelMedium)
 	Backoff             []time.Duration   // retry backoff durations; defaults to {15s, 30s, 60s} if nil
 	ContextWindowTokens int
+	Pricing             *llm.Pricing // optional; prices usage when no gateway cost is reported
 }
 
 var _ llm.Service = (*Service)(nil)
@@ -134,7 +135,8 @@ type content struct {
 	// is somewhat acceptable but hard to read.
 	Text      *string         `json:"text,omitempty"`
 	MediaType string          `json:"media_type,omitempty"` // for image
-	Source    json.RawMessage `json:"source,omitempty"`     // for image
+	Source    json.RawMessage `json:"source,omitempty"`     // for image or document
+	Title     string          `json:"title,omitempty"`      // for document
 
 	// for thinking
 	Thinking  *string `json:"thinking,omitempty"`
@@ -171,6 +173,12 @@ type content struct {
 	//}
 	ToolResult []content `json:"content,omitempty"`
 
+	// ServerResult is the raw "content" of a server tool result block
+	// (web_search_tool_result etc.); see UnmarshalJSON. Not sent to Claude.
+	ServerResult json.RawMessage `json:"-"`
+	// raw, if set, is sent in place of the other fields; see MarshalJSON.
+	raw json.RawMessage
+
 	// timing information for tool_result; not sent to Claude
 	StartTime *time.Time `json:"-"`
 	EndTime   *time.Time `json:"-"`
@@ -232,8 +240,9 @@ type response struct {
 }
 
 type toolChoice struct {
-	Type string `json:"type"`
-	Name string `json:"name,omitempty"`
+	Type                   string `json:"type"`
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
 }
 
 // https://docs.anthropic.com/en/api/messages#body-system
@@ -316,6 +325,7 @@ var (
 		"end_turn":      llm.StopReasonEndTurn,
 		"tool_use":      llm.StopReasonToolUse,
 		"refusal":       llm.StopReasonRefusal,
+		"pause_turn":    llm.StopReasonPauseTurn,
 	}
 )
 
@@ -331,6 +341,10 @@ func fromLLMContent(c llm.Content) content {
 	if len(c.ToolResult) > 0 {
 		toolResult = make([]content, len(c.ToolResult))
 		for i, tr := range c.ToolResult {
+			if tr.IsDocument() {
+				toolResult[i] = fromLLMDocument(tr)
+				continue
+			}
 			// For image content inside a tool_result, we need to map it to "image" type
 			if tr.MediaType != "" && tr.MediaType == "image/jpeg" || tr.MediaType == "image/png" {
 				// Format as an image for Claude
@@ -353,7 +367,12 @@ func fromLLMContent(c llm.Content) content {
 	// Set fields based on content type to avoid sending invalid fields
 	switch c.Type {
 	case llm.ContentTypeText:
-		// Images are represented as text with MediaType and Data
+		// Images and documents are represented as text with MediaType and Data
+		if c.IsDocument() {
+			doc := fromLLMDocument(c)
+			doc.CacheControl = d.CacheControl
+			return doc
+		}
 		if c.MediaType != "" {
 			d.Type = "image"
 			d.Source = json.RawMessage(fmt.Sprintf(`{"type":"base64","media_type":"%s","data":"%s"}`,
@@ -384,6 +403,30 @@ func fromLLMContent(c llm.Content) content {
 	return d
 }
 
+// fromLLMDocument converts document content to an Anthropic document block.
+// PDFs are sent as base64; plain text is sent as a text source.
+func fromLLMDocument(c llm.Content) content {
+	source := map[string]string{
+		"type":       "base64",
+		"media_type": c.MediaType,
+		"data":       c.Data,
+	}
+	if c.MediaType == llm.MediaTypePlainText {
+		text, err := llm.DocumentText(c)
+		if err != nil {
+			return fromLLMContent(llm.DocumentFallback(c))
+		}
+		source["type"] = "text"
+		source["data"] = text
+	}
+	src, _ := json.Marshal(source)
+	return content{
+		Type:   "document",
+		Source: src,
+		Title:  c.Title,
+	}
+}
+
 func fromLLMToolUse(tu *llm.ToolUse) *toolUse {
 	if tu == nil {
 		return nil
@@ -417,6 +460,13 @@ func fromLLMMessage(msg llm.Message) message {
 		if c.Type == llm.ContentTypeThinking && c.Signature == "" {
 			continue
 		}
+		if c.IsServerToolContent() {
+			// Resend only server tool blocks that came from Claude.
+			if isServerToolBlock(c.Raw) {
+				contents = append(contents, content{raw: c.Raw, CacheControl: fromLLMCache(c.Cache)})
+			}
+			continue
+		}
 		contents = append(contents, fromLLMContent(c))
 	}
 	return message{
@@ -436,7 +486,21 @@ func fromLLMToolChoice(tc *llm.ToolChoice) *toolChoice {
 	}
 }
 
+// fromLLMRequestToolChoice converts r's tool choice, applying r.DisableParallelToolCalls.
+func fromLLMRequestToolChoice(r *llm.Request) *toolChoice {
+	if !r.DisableParallelToolCalls || len(r.Tools) == 0 {
+		return fromLLMToolChoice(r.ToolChoice)
+	}
+	tc := fromLLMToolChoice(cmp.Or(r.ToolChoice, &llm.ToolChoice{Type: llm.ToolChoiceTypeAuto}))
+	// Anthropic rejects disable_parallel_tool_use with tool_choice "none".
+	tc.DisableParallelToolUse = tc.Type != "none"
+	return tc
+}
+
 func fromLLMTool(t *llm.Tool) *tool {
+	if st, ok := serverTools[t.Type]; ok {
+		return &tool{Name: st.name, Type: st.typ}
+	}
 	return &tool{
 		Name:         t.Name,
 		Type:         t.Type,
@@ -488,7 +552,7 @@ func (s *Service) fromLLMRequest(r *llm.Request) *request {
 		Model:      model,
 		Messages:   messages,
 		MaxTokens:  maxTokens,
-		ToolChoice: fromLLMToolChoice(r.ToolChoice),
+		ToolChoice: fromLLMRequestToolChoice(r),
 		Tools:      mapped(r.Tools, fromLLMTool),
 		System:     mapped(r.System, fromLLMSystem),
 	}
@@ -511,9 +575,46 @@ func (s *Service) fromLLMRequest(r *llm.Request) *request {
 			req.Thinking.BudgetTokens = req.MaxTokens - 1024
 		}
 	}
+	applyResponseSchema(req, r.ResponseSchema)
 	return req
 }
 
+// applyResponseSchema maps a structured-output request onto Anthropic's API,
+// which has no native JSON mode: the schema becomes a tool the model is forced to call.
+// Extended thinking is incompatible with forced tool use, so it is disabled.
+func applyResponseSchema(req *request, rs *llm.ResponseSchema) {
+	if rs == nil {
+		return
+	}
+	req.Tools = append(req.Tools, &tool{
+		Name:        rs.SchemaName(),
+		Description: cmp.Or(rs.Description, "Respond with the requested structured output."),
+		InputSchema: rs.Schema,
+	})
+	req.ToolChoice = &toolChoice{Type: "tool", Name: rs.SchemaName()}
+	req.Thinking = nil
+}
+
+// structuredOutputToText rewrites the forced structured-output tool call
+// produced by applyResponseSchema into a plain text JSON reply.
+func structuredOutputToText(resp *llm.Response, rs *llm.ResponseSchema) {
+	if rs == nil {
+		return
+	}
+	var contents []llm.Content
+	for _, c := range resp.Content {
+		if c.Type == llm.ContentTypeToolUse && c.ToolName == rs.SchemaName() {
+			contents = append(contents, llm.Content{Type: llm.ContentTypeText, Text: string(c.ToolInput)})
+			continue
+		}
+		contents = append(contents, c)
+	}
+	resp.Content = contents
+	if resp.StopReason == llm.StopReasonToolUse {
+		resp.StopReason = llm.StopReasonEndTurn
+	}
+}
+
 // fromLLMRequestStrippingAllThinking is like fromLLMRequest but strips thinking
 // blocks from ALL assistant messages (including the last one). Used as a fallback
 // when the API rejects thinking signatures — e.g. after model version rotation.
@@ -535,7 +636,7 @@ func (s *Service) fromLLMRequestStrippingAllThinking(r *llm.Request) *request {
 		Model:      model,
 		Messages:   messages,
 		MaxTokens:  maxTokens,
-		ToolChoice: fromLLMToolChoice(r.ToolChoice),
+		ToolChoice: fromLLMRequestToolChoice(r),
 		Tools:      mapped(r.Tools, fromLLMTool),
 		System:     mapped(r.System, fromLLMSystem),
 	}
@@ -554,6 +655,7 @@ func (s *Service) fromLLMRequestStrippingAllThinking(r *llm.Request) *request {
 			req.Thinking.BudgetTokens = req.MaxTokens - 1024
 		}
 	}
+	applyResponseSchema(req, r.ResponseSchema)
 	return req
 }
 
@@ -604,7 +706,7 @@ func toLLMResponse(r *response) *llm.Response {
 		Type:         r.Type,
 		Role:         toLLMRole[r.Role],
 		Model:        r.Model,
-		Content:      mapped(r.Content, toLLMContent),
+		Content:      toLLMServerToolContents(r.Content, mapped(r.Content, toLLMContent)),
 		StopReason:   toLLMStopReason[r.StopReason],
 		StopSequence: r.StopSequence,
 		Usage:        toLLMUsage(r.Usage),
@@ -785,7 +887,7 @@ func parseSSEStream(r io.Reader) (*response, error) {
 			block := *event.ContentBlock
 			// For tool_use blocks, the initial input is always empty {};
 			// clear it so delta accumulation starts fresh.
-			if block.Type == "tool_use" {
+			if block.Type == "tool_use" || block.Type == "server_tool_use" {
 				block.ToolInput = nil
 			}
 			contents[event.Index] = block
@@ -863,7 +965,7 @@ func parseSSEStream(r io.Reader) (*response, error) {
 	// Anthropic requires the "input" field on tool_use blocks, and
 	// json:"input,omitempty" omits nil, causing a 400 error.
 	for i := range contents {
-		if contents[i].Type == "tool_use" && contents[i].ToolInput == nil {
+		if (contents[i].Type == "tool_use" || contents[i].Type == "server_tool_use") && contents[i].ToolInput == nil {
 			contents[i].ToolInput = json.RawMessage("{}")
 		}
 	}
@@ -882,6 +984,7 @@ func (s *Service) Do(ctx context.Context, ir *llm.Request) (*llm.Response, error
 		return nil, err
 	}
 	payload = append(payload, '\n')
+	betas := serverToolBetas(request.Tools)
 
 	// strippedPayload is built lazily on the first "Invalid signature" error.
 	// It strips ALL thinking blocks from the request as a fallback.
@@ -923,6 +1026,9 @@ func (s *Service) Do(ctx context.Context, ir *llm.Request) (*llm.Response, error
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("X-API-Key", s.APIKey)
 		req.Header.Set("Anthropic-Version", "2023-06-01")
+		if betas != "" {
+			req.Header.Set("Anthropic-Beta", betas)
+		}
 
 		resp, err := httpc.Do(req)
 		if err != nil {
@@ -944,10 +1050,11 @@ func (s *Service) Do(ctx context.Context, ir *llm.Request) (*llm.Response, error
 				continue
 			}
 			// Calculate and set the cost_usd field
-			response.Usage.CostUSD = llm.CostUSDFromResponse(resp.Header)
+			response.Usage.CostUSD = llm.CostUSD(resp.Header, s.Pricing, toLLMUsage(response.Usage))
 
 			endTime := time.Now()
 			result := toLLMResponse(response)
+			structuredOutputToText(result, ir.ResponseSchema)
 			result.StartTime = &startTime
 			result.EndTime = &endTime
 			return result, nil
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -295,6 +296,16 @@ func TestToLLMResponse(t *testing.T) {
 	}
 }
 
+func TestToLLMResponsePauseTurn(t *testing.T) {
+	got := toLLMResponse(&response{Role: "assistant", StopReason: "pause_turn"})
+	if got.StopReason != llm.StopReasonPauseTurn {
+		t.Errorf("toLLMResponse().StopReason = %v, want %v", got.StopReason, llm.StopReasonPauseTurn)
+	}
+	if got.ToMessage().EndOfTurn {
+		t.Error("paused response ends the turn")
+	}
+}
+
 func TestFromLLMToolUse(t *testing.T) {
 	tests := []struct {
 		name string
@@ -2236,3 +2247,326 @@ func TestParseSSEStreamInvalidCharInJSON(t *testing.T) {
 		t.Errorf("resp.ID = %q, want %q", resp.ID, "msg_ok")
 	}
 }
+
+func TestFromLLMRequestResponseSchema(t *testing.T) {
+	s := &Service{Model: Claude46Opus, ThinkingLevel: llm.ThinkingLevelMedium}
+	rs := &llm.ResponseSchema{
+		Name:   "answer",
+		Schema: llm.MustSchema(`{"type":"object","properties":{"answer":{"type":"string"}}}`),
+	}
+	req := s.fromLLMRequest(&llm.Request{
+		Messages:       []llm.Message{llm.UserStringMessage("question")},
+		ResponseSchema: rs,
+	})
+
+	if len(req.Tools) != 1 || req.Tools[0].Name != "answer" {
+		t.Fatalf("Tools = %+v, want single forced tool %q", req.Tools, "answer")
+	}
+	if req.ToolChoice == nil || req.ToolChoice.Type != "tool" || req.ToolChoice.Name != "answer" {
+		t.Errorf("ToolChoice = %+v, want forced tool %q", req.ToolChoice, "answer")
+	}
+	if req.Thinking != nil {
+		t.Errorf("Thinking = %+v, want nil with forced tool use", req.Thinking)
+	}
+}
+
+func TestStructuredOutputToText(t *testing.T) {
+	rs := &llm.ResponseSchema{Name: "answer"}
+	resp := &llm.Response{
+		StopReason: llm.StopReasonToolUse,
+		Content: []llm.Content{
+			{Type: llm.ContentTypeToolUse, ID: "t1", ToolName: "answer", ToolInput: json.RawMessage(`{"answer":"42"}`)},
+		},
+	}
+	structuredOutputToText(resp, rs)
+
+	if resp.StopReason != llm.StopReasonEndTurn {
+		t.Errorf("StopReason = %v, want %v", resp.StopReason, llm.StopReasonEndTurn)
+	}
+	if len(resp.Content) != 1 || resp.Content[0].Type != llm.ContentTypeText || resp.Content[0].Text != `{"answer":"42"}` {
+		t.Errorf("Content = %+v, want single text JSON", resp.Content)
+	}
+}
+
+func TestFromLLMContentDocument(t *testing.T) {
+	pdf := llm.DocumentContent(llm.MediaTypePDF, "spec.pdf", []byte("%PDF-1.4"))
+	got := fromLLMContent(pdf)
+	if got.Type != "document" || got.Title != "spec.pdf" {
+		t.Fatalf("fromLLMContent(pdf) = %+v, want document titled spec.pdf", got)
+	}
+	var src map[string]string
+	if err := json.Unmarshal(got.Source, &src); err != nil {
+		t.Fatalf("invalid source: %v", err)
+	}
+	if src["type"] != "base64" || src["media_type"] != llm.MediaTypePDF || src["data"] != pdf.Data {
+		t.Errorf("source = %v, want base64 PDF", src)
+	}
+
+	txt := llm.DocumentContent(llm.MediaTypePlainText, "notes.txt", []byte("hello"))
+	got = fromLLMContent(txt)
+	if err := json.Unmarshal(got.Source, &src); err != nil {
+		t.Fatalf("invalid source: %v", err)
+	}
+	if got.Type != "document" || src["type"] != "text" || src["data"] != "hello" {
+		t.Errorf("fromLLMContent(text) = %+v with source %v, want text document", got, src)
+	}
+
+	// Documents inside tool results are sent as document blocks too.
+	tr := fromLLMContent(llm.Content{
+		Type:       llm.ContentTypeToolResult,
+		ToolUseID:  "t1",
+		ToolResult: []llm.Content{llm.StringContent("Document from spec.pdf"), pdf},
+	})
+	if len(tr.ToolResult) != 2 || tr.ToolResult[1].Type != "document" {
+		t.Errorf("tool result = %+v, want text followed by document", tr.ToolResult)
+	}
+}
+
+func TestFromLLMRequestToolChoiceDisableParallel(t *testing.T) {
+	bash := &llm.Tool{Name: "bash", Description: "run bash", InputSchema: llm.MustSchema(`{"type":"object","properties":{}}`)}
+	tests := []struct {
+		name string
+		req  *llm.Request
+		want *toolChoice
+	}{
+		{
+			name: "parallel allowed",
+			req:  &llm.Request{Tools: []*llm.Tool{bash}},
+			want: nil,
+		},
+		{
+			name: "no tool choice",
+			req:  &llm.Request{Tools: []*llm.Tool{bash}, DisableParallelToolCalls: true},
+			want: &toolChoice{Type: "auto", DisableParallelToolUse: true},
+		},
+		{
+			name: "explicit tool choice",
+			req:  &llm.Request{Tools: []*llm.Tool{bash}, ToolChoice: &llm.ToolChoice{Type: llm.ToolChoiceTypeTool, Name: "bash"}, DisableParallelToolCalls: true},
+			want: &toolChoice{Type: "tool", Name: "bash", DisableParallelToolUse: true},
+		},
+		{
+			name: "tool choice none",
+			req:  &llm.Request{Tools: []*llm.Tool{bash}, ToolChoice: &llm.ToolChoice{Type: llm.ToolChoiceTypeNone}, DisableParallelToolCalls: true},
+			want: &toolChoice{Type: "none"},
+		},
+		{
+			name: "no tools",
+			req:  &llm.Request{DisableParallelToolCalls: true},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fromLLMRequestToolChoice(tt.req)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("fromLLMRequestToolChoice() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromLLMRequestServerTools(t *testing.T) {
+	s := &Service{}
+	req := s.fromLLMRequest(&llm.Request{
+		Tools: []*llm.Tool{
+			{Name: "bash", Description: "run bash", InputSchema: llm.MustSchema(`{"type":"object","properties":{}}`)},
+			llm.ServerTool(llm.ToolTypeWebSearch),
+			llm.ServerTool(llm.ToolTypeCodeExecution),
+			llm.ServerTool(llm.ToolTypeURLContext),
+		},
+		Messages: []llm.Message{
+			llm.UserStringMessage("search for it"),
+			{Role: llm.MessageRoleAssistant, Content: []llm.Content{
+				{ID: "srvtoolu_1", Type: llm.ContentTypeServerToolUse, ToolName: llm.ToolTypeWebSearch, ToolInput: json.RawMessage(`{"query":"q"}`)},
+				llm.ServerToolResultContent("srvtoolu_1", llm.ToolTypeWebSearch, llm.ServerToolResult{Type: llm.ToolTypeWebSearch}),
+				{Type: llm.ContentTypeText, Text: "found it"},
+			}},
+		},
+	})
+
+	want := []tool{
+		{Name: "bash", Description: "run bash", InputSchema: llm.MustSchema(`{"type":"object","properties":{}}`)},
+		{Name: "web_search", Type: "web_search_20250305"},
+		{Name: "code_execution", Type: "code_execution_20250825"},
+		{Name: "web_fetch", Type: "web_fetch_20250910"},
+	}
+	if len(req.Tools) != len(want) {
+		t.Fatalf("got %d tools, want %d", len(req.Tools), len(want))
+	}
+	for i, w := range want {
+		got := req.Tools[i]
+		if got.Name != w.Name || got.Type != w.Type || string(got.InputSchema) != string(w.InputSchema) {
+			t.Errorf("Tools[%d] = %+v, want %+v", i, *got, w)
+		}
+	}
+	if got, want := serverToolBetas(req.Tools), "code-execution-2025-08-25,web-fetch-2025-09-10"; got != want {
+		t.Errorf("serverToolBetas() = %q, want %q", got, want)
+	}
+
+	// Server tool activity with no raw Anthropic block is not sent back.
+	assistant := req.Messages[1]
+	if len(assistant.Content) != 1 || assistant.Content[0].Type != "text" {
+		t.Errorf("assistant content = %+v, want only the text block", assistant.Content)
+	}
+}
+
+func TestServerToolBlocksResentAfterPause(t *testing.T) {
+	sse := func(events ...string) string {
+		var b strings.Builder
+		for _, e := range events {
+			b.WriteString("data: " + e + "\n\n")
+		}
+		return b.String()
+	}
+	search := `{"type":"web_search_tool_result","tool_use_id":"srvtoolu_1","content":[{"type":"web_search_result","url":"https://go.dev/doc/go1.26","title":"Go 1.26 Release Notes","encrypted_content":"abc","page_age":null}]}`
+	responses := []string{
+		sse(
+			`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"test","content":[],"stop_reason":null,"usage":{"input_tokens":10,"output_tokens":0}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{}}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"query\": \"go 1.26\"}"}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"content_block_start","index":1,"content_block":`+search+`}`,
+			`{"type":"content_block_stop","index":1}`,
+			`{"type":"message_delta","delta":{"stop_reason":"pause_turn"},"usage":{"output_tokens":5}}`,
+			`{"type":"message_stop"}`,
+		),
+		sse(
+			`{"type":"message_start","message":{"id":"msg_2","type":"message","role":"assistant","model":"test","content":[],"stop_reason":null,"usage":{"input_tokens":10,"output_tokens":0}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":"done"}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":5}}`,
+			`{"type":"message_stop"}`,
+		),
+	}
+	var bodies [][]byte
+	s := &Service{
+		APIKey: "test-key",
+		HTTPC: &http.Client{Transport: &roundTripFunc{fn: func(req *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			bodies = append(bodies, body)
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"text/event-stream"}},
+				Body:       io.NopCloser(strings.NewReader(responses[len(bodies)-1])),
+			}
+			return resp, nil
+		}}},
+	}
+
+	req := &llm.Request{
+		Tools:    []*llm.Tool{llm.ServerTool(llm.ToolTypeWebSearch)},
+		Messages: []llm.Message{llm.UserStringMessage("search for go 1.26")},
+	}
+	resp, err := s.Do(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.StopReason != llm.StopReasonPauseTurn {
+		t.Fatalf("StopReason = %v, want pause_turn", resp.StopReason)
+	}
+	req.Messages = append(req.Messages, resp.ToMessage())
+	if _, err := s.Do(context.Background(), req); err != nil {
+		t.Fatalf("Do() after pause error = %v", err)
+	}
+
+	var sent struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content any    `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(bodies[1], &sent); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent.Messages) != 2 || sent.Messages[1].Role != "assistant" {
+		t.Fatalf("sent messages = %+v, want user and assistant", sent.Messages)
+	}
+	var want any
+	if err := json.Unmarshal([]byte(`[{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{"query":"go 1.26"}},`+search+`]`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if got := sent.Messages[1].Content; !reflect.DeepEqual(got, want) {
+		t.Errorf("resent assistant content = %v, want %v", got, want)
+	}
+}
+
+func TestServerToolBetasNone(t *testing.T) {
+	tools := []*tool{{Name: "bash"}, fromLLMTool(llm.ServerTool(llm.ToolTypeWebSearch))}
+	if got := serverToolBetas(tools); got != "" {
+		t.Errorf("serverToolBetas() = %q, want empty", got)
+	}
+}
+
+func TestParseSSEStreamServerTools(t *testing.T) {
+	var b strings.Builder
+	event := func(data string) {
+		var e struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			t.Fatal(err)
+		}
+		b.WriteString("event: " + e.Type + "\ndata: " + data + "\n\n")
+	}
+	event(`{"type":"message_start","message":{"id":"msg_srv","type":"message","role":"assistant","model":"test","content":[],"stop_reason":null,"usage":{"input_tokens":10,"output_tokens":0}}}`)
+	event(`{"type":"content_block_start","index":0,"content_block":{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{}}}`)
+	event(`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"query\": \"go 1.26 release\"}"}}`)
+	event(`{"type":"content_block_stop","index":0}`)
+	event(`{"type":"content_block_start","index":1,"content_block":{"type":"web_search_tool_result","tool_use_id":"srvtoolu_1","content":[{"type":"web_search_result","url":"https://go.dev/doc/go1.26","title":"Go 1.26 Release Notes","encrypted_content":"abc","page_age":null}]}}`)
+	event(`{"type":"content_block_stop","index":1}`)
+	event(`{"type":"content_block_start","index":2,"content_block":{"type":"server_tool_use","id":"srvtoolu_2","name":"bash_code_execution","input":{}}}`)
+	event(`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"command\": \"echo hi\"}"}}`)
+	event(`{"type":"content_block_stop","index":2}`)
+	event(`{"type":"content_block_start","index":3,"content_block":{"type":"bash_code_execution_tool_result","tool_use_id":"srvtoolu_2","content":{"type":"bash_code_execution_result","stdout":"hi\n","stderr":"","return_code":0}}}`)
+	event(`{"type":"content_block_stop","index":3}`)
+	event(`{"type":"content_block_start","index":4,"content_block":{"type":"web_fetch_tool_result","tool_use_id":"srvtoolu_3","content":{"type":"web_fetch_tool_error","error_code":"url_not_accessible"}}}`)
+	event(`{"type":"content_block_stop","index":4}`)
+	event(`{"type":"content_block_start","index":5,"content_block":{"type":"text","text":""}}`)
+	event(`{"type":"content_block_delta","index":5,"delta":{"type":"text_delta","text":"Go 1.26 is out."}}`)
+	event(`{"type":"content_block_stop","index":5}`)
+	event(`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":20}}`)
+	event(`{"type":"message_stop"}`)
+
+	resp, err := parseSSEStream(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("parseSSEStream() error = %v", err)
+	}
+	got := toLLMResponse(resp).Content
+	if len(got) != 6 {
+		t.Fatalf("got %d contents, want 6", len(got))
+	}
+
+	if got[0].Type != llm.ContentTypeServerToolUse || got[0].ToolName != llm.ToolTypeWebSearch || got[0].ID != "srvtoolu_1" {
+		t.Errorf("content[0] = %+v, want web_search server tool use", got[0])
+	}
+	search, ok := got[1].Display.(llm.ServerToolResult)
+	if got[1].Type != llm.ContentTypeServerToolResult || !ok {
+		t.Fatalf("content[1] = %+v, want server tool result", got[1])
+	}
+	if search.Query != "go 1.26 release" {
+		t.Errorf("search query = %q, want %q", search.Query, "go 1.26 release")
+	}
+	if len(search.Sources) != 1 || search.Sources[0].URL != "https://go.dev/doc/go1.26" || search.Sources[0].Title != "Go 1.26 Release Notes" {
+		t.Errorf("search sources = %+v", search.Sources)
+	}
+
+	if got[2].ToolName != llm.ToolTypeCodeExecution {
+		t.Errorf("content[2].ToolName = %q, want %q", got[2].ToolName, llm.ToolTypeCodeExecution)
+	}
+	exec := got[3].Display.(llm.ServerToolResult)
+	if exec.Code != "echo hi" || exec.Output != "hi\n" || got[3].ToolError {
+		t.Errorf("code execution result = %+v (error %v)", exec, got[3].ToolError)
+	}
+
+	fetch := got[4].Display.(llm.ServerToolResult)
+	if !got[4].ToolError || fetch.Type != llm.ToolTypeURLContext || fetch.Error != "url_not_accessible" {
+		t.Errorf("web fetch result = %+v (error %v)", fetch, got[4].ToolError)
+	}
+
+	if got[5].Type != llm.ContentTypeText || got[5].Text != "Go 1.26 is out." {
+		t.Errorf("content[5] = %+v, want text", got[5])
+	}
+}
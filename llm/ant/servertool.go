@@ -0,0 +1,224 @@
+package ant
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"shelley.exe.dev/llm"
+)
+
+// serverTools maps llm server tool types to Anthropic's versioned tool definitions.
+// https://docs.anthropic.com/en/docs/agents-and-tools/tool-use/web-search-tool
+// https://docs.anthropic.com/en/docs/agents-and-tools/tool-use/code-execution-tool
+// https://docs.anthropic.com/en/docs/agents-and-tools/tool-use/web-fetch-tool
+var serverTools = map[string]struct {
+	name, typ, beta string
+}{
+	llm.ToolTypeWebSearch:     {name: "web_search", typ: "web_search_20250305"},
+	llm.ToolTypeCodeExecution: {name: "code_execution", typ: "code_execution_20250825", beta: "code-execution-2025-08-25"},
+	llm.ToolTypeURLContext:    {name: "web_fetch", typ: "web_fetch_20250910", beta: "web-fetch-2025-09-10"},
+}
+
+// serverToolBetas returns the anthropic-beta header value required by tools, if any.
+func serverToolBetas(tools []*tool) string {
+	var betas []string
+	for _, st := range serverTools {
+		if st.beta != "" && slices.ContainsFunc(tools, func(t *tool) bool { return t.Type == st.typ }) {
+			betas = append(betas, st.beta)
+		}
+	}
+	slices.Sort(betas)
+	return strings.Join(betas, ",")
+}
+
+// serverToolResultTypes maps server tool result block types to llm server tool types.
+var serverToolResultTypes = map[string]string{
+	"web_search_tool_result":                 llm.ToolTypeWebSearch,
+	"code_execution_tool_result":             llm.ToolTypeCodeExecution,
+	"bash_code_execution_tool_result":        llm.ToolTypeCodeExecution,
+	"text_editor_code_execution_tool_result": llm.ToolTypeCodeExecution,
+	"web_fetch_tool_result":                  llm.ToolTypeURLContext,
+}
+
+// UnmarshalJSON decodes a content block.
+// Server tool result blocks reuse the "content" key for payloads that are not
+// content blocks (a code execution result is an object, for example),
+// so for those the raw value is kept in ServerResult instead.
+func (c *content) UnmarshalJSON(data []byte) error {
+	type plain content
+	var aux struct {
+		*plain
+		Content json.RawMessage `json:"content,omitempty"`
+	}
+	aux.plain = (*plain)(c)
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if len(aux.Content) == 0 || string(aux.Content) == "null" {
+		return nil
+	}
+	if _, ok := serverToolResultTypes[c.Type]; ok {
+		c.ServerResult = aux.Content
+		return nil
+	}
+	return json.Unmarshal(aux.Content, &c.ToolResult)
+}
+
+// MarshalJSON encodes a content block.
+// Server tool blocks are resent exactly as Claude produced them, from raw.
+func (c content) MarshalJSON() ([]byte, error) {
+	type plain content
+	if len(c.raw) == 0 {
+		return json.Marshal(plain(c))
+	}
+	if len(c.CacheControl) == 0 {
+		return c.raw, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(c.raw, &fields); err != nil {
+		return nil, err
+	}
+	fields["cache_control"] = c.CacheControl
+	return json.Marshal(fields)
+}
+
+// isServerToolBlock reports whether raw is an Anthropic server tool block.
+func isServerToolBlock(raw json.RawMessage) bool {
+	var block struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(raw, &block) != nil {
+		return false
+	}
+	_, ok := serverToolResultTypes[block.Type]
+	return ok || block.Type == "server_tool_use"
+}
+
+// toLLMServerToolContents replaces the conversions of raw's server tool blocks
+// (converted[i] is the conversion of raw[i]) with server tool content.
+// Inputs of server_tool_use blocks are copied into the matching results,
+// so that each result is self-describing.
+// Each block is also kept in Raw, to be sent back when the turn continues.
+func toLLMServerToolContents(raw []content, converted []llm.Content) []llm.Content {
+	uses := make(map[string]content)
+	for i, c := range raw {
+		if c.Type != "server_tool_use" {
+			continue
+		}
+		uses[c.ID] = c
+		converted[i] = llm.Content{
+			ID:        c.ID,
+			Type:      llm.ContentTypeServerToolUse,
+			ToolName:  toLLMServerToolName(c.ToolName),
+			ToolInput: c.ToolInput,
+			Raw: serverToolBlock(map[string]any{
+				"type":  c.Type,
+				"id":    c.ID,
+				"name":  c.ToolName,
+				"input": orJSON(c.ToolInput, "{}"),
+			}),
+		}
+	}
+	for i, c := range raw {
+		typ, ok := serverToolResultTypes[c.Type]
+		if !ok {
+			continue
+		}
+		r := parseServerToolResult(typ, c.ServerResult)
+		if use, ok := uses[c.ToolUseID]; ok {
+			var in struct {
+				Query   string `json:"query"`
+				URL     string `json:"url"`
+				Code    string `json:"code"`
+				Command string `json:"command"`
+			}
+			json.Unmarshal(use.ToolInput, &in)
+			r.Query = cmp.Or(in.Query, in.URL)
+			r.Code = cmp.Or(in.Code, in.Command)
+		}
+		converted[i] = llm.ServerToolResultContent(c.ToolUseID, typ, r)
+		converted[i].Raw = serverToolBlock(map[string]any{
+			"type":        c.Type,
+			"tool_use_id": c.ToolUseID,
+			"content":     orJSON(c.ServerResult, "null"),
+		})
+	}
+	return converted
+}
+
+// serverToolBlock encodes the fields of a server tool block.
+// It returns nil if they cannot be encoded, in which case the block is not resent.
+func serverToolBlock(fields map[string]any) json.RawMessage {
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// orJSON returns raw, or def if raw is empty.
+func orJSON(raw json.RawMessage, def string) json.RawMessage {
+	if len(raw) == 0 {
+		return json.RawMessage(def)
+	}
+	return raw
+}
+
+// toLLMServerToolName maps an Anthropic server tool name back to its llm type.
+// Code execution runs as several sub-tools (bash_code_execution, text_editor_code_execution).
+func toLLMServerToolName(name string) string {
+	for typ, st := range serverTools {
+		if st.name == name {
+			return typ
+		}
+	}
+	if strings.HasSuffix(name, "code_execution") {
+		return llm.ToolTypeCodeExecution
+	}
+	return name
+}
+
+// parseServerToolResult decodes the content of a server tool result block.
+func parseServerToolResult(typ string, raw json.RawMessage) llm.ServerToolResult {
+	r := llm.ServerToolResult{Type: typ}
+	// A web search result is a list of pages; everything else is a single object.
+	var pages []struct {
+		URL   string `json:"url"`
+		Title string `json:"title"`
+	}
+	if json.Unmarshal(raw, &pages) == nil {
+		for _, p := range pages {
+			r.Sources = append(r.Sources, llm.ServerToolSource{Title: p.Title, URL: p.URL})
+		}
+		return r
+	}
+	var obj struct {
+		ErrorCode  string `json:"error_code"`
+		Stdout     string `json:"stdout"`
+		Stderr     string `json:"stderr"`
+		ReturnCode int    `json:"return_code"`
+		URL        string `json:"url"`
+		Content    struct {
+			Title string `json:"title"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		r.Error = "malformed result: " + err.Error()
+		return r
+	}
+	switch {
+	case obj.ErrorCode != "":
+		r.Error = obj.ErrorCode
+	case obj.URL != "":
+		r.Sources = []llm.ServerToolSource{{Title: obj.Content.Title, URL: obj.URL}}
+	default:
+		r.Output = obj.Stdout + obj.Stderr
+		if obj.ReturnCode != 0 {
+			r.Output += fmt.Sprintf("\n[exit status %d]", obj.ReturnCode)
+		}
+	}
+	return r
+}
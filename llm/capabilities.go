@@ -0,0 +1,31 @@
+package llm
+
+// Capabilities describes what a model can handle.
+// Tool sets use it to pick tool schemas and decide which tools to offer.
+type Capabilities struct {
+	// Tools reports whether the model supports tool calls at all.
+	Tools bool `json:"tools"`
+	// Images reports whether the model accepts image input.
+	Images bool `json:"images"`
+	// Reasoning reports whether the model produces reasoning (thinking) output.
+	Reasoning bool `json:"reasoning"`
+	// SimplifiedPatch selects the simplified patch tool schema,
+	// for models that struggle with the full one.
+	SimplifiedPatch bool `json:"simplified_patch"`
+	// ParallelToolCalls reports whether the model may make several tool calls in one response.
+	ParallelToolCalls bool `json:"parallel_tool_calls"`
+	// MaxImageDimension is the maximum pixel dimension of images sent to the model.
+	// Zero defers to the service's MaxImageDimension.
+	MaxImageDimension int `json:"max_image_dimension,omitempty"`
+	// MaxTools is the maximum number of tools to offer the model; zero means no limit.
+	MaxTools int `json:"max_tools,omitempty"`
+}
+
+// DefaultCapabilities are assumed for models that do not describe themselves.
+// They are deliberately conservative about the patch schema.
+var DefaultCapabilities = Capabilities{
+	Tools:             true,
+	Images:            true,
+	SimplifiedPatch:   true,
+	ParallelToolCalls: true,
+}
@@ -49,13 +49,28 @@ type responsesRequest struct {
 	Instructions string               `json:"instructions,omitempty"` // System prompt
 	Tools        []responsesTool      `json:"tools,omitempty"`
 	ToolChoice   any                  `json:"tool_choice,omitempty"`
+	// ParallelToolCalls is only sent (as false) to disable parallel tool calls.
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
 	// Note: max_output_tokens is not supported by ChatGPT API
 	Reasoning *responsesReasoning `json:"reasoning,omitempty"`
 	Include   []string            `json:"include,omitempty"`
+	Text      *responsesText      `json:"text,omitempty"`
 	Stream    bool                `json:"stream"` // Must be true for ChatGPT API
 	Store     bool                `json:"store"`  // Must be false for ChatGPT API
 }
 
+// responsesText configures the output text format, used for structured outputs.
+type responsesText struct {
+	Format responsesTextFormat `json:"format"`
+}
+
+type responsesTextFormat struct {
+	Type        string          `json:"type"` // "text", "json_object", "json_schema"
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+}
+
 type responsesReasoning struct {
 	Effort  string `json:"effort,omitempty"`  // "low", "medium", "high"
 	Summary string `json:"summary,omitempty"` // "auto", "concise", "detailed"
@@ -72,8 +87,10 @@ type responsesInputItem struct {
 }
 
 type responsesContent struct {
-	Type string `json:"type"` // "input_text", "output_text"
-	Text string `json:"text"`
+	Type     string `json:"type"` // "input_text", "output_text", "input_file"
+	Text     string `json:"text,omitempty"`
+	Filename string `json:"filename,omitempty"`  // for input_file
+	FileData string `json:"file_data,omitempty"` // for input_file, as a data URL
 }
 
 type responsesTool struct {
@@ -432,9 +449,17 @@ func fromLLMMessage(msg llm.Message) []responsesInputItem {
 	}
 
 	// Process tool results first
+	var attachments []responsesContent
 	for _, tr := range toolResults {
 		var texts []string
 		for _, result := range tr.ToolResult {
+			// Function call outputs are text only, so PDFs are attached in a following user message.
+			if result.MediaType == llm.MediaTypePDF {
+				attachments = append(attachments, fromLLMDocument(result))
+				texts = append(texts, fmt.Sprintf("[document %s attached below]", cmp.Or(result.Title, result.MediaType)))
+				continue
+			}
+			result = llm.DocumentFallback(result)
 			if strings.TrimSpace(result.Text) != "" {
 				texts = append(texts, result.Text)
 			}
@@ -455,6 +480,13 @@ func fromLLMMessage(msg llm.Message) []responsesInputItem {
 			Output: cmp.Or(toolResultContent, " "),
 		})
 	}
+	if len(attachments) > 0 {
+		items = append(items, responsesInputItem{
+			Type:    "message",
+			Role:    "user",
+			Content: attachments,
+		})
+	}
 
 	// Process regular content
 	if len(regularContent) > 0 {
@@ -464,6 +496,10 @@ func fromLLMMessage(msg llm.Message) []responsesInputItem {
 		for _, c := range regularContent {
 			switch c.Type {
 			case llm.ContentTypeText:
+				if c.IsDocument() {
+					messageContent = append(messageContent, fromLLMDocument(c))
+					continue
+				}
 				if c.Text != "" {
 					contentType := "input_text"
 					if msg.Role == llm.MessageRoleAssistant {
@@ -506,6 +542,19 @@ func fromLLMMessage(msg llm.Message) []responsesInputItem {
 	return items
 }
 
+// fromLLMDocument converts document content to a Responses API input part.
+// PDFs are sent inline as input_file; other documents are sent as their text.
+func fromLLMDocument(c llm.Content) responsesContent {
+	if c.MediaType == llm.MediaTypePDF {
+		return responsesContent{
+			Type:     "input_file",
+			Filename: cmp.Or(c.Title, "document.pdf"),
+			FileData: "data:" + c.MediaType + ";base64," + c.Data,
+		}
+	}
+	return responsesContent{Type: "input_text", Text: llm.DocumentFallback(c).Text}
+}
+
 // fromLLMTool converts llm.Tool to Responses API tool format
 func fromLLMTool(t *llm.Tool) responsesTool {
 	return responsesTool{
@@ -548,6 +597,19 @@ func fromLLMSystem(systemContent []llm.SystemContent) []responsesInputItem {
 	}
 }
 
+// fromLLMResponseSchema converts an llm.ResponseSchema to a Responses API text format.
+func fromLLMResponseSchema(rs *llm.ResponseSchema) *responsesText {
+	if rs == nil {
+		return nil
+	}
+	return &responsesText{Format: responsesTextFormat{
+		Type:        "json_schema",
+		Name:        rs.SchemaName(),
+		Description: rs.Description,
+		Schema:      rs.Schema,
+	}}
+}
+
 // fromLLMToolChoice converts llm.ToolChoice to Responses API format
 func fromLLMToolChoice(tc *llm.ToolChoice) any {
 	if tc == nil {
@@ -679,6 +741,8 @@ func (s *Service) toLLMUsage(usage responsesUsage, headers http.Header) llm.Usag
 		OutputTokens:             out,
 		ReasoningTokens:          reasoning,
 	}
+	// Codex is billed through the ChatGPT subscription, so there is no per-token
+	// price; only a gateway-reported cost is recorded.
 	u.CostUSD = llm.CostUSDFromResponse(headers)
 	return u
 }
@@ -747,6 +811,10 @@ func (s *Service) Do(ctx context.Context, ir *llm.Request) (*llm.Response, error
 	// Convert tools
 	var tools []responsesTool
 	for _, t := range ir.Tools {
+		// Server tools are not supported by the Codex backend.
+		if t.IsServerTool() {
+			continue
+		}
 		tools = append(tools, fromLLMTool(t))
 	}
 
@@ -756,6 +824,7 @@ func (s *Service) Do(ctx context.Context, ir *llm.Request) (*llm.Response, error
 		Input:        allInput,
 		Instructions: instructions,
 		Tools:        tools,
+		Text:         fromLLMResponseSchema(ir.ResponseSchema),
 		Stream:       true,  // Required for ChatGPT API
 		Store:        false, // Required for ChatGPT API
 	}
@@ -773,6 +842,9 @@ func (s *Service) Do(ctx context.Context, ir *llm.Request) (*llm.Response, error
 	if ir.ToolChoice != nil {
 		req.ToolChoice = fromLLMToolChoice(ir.ToolChoice)
 	}
+	if ir.DisableParallelToolCalls && len(req.Tools) > 0 {
+		req.ParallelToolCalls = new(bool)
+	}
 
 	fullURL := ChatGPTAPIURL + "/responses"
 
@@ -938,6 +1010,10 @@ func (s *Service) DoStreamWithThinking(ctx context.Context, ir *llm.Request, onT
 	// Convert tools
 	var tools []responsesTool
 	for _, t := range ir.Tools {
+		// Server tools are not supported by the Codex backend.
+		if t.IsServerTool() {
+			continue
+		}
 		tools = append(tools, fromLLMTool(t))
 	}
 
@@ -947,6 +1023,7 @@ func (s *Service) DoStreamWithThinking(ctx context.Context, ir *llm.Request, onT
 		Input:        allInput,
 		Instructions: instructions,
 		Tools:        tools,
+		Text:         fromLLMResponseSchema(ir.ResponseSchema),
 		Stream:       true,
 		Store:        false,
 	}
@@ -964,6 +1041,9 @@ func (s *Service) DoStreamWithThinking(ctx context.Context, ir *llm.Request, onT
 	if ir.ToolChoice != nil {
 		req.ToolChoice = fromLLMToolChoice(ir.ToolChoice)
 	}
+	if ir.DisableParallelToolCalls && len(req.Tools) > 0 {
+		req.ParallelToolCalls = new(bool)
+	}
 
 	fullURL := ChatGPTAPIURL + "/responses"
 
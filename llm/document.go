@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"shelley.exe.dev/llm/docutil"
+)
+
+// Document media types.
+// Like images, documents are represented as ContentTypeText with MediaType set and
+// base64-encoded Data; Title optionally carries the file name.
+const (
+	MediaTypePDF       = "application/pdf"
+	MediaTypePlainText = "text/plain"
+)
+
+// maxDocumentFallbackLen caps the extracted text sent in place of a document,
+// so a large datasheet cannot blow out the context window on its own.
+const maxDocumentFallbackLen = 100_000
+
+// DocumentContent returns content carrying a document.
+func DocumentContent(mediaType, title string, data []byte) Content {
+	return Content{
+		Type:      ContentTypeText,
+		MediaType: mediaType,
+		Title:     title,
+		Data:      base64.StdEncoding.EncodeToString(data),
+	}
+}
+
+// IsImage reports whether c carries an image.
+func (c Content) IsImage() bool {
+	return c.Type == ContentTypeText && strings.HasPrefix(c.MediaType, "image/")
+}
+
+// IsDocument reports whether c carries a document (PDF or plain text).
+func (c Content) IsDocument() bool {
+	return c.Type == ContentTypeText && IsDocumentMediaType(c.MediaType)
+}
+
+// IsDocumentMediaType reports whether mediaType is a supported document type.
+func IsDocumentMediaType(mediaType string) bool {
+	return mediaType == MediaTypePDF || mediaType == MediaTypePlainText
+}
+
+// DocumentText returns the text of a document.
+// PDF text is extracted with docutil.ExtractPDFText.
+func DocumentText(c Content) (string, error) {
+	if !c.IsDocument() {
+		return "", fmt.Errorf("content is not a document")
+	}
+	data, err := base64.StdEncoding.DecodeString(c.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode document data: %w", err)
+	}
+	if c.MediaType == MediaTypePDF {
+		return docutil.ExtractPDFText(data)
+	}
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("document is not valid UTF-8 text")
+	}
+	return string(data), nil
+}
+
+// DocumentFallback converts document content to plain text content, for providers
+// (or positions, such as tool results) without native document support.
+// Non-document content is returned unchanged.
+// If no text can be extracted, the returned content says so, so the model knows a document was there.
+func DocumentFallback(c Content) Content {
+	if !c.IsDocument() {
+		return c
+	}
+	name := c.Title
+	if name == "" {
+		name = c.MediaType
+	}
+	text, err := DocumentText(c)
+	if err != nil {
+		return StringContent(fmt.Sprintf("[document %s: text could not be extracted: %v]", name, err))
+	}
+	if len(text) > maxDocumentFallbackLen {
+		text = cutUTF8(text, maxDocumentFallbackLen) + "\n[... document truncated ...]"
+	}
+	return StringContent(fmt.Sprintf("[document %s]\n%s", name, text))
+}
+
+// cutUTF8 returns the longest prefix of s of at most n bytes that does not
+// split a UTF-8 character.
+func cutUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
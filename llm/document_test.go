@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// testPDF is a minimal single-page PDF with an uncompressed content stream.
+const testPDF = "%PDF-1.4\n" +
+	"1 0 obj\n<< /Type /Page >>\nendobj\n" +
+	"2 0 obj\n<< /Length 30 >>\nstream\nBT (Pin 3 is ground) Tj ET\nendstream\nendobj\n" +
+	"%%EOF\n"
+
+func TestDocumentContent(t *testing.T) {
+	c := DocumentContent(MediaTypePDF, "spec.pdf", []byte(testPDF))
+	if !c.IsDocument() {
+		t.Error("IsDocument() = false for PDF content")
+	}
+	if c.IsImage() {
+		t.Error("IsImage() = true for PDF content")
+	}
+	if c.Title != "spec.pdf" {
+		t.Errorf("Title = %q, want %q", c.Title, "spec.pdf")
+	}
+
+	img := Content{Type: ContentTypeText, MediaType: "image/png", Data: "AAAA"}
+	if img.IsDocument() || !img.IsImage() {
+		t.Errorf("image content: IsDocument() = %v, IsImage() = %v", img.IsDocument(), img.IsImage())
+	}
+	if StringContent("hi").IsDocument() {
+		t.Error("IsDocument() = true for plain text content")
+	}
+}
+
+func TestDocumentFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		content Content
+		want    string
+	}{
+		{
+			name:    "pdf",
+			content: DocumentContent(MediaTypePDF, "spec.pdf", []byte(testPDF)),
+			want:    "[document spec.pdf]\nPin 3 is ground",
+		},
+		{
+			name:    "plain text",
+			content: DocumentContent(MediaTypePlainText, "notes.txt", []byte("hello")),
+			want:    "[document notes.txt]\nhello",
+		},
+		{
+			name:    "no text layer",
+			content: DocumentContent(MediaTypePDF, "", []byte("%PDF-1.4\n%%EOF\n")),
+			want:    "[document application/pdf: text could not be extracted",
+		},
+		{
+			name:    "not a document",
+			content: StringContent("unchanged"),
+			want:    "unchanged",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DocumentFallback(tt.content)
+			if got.Type != ContentTypeText || got.MediaType != "" {
+				t.Errorf("DocumentFallback() = %+v, want plain text content", got)
+			}
+			if !strings.HasPrefix(got.Text, tt.want) {
+				t.Errorf("DocumentFallback().Text = %q, want prefix %q", got.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentFallbackTruncatesOnRuneBoundary(t *testing.T) {
+	// "é" is two bytes, so the limit falls inside one.
+	text := "x" + strings.Repeat("é", maxDocumentFallbackLen)
+	got := DocumentFallback(DocumentContent(MediaTypePlainText, "notes.txt", []byte(text))).Text
+	if !utf8.ValidString(got) {
+		t.Fatalf("DocumentFallback() produced invalid UTF-8: %q", got[len(got)-40:])
+	}
+	if !strings.HasSuffix(got, "é\n[... document truncated ...]") {
+		t.Errorf("DocumentFallback() = %q, want truncated text", got[len(got)-40:])
+	}
+}
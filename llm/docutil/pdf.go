@@ -0,0 +1,376 @@
+package docutil
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// IsPDF checks if data is a PDF document based on file magic.
+func IsPDF(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("%PDF-"))
+}
+
+// ErrNoText is returned when a document contains no extractable text
+// (for example, a scanned PDF without a text layer).
+var ErrNoText = errors.New("document contains no extractable text")
+
+// ExtractPDFText extracts the text of a PDF document.
+// It uses pdftotext (poppler) when it is installed, because its layout handling is far better,
+// and otherwise falls back to a built-in extractor that understands the common cases:
+// uncompressed and Flate-compressed content streams with literal or hex string operands.
+func ExtractPDFText(data []byte) (string, error) {
+	if !IsPDF(data) {
+		return "", fmt.Errorf("not a PDF document")
+	}
+	if text, err := pdftotext(data); err == nil && strings.TrimSpace(text) != "" {
+		return text, nil
+	}
+	text := extractPDFTextBuiltin(data)
+	if strings.TrimSpace(text) == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+func pdftotext(data []byte) (string, error) {
+	path, err := exec.LookPath("pdftotext")
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, "-layout", "-", "-")
+	cmd.Stdin = bytes.NewReader(data)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("pdftotext: %w: %s", err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+var streamRe = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+
+// Limits on decompressed content, so that a small PDF cannot expand into
+// gigabytes. Streams are truncated at the first, and the rest of the file is
+// skipped at the second.
+const (
+	maxPDFStreamSize = 16 << 20
+	maxPDFTotalSize  = 64 << 20
+)
+
+// extractPDFTextBuiltin walks every stream in the file and pulls text out of the ones
+// that look like page content streams. Fonts with custom encodings are not decoded,
+// so output for such documents may be incomplete.
+func extractPDFTextBuiltin(data []byte) string {
+	var out strings.Builder
+	budget := int64(maxPDFTotalSize)
+	for _, loc := range streamRe.FindAllSubmatchIndex(data, -1) {
+		if budget <= 0 {
+			break
+		}
+		dict := data[loc[2]:loc[3]]
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		raw := data[start : start+end]
+		if bytes.Contains(dict, []byte("/Subtype")) || bytes.Contains(dict, []byte("/Type/XRef")) || bytes.Contains(dict, []byte("/Type /XRef")) {
+			// Images, fonts and cross-reference streams carry no page text.
+			continue
+		}
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			r, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				continue
+			}
+			// Truncated streams still yield a useful prefix, so read errors are ignored.
+			raw, _ = io.ReadAll(io.LimitReader(r, min(maxPDFStreamSize, budget)))
+			budget -= int64(len(raw))
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			// Other filters (DCT, LZW, ...) are not supported.
+			continue
+		}
+		text := contentStreamText(raw)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		out.WriteString(text)
+		if !strings.HasSuffix(text, "\n") {
+			out.WriteByte('\n')
+		}
+	}
+	return out.String()
+}
+
+// contentStreamText interprets the text-showing operators of a PDF content stream.
+func contentStreamText(stream []byte) string {
+	var out strings.Builder
+	var operands []string // decoded string operands since the last operator
+	inText := false
+	lastWasNewline := true
+
+	newline := func() {
+		if !lastWasNewline {
+			out.WriteByte('\n')
+			lastWasNewline = true
+		}
+	}
+	write := func(s string) {
+		if s == "" {
+			return
+		}
+		out.WriteString(s)
+		lastWasNewline = false
+	}
+
+	s := stream
+	for len(s) > 0 {
+		c := s[0]
+		switch {
+		case isPDFSpace(c):
+			s = s[1:]
+		case c == '%':
+			if i := bytes.IndexAny(s, "\r\n"); i >= 0 {
+				s = s[i:]
+			} else {
+				s = nil
+			}
+		case c == '(':
+			str, rest := readLiteralString(s)
+			operands = append(operands, str)
+			s = rest
+		case c == '<' && len(s) > 1 && s[1] == '<':
+			s = s[2:]
+		case c == '>' && len(s) > 1 && s[1] == '>':
+			s = s[2:]
+		case c == '<':
+			str, rest := readHexString(s)
+			operands = append(operands, str)
+			s = rest
+		case c == '[':
+			// TJ arrays mix strings with kerning adjustments; large negative
+			// adjustments are how most producers encode inter-word spaces.
+			end := arrayEnd(s)
+			arr := s[1:end]
+			var sb strings.Builder
+			for len(arr) > 0 {
+				switch {
+				case arr[0] == '(':
+					str, rest := readLiteralString(arr)
+					sb.WriteString(str)
+					arr = rest
+				case arr[0] == '<':
+					str, rest := readHexString(arr)
+					sb.WriteString(str)
+					arr = rest
+				case arr[0] == '-' || arr[0] == '.' || (arr[0] >= '0' && arr[0] <= '9'):
+					i := 1
+					for i < len(arr) && (arr[i] == '.' || (arr[i] >= '0' && arr[i] <= '9')) {
+						i++
+					}
+					var n float64
+					fmt.Sscanf(string(arr[:i]), "%g", &n)
+					if n < -200 {
+						sb.WriteByte(' ')
+					}
+					arr = arr[i:]
+				default:
+					arr = arr[1:]
+				}
+			}
+			operands = append(operands, sb.String())
+			if end < len(s) {
+				end++
+			}
+			s = s[end:]
+		default:
+			i := 0
+			for i < len(s) && !isPDFSpace(s[i]) && !isPDFDelimiter(s[i]) {
+				i++
+			}
+			if i == 0 {
+				s = s[1:]
+				continue
+			}
+			tok := string(s[:i])
+			s = s[i:]
+			switch tok {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				newline()
+			case "Tj", "TJ":
+				if inText {
+					write(strings.Join(operands, ""))
+				}
+			case "'", "\"":
+				if inText {
+					newline()
+					write(strings.Join(operands, ""))
+				}
+			case "T*", "Td", "TD":
+				if inText {
+					newline()
+				}
+			}
+			if !isPDFOperand(tok) {
+				operands = operands[:0]
+			}
+		}
+	}
+	return out.String()
+}
+
+// isPDFOperand reports whether tok is a numeric or name operand rather than an operator.
+func isPDFOperand(tok string) bool {
+	if strings.HasPrefix(tok, "/") {
+		return true
+	}
+	for _, r := range tok {
+		if !unicode.IsDigit(r) && r != '.' && r != '-' && r != '+' {
+			return false
+		}
+	}
+	return true
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// arrayEnd returns the index of the ']' closing the array that starts at s[0],
+// skipping over brackets inside string operands.
+func arrayEnd(s []byte) int {
+	for i := 1; i < len(s); {
+		switch s[i] {
+		case ']':
+			return i
+		case '(':
+			_, rest := readLiteralString(s[i:])
+			i = len(s) - len(rest)
+		default:
+			i++
+		}
+	}
+	return len(s)
+}
+
+// readLiteralString decodes a (...) string starting at s[0] and returns the remaining input.
+func readLiteralString(s []byte) (string, []byte) {
+	var sb strings.Builder
+	depth := 0
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == '(':
+			if depth > 0 {
+				sb.WriteByte(c)
+			}
+			depth++
+			i++
+		case c == ')':
+			depth--
+			i++
+			if depth == 0 {
+				return sb.String(), s[i:]
+			}
+			sb.WriteByte(c)
+		case c == '\\' && i+1 < len(s):
+			i++
+			e := s[i]
+			switch e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// Line continuation.
+			default:
+				if e >= '0' && e <= '7' {
+					n := 0
+					j := 0
+					for j < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7' {
+						n = n*8 + int(s[i]-'0')
+						i++
+						j++
+					}
+					writePDFByte(&sb, byte(n))
+					continue
+				}
+				sb.WriteByte(e)
+			}
+			i++
+		default:
+			writePDFByte(&sb, c)
+			i++
+		}
+	}
+	return sb.String(), nil
+}
+
+// readHexString decodes a <...> string starting at s[0] and returns the remaining input.
+func readHexString(s []byte) (string, []byte) {
+	end := bytes.IndexByte(s, '>')
+	if end < 0 {
+		return "", nil
+	}
+	var digits []byte
+	for _, c := range s[1:end] {
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	raw := make([]byte, len(digits)/2)
+	for i := range raw {
+		fmt.Sscanf(string(digits[2*i:2*i+2]), "%02x", &raw[i])
+	}
+	// A UTF-16BE byte order mark or a run of two-byte codes with a leading zero byte
+	// indicates UTF-16; otherwise treat bytes as single-byte codes.
+	if len(raw) >= 2 && (raw[0] == 0xFE && raw[1] == 0xFF || raw[0] == 0) {
+		if raw[0] == 0xFE {
+			raw = raw[2:]
+		}
+		var sb strings.Builder
+		for i := 0; i+1 < len(raw); i += 2 {
+			sb.WriteRune(rune(raw[i])<<8 | rune(raw[i+1]))
+		}
+		return sb.String(), s[end+1:]
+	}
+	var sb strings.Builder
+	for _, b := range raw {
+		writePDFByte(&sb, b)
+	}
+	return sb.String(), s[end+1:]
+}
+
+// writePDFByte writes a single-byte character code as a Latin-1 rune, dropping control characters.
+func writePDFByte(sb *strings.Builder, b byte) {
+	if b < 0x20 && b != '\n' && b != '\t' {
+		return
+	}
+	sb.WriteRune(rune(b))
+}
@@ -0,0 +1,85 @@
+package docutil
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF returns a minimal PDF whose single page draws the given content stream.
+func buildPDF(t *testing.T, content string, compress bool) []byte {
+	t.Helper()
+	stream := []byte(content)
+	filter := ""
+	if compress {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		w.Write(stream)
+		w.Close()
+		stream = buf.Bytes()
+		filter = " /Filter /FlateDecode"
+	}
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	pdf.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n")
+	pdf.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Length %d%s >>\nstream\n", len(stream), filter)
+	pdf.Write(stream)
+	pdf.WriteString("\nendstream\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func TestIsPDF(t *testing.T) {
+	if !IsPDF([]byte("%PDF-1.7\n...")) {
+		t.Error("IsPDF() = false for PDF magic")
+	}
+	if IsPDF([]byte("hello")) {
+		t.Error("IsPDF() = true for plain text")
+	}
+}
+
+func TestExtractPDFTextBuiltin(t *testing.T) {
+	content := `BT /F1 12 Tf 72 720 Td (Design Spec) Tj 0 -14 Td [(Max) -250 (voltage:) -250 <332e3356>] TJ T* (escaped \(parens\)) Tj ET`
+	for _, compress := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compress=%v", compress), func(t *testing.T) {
+			got := extractPDFTextBuiltin(buildPDF(t, content, compress))
+			want := "Design Spec\nMax voltage: 3.3V\nescaped (parens)\n"
+			if got != want {
+				t.Errorf("extractPDFTextBuiltin() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestExtractPDFTextDecompressionLimit(t *testing.T) {
+	// The text past the per-stream limit is never decompressed.
+	content := `BT (start) Tj ET` + strings.Repeat(" ", maxPDFStreamSize) + `BT (hidden) Tj ET`
+	got := extractPDFTextBuiltin(buildPDF(t, content, true))
+	if got != "start\n" {
+		t.Errorf("extractPDFTextBuiltin() = %q, want %q", got, "start\n")
+	}
+}
+
+func TestExtractPDFText(t *testing.T) {
+	text, err := ExtractPDFText(buildPDF(t, `BT (Datasheet) Tj ET`, true))
+	if err != nil {
+		t.Fatalf("ExtractPDFText() error = %v", err)
+	}
+	if !strings.Contains(text, "Datasheet") {
+		t.Errorf("ExtractPDFText() = %q, want it to contain %q", text, "Datasheet")
+	}
+
+	if _, err := ExtractPDFText([]byte("not a pdf")); err == nil {
+		t.Error("ExtractPDFText() on non-PDF succeeded, want error")
+	}
+
+	// A page with only graphics operators has no text layer.
+	_, err = ExtractPDFText(buildPDF(t, `0 0 m 100 100 l S`, false))
+	if !errors.Is(err, ErrNoText) {
+		t.Errorf("ExtractPDFText() error = %v, want ErrNoText", err)
+	}
+}
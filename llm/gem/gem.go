@@ -28,6 +28,7 @@ type Service struct {
 	APIKey              string       // must be non-empty
 	Model               string       // defaults to DefaultModel if empty
 	ContextWindowTokens int
+	Pricing             *llm.Pricing // optional; prices usage when no gateway cost is reported
 }
 
 var _ llm.Service = (*Service)(nil)
@@ -46,6 +47,9 @@ func convertToolSchemas(tools []*llm.Tool) ([]gemini.FunctionDeclaration, error)
 
 	var decls []gemini.FunctionDeclaration
 	for _, tool := range tools {
+		if tool.IsServerTool() {
+			continue
+		}
 		// Parse the schema from raw JSON
 		var schemaJSON map[string]any
 		if err := json.Unmarshal(tool.InputSchema, &schemaJSON); err != nil {
@@ -189,6 +193,11 @@ func (s *Service) buildGeminiRequest(req *llm.Request) (*gemini.Request, error)
 		for _, c := range msg.Content {
 			switch c.Type {
 			case llm.ContentTypeText, llm.ContentTypeThinking, llm.ContentTypeRedactedThinking:
+				// Images and documents are sent as inline data
+				if c.Type == llm.ContentTypeText && c.MediaType != "" {
+					content.Parts = append(content.Parts, fromLLMInlineData(c))
+					continue
+				}
 				// Simple text content
 				content.Parts = append(content.Parts, gemini.Part{
 					Text: c.Text,
@@ -231,11 +240,17 @@ func (s *Service) buildGeminiRequest(req *llm.Request) (*gemini.Request, error)
 
 				// Handle tool results: Gemini only supports string results
 				// Combine all text content into a single string
+				// Images and documents are attached as inline data parts after the function response
 				var resultText string
+				var inlineParts []gemini.Part
 				if len(c.ToolResult) > 0 {
 					// Collect all text from content objects
 					texts := make([]string, 0, len(c.ToolResult))
 					for _, result := range c.ToolResult {
+						if result.Type == llm.ContentTypeText && result.MediaType != "" {
+							inlineParts = append(inlineParts, fromLLMInlineData(result))
+							continue
+						}
 						if result.Text != "" {
 							texts = append(texts, result.Text)
 						}
@@ -280,6 +295,7 @@ func (s *Service) buildGeminiRequest(req *llm.Request) (*gemini.Request, error)
 						Response: response,
 					},
 				})
+				content.Parts = append(content.Parts, inlineParts...)
 			}
 		}
 
@@ -296,11 +312,74 @@ func (s *Service) buildGeminiRequest(req *llm.Request) (*gemini.Request, error)
 		if len(decls) > 0 {
 			gemReq.Tools = []gemini.Tool{{FunctionDeclarations: decls}}
 		}
+		gemReq.Tools = append(gemReq.Tools, fromLLMServerTools(req.Tools)...)
+	}
+
+	// Structured output maps to a JSON response MIME type plus responseSchema
+	if req.ResponseSchema != nil {
+		var schemaJSON map[string]any
+		if err := json.Unmarshal(req.ResponseSchema.Schema, &schemaJSON); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response schema: %w", err)
+		}
+		schema := convertJSONSchemaToGeminiSchema(schemaJSON)
+		gemReq.GenerationConfig = &gemini.GenerationConfig{
+			ResponseMimeType: "application/json",
+			ResponseSchema:   &schema,
+		}
 	}
 
 	return gemReq, nil
 }
 
+// fromLLMInlineData converts image or document content to a Gemini inline data part.
+func fromLLMInlineData(c llm.Content) gemini.Part {
+	return gemini.Part{
+		InlineData: &gemini.Blob{
+			MimeType: c.MediaType,
+			Data:     c.Data,
+		},
+	}
+}
+
+// fromLLMServerTools converts server tools to Gemini's built-in tools, one Tool each.
+// Note that older Gemini models reject requests combining built-in tools with function declarations.
+func fromLLMServerTools(tools []*llm.Tool) []gemini.Tool {
+	var out []gemini.Tool
+	for _, t := range tools {
+		switch t.Type {
+		case llm.ToolTypeWebSearch:
+			out = append(out, gemini.Tool{GoogleSearch: &struct{}{}})
+		case llm.ToolTypeCodeExecution:
+			out = append(out, gemini.Tool{CodeExecution: &struct{}{}})
+		case llm.ToolTypeURLContext:
+			out = append(out, gemini.Tool{URLContext: &struct{}{}})
+		}
+	}
+	return out
+}
+
+// toLLMGrounding converts grounding metadata to web search server tool content.
+func toLLMGrounding(gm *gemini.GroundingMetadata) []llm.Content {
+	if gm == nil || (len(gm.WebSearchQueries) == 0 && len(gm.GroundingChunks) == 0) {
+		return nil
+	}
+	id := fmt.Sprintf("gemini_search_%d", time.Now().UnixNano())
+	r := llm.ServerToolResult{
+		Type:  llm.ToolTypeWebSearch,
+		Query: strings.Join(gm.WebSearchQueries, "; "),
+	}
+	for _, chunk := range gm.GroundingChunks {
+		if chunk.Web != nil {
+			r.Sources = append(r.Sources, llm.ServerToolSource{Title: chunk.Web.Title, URL: chunk.Web.URI})
+		}
+	}
+	input, _ := json.Marshal(map[string][]string{"queries": gm.WebSearchQueries})
+	return []llm.Content{
+		{ID: id, Type: llm.ContentTypeServerToolUse, ToolName: llm.ToolTypeWebSearch, ToolInput: input},
+		llm.ServerToolResultContent(id, llm.ToolTypeWebSearch, r),
+	}
+}
+
 // convertGeminiResponsesToContent converts a Gemini response to llm.Content
 func convertGeminiResponseToContent(res *gemini.Response) []llm.Content {
 	if res == nil || len(res.Candidates) == 0 || len(res.Candidates[0].Content.Parts) == 0 {
@@ -310,7 +389,11 @@ func convertGeminiResponseToContent(res *gemini.Response) []llm.Content {
 		}}
 	}
 
-	var contents []llm.Content
+	// Grounding happens before the model writes its answer, so it goes first.
+	contents := toLLMGrounding(res.Candidates[0].GroundingMetadata)
+
+	// ID of the latest executable code part, which the following result belongs to
+	var codeID, code string
 
 	// Process each part in the first candidate's content
 	for i, part := range res.Candidates[0].Content.Parts {
@@ -359,6 +442,26 @@ func convertGeminiResponseToContent(res *gemini.Response) []llm.Content {
 				"tool_name", part.FunctionCall.Name,
 				"args", string(args),
 				"thought_signature", part.ThoughtSignature)
+		} else if part.ExecutableCode != nil {
+			codeID = fmt.Sprintf("gemini_code_%d_%d", i, time.Now().UnixNano())
+			code = part.ExecutableCode.Code
+			input, _ := json.Marshal(part.ExecutableCode)
+			contents = append(contents, llm.Content{
+				ID:        codeID,
+				Type:      llm.ContentTypeServerToolUse,
+				ToolName:  llm.ToolTypeCodeExecution,
+				ToolInput: input,
+			})
+		} else if part.CodeExecutionResult != nil {
+			r := llm.ServerToolResult{
+				Type:   llm.ToolTypeCodeExecution,
+				Code:   code,
+				Output: part.CodeExecutionResult.Output,
+			}
+			if part.CodeExecutionResult.Outcome != gemini.OutcomeOK {
+				r.Error = string(part.CodeExecutionResult.Outcome)
+			}
+			contents = append(contents, llm.ServerToolResultContent(codeID, llm.ToolTypeCodeExecution, r))
 		} else if part.FunctionResponse != nil {
 			// We shouldn't normally get function responses from the model, but just in case
 			respData, _ := json.Marshal(part.FunctionResponse.Response)
@@ -394,6 +497,21 @@ func ensureToolIDs(contents []llm.Content) {
 	}
 }
 
+// toLLMUsage returns the usage Gemini reports, or an estimate if it reports none.
+func toLLMUsage(req *gemini.Request, res *gemini.Response) llm.Usage {
+	if res == nil || res.UsageMetadata == nil {
+		return calculateUsage(req, res)
+	}
+	um := res.UsageMetadata
+	cached := min(um.CachedContentTokenCount, um.PromptTokenCount)
+	return llm.Usage{
+		InputTokens:          uint64(um.PromptTokenCount - cached),
+		CacheReadInputTokens: uint64(cached),
+		OutputTokens:         uint64(um.CandidatesTokenCount + um.ThoughtsTokenCount),
+		ReasoningTokens:      uint64(um.ThoughtsTokenCount),
+	}
+}
+
 func calculateUsage(req *gemini.Request, res *gemini.Response) llm.Usage {
 	// Very rough estimation of token counts
 	var inputTokens uint64
@@ -587,8 +705,8 @@ func (s *Service) Do(ctx context.Context, ir *llm.Request) (*llm.Response, error
 
 	ensureToolIDs(content)
 
-	usage := calculateUsage(gemReq, gemRes)
-	usage.CostUSD = llm.CostUSDFromResponse(gemRes.Header())
+	usage := toLLMUsage(gemReq, gemRes)
+	usage.CostUSD = llm.CostUSD(gemRes.Header(), s.Pricing, usage)
 
 	stopReason := llm.StopReasonEndTurn
 	for _, part := range content {
@@ -726,6 +726,36 @@ func TestCalculateUsageWithEmptyText(t *testing.T) {
 	}
 }
 
+func TestToLLMUsageFromUsageMetadata(t *testing.T) {
+	res := &gemini.Response{
+		UsageMetadata: &gemini.UsageMetadata{
+			PromptTokenCount:        1000,
+			CachedContentTokenCount: 600,
+			CandidatesTokenCount:    200,
+			ThoughtsTokenCount:      50,
+			TotalTokenCount:         1250,
+		},
+	}
+
+	usage := toLLMUsage(&gemini.Request{}, res)
+	want := llm.Usage{
+		InputTokens:          400,
+		CacheReadInputTokens: 600,
+		OutputTokens:         250,
+		ReasoningTokens:      50,
+	}
+	if usage != want {
+		t.Errorf("toLLMUsage() = %+v, want %+v", usage, want)
+	}
+
+	// Without usage metadata, usage is estimated.
+	res.UsageMetadata = nil
+	req := &gemini.Request{Contents: []gemini.Content{{Parts: []gemini.Part{{Text: "12345678"}}}}}
+	if usage := toLLMUsage(req, res); usage.InputTokens != 2 {
+		t.Errorf("estimated InputTokens = %d, want 2", usage.InputTokens)
+	}
+}
+
 func TestCalculateUsageWithComplexFunctionCall(t *testing.T) {
 	// Test with complex function call arguments
 	req := &gemini.Request{
@@ -781,3 +811,140 @@ func TestCalculateUsageWithComplexFunctionCall(t *testing.T) {
 		t.Errorf("Expected output tokens with complex function call to be greater than 0, got %d", usage.OutputTokens)
 	}
 }
+
+func TestBuildGeminiRequestResponseSchema(t *testing.T) {
+	service := &Service{Model: DefaultModel, APIKey: "test-api-key"}
+	req := &llm.Request{
+		Messages: []llm.Message{llm.UserStringMessage("question")},
+		ResponseSchema: &llm.ResponseSchema{
+			Schema: llm.MustSchema(`{"type":"object","properties":{"answer":{"type":"string"}}}`),
+		},
+	}
+	gemReq, err := service.buildGeminiRequest(req)
+	if err != nil {
+		t.Fatalf("Failed to build Gemini request: %v", err)
+	}
+	if gemReq.GenerationConfig == nil {
+		t.Fatal("Expected generation config, got nil")
+	}
+	if gemReq.GenerationConfig.ResponseMimeType != "application/json" {
+		t.Errorf("ResponseMimeType = %q, want application/json", gemReq.GenerationConfig.ResponseMimeType)
+	}
+	if gemReq.GenerationConfig.ResponseSchema == nil || gemReq.GenerationConfig.ResponseSchema.Type != gemini.DataTypeOBJECT {
+		t.Errorf("ResponseSchema = %+v, want OBJECT schema", gemReq.GenerationConfig.ResponseSchema)
+	}
+}
+
+func TestBuildGeminiRequestInlineData(t *testing.T) {
+	service := &Service{Model: DefaultModel, APIKey: "test-api-key"}
+	pdf := llm.DocumentContent(llm.MediaTypePDF, "spec.pdf", []byte("%PDF-1.4"))
+	req := &llm.Request{
+		Messages: []llm.Message{
+			{Role: llm.MessageRoleUser, Content: []llm.Content{llm.StringContent("Summarize this"), pdf}},
+			{Role: llm.MessageRoleAssistant, Content: []llm.Content{
+				{Type: llm.ContentTypeToolUse, ID: "t1", ToolName: "read_document", ToolInput: json.RawMessage(`{"path":"spec.pdf"}`)},
+			}},
+			{Role: llm.MessageRoleUser, Content: []llm.Content{
+				{Type: llm.ContentTypeToolResult, ToolUseID: "t1", ToolResult: []llm.Content{llm.StringContent("Document"), pdf}},
+			}},
+		},
+	}
+	gemReq, err := service.buildGeminiRequest(req)
+	if err != nil {
+		t.Fatalf("Failed to build Gemini request: %v", err)
+	}
+
+	parts := gemReq.Contents[0].Parts
+	if len(parts) != 2 || parts[1].InlineData == nil {
+		t.Fatalf("Expected text and inline data parts, got %+v", parts)
+	}
+	if parts[1].InlineData.MimeType != llm.MediaTypePDF || parts[1].InlineData.Data != pdf.Data {
+		t.Errorf("InlineData = %+v, want base64 PDF", parts[1].InlineData)
+	}
+
+	parts = gemReq.Contents[2].Parts
+	if len(parts) != 2 || parts[0].FunctionResponse == nil || parts[1].InlineData == nil {
+		t.Fatalf("Expected function response followed by inline data, got %+v", parts)
+	}
+	if parts[0].FunctionResponse.Response["result"] != "Document" {
+		t.Errorf("result = %v, want text only", parts[0].FunctionResponse.Response["result"])
+	}
+}
+
+func TestBuildGeminiRequestServerTools(t *testing.T) {
+	service := &Service{Model: DefaultModel, APIKey: "test-api-key"}
+	req := &llm.Request{
+		Messages: []llm.Message{
+			llm.UserStringMessage("question"),
+			{Role: llm.MessageRoleAssistant, Content: []llm.Content{
+				{ID: "c1", Type: llm.ContentTypeServerToolUse, ToolName: llm.ToolTypeCodeExecution},
+				llm.ServerToolResultContent("c1", llm.ToolTypeCodeExecution, llm.ServerToolResult{Type: llm.ToolTypeCodeExecution, Output: "2"}),
+				llm.StringContent("It is 2."),
+			}},
+		},
+		Tools: []*llm.Tool{
+			{Name: "bash", Description: "run bash", InputSchema: llm.MustSchema(`{"type":"object","properties":{"command":{"type":"string"}}}`)},
+			llm.ServerTool(llm.ToolTypeWebSearch),
+			llm.ServerTool(llm.ToolTypeCodeExecution),
+			llm.ServerTool(llm.ToolTypeURLContext),
+		},
+	}
+	gemReq, err := service.buildGeminiRequest(req)
+	if err != nil {
+		t.Fatalf("Failed to build Gemini request: %v", err)
+	}
+
+	data, err := json.Marshal(gemReq.Tools[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"googleSearch":{}},{"codeExecution":{}},{"urlContext":{}}]`; string(data) != want {
+		t.Errorf("server tools = %s, want %s", data, want)
+	}
+	if decls := gemReq.Tools[0].FunctionDeclarations; len(decls) != 1 || decls[0].Name != "bash" {
+		t.Errorf("function declarations = %+v, want only bash", decls)
+	}
+	if parts := gemReq.Contents[1].Parts; len(parts) != 1 || parts[0].Text != "It is 2." {
+		t.Errorf("assistant parts = %+v, want server tool content dropped", parts)
+	}
+}
+
+func TestConvertResponseWithServerTools(t *testing.T) {
+	const body = `{"candidates":[{
+		"content":{"role":"model","parts":[
+			{"executableCode":{"language":"PYTHON","code":"print(1+1)"}},
+			{"codeExecutionResult":{"outcome":"OUTCOME_OK","output":"2\n"}},
+			{"text":"It is 2."}
+		]},
+		"groundingMetadata":{
+			"webSearchQueries":["one plus one"],
+			"groundingChunks":[{"web":{"uri":"https://example.com/math","title":"example.com"}}]
+		}
+	}]}`
+	var gemRes gemini.Response
+	if err := json.Unmarshal([]byte(body), &gemRes); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	content := convertGeminiResponseToContent(&gemRes)
+	if len(content) != 5 {
+		t.Fatalf("Expected 5 content items, got %d: %+v", len(content), content)
+	}
+
+	search := content[1].Display.(llm.ServerToolResult)
+	if content[0].Type != llm.ContentTypeServerToolUse || search.Query != "one plus one" ||
+		len(search.Sources) != 1 || search.Sources[0].URL != "https://example.com/math" {
+		t.Errorf("grounding = %+v, %+v", content[0], search)
+	}
+
+	if content[2].Type != llm.ContentTypeServerToolUse || content[2].ToolName != llm.ToolTypeCodeExecution {
+		t.Errorf("content[2] = %+v, want code execution use", content[2])
+	}
+	exec := content[3].Display.(llm.ServerToolResult)
+	if content[3].ToolUseID != content[2].ID || exec.Code != "print(1+1)" || exec.Output != "2\n" || content[3].ToolError {
+		t.Errorf("code execution result = %+v, %+v", content[3], exec)
+	}
+	if content[4].Text != "It is 2." {
+		t.Errorf("content[4].Text = %q, want %q", content[4].Text, "It is 2.")
+	}
+}
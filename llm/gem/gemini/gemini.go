@@ -24,8 +24,18 @@ type Request struct {
 
 // https://ai.google.dev/api/generate-content#response-body
 type Response struct {
-	Candidates []Candidate `json:"candidates"`
-	headers    http.Header // captured HTTP response headers
+	Candidates    []Candidate    `json:"candidates"`
+	UsageMetadata *UsageMetadata `json:"usageMetadata,omitempty"`
+	headers       http.Header    // captured HTTP response headers
+}
+
+// https://ai.google.dev/api/generate-content#UsageMetadata
+type UsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"` // includes cached content
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount,omitempty"` // not included in CandidatesTokenCount
+	TotalTokenCount         int `json:"totalTokenCount"`
 }
 
 // Header returns the HTTP response headers.
@@ -34,7 +44,24 @@ func (r *Response) Header() http.Header {
 }
 
 type Candidate struct {
-	Content Content `json:"content"`
+	Content           Content            `json:"content"`
+	GroundingMetadata *GroundingMetadata `json:"groundingMetadata,omitempty"`
+}
+
+// GroundingMetadata describes the sources of a response grounded with Google Search or URL context.
+// https://ai.google.dev/api/generate-content#GroundingMetadata
+type GroundingMetadata struct {
+	WebSearchQueries []string         `json:"webSearchQueries,omitempty"`
+	GroundingChunks  []GroundingChunk `json:"groundingChunks,omitempty"`
+}
+
+type GroundingChunk struct {
+	Web *GroundingChunkWeb `json:"web,omitempty"`
+}
+
+type GroundingChunkWeb struct {
+	URI   string `json:"uri"`
+	Title string `json:"title,omitempty"`
 }
 
 type Content struct {
@@ -53,10 +80,16 @@ type Part struct {
 	// ThoughtSignature is required for Gemini 3 models when using function calling.
 	// It must be passed back exactly as received when sending the conversation history.
 	ThoughtSignature string `json:"thoughtSignature,omitempty"`
-	// TODO inlineData
+	InlineData       *Blob  `json:"inlineData,omitempty"`
 	// TODO fileData
 }
 
+// https://ai.google.dev/api/caching#Blob
+type Blob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"` // base64-encoded
+}
+
 type FunctionCall struct {
 	Name string         `json:"name"`
 	Args map[string]any `json:"args"`
@@ -72,11 +105,12 @@ type ExecutableCode struct {
 	Code     string   `json:"code"`
 }
 
-type Language int
+// Language is a programming language, encoded as its enum name in JSON.
+type Language string
 
 const (
-	LanguageUnspecified Language = 0
-	LanguagePython      Language = 1 // python >= 3.10 with numpy and simpy
+	LanguageUnspecified Language = "LANGUAGE_UNSPECIFIED"
+	LanguagePython      Language = "PYTHON" // python >= 3.10 with numpy and simpy
 )
 
 type CodeExecutionResult struct {
@@ -84,13 +118,14 @@ type CodeExecutionResult struct {
 	Output  string  `json:"output"`
 }
 
-type Outcome int
+// Outcome is the outcome of code execution, encoded as its enum name in JSON.
+type Outcome string
 
 const (
-	OutcomeUnspecified      Outcome = 0
-	OutcomeOK               Outcome = 1
-	OutcomeFailed           Outcome = 2
-	OutcomeDeadlineExceeded Outcome = 3
+	OutcomeUnspecified      Outcome = "OUTCOME_UNSPECIFIED"
+	OutcomeOK               Outcome = "OUTCOME_OK"
+	OutcomeFailed           Outcome = "OUTCOME_FAILED"
+	OutcomeDeadlineExceeded Outcome = "OUTCOME_DEADLINE_EXCEEDED"
 )
 
 // https://ai.google.dev/api/generate-content#v1beta.GenerationConfig
@@ -100,10 +135,12 @@ type GenerationConfig struct {
 }
 
 // https://ai.google.dev/api/caching#Tool
+// Tool is a union: set exactly one field per Tool.
 type Tool struct {
-	FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations"`
+	FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations,omitempty"`
 	CodeExecution        *struct{}             `json:"codeExecution,omitempty"` // if present, enables the model to execute code
-	// TODO googleSearchRetrieval https://ai.google.dev/api/caching#GoogleSearchRetrieval
+	GoogleSearch         *struct{}             `json:"googleSearch,omitempty"`  // if present, enables grounding with Google Search
+	URLContext           *struct{}             `json:"urlContext,omitempty"`    // if present, enables the model to fetch URLs from the prompt
 }
 
 // https://ai.google.dev/api/caching#FunctionDeclaration
@@ -57,17 +57,26 @@ func UseSimplifiedPatch(svc Service) bool {
 func MustSchema(schema string) json.RawMessage {
 	schema = strings.TrimSpace(schema)
 	bytes := []byte(schema)
+	if err := CheckSchema(bytes); err != nil {
+		panic(err.Error() + ": " + schema)
+	}
+	return json.RawMessage(bytes)
+}
+
+// CheckSchema reports whether schema is acceptable as a tool input or response schema.
+// The schema must have at least type="object" and a properties key.
+func CheckSchema(schema json.RawMessage) error {
 	var obj map[string]any
-	if err := json.Unmarshal(bytes, &obj); err != nil {
-		panic("failed to parse JSON schema: " + schema + ": " + err.Error())
+	if err := json.Unmarshal(schema, &obj); err != nil {
+		return fmt.Errorf("failed to parse JSON schema: %w", err)
 	}
 	if typ, ok := obj["type"]; !ok || typ != "object" {
-		panic("JSON schema must have type='object': " + schema)
+		return fmt.Errorf("JSON schema must have type='object'")
 	}
 	if _, ok := obj["properties"]; !ok {
-		panic("JSON schema must have 'properties' key: " + schema)
+		return fmt.Errorf("JSON schema must have 'properties' key")
 	}
-	return json.RawMessage(bytes)
+	return nil
 }
 
 func EmptySchema() json.RawMessage {
@@ -88,6 +97,15 @@ type Request struct {
 	ToolChoice *ToolChoice
 	Tools      []*Tool
 	System     []SystemContent
+
+	// ResponseSchema, if set, asks the model to reply with a single JSON value
+	// conforming to the schema. Each provider maps it to its native mechanism.
+	// Use DoJSON to send the request and decode the result.
+	ResponseSchema *ResponseSchema
+
+	// DisableParallelToolCalls asks the model to make at most one tool call per response.
+	// Providers without such a setting ignore it.
+	DisableParallelToolCalls bool
 }
 
 // Message represents a message in the conversation.
@@ -128,6 +146,7 @@ type Tool struct {
 	Name string
 	// Type is used by the text editor tool; see
 	// https://docs.anthropic.com/en/docs/build-with-claude/tool-use/text-editor-tool
+	// It is also the discriminator for server tools (see ServerTool).
 	Type        string
 	Description string
 	InputSchema json.RawMessage
@@ -165,8 +184,11 @@ type Content struct {
 	Type ContentType
 	Text string
 
-	// Media type for image content
+	// Media type for image and document content.
+	// The base64-encoded image or document is in Data.
 	MediaType string
+	// Title is an optional file name or title for document content.
+	Title string
 
 	// for thinking
 	Thinking        string
@@ -190,6 +212,14 @@ type Content struct {
 	// Display is content to be displayed to the user, copied from ToolOut
 	Display any
 
+	// Redactions names the kind of each secret redacted from a tool_result;
+	// added externally; not sent to the LLM
+	Redactions []string `json:",omitempty"`
+
+	// Raw is server tool content in the encoding of the provider that produced it.
+	// That provider is sent it back unchanged, so that a paused turn can resume.
+	Raw json.RawMessage `json:",omitempty"`
+
 	Cache bool
 }
 
@@ -253,6 +283,16 @@ const (
 	StopReasonEndTurn
 	StopReasonToolUse
 	StopReasonRefusal
+	// StopReasonPauseTurn means the provider paused a long turn, e.g. one
+	// running server tools. Sending the response back continues it.
+	StopReasonPauseTurn
+)
+
+// Content types for provider-executed server tools.
+// They are declared separately so that the persisted values above do not change.
+const (
+	ContentTypeServerToolUse ContentType = ContentTypeToolResult + 1 + iota
+	ContentTypeServerToolResult
 )
 
 // ThinkingLevel controls how much thinking/reasoning the model does.
@@ -314,7 +354,7 @@ func (m *Response) ToMessage() Message {
 	return Message{
 		Role:      m.Role,
 		Content:   m.Content,
-		EndOfTurn: m.StopReason != StopReasonToolUse, // End of turn unless there are tools to call
+		EndOfTurn: m.StopReason != StopReasonToolUse && m.StopReason != StopReasonPauseTurn, // End of turn unless there are tools to call or the turn continues
 	}
 }
 
@@ -32,11 +32,13 @@ func _() {
 	_ = x[ContentTypeRedactedThinking-4]
 	_ = x[ContentTypeToolUse-5]
 	_ = x[ContentTypeToolResult-6]
+	_ = x[ContentTypeServerToolUse-7]
+	_ = x[ContentTypeServerToolResult-8]
 }
 
-const _ContentType_name = "ContentTypeTextContentTypeThinkingContentTypeRedactedThinkingContentTypeToolUseContentTypeToolResult"
+const _ContentType_name = "ContentTypeTextContentTypeThinkingContentTypeRedactedThinkingContentTypeToolUseContentTypeToolResultContentTypeServerToolUseContentTypeServerToolResult"
 
-var _ContentType_index = [...]uint8{0, 15, 34, 61, 79, 100}
+var _ContentType_index = [...]uint8{0, 15, 34, 61, 79, 100, 124, 151}
 
 func (i ContentType) String() string {
 	idx := int(i) - 2
@@ -75,11 +77,12 @@ func _() {
 	_ = x[StopReasonEndTurn-13]
 	_ = x[StopReasonToolUse-14]
 	_ = x[StopReasonRefusal-15]
+	_ = x[StopReasonPauseTurn-16]
 }
 
-const _StopReason_name = "StopReasonStopSequenceStopReasonMaxTokensStopReasonEndTurnStopReasonToolUseStopReasonRefusal"
+const _StopReason_name = "StopReasonStopSequenceStopReasonMaxTokensStopReasonEndTurnStopReasonToolUseStopReasonRefusalStopReasonPauseTurn"
 
-var _StopReason_index = [...]uint8{0, 22, 41, 58, 75, 92}
+var _StopReason_index = [...]uint8{0, 22, 41, 58, 75, 92, 111}
 
 func (i StopReason) String() string {
 	idx := int(i) - 11
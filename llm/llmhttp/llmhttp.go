@@ -5,11 +5,13 @@ package llmhttp
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
 	"time"
 
+	"shelley.exe.dev/redact"
 	"shelley.exe.dev/version"
 )
 
@@ -64,6 +66,29 @@ func ProviderFromContext(ctx context.Context) string {
 // Recorder is called after each LLM HTTP request with the request/response details.
 type Recorder func(ctx context.Context, url string, requestBody, responseBody []byte, statusCode int, err error, duration time.Duration)
 
+// Redacting returns a Recorder that removes secrets from the request and
+// response bodies and the error before passing them to rec. The request
+// itself is sent unchanged.
+func Redacting(rec Recorder, r *redact.Redactor) Recorder {
+	if rec == nil || r == nil {
+		return rec
+	}
+	return func(ctx context.Context, url string, requestBody, responseBody []byte, statusCode int, err error, duration time.Duration) {
+		if s, kinds := r.Redact(string(requestBody)); len(kinds) > 0 {
+			requestBody = []byte(s)
+		}
+		if s, kinds := r.Redact(string(responseBody)); len(kinds) > 0 {
+			responseBody = []byte(s)
+		}
+		if err != nil {
+			if s, kinds := r.Redact(err.Error()); len(kinds) > 0 {
+				err = errors.New(s)
+			}
+		}
+		rec(ctx, url, requestBody, responseBody, statusCode, err, duration)
+	}
+}
+
 // Transport wraps an http.RoundTripper to add Shelley-specific headers
 // and optionally record requests to a database.
 type Transport struct {
@@ -356,6 +356,7 @@ type Service struct {
 	ModelURL  string       // optional, overrides Model.URL
 	MaxTokens int          // defaults to DefaultMaxTokens if zero
 	Org       string       // optional - organization ID
+	Pricing   *llm.Pricing // optional; prices usage when no gateway cost is reported
 }
 
 var _ llm.Service = (*Service)(nil)
@@ -455,7 +456,8 @@ var (
 func fromLLMContent(c llm.Content) (string, []openai.ToolCall) {
 	switch c.Type {
 	case llm.ContentTypeText:
-		return c.Text, nil
+		// Chat completions have no document input; send the extracted text instead.
+		return llm.DocumentFallback(c).Text, nil
 	case llm.ContentTypeToolUse:
 		// For OpenAI, tool use is sent as a null content with tool_calls in the message
 		return "", []openai.ToolCall{
@@ -477,6 +479,7 @@ func fromLLMContent(c llm.Content) (string, []openai.ToolCall) {
 			// Collect all text from content objects
 			texts := make([]string, 0, len(c.ToolResult))
 			for _, result := range c.ToolResult {
+				result = llm.DocumentFallback(result)
 				if result.Text != "" {
 					texts = append(texts, result.Text)
 				}
@@ -502,9 +505,12 @@ func fromLLMMessage(msg llm.Message) []openai.ChatCompletionMessage {
 	var toolResults []llm.Content
 
 	for _, c := range msg.Content {
-		if c.Type == llm.ContentTypeToolResult {
+		switch {
+		case c.IsServerToolContent():
+			// Informational only; chat completions have no server tools.
+		case c.Type == llm.ContentTypeToolResult:
 			toolResults = append(toolResults, c)
-		} else {
+		default:
 			regularContent = append(regularContent, c)
 		}
 	}
@@ -515,6 +521,7 @@ func fromLLMMessage(msg llm.Message) []openai.ChatCompletionMessage {
 		// Collect all text from content objects
 		var texts []string
 		for _, result := range tr.ToolResult {
+			result = llm.DocumentFallback(result)
 			if strings.TrimSpace(result.Text) != "" {
 				texts = append(texts, result.Text)
 			}
@@ -599,6 +606,21 @@ func fromLLMTool(t *llm.Tool) openai.Tool {
 	}
 }
 
+// fromLLMResponseSchema converts an llm.ResponseSchema to an OpenAI json_schema response format.
+func fromLLMResponseSchema(rs *llm.ResponseSchema) *openai.ChatCompletionResponseFormat {
+	if rs == nil {
+		return nil
+	}
+	return &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:        rs.SchemaName(),
+			Description: rs.Description,
+			Schema:      rs.Schema,
+		},
+	}
+}
+
 // fromLLMSystem converts llm.SystemContent to an OpenAI system message.
 func fromLLMSystem(systemContent []llm.SystemContent) []openai.ChatCompletionMessage {
 	if len(systemContent) == 0 {
@@ -711,7 +733,10 @@ func (s *Service) toLLMUsage(au openai.Usage, headers http.Header) llm.Usage {
 		CacheReadInputTokens: cached,
 		OutputTokens:         out,
 	}
-	u.CostUSD = llm.CostUSDFromResponse(headers)
+	if au.CompletionTokensDetails != nil {
+		u.ReasoningTokens = uint64(au.CompletionTokensDetails.ReasoningTokens)
+	}
+	u.CostUSD = llm.CostUSD(headers, s.Pricing, u)
 	return u
 }
 
@@ -814,6 +839,10 @@ func (s *Service) Do(ctx context.Context, ir *llm.Request) (*llm.Response, error
 	// Convert tools
 	var tools []openai.Tool
 	for _, t := range ir.Tools {
+		// Chat completions have no server tools.
+		if t.IsServerTool() {
+			continue
+		}
 		tools = append(tools, fromLLMTool(t))
 	}
 
@@ -824,6 +853,10 @@ func (s *Service) Do(ctx context.Context, ir *llm.Request) (*llm.Response, error
 		Tools:               tools,
 		ToolChoice:          fromLLMToolChoice(ir.ToolChoice), // TODO: make fromLLMToolChoice return an error when a perfect translation is not possible
 		MaxCompletionTokens: cmp.Or(s.MaxTokens, DefaultMaxTokens),
+		ResponseFormat:      fromLLMResponseSchema(ir.ResponseSchema),
+	}
+	if ir.DisableParallelToolCalls && len(tools) > 0 {
+		req.ParallelToolCalls = false
 	}
 	// Construct the full URL for logging and debugging
 	fullURL := baseURL + "/chat/completions"
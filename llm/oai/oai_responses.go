@@ -30,6 +30,7 @@ type ResponsesService struct {
 	Org           string            // optional - organization ID
 	DumpLLM       bool              // whether to dump request/response text to files for debugging; defaults to false
 	ThinkingLevel llm.ThinkingLevel // thinking level (ThinkingLevelOff disables reasoning)
+	Pricing       *llm.Pricing      // optional; prices usage when no gateway cost is reported
 }
 
 var _ llm.Service = (*ResponsesService)(nil)
@@ -37,15 +38,29 @@ var _ llm.Service = (*ResponsesService)(nil)
 // Responses API request/response types
 
 type responsesRequest struct {
-	Model           string               `json:"model"`
-	Input           []responsesInputItem `json:"input"`
-	Tools           []responsesTool      `json:"tools,omitempty"`
-	ToolChoice      any                  `json:"tool_choice,omitempty"`
-	MaxOutputTokens int                  `json:"max_output_tokens,omitempty"`
-	Reasoning       *responsesReasoning  `json:"reasoning,omitempty"`
-	Include         []string             `json:"include,omitempty"`
-	Stream          bool                 `json:"stream"`
-	Store           bool                 `json:"store"`
+	Model             string               `json:"model"`
+	Input             []responsesInputItem `json:"input"`
+	Tools             []responsesTool      `json:"tools,omitempty"`
+	ToolChoice        any                  `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool                `json:"parallel_tool_calls,omitempty"`
+	MaxOutputTokens   int                  `json:"max_output_tokens,omitempty"`
+	Reasoning         *responsesReasoning  `json:"reasoning,omitempty"`
+	Include           []string             `json:"include,omitempty"`
+	Text              *responsesText       `json:"text,omitempty"`
+	Stream            bool                 `json:"stream"`
+	Store             bool                 `json:"store"`
+}
+
+// responsesText configures the output text format, used for structured outputs.
+type responsesText struct {
+	Format responsesTextFormat `json:"format"`
+}
+
+type responsesTextFormat struct {
+	Type        string          `json:"type"` // "text", "json_object", "json_schema"
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
 }
 
 type responsesReasoning struct {
@@ -64,15 +79,22 @@ type responsesInputItem struct {
 }
 
 type responsesContent struct {
-	Type string `json:"type"` // "input_text", "output_text"
-	Text string `json:"text"`
+	Type     string `json:"type"` // "input_text", "output_text", "input_file"
+	Text     string `json:"text,omitempty"`
+	Filename string `json:"filename,omitempty"`  // for input_file
+	FileData string `json:"file_data,omitempty"` // for input_file, as a data URL
 }
 
 type responsesTool struct {
-	Type        string          `json:"type"` // "function"
-	Name        string          `json:"name"`
-	Description string          `json:"description,omitempty"`
-	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Type        string              `json:"type"` // "function", or a built-in tool such as "web_search"
+	Name        string              `json:"name,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Parameters  json.RawMessage     `json:"parameters,omitempty"`
+	Container   *responsesContainer `json:"container,omitempty"` // for code_interpreter
+}
+
+type responsesContainer struct {
+	Type string `json:"type"` // "auto"
 }
 
 type responsesResponse struct {
@@ -97,6 +119,24 @@ type responsesOutputItem struct {
 	Arguments        string             `json:"arguments,omitempty"` // for function_call
 	Summary          reasoningSummaries `json:"summary,omitempty"`   // for reasoning
 	ReasoningContent []string           `json:"-"`                   // populated by SSE parser
+
+	Action  *responsesSearchAction `json:"action,omitempty"`  // for web_search_call
+	Code    string                 `json:"code,omitempty"`    // for code_interpreter_call
+	Outputs []responsesCodeOutput  `json:"outputs,omitempty"` // for code_interpreter_call
+}
+
+type responsesSearchAction struct {
+	Type    string `json:"type"` // "search", "open_page", "find"
+	Query   string `json:"query,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Sources []struct {
+		URL string `json:"url"`
+	} `json:"sources,omitempty"`
+}
+
+type responsesCodeOutput struct {
+	Type string `json:"type"` // "logs", "image"
+	Logs string `json:"logs,omitempty"`
 }
 
 // reasoningSummaries handles both plain ["text"] and tagged [{"type":"summary_text","text":"..."}] formats.
@@ -164,10 +204,18 @@ func fromLLMMessageResponses(msg llm.Message) []responsesInputItem {
 	}
 
 	// Process tool results first - they need to come before the assistant message
+	var attachments []responsesContent
 	for _, tr := range toolResults {
 		// Collect all text from content objects
 		var texts []string
 		for _, result := range tr.ToolResult {
+			// Function call outputs are text only, so PDFs are attached in a following user message.
+			if result.MediaType == llm.MediaTypePDF {
+				attachments = append(attachments, fromLLMDocumentResponses(result))
+				texts = append(texts, fmt.Sprintf("[document %s attached below]", cmp.Or(result.Title, result.MediaType)))
+				continue
+			}
+			result = llm.DocumentFallback(result)
 			if strings.TrimSpace(result.Text) != "" {
 				texts = append(texts, result.Text)
 			}
@@ -189,6 +237,13 @@ func fromLLMMessageResponses(msg llm.Message) []responsesInputItem {
 			Output: cmp.Or(toolResultContent, " "),
 		})
 	}
+	if len(attachments) > 0 {
+		items = append(items, responsesInputItem{
+			Type:    "message",
+			Role:    "user",
+			Content: attachments,
+		})
+	}
 
 	// Process regular content
 	if len(regularContent) > 0 {
@@ -198,6 +253,10 @@ func fromLLMMessageResponses(msg llm.Message) []responsesInputItem {
 		for _, c := range regularContent {
 			switch c.Type {
 			case llm.ContentTypeText:
+				if c.IsDocument() {
+					messageContent = append(messageContent, fromLLMDocumentResponses(c))
+					continue
+				}
 				if c.Text != "" {
 					contentType := "input_text"
 					if msg.Role == llm.MessageRoleAssistant {
@@ -239,6 +298,19 @@ func fromLLMMessageResponses(msg llm.Message) []responsesInputItem {
 	return items
 }
 
+// fromLLMDocumentResponses converts document content to a Responses API input part.
+// PDFs are sent inline as input_file; other documents are sent as their text.
+func fromLLMDocumentResponses(c llm.Content) responsesContent {
+	if c.MediaType == llm.MediaTypePDF {
+		return responsesContent{
+			Type:     "input_file",
+			Filename: cmp.Or(c.Title, "document.pdf"),
+			FileData: "data:" + c.MediaType + ";base64," + c.Data,
+		}
+	}
+	return responsesContent{Type: "input_text", Text: llm.DocumentFallback(c).Text}
+}
+
 // fromLLMToolResponses converts llm.Tool to Responses API tool format
 func fromLLMToolResponses(t *llm.Tool) responsesTool {
 	return responsesTool{
@@ -249,6 +321,67 @@ func fromLLMToolResponses(t *llm.Tool) responsesTool {
 	}
 }
 
+// fromLLMServerToolResponses converts a server tool to a Responses API built-in tool.
+// URL context has no equivalent and is omitted.
+func fromLLMServerToolResponses(t *llm.Tool) (responsesTool, bool) {
+	switch t.Type {
+	case llm.ToolTypeWebSearch:
+		return responsesTool{Type: "web_search"}, true
+	case llm.ToolTypeCodeExecution:
+		return responsesTool{Type: "code_interpreter", Container: &responsesContainer{Type: "auto"}}, true
+	}
+	return responsesTool{}, false
+}
+
+// toLLMServerToolResponses converts a built-in tool call output item to server tool content.
+func toLLMServerToolResponses(item responsesOutputItem) []llm.Content {
+	r := llm.ServerToolResult{}
+	var input any
+	switch item.Type {
+	case "web_search_call":
+		r.Type = llm.ToolTypeWebSearch
+		if a := item.Action; a != nil {
+			r.Query = cmp.Or(a.Query, a.URL)
+			for _, src := range a.Sources {
+				r.Sources = append(r.Sources, llm.ServerToolSource{URL: src.URL})
+			}
+			input = a
+		}
+	case "code_interpreter_call":
+		r.Type = llm.ToolTypeCodeExecution
+		r.Code = item.Code
+		var logs []string
+		for _, o := range item.Outputs {
+			if o.Logs != "" {
+				logs = append(logs, o.Logs)
+			}
+		}
+		r.Output = strings.Join(logs, "\n")
+		input = map[string]string{"code": item.Code}
+	}
+	if item.Status == "failed" {
+		r.Error = "call failed"
+	}
+	inputJSON, _ := json.Marshal(input)
+	return []llm.Content{
+		{ID: item.ID, Type: llm.ContentTypeServerToolUse, ToolName: r.Type, ToolInput: inputJSON},
+		llm.ServerToolResultContent(item.ID, r.Type, r),
+	}
+}
+
+// fromLLMResponseSchemaResponses converts an llm.ResponseSchema to a Responses API text format.
+func fromLLMResponseSchemaResponses(rs *llm.ResponseSchema) *responsesText {
+	if rs == nil {
+		return nil
+	}
+	return &responsesText{Format: responsesTextFormat{
+		Type:        "json_schema",
+		Name:        rs.SchemaName(),
+		Description: rs.Description,
+		Schema:      rs.Schema,
+	}}
+}
+
 // fromLLMSystemResponses converts llm.SystemContent to Responses API input items
 func fromLLMSystemResponses(systemContent []llm.SystemContent) []responsesInputItem {
 	if len(systemContent) == 0 {
@@ -333,6 +466,8 @@ func (s *ResponsesService) toLLMResponseFromResponses(resp *responsesResponse, h
 				ToolInput: json.RawMessage(item.Arguments),
 			})
 			stopReason = llm.StopReasonToolUse
+		case "web_search_call", "code_interpreter_call":
+			contents = append(contents, toLLMServerToolResponses(item)...)
 		}
 	}
 
@@ -379,7 +514,7 @@ func (s *ResponsesService) toLLMUsageFromResponses(usage responsesUsage, headers
 		OutputTokens:         out,
 		ReasoningTokens:      reasoning,
 	}
-	u.CostUSD = llm.CostUSDFromResponse(headers)
+	u.CostUSD = llm.CostUSD(headers, s.Pricing, u)
 	return u
 }
 
@@ -409,25 +544,45 @@ func (s *ResponsesService) buildRequest(ir *llm.Request, model Model) responsesR
 		allInput = append(allInput, fromLLMMessageResponses(msg)...)
 	}
 	var tools []responsesTool
+	var include []string
 	for _, t := range ir.Tools {
-		tools = append(tools, fromLLMToolResponses(t))
+		if !t.IsServerTool() {
+			tools = append(tools, fromLLMToolResponses(t))
+			continue
+		}
+		st, ok := fromLLMServerToolResponses(t)
+		if !ok {
+			continue
+		}
+		tools = append(tools, st)
+		switch st.Type {
+		case "web_search":
+			include = append(include, "web_search_call.action.sources")
+		case "code_interpreter":
+			include = append(include, "code_interpreter_call.outputs")
+		}
 	}
 	req := responsesRequest{
 		Model:           model.ModelName,
 		Input:           allInput,
 		Tools:           tools,
 		MaxOutputTokens: cmp.Or(s.MaxTokens, DefaultMaxTokens),
+		Text:            fromLLMResponseSchemaResponses(ir.ResponseSchema),
+		Include:         include,
 	}
 	if s.ThinkingLevel != llm.ThinkingLevelOff {
 		effort := s.ThinkingLevel.ThinkingEffort()
 		if effort != "" {
 			req.Reasoning = &responsesReasoning{Effort: effort, Summary: "detailed"}
-			req.Include = []string{"reasoning.encrypted_content"}
+			req.Include = append(req.Include, "reasoning.encrypted_content")
 		}
 	}
 	if ir.ToolChoice != nil {
 		req.ToolChoice = fromLLMToolChoice(ir.ToolChoice)
 	}
+	if ir.DisableParallelToolCalls && len(tools) > 0 {
+		req.ParallelToolCalls = new(bool)
+	}
 	return req
 }
 
@@ -724,6 +879,7 @@ func parseSSEStream(body io.Reader, onText func(string), onThinking func(string)
 	var textContent strings.Builder
 	pendingCalls := make(map[string]*responsesOutputItem)
 	pendingReasoning := make(map[string]*responsesOutputItem)
+	var serverCalls []responsesOutputItem
 	var activeReasoningID string
 	var reasoningSummary, reasoningContent strings.Builder
 
@@ -758,6 +914,11 @@ func parseSSEStream(body io.Reader, onText func(string), onThinking func(string)
 				continue
 			}
 			switch item.Type {
+			case "web_search_call", "code_interpreter_call":
+				var oi responsesOutputItem
+				if event.Type == "response.output_item.done" && json.Unmarshal(event.Item, &oi) == nil {
+					serverCalls = append(serverCalls, oi)
+				}
 			case "function_call":
 				if item.CallID != "" {
 					pendingCalls[item.CallID] = &responsesOutputItem{
@@ -862,6 +1023,8 @@ func parseSSEStream(body io.Reader, onText func(string), onThinking func(string)
 	flushReasoningDeltas(activeReasoningID, pendingReasoning, &reasoningSummary, &reasoningContent)
 
 	if len(response.Output) == 0 {
+		// Built-in tool calls precede the text that draws on them.
+		response.Output = append(response.Output, serverCalls...)
 		if textContent.Len() > 0 {
 			response.Output = append(response.Output, responsesOutputItem{
 				Type: "message", Role: "assistant",
@@ -727,8 +727,8 @@ func TestResponsesServiceDoWithReasoningTokens(t *testing.T) {
 				{Type: "message", Role: "assistant", Content: []responsesContent{{Type: "output_text", Text: "Answer."}}},
 			},
 			Usage: responsesUsage{
-				InputTokens:  50,
-				OutputTokens: 30,
+				InputTokens:         50,
+				OutputTokens:        30,
 				OutputTokensDetails: &responsesOutputTokensDetails{ReasoningTokens: 15},
 			},
 		}
@@ -779,3 +779,140 @@ func TestResponsesServiceDoWithReasoningTokens(t *testing.T) {
 		t.Error("expected text content")
 	}
 }
+
+func TestBuildRequestResponseSchema(t *testing.T) {
+	svc := &ResponsesService{Model: GPT5}
+	schema := llm.MustSchema(`{"type":"object","properties":{"answer":{"type":"string"}}}`)
+	req := svc.buildRequest(&llm.Request{
+		Messages:       []llm.Message{llm.UserStringMessage("question")},
+		ResponseSchema: &llm.ResponseSchema{Schema: schema},
+	}, svc.Model)
+
+	if req.Text == nil {
+		t.Fatal("expected text format to be set")
+	}
+	if req.Text.Format.Type != "json_schema" {
+		t.Errorf("Format.Type = %q, want json_schema", req.Text.Format.Type)
+	}
+	if req.Text.Format.Name != llm.DefaultResponseSchemaName {
+		t.Errorf("Format.Name = %q, want %q", req.Text.Format.Name, llm.DefaultResponseSchemaName)
+	}
+	if string(req.Text.Format.Schema) != string(schema) {
+		t.Errorf("Format.Schema = %s, want %s", req.Text.Format.Schema, schema)
+	}
+}
+
+func TestFromLLMMessageResponsesDocument(t *testing.T) {
+	pdf := llm.DocumentContent(llm.MediaTypePDF, "spec.pdf", []byte("%PDF-1.4"))
+
+	items := fromLLMMessageResponses(llm.Message{
+		Role:    llm.MessageRoleUser,
+		Content: []llm.Content{llm.StringContent("Summarize this"), pdf},
+	})
+	if len(items) != 1 || len(items[0].Content) != 2 {
+		t.Fatalf("items = %+v, want one message with two parts", items)
+	}
+	file := items[0].Content[1]
+	if file.Type != "input_file" || file.Filename != "spec.pdf" || file.FileData != "data:application/pdf;base64,"+pdf.Data {
+		t.Errorf("file part = %+v, want inline input_file", file)
+	}
+
+	// Function call outputs are text only, so the PDF follows in a user message.
+	items = fromLLMMessageResponses(llm.Message{
+		Role: llm.MessageRoleUser,
+		Content: []llm.Content{{
+			Type:       llm.ContentTypeToolResult,
+			ToolUseID:  "call_1",
+			ToolResult: []llm.Content{pdf},
+		}},
+	})
+	if len(items) != 2 {
+		t.Fatalf("items = %+v, want function_call_output and attachment message", items)
+	}
+	if items[0].Type != "function_call_output" || !strings.Contains(items[0].Output, "spec.pdf attached below") {
+		t.Errorf("output item = %+v, want note about attachment", items[0])
+	}
+	if items[1].Role != "user" || len(items[1].Content) != 1 || items[1].Content[0].Type != "input_file" {
+		t.Errorf("attachment item = %+v, want user message with input_file", items[1])
+	}
+}
+
+func TestBuildRequestServerTools(t *testing.T) {
+	svc := &ResponsesService{Model: GPT5}
+	req := svc.buildRequest(&llm.Request{
+		Messages: []llm.Message{llm.UserStringMessage("question")},
+		Tools: []*llm.Tool{
+			llm.ServerTool(llm.ToolTypeWebSearch),
+			llm.ServerTool(llm.ToolTypeCodeExecution),
+			llm.ServerTool(llm.ToolTypeURLContext),
+		},
+	}, svc.Model)
+
+	data, err := json.Marshal(req.Tools)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"type":"web_search"},{"type":"code_interpreter","container":{"type":"auto"}}]`
+	if string(data) != want {
+		t.Errorf("tools = %s, want %s", data, want)
+	}
+	wantInclude := []string{"web_search_call.action.sources", "code_interpreter_call.outputs"}
+	if strings.Join(req.Include, ",") != strings.Join(wantInclude, ",") {
+		t.Errorf("include = %v, want %v", req.Include, wantInclude)
+	}
+}
+
+func TestBuildRequestDisableParallelToolCalls(t *testing.T) {
+	svc := &ResponsesService{Model: GPT5}
+	bash := &llm.Tool{Name: "bash", Description: "run bash", InputSchema: llm.MustSchema(`{"type":"object","properties":{}}`)}
+
+	req := svc.buildRequest(&llm.Request{Tools: []*llm.Tool{bash}}, svc.Model)
+	if req.ParallelToolCalls != nil {
+		t.Errorf("parallel_tool_calls = %v, want unset", *req.ParallelToolCalls)
+	}
+
+	req = svc.buildRequest(&llm.Request{Tools: []*llm.Tool{bash}, DisableParallelToolCalls: true}, svc.Model)
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"parallel_tool_calls":false`) {
+		t.Errorf("request = %s, want parallel_tool_calls false", data)
+	}
+}
+
+func TestParseSSEStreamServerTools(t *testing.T) {
+	sse := strings.Join([]string{
+		`data: {"type":"response.output_item.added","item":{"type":"web_search_call","id":"ws_1","status":"in_progress"}}`,
+		`data: {"type":"response.output_item.done","item":{"type":"web_search_call","id":"ws_1","status":"completed","action":{"type":"search","query":"go 1.26","sources":[{"type":"url","url":"https://go.dev/doc/go1.26"}]}}}`,
+		`data: {"type":"response.output_item.done","item":{"type":"code_interpreter_call","id":"ci_1","status":"completed","code":"print(1+1)","outputs":[{"type":"logs","logs":"2\n"}]}}`,
+		`data: {"type":"response.output_text.delta","delta":"Done."}`,
+		`data: {"type":"response.completed","response":{"id":"resp-1","output":[],"usage":{"input_tokens":10,"output_tokens":5}}}`,
+		"",
+	}, "\n")
+
+	resp, err := parseSSEStream(strings.NewReader(sse), nil, nil)
+	if err != nil {
+		t.Fatalf("parseSSEStream: %v", err)
+	}
+	svc := &ResponsesService{}
+	got := svc.toLLMResponseFromResponses(resp, nil).Content
+	if len(got) != 5 {
+		t.Fatalf("got %d contents, want 5: %+v", len(got), got)
+	}
+
+	if got[0].Type != llm.ContentTypeServerToolUse || got[0].ToolName != llm.ToolTypeWebSearch {
+		t.Errorf("content[0] = %+v, want web search use", got[0])
+	}
+	search := got[1].Display.(llm.ServerToolResult)
+	if search.Query != "go 1.26" || len(search.Sources) != 1 || search.Sources[0].URL != "https://go.dev/doc/go1.26" {
+		t.Errorf("search result = %+v", search)
+	}
+	exec := got[3].Display.(llm.ServerToolResult)
+	if exec.Code != "print(1+1)" || exec.Output != "2\n" {
+		t.Errorf("code interpreter result = %+v", exec)
+	}
+	if got[4].Type != llm.ContentTypeText || got[4].Text != "Done." {
+		t.Errorf("content[4] = %+v, want text", got[4])
+	}
+}
@@ -1324,3 +1324,53 @@ func TestServiceDo(t *testing.T) {
 		t.Errorf("resp.Usage.OutputTokens = %d, expected 20", resp.Usage.OutputTokens)
 	}
 }
+
+func TestFromLLMResponseSchema(t *testing.T) {
+	if got := fromLLMResponseSchema(nil); got != nil {
+		t.Errorf("fromLLMResponseSchema(nil) = %+v, want nil", got)
+	}
+	schema := llm.MustSchema(`{"type":"object","properties":{"answer":{"type":"string"}}}`)
+	got := fromLLMResponseSchema(&llm.ResponseSchema{Name: "answer", Schema: schema})
+	if got.Type != openai.ChatCompletionResponseFormatTypeJSONSchema {
+		t.Errorf("Type = %q, want json_schema", got.Type)
+	}
+	if got.JSONSchema == nil || got.JSONSchema.Name != "answer" {
+		t.Fatalf("JSONSchema = %+v, want name %q", got.JSONSchema, "answer")
+	}
+}
+
+func TestFromLLMMessageDocumentFallback(t *testing.T) {
+	doc := llm.DocumentContent(llm.MediaTypePlainText, "notes.txt", []byte("pin 3 is ground"))
+	msgs := fromLLMMessage(llm.Message{
+		Role: llm.MessageRoleUser,
+		Content: []llm.Content{
+			{Type: llm.ContentTypeToolResult, ToolUseID: "call_1", ToolResult: []llm.Content{doc}},
+			doc,
+		},
+	})
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(msgs))
+	}
+	for _, m := range msgs {
+		if m.Content != "[document notes.txt]\npin 3 is ground" {
+			t.Errorf("%s message content = %q, want extracted document text", m.Role, m.Content)
+		}
+	}
+}
+
+func TestFromLLMMessageSkipsServerToolContent(t *testing.T) {
+	msgs := fromLLMMessage(llm.Message{
+		Role: llm.MessageRoleAssistant,
+		Content: []llm.Content{
+			{ID: "ws_1", Type: llm.ContentTypeServerToolUse, ToolName: llm.ToolTypeWebSearch},
+			llm.ServerToolResultContent("ws_1", llm.ToolTypeWebSearch, llm.ServerToolResult{
+				Type:    llm.ToolTypeWebSearch,
+				Sources: []llm.ServerToolSource{{Title: "Go", URL: "https://go.dev"}},
+			}),
+			{Type: llm.ContentTypeText, Text: "answer"},
+		},
+	})
+	if len(msgs) != 1 || msgs[0].Content != "answer" {
+		t.Errorf("messages = %+v, want a single message with just the answer", msgs)
+	}
+}
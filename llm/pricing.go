@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"cmp"
+	"net/http"
+)
+
+// Pricing is a model's price list, used to compute Usage.CostUSD when
+// neither the provider nor a gateway reports the cost of a request.
+type Pricing struct {
+	Prices
+	// Tiers override Prices for requests with large inputs (context-tier pricing).
+	// The tier with the highest AboveInputTokens below the request's total input applies.
+	Tiers []PricingTier `json:"tiers,omitempty"`
+}
+
+// Prices are in USD per million tokens.
+type Prices struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+	// CacheRead and CacheWrite default to Input when zero.
+	CacheRead  float64 `json:"cache_read,omitempty"`
+	CacheWrite float64 `json:"cache_write,omitempty"`
+	// Reasoning prices reasoning tokens, which providers count as part of the output.
+	// It defaults to Output when zero.
+	Reasoning float64 `json:"reasoning,omitempty"`
+}
+
+// PricingTier is a set of prices that applies above an input size.
+type PricingTier struct {
+	AboveInputTokens uint64 `json:"above_input_tokens"`
+	Prices
+}
+
+// IsZero reports whether p sets no prices at all.
+func (p *Pricing) IsZero() bool {
+	return p == nil || (p.Prices == Prices{} && len(p.Tiers) == 0)
+}
+
+// Cost returns the cost of u in USD. A nil Pricing costs nothing.
+func (p *Pricing) Cost(u Usage) float64 {
+	if p == nil {
+		return 0
+	}
+	prices := p.Prices
+	var tierAbove uint64
+	in := u.TotalInputTokens()
+	for _, t := range p.Tiers {
+		if in > t.AboveInputTokens && t.AboveInputTokens >= tierAbove {
+			prices, tierAbove = t.Prices, t.AboveInputTokens
+		}
+	}
+	reasoning := min(u.ReasoningTokens, u.OutputTokens)
+	perMillion := float64(u.InputTokens)*prices.Input +
+		float64(u.CacheCreationInputTokens)*cmp.Or(prices.CacheWrite, prices.Input) +
+		float64(u.CacheReadInputTokens)*cmp.Or(prices.CacheRead, prices.Input) +
+		float64(u.OutputTokens-reasoning)*prices.Output +
+		float64(reasoning)*cmp.Or(prices.Reasoning, prices.Output)
+	return perMillion / 1e6
+}
+
+// CostUSD returns the cost of a request: the gateway-reported cost if there is one
+// (see CostUSDFromResponse), and otherwise the cost of u under pricing.
+func CostUSD(headers http.Header, pricing *Pricing, u Usage) float64 {
+	if cost := CostUSDFromResponse(headers); cost != 0 {
+		return cost
+	}
+	return pricing.Cost(u)
+}
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestPricingCost(t *testing.T) {
+	pricing := &Pricing{
+		Prices: Prices{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		Tiers: []PricingTier{
+			{AboveInputTokens: 200_000, Prices: Prices{Input: 6, Output: 22.5, CacheRead: 0.6, CacheWrite: 7.5}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		pricing *Pricing
+		usage   Usage
+		want    float64
+	}{
+		{
+			name:    "base prices",
+			pricing: pricing,
+			usage:   Usage{InputTokens: 1000, CacheReadInputTokens: 10000, CacheCreationInputTokens: 2000, OutputTokens: 500},
+			want:    (1000*3 + 10000*0.3 + 2000*3.75 + 500*15) / 1e6,
+		},
+		{
+			name:    "context tier",
+			pricing: pricing,
+			usage:   Usage{InputTokens: 1000, CacheReadInputTokens: 250_000, OutputTokens: 500},
+			want:    (1000*6 + 250_000*0.6 + 500*22.5) / 1e6,
+		},
+		{
+			name:    "exactly at tier threshold uses base prices",
+			pricing: pricing,
+			usage:   Usage{InputTokens: 200_000},
+			want:    200_000 * 3 / 1e6,
+		},
+		{
+			name:    "cache prices default to input",
+			pricing: &Pricing{Prices: Prices{Input: 2, Output: 8}},
+			usage:   Usage{CacheReadInputTokens: 1000, CacheCreationInputTokens: 1000},
+			want:    (1000*2 + 1000*2) / 1e6,
+		},
+		{
+			name:    "reasoning priced separately",
+			pricing: &Pricing{Prices: Prices{Input: 1, Output: 4, Reasoning: 10}},
+			usage:   Usage{OutputTokens: 1000, ReasoningTokens: 400},
+			want:    (600*4 + 400*10) / 1e6,
+		},
+		{
+			name:    "nil pricing",
+			pricing: nil,
+			usage:   Usage{InputTokens: 1000, OutputTokens: 1000},
+			want:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pricing.Cost(tt.usage); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("Cost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCostUSDPrefersGatewayHeader(t *testing.T) {
+	pricing := &Pricing{Prices: Prices{Input: 1, Output: 1}}
+	usage := Usage{InputTokens: 1_000_000}
+
+	h := http.Header{}
+	if got := CostUSD(h, pricing, usage); got != 1 {
+		t.Errorf("CostUSD without header = %v, want 1", got)
+	}
+	h.Set("Exedev-Gateway-Cost", "0.25")
+	if got := CostUSD(h, pricing, usage); got != 0.25 {
+		t.Errorf("CostUSD with header = %v, want 0.25", got)
+	}
+}
+
+func TestPricingIsZero(t *testing.T) {
+	var nilPricing *Pricing
+	if !nilPricing.IsZero() || !(&Pricing{}).IsZero() {
+		t.Error("expected nil and empty pricing to be zero")
+	}
+	if (&Pricing{Prices: Prices{Output: 1}}).IsZero() {
+		t.Error("expected pricing with an output price to be non-zero")
+	}
+}
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Server tool types.
+// A Tool whose Type is one of these is executed by the provider rather than locally:
+// it has no InputSchema or Run function, and its activity is reported back as
+// ContentTypeServerToolUse and ContentTypeServerToolResult content.
+// Providers silently omit server tools they do not support.
+const (
+	ToolTypeWebSearch     = "web_search"
+	ToolTypeCodeExecution = "code_execution"
+	ToolTypeURLContext    = "url_context"
+)
+
+// ServerToolTypes lists all server tool types.
+var ServerToolTypes = []string{ToolTypeWebSearch, ToolTypeCodeExecution, ToolTypeURLContext}
+
+// ServerTool returns a provider-executed tool of the given type.
+func ServerTool(typ string) *Tool {
+	return &Tool{Name: typ, Type: typ}
+}
+
+// IsServerTool reports whether t is executed by the provider.
+func (t *Tool) IsServerTool() bool {
+	return IsServerToolType(t.Type)
+}
+
+// IsServerToolType reports whether typ is a server tool type.
+func IsServerToolType(typ string) bool {
+	return slices.Contains(ServerToolTypes, typ)
+}
+
+// IsServerToolContent reports whether c records server tool activity.
+// Such content is shown to the user. Only the provider that produced it is sent it back,
+// using Raw; other providers skip it.
+func (c Content) IsServerToolContent() bool {
+	return c.Type == ContentTypeServerToolUse || c.Type == ContentTypeServerToolResult
+}
+
+// ServerToolResult is the Display data of ContentTypeServerToolResult content.
+type ServerToolResult struct {
+	// Type is the server tool type, e.g. ToolTypeWebSearch.
+	Type string `json:"type"`
+	// Query is the search query or fetched URL, if any.
+	Query string `json:"query,omitempty"`
+	// Sources are the pages a web search or URL fetch returned.
+	Sources []ServerToolSource `json:"sources,omitempty"`
+	// Code is the code that was executed, for code execution.
+	Code string `json:"code,omitempty"`
+	// Output is the combined output of code execution.
+	Output string `json:"output,omitempty"`
+	// Error describes a failed server tool call.
+	Error string `json:"error,omitempty"`
+}
+
+// ServerToolSource is a web page returned by a server tool.
+type ServerToolSource struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"`
+}
+
+// ServerToolResultContent returns content recording the result of a server tool call.
+// The text is a plain summary for consumers that ignore Display.
+func ServerToolResultContent(toolUseID, toolName string, r ServerToolResult) Content {
+	return Content{
+		Type:      ContentTypeServerToolResult,
+		ToolUseID: toolUseID,
+		ToolName:  toolName,
+		ToolError: r.Error != "",
+		Text:      r.summary(),
+		Display:   r,
+	}
+}
+
+func (r ServerToolResult) summary() string {
+	switch {
+	case r.Error != "":
+		return r.Type + " failed: " + r.Error
+	case r.Output != "":
+		return r.Output
+	}
+	var sb strings.Builder
+	for _, src := range r.Sources {
+		fmt.Fprintf(&sb, "- %s %s\n", src.Title, src.URL)
+	}
+	return sb.String()
+}
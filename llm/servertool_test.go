@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"testing"
+)
+
+func TestServerTool(t *testing.T) {
+	for _, typ := range ServerToolTypes {
+		tool := ServerTool(typ)
+		if !tool.IsServerTool() {
+			t.Errorf("ServerTool(%q).IsServerTool() = false, want true", typ)
+		}
+		if tool.Name != typ {
+			t.Errorf("ServerTool(%q).Name = %q, want %q", typ, tool.Name, typ)
+		}
+	}
+	if (&Tool{Name: "bash"}).IsServerTool() {
+		t.Error("bash tool reported as server tool")
+	}
+	if IsServerToolType("custom") {
+		t.Error(`IsServerToolType("custom") = true, want false`)
+	}
+}
+
+func TestServerToolResultContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		result    ServerToolResult
+		wantText  string
+		wantError bool
+	}{
+		{
+			name: "sources",
+			result: ServerToolResult{Type: ToolTypeWebSearch, Sources: []ServerToolSource{
+				{Title: "Go", URL: "https://go.dev"},
+				{URL: "https://pkg.go.dev"},
+			}},
+			wantText: "- Go https://go.dev\n-  https://pkg.go.dev\n",
+		},
+		{
+			name:     "output",
+			result:   ServerToolResult{Type: ToolTypeCodeExecution, Code: "print(2)", Output: "2\n"},
+			wantText: "2\n",
+		},
+		{
+			name:      "error",
+			result:    ServerToolResult{Type: ToolTypeURLContext, Error: "url_not_accessible"},
+			wantText:  "url_context failed: url_not_accessible",
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ServerToolResultContent("id1", tt.result.Type, tt.result)
+			if c.Type != ContentTypeServerToolResult || c.ToolUseID != "id1" || c.ToolName != tt.result.Type {
+				t.Errorf("content = %+v", c)
+			}
+			if !c.IsServerToolContent() {
+				t.Error("IsServerToolContent() = false, want true")
+			}
+			if c.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", c.Text, tt.wantText)
+			}
+			if c.ToolError != tt.wantError {
+				t.Errorf("ToolError = %v, want %v", c.ToolError, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestServerToolContentTypeString(t *testing.T) {
+	if got := ContentTypeServerToolUse.String(); got != "ContentTypeServerToolUse" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := ContentTypeServerToolResult.String(); got != "ContentTypeServerToolResult" {
+		t.Errorf("String() = %q", got)
+	}
+	// Persisted values of existing content types must not change.
+	if ContentTypeToolResult != 6 || ContentTypeServerToolUse != 7 || ContentTypeServerToolResult != 8 {
+		t.Errorf("unexpected content type values: %d %d %d", ContentTypeToolResult, ContentTypeServerToolUse, ContentTypeServerToolResult)
+	}
+}
@@ -0,0 +1,258 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// DefaultResponseSchemaName is used when a ResponseSchema has no Name.
+const DefaultResponseSchemaName = "structured_output"
+
+// ResponseSchema describes the JSON value a model must reply with.
+type ResponseSchema struct {
+	// Name identifies the schema to the provider (e.g. the forced tool name for Anthropic).
+	// Defaults to DefaultResponseSchemaName.
+	Name string
+	// Description is an optional human-readable description of the expected output.
+	Description string
+	// Schema is the JSON schema for the reply. The top-level type must be "object".
+	Schema json.RawMessage
+}
+
+// SchemaName returns the schema name, falling back to DefaultResponseSchemaName.
+func (rs *ResponseSchema) SchemaName() string {
+	if rs == nil || rs.Name == "" {
+		return DefaultResponseSchemaName
+	}
+	return rs.Name
+}
+
+// ErrNoResponseSchema is returned by DoJSON when the request has no ResponseSchema.
+var ErrNoResponseSchema = errors.New("request has no response schema")
+
+// ResponseText returns the concatenated text content of a response.
+func ResponseText(resp *Response) string {
+	if resp == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, c := range resp.Content {
+		if c.Type == ContentTypeText && c.MediaType == "" {
+			sb.WriteString(c.Text)
+		}
+	}
+	return sb.String()
+}
+
+// DoJSON sends req to svc, validates the reply against req.ResponseSchema, and decodes it into a T.
+// The raw response is returned alongside the value so callers can inspect usage.
+func DoJSON[T any](ctx context.Context, svc Service, req *Request) (T, *Response, error) {
+	var zero T
+	if req.ResponseSchema == nil {
+		return zero, nil, ErrNoResponseSchema
+	}
+	resp, err := svc.Do(ctx, req)
+	if err != nil {
+		return zero, nil, err
+	}
+	data, err := ExtractJSON(ResponseText(resp))
+	if err != nil {
+		return zero, resp, err
+	}
+	if err := ValidateJSON(req.ResponseSchema.Schema, data); err != nil {
+		return zero, resp, fmt.Errorf("structured output does not match schema: %w", err)
+	}
+	var out T
+	if err := json.Unmarshal(data, &out); err != nil {
+		return zero, resp, fmt.Errorf("failed to decode structured output: %w", err)
+	}
+	return out, resp, nil
+}
+
+// ExtractJSON returns the JSON value in text.
+// Some providers wrap structured output in a markdown code fence despite being asked not to; it is removed.
+func ExtractJSON(text string) (json.RawMessage, error) {
+	text = strings.TrimSpace(text)
+	if rest, ok := strings.CutPrefix(text, "```"); ok {
+		// Drop the optional language tag on the opening fence.
+		if i := strings.IndexByte(rest, '\n'); i >= 0 {
+			rest = rest[i+1:]
+		}
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+	}
+	if text == "" {
+		return nil, errors.New("empty structured output")
+	}
+	if !json.Valid([]byte(text)) {
+		return nil, fmt.Errorf("structured output is not valid JSON: %s", truncate(text, 200))
+	}
+	return json.RawMessage(text), nil
+}
+
+// ValidateJSON checks data against a JSON schema.
+// It supports the subset of JSON schema used for tool and response schemas:
+// type, properties, required, additionalProperties, enum, items, minItems, maxItems,
+// minimum, maximum, minLength and maxLength.
+func ValidateJSON(schema, data json.RawMessage) error {
+	var s map[string]any
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return validateValue("$", s, v)
+}
+
+func validateValue(path string, schema map[string]any, v any) error {
+	if err := validateType(path, schema["type"], v); err != nil {
+		return err
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value %v is not one of the allowed values", path, v)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, ok := val[name]; !ok {
+					return fmt.Errorf("%s: missing required property %q", path, name)
+				}
+			}
+		}
+		for name, pv := range val {
+			ps, ok := props[name].(map[string]any)
+			if !ok {
+				if ap, ok := schema["additionalProperties"].(bool); ok && !ap {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+				continue
+			}
+			if err := validateValue(path+"."+name, ps, pv); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if n, ok := schema["minItems"].(float64); ok && float64(len(val)) < n {
+			return fmt.Errorf("%s: expected at least %v items, got %d", path, n, len(val))
+		}
+		if n, ok := schema["maxItems"].(float64); ok && float64(len(val)) > n {
+			return fmt.Errorf("%s: expected at most %v items, got %d", path, n, len(val))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range val {
+				if err := validateValue(fmt.Sprintf("%s[%d]", path, i), items, item); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := len([]rune(val))
+		if m, ok := schema["minLength"].(float64); ok && float64(n) < m {
+			return fmt.Errorf("%s: string shorter than %v characters", path, m)
+		}
+		if m, ok := schema["maxLength"].(float64); ok && float64(n) > m {
+			return fmt.Errorf("%s: string longer than %v characters", path, m)
+		}
+	case float64:
+		if m, ok := schema["minimum"].(float64); ok && val < m {
+			return fmt.Errorf("%s: %v is less than minimum %v", path, val, m)
+		}
+		if m, ok := schema["maximum"].(float64); ok && val > m {
+			return fmt.Errorf("%s: %v is greater than maximum %v", path, val, m)
+		}
+	}
+	return nil
+}
+
+// validateType checks v against a schema "type", which may be a string or a list of strings.
+func validateType(path string, typ, v any) error {
+	var types []string
+	switch t := typ.(type) {
+	case nil:
+		return nil
+	case string:
+		types = []string{t}
+	case []any:
+		for _, x := range t {
+			if s, ok := x.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+	actual := jsonTypeOf(v)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), actual)
+}
+
+func jsonTypeOf(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func jsonEqual(a, b any) bool {
+	switch av := a.(type) {
+	case []any:
+		bv, ok := b.([]any)
+		return ok && slices.EqualFunc(av, bv, jsonEqual)
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, x := range av {
+			if y, ok := bv[k]; !ok || !jsonEqual(x, y) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return cutUTF8(s, n) + "..."
+}
@@ -0,0 +1,183 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// textService is a Service that always replies with the given text.
+type textService struct {
+	mockService
+	text string
+}
+
+func (s *textService) Do(ctx context.Context, req *Request) (*Response, error) {
+	return &Response{Content: []Content{StringContent(s.text)}}, nil
+}
+
+func TestCheckSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr bool
+	}{
+		{"valid", `{"type":"object","properties":{}}`, false},
+		{"invalid json", `{`, true},
+		{"not object", `{"type":"string","properties":{}}`, true},
+		{"no properties", `{"type":"object"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSchema(json.RawMessage(tt.schema))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckSchema(%s) error = %v, wantErr %v", tt.schema, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{"plain", `{"a":1}`, `{"a":1}`, false},
+		{"whitespace", "  {\"a\":1}\n", `{"a":1}`, false},
+		{"fenced", "```json\n{\"a\":1}\n```", `{"a":1}`, false},
+		{"fenced no lang", "```\n{\"a\":1}\n```", `{"a":1}`, false},
+		{"empty", "", "", true},
+		{"not json", "here you go: {", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractJSON(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractJSON(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ExtractJSON(%q) = %s, want %s", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractJSONErrorTruncatesOnRuneBoundary(t *testing.T) {
+	// "é" is two bytes, so the 200-byte limit falls inside one.
+	_, err := ExtractJSON("x" + strings.Repeat("é", 200))
+	if err == nil {
+		t.Fatal("ExtractJSON() error = nil, want invalid JSON")
+	}
+	if !utf8.ValidString(err.Error()) || !strings.HasSuffix(err.Error(), "é...") {
+		t.Errorf("ExtractJSON() error = %q, want valid UTF-8 cut before the limit", err)
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"required": ["name", "tags"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "maxLength": 5},
+			"count": {"type": "integer", "minimum": 0, "maximum": 10},
+			"ratio": {"type": "number"},
+			"kind": {"type": "string", "enum": ["a", "b"]},
+			"note": {"type": ["string", "null"]},
+			"tags": {"type": "array", "minItems": 1, "maxItems": 2, "items": {"type": "string"}}
+		}
+	}`)
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"valid", `{"name":"x","count":3,"ratio":2,"kind":"a","note":null,"tags":["t"]}`, ""},
+		{"missing required", `{"name":"x"}`, `$: missing required property "tags"`},
+		{"extra property", `{"name":"x","tags":["t"],"other":1}`, `$: unexpected property "other"`},
+		{"wrong type", `{"name":1,"tags":["t"]}`, "$.name: expected string, got integer"},
+		{"not integer", `{"name":"x","count":1.5,"tags":["t"]}`, "$.count: expected integer, got number"},
+		{"too long", `{"name":"abcdef","tags":["t"]}`, "$.name: string longer than 5 characters"},
+		{"too short", `{"name":"","tags":["t"]}`, "$.name: string shorter than 1 characters"},
+		{"below minimum", `{"name":"x","count":-1,"tags":["t"]}`, "$.count: -1 is less than minimum 0"},
+		{"above maximum", `{"name":"x","count":11,"tags":["t"]}`, "$.count: 11 is greater than maximum 10"},
+		{"enum", `{"name":"x","kind":"c","tags":["t"]}`, "$.kind: value c is not one of the allowed values"},
+		{"too few items", `{"name":"x","tags":[]}`, "$.tags: expected at least 1 items, got 0"},
+		{"too many items", `{"name":"x","tags":["a","b","c"]}`, "$.tags: expected at most 2 items, got 3"},
+		{"bad item", `{"name":"x","tags":["a",2]}`, "$.tags[1]: expected string, got integer"},
+		{"not object", `[]`, "$: expected object, got array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateJSON(schema, json.RawMessage(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateJSON() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("ValidateJSON() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDoJSON(t *testing.T) {
+	type answer struct {
+		Answer string `json:"answer"`
+	}
+	rs := &ResponseSchema{Schema: MustSchema(`{
+		"type": "object",
+		"required": ["answer"],
+		"properties": {"answer": {"type": "string"}}
+	}`)}
+	req := &Request{ResponseSchema: rs}
+
+	t.Run("decodes", func(t *testing.T) {
+		svc := &textService{text: "```json\n{\"answer\":\"42\"}\n```"}
+		got, resp, err := DoJSON[answer](context.Background(), svc, req)
+		if err != nil {
+			t.Fatalf("DoJSON() error = %v", err)
+		}
+		if got.Answer != "42" {
+			t.Errorf("DoJSON() = %+v, want answer 42", got)
+		}
+		if resp == nil {
+			t.Error("DoJSON() response = nil, want non-nil")
+		}
+	})
+
+	t.Run("schema mismatch", func(t *testing.T) {
+		svc := &textService{text: `{"answer":42}`}
+		_, resp, err := DoJSON[answer](context.Background(), svc, req)
+		if err == nil || !strings.Contains(err.Error(), "does not match schema") {
+			t.Fatalf("DoJSON() error = %v, want schema mismatch", err)
+		}
+		if resp == nil {
+			t.Error("DoJSON() response = nil, want the raw response on validation failure")
+		}
+	})
+
+	t.Run("no schema", func(t *testing.T) {
+		_, _, err := DoJSON[answer](context.Background(), &textService{}, &Request{})
+		if !errors.Is(err, ErrNoResponseSchema) {
+			t.Fatalf("DoJSON() error = %v, want ErrNoResponseSchema", err)
+		}
+	})
+}
+
+func TestResponseSchemaName(t *testing.T) {
+	var nilSchema *ResponseSchema
+	if got := nilSchema.SchemaName(); got != DefaultResponseSchemaName {
+		t.Errorf("nil SchemaName() = %q, want %q", got, DefaultResponseSchemaName)
+	}
+	if got := (&ResponseSchema{Name: "slug"}).SchemaName(); got != "slug" {
+		t.Errorf("SchemaName() = %q, want %q", got, "slug")
+	}
+}
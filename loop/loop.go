@@ -2,6 +2,7 @@ package loop
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
@@ -9,9 +10,20 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"shelley.exe.dev/claudetool"
 	"shelley.exe.dev/gitstate"
 	"shelley.exe.dev/llm"
+	"shelley.exe.dev/metrics"
+	"shelley.exe.dev/redact"
+	"shelley.exe.dev/tracing"
+)
+
+var (
+	toolCalls        = metrics.NewCounter("shelley_tool_calls_total", "Tool calls by tool and outcome (ok or error). Calls to unknown tools count as tool \"unknown\".", "tool", "outcome")
+	toolCallDuration = metrics.NewHistogram("shelley_tool_call_duration_seconds", "Time to run a tool call, by tool.", metrics.LongDurationBuckets, "tool")
 )
 
 // MessageRecordFunc is called to record new messages to persistent storage.
@@ -41,6 +53,19 @@ type Config struct {
 	// OnStreamThinking is called with partial thinking as it streams from the LLM.
 	// Only used if the LLM service implements llm.ThinkingStreamingService.
 	OnStreamThinking func(text string)
+	// DisableParallelToolCalls asks the LLM for at most one tool call per response,
+	// for models that do not handle parallel tool calls well.
+	DisableParallelToolCalls bool
+	// GetTools returns the tools to offer the LLM. If set, it is called before
+	// every request, so the tool list can change mid-turn (e.g. when a skill is
+	// activated). If nil, Config.Tools is used as a static value.
+	GetTools func() []*llm.Tool
+	// Redactor removes secrets from tool results before they are recorded
+	// or sent to the LLM. If nil, tool results are kept verbatim.
+	Redactor *redact.Redactor
+	// TraceContext, if set, is called at the start of each turn to attach
+	// the span the turn's LLM request and tool call spans belong under.
+	TraceContext func(ctx context.Context) context.Context
 }
 
 // Loop manages a conversation turn with an LLM including tool execution and message recording.
@@ -48,6 +73,7 @@ type Config struct {
 type Loop struct {
 	llm              llm.Service
 	tools            []*llm.Tool
+	getTools         func() []*llm.Tool
 	recordMessage    MessageRecordFunc
 	history          []llm.Message
 	messageQueue     []llm.Message
@@ -61,6 +87,10 @@ type Loop struct {
 	lastGitState     *gitstate.GitState
 	onStreamText     func(string)
 	onStreamThinking func(string)
+	// disableParallelToolCalls is copied into every request.
+	disableParallelToolCalls bool
+	redactor                 *redact.Redactor
+	traceContext             func(context.Context) context.Context
 }
 
 // NewLoop creates a new Loop instance with the provided configuration
@@ -81,6 +111,7 @@ func NewLoop(config Config) *Loop {
 		llm:              config.LLM,
 		history:          config.History,
 		tools:            config.Tools,
+		getTools:         config.GetTools,
 		recordMessage:    config.RecordMessage,
 		messageQueue:     make([]llm.Message, 0),
 		logger:           logger,
@@ -91,6 +122,10 @@ func NewLoop(config Config) *Loop {
 		lastGitState:     initialGitState,
 		onStreamText:     config.OnStreamText,
 		onStreamThinking: config.OnStreamThinking,
+
+		disableParallelToolCalls: config.DisableParallelToolCalls,
+		redactor:                 config.Redactor,
+		traceContext:             config.TraceContext,
 	}
 }
 
@@ -205,10 +240,13 @@ func (l *Loop) ProcessOneTurn(ctx context.Context) error {
 // mutual recursion (processLLMRequest ↔ executeToolCalls) caused, because
 // each iteration's locals are freed before the next iteration starts.
 func (l *Loop) processLLMRequest(ctx context.Context) error {
+	if l.traceContext != nil {
+		ctx = l.traceContext(ctx)
+	}
 	for {
 		l.mu.Lock()
 		messages := append([]llm.Message(nil), l.history...)
-		tools := l.tools
+		tools := l.currentToolsLocked()
 		system := l.system
 		llmService := l.llm
 		l.mu.Unlock()
@@ -239,9 +277,10 @@ func (l *Loop) processLLMRequest(ctx context.Context) error {
 		}
 
 		req := &llm.Request{
-			Messages: messages,
-			Tools:    tools,
-			System:   system,
+			Messages:                 messages,
+			Tools:                    tools,
+			System:                   system,
+			DisableParallelToolCalls: l.disableParallelToolCalls,
 		}
 
 		// Insert missing tool results if the previous message had tool_use blocks
@@ -255,8 +294,13 @@ func (l *Loop) processLLMRequest(ctx context.Context) error {
 		}
 		l.logger.Debug("sending LLM request", "message_count", len(messages), "tool_count", len(tools), "system_items", len(system), "system_length", systemLen)
 
+		spanCtx, span := tracing.Tracer().Start(ctx, "llm request", trace.WithAttributes(
+			attribute.Int("shelley.message_count", len(messages)),
+			attribute.Int("shelley.tool_count", len(tools)),
+		))
+
 		// Add a timeout for the LLM request to prevent indefinite hangs
-		llmCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		llmCtx, cancel := context.WithTimeout(spanCtx, 5*time.Minute)
 
 		// Retry LLM requests that fail with retryable errors (EOF, connection reset)
 		const maxRetries = 2
@@ -287,9 +331,11 @@ func (l *Loop) processLLMRequest(ctx context.Context) error {
 				"error", err,
 				"attempt", attempt,
 				"max_retries", maxRetries)
+			span.AddEvent("retry", trace.WithAttributes(attribute.Int("shelley.attempt", attempt)))
 			time.Sleep(time.Second * time.Duration(attempt)) // Simple backoff
 		}
 		cancel()
+		endLLMSpan(span, resp, err)
 
 		if err != nil {
 			// Record the error as a message so it can be displayed in the UI
@@ -340,6 +386,12 @@ func (l *Loop) processLLMRequest(ctx context.Context) error {
 			l.logger.Error("failed to record assistant message", "error", err)
 		}
 
+		// A paused turn continues by sending the response back as it is.
+		if resp.StopReason == llm.StopReasonPauseTurn {
+			l.logger.Debug("LLM paused the turn, continuing")
+			continue
+		}
+
 		// If no tool calls, the turn is over
 		if resp.StopReason != llm.StopReasonToolUse {
 			l.checkGitStateChange(ctx)
@@ -354,6 +406,26 @@ func (l *Loop) processLLMRequest(ctx context.Context) error {
 	}
 }
 
+// endLLMSpan records the outcome and token usage of an LLM request on its
+// span and ends it. Error text can quote the conversation, so spans only
+// record that the request failed.
+func endLLMSpan(span trace.Span, resp *llm.Response, err error) {
+	defer span.End()
+	if err != nil {
+		span.SetStatus(codes.Error, "llm request failed")
+		return
+	}
+	span.SetAttributes(
+		attribute.String("gen_ai.response.model", resp.Model),
+		attribute.String("gen_ai.response.finish_reason", resp.StopReason.String()),
+		attribute.Int64("gen_ai.usage.input_tokens", int64(resp.Usage.InputTokens)),
+		attribute.Int64("gen_ai.usage.output_tokens", int64(resp.Usage.OutputTokens)),
+		attribute.Int64("shelley.usage.cache_read_input_tokens", int64(resp.Usage.CacheReadInputTokens)),
+		attribute.Int64("shelley.usage.cache_creation_input_tokens", int64(resp.Usage.CacheCreationInputTokens)),
+		attribute.Float64("shelley.usage.cost_usd", resp.Usage.CostUSD),
+	)
+}
+
 // checkGitStateChange checks if the git state has changed and calls the callback if so.
 // This is called at the end of each turn.
 func (l *Loop) checkGitStateChange(ctx context.Context) {
@@ -439,6 +511,14 @@ func (l *Loop) handleMaxTokensTruncation(ctx context.Context, resp *llm.Response
 	return nil
 }
 
+// currentToolsLocked returns the tools to offer the LLM. Caller must hold l.mu.
+func (l *Loop) currentToolsLocked() []*llm.Tool {
+	if l.getTools != nil {
+		return l.getTools()
+	}
+	return l.tools
+}
+
 // executeToolCalls runs the tools from an LLM response and appends the results
 // to l.history. It does NOT call processLLMRequest — the caller loops instead.
 func (l *Loop) executeToolCalls(ctx context.Context, content []llm.Content) error {
@@ -452,8 +532,11 @@ func (l *Loop) executeToolCalls(ctx context.Context, content []llm.Content) erro
 		l.logger.Debug("executing tool", "name", c.ToolName, "id", c.ID)
 
 		// Find the tool
+		l.mu.Lock()
+		tools := l.currentToolsLocked()
+		l.mu.Unlock()
 		var tool *llm.Tool
-		for _, t := range l.tools {
+		for _, t := range tools {
 			if t.Name == c.ToolName {
 				tool = t
 				break
@@ -462,6 +545,7 @@ func (l *Loop) executeToolCalls(ctx context.Context, content []llm.Content) erro
 
 		if tool == nil {
 			l.logger.Error("tool not found", "name", c.ToolName)
+			toolCalls.Inc("unknown", "error")
 			toolResults = append(toolResults, llm.Content{
 				Type:      llm.ContentTypeToolResult,
 				ToolUseID: c.ID,
@@ -474,13 +558,26 @@ func (l *Loop) executeToolCalls(ctx context.Context, content []llm.Content) erro
 		}
 
 		// Execute the tool with working directory set in context
-		toolCtx := ctx
+		toolCtx, span := tracing.Tracer().Start(ctx, "tool "+c.ToolName, trace.WithAttributes(
+			attribute.String("gen_ai.tool.name", c.ToolName),
+			attribute.String("gen_ai.tool.call.id", c.ID),
+		))
 		if l.workingDir != "" {
-			toolCtx = claudetool.WithWorkingDir(ctx, l.workingDir)
+			toolCtx = claudetool.WithWorkingDir(toolCtx, l.workingDir)
 		}
 		startTime := time.Now()
 		result := tool.Run(toolCtx, c.ToolInput)
 		endTime := time.Now()
+		if result.Error != nil {
+			span.SetStatus(codes.Error, "tool error")
+		}
+		span.End()
+		outcome := "ok"
+		if result.Error != nil {
+			outcome = "error"
+		}
+		toolCalls.Inc(c.ToolName, outcome)
+		toolCallDuration.Observe(endTime.Sub(startTime).Seconds(), c.ToolName)
 
 		var toolResultContent []llm.Content
 		if result.Error != nil {
@@ -493,6 +590,15 @@ func (l *Loop) executeToolCalls(ctx context.Context, content []llm.Content) erro
 			l.logger.Debug("tool executed successfully", "name", c.ToolName, "duration", endTime.Sub(startTime))
 		}
 
+		toolResultContent, redactions := l.redactContents(toolResultContent)
+		display, displayRedactions := l.redactDisplay(result.Display)
+		if len(redactions) == 0 {
+			redactions = displayRedactions
+		}
+		if len(redactions) > 0 {
+			l.logger.Info("redacted secrets from tool result", "name", c.ToolName, "kinds", redactions)
+		}
+
 		toolResults = append(toolResults, llm.Content{
 			Type:             llm.ContentTypeToolResult,
 			ToolUseID:        c.ID,
@@ -500,7 +606,8 @@ func (l *Loop) executeToolCalls(ctx context.Context, content []llm.Content) erro
 			ToolResult:       toolResultContent,
 			ToolUseStartTime: &startTime,
 			ToolUseEndTime:   &endTime,
-			Display:          result.Display,
+			Display:          display,
+			Redactions:       redactions,
 		})
 	}
 
@@ -533,6 +640,47 @@ func (l *Loop) executeToolCalls(ctx context.Context, content []llm.Content) erro
 	return nil
 }
 
+// redactContents redacts secrets from the text of tool result contents.
+func (l *Loop) redactContents(contents []llm.Content) ([]llm.Content, []string) {
+	if l.redactor == nil {
+		return contents, nil
+	}
+	var found []string
+	out := make([]llm.Content, len(contents))
+	for i, c := range contents {
+		if c.Type == llm.ContentTypeText {
+			var kinds []string
+			c.Text, kinds = l.redactor.Redact(c.Text)
+			found = append(found, kinds...)
+		}
+		out[i] = c
+	}
+	return out, found
+}
+
+// redactDisplay redacts secrets from a tool's display data. Display data
+// containing secrets is returned in its JSON form, which is how it is stored.
+func (l *Loop) redactDisplay(display any) (any, []string) {
+	if l.redactor == nil || display == nil {
+		return display, nil
+	}
+	data, err := json.Marshal(display)
+	if err != nil {
+		return display, nil
+	}
+	redacted, kinds := l.redactor.Redact(string(data))
+	if len(kinds) == 0 {
+		return display, nil
+	}
+	var out any
+	if err := json.Unmarshal([]byte(redacted), &out); err != nil {
+		// A pattern matched across JSON syntax; keep nothing rather than the secret.
+		l.logger.Warn("dropping display data after redaction", "error", err)
+		return nil, kinds
+	}
+	return out, kinds
+}
+
 // insertMissingToolResults fixes tool_result issues in the conversation history:
 //  1. Adds error results for tool_uses that were requested but not included in the next message.
 //     This can happen when a request is cancelled or fails after the LLM responds with tool_use
@@ -24,6 +24,8 @@ import (
 //   - "subagent: <slug> <prompt>" - triggers subagent tool
 //   - "change_dir: <path>" - triggers change_dir tool
 //   - "delay: <seconds>" - delays response by specified seconds
+//   - "json: <value>" - with a ResponseSchema, replies with the given JSON verbatim
+//     (otherwise a structured request gets a synthesized value matching the schema)
 //   - See Do() method for complete list of supported patterns
 type PredictableService struct {
 	// TokenContextWindow size
@@ -133,6 +135,11 @@ func (s *PredictableService) Do(ctx context.Context, req *llm.Request) (*llm.Res
 		}
 	}
 
+	// Structured output requests get a JSON reply instead of the usual patterns
+	if req.ResponseSchema != nil {
+		return s.makeStructuredResponse(req.ResponseSchema, inputText, inputTokens), nil
+	}
+
 	// If the message is purely a tool result (no text), acknowledge it and end turn
 	if hasToolResult && inputText == "" {
 		return s.makeResponse("Done.", inputTokens), nil
@@ -350,6 +357,55 @@ func (s *PredictableService) makeBashToolResponse(command string, inputTokens ui
 	}
 }
 
+// makeStructuredResponse replies to a request with a ResponseSchema.
+// "json: <value>" inputs are returned verbatim; anything else gets a value synthesized from the schema.
+func (s *PredictableService) makeStructuredResponse(rs *llm.ResponseSchema, inputText string, inputTokens uint64) *llm.Response {
+	if v, ok := strings.CutPrefix(inputText, "json: "); ok {
+		return s.makeResponse(v, inputTokens)
+	}
+	var schema map[string]any
+	json.Unmarshal(rs.Schema, &schema)
+	out, _ := json.Marshal(predictableSchemaValue(schema, rs.SchemaName()))
+	return s.makeResponse(string(out), inputTokens)
+}
+
+// predictableSchemaValue builds a deterministic value that satisfies schema.
+func predictableSchemaValue(schema map[string]any, name string) any {
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	typ, _ := schema["type"].(string)
+	switch typ {
+	case "object":
+		obj := map[string]any{}
+		props, _ := schema["properties"].(map[string]any)
+		for prop, ps := range props {
+			psm, _ := ps.(map[string]any)
+			obj[prop] = predictableSchemaValue(psm, prop)
+		}
+		return obj
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		minItems, _ := schema["minItems"].(float64)
+		arr := []any{}
+		for i := 0; i < max(1, int(minItems)); i++ {
+			arr = append(arr, predictableSchemaValue(items, name))
+		}
+		return arr
+	case "integer", "number":
+		if m, ok := schema["minimum"].(float64); ok {
+			return m
+		}
+		return 0
+	case "boolean":
+		return false
+	case "null":
+		return nil
+	default:
+		return "predictable " + name
+	}
+}
+
 // makeThinkingResponse creates a response with extended thinking content
 func (s *PredictableService) makeThinkingResponse(thoughts string, inputTokens uint64) *llm.Response {
 	responseText := "I've considered my approach."
@@ -0,0 +1,332 @@
+// Package metrics keeps process-wide counters, gauges and histograms and
+// writes them in the Prometheus text exposition format.
+//
+// Metrics are declared as package-level variables next to the code that
+// updates them:
+//
+//	var toolCalls = metrics.NewCounter("shelley_tool_calls_total", "Tool calls by tool and outcome.", "tool", "outcome")
+//
+//	toolCalls.Inc("bash", "ok")
+//
+// Label values are passed in the order the label names were declared.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the Content-Type of the output of Write.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DurationBuckets are histogram buckets, in seconds, for operations that
+// take milliseconds to seconds, such as database queries.
+var DurationBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+// LongDurationBuckets are histogram buckets, in seconds, for operations that
+// take seconds to many minutes, such as LLM requests and agent turns.
+var LongDurationBuckets = []float64{.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800, 3600}
+
+var registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+func register(name string, m metric) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.metrics == nil {
+		registry.metrics = make(map[string]metric)
+	}
+	if _, ok := registry.metrics[name]; ok {
+		panic("metrics: duplicate metric " + name)
+	}
+	registry.metrics[name] = m
+}
+
+// Write writes every registered metric to w.
+func Write(w io.Writer) error {
+	registry.mu.Lock()
+	names := make([]string, 0, len(registry.metrics))
+	for name := range registry.metrics {
+		names = append(names, name)
+	}
+	metrics := make([]metric, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		metrics = append(metrics, registry.metrics[name])
+	}
+	registry.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+// WriteGauge writes a single unlabelled gauge, for values computed when the
+// metrics are scraped rather than tracked as they change.
+func WriteGauge(w io.Writer, name, help string, value float64) error {
+	bw := bufio.NewWriter(w)
+	d := desc{name: name, help: help, typ: "gauge"}
+	d.writeHeader(bw)
+	d.writeSample(bw, "", nil, "", "", value)
+	return bw.Flush()
+}
+
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+// key identifies a series by its label values.
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+func (d *desc) writeHeader(w *bufio.Writer) {
+	help := strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(d.help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, help, d.name, d.typ)
+}
+
+// writeSample writes one sample of the series with the given label values,
+// adding the extra label (such as a histogram's "le") if extraName is set.
+func (d *desc) writeSample(w *bufio.Writer, suffix string, values []string, extraName, extraValue string, v float64) {
+	w.WriteString(d.name)
+	w.WriteString(suffix)
+	if len(values) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, value := range values {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, d.labels[i], value)
+		}
+		if extraName != "" {
+			if len(values) > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeLabel(w *bufio.Writer, name, value string) {
+	w.WriteString(name)
+	w.WriteString(`="`)
+	labelEscaper.WriteString(w, value)
+	w.WriteByte('"')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// value is a counter or gauge series.
+type value struct {
+	labels []string
+	v      float64
+}
+
+// values holds the series of a counter or gauge.
+type values struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*value
+}
+
+func (vs *values) add(delta float64, labels []string) {
+	key := vs.key(labels)
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	s, ok := vs.series[key]
+	if !ok {
+		s = &value{labels: slices.Clone(labels)}
+		vs.series[key] = s
+	}
+	s.v += delta
+}
+
+func (vs *values) set(v float64, labels []string) {
+	key := vs.key(labels)
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	s, ok := vs.series[key]
+	if !ok {
+		s = &value{labels: slices.Clone(labels)}
+		vs.series[key] = s
+	}
+	s.v = v
+}
+
+func (vs *values) write(w *bufio.Writer) {
+	vs.writeHeader(w)
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	for _, key := range sortedKeys(vs.series) {
+		s := vs.series[key]
+		vs.writeSample(w, "", s.labels, "", "", s.v)
+	}
+}
+
+// Counter is a value that only goes up, such as a count of requests.
+type Counter struct {
+	values
+}
+
+// NewCounter registers a counter. It panics if the name is already in use.
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{values{desc: desc{name: name, help: help, typ: "counter", labels: labels}, series: make(map[string]*value)}}
+	register(name, c)
+	return c
+}
+
+// Inc adds one to the series with the given label values.
+func (c *Counter) Inc(labels ...string) {
+	c.add(1, labels)
+}
+
+// Add adds v, which must not be negative, to the series with the given
+// label values.
+func (c *Counter) Add(v float64, labels ...string) {
+	if v < 0 {
+		panic("metrics: counter " + c.name + " cannot decrease")
+	}
+	c.add(v, labels)
+}
+
+// Gauge is a value that goes up and down, such as a number of connections.
+type Gauge struct {
+	values
+}
+
+// NewGauge registers a gauge. It panics if the name is already in use.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{values{desc: desc{name: name, help: help, typ: "gauge", labels: labels}, series: make(map[string]*value)}}
+	register(name, g)
+	return g
+}
+
+// Set sets the series with the given label values to v.
+func (g *Gauge) Set(v float64, labels ...string) {
+	g.set(v, labels)
+}
+
+// Add adds v to the series with the given label values.
+func (g *Gauge) Add(v float64, labels ...string) {
+	g.add(v, labels)
+}
+
+// Inc adds one to the series with the given label values.
+func (g *Gauge) Inc(labels ...string) {
+	g.add(1, labels)
+}
+
+// Dec subtracts one from the series with the given label values.
+func (g *Gauge) Dec(labels ...string) {
+	g.add(-1, labels)
+}
+
+// Histogram counts observations, such as durations, in buckets.
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds,
+// which must be sorted. It panics if the name is already in use.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if !slices.IsSorted(buckets) {
+		panic("metrics: buckets of " + name + " are not sorted")
+	}
+	h := &Histogram{
+		desc:    desc{name: name, help: help, typ: "histogram", labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	register(name, h)
+	return h
+}
+
+// Observe records v in the series with the given label values.
+func (h *Histogram) Observe(v float64, labels ...string) {
+	key := h.key(labels)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{labels: slices.Clone(labels), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.writeHeader(w)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			h.writeSample(w, "_bucket", s.labels, "le", formatFloat(bound), float64(cumulative))
+		}
+		h.writeSample(w, "_bucket", s.labels, "le", "+Inf", float64(s.count))
+		h.writeSample(w, "_sum", s.labels, "", "", s.sum)
+		h.writeSample(w, "_count", s.labels, "", "", float64(s.count))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	c := NewCounter("test_requests_total", "Requests by method.\nSecond line.", "method", "status")
+	c.Inc("GET", "ok")
+	c.Add(2, "GET", "ok")
+	c.Inc("POST", `say "hi"`)
+
+	g := NewGauge("test_connections", "Open connections.")
+	g.Inc()
+	g.Inc()
+	g.Dec()
+
+	h := NewHistogram("test_duration_seconds", "Durations.", []float64{0.1, 1}, "op")
+	h.Observe(0.05, "read")
+	h.Observe(0.1, "read")
+	h.Observe(0.5, "read")
+	h.Observe(5, "read")
+
+	var buf bytes.Buffer
+	if err := Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# HELP test_requests_total Requests by method.\\nSecond line.\n# TYPE test_requests_total counter\n" +
+			`test_requests_total{method="GET",status="ok"} 3` + "\n" +
+			`test_requests_total{method="POST",status="say \"hi\""} 1` + "\n",
+		"# TYPE test_connections gauge\ntest_connections 1\n",
+		"# TYPE test_duration_seconds histogram\n" +
+			`test_duration_seconds_bucket{op="read",le="0.1"} 2` + "\n" +
+			`test_duration_seconds_bucket{op="read",le="1"} 3` + "\n" +
+			`test_duration_seconds_bucket{op="read",le="+Inf"} 4` + "\n" +
+			`test_duration_seconds_sum{op="read"} 5.65` + "\n" +
+			`test_duration_seconds_count{op="read"} 4` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing:\n%s\ngot:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "test_connections") > strings.Index(out, "test_requests_total") {
+		t.Errorf("metrics not sorted by name:\n%s", out)
+	}
+
+	buf.Reset()
+	WriteGauge(&buf, "test_scraped", "Computed at scrape time.", 7)
+	if want := "# HELP test_scraped Computed at scrape time.\n# TYPE test_scraped gauge\ntest_scraped 7\n"; buf.String() != want {
+		t.Errorf("WriteGauge = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestMisuse(t *testing.T) {
+	c := NewCounter("test_misuse_total", "Misuse.", "a")
+	for name, fn := range map[string]func(){
+		"duplicate":        func() { NewCounter("test_misuse_total", "Again.") },
+		"label count":      func() { c.Inc("x", "y") },
+		"negative counter": func() { c.Add(-1, "x") },
+		"unsorted buckets": func() { NewHistogram("test_unsorted", "Unsorted.", []float64{2, 1}) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
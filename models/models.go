@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"shelley.exe.dev/db"
@@ -15,6 +16,8 @@ import (
 	"shelley.exe.dev/llm"
 	"shelley.exe.dev/llm/codex"
 	"shelley.exe.dev/llm/llmhttp"
+	"shelley.exe.dev/metrics"
+	"shelley.exe.dev/redact"
 )
 
 // Provider represents an LLM provider
@@ -60,6 +63,9 @@ type Model struct {
 	// OAuthFallback indicates this model can also be exposed through OAuth credentials.
 	OAuthFallback string
 
+	// Capabilities describes what the model can handle (see ModelSpec.Capabilities)
+	Capabilities llm.Capabilities
+
 	// Factory creates an llm.Service instance for this model
 	Factory func(config *Config, httpc *http.Client) (llm.Service, error)
 }
@@ -122,6 +128,20 @@ type Config struct {
 
 	// Database for recording LLM requests (optional)
 	DB *db.DB
+
+	// Pricing overrides model prices by model ID (optional)
+	Pricing map[string]llm.Pricing
+
+	// Redactor removes secrets from recorded LLM requests (optional)
+	Redactor *redact.Redactor
+}
+
+// pricingFor returns the pricing for spec, preferring a configured override.
+func (c *Config) pricingFor(spec ModelSpec) *llm.Pricing {
+	if p, ok := c.Pricing[spec.ID]; ok {
+		return &p
+	}
+	return spec.Pricing
 }
 
 // getAnthropicURL returns the Anthropic API URL, with gateway suffix if gateway is set
@@ -203,6 +223,8 @@ type serviceEntry struct {
 	source      string // Human-readable source (e.g., "exe.dev gateway", "$ANTHROPIC_API_KEY")
 	displayName string // For custom models, the user-provided display name
 	tags        string // For custom models, user-provided tags
+
+	capabilities llm.Capabilities
 }
 
 // ConfigInfo is an optional interface that services can implement to provide configuration details for logging
@@ -220,6 +242,48 @@ type loggingService struct {
 	db       *db.DB
 }
 
+var (
+	llmRequests        = metrics.NewCounter("shelley_llm_requests_total", "LLM requests by model, provider and status (ok, error or canceled).", "model", "provider", "status")
+	llmRequestDuration = metrics.NewHistogram("shelley_llm_request_duration_seconds", "Time to complete an LLM request, including streaming the response.", metrics.LongDurationBuckets, "model", "provider")
+	llmFirstToken      = metrics.NewHistogram("shelley_llm_first_token_seconds", "Time from sending a streaming LLM request to its first text or thinking.", metrics.LongDurationBuckets, "model", "provider")
+	llmTokens          = metrics.NewCounter("shelley_llm_tokens_total", "Tokens used by LLM requests by model and type (input, output, cache_read, cache_creation, reasoning).", "model", "type")
+	llmCost            = metrics.NewCounter("shelley_llm_cost_usd_total", "Cost of LLM requests in US dollars by model.", "model")
+)
+
+// recordMetrics updates the LLM request metrics after a request.
+func (l *loggingService) recordMetrics(duration time.Duration, response *llm.Response, err error) {
+	status := "ok"
+	if errors.Is(err, context.Canceled) {
+		status = "canceled"
+	} else if err != nil {
+		status = "error"
+	}
+	provider := string(l.provider)
+	llmRequests.Inc(l.modelID, provider, status)
+	llmRequestDuration.Observe(duration.Seconds(), l.modelID, provider)
+	if err != nil || response == nil {
+		return
+	}
+	u := response.Usage
+	for _, tokens := range []struct {
+		typ string
+		n   uint64
+	}{
+		{"input", u.InputTokens},
+		{"output", u.OutputTokens},
+		{"cache_read", u.CacheReadInputTokens},
+		{"cache_creation", u.CacheCreationInputTokens},
+		{"reasoning", u.ReasoningTokens},
+	} {
+		if tokens.n > 0 {
+			llmTokens.Add(float64(tokens.n), l.modelID, tokens.typ)
+		}
+	}
+	if u.CostUSD > 0 {
+		llmCost.Add(u.CostUSD, l.modelID)
+	}
+}
+
 // Do wraps the underlying service's Do method with logging and database recording
 func (l *loggingService) Do(ctx context.Context, request *llm.Request) (*llm.Response, error) {
 	start := time.Now()
@@ -233,6 +297,7 @@ func (l *loggingService) Do(ctx context.Context, request *llm.Request) (*llm.Res
 
 	duration := time.Since(start)
 	durationSeconds := duration.Seconds()
+	l.recordMetrics(duration, response, err)
 
 	// Log the completion with usage information
 	if err != nil {
@@ -309,6 +374,27 @@ func (l *loggingService) DoStreamWithThinking(ctx context.Context, request *llm.
 	ctx = llmhttp.WithModelID(ctx, l.modelID)
 	ctx = llmhttp.WithProvider(ctx, string(l.provider))
 
+	var firstToken sync.Once
+	observeFirstToken := func() {
+		firstToken.Do(func() {
+			llmFirstToken.Observe(time.Since(start).Seconds(), l.modelID, string(l.provider))
+		})
+	}
+	if onText != nil {
+		next := onText
+		onText = func(text string) {
+			observeFirstToken()
+			next(text)
+		}
+	}
+	if onThinking != nil {
+		next := onThinking
+		onThinking = func(text string) {
+			observeFirstToken()
+			next(text)
+		}
+	}
+
 	var (
 		response *llm.Response
 		err      error
@@ -324,6 +410,7 @@ func (l *loggingService) DoStreamWithThinking(ctx context.Context, request *llm.
 
 	duration := time.Since(start)
 	durationSeconds := duration.Seconds()
+	l.recordMetrics(duration, response, err)
 
 	if err != nil {
 		l.logger.Error("LLM streaming request failed",
@@ -417,7 +504,7 @@ func NewManager(cfg *Config) (*Manager, error) {
 				}
 			}()
 		}
-		httpc = llmhttp.NewClient(nil, recorder)
+		httpc = llmhttp.NewClient(nil, llmhttp.Redacting(recorder, cfg.Redactor))
 	} else {
 		// Still use the custom transport for headers, just without recording
 		httpc = llmhttp.NewClient(nil, nil)
@@ -452,12 +539,13 @@ func (m *Manager) loadBuiltInModels() {
 		svc, err := model.Factory(m.cfg, m.httpc)
 		if err == nil {
 			m.services[model.ID] = serviceEntry{
-				service:     svc,
-				provider:    model.Provider,
-				modelID:     model.ID,
-				source:      model.Source(m.cfg),
-				displayName: model.ID,
-				tags:        model.Tags,
+				service:      svc,
+				provider:     model.Provider,
+				modelID:      model.ID,
+				source:       model.Source(m.cfg),
+				displayName:  model.ID,
+				tags:         model.Tags,
+				capabilities: model.Capabilities,
 			}
 			m.modelOrder = append(m.modelOrder, model.ID)
 		}
@@ -473,12 +561,13 @@ func (m *Manager) loadBuiltInModels() {
 
 		oauthModelID := model.ID + "-oauth"
 		m.services[oauthModelID] = serviceEntry{
-			service:     oauthService,
-			provider:    model.Provider,
-			modelID:     oauthModelID,
-			source:      "OAuth",
-			displayName: oauthModelID,
-			tags:        model.Tags,
+			service:      oauthService,
+			provider:     model.Provider,
+			modelID:      oauthModelID,
+			source:       "OAuth",
+			displayName:  oauthModelID,
+			tags:         model.Tags,
+			capabilities: model.Capabilities,
 		}
 		m.modelOrder = append(m.modelOrder, oauthModelID)
 	}
@@ -508,12 +597,13 @@ func (m *Manager) loadCustomModels() error {
 		}
 
 		m.services[model.ModelID] = serviceEntry{
-			service:     svc,
-			provider:    Provider(model.ProviderType),
-			modelID:     model.ModelID,
-			source:      string(SourceCustom),
-			displayName: model.DisplayName,
-			tags:        model.Tags,
+			service:      svc,
+			provider:     Provider(model.ProviderType),
+			modelID:      model.ModelID,
+			source:       string(SourceCustom),
+			displayName:  model.DisplayName,
+			tags:         model.Tags,
+			capabilities: CustomModelCapabilities(&model),
 		}
 		m.modelOrder = append(m.modelOrder, model.ModelID)
 	}
@@ -561,6 +651,16 @@ func (m *Manager) HasModel(modelID string) bool {
 	return ok
 }
 
+// ModelCapabilities returns the capabilities of the given model.
+// It reports false for unknown models.
+func (m *Manager) ModelCapabilities(modelID string) (llm.Capabilities, bool) {
+	entry, ok := m.services[modelID]
+	if !ok {
+		return llm.Capabilities{}, false
+	}
+	return entry.capabilities, true
+}
+
 // ModelInfo contains display name, tags, and source for a model
 type ModelInfo struct {
 	DisplayName string
@@ -584,6 +684,7 @@ func (m *Manager) GetModelInfo(modelID string) *ModelInfo {
 // createServiceFromModel creates an LLM service from a database model configuration
 func (m *Manager) createServiceFromModel(model *generated.Model) llm.Service {
 	if spec, ok := customModelSpec(model); ok {
+		spec.Pricing = m.cfg.pricingFor(spec)
 		svc, err := newCustomService(spec, model.ApiKey, m.httpc)
 		if err != nil {
 			if m.logger != nil {
@@ -1,9 +1,11 @@
 package models
 
 import (
+	"bytes"
 	"context"
 	"log/slog"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"shelley.exe.dev/db"
 	"shelley.exe.dev/db/generated"
 	"shelley.exe.dev/llm"
+	"shelley.exe.dev/metrics"
 )
 
 func TestAll(t *testing.T) {
@@ -51,6 +54,127 @@ func TestRegistryHasUniqueIDs(t *testing.T) {
 	}
 }
 
+func TestRegistryPricing(t *testing.T) {
+	for _, spec := range Registry() {
+		if spec.Provider == ProviderBuiltIn {
+			continue // free
+		}
+		if spec.Pricing.IsZero() {
+			t.Errorf("registry spec %q has no pricing", spec.ID)
+		}
+	}
+
+	var spec ModelSpec
+	for _, s := range Registry() {
+		if s.ID == "claude-sonnet-4.6" {
+			spec = s
+		}
+	}
+	if spec.ID == "" {
+		t.Fatal("claude-sonnet-4.6 not in registry")
+	}
+	cfg := &Config{}
+	if got := cfg.pricingFor(spec); got != spec.Pricing {
+		t.Errorf("pricingFor without override = %+v, want built-in pricing", got)
+	}
+	override := llm.Pricing{Prices: llm.Prices{Input: 1, Output: 2}}
+	cfg.Pricing = map[string]llm.Pricing{spec.ID: override}
+	if got := cfg.pricingFor(spec); got == nil || got.Prices != override.Prices || len(got.Tiers) != 0 {
+		t.Errorf("pricingFor with override = %+v, want %+v", got, override)
+	}
+}
+
+func TestManagerModelCapabilities(t *testing.T) {
+	manager, err := NewManager(&Config{
+		AnthropicAPIKey: "test-key",
+		OpenAIAPIKey:    "test-key",
+		GeminiAPIKey:    "test-key",
+		FireworksAPIKey: "test-key",
+	})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	tests := []struct {
+		modelID    string
+		simplified bool
+		images     bool
+	}{
+		{modelID: "claude-opus-4.6", simplified: false, images: true},
+		{modelID: "claude-haiku-4.5", simplified: true, images: true},
+		{modelID: "gpt-5.4", simplified: false, images: true},
+		{modelID: "gemini-3-pro", simplified: false, images: true},
+		{modelID: "gpt-oss-20b-fireworks", simplified: true, images: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.modelID, func(t *testing.T) {
+			caps, ok := manager.ModelCapabilities(tt.modelID)
+			if !ok {
+				t.Fatalf("ModelCapabilities(%q) not found", tt.modelID)
+			}
+			if !caps.Tools || !caps.ParallelToolCalls {
+				t.Errorf("expected tools and parallel tool calls, got %+v", caps)
+			}
+			if caps.SimplifiedPatch != tt.simplified {
+				t.Errorf("SimplifiedPatch = %v, want %v", caps.SimplifiedPatch, tt.simplified)
+			}
+			if caps.Images != tt.images {
+				t.Errorf("Images = %v, want %v", caps.Images, tt.images)
+			}
+		})
+	}
+
+	if _, ok := manager.ModelCapabilities("no-such-model"); ok {
+		t.Error("expected unknown model to have no capabilities")
+	}
+}
+
+func TestCustomModelCapabilities(t *testing.T) {
+	model := &generated.Model{ModelID: "custom-caps", ProviderType: "openai", ModelName: "m"}
+	if got := CustomModelCapabilities(model); got != DefaultCapabilities("openai") {
+		t.Errorf("CustomModelCapabilities() = %+v, want provider defaults", got)
+	}
+	if DefaultCapabilities("codex").SimplifiedPatch {
+		t.Error("expected codex models to default to the full patch schema")
+	}
+
+	caps := `{"tools":true,"images":false,"simplified_patch":false,"parallel_tool_calls":false,"max_tools":8}`
+	model.Capabilities = &caps
+	want := llm.Capabilities{Tools: true, MaxTools: 8}
+	if got := CustomModelCapabilities(model); got != want {
+		t.Errorf("CustomModelCapabilities() = %+v, want %+v", got, want)
+	}
+	spec, ok := customModelSpec(model)
+	if !ok {
+		t.Fatal("customModelSpec failed")
+	}
+	if spec.Capabilities() != want {
+		t.Errorf("spec.Capabilities() = %+v, want %+v", spec.Capabilities(), want)
+	}
+}
+
+func TestCustomModelSpecPricing(t *testing.T) {
+	pricing := `{"input":1.5,"output":6,"cache_read":0.15}`
+	spec, ok := customModelSpec(&generated.Model{
+		ModelID:      "custom-priced",
+		ProviderType: "anthropic",
+		ModelName:    "claude-test",
+		Pricing:      &pricing,
+	})
+	if !ok {
+		t.Fatal("customModelSpec failed")
+	}
+	want := llm.Prices{Input: 1.5, Output: 6, CacheRead: 0.15}
+	if spec.Pricing == nil || spec.Pricing.Prices != want {
+		t.Errorf("Pricing = %+v, want %+v", spec.Pricing, want)
+	}
+
+	spec, _ = customModelSpec(&generated.Model{ModelID: "custom-unpriced", ProviderType: "anthropic", ModelName: "claude-test"})
+	if spec.Pricing != nil {
+		t.Errorf("Pricing = %+v, want nil", spec.Pricing)
+	}
+}
+
 func TestRegistryContextWindowsFlowIntoServices(t *testing.T) {
 	cfg := &Config{
 		AnthropicAPIKey: "test-key",
@@ -649,3 +773,36 @@ func TestPreferredToolModelsAreRegistered(t *testing.T) {
 		}
 	}
 }
+
+func TestLoggingServiceMetrics(t *testing.T) {
+	manager, err := NewManager(&Config{Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	svc, err := manager.GetService("predictable")
+	if err != nil {
+		t.Fatalf("GetService failed: %v", err)
+	}
+	req := &llm.Request{Messages: []llm.Message{{
+		Role:    llm.MessageRoleUser,
+		Content: []llm.Content{{Type: llm.ContentTypeText, Text: "echo: metrics"}},
+	}}}
+	if _, err := svc.Do(t.Context(), req); err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if _, err := svc.(llm.StreamingService).DoStream(t.Context(), req, func(string) {}); err != nil {
+		t.Fatalf("DoStream failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	metrics.Write(&buf)
+	for _, want := range []string{
+		`shelley_llm_requests_total{model="predictable",provider="builtin",status="ok"} 2`,
+		`shelley_llm_request_duration_seconds_count{model="predictable",provider="builtin"} 2`,
+		`shelley_llm_tokens_total{model="predictable",type="input"}`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("metrics missing %s:\n%s", want, buf.String())
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -45,9 +46,45 @@ type ModelSpec struct {
 	SupportsReasoning   bool
 	SupportsTools       bool
 	SupportsImages      bool
-	APIKeyEnv           string
-	Endpoint            string
-	PatchBehavior       PatchBehavior
+	// SupportsParallelToolCalls reports whether the model handles several tool calls per response.
+	SupportsParallelToolCalls bool
+	// MaxImageDimension overrides the service's image size limit; 0 keeps it.
+	MaxImageDimension int
+	// MaxTools caps the number of tools offered to the model; 0 means no limit.
+	MaxTools      int
+	APIKeyEnv     string
+	Endpoint      string
+	PatchBehavior PatchBehavior
+	// Pricing computes request costs when no gateway reports them; nil if unknown.
+	Pricing *llm.Pricing
+}
+
+// Capabilities returns the capability descriptor tool sets use for this model.
+func (s ModelSpec) Capabilities() llm.Capabilities {
+	return llm.Capabilities{
+		Tools:             s.SupportsTools,
+		Images:            s.SupportsImages,
+		Reasoning:         s.SupportsReasoning,
+		SimplifiedPatch:   s.PatchBehavior == PatchBehaviorSimplified,
+		ParallelToolCalls: s.SupportsParallelToolCalls,
+		MaxImageDimension: s.MaxImageDimension,
+		MaxTools:          s.MaxTools,
+	}
+}
+
+// withCapabilities returns spec with its capability fields set from c.
+func (s ModelSpec) withCapabilities(c llm.Capabilities) ModelSpec {
+	s.SupportsTools = c.Tools
+	s.SupportsImages = c.Images
+	s.SupportsReasoning = c.Reasoning
+	s.SupportsParallelToolCalls = c.ParallelToolCalls
+	s.MaxImageDimension = c.MaxImageDimension
+	s.MaxTools = c.MaxTools
+	s.PatchBehavior = PatchBehaviorDefault
+	if c.SimplifiedPatch {
+		s.PatchBehavior = PatchBehaviorSimplified
+	}
+	return s
 }
 
 func Registry() []ModelSpec {
@@ -67,6 +104,7 @@ func builtInModels() []Model {
 			RequiredEnvVars: append([]string(nil), spec.RequiredEnvVars...),
 			GatewayEnabled:  spec.GatewayEnabled,
 			OAuthFallback:   spec.OAuthFallback,
+			Capabilities:    spec.Capabilities(),
 			Factory: func(config *Config, httpc *http.Client) (llm.Service, error) {
 				return newBuiltInService(spec, config, httpc)
 			},
@@ -76,6 +114,7 @@ func builtInModels() []Model {
 }
 
 func newBuiltInService(spec ModelSpec, config *Config, httpc *http.Client) (llm.Service, error) {
+	pricing := config.pricingFor(spec)
 	switch spec.Transport {
 	case TransportAnthropic:
 		apiKey := config.AnthropicAPIKey
@@ -88,6 +127,7 @@ func newBuiltInService(spec ModelSpec, config *Config, httpc *http.Client) (llm.
 			HTTPC:               httpc,
 			ThinkingLevel:       llm.ThinkingLevelMedium,
 			ContextWindowTokens: spec.ContextWindowTokens,
+			Pricing:             pricing,
 		}
 		if url := config.getAnthropicURL(); url != "" {
 			svc.URL = url
@@ -115,6 +155,7 @@ func newBuiltInService(spec ModelSpec, config *Config, httpc *http.Client) (llm.
 			ModelURL: endpoint,
 			Model:    model,
 			HTTPC:    httpc,
+			Pricing:  pricing,
 		}, nil
 	case TransportOpenAIResponse:
 		apiKey, endpoint, err := openAIServiceConfig(spec, config)
@@ -139,6 +180,7 @@ func newBuiltInService(spec ModelSpec, config *Config, httpc *http.Client) (llm.
 			HTTPC:         httpc,
 			ModelURL:      endpoint,
 			ThinkingLevel: llm.ThinkingLevelMedium,
+			Pricing:       pricing,
 		}, nil
 	case TransportGemini:
 		apiKey := config.GeminiAPIKey
@@ -150,6 +192,7 @@ func newBuiltInService(spec ModelSpec, config *Config, httpc *http.Client) (llm.
 			Model:               spec.ModelName,
 			HTTPC:               httpc,
 			ContextWindowTokens: spec.ContextWindowTokens,
+			Pricing:             pricing,
 		}
 		if url := config.getGeminiURL(); url != "" {
 			svc.URL = url
@@ -179,7 +222,49 @@ func openAIServiceConfig(spec ModelSpec, config *Config) (apiKey string, endpoin
 	}
 }
 
+// DefaultCapabilities returns the capabilities assumed for a custom model of the
+// given provider type when the user has not set them.
+func DefaultCapabilities(providerType string) llm.Capabilities {
+	c := llm.DefaultCapabilities
+	switch providerType {
+	case "anthropic", "openai-responses", "gemini":
+		c.Reasoning = true
+	case "codex":
+		// Codex models are GPT-5 family models, which handle the full patch schema.
+		c.Reasoning = true
+		c.SimplifiedPatch = false
+	}
+	return c
+}
+
+// CustomModelCapabilities returns the capabilities stored for a custom model,
+// or the provider defaults if none are stored.
+func CustomModelCapabilities(model *generated.Model) llm.Capabilities {
+	if model.Capabilities != nil {
+		var c llm.Capabilities
+		if err := json.Unmarshal([]byte(*model.Capabilities), &c); err == nil {
+			return c
+		}
+	}
+	return DefaultCapabilities(model.ProviderType)
+}
+
 func customModelSpec(model *generated.Model) (ModelSpec, bool) {
+	spec, ok := customModelTransportSpec(model)
+	if !ok {
+		return spec, false
+	}
+	spec = spec.withCapabilities(CustomModelCapabilities(model))
+	if model.Pricing != nil {
+		var p llm.Pricing
+		if err := json.Unmarshal([]byte(*model.Pricing), &p); err == nil && !p.IsZero() {
+			spec.Pricing = &p
+		}
+	}
+	return spec, ok
+}
+
+func customModelTransportSpec(model *generated.Model) (ModelSpec, bool) {
 	switch model.ProviderType {
 	case "anthropic":
 		return ModelSpec{
@@ -244,6 +329,7 @@ func newCustomService(spec ModelSpec, apiKey string, httpc *http.Client) (llm.Se
 			HTTPC:               httpc,
 			ThinkingLevel:       llm.ThinkingLevelMedium,
 			ContextWindowTokens: spec.ContextWindowTokens,
+			Pricing:             spec.Pricing,
 		}, nil
 	case TransportOpenAI:
 		return &oai.Service{
@@ -257,6 +343,7 @@ func newCustomService(spec ModelSpec, apiKey string, httpc *http.Client) (llm.Se
 			},
 			MaxTokens: spec.MaxOutputTokens,
 			HTTPC:     httpc,
+			Pricing:   spec.Pricing,
 		}, nil
 	case TransportOpenAIResponse:
 		return &oai.ResponsesService{
@@ -271,6 +358,7 @@ func newCustomService(spec ModelSpec, apiKey string, httpc *http.Client) (llm.Se
 			MaxTokens:     spec.MaxOutputTokens,
 			HTTPC:         httpc,
 			ThinkingLevel: llm.ThinkingLevelMedium,
+			Pricing:       spec.Pricing,
 		}, nil
 	case TransportGemini:
 		return &gem.Service{
@@ -279,6 +367,7 @@ func newCustomService(spec ModelSpec, apiKey string, httpc *http.Client) (llm.Se
 			Model:               spec.ModelName,
 			HTTPC:               httpc,
 			ContextWindowTokens: spec.ContextWindowTokens,
+			Pricing:             spec.Pricing,
 		}, nil
 	default:
 		return nil, fmt.Errorf("unsupported custom transport %q for model %s", spec.Transport, spec.ID)
@@ -300,8 +389,10 @@ func anthropicSpec(id, displayName, modelName, tags string, maxOutputTokens int)
 		SupportsReasoning:   true,
 		SupportsTools:       true,
 		SupportsImages:      true,
-		APIKeyEnv:           ant.APIKeyEnv,
-		Endpoint:            ant.DefaultURL,
+
+		SupportsParallelToolCalls: true,
+		APIKeyEnv:                 ant.APIKeyEnv,
+		Endpoint:                  ant.DefaultURL,
 	}
 }
 
@@ -324,9 +415,11 @@ func openAIResponseSpec(id, displayName string, model oai.Model, tags string) Mo
 		SupportsReasoning:   true,
 		SupportsTools:       true,
 		SupportsImages:      true,
-		APIKeyEnv:           oai.OpenAIAPIKeyEnv,
-		Endpoint:            model.URL,
-		PatchBehavior:       patchBehavior,
+
+		SupportsParallelToolCalls: true,
+		APIKeyEnv:                 oai.OpenAIAPIKeyEnv,
+		Endpoint:                  model.URL,
+		PatchBehavior:             patchBehavior,
 	}
 }
 
@@ -349,9 +442,11 @@ func openAICompatSpec(id, displayName string, provider Provider, model oai.Model
 		SupportsReasoning:   model.IsReasoningModel,
 		SupportsTools:       true,
 		SupportsImages:      true,
-		APIKeyEnv:           model.APIKeyEnv,
-		Endpoint:            model.URL,
-		PatchBehavior:       patchBehavior,
+
+		SupportsParallelToolCalls: true,
+		APIKeyEnv:                 model.APIKeyEnv,
+		Endpoint:                  model.URL,
+		PatchBehavior:             patchBehavior,
 	}
 }
 
@@ -368,24 +463,51 @@ func geminiSpec(id, displayName, modelName, tags string, contextWindowTokens int
 		SupportsReasoning:   true,
 		SupportsTools:       true,
 		SupportsImages:      true,
-		APIKeyEnv:           gem.GeminiAPIKeyEnv,
+
+		SupportsParallelToolCalls: true,
+		APIKeyEnv:                 gem.GeminiAPIKeyEnv,
 	}
 }
 
+// List prices in USD per million tokens, as published by each provider.
+// They can be overridden per model with "pricing" in shelley.json.
+var (
+	opusPricing   = llm.Pricing{Prices: llm.Prices{Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25}}
+	sonnetPricing = llm.Pricing{
+		Prices: llm.Prices{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		Tiers: []llm.PricingTier{
+			{AboveInputTokens: 200_000, Prices: llm.Prices{Input: 6, Output: 22.5, CacheRead: 0.6, CacheWrite: 7.5}},
+		},
+	}
+	haikuPricing      = llm.Pricing{Prices: llm.Prices{Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25}}
+	gpt54Pricing      = llm.Pricing{Prices: llm.Prices{Input: 2.5, Output: 15, CacheRead: 0.25}}
+	gpt5CodexPricing  = llm.Pricing{Prices: llm.Prices{Input: 1.75, Output: 14, CacheRead: 0.175}}
+	glm47Pricing      = llm.Pricing{Prices: llm.Prices{Input: 0.6, Output: 2.2}}
+	glm46Pricing      = llm.Pricing{Prices: llm.Prices{Input: 0.55, Output: 2.19}}
+	gptOSS20BPricing  = llm.Pricing{Prices: llm.Prices{Input: 0.07, Output: 0.3}}
+	gemini3ProPricing = llm.Pricing{
+		Prices: llm.Prices{Input: 2, Output: 12, CacheRead: 0.2},
+		Tiers: []llm.PricingTier{
+			{AboveInputTokens: 200_000, Prices: llm.Prices{Input: 4, Output: 18, CacheRead: 0.4}},
+		},
+	}
+	gemini3FlashPricing = llm.Pricing{Prices: llm.Prices{Input: 0.5, Output: 3, CacheRead: 0.05}}
+)
+
 var builtInModelSpecs = []ModelSpec{
-	anthropicSpec("claude-opus-4.6", "Claude Opus 4.6 (default)", ant.Claude46Opus, "", 128000),
-	anthropicSpec("claude-opus-4.5", "Claude Opus 4.5", ant.Claude45Opus, "", 128000),
-	anthropicSpec("claude-sonnet-4.6", "Claude Sonnet 4.6", ant.Claude46Sonnet, "", 64000),
-	anthropicSpec("claude-sonnet-4.5", "Claude Sonnet 4.5", ant.Claude45Sonnet, "", 64000),
-	anthropicSpec("claude-haiku-4.5", "Claude Haiku 4.5", ant.Claude45Haiku, "slug-backup", 64000),
-	openAICompatSpec("glm-4.7-fireworks", "GLM-4.7 on Fireworks", ProviderFireworks, oai.GLM47Fireworks, "", true),
-	withOAuthFallback(openAIResponseSpec("gpt-5.4", "GPT-5.4", oai.GPT54, ""), "codex"),
-	withOAuthFallback(openAIResponseSpec("gpt-5.3-codex", "GPT-5.3 Codex", oai.GPT53Codex, ""), "codex"),
-	withOAuthFallback(openAIResponseSpec("gpt-5.2-codex", "GPT-5.2 Codex", oai.GPT52Codex, ""), "codex"),
-	openAICompatSpec("gpt-oss-20b-fireworks", "GPT-OSS 20B on Fireworks", ProviderFireworks, oai.GPTOSS20B, "slug", true),
-	openAICompatSpec("glm-4p6-fireworks", "GLM-4P6 on Fireworks", ProviderFireworks, oai.GLM4P6Fireworks, "", false),
-	geminiSpec("gemini-3-pro", "Gemini 3 Pro", "gemini-3-pro-preview", "", 1000000),
-	geminiSpec("gemini-3-flash", "Gemini 3 Flash", "gemini-3-flash-preview", "", 1000000),
+	withPricing(anthropicSpec("claude-opus-4.6", "Claude Opus 4.6 (default)", ant.Claude46Opus, "", 128000), opusPricing),
+	withPricing(anthropicSpec("claude-opus-4.5", "Claude Opus 4.5", ant.Claude45Opus, "", 128000), opusPricing),
+	withPricing(anthropicSpec("claude-sonnet-4.6", "Claude Sonnet 4.6", ant.Claude46Sonnet, "", 64000), sonnetPricing),
+	withPricing(anthropicSpec("claude-sonnet-4.5", "Claude Sonnet 4.5", ant.Claude45Sonnet, "", 64000), sonnetPricing),
+	withPricing(withSimplifiedPatch(anthropicSpec("claude-haiku-4.5", "Claude Haiku 4.5", ant.Claude45Haiku, "slug-backup", 64000)), haikuPricing),
+	withPricing(withTextOnly(withSimplifiedPatch(openAICompatSpec("glm-4.7-fireworks", "GLM-4.7 on Fireworks", ProviderFireworks, oai.GLM47Fireworks, "", true))), glm47Pricing),
+	withPricing(withOAuthFallback(openAIResponseSpec("gpt-5.4", "GPT-5.4", oai.GPT54, ""), "codex"), gpt54Pricing),
+	withPricing(withOAuthFallback(openAIResponseSpec("gpt-5.3-codex", "GPT-5.3 Codex", oai.GPT53Codex, ""), "codex"), gpt5CodexPricing),
+	withPricing(withOAuthFallback(openAIResponseSpec("gpt-5.2-codex", "GPT-5.2 Codex", oai.GPT52Codex, ""), "codex"), gpt5CodexPricing),
+	withPricing(withTextOnly(withSimplifiedPatch(openAICompatSpec("gpt-oss-20b-fireworks", "GPT-OSS 20B on Fireworks", ProviderFireworks, oai.GPTOSS20B, "slug", true))), gptOSS20BPricing),
+	withPricing(withTextOnly(withSimplifiedPatch(openAICompatSpec("glm-4p6-fireworks", "GLM-4P6 on Fireworks", ProviderFireworks, oai.GLM4P6Fireworks, "", false))), glm46Pricing),
+	withPricing(geminiSpec("gemini-3-pro", "Gemini 3 Pro", "gemini-3-pro-preview", "", 1000000), gemini3ProPricing),
+	withPricing(withSimplifiedPatch(geminiSpec("gemini-3-flash", "Gemini 3 Flash", "gemini-3-flash-preview", "", 1000000)), gemini3FlashPricing),
 	{
 		ID:              "predictable",
 		Provider:        ProviderBuiltIn,
@@ -394,8 +516,10 @@ var builtInModelSpecs = []ModelSpec{
 		GatewayEnabled:  true,
 		SupportsTools:   true,
 		SupportsImages:  true,
-		PatchBehavior:   PatchBehaviorDefault,
+		PatchBehavior:   PatchBehaviorSimplified,
 		RequiredEnvVars: []string{},
+
+		SupportsParallelToolCalls: true,
 	},
 }
 
@@ -403,3 +527,18 @@ func withOAuthFallback(spec ModelSpec, provider string) ModelSpec {
 	spec.OAuthFallback = provider
 	return spec
 }
+
+func withSimplifiedPatch(spec ModelSpec) ModelSpec {
+	spec.PatchBehavior = PatchBehaviorSimplified
+	return spec
+}
+
+func withTextOnly(spec ModelSpec) ModelSpec {
+	spec.SupportsImages = false
+	return spec
+}
+
+func withPricing(spec ModelSpec, pricing llm.Pricing) ModelSpec {
+	spec.Pricing = &pricing
+	return spec
+}
@@ -0,0 +1,188 @@
+// Package redact removes secrets, such as API keys and private keys, from
+// text before it is stored or sent to a model.
+//
+// Each secret is replaced by a placeholder naming the detector that found it
+// and a short hash of the secret, e.g. [REDACTED:github-token:1a2b3c4d], so
+// the same secret always gets the same placeholder and the model can still
+// tell secrets apart.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Config configures redaction. It is the "redaction" section of shelley.json.
+type Config struct {
+	// Disabled turns redaction off entirely.
+	Disabled bool `json:"disabled,omitempty"`
+	// Patterns are additional secrets to redact.
+	Patterns []Pattern `json:"patterns,omitempty"`
+}
+
+// Pattern is a user-defined secret detector.
+type Pattern struct {
+	// Name appears in the placeholder. It defaults to "custom".
+	Name string `json:"name,omitempty"`
+	// Regex matches the secret. If it has a capture group, only the first
+	// group is redacted, so context such as "key=" can be matched but kept.
+	Regex string `json:"regex"`
+}
+
+// detector finds one kind of secret.
+type detector struct {
+	name string
+	re   *regexp.Regexp
+	// group is the submatch holding the secret; 0 is the whole match.
+	group int
+	// check, if set, must accept the secret for it to be redacted.
+	check func(string) bool
+}
+
+// builtins are the detectors that are always enabled. More specific
+// detectors come first, so a secret is named by the most specific one.
+var builtins = []detector{
+	{
+		name: "private-key",
+		re:   regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----[\s\S]*?-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----`),
+	},
+	{
+		name: "aws-access-key",
+		re:   regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`),
+	},
+	{
+		name:  "aws-secret-key",
+		re:    regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|key).{0,20}?[=:]\s*["']?([A-Za-z0-9/+]{40})\b`),
+		group: 1,
+	},
+	{
+		name: "github-token",
+		re:   regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`),
+	},
+	{
+		name: "anthropic-key",
+		re:   regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`),
+	},
+	{
+		name: "openai-key",
+		re:   regexp.MustCompile(`\bsk-(?:proj-|svcacct-|admin-)?[A-Za-z0-9_-]{20,}`),
+	},
+	{
+		// Passwords in URLs, as printed by "git remote -v".
+		name:  "url-password",
+		re:    regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^/\s:@"'\[]+:([^/\s:@"'\[]+)@`),
+		group: 1,
+	},
+	{
+		// Values assigned to secret-sounding names, as in env output and
+		// .env files. Only random-looking values are redacted, so code like
+		// "token = nextToken()" is left alone.
+		name:  "high-entropy",
+		re:    regexp.MustCompile(`(?i)[a-z0-9_.-]*(?:secret|token|passw(?:or)?d|api[_-]?key|access[_-]?key|private[_-]?key|auth|credential)[a-z0-9_.-]*["']?\s*[:=]\s*["']?([A-Za-z0-9/+_.~=-]{12,})`),
+		group: 1,
+		check: looksRandom,
+	},
+}
+
+// Redactor redacts secrets. A nil *Redactor redacts nothing.
+type Redactor struct {
+	detectors []detector
+}
+
+// New returns a Redactor using the built-in detectors and cfg's patterns, or
+// nil if cfg disables redaction.
+func New(cfg Config) (*Redactor, error) {
+	if cfg.Disabled {
+		return nil, nil
+	}
+	r := &Redactor{detectors: append([]detector(nil), builtins...)}
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %q: %w", p.Regex, err)
+		}
+		d := detector{name: p.Name, re: re}
+		if d.name == "" {
+			d.name = "custom"
+		}
+		if re.NumSubexp() > 0 {
+			d.group = 1
+		}
+		r.detectors = append(r.detectors, d)
+	}
+	return r, nil
+}
+
+// Redact returns s with secrets replaced by placeholders, and the detector
+// name for each secret replaced.
+func (r *Redactor) Redact(s string) (string, []string) {
+	if r == nil || s == "" {
+		return s, nil
+	}
+	var found []string
+	for _, d := range r.detectors {
+		matches := d.re.FindAllStringSubmatchIndex(s, -1)
+		if matches == nil {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			start, end := m[2*d.group], m[2*d.group+1]
+			if start < 0 || start == end {
+				continue
+			}
+			secret := s[start:end]
+			if strings.HasPrefix(secret, "[REDACTED:") || (d.check != nil && !d.check(secret)) {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(Placeholder(d.name, secret))
+			last = end
+			found = append(found, d.name)
+		}
+		if last > 0 {
+			b.WriteString(s[last:])
+			s = b.String()
+		}
+	}
+	return s, found
+}
+
+// Placeholder returns the text that replaces secret.
+func Placeholder(name, secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return "[REDACTED:" + name + ":" + hex.EncodeToString(sum[:4]) + "]"
+}
+
+// looksRandom reports whether s is likely a generated secret rather than a
+// word or identifier: it mixes letters and digits and has high entropy.
+func looksRandom(s string) bool {
+	var letters, digits bool
+	for _, c := range s {
+		letters = letters || unicode.IsLetter(c)
+		digits = digits || unicode.IsDigit(c)
+	}
+	return letters && digits && entropy(s) >= 3.5
+}
+
+// entropy returns the Shannon entropy of s in bits per byte.
+func entropy(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	var h float64
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(len(s))
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
@@ -0,0 +1,551 @@
+package server
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"shelley.exe.dev/db"
+	"shelley.exe.dev/db/generated"
+)
+
+// Built-in authentication is off until an admin password is set with
+// "shelley auth set-password". From then on every TCP request needs either a
+// login session cookie, which grants admin scope, or an API token sent as
+// "Authorization: Bearer <token>". The Unix socket stays trusted.
+
+const (
+	// adminPasswordSetting stores the PBKDF2 hash of the admin password. It is
+	// never returned by the settings API.
+	adminPasswordSetting = "admin_password_hash"
+	sessionCookieName    = "shelley_session"
+	sessionTTL           = 30 * 24 * time.Hour
+	// apiTokenPrefix makes tokens easy to recognise, e.g. by secret scanners.
+	apiTokenPrefix = "shelley_"
+	// apiTokenTouchInterval limits how often a token's last-used time is written.
+	apiTokenTouchInterval = time.Minute
+	// loginFailureDelay slows down password guessing.
+	loginFailureDelay = time.Second
+
+	pbkdf2Iterations = 600_000
+)
+
+// AuthScope is what an API token may do.
+type AuthScope string
+
+const (
+	// ScopeRead allows GET and HEAD requests to everything but admin routes.
+	ScopeRead AuthScope = "read"
+	// ScopeChat also allows starting and continuing conversations, and
+	// everything else outside admin routes.
+	ScopeChat AuthScope = "chat"
+	// ScopeAdmin allows everything, like a login session.
+	ScopeAdmin AuthScope = "admin"
+)
+
+// ParseAuthScope validates a scope name.
+func ParseAuthScope(s string) (AuthScope, error) {
+	switch scope := AuthScope(s); scope {
+	case ScopeRead, ScopeChat, ScopeAdmin:
+		return scope, nil
+	}
+	return "", fmt.Errorf("invalid scope %q (want read, chat or admin)", s)
+}
+
+// adminRoutes are path prefixes that need admin scope for any method:
+// server configuration, credentials, and direct shell or file access.
+var adminRoutes = []string{
+	"/api/auth/tokens",
+	"/api/custom-models",
+	"/api/notification-channel",
+	"/api/skills",
+	"/api/codex-auth/",
+	"/api/exec-ws",
+	"/api/write-file",
+	"/settings",
+	"/upgrade",
+	"/exit",
+	"/debug/",
+}
+
+// Allows reports whether a request with this scope may be made.
+func (s AuthScope) Allows(method, path string) bool {
+	if s == ScopeAdmin {
+		return true
+	}
+	for _, prefix := range adminRoutes {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	readOnly := method == http.MethodGet || method == http.MethodHead
+	// Lifting a conversation's sandbox would undo the confinement of its
+	// shell commands.
+	if strings.HasPrefix(path, "/api/conversation/") && strings.HasSuffix(path, "/sandbox") && !readOnly {
+		return false
+	}
+	if s == ScopeChat {
+		return true
+	}
+	return s == ScopeRead && readOnly
+}
+
+// authExempt reports whether a request is served without authentication:
+// the login page itself, and notification replies, which carry their own
+// token.
+func authExempt(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/login", "/logout", "/api/auth/status", "/notifications/reply", "/notifications/email-reply":
+		return true
+	}
+	return false
+}
+
+// HashPassword returns an encoded PBKDF2-SHA256 hash of password.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, pbkdf2Iterations, 32)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", pbkdf2Iterations, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+// checkPassword reports whether password matches a hash from HashPassword.
+func checkPassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := enc.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	return err == nil && subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// SetAdminPassword sets the admin password, turning on authentication, and
+// ends all login sessions. An empty password turns authentication off.
+func SetAdminPassword(ctx context.Context, database *db.DB, password string) error {
+	hash := ""
+	if password != "" {
+		var err error
+		if hash, err = HashPassword(password); err != nil {
+			return err
+		}
+	}
+	if err := database.SetSetting(ctx, adminPasswordSetting, hash); err != nil {
+		return err
+	}
+	return database.DeleteAllAuthSessions(ctx)
+}
+
+// hashSecret returns the hex SHA-256 of a token or session ID. These are
+// random, so a plain hash is enough to keep them out of the database.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CreateAPIToken creates an API token and returns the secret to give to the
+// client, which is not stored. A zero ttl means the token never expires.
+func CreateAPIToken(ctx context.Context, database *db.DB, name string, scope AuthScope, ttl time.Duration) (string, *generated.ApiToken, error) {
+	if _, err := ParseAuthScope(string(scope)); err != nil {
+		return "", nil, err
+	}
+	secret, err := randomSecret()
+	if err != nil {
+		return "", nil, err
+	}
+	secret = apiTokenPrefix + secret
+	var expiresIn *string
+	if ttl > 0 {
+		delay := sqliteDelay(ttl)
+		expiresIn = &delay
+	}
+	token, err := database.CreateAPIToken(ctx, generated.CreateAPITokenParams{
+		TokenID:   "tok-" + uuid.New().String()[:8],
+		Name:      name,
+		TokenHash: hashSecret(secret),
+		Scope:     string(scope),
+		ExpiresIn: expiresIn,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return secret, token, nil
+}
+
+// ParseTokenTTL parses a token lifetime such as "90d", "12h" or "never".
+func ParseTokenTTL(s string) (time.Duration, error) {
+	switch s {
+	case "", "0", "never":
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid token lifetime %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid token lifetime %q", s)
+	}
+	return d, nil
+}
+
+// authEnabled reports whether an admin password has been set.
+func (s *Server) authEnabled(ctx context.Context) (bool, error) {
+	hash, err := s.db.GetSetting(ctx, adminPasswordSetting)
+	return hash != "", err
+}
+
+// authenticate returns the scope a request is authenticated with, or false
+// if it carries no valid credentials.
+func (s *Server) authenticate(r *http.Request) (AuthScope, bool, error) {
+	ctx := r.Context()
+	if secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token, err := s.db.GetAPITokenByHash(ctx, hashSecret(strings.TrimSpace(secret)))
+		if err != nil || token == nil {
+			return "", false, err
+		}
+		if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) > apiTokenTouchInterval {
+			if err := s.db.TouchAPIToken(ctx, token.TokenID); err != nil {
+				s.logger.Warn("Failed to record API token use", "token", token.TokenID, "error", err)
+			}
+		}
+		return AuthScope(token.Scope), true, nil
+	}
+	if cookie, err := r.Cookie(sessionCookieName); err == nil && cookie.Value != "" {
+		ok, err := s.db.AuthSessionExists(ctx, hashSecret(cookie.Value))
+		if err != nil || !ok {
+			return "", false, err
+		}
+		return ScopeAdmin, true, nil
+	}
+	return "", false, nil
+}
+
+// AuthMiddleware enforces built-in authentication once it is turned on.
+// Unauthenticated page loads are sent to the login page; other requests get
+// 401.
+func (s *Server) AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authExempt(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		enabled, err := s.authEnabled(r.Context())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to check authentication: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if !enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		scope, ok, err := s.authenticate(r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to check authentication: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			if r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/") && r.Header.Get("Authorization") == "" {
+				http.Redirect(w, r, "/login?next="+template.URLQueryEscaper(r.URL.RequestURI()), http.StatusSeeOther)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="shelley"`)
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		if !scope.Allows(r.Method, r.URL.Path) {
+			http.Error(w, fmt.Sprintf("token scope %q does not allow %s %s", scope, r.Method, r.URL.Path), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var loginTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Shelley: Log in</title>
+<style>
+body { font-family: system-ui, sans-serif; display: flex; justify-content: center; margin-top: 15vh; }
+form { display: flex; flex-direction: column; gap: 0.75rem; width: 18rem; }
+input, button { font-size: 1rem; padding: 0.5rem; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<form method="POST" action="/login">
+<h1>Shelley</h1>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<input type="hidden" name="next" value="{{.Next}}">
+<input type="password" name="password" placeholder="Admin password" autocomplete="current-password" autofocus required>
+<button type="submit">Log in</button>
+</form>
+</body>
+</html>
+`))
+
+// safeRedirect returns next if it is a path on this server, and "/" otherwise.
+func safeRedirect(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// handleLogin serves the login page (GET) and checks the admin password
+// (POST, as a form or JSON {"password"}), starting a session on success.
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		loginTemplate.Execute(w, map[string]string{"Next": safeRedirect(r.URL.Query().Get("next"))})
+	case http.MethodPost:
+		s.handleLoginPost(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleLoginPost(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var password, next string
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isJSON := mediaType == "application/json"
+	if isJSON {
+		var req struct {
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		password = req.Password
+	} else {
+		password = r.PostFormValue("password")
+		next = safeRedirect(r.PostFormValue("next"))
+	}
+
+	hash, err := s.db.GetSetting(ctx, adminPasswordSetting)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to check password: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if hash == "" {
+		http.Error(w, "Authentication is not enabled", http.StatusBadRequest)
+		return
+	}
+	if !checkPassword(hash, password) {
+		s.logger.Warn("Failed login attempt", "remote_addr", r.RemoteAddr)
+		time.Sleep(loginFailureDelay)
+		if isJSON {
+			http.Error(w, "incorrect password", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusUnauthorized)
+		loginTemplate.Execute(w, map[string]string{"Next": next, "Error": "Incorrect password."})
+		return
+	}
+
+	sessionID, err := randomSecret()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create session: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := s.db.DeleteExpiredAuthSessions(ctx); err != nil {
+		s.logger.Warn("Failed to prune expired sessions", "error", err)
+	}
+	if err := s.db.CreateAuthSession(ctx, hashSecret(sessionID), sqliteDelay(sessionTTL)); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create session: %v", err), http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    sessionID,
+		Path:     "/",
+		MaxAge:   int(sessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	if isJSON {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		return
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// handleLogout handles POST /logout, ending the current login session.
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil && cookie.Value != "" {
+		if err := s.db.DeleteAuthSession(r.Context(), hashSecret(cookie.Value)); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to end session: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// handleAuthStatus reports whether authentication is on and how the request
+// is authenticated. Requests over the Unix socket are not authenticated, but
+// are trusted.
+func (s *Server) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
+	enabled, err := s.authEnabled(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to check authentication: %v", err), http.StatusInternalServerError)
+		return
+	}
+	status := AuthStatus{Enabled: enabled}
+	if enabled {
+		scope, ok, err := s.authenticate(r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to check authentication: %v", err), http.StatusInternalServerError)
+			return
+		}
+		status.Authenticated = ok
+		if ok {
+			status.Scope = string(scope)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+func toAPITokenAPI(t generated.ApiToken) APITokenAPI {
+	return APITokenAPI{
+		TokenID:    t.TokenID,
+		Name:       t.Name,
+		Scope:      t.Scope,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+// handleAPITokens handles GET (list) and POST (create) on /api/auth/tokens.
+func (s *Server) handleAPITokens(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		tokens, err := s.db.ListAPITokens(r.Context())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to list tokens: %v", err), http.StatusInternalServerError)
+			return
+		}
+		result := make([]APITokenAPI, 0, len(tokens))
+		for _, t := range tokens {
+			result = append(result, toAPITokenAPI(t))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+
+	case http.MethodPost:
+		var req CreateAPITokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.Name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		scope, err := ParseAuthScope(req.Scope)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ttl, err := ParseTokenTTL(req.ExpiresIn)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		secret, token, err := CreateAPIToken(r.Context(), s.db, req.Name, scope, ttl)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to create token: %v", err), http.StatusInternalServerError)
+			return
+		}
+		result := toAPITokenAPI(*token)
+		result.Token = secret
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(result)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAPIToken handles DELETE /api/auth/tokens/{id}, revoking a token.
+func (s *Server) handleAPIToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	tokenID := strings.TrimPrefix(r.URL.Path, "/api/auth/tokens/")
+	found, err := s.db.DeleteAPIToken(r.Context(), tokenID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to revoke token: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
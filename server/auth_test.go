@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuth(t *testing.T) {
+	server, database, _ := newTestServer(t)
+	ctx := context.Background()
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+	handler := server.AuthMiddleware(mux)
+
+	type request struct {
+		method, target, body string
+		token                string
+		cookie               *http.Cookie
+		form                 bool
+	}
+	do := func(r request) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(r.method, r.target, strings.NewReader(r.body))
+		if r.form {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else if r.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if r.token != "" {
+			req.Header.Set("Authorization", "Bearer "+r.token)
+		}
+		if r.cookie != nil {
+			req.AddCookie(r.cookie)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Authentication is off until a password is set.
+	if w := do(request{method: "GET", target: "/api/conversations"}); w.Code != http.StatusOK {
+		t.Fatalf("without auth: %d %s", w.Code, w.Body.String())
+	}
+	if err := SetAdminPassword(ctx, database, "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := do(request{method: "GET", target: "/api/conversations"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("API without credentials: %d", w.Code)
+	}
+	if w := do(request{method: "GET", target: "/c/abc"}); w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/login?next=%2Fc%2Fabc" {
+		t.Errorf("page without credentials: %d %q", w.Code, w.Header().Get("Location"))
+	}
+	if w := do(request{method: "GET", target: "/login"}); w.Code != http.StatusOK {
+		t.Errorf("login page: %d", w.Code)
+	}
+	if w := do(request{method: "POST", target: "/notifications/reply?token=x", body: `{"message":"hi"}`}); w.Code != http.StatusUnauthorized || strings.Contains(w.Body.String(), "authentication required") {
+		t.Errorf("notification replies should check their own token: %d %s", w.Code, w.Body.String())
+	}
+
+	// Logging in with the password starts an admin session.
+	if w := do(request{method: "POST", target: "/login", body: "password=wrong", form: true}); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: %d", w.Code)
+	}
+	form := url.Values{"password": {"hunter2"}, "next": {"//evil.example.com"}}
+	w := do(request{method: "POST", target: "/login", body: form.Encode(), form: true})
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" {
+		t.Fatalf("login: %d %q", w.Code, w.Header().Get("Location"))
+	}
+	var session *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == sessionCookieName {
+			session = c
+		}
+	}
+	if session == nil || !session.HttpOnly {
+		t.Fatalf("no session cookie: %v", w.Result().Cookies())
+	}
+	if w := do(request{method: "GET", target: "/settings", cookie: session}); w.Code != http.StatusOK || strings.Contains(w.Body.String(), adminPasswordSetting) {
+		t.Errorf("settings with session: %d %s", w.Code, w.Body.String())
+	}
+
+	// An admin session can manage tokens.
+	createToken := func(scope string) APITokenAPI {
+		t.Helper()
+		w := do(request{method: "POST", target: "/api/auth/tokens", body: `{"name":"ci","scope":"` + scope + `","expires_in":"30d"}`, cookie: session})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create %s token: %d %s", scope, w.Code, w.Body.String())
+		}
+		var token APITokenAPI
+		if err := json.Unmarshal(w.Body.Bytes(), &token); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(token.Token, apiTokenPrefix) || token.ExpiresAt == nil || token.ExpiresAt.Before(time.Now().Add(29*24*time.Hour)) {
+			t.Fatalf("created token: %+v", token)
+		}
+		return token
+	}
+	read := createToken("read")
+	chat := createToken("chat")
+
+	if w := do(request{method: "GET", target: "/api/conversations", token: read.Token}); w.Code != http.StatusOK {
+		t.Errorf("read token GET: %d", w.Code)
+	}
+	if w := do(request{method: "POST", target: "/api/conversations/new", body: `{}`, token: read.Token}); w.Code != http.StatusForbidden {
+		t.Errorf("read token POST: %d", w.Code)
+	}
+	if w := do(request{method: "POST", target: "/api/conversations/new", body: `{}`, token: chat.Token}); w.Code == http.StatusForbidden || w.Code == http.StatusUnauthorized {
+		t.Errorf("chat token POST: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(request{method: "GET", target: "/settings", token: chat.Token}); w.Code != http.StatusForbidden {
+		t.Errorf("chat token on admin route: %d", w.Code)
+	}
+	if w := do(request{method: "POST", target: "/api/conversation/c1/sandbox", body: `{}`, token: chat.Token}); w.Code != http.StatusForbidden {
+		t.Errorf("chat token changing sandbox: %d", w.Code)
+	}
+	if w := do(request{method: "GET", target: "/api/conversations", token: "shelley_forged"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown token: %d", w.Code)
+	}
+
+	// Listing never returns secrets, and use is recorded.
+	w = do(request{method: "GET", target: "/api/auth/tokens", cookie: session})
+	var tokens []APITokenAPI
+	if err := json.Unmarshal(w.Body.Bytes(), &tokens); err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0].Token != "" || tokens[0].LastUsedAt == nil {
+		t.Errorf("token list: %+v", tokens)
+	}
+
+	// Revoked tokens and ended sessions stop working.
+	if w := do(request{method: "DELETE", target: "/api/auth/tokens/" + read.TokenID, cookie: session}); w.Code != http.StatusNoContent {
+		t.Errorf("revoke: %d", w.Code)
+	}
+	if w := do(request{method: "GET", target: "/api/conversations", token: read.Token}); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: %d", w.Code)
+	}
+	if w := do(request{method: "POST", target: "/logout", cookie: session}); w.Code != http.StatusSeeOther {
+		t.Errorf("logout: %d", w.Code)
+	}
+	if w := do(request{method: "GET", target: "/api/conversations", cookie: session}); w.Code != http.StatusUnauthorized {
+		t.Errorf("session after logout: %d", w.Code)
+	}
+}
+
+func TestParseTokenTTL(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"":      0,
+		"never": 0,
+		"30d":   30 * 24 * time.Hour,
+		"12h":   12 * time.Hour,
+	} {
+		if got, err := ParseTokenTTL(in); err != nil || got != want {
+			t.Errorf("ParseTokenTTL(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"-1d", "soon", "0d"} {
+		if _, err := ParseTokenTTL(in); err == nil {
+			t.Errorf("ParseTokenTTL(%q) succeeded", in)
+		}
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !checkPassword(hash, "correct horse") {
+		t.Error("correct password rejected")
+	}
+	if checkPassword(hash, "battery staple") || checkPassword("", "") {
+		t.Error("wrong password accepted")
+	}
+}
@@ -41,24 +41,6 @@ func (s *Server) clearPendingCodexAuth() {
 	s.codexAuthMu.Unlock()
 }
 
-// CodexAuthStatusResponse is the response for GET /api/codex-auth/status
-type CodexAuthStatusResponse struct {
-	Authenticated bool    `json:"authenticated"`
-	AccountID     *string `json:"account_id,omitempty"`
-	ExpiresAt     *int64  `json:"expires_at,omitempty"`
-}
-
-// CodexPkceStartResponse is the response for POST /api/codex-auth/pkce/start
-type CodexPkceStartResponse struct {
-	AuthURL string `json:"auth_url"`
-	State   string `json:"state"`
-}
-
-// CodexPkceCompleteRequest is the request for POST /api/codex-auth/pkce/complete
-type CodexPkceCompleteRequest struct {
-	CallbackURL string `json:"callback_url"`
-}
-
 // handleCodexAuthStatus handles GET /api/codex-auth/status
 func (s *Server) handleCodexAuthStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
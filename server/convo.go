@@ -2,19 +2,25 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
 	"shelley.exe.dev/claudetool"
+	"shelley.exe.dev/claudetool/sandbox"
 	"shelley.exe.dev/db"
 	"shelley.exe.dev/db/generated"
 	"shelley.exe.dev/gitstate"
 	"shelley.exe.dev/llm"
 	"shelley.exe.dev/llm/llmhttp"
 	"shelley.exe.dev/loop"
+	"shelley.exe.dev/server/notifications"
+	"shelley.exe.dev/shelleyapi"
+	"shelley.exe.dev/skills"
 	"shelley.exe.dev/subpub"
 )
 
@@ -48,14 +54,34 @@ type ConversationManager struct {
 	// agentWorking tracks whether the agent is currently working.
 	// This is explicitly managed and broadcast to subscribers when it changes.
 	agentWorking bool
-
-	// onStateChange is called when the conversation state changes.
+	// workingSince is when the agent last started working.
+	workingSince time.Time
+
+	// pendingToolCalls are tool calls without results yet, keyed by tool use
+	// ID, tracked for tool notification events.
+	pendingToolCalls map[string]*pendingToolCall
+	// contextNearlyFull is set once EventContextNearlyFull has been sent,
+	// until the context usage drops again.
+	contextNearlyFull bool
+	// approvals tracks tool calls refused by a permission check that the
+	// user can approve from a notification reply.
+	approvals toolApprovals
+	// sandbox confines the conversation's bash commands, when enabled.
+	sandbox sandbox.Settings
+
+	// onStateChange is called when the conversation state changes, with how
+	// long the agent worked when it stops working.
 	// This allows the server to broadcast state changes to all subscribers.
-	onStateChange func(state ConversationState)
+	onStateChange func(state ConversationState, turnDuration time.Duration)
+
+	// notify sends a notification event for this conversation, if set.
+	notify func(eventType notifications.EventType, payload any)
+	// traceContext attaches the span of the conversation's running job, if set.
+	traceContext func(ctx context.Context) context.Context
 }
 
 // NewConversationManager constructs a manager with dependencies but defers hydration until needed.
-func NewConversationManager(conversationID string, database *db.DB, baseLogger *slog.Logger, toolSetConfig claudetool.ToolSetConfig, recordMessage loop.MessageRecordFunc, onStateChange func(ConversationState), systemPromptTemplate string) *ConversationManager {
+func NewConversationManager(conversationID string, database *db.DB, baseLogger *slog.Logger, toolSetConfig claudetool.ToolSetConfig, recordMessage loop.MessageRecordFunc, onStateChange func(ConversationState, time.Duration), systemPromptTemplate string) *ConversationManager {
 	logger := baseLogger
 	if logger == nil {
 		logger = slog.Default()
@@ -83,18 +109,31 @@ func (cm *ConversationManager) SetAgentWorking(working bool) {
 		return
 	}
 	cm.agentWorking = working
+	var turnDuration time.Duration
+	if working {
+		cm.workingSince = time.Now()
+	} else {
+		if !cm.workingSince.IsZero() {
+			turnDuration = time.Since(cm.workingSince)
+		}
+		cm.clearPendingToolCallsLocked()
+	}
 	onStateChange := cm.onStateChange
 	convID := cm.conversationID
 	modelID := cm.modelID
 	cm.mu.Unlock()
 
+	if turnDuration > 0 {
+		turnDurations.Observe(turnDuration.Seconds(), modelID)
+	}
+
 	cm.logger.Debug("agent working state changed", "working", working)
 	if onStateChange != nil {
 		onStateChange(ConversationState{
 			ConversationID: convID,
 			Working:        working,
 			Model:          modelID,
-		})
+		}, turnDuration)
 	}
 }
 
@@ -125,6 +164,22 @@ func (cm *ConversationManager) SetConversation(conversation generated.Conversati
 	cm.mu.Unlock()
 }
 
+// SandboxSettings returns the sandbox settings for the conversation's bash
+// commands.
+func (cm *ConversationManager) SandboxSettings() sandbox.Settings {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.sandbox
+}
+
+// SetSandboxSettings changes the sandbox settings. They apply from the next
+// bash command.
+func (cm *ConversationManager) SetSandboxSettings(s sandbox.Settings) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.sandbox = s
+}
+
 // Hydrate loads conversation metadata from the database and generates a system
 // prompt if one doesn't exist yet. It does NOT cache the message history;
 // ensureLoop reads messages fresh from the DB when creating a loop so that
@@ -157,6 +212,13 @@ func (cm *ConversationManager) Hydrate(ctx context.Context) error {
 		modelID = *conversation.Model
 	}
 
+	var sandboxSettings sandbox.Settings
+	if conversation.Sandbox != nil {
+		if err := json.Unmarshal([]byte(*conversation.Sandbox), &sandboxSettings); err != nil {
+			cm.logger.Warn("Ignoring invalid sandbox settings", "error", err)
+		}
+	}
+
 	// Generate system prompt if missing:
 	// - For user-initiated conversations: full system prompt
 	// - For subagent conversations (has parent): minimal subagent prompt
@@ -187,6 +249,7 @@ func (cm *ConversationManager) Hydrate(ctx context.Context) error {
 	cm.mu.Lock()
 	cm.conversation = *conversation
 	cm.hasConversation = true
+	cm.sandbox = sandboxSettings
 	cm.hasConversationEvents = hasNonSystemMessages(messages)
 	cm.lastActivity = time.Now()
 	cm.hydrated = true
@@ -367,6 +430,28 @@ func (cm *ConversationManager) createSubagentSystemPrompt(ctx context.Context) (
 	return created, nil
 }
 
+// refreshSkills rediscovers skills for the running loop's activate_skill tool.
+func (cm *ConversationManager) refreshSkills() {
+	cm.mu.Lock()
+	toolSet := cm.toolSet
+	cwd := cm.cwd
+	cm.mu.Unlock()
+	if toolSet == nil {
+		return
+	}
+	toolSet.SetSkills(skills.Collect(cwd, gitstate.GetGitState(cwd).Worktree))
+}
+
+// listsSkills reports whether the system prompt includes an <available_skills> block.
+func listsSkills(system []llm.SystemContent) bool {
+	for _, sc := range system {
+		if strings.Contains(sc.Text, "<available_skills>") {
+			return true
+		}
+	}
+	return false
+}
+
 func (cm *ConversationManager) partitionMessages(messages []generated.Message) ([]llm.Message, []llm.SystemContent) {
 	var history []llm.Message
 	var system []llm.SystemContent
@@ -457,6 +542,24 @@ func (cm *ConversationManager) ensureLoop(service llm.Service, modelID string) e
 	toolSetConfig.ModelID = modelID
 	toolSetConfig.ConversationID = conversationID
 	toolSetConfig.ParentConversationID = conversationID // For subagent tool
+	// Discovering skills walks the project tree, so only do it when the
+	// system prompt offers them.
+	if listsSkills(system) {
+		toolSetConfig.Skills = skills.Collect(cwd, gitstate.GetGitState(cwd).Worktree)
+	}
+	if cm.notify != nil {
+		notify := cm.notify
+		toolSetConfig.OnPermissionDenied = func(toolName, input string, err error) {
+			notify(notifications.EventToolApprovalNeeded, notifications.ToolApprovalPayload{
+				ToolName:   toolName,
+				Input:      input,
+				Reason:     err.Error(),
+				ApprovalID: cm.approvals.request(toolName, input),
+			})
+		}
+	}
+	toolSetConfig.IsApproved = cm.approvals.isApproved
+	toolSetConfig.Sandbox = cm.SandboxSettings
 	toolSetConfig.OnWorkingDirChange = func(newDir string) {
 		// Persist working directory change to database
 		if err := db.UpdateConversationCwd(context.Background(), conversationID, newDir); err != nil {
@@ -482,7 +585,7 @@ func (cm *ConversationManager) ensureLoop(service llm.Service, modelID string) e
 		}
 		cm.SetConversation(conv)
 		cm.subpub.Broadcast(mustTransientStreamEvent(conversationID, nil, eventTypeConversationUpdated, StreamResponse{
-			Conversation: conv,
+			Conversation: shelleyapi.Conversation(conv),
 		}))
 	}
 
@@ -495,11 +598,13 @@ func (cm *ConversationManager) ensureLoop(service llm.Service, modelID string) e
 		LLM:           service,
 		History:       history,
 		Tools:         toolSet.Tools(),
+		GetTools:      toolSet.Tools,
 		RecordMessage: recordMessage,
 		Logger:        logger,
 		System:        system,
 		WorkingDir:    cwd,
 		GetWorkingDir: toolSet.WorkingDir().Get,
+		Redactor:      toolSetConfig.Redactor,
 		OnGitStateChange: func(ctx context.Context, state *gitstate.GitState) {
 			cm.recordGitStateChange(ctx, state)
 		},
@@ -513,6 +618,8 @@ func (cm *ConversationManager) ensureLoop(service llm.Service, modelID string) e
 				StreamingThinking: text,
 			}))
 		},
+		DisableParallelToolCalls: !toolSet.Capabilities().ParallelToolCalls,
+		TraceContext:             cm.traceContext,
 	})
 
 	if cm.GetModel() == "" && modelID != "" {
@@ -785,7 +892,7 @@ func (cm *ConversationManager) notifyGitStateChange(ctx context.Context, msg *ge
 	apiMessages := toAPIMessages([]generated.Message{*msg})
 	streamData := StreamResponse{
 		Messages:     apiMessages,
-		Conversation: conversation,
+		Conversation: shelleyapi.Conversation(conversation),
 	}
 	cm.subpub.Broadcast(mustTransientStreamEvent(cm.conversationID, nil, eventTypeMessageCreated, streamData))
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -14,53 +15,17 @@ import (
 	"shelley.exe.dev/llm/ant"
 	"shelley.exe.dev/llm/gem"
 	"shelley.exe.dev/llm/oai"
+	"shelley.exe.dev/models"
 )
 
-// ModelAPI is the API representation of a model
-type ModelAPI struct {
-	ModelID      string `json:"model_id"`
-	DisplayName  string `json:"display_name"`
-	ProviderType string `json:"provider_type"`
-	Endpoint     string `json:"endpoint"`
-	APIKey       string `json:"-"`
-	HasAPIKey    bool   `json:"has_api_key"`
-	ModelName    string `json:"model_name"`
-	MaxTokens    int64  `json:"max_tokens"`
-	Tags         string `json:"tags"` // Comma-separated tags (e.g., "slug" for slug generation)
-}
-
-// CreateModelRequest is the request body for creating a model
-type CreateModelRequest struct {
-	DisplayName  string `json:"display_name"`
-	ProviderType string `json:"provider_type"`
-	Endpoint     string `json:"endpoint"`
-	APIKey       string `json:"api_key"`
-	ModelName    string `json:"model_name"`
-	MaxTokens    int64  `json:"max_tokens"`
-	Tags         string `json:"tags"` // Comma-separated tags
-}
-
-// UpdateModelRequest is the request body for updating a model
-type UpdateModelRequest struct {
-	DisplayName  string `json:"display_name"`
-	ProviderType string `json:"provider_type"`
-	Endpoint     string `json:"endpoint"`
-	APIKey       string `json:"api_key"` // Empty string means keep existing
-	ModelName    string `json:"model_name"`
-	MaxTokens    int64  `json:"max_tokens"`
-	Tags         string `json:"tags"` // Comma-separated tags
-}
-
-// TestModelRequest is the request body for testing a model
-type TestModelRequest struct {
-	ModelID      string `json:"model_id,omitempty"` // If provided, use stored API key
-	ProviderType string `json:"provider_type"`
-	Endpoint     string `json:"endpoint"`
-	APIKey       string `json:"api_key"`
-	ModelName    string `json:"model_name"`
-}
-
 func toModelAPI(m generated.Model) ModelAPI {
+	var pricing *llm.Pricing
+	if m.Pricing != nil {
+		var p llm.Pricing
+		if err := json.Unmarshal([]byte(*m.Pricing), &p); err == nil {
+			pricing = &p
+		}
+	}
 	return ModelAPI{
 		ModelID:      m.ModelID,
 		DisplayName:  m.DisplayName,
@@ -70,9 +35,80 @@ func toModelAPI(m generated.Model) ModelAPI {
 		ModelName:    m.ModelName,
 		MaxTokens:    m.MaxTokens,
 		Tags:         m.Tags,
+		Pricing:      pricing,
+		Capabilities: models.CustomModelCapabilities(&m),
 	}
 }
 
+// encodePricing validates p and encodes it for storage. Unset or all-zero
+// pricing is stored as NULL.
+func encodePricing(p *llm.Pricing) (*string, error) {
+	if p.IsZero() {
+		return nil, nil
+	}
+	prices := []llm.Prices{p.Prices}
+	for _, t := range p.Tiers {
+		prices = append(prices, t.Prices)
+	}
+	for _, pr := range prices {
+		if pr.Input < 0 || pr.Output < 0 || pr.CacheRead < 0 || pr.CacheWrite < 0 || pr.Reasoning < 0 {
+			return nil, fmt.Errorf("pricing must not be negative")
+		}
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	str := string(data)
+	return &str, nil
+}
+
+// rawModelFields are the fields of a create or update request whose
+// omission means something different from null or zero values.
+type rawModelFields struct {
+	Pricing      json.RawMessage `json:"pricing"`
+	Capabilities json.RawMessage `json:"capabilities"`
+}
+
+// decodeModelRequest decodes a create or update request into req and also
+// returns its raw fields.
+func decodeModelRequest(r *http.Request, req any) (rawModelFields, error) {
+	var raw rawModelFields
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return raw, err
+	}
+	if err := json.Unmarshal(body, req); err != nil {
+		return raw, err
+	}
+	err = json.Unmarshal(body, &raw)
+	return raw, err
+}
+
+// encodeCapabilities validates requested capabilities and encodes them for
+// storage. They are applied over base, the stored capabilities or the
+// provider defaults, so a partial object changes only the fields it names.
+// Null or omitted capabilities are stored as NULL, meaning the provider
+// defaults.
+func encodeCapabilities(raw json.RawMessage, base llm.Capabilities) (*string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	c := base
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("invalid capabilities: %w", err)
+	}
+	if c.MaxTools < 0 || c.MaxImageDimension < 0 {
+		return nil, fmt.Errorf("capabilities max_tools and max_image_dimension must not be negative")
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	str := string(data)
+	return &str, nil
+}
+
 func (s *Server) handleCustomModels(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -102,7 +138,8 @@ func (s *Server) handleListModels(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleCreateModel(w http.ResponseWriter, r *http.Request) {
 	var req CreateModelRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	raw, err := decodeModelRequest(r, &req)
+	if err != nil {
 		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
 		return
 	}
@@ -125,6 +162,17 @@ func (s *Server) handleCreateModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pricing, err := encodePricing(req.Pricing)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	capabilities, err := encodeCapabilities(raw.Capabilities, models.DefaultCapabilities(req.ProviderType))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Generate model ID
 	modelID := "custom-" + uuid.New().String()[:8]
 
@@ -142,6 +190,8 @@ func (s *Server) handleCreateModel(w http.ResponseWriter, r *http.Request) {
 		ModelName:    req.ModelName,
 		MaxTokens:    req.MaxTokens,
 		Tags:         req.Tags,
+		Pricing:      pricing,
+		Capabilities: capabilities,
 	})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create model: %v", err), http.StatusInternalServerError)
@@ -215,11 +265,33 @@ func (s *Server) handleUpdateModel(w http.ResponseWriter, r *http.Request, model
 	}
 
 	var req UpdateModelRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	raw, err := decodeModelRequest(r, &req)
+	if err != nil {
 		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
 		return
 	}
 
+	// Omitted pricing keeps the stored pricing; null or zero prices clear it.
+	pricing := existing.Pricing
+	if raw.Pricing != nil {
+		if pricing, err = encodePricing(req.Pricing); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	// Omitted capabilities keep the stored ones; a partial object changes them.
+	capabilities := existing.Capabilities
+	if raw.Capabilities != nil {
+		base := models.DefaultCapabilities(req.ProviderType)
+		if existing.Capabilities != nil {
+			base = models.CustomModelCapabilities(existing)
+		}
+		if capabilities, err = encodeCapabilities(raw.Capabilities, base); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Use existing API key if not provided
 	apiKey := req.APIKey
 	if apiKey == "" {
@@ -239,6 +311,8 @@ func (s *Server) handleUpdateModel(w http.ResponseWriter, r *http.Request, model
 		ModelName:    req.ModelName,
 		MaxTokens:    req.MaxTokens,
 		Tags:         req.Tags,
+		Pricing:      pricing,
+		Capabilities: capabilities,
 		ModelID:      modelID,
 	})
 	if err != nil {
@@ -270,11 +344,6 @@ func (s *Server) handleDeleteModel(w http.ResponseWriter, r *http.Request, model
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// DuplicateModelRequest allows overriding fields when duplicating
-type DuplicateModelRequest struct {
-	DisplayName string `json:"display_name,omitempty"`
-}
-
 func (s *Server) handleDuplicateModel(w http.ResponseWriter, r *http.Request, modelID string) {
 	// Get the source model (including API key)
 	source, err := s.db.GetModel(r.Context(), modelID)
@@ -308,6 +377,8 @@ func (s *Server) handleDuplicateModel(w http.ResponseWriter, r *http.Request, mo
 		ModelName:    source.ModelName,
 		MaxTokens:    source.MaxTokens,
 		Tags:         "", // Don't copy tags
+		Pricing:      source.Pricing,
+		Capabilities: source.Capabilities,
 	})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to duplicate model: %v", err), http.StatusInternalServerError)
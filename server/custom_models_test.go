@@ -14,6 +14,7 @@ import (
 	"shelley.exe.dev/db/generated"
 	"shelley.exe.dev/llm"
 	"shelley.exe.dev/llm/ant"
+	"shelley.exe.dev/models"
 )
 
 // TestCustomModelWithThinking tests that the custom model test endpoint
@@ -254,3 +255,156 @@ func assertNoAPIKeyLeak(t *testing.T, body []byte) {
 		t.Fatalf("response missing has_api_key=true: %s", string(body))
 	}
 }
+
+func TestCustomModelPricing(t *testing.T) {
+	h := NewTestHarness(t)
+
+	body := bytes.NewBufferString(`{"display_name":"Priced Model","provider_type":"anthropic","endpoint":"https://example.com","api_key":"secret","model_name":"claude-test","max_tokens":200000,"pricing":{"input":1.5,"output":6,"cache_read":0.15}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/custom-models", body)
+	w := httptest.NewRecorder()
+	h.server.handleCreateModel(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var created ModelAPI
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := llm.Prices{Input: 1.5, Output: 6, CacheRead: 0.15}
+	if created.Pricing == nil || created.Pricing.Prices != want {
+		t.Fatalf("created pricing = %+v, want %+v", created.Pricing, want)
+	}
+
+	t.Run("duplicate keeps pricing", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/custom-models/"+created.ModelID+"/duplicate", nil)
+		w := httptest.NewRecorder()
+		h.server.handleDuplicateModel(w, req, created.ModelID)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var dup ModelAPI
+		if err := json.Unmarshal(w.Body.Bytes(), &dup); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if dup.Pricing == nil || dup.Pricing.Prices != want {
+			t.Fatalf("duplicated pricing = %+v, want %+v", dup.Pricing, want)
+		}
+	})
+
+	t.Run("update without pricing keeps it", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"display_name":"Renamed","provider_type":"anthropic","endpoint":"https://example.com","model_name":"claude-test","max_tokens":200000}`)
+		req := httptest.NewRequest(http.MethodPut, "/api/custom-models/"+created.ModelID, body)
+		w := httptest.NewRecorder()
+		h.server.handleUpdateModel(w, req, created.ModelID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var updated ModelAPI
+		if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if updated.Pricing == nil || updated.Pricing.Prices != want {
+			t.Fatalf("updated pricing = %+v, want %+v", updated.Pricing, want)
+		}
+	})
+
+	t.Run("update clears pricing", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"display_name":"Priced Model","provider_type":"anthropic","endpoint":"https://example.com","model_name":"claude-test","max_tokens":200000,"pricing":null}`)
+		req := httptest.NewRequest(http.MethodPut, "/api/custom-models/"+created.ModelID, body)
+		w := httptest.NewRecorder()
+		h.server.handleUpdateModel(w, req, created.ModelID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if strings.Contains(w.Body.String(), `"pricing"`) {
+			t.Fatalf("expected pricing to be cleared: %s", w.Body.String())
+		}
+	})
+
+	t.Run("negative prices rejected", func(t *testing.T) {
+		body := bytes.NewBufferString(`{"display_name":"Bad","provider_type":"anthropic","endpoint":"https://example.com","api_key":"secret","model_name":"claude-test","pricing":{"input":-1,"output":1}}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/custom-models", body)
+		w := httptest.NewRecorder()
+		h.server.handleCreateModel(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestCustomModelCapabilities(t *testing.T) {
+	h := NewTestHarness(t)
+
+	body := bytes.NewBufferString(`{"display_name":"Default Caps","provider_type":"openai","endpoint":"https://example.com","api_key":"secret","model_name":"m"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/custom-models", body)
+	w := httptest.NewRecorder()
+	h.server.handleCreateModel(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created ModelAPI
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created.Capabilities != models.DefaultCapabilities("openai") {
+		t.Fatalf("capabilities = %+v, want provider defaults", created.Capabilities)
+	}
+
+	body = bytes.NewBufferString(`{"display_name":"Default Caps","provider_type":"openai","endpoint":"https://example.com","model_name":"m","capabilities":{"tools":true,"images":false,"simplified_patch":false,"parallel_tool_calls":false,"max_tools":5}}`)
+	req = httptest.NewRequest(http.MethodPut, "/api/custom-models/"+created.ModelID, body)
+	w = httptest.NewRecorder()
+	h.server.handleUpdateModel(w, req, created.ModelID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var updated ModelAPI
+	if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := llm.Capabilities{Tools: true, MaxTools: 5}
+	if updated.Capabilities != want {
+		t.Fatalf("capabilities = %+v, want %+v", updated.Capabilities, want)
+	}
+
+	update := func(body string) llm.Capabilities {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPut, "/api/custom-models/"+created.ModelID, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		h.server.handleUpdateModel(w, req, created.ModelID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var updated ModelAPI
+		if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return updated.Capabilities
+	}
+
+	// Omitting capabilities keeps the stored ones.
+	if got := update(`{"display_name":"Renamed","provider_type":"openai","endpoint":"https://example.com","model_name":"m"}`); got != want {
+		t.Fatalf("capabilities after rename = %+v, want %+v", got, want)
+	}
+
+	// A partial object changes only the fields it names, keeping the stored
+	// values of the others.
+	partial := want
+	partial.MaxTools = 7
+	if got := update(`{"display_name":"Renamed","provider_type":"openai","endpoint":"https://example.com","model_name":"m","capabilities":{"max_tools":7}}`); got != partial {
+		t.Fatalf("capabilities = %+v, want %+v", got, partial)
+	}
+
+	// Null restores the provider defaults.
+	if got := update(`{"display_name":"Renamed","provider_type":"openai","endpoint":"https://example.com","model_name":"m","capabilities":null}`); got != models.DefaultCapabilities("openai") {
+		t.Fatalf("capabilities = %+v, want provider defaults", got)
+	}
+
+	body = bytes.NewBufferString(`{"display_name":"Bad","provider_type":"openai","endpoint":"https://example.com","api_key":"secret","model_name":"m","capabilities":{"tools":true,"max_tools":-1}}`)
+	req = httptest.NewRequest(http.MethodPost, "/api/custom-models", body)
+	w = httptest.NewRecorder()
+	h.server.handleCreateModel(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"shelley.exe.dev/db"
 	"shelley.exe.dev/db/generated"
 	"shelley.exe.dev/llm"
+	"shelley.exe.dev/server/notifications"
+	"shelley.exe.dev/shelleyapi"
 	"shelley.exe.dev/slug"
 )
 
@@ -56,13 +58,6 @@ EXCISE: dead-end debugging (keep only final fix), verbose tool output (keep only
 
 Compression: recent activity (~last 20%) gets more detail; older activity compresses to conclusions. Short conversations (< 20 messages) preserve more. Long conversations (> 100 messages) aggressively compress old activity. Total output: 500-2000 words. When in doubt, keep it.`
 
-// DistillConversationRequest represents the request to distill a conversation
-type DistillConversationRequest struct {
-	SourceConversationID string `json:"source_conversation_id"`
-	Model                string `json:"model,omitempty"`
-	Cwd                  string `json:"cwd,omitempty"`
-}
-
 // handleDistillConversation handles POST /api/conversations/distill
 // Creates a new conversation and uses an LLM to distill the source conversation
 // into an operational summary as the initial user message.
@@ -128,7 +123,7 @@ func (s *Server) handleDistillConversation(w http.ResponseWriter, r *http.Reques
 	// Notify conversation list subscribers
 	go s.publishConversationListUpdate(ConversationListUpdate{
 		Type:         "update",
-		Conversation: conversation,
+		Conversation: (*shelleyapi.Conversation)(conversation),
 	})
 
 	timeoutSeconds := int64(120)
@@ -170,7 +165,7 @@ func (s *Server) handleDistillConversation(w http.ResponseWriter, r *http.Reques
 	}
 	statusEvent, err := s.eventLog.Append(ctx, conversationID, &job.JobID, &statusMessage.MessageID, eventTypeMessageCreated, StreamResponse{
 		Messages:     toAPIMessages([]generated.Message{*statusMessage}),
-		Conversation: *conversation,
+		Conversation: shelleyapi.Conversation(*conversation),
 	})
 	if err != nil {
 		s.logger.Error("Failed to append status message event", "conversationID", conversationID, "error", err)
@@ -291,7 +286,7 @@ func (s *Server) runDistillation(ctx context.Context, conversationID, jobID, sou
 				logger.Error("Failed to get runtime after distill slug generation", "error", err)
 			} else {
 				event, err := s.eventLog.Append(ctx, conversationID, runtime.ActiveJobID, nil, eventTypeConversationUpdated, StreamResponse{
-					Conversation: *conversation,
+					Conversation: shelleyapi.Conversation(*conversation),
 				})
 				if err != nil {
 					logger.Error("Failed to append conversation update event", "error", err)
@@ -301,7 +296,7 @@ func (s *Server) runDistillation(ctx context.Context, conversationID, jobID, sou
 			}
 			go s.publishConversationListUpdate(ConversationListUpdate{
 				Type:         "update",
-				Conversation: conversation,
+				Conversation: (*shelleyapi.Conversation)(conversation),
 			})
 		}
 	}
@@ -316,6 +311,12 @@ func (s *Server) runDistillation(ctx context.Context, conversationID, jobID, sou
 		logger.Error("Failed to finish distill job", "jobID", jobID, "error", err)
 		return
 	}
+
+	payload := notifications.DistillDonePayload{SourceTitle: sourceSlug}
+	if conversation, err := s.db.GetConversationByID(ctx, conversationID); err == nil && conversation.Slug != nil {
+		payload.ConversationTitle = *conversation.Slug
+	}
+	s.notifyConversationEvent(conversationID, notifications.EventDistillDone, payload)
 	if err := s.broadcastPersistedConversationStateUpdate(ctx, conversationID); err != nil {
 		logger.Error("Failed to broadcast distill conversation state", "jobID", jobID, "error", err)
 	}
@@ -6,16 +6,17 @@ import (
 
 	"shelley.exe.dev/db"
 	"shelley.exe.dev/db/generated"
+	"shelley.exe.dev/shelleyapi"
 )
 
 const (
-	eventTypeConversationUpdated      = "conversation.updated"
-	eventTypeConversationStateChanged = "conversation.state.changed"
-	eventTypeMessageCreated           = "message.created"
-	eventTypeMessageUpdated           = "message.updated"
-	eventTypeJobCreated               = "job.created"
-	eventTypeJobUpdated               = "job.updated"
-	eventTypeNotificationCreated      = "notification.created"
+	eventTypeConversationUpdated      = shelleyapi.EventTypeConversationUpdated
+	eventTypeConversationStateChanged = shelleyapi.EventTypeConversationStateChanged
+	eventTypeMessageCreated           = shelleyapi.EventTypeMessageCreated
+	eventTypeMessageUpdated           = shelleyapi.EventTypeMessageUpdated
+	eventTypeJobCreated               = shelleyapi.EventTypeJobCreated
+	eventTypeJobUpdated               = shelleyapi.EventTypeJobUpdated
+	eventTypeNotificationCreated      = shelleyapi.EventTypeNotificationCreated
 )
 
 type EventLogService struct {
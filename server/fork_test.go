@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shelley.exe.dev/db"
+	"shelley.exe.dev/db/generated"
+	"shelley.exe.dev/llm"
+)
+
+func TestForkConversation(t *testing.T) {
+	h := NewTestHarness(t)
+	ctx := t.Context()
+
+	slug := "fork-source"
+	cwd := "/tmp"
+	model := "predictable"
+	source, err := h.db.CreateConversation(ctx, &slug, true, &cwd, &model)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	var first *generated.Message
+	for i, text := range []string{"question", "answer", "follow-up"} {
+		msgType, role := db.MessageTypeUser, llm.MessageRoleUser
+		if i%2 == 1 {
+			msgType, role = db.MessageTypeAgent, llm.MessageRoleAssistant
+		}
+		msg, err := h.db.CreateMessage(ctx, db.CreateMessageParams{
+			ConversationID: source.ConversationID,
+			Type:           msgType,
+			LLMData:        llm.Message{Role: role, Content: []llm.Content{{Type: llm.ContentTypeText, Text: text}}},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create message: %v", err)
+		}
+		if first == nil {
+			first = msg
+		}
+	}
+
+	mux := http.NewServeMux()
+	h.server.RegisterRoutes(mux)
+	fork := func(body string) generated.Conversation {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/api/conversation/"+source.ConversationID+"/fork", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var conv generated.Conversation
+		if err := json.NewDecoder(rec.Body).Decode(&conv); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return conv
+	}
+
+	whole := fork("")
+	if whole.Slug == nil || *whole.Slug != "fork-source-fork" {
+		t.Errorf("Expected slug fork-source-fork, got %v", whole.Slug)
+	}
+	if whole.Cwd == nil || *whole.Cwd != cwd || whole.Model == nil || *whole.Model != model {
+		t.Errorf("Expected cwd and model to be copied, got %v %v", whole.Cwd, whole.Model)
+	}
+	messages, err := h.db.ListMessages(ctx, whole.ConversationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 copied messages, got %d", len(messages))
+	}
+	if messages[2].LlmData == nil || !strings.Contains(*messages[2].LlmData, "follow-up") {
+		t.Errorf("Unexpected last message %v", messages[2].LlmData)
+	}
+
+	partial := fork(fmt.Sprintf(`{"sequence_id": %d}`, first.SequenceID))
+	if partial.Slug == nil || *partial.Slug != "fork-source-fork-2" {
+		t.Errorf("Expected slug fork-source-fork-2, got %v", partial.Slug)
+	}
+	messages, err = h.db.ListMessages(ctx, partial.ConversationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].SequenceID != first.SequenceID {
+		t.Errorf("Expected only the first message, got %d messages", len(messages))
+	}
+
+	req := httptest.NewRequest("POST", "/api/conversation/missing/fork", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rec.Code)
+	}
+}
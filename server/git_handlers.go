@@ -11,34 +11,10 @@ import (
 	"strconv"
 	"strings"
 	"time"
-)
-
-// GitDiffInfo represents a commit or working changes
-type GitDiffInfo struct {
-	ID         string    `json:"id"`
-	Message    string    `json:"message"`
-	Author     string    `json:"author"`
-	Timestamp  time.Time `json:"timestamp"`
-	FilesCount int       `json:"filesCount"`
-	Additions  int       `json:"additions"`
-	Deletions  int       `json:"deletions"`
-}
-
-// GitFileInfo represents a file in a diff
-type GitFileInfo struct {
-	Path        string `json:"path"`
-	Status      string `json:"status"` // added, modified, deleted
-	Additions   int    `json:"additions"`
-	Deletions   int    `json:"deletions"`
-	IsGenerated bool   `json:"isGenerated"`
-}
 
-// GitFileDiff represents the content of a file diff
-type GitFileDiff struct {
-	Path       string `json:"path"`
-	OldContent string `json:"oldContent"`
-	NewContent string `json:"newContent"`
-}
+	"shelley.exe.dev/gitstate"
+	"shelley.exe.dev/shelleyapi"
+)
 
 // emptyTreeHash is the well-known hash for git's empty tree object.
 // Used to diff root commits that have no parent.
@@ -47,8 +23,7 @@ const emptyTreeHash = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
 // parentRef returns the parent commit hash for a commit.
 // For root commits (no parent), it returns the empty tree hash.
 func parentRef(gitDir, commitHash string) string {
-	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", commitHash+"^")
-	cmd.Dir = gitDir
+	cmd := gitstate.Command(gitDir, "rev-parse", "--verify", "--quiet", commitHash+"^")
 	out, err := cmd.Output()
 	if err != nil {
 		return emptyTreeHash
@@ -58,8 +33,7 @@ func parentRef(gitDir, commitHash string) string {
 
 // getGitRoot returns the git repository root for the given directory
 func getGitRoot(dir string) (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	cmd.Dir = dir
+	cmd := gitstate.Command(dir, "rev-parse", "--show-toplevel")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", err
@@ -119,8 +93,7 @@ func (s *Server) handleGitDiffs(w http.ResponseWriter, r *http.Request) {
 	var diffs []GitDiffInfo
 
 	// Working changes
-	workingStatCmd := exec.Command("git", "diff", "HEAD", "--numstat")
-	workingStatCmd.Dir = gitRoot
+	workingStatCmd := gitstate.Command(gitRoot, "diff", "HEAD", "--numstat")
 	workingStatOutput, _ := workingStatCmd.Output()
 	workingAdditions, workingDeletions, workingFilesCount := parseDiffStat(string(workingStatOutput))
 
@@ -135,8 +108,7 @@ func (s *Server) handleGitDiffs(w http.ResponseWriter, r *http.Request) {
 	})
 
 	// Get commits
-	cmd := exec.Command("git", "log", "--oneline", "-20", "--pretty=format:%H%x00%s%x00%an%x00%at")
-	cmd.Dir = gitRoot
+	cmd := gitstate.Command(gitRoot, "log", "--oneline", "-20", "--pretty=format:%H%x00%s%x00%an%x00%at")
 	output, err := cmd.Output()
 	if err == nil {
 		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
@@ -153,8 +125,7 @@ func (s *Server) handleGitDiffs(w http.ResponseWriter, r *http.Request) {
 
 			// Get diffstat
 			parent := parentRef(gitRoot, parts[0])
-			statCmd := exec.Command("git", "diff", parent, parts[0], "--numstat")
-			statCmd.Dir = gitRoot
+			statCmd := gitstate.Command(gitRoot, "diff", parent, parts[0], "--numstat")
 			statOutput, _ := statCmd.Output()
 			additions, deletions, filesCount := parseDiffStat(string(statOutput))
 
@@ -171,9 +142,9 @@ func (s *Server) handleGitDiffs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"diffs":   diffs,
-		"gitRoot": gitRoot,
+	json.NewEncoder(w).Encode(shelleyapi.GitDiffsResponse{
+		Diffs:   diffs,
+		GitRoot: gitRoot,
 	})
 }
 
@@ -209,13 +180,13 @@ func (s *Server) handleGitDiffFiles(w http.ResponseWriter, r *http.Request) {
 	var statBaseArg string
 
 	if diffID == "working" {
-		cmd = exec.Command("git", "diff", "--name-status", "HEAD")
+		cmd = gitstate.Command(gitRoot, "diff", "--name-status", "HEAD")
 		statBaseArg = "HEAD"
 	} else {
 		// Diff from the selected commit's parent to the working tree,
 		// showing all changes from that point through the current state.
 		parent := parentRef(gitRoot, diffID)
-		cmd = exec.Command("git", "diff", "--name-status", parent)
+		cmd = gitstate.Command(gitRoot, "diff", "--name-status", parent)
 		statBaseArg = parent
 	}
 	cmd.Dir = gitRoot
@@ -250,8 +221,7 @@ func (s *Server) handleGitDiffFiles(w http.ResponseWriter, r *http.Request) {
 
 		// Get additions/deletions for this file
 		// For both working and commit diffs, we compare statBaseArg to working tree
-		statCmd := exec.Command("git", "diff", statBaseArg, "--numstat", "--", parts[1])
-		statCmd.Dir = gitRoot
+		statCmd := gitstate.Command(gitRoot, "diff", statBaseArg, "--numstat", "--", parts[1])
 		statOutput, _ := statCmd.Output()
 		additions, deletions := 0, 0
 		if statOutput != nil {
@@ -352,8 +322,7 @@ func (s *Server) handleGitFileDiff(w http.ResponseWriter, r *http.Request) {
 	if baseRef == emptyTreeHash {
 		oldContent = ""
 	} else {
-		oldCmd := exec.Command("git", "show", baseRef+":"+filePath)
-		oldCmd.Dir = gitRoot
+		oldCmd := gitstate.Command(gitRoot, "show", baseRef+":"+filePath)
 		oldOutput, _ := oldCmd.Output()
 		oldContent = string(oldOutput)
 	}
@@ -450,8 +419,7 @@ func (s *Server) handleGitCreateWorktree(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Fetch origin first (best-effort)
-	fetchCmd := exec.Command("git", "fetch", "origin")
-	fetchCmd.Dir = mainRoot
+	fetchCmd := gitstate.Command(mainRoot, "fetch", "origin")
 	fetchCmd.Run() // ignore errors
 
 	// Determine the branch name from the worktree path
@@ -459,14 +427,12 @@ func (s *Server) handleGitCreateWorktree(w http.ResponseWriter, r *http.Request)
 
 	// Create the worktree with a new branch based on origin/main (or HEAD)
 	base := "HEAD"
-	checkCmd := exec.Command("git", "rev-parse", "--verify", "origin/main")
-	checkCmd.Dir = mainRoot
+	checkCmd := gitstate.Command(mainRoot, "rev-parse", "--verify", "origin/main")
 	if err := checkCmd.Run(); err == nil {
 		base = "origin/main"
 	}
 
-	cmd := exec.Command("git", "worktree", "add", "-b", branchName, worktreePath, base)
-	cmd.Dir = mainRoot
+	cmd := gitstate.Command(mainRoot, "worktree", "add", "-b", branchName, worktreePath, base)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"crypto/rand"
@@ -20,10 +21,12 @@ import (
 	"time"
 
 	"shelley.exe.dev/claudetool/browse"
+	"shelley.exe.dev/claudetool/sandbox"
 	"shelley.exe.dev/db/generated"
 	"shelley.exe.dev/gitstate"
 	"shelley.exe.dev/llm"
 	"shelley.exe.dev/models"
+	"shelley.exe.dev/shelleyapi"
 	"shelley.exe.dev/slug"
 	"shelley.exe.dev/ui"
 	"shelley.exe.dev/version"
@@ -252,15 +255,21 @@ func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
 	}
 	defer destFile.Close()
 
+	// Sniff the media type so clients can tell images from documents
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	head = head[:n]
+	mediaType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+
 	// Copy the file contents to the destination file
-	if _, err := io.Copy(destFile, file); err != nil {
+	if _, err := io.Copy(destFile, io.MultiReader(bytes.NewReader(head), file)); err != nil {
 		http.Error(w, "failed to save file: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Return the path to the saved file
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"path": filename})
+	json.NewEncoder(w).Encode(map[string]string{"path": filename, "media_type": mediaType})
 }
 
 // staticHandler serves files from the provided filesystem.
@@ -596,7 +605,7 @@ func (s *Server) handleConversations(w http.ResponseWriter, r *http.Request) {
 		}
 
 		cws := ConversationWithState{
-			Conversation:  conv,
+			Conversation:  shelleyapi.Conversation(conv),
 			Working:       runtime.Working,
 			SubagentCount: subagentCounts[conv.ConversationID],
 		}
@@ -652,6 +661,12 @@ func (s *Server) conversationMux() *http.ServeMux {
 	mux.HandleFunc("POST /{id}/rename", func(w http.ResponseWriter, r *http.Request) {
 		s.handleRenameConversation(w, r, r.PathValue("id"))
 	})
+	mux.HandleFunc("POST /{id}/fork", func(w http.ResponseWriter, r *http.Request) {
+		s.handleForkConversation(w, r, r.PathValue("id"))
+	})
+	mux.HandleFunc("/{id}/sandbox", func(w http.ResponseWriter, r *http.Request) {
+		s.handleConversationSandbox(w, r, r.PathValue("id"))
+	})
 	mux.HandleFunc("GET /{id}/subagents", func(w http.ResponseWriter, r *http.Request) {
 		s.handleGetSubagents(w, r, r.PathValue("id"))
 	})
@@ -704,8 +719,8 @@ func (s *Server) handleGetConversation(w http.ResponseWriter, r *http.Request, c
 	apiMessages := toAPIMessages(messages)
 	json.NewEncoder(w).Encode(StreamResponse{
 		Messages:     apiMessages,
-		Conversation: conversation,
-		Runtime:      runtime,
+		Conversation: shelleyapi.Conversation(conversation),
+		Runtime:      (*shelleyapi.ConversationRuntime)(runtime),
 		LastEventID:  runtime.LastEventID,
 		// ConversationState is sent via the streaming endpoint, not on initial load
 		ContextWindowSize: calculateContextWindowSize(apiMessages),
@@ -750,13 +765,6 @@ func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request, jobID stri
 	json.NewEncoder(w).Encode(job)
 }
 
-// ChatRequest represents a chat message from the user
-type ChatRequest struct {
-	Message string `json:"message"`
-	Model   string `json:"model,omitempty"`
-	Cwd     string `json:"cwd,omitempty"`
-}
-
 // handleChatConversation handles POST /conversation/<id>/chat
 func (s *Server) handleChatConversation(w http.ResponseWriter, r *http.Request, conversationID string) {
 	if r.Method != http.MethodPost {
@@ -881,7 +889,7 @@ func (s *Server) handleChatConversation(w http.ResponseWriter, r *http.Request,
 					return
 				}
 				event, err := s.eventLog.Append(ctxNoCancel, conversationID, runtime.ActiveJobID, nil, eventTypeConversationUpdated, StreamResponse{
-					Conversation: *conversation,
+					Conversation: shelleyapi.Conversation(*conversation),
 				})
 				if err != nil {
 					s.logger.Error("Failed to append conversation update event", "conversationID", conversationID, "error", err)
@@ -918,6 +926,13 @@ func (s *Server) handleNewConversation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Sandbox != nil {
+		if err := sandbox.Settings(*req.Sandbox).Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Get LLM service for the requested model
 	modelID := req.Model
 	if modelID == "" {
@@ -953,11 +968,18 @@ func (s *Server) handleNewConversation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	conversationID := conversation.ConversationID
+	if req.Sandbox != nil && req.Sandbox.Enabled {
+		if err := s.setConversationSandbox(ctx, conversationID, sandbox.Settings(*req.Sandbox)); err != nil {
+			s.logger.Error("Failed to set conversation sandbox", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
 
 	// Notify conversation list subscribers about the new conversation
 	go s.publishConversationListUpdate(ConversationListUpdate{
 		Type:         "update",
-		Conversation: conversation,
+		Conversation: (*shelleyapi.Conversation)(conversation),
 	})
 
 	userEmail := r.Header.Get("X-ExeDev-Email")
@@ -1039,7 +1061,7 @@ func (s *Server) handleNewConversation(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 				event, err := s.eventLog.Append(ctxNoCancel, conversationID, runtime.ActiveJobID, nil, eventTypeConversationUpdated, StreamResponse{
-					Conversation: *conversation,
+					Conversation: shelleyapi.Conversation(*conversation),
 				})
 				if err != nil {
 					s.logger.Error("Failed to append conversation update event", "conversationID", conversationID, "error", err)
@@ -1146,6 +1168,8 @@ func (s *Server) handleStreamConversation(w http.ResponseWriter, r *http.Request
 	// Subscribe before sending the initial payload so in-place Broadcast updates
 	// cannot land between the initial fetch and the subscription setup.
 	next := manager.subpub.Subscribe(ctx, lastEventID)
+	sseSubscribers.Inc()
+	defer sseSubscribers.Dec()
 
 	catchUpEvents, err := s.db.ListConversationEventsSince(ctx, conversationID, lastEventID)
 	if err != nil {
@@ -1167,8 +1191,8 @@ func (s *Server) handleStreamConversation(w http.ResponseWriter, r *http.Request
 	}
 
 	heartbeat := mustTransientStreamEvent(conversationID, runtime.ActiveJobID, eventTypeHeartbeat, StreamResponse{
-		Conversation: conversation,
-		Runtime:      runtime,
+		Conversation: shelleyapi.Conversation(conversation),
+		Runtime:      (*shelleyapi.ConversationRuntime)(runtime),
 		LastEventID:  runtime.LastEventID,
 		ConversationState: &ConversationState{
 			ConversationID: conversationID,
@@ -1212,8 +1236,8 @@ func (s *Server) handleStreamConversation(w http.ResponseWriter, r *http.Request
 				}
 
 				heartbeat := mustTransientStreamEvent(conversationID, runtime.ActiveJobID, eventTypeHeartbeat, StreamResponse{
-					Conversation: conv,
-					Runtime:      runtime,
+					Conversation: shelleyapi.Conversation(conv),
+					Runtime:      (*shelleyapi.ConversationRuntime)(runtime),
 					LastEventID:  runtime.LastEventID,
 					ConversationState: &ConversationState{
 						ConversationID: conversationID,
@@ -1260,15 +1284,6 @@ func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(version.GetInfo())
 }
 
-// ModelInfo represents a model in the API response
-type ModelInfo struct {
-	ID               string `json:"id"`
-	DisplayName      string `json:"display_name,omitempty"`
-	Source           string `json:"source,omitempty"` // Human-readable source (e.g., "exe.dev gateway", "$ANTHROPIC_API_KEY")
-	Ready            bool   `json:"ready"`
-	MaxContextTokens int    `json:"max_context_tokens,omitempty"`
-}
-
 // getModelList returns the list of available models
 func (s *Server) getModelList() []ModelInfo {
 	modelList := []ModelInfo{}
@@ -1370,7 +1385,7 @@ func (s *Server) handleArchiveConversation(w http.ResponseWriter, r *http.Reques
 	// Notify conversation list subscribers
 	go s.publishConversationListUpdate(ConversationListUpdate{
 		Type:         "update",
-		Conversation: conversation,
+		Conversation: (*shelleyapi.Conversation)(conversation),
 	})
 
 	w.Header().Set("Content-Type", "application/json")
@@ -1395,7 +1410,7 @@ func (s *Server) handleUnarchiveConversation(w http.ResponseWriter, r *http.Requ
 	// Notify conversation list subscribers
 	go s.publishConversationListUpdate(ConversationListUpdate{
 		Type:         "update",
-		Conversation: conversation,
+		Conversation: (*shelleyapi.Conversation)(conversation),
 	})
 
 	w.Header().Set("Content-Type", "application/json")
@@ -1455,11 +1470,6 @@ func (s *Server) handleConversationBySlug(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(conversation)
 }
 
-// RenameRequest represents a request to rename a conversation
-type RenameRequest struct {
-	Slug string `json:"slug"`
-}
-
 // handleRenameConversation handles POST /conversation/<id>/rename
 func (s *Server) handleRenameConversation(w http.ResponseWriter, r *http.Request, conversationID string) {
 	if r.Method != http.MethodPost {
@@ -1496,7 +1506,7 @@ func (s *Server) handleRenameConversation(w http.ResponseWriter, r *http.Request
 		return
 	}
 	if _, err := s.eventLog.Append(ctx, conversationID, runtime.ActiveJobID, nil, eventTypeConversationUpdated, StreamResponse{
-		Conversation: *conversation,
+		Conversation: shelleyapi.Conversation(*conversation),
 	}); err != nil {
 		s.logger.Error("Failed to append conversation update event", "conversationID", conversationID, "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -1506,13 +1516,65 @@ func (s *Server) handleRenameConversation(w http.ResponseWriter, r *http.Request
 	// Notify conversation list subscribers
 	go s.publishConversationListUpdate(ConversationListUpdate{
 		Type:         "update",
-		Conversation: conversation,
+		Conversation: (*shelleyapi.Conversation)(conversation),
 	})
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(conversation)
 }
 
+// handleForkConversation handles POST /conversation/<id>/fork
+// Copies the conversation, optionally only up to a message, into a new
+// conversation that can be continued independently.
+func (s *Server) handleForkConversation(w http.ResponseWriter, r *http.Request, conversationID string) {
+	ctx := r.Context()
+
+	var req ForkConversationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	source, err := s.db.GetConversationByID(ctx, conversationID)
+	if err != nil {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return
+	}
+
+	conversation, err := s.db.ForkConversation(ctx, conversationID, req.SequenceID)
+	if err != nil {
+		s.logger.Error("Failed to fork conversation", "conversationID", conversationID, "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if source.Slug != nil {
+		base := *source.Slug + "-fork"
+		for attempt := 1; attempt <= 100; attempt++ {
+			forkSlug := base
+			if attempt > 1 {
+				forkSlug = fmt.Sprintf("%s-%d", base, attempt)
+			}
+			if renamed, err := s.db.UpdateConversationSlug(ctx, conversation.ConversationID, forkSlug); err == nil {
+				conversation = renamed
+				break
+			}
+		}
+	}
+
+	// Notify conversation list subscribers
+	go s.publishConversationListUpdate(ConversationListUpdate{
+		Type:         "update",
+		Conversation: (*shelleyapi.Conversation)(conversation),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(conversation)
+}
+
 // handleVersionCheck returns version check information including update availability
 func (s *Server) handleVersionCheck(w http.ResponseWriter, r *http.Request) {
 	forceRefresh := r.URL.Query().Get("refresh") == "true"
@@ -1609,6 +1671,8 @@ func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Failed to get settings: %v", err), http.StatusInternalServerError)
 		return
 	}
+	delete(settings, replySecretSetting)
+	delete(settings, adminPasswordSetting)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(settings)
@@ -1633,7 +1697,9 @@ func (s *Server) handleSetSetting(w http.ResponseWriter, r *http.Request) {
 
 	// Only allow known setting keys
 	allowedKeys := map[string]bool{
-		"auto_upgrade": true,
+		"auto_upgrade":             true,
+		"long_tool_notify_minutes": true,
+		publicURLSetting:           true,
 	}
 	if !allowedKeys[req.Key] {
 		http.Error(w, fmt.Sprintf("Invalid setting key: %s", req.Key), http.StatusBadRequest)
@@ -7,12 +7,18 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"shelley.exe.dev/db"
 	"shelley.exe.dev/db/generated"
 	"shelley.exe.dev/llm"
+	"shelley.exe.dev/shelleyapi"
+	"shelley.exe.dev/tracing"
 )
 
 type JobKind string
@@ -53,10 +59,22 @@ type FinishJobParams struct {
 type JobService struct {
 	db     *db.DB
 	logger *slog.Logger
+
+	spansMu sync.Mutex
+	// spans holds the spans of running jobs, ended by FinishJob.
+	spans map[string]trace.Span
+	// conversationJobs maps a conversation to its most recently started
+	// job that is still running, whose span its loop's spans go under.
+	conversationJobs map[string]string
 }
 
 func NewJobService(database *db.DB, logger *slog.Logger) *JobService {
-	return &JobService{db: database, logger: logger}
+	return &JobService{
+		db:               database,
+		logger:           logger,
+		spans:            make(map[string]trace.Span),
+		conversationJobs: make(map[string]string),
+	}
 }
 
 func marshalOptionalJSON(payload any) (*string, error) {
@@ -118,7 +136,7 @@ func (s *JobService) StartJob(ctx context.Context, params StartJobParams) (*gene
 			return err
 		}
 		if _, err := appendConversationEventTx(ctx, q, params.ConversationID, &job.JobID, nil, eventTypeConversationStateChanged, StreamResponse{
-			Runtime: &runtime,
+			Runtime: (*shelleyapi.ConversationRuntime)(&runtime),
 			ConversationState: &ConversationState{
 				ConversationID: params.ConversationID,
 				Working:        runtime.Working,
@@ -133,9 +151,72 @@ func (s *JobService) StartJob(ctx context.Context, params StartJobParams) (*gene
 		return nil, err
 	}
 
+	s.startSpan(ctx, &job, params)
 	return &job, nil
 }
 
+// startSpan starts the span that covers job until FinishJob. A subagent's
+// job is a child of the span it was started from, normally its parent's
+// subagent tool call, and is linked to the parent job's span.
+func (s *JobService) startSpan(ctx context.Context, job *generated.JobRun, params StartJobParams) {
+	attrs := []attribute.KeyValue{
+		attribute.String("shelley.job.id", job.JobID),
+		attribute.String("shelley.job.kind", string(params.Kind)),
+		attribute.String("shelley.conversation.id", params.ConversationID),
+		attribute.String("shelley.model.id", params.ModelID),
+	}
+	var opts []trace.SpanStartOption
+
+	s.spansMu.Lock()
+	defer s.spansMu.Unlock()
+	if params.ParentJobID != nil {
+		attrs = append(attrs, attribute.String("shelley.job.parent_id", *params.ParentJobID))
+		if parent, ok := s.spans[*params.ParentJobID]; ok {
+			opts = append(opts, trace.WithLinks(trace.Link{SpanContext: parent.SpanContext()}))
+			if !trace.SpanContextFromContext(ctx).IsValid() {
+				ctx = trace.ContextWithSpan(ctx, parent)
+			}
+		}
+	}
+	opts = append(opts, trace.WithAttributes(attrs...))
+	_, span := tracing.Tracer().Start(ctx, "job "+string(params.Kind), opts...)
+	s.spans[job.JobID] = span
+	s.conversationJobs[params.ConversationID] = job.JobID
+}
+
+// endSpan ends the span of a finished job.
+func (s *JobService) endSpan(job *generated.JobRun) {
+	s.spansMu.Lock()
+	span, ok := s.spans[job.JobID]
+	delete(s.spans, job.JobID)
+	if s.conversationJobs[job.ConversationID] == job.JobID {
+		delete(s.conversationJobs, job.ConversationID)
+	}
+	s.spansMu.Unlock()
+	if !ok {
+		return
+	}
+	span.SetAttributes(attribute.String("shelley.job.status", job.Status))
+	switch JobStatus(job.Status) {
+	case JobStatusFailed, JobStatusTimedOut:
+		// The job's error can quote the conversation, which must not
+		// leave the machine in traces.
+		span.SetStatus(codes.Error, job.Status)
+	}
+	span.End()
+}
+
+// TraceContext returns ctx carrying the span of the conversation's running
+// job, if it has one, so work done for the job is traced under it.
+func (s *JobService) TraceContext(ctx context.Context, conversationID string) context.Context {
+	s.spansMu.Lock()
+	defer s.spansMu.Unlock()
+	if span, ok := s.spans[s.conversationJobs[conversationID]]; ok {
+		return trace.ContextWithSpan(ctx, span)
+	}
+	return ctx
+}
+
 func (s *JobService) FinishJob(ctx context.Context, params FinishJobParams) (*generated.JobRun, error) {
 	outputJSON, err := marshalOptionalJSON(params.Output)
 	if err != nil {
@@ -176,7 +257,7 @@ func (s *JobService) FinishJob(ctx context.Context, params FinishJobParams) (*ge
 			return err
 		}
 		if _, err := appendConversationEventTx(ctx, q, current.ConversationID, &job.JobID, nil, eventTypeConversationStateChanged, StreamResponse{
-			Runtime: &runtime,
+			Runtime: (*shelleyapi.ConversationRuntime)(&runtime),
 			ConversationState: &ConversationState{
 				ConversationID: current.ConversationID,
 				Working:        runtime.Working,
@@ -191,6 +272,7 @@ func (s *JobService) FinishJob(ctx context.Context, params FinishJobParams) (*ge
 		return nil, err
 	}
 
+	s.endSpan(&job)
 	return &job, nil
 }
 
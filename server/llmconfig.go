@@ -4,6 +4,8 @@ import (
 	"log/slog"
 
 	"shelley.exe.dev/db"
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/redact"
 )
 
 // Link represents a custom link to be displayed in the UI
@@ -40,6 +42,17 @@ type LLMConfig struct {
 	// UpdateSource configures where to check for updates (optional)
 	UpdateSource *UpdateSourceConfig
 
+	// ServerTools lists provider-executed tools to enable (optional), e.g. "web_search".
+	// See llm.ServerToolTypes.
+	ServerTools []string
+
+	// Pricing overrides model prices by model ID (optional).
+	// Prices are used to compute costs when no gateway reports them.
+	Pricing map[string]llm.Pricing
+
+	// Redactor removes secrets from tool results and recorded LLM requests (optional)
+	Redactor *redact.Redactor
+
 	// SystemPrompt overrides the default system prompt template (optional)
 	// This is a Go text/template that receives SystemPromptData
 	SystemPrompt string
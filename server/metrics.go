@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net/http"
+
+	"shelley.exe.dev/metrics"
+)
+
+// Metrics updated by the server. LLM requests, tool calls and database
+// queries are counted by the models, loop and db packages.
+var (
+	turnDurations         = metrics.NewHistogram("shelley_turn_duration_seconds", "Time the agent spent working on a turn, by model.", metrics.LongDurationBuckets, "model")
+	sseSubscribers        = metrics.NewGauge("shelley_sse_subscribers", "Open conversation streams.")
+	notificationDelivered = metrics.NewCounter("shelley_notification_deliveries_total", "Notification delivery attempts by event type and result (sent, retry or failed).", "event", "result")
+)
+
+// handleMetrics serves GET /metrics in the Prometheus text format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	active := len(s.activeConversations)
+	managers := make([]*ConversationManager, 0, active)
+	for _, manager := range s.activeConversations {
+		managers = append(managers, manager)
+	}
+	s.mu.Unlock()
+	working := 0
+	for _, manager := range managers {
+		if manager.IsAgentWorking() {
+			working++
+		}
+	}
+
+	w.Header().Set("Content-Type", metrics.ContentType)
+	metrics.WriteGauge(w, "shelley_active_conversations", "Conversations loaded in memory.", float64(active))
+	metrics.WriteGauge(w, "shelley_working_conversations", "Conversations whose agent is working on a turn.", float64(working))
+	metrics.Write(w)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	h := NewTestHarness(t)
+	h.NewConversation("bash: echo metrics", t.TempDir())
+	h.WaitToolResult()
+	h.WaitResponse()
+
+	mux := http.NewServeMux()
+	h.server.RegisterRoutes(mux)
+	scrape := func() string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+			t.Errorf("Unexpected Content-Type %q", ct)
+		}
+		return rec.Body.String()
+	}
+
+	want := []string{
+		"shelley_active_conversations 1\n",
+		`shelley_tool_calls_total{tool="bash",outcome="ok"}`,
+		`shelley_tool_call_duration_seconds_count{tool="bash"}`,
+		`shelley_turn_duration_seconds_count{model="predictable"}`,
+		`shelley_db_transaction_duration_seconds_count{type="tx"}`,
+		"# TYPE shelley_sse_subscribers gauge\n",
+	}
+	// The turn's duration is recorded just after its last message.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		out := scrape()
+		var missing []string
+		for _, s := range want {
+			if !strings.Contains(out, s) {
+				missing = append(missing, s)
+			}
+		}
+		if len(missing) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("metrics missing %q:\n%s", missing, out)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,44 +15,6 @@ import (
 	"shelley.exe.dev/server/notifications"
 )
 
-type NotificationChannelAPI struct {
-	ChannelID   string `json:"channel_id"`
-	ChannelType string `json:"channel_type"`
-	DisplayName string `json:"display_name"`
-	Enabled     bool   `json:"enabled"`
-	Config      any    `json:"config"`
-}
-
-type CreateNotificationChannelRequest struct {
-	ChannelType string `json:"channel_type"`
-	DisplayName string `json:"display_name"`
-	Enabled     bool   `json:"enabled"`
-	Config      any    `json:"config"`
-}
-
-type UpdateNotificationChannelRequest struct {
-	DisplayName string `json:"display_name"`
-	Enabled     bool   `json:"enabled"`
-	Config      any    `json:"config"`
-}
-
-type ConfigField struct {
-	Name        string   `json:"name"`
-	Label       string   `json:"label"`
-	Type        string   `json:"type"`
-	Required    bool     `json:"required"`
-	Placeholder string   `json:"placeholder,omitempty"`
-	Default     string   `json:"default,omitempty"`
-	Description string   `json:"description,omitempty"`
-	Options     []string `json:"options,omitempty"`
-}
-
-type ChannelTypeInfo struct {
-	Type         string        `json:"type"`
-	Label        string        `json:"label"`
-	ConfigFields []ConfigField `json:"config_fields"`
-}
-
 var channelTypeInfo = map[string]ChannelTypeInfo{
 	"discord": {
 		Type:  "discord",
@@ -64,6 +28,27 @@ var channelTypeInfo = map[string]ChannelTypeInfo{
 		Label: "Email (exe.dev)",
 		ConfigFields: []ConfigField{
 			{Name: "to", Label: "Recipient Email", Type: "string", Required: true, Placeholder: "you@example.com"},
+			{Name: "replies", Label: "Allow Replies", Type: "string", Default: "false", Options: []string{"false", "true"}, Description: "Lets you answer by replying to the email. Replies must be delivered as raw messages to POST /notifications/email-reply, e.g. by a local mail server or mailbox poller."},
+		},
+	},
+	"slack": {
+		Type:  "slack",
+		Label: "Slack Webhook",
+		ConfigFields: []ConfigField{
+			{Name: "webhook_url", Label: "Webhook URL", Type: "string", Required: true, Placeholder: "https://hooks.slack.com/services/..."},
+		},
+	},
+	"webhook": {
+		Type:  "webhook",
+		Label: "HTTP Webhook",
+		ConfigFields: []ConfigField{
+			{Name: "url", Label: "URL", Type: "string", Required: true, Placeholder: "https://example.com/hooks/shelley"},
+			{Name: "method", Label: "Method", Type: "string", Required: true, Default: "POST", Options: []string{"POST", "PUT"}},
+			{Name: "headers", Label: "Headers", Type: "text", Placeholder: "Authorization: Bearer ...", Description: "Optional. One \"Name: value\" header per line."},
+			{Name: "body_template", Label: "Body Template", Type: "text", Placeholder: `{"text": {{json .Payload.ConversationTitle}}}`, Description: "Optional Go text/template over the event (.Type, .Title, .Text, .ConversationID, .Cwd, .DurationSeconds, .Payload, .Reply). Defaults to the event as JSON."},
+			{Name: "content_type", Label: "Content Type", Type: "string", Default: "application/json"},
+			{Name: "secret", Label: "Signing Secret", Type: "password", Description: "Optional. Signs the body with HMAC-SHA256, sent as X-Shelley-Signature: sha256=<hex>."},
+			{Name: "replies", Label: "Allow Replies", Type: "string", Default: "false", Options: []string{"false", "true"}, Description: "Adds a \"reply\" object with a token and callback URL. POST {\"action\": \"message\" | \"approve\" | \"deny\", \"message\": ...} to the URL to answer."},
 		},
 	},
 	"ntfy": {
@@ -77,6 +62,7 @@ var channelTypeInfo = map[string]ChannelTypeInfo{
 			{Name: "password", Label: "Password", Type: "password", Description: "Optional. For private topics, use with username."},
 			{Name: "done_priority", Label: "Done Priority", Type: "string", Required: true, Default: "default", Options: []string{"min", "low", "default", "high", "max"}},
 			{Name: "error_priority", Label: "Error Priority", Type: "string", Required: true, Default: "high", Options: []string{"min", "low", "default", "high", "max"}},
+			{Name: "replies", Label: "Allow Replies", Type: "string", Default: "false", Options: []string{"false", "true"}, Description: "Adds Approve, Deny and Continue buttons. Requires the public_url setting."},
 		},
 	},
 }
@@ -209,6 +195,16 @@ func (s *Server) handleNotificationChannel(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if strings.HasSuffix(path, "/deliveries") {
+		channelID := strings.TrimSuffix(path, "/deliveries")
+		if r.Method == http.MethodGet {
+			s.handleListNotificationDeliveries(w, r, channelID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
 	if strings.Contains(path, "/") {
 		http.Error(w, "Invalid channel ID", http.StatusBadRequest)
 		return
@@ -322,9 +318,9 @@ func (s *Server) handleTestNotificationChannel(w http.ResponseWriter, r *http.Re
 	ch, err := notifications.CreateFromConfig(config, s.logger)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]any{
-			"success": false,
-			"message": fmt.Sprintf("Failed to create channel: %v", err),
+		json.NewEncoder(w).Encode(TestNotificationChannelResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to create channel: %v", err),
 		})
 		return
 	}
@@ -344,20 +340,69 @@ func (s *Server) handleTestNotificationChannel(w http.ResponseWriter, r *http.Re
 
 	if err := ch.Send(ctx, testEvent); err != nil {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]any{
-			"success": false,
-			"message": fmt.Sprintf("Test failed: %v", err),
+		json.NewEncoder(w).Encode(TestNotificationChannelResponse{
+			Success: false,
+			Message: fmt.Sprintf("Test failed: %v", err),
 		})
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]any{
-		"success": true,
-		"message": "Test notification sent successfully",
+	json.NewEncoder(w).Encode(TestNotificationChannelResponse{
+		Success: true,
+		Message: "Test notification sent successfully",
 	})
 }
 
+func (s *Server) handleListNotificationDeliveries(w http.ResponseWriter, r *http.Request, channelID string) {
+	if _, err := s.db.GetNotificationChannel(r.Context(), channelID); err != nil {
+		http.Error(w, fmt.Sprintf("Channel not found: %v", err), http.StatusNotFound)
+		return
+	}
+
+	limit := int64(50)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+		if limit > 500 {
+			limit = 500
+		}
+	}
+
+	deliveries, err := s.db.ListNotificationDeliveries(r.Context(), channelID, limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get notification deliveries: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]NotificationDeliveryAPI, len(deliveries))
+	for i, d := range deliveries {
+		result[i] = NotificationDeliveryAPI{
+			DeliveryID:     d.DeliveryID,
+			EventType:      d.EventType,
+			ConversationID: d.ConversationID,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			LastError:      d.LastError,
+			NextAttemptAt:  d.NextAttemptAt,
+			CreatedAt:      d.CreatedAt,
+			UpdatedAt:      d.UpdatedAt,
+		}
+		if event, err := notifications.UnmarshalEvent([]byte(d.EventJson)); err == nil {
+			if summary, ok := notifications.Describe(event); ok {
+				result[i].Title = summary.Title
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
 func (s *Server) handleNotificationChannelTypes(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -368,16 +413,27 @@ func (s *Server) handleNotificationChannelTypes(w http.ResponseWriter, r *http.R
 	json.NewEncoder(w).Encode(s.getNotificationChannelTypes())
 }
 
+// filterConfigFields are the event filter settings shared by every channel
+// type; see notifications.ParseFilter.
+var filterConfigFields = []ConfigField{
+	{Name: "events", Label: "Events", Type: "string", Placeholder: "agent_done, agent_error, tool_approval_needed", Description: "Optional. Comma-separated event types to send: agent_done, agent_error, tool_approval_needed, tool_running, subagent_done, distill_done, context_nearly_full, upgrade_done, git_commit. Empty sends all."},
+	{Name: "conversations", Label: "Conversations", Type: "string", Placeholder: "deploy-*", Description: "Optional. Comma-separated globs matched against conversation IDs and titles."},
+	{Name: "cwd_globs", Label: "Working Directories", Type: "string", Placeholder: "/home/me/work/**", Description: "Optional. Comma-separated globs matched against the conversation's directory. A trailing /** matches subdirectories."},
+	{Name: "min_duration", Label: "Minimum Turn Duration", Type: "string", Placeholder: "2m", Description: "Optional. Skip completions of turns shorter than this, e.g. 30s or 5m."},
+	{Name: "include_subagents", Label: "Include Subagents", Type: "string", Default: "false", Options: []string{"false", "true"}},
+}
+
 // getNotificationChannelTypes returns channel type metadata for the frontend.
 func (s *Server) getNotificationChannelTypes() []ChannelTypeInfo {
 	types := notifications.RegisteredTypes()
 	result := make([]ChannelTypeInfo, 0, len(types))
 	for _, t := range types {
-		if info, ok := channelTypeInfo[t]; ok {
-			result = append(result, info)
-		} else {
-			result = append(result, ChannelTypeInfo{Type: t, Label: t})
+		info, ok := channelTypeInfo[t]
+		if !ok {
+			info = ChannelTypeInfo{Type: t, Label: t}
 		}
+		info.ConfigFields = slices.Concat(info.ConfigFields, filterConfigFields)
+		result = append(result, info)
 	}
 	return result
 }
@@ -404,6 +460,9 @@ func (s *Server) ReloadNotificationChannels() {
 			s.logger.Warn("Failed to create notification channel", "id", dbCh.ChannelID, "error", err)
 			continue
 		}
+		if fc, ok := ch.(*notifications.FilteredChannel); ok {
+			fc.ID = dbCh.ChannelID
+		}
 		active = append(active, ch)
 	}
 
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"shelley.exe.dev/claudetool/bashkit"
+	"shelley.exe.dev/db/generated"
+	"shelley.exe.dev/gitstate"
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/server/notifications"
+	"shelley.exe.dev/shelleyapi"
+)
+
+// defaultLongToolNotifyAfter is how long a tool call may run before
+// EventToolRunning is sent, unless the long_tool_notify_minutes setting
+// overrides it.
+const defaultLongToolNotifyAfter = 5 * time.Minute
+
+// contextNearlyFullPercent is the context window usage at which
+// EventContextNearlyFull is sent.
+const contextNearlyFullPercent = 90
+
+// maxNotifiedToolInput limits the tool input included in notification payloads.
+const maxNotifiedToolInput = 255
+
+// pendingToolCall is a tool call that has been requested but has no result yet.
+type pendingToolCall struct {
+	name      string
+	input     string
+	timer     *time.Timer
+	gitCommit bool
+}
+
+// notifyConversationEvent dispatches a notification event for conversationID
+// in the background, filling in its working directory and subagent status
+// and a way to reply, and broadcasts it to the UI.
+func (s *Server) notifyConversationEvent(conversationID string, eventType notifications.EventType, payload any) {
+	event := notifications.Event{
+		Type:           eventType,
+		ConversationID: conversationID,
+		Timestamp:      time.Now(),
+		Payload:        payload,
+	}
+	if conv, err := s.db.GetConversationByID(context.Background(), conversationID); err == nil {
+		event.Subagent = conv.ParentConversationID != nil
+		if conv.Cwd != nil {
+			event.Cwd = *conv.Cwd
+		}
+	}
+	dispatched := event
+	s.attachReply(context.Background(), &dispatched)
+	go s.notifDispatcher.Dispatch(context.Background(), dispatched)
+	s.broadcastNotificationEvent(event)
+}
+
+// apiNotificationEvent converts event to its form on conversation streams.
+func apiNotificationEvent(event notifications.Event) *shelleyapi.NotificationEvent {
+	apiEvent := &shelleyapi.NotificationEvent{
+		Type:           string(event.Type),
+		ConversationID: event.ConversationID,
+		Timestamp:      event.Timestamp,
+		Cwd:            event.Cwd,
+		Subagent:       event.Subagent,
+	}
+	if event.Payload != nil {
+		apiEvent.Payload, _ = json.Marshal(event.Payload)
+	}
+	if event.Reply != nil {
+		apiEvent.Reply = &shelleyapi.NotificationReply{URL: event.Reply.URL, Token: event.Reply.Token}
+		for _, action := range event.Reply.Actions {
+			apiEvent.Reply.Actions = append(apiEvent.Reply.Actions, string(action))
+		}
+	}
+	return apiEvent
+}
+
+// longToolNotifyAfter returns how long a tool call may run before a notification.
+func (s *Server) longToolNotifyAfter(ctx context.Context) time.Duration {
+	if v, err := s.db.GetSetting(ctx, "long_tool_notify_minutes"); err == nil && v != "" {
+		if minutes, err := strconv.ParseFloat(v, 64); err == nil && minutes > 0 {
+			return time.Duration(minutes * float64(time.Minute))
+		}
+	}
+	return defaultLongToolNotifyAfter
+}
+
+// observeMessageForNotifications inspects a recorded message for events that
+// depend on the conversation's progress: tool calls that run long, git commits
+// made by the agent, and the context window filling up.
+func (s *Server) observeMessageForNotifications(ctx context.Context, manager *ConversationManager, conversation generated.Conversation, message llm.Message, usage llm.Usage) {
+	conversationID := conversation.ConversationID
+	for _, c := range message.Content {
+		switch c.Type {
+		case llm.ContentTypeToolUse:
+			call := newPendingToolCall(c.ToolName, c.ToolInput)
+			after := s.longToolNotifyAfter(ctx)
+			payload := notifications.ToolRunningPayload{ToolName: call.name, Input: call.input, ElapsedSeconds: int(after.Seconds())}
+			call.timer = time.AfterFunc(after, func() {
+				s.notifyConversationEvent(conversationID, notifications.EventToolRunning, payload)
+			})
+			manager.addPendingToolCall(c.ID, call)
+
+		case llm.ContentTypeToolResult:
+			call := manager.finishPendingToolCall(c.ToolUseID)
+			if call != nil && call.gitCommit && !c.ToolError && conversation.Cwd != nil {
+				go s.notifyGitCommit(conversationID, *conversation.Cwd, call.input)
+			}
+		}
+	}
+
+	if message.Role == llm.MessageRoleAssistant && conversation.Model != nil {
+		s.checkContextWindow(manager, conversationID, *conversation.Model, usage)
+	}
+}
+
+// checkContextWindow sends EventContextNearlyFull once each time the
+// conversation's context usage crosses contextNearlyFullPercent.
+func (s *Server) checkContextWindow(manager *ConversationManager, conversationID, modelID string, usage llm.Usage) {
+	used := usage.ContextWindowUsed()
+	if used == 0 {
+		return
+	}
+	svc, err := s.llmManager.GetService(modelID)
+	if err != nil || svc.TokenContextWindow() <= 0 {
+		return
+	}
+	window := uint64(svc.TokenContextWindow())
+	full := used*100 >= window*contextNearlyFullPercent
+	if !manager.setContextNearlyFull(full) || !full {
+		return
+	}
+	s.notifyConversationEvent(conversationID, notifications.EventContextNearlyFull, notifications.ContextNearlyFullPayload{
+		Model:        modelID,
+		UsedTokens:   used,
+		WindowTokens: window,
+	})
+}
+
+// notifyGitCommit sends EventGitCommit for the commit now at HEAD in dir.
+func (s *Server) notifyGitCommit(conversationID, dir, command string) {
+	payload := notifications.GitCommitPayload{Command: command}
+	cmd := gitstate.Command(dir, "log", "-1", "--format=%h%x00%s")
+	if out, err := cmd.Output(); err == nil {
+		payload.Commit, payload.Subject, _ = strings.Cut(strings.TrimSpace(string(out)), "\x00")
+	}
+	s.notifyConversationEvent(conversationID, notifications.EventGitCommit, payload)
+}
+
+// newPendingToolCall describes a tool call for notifications. Whether a bash
+// command commits is decided from the whole command, as the summary kept for
+// notification payloads may cut off a commit at its end.
+func newPendingToolCall(toolName string, input json.RawMessage) *pendingToolCall {
+	text := toolInputText(toolName, input)
+	call := &pendingToolCall{name: toolName, input: text}
+	if toolName == "bash" {
+		call.gitCommit, _ = bashkit.WillRunGitCommit(text)
+	}
+	call.input = truncateUTF8(call.input, maxNotifiedToolInput)
+	return call
+}
+
+// toolInputText returns a readable form of a tool call's input: a bash
+// call's command, or the raw JSON of any other call.
+func toolInputText(toolName string, input json.RawMessage) string {
+	if toolName == "bash" {
+		var bashInput struct {
+			Command string `json:"command"`
+		}
+		if json.Unmarshal(input, &bashInput) == nil {
+			return bashInput.Command
+		}
+	}
+	return string(input)
+}
+
+// addPendingToolCall records a tool call awaiting its result.
+func (cm *ConversationManager) addPendingToolCall(id string, call *pendingToolCall) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.pendingToolCalls == nil {
+		cm.pendingToolCalls = make(map[string]*pendingToolCall)
+	}
+	cm.pendingToolCalls[id] = call
+}
+
+// finishPendingToolCall removes and returns the pending tool call with the
+// given ID, stopping its long-running timer. It returns nil if there is none.
+func (cm *ConversationManager) finishPendingToolCall(id string) *pendingToolCall {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	call := cm.pendingToolCalls[id]
+	if call != nil {
+		call.timer.Stop()
+		delete(cm.pendingToolCalls, id)
+	}
+	return call
+}
+
+// clearPendingToolCallsLocked forgets all pending tool calls, for when the
+// agent stops working. cm.mu must be held.
+func (cm *ConversationManager) clearPendingToolCallsLocked() {
+	for id, call := range cm.pendingToolCalls {
+		call.timer.Stop()
+		delete(cm.pendingToolCalls, id)
+	}
+}
+
+// setContextNearlyFull records whether the context window is nearly full and
+// reports whether that changed.
+func (cm *ConversationManager) setContextNearlyFull(full bool) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	changed := cm.contextNearlyFull != full
+	cm.contextNearlyFull = full
+	return changed
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"shelley.exe.dev/claudetool"
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/server/notifications"
+)
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []notifications.Event
+}
+
+func (r *eventRecorder) Name() string { return "recorder" }
+
+func (r *eventRecorder) Send(_ context.Context, event notifications.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// waitFor returns the first recorded event of the given type, failing the test
+// if none arrives in time.
+func (r *eventRecorder) waitFor(t *testing.T, eventType notifications.EventType) notifications.Event {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		for _, e := range r.events {
+			if e.Type == eventType {
+				r.mu.Unlock()
+				return e
+			}
+		}
+		r.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("no %s event received", eventType)
+	return notifications.Event{}
+}
+
+func TestToolNotificationEvents(t *testing.T) {
+	server, database, _ := newTestServer(t)
+	ctx := context.Background()
+	recorder := &eventRecorder{}
+	server.RegisterNotificationChannel(recorder)
+
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "Add the thing"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	model := "predictable"
+	conv, err := database.CreateConversation(ctx, nil, true, &repo, &model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := NewConversationManager(conv.ConversationID, database, nil, claudetool.ToolSetConfig{}, nil, nil, "")
+	server.mu.Lock()
+	server.activeConversations[conv.ConversationID] = manager
+	server.mu.Unlock()
+	if err := database.SetSetting(ctx, "long_tool_notify_minutes", "0.001"); err != nil {
+		t.Fatal(err)
+	}
+
+	toolUse := llm.Message{
+		Role: llm.MessageRoleAssistant,
+		Content: []llm.Content{{
+			ID:        "tool-1",
+			Type:      llm.ContentTypeToolUse,
+			ToolName:  "bash",
+			ToolInput: []byte(`{"command":"git commit -m 'Add the thing'"}`),
+		}},
+	}
+	if err := server.recordMessage(ctx, conv.ConversationID, toolUse, llm.Usage{InputTokens: 190000}); err != nil {
+		t.Fatal(err)
+	}
+
+	full := recorder.waitFor(t, notifications.EventContextNearlyFull)
+	if p, ok := full.Payload.(notifications.ContextNearlyFullPayload); !ok || p.Percent() != 95 {
+		t.Errorf("context payload = %+v", full.Payload)
+	}
+	running := recorder.waitFor(t, notifications.EventToolRunning)
+	if p, ok := running.Payload.(notifications.ToolRunningPayload); !ok || p.ToolName != "bash" || running.Cwd != repo {
+		t.Errorf("tool running event = %+v", running)
+	}
+
+	toolResult := llm.Message{
+		Role: llm.MessageRoleUser,
+		Content: []llm.Content{{
+			Type:      llm.ContentTypeToolResult,
+			ToolUseID: "tool-1",
+		}},
+	}
+	if err := server.recordMessage(ctx, conv.ConversationID, toolResult, llm.Usage{}); err != nil {
+		t.Fatal(err)
+	}
+	commit := recorder.waitFor(t, notifications.EventGitCommit)
+	if p, ok := commit.Payload.(notifications.GitCommitPayload); !ok || p.Subject != "Add the thing" || p.Commit == "" {
+		t.Errorf("git commit payload = %+v", commit.Payload)
+	}
+	if len(manager.pendingToolCalls) != 0 {
+		t.Errorf("tool call still pending after its result")
+	}
+}
+
+func TestPendingToolCallLongCommit(t *testing.T) {
+	command := "echo " + strings.Repeat("x", 2*maxNotifiedToolInput) + " && git commit -m 'Add the thing'"
+	input, err := json.Marshal(map[string]string{"command": command})
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := newPendingToolCall("bash", input)
+	if !call.gitCommit {
+		t.Error("commit at the end of a long command not detected")
+	}
+	if len(call.input) != maxNotifiedToolInput+len("...") {
+		t.Errorf("input not truncated for the payload: %d bytes", len(call.input))
+	}
+
+	input, err = json.Marshal(map[string]string{"command": "echo x" + strings.Repeat("é", maxNotifiedToolInput)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call := newPendingToolCall("bash", input); !utf8.ValidString(call.input) {
+		t.Errorf("input truncated inside a character: %q", call.input)
+	}
+}
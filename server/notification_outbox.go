@@ -0,0 +1,272 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"shelley.exe.dev/db"
+	"shelley.exe.dev/db/generated"
+	"shelley.exe.dev/server/notifications"
+)
+
+const (
+	// notificationOutboxInterval is how often the outbox looks for due
+	// deliveries when nothing wakes it sooner.
+	notificationOutboxInterval = 5 * time.Second
+	// notificationOutboxBatch limits the deliveries loaded per pass.
+	notificationOutboxBatch = 200
+	// notificationChannelMinInterval rate-limits each channel: after a send,
+	// its due deliveries wait this long and are then sent together, with
+	// several events of one type coalesced into a digest.
+	notificationChannelMinInterval = 10 * time.Second
+	// subagentDigestWindow holds subagent completions back briefly so that a
+	// burst of them arrives as a single digest.
+	subagentDigestWindow = 30 * time.Second
+	// notificationRetryBase and notificationRetryMax bound the exponential
+	// backoff between attempts at a failed delivery.
+	notificationRetryBase = 10 * time.Second
+	notificationRetryMax  = 10 * time.Minute
+	// notificationMaxAttempts is how many times a delivery is tried before it
+	// is marked failed.
+	notificationMaxAttempts = 6
+	// notificationDeliveryRetention is how long sent and failed deliveries
+	// are kept for the delivery history, as an SQLite datetime modifier.
+	notificationDeliveryRetention = "-7 days"
+)
+
+// notificationOutbox persists notification events per channel and delivers
+// them in the background, retrying failures with backoff. It implements
+// notifications.Outbox.
+type notificationOutbox struct {
+	db         *db.DB
+	dispatcher *notifications.Dispatcher
+	logger     *slog.Logger
+	wake       chan struct{}
+
+	// lastSent is when each channel was last sent to. It is only used by
+	// the delivery loop.
+	lastSent  map[string]time.Time
+	lastPrune time.Time
+}
+
+func newNotificationOutbox(database *db.DB, dispatcher *notifications.Dispatcher, logger *slog.Logger) *notificationOutbox {
+	return &notificationOutbox{
+		db:         database,
+		dispatcher: dispatcher,
+		logger:     logger,
+		wake:       make(chan struct{}, 1),
+		lastSent:   make(map[string]time.Time),
+	}
+}
+
+// Enqueue stores event for delivery to the channel and wakes the delivery loop.
+func (o *notificationOutbox) Enqueue(ctx context.Context, channelID string, event notifications.Event) error {
+	eventJSON, err := notifications.MarshalEvent(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	var delay time.Duration
+	if event.Type == notifications.EventSubagentDone {
+		delay = subagentDigestWindow
+	}
+	var conversationID *string
+	if event.ConversationID != "" {
+		conversationID = &event.ConversationID
+	}
+	_, err = o.db.CreateNotificationDelivery(ctx, generated.CreateNotificationDeliveryParams{
+		ChannelID:      channelID,
+		EventType:      string(event.Type),
+		ConversationID: conversationID,
+		EventJson:      string(eventJSON),
+		Delay:          sqliteDelay(delay),
+	})
+	if err != nil {
+		return err
+	}
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// run delivers due notifications until shutdown is closed.
+func (o *notificationOutbox) run(shutdown <-chan struct{}) {
+	ticker := time.NewTicker(notificationOutboxInterval)
+	defer ticker.Stop()
+
+	for {
+		o.deliverDue(context.Background())
+		select {
+		case <-ticker.C:
+		case <-o.wake:
+		case <-shutdown:
+			return
+		}
+	}
+}
+
+// deliverDue makes one pass over the pending deliveries. They are grouped by
+// channel and event type, and each group with a delivery that is due is sent
+// as one message: the event itself, or a digest when there are several.
+// Events that can be replied to are never grouped, since a digest offers no
+// way to answer them. Groups for a channel that was sent to recently wait
+// for a later pass.
+func (o *notificationOutbox) deliverDue(ctx context.Context) {
+	if time.Since(o.lastPrune) > time.Hour {
+		o.lastPrune = time.Now()
+		if err := o.db.DeleteFinishedNotificationDeliveries(ctx, notificationDeliveryRetention); err != nil {
+			o.logger.Warn("Failed to prune notification deliveries", "error", err)
+		}
+	}
+
+	pending, err := o.db.GetPendingNotificationDeliveries(ctx, notificationOutboxBatch)
+	if err != nil {
+		o.logger.Error("Failed to load notification deliveries", "error", err)
+		return
+	}
+
+	type group struct {
+		channelID  string
+		eventType  string
+		due        bool
+		deliveries []generated.NotificationDelivery
+	}
+	now := time.Now()
+	var groups []*group
+	byKey := make(map[[2]string]*group)
+	for _, d := range pending {
+		key := [2]string{d.ChannelID, d.EventType}
+		if hasReply(d) {
+			key[1] = "#" + strconv.FormatInt(d.DeliveryID, 10)
+		}
+		g := byKey[key]
+		if g == nil {
+			g = &group{channelID: d.ChannelID, eventType: d.EventType}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.due = g.due || !d.NextAttemptAt.After(now)
+		g.deliveries = append(g.deliveries, d)
+	}
+
+	sentThisPass := make(map[string]bool)
+	for _, g := range groups {
+		if !g.due {
+			continue
+		}
+		if !sentThisPass[g.channelID] && now.Sub(o.lastSent[g.channelID]) < notificationChannelMinInterval {
+			continue
+		}
+		ch, ok := o.dispatcher.Channel(g.channelID)
+		if !ok {
+			o.finish(ctx, g.deliveries, errors.New("channel is disabled or misconfigured"), true)
+			continue
+		}
+		event, err := o.combine(g.deliveries)
+		if err != nil {
+			o.finish(ctx, g.deliveries, err, true)
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err = ch.Send(sendCtx, event)
+		cancel()
+		sentThisPass[g.channelID] = true
+		o.lastSent[g.channelID] = now
+		if err != nil {
+			o.logger.Warn("notification channel failed",
+				"channel", ch.Name(),
+				"event", g.eventType,
+				"error", err,
+			)
+		}
+		o.finish(ctx, g.deliveries, err, false)
+	}
+}
+
+// hasReply reports whether a delivery's event carries a Reply.
+func hasReply(d generated.NotificationDelivery) bool {
+	var event struct {
+		Reply *notifications.Reply `json:"reply"`
+	}
+	return json.Unmarshal([]byte(d.EventJson), &event) == nil && event.Reply != nil
+}
+
+// combine decodes deliveries of one type into the event to send, wrapping
+// them in an EventDigest if there is more than one.
+func (o *notificationOutbox) combine(deliveries []generated.NotificationDelivery) (notifications.Event, error) {
+	events := make([]notifications.Event, 0, len(deliveries))
+	for _, d := range deliveries {
+		event, err := notifications.UnmarshalEvent([]byte(d.EventJson))
+		if err != nil {
+			return notifications.Event{}, fmt.Errorf("decode event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if len(events) == 1 {
+		return events[0], nil
+	}
+	last := events[len(events)-1]
+	return notifications.Event{
+		Type:      notifications.EventDigest,
+		Timestamp: last.Timestamp,
+		Payload:   notifications.DigestPayload{Type: last.Type, Events: events},
+		Subagent:  last.Subagent,
+	}, nil
+}
+
+// finish records the outcome of an attempt at deliveries. Failed deliveries
+// are retried with backoff unless permanent is set or they are out of attempts.
+func (o *notificationOutbox) finish(ctx context.Context, deliveries []generated.NotificationDelivery, sendErr error, permanent bool) {
+	for _, d := range deliveries {
+		params := generated.UpdateNotificationDeliveryParams{
+			Status:     "sent",
+			Attempts:   d.Attempts + 1,
+			Delay:      sqliteDelay(0),
+			DeliveryID: d.DeliveryID,
+		}
+		if permanent {
+			params.Attempts = d.Attempts
+		}
+		if sendErr != nil {
+			msg := sendErr.Error()
+			params.LastError = &msg
+			params.Status = "failed"
+			if !permanent && params.Attempts < notificationMaxAttempts {
+				params.Status = "pending"
+				params.Delay = sqliteDelay(notificationRetryDelay(params.Attempts))
+			}
+		}
+		result := params.Status
+		if result == "pending" {
+			result = "retry"
+		}
+		notificationDelivered.Inc(d.EventType, result)
+		if err := o.db.UpdateNotificationDelivery(ctx, params); err != nil {
+			o.logger.Error("Failed to update notification delivery", "id", d.DeliveryID, "error", err)
+		}
+	}
+}
+
+// notificationRetryDelay returns the wait before the next attempt after the
+// given number of failed attempts.
+func notificationRetryDelay(attempts int64) time.Duration {
+	delay := notificationRetryBase
+	for i := int64(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= notificationRetryMax {
+			return notificationRetryMax
+		}
+	}
+	return delay
+}
+
+// sqliteDelay formats d as an SQLite datetime modifier.
+func sqliteDelay(d time.Duration) string {
+	return fmt.Sprintf("+%d seconds", int64(d/time.Second))
+}
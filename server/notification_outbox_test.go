@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"shelley.exe.dev/db/generated"
+	"shelley.exe.dev/server/notifications"
+)
+
+// flakyChannel records events and fails while fail is set.
+type flakyChannel struct {
+	mu     sync.Mutex
+	fail   bool
+	events []notifications.Event
+}
+
+func (c *flakyChannel) Name() string { return "flaky" }
+
+func (c *flakyChannel) Send(_ context.Context, event notifications.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail {
+		return errors.New("service unavailable")
+	}
+	c.events = append(c.events, event)
+	return nil
+}
+
+func (c *flakyChannel) received() []notifications.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]notifications.Event(nil), c.events...)
+}
+
+var outboxTestChannel = &flakyChannel{}
+
+func init() {
+	notifications.Register("outbox-test", func(map[string]any, *slog.Logger) (notifications.Channel, error) {
+		return outboxTestChannel, nil
+	})
+}
+
+func TestNotificationOutbox(t *testing.T) {
+	server, database, _ := newTestServer(t)
+	ctx := context.Background()
+	ch := outboxTestChannel
+	ch.fail = true
+
+	if _, err := database.CreateNotificationChannel(ctx, generated.CreateNotificationChannelParams{
+		ChannelID:   "notif-outbox",
+		ChannelType: "outbox-test",
+		DisplayName: "Outbox",
+		Enabled:     1,
+		Config:      `{"include_subagents": true, "replies": true}`,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	server.ReloadNotificationChannels()
+	outbox := server.notifOutbox
+
+	// makeDue moves every pending delivery's next attempt to now and lifts the
+	// channel's rate limit, so tests need not wait out the delays.
+	makeDue := func() {
+		t.Helper()
+		pending, err := database.GetPendingNotificationDeliveries(ctx, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range pending {
+			if err := database.UpdateNotificationDelivery(ctx, generated.UpdateNotificationDeliveryParams{
+				Status:     d.Status,
+				Attempts:   d.Attempts,
+				LastError:  d.LastError,
+				Delay:      sqliteDelay(0),
+				DeliveryID: d.DeliveryID,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		delete(outbox.lastSent, "notif-outbox")
+	}
+	history := func() []NotificationDeliveryAPI {
+		t.Helper()
+		mux := http.NewServeMux()
+		server.RegisterRoutes(mux)
+		req := httptest.NewRequest(http.MethodGet, "/api/notification-channels/notif-outbox/deliveries", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("history status %d: %s", w.Code, w.Body.String())
+		}
+		var deliveries []NotificationDeliveryAPI
+		if err := json.Unmarshal(w.Body.Bytes(), &deliveries); err != nil {
+			t.Fatal(err)
+		}
+		return deliveries
+	}
+
+	server.notifDispatcher.Dispatch(ctx, notifications.Event{
+		Type:           notifications.EventAgentDone,
+		ConversationID: "c-1",
+		Timestamp:      time.Now(),
+		Payload:        notifications.AgentDonePayload{ConversationTitle: "first"},
+		Duration:       90 * time.Second,
+	})
+
+	// A failed send stays pending with the error recorded.
+	outbox.deliverDue(ctx)
+	got := history()
+	if len(got) != 1 || got[0].Status != "pending" || got[0].Attempts != 1 || got[0].LastError == nil || got[0].Title != "Agent finished: first" {
+		t.Fatalf("after failure: %+v", got)
+	}
+	if !got[0].NextAttemptAt.After(got[0].UpdatedAt) {
+		t.Errorf("retry not delayed: next attempt %v, updated %v", got[0].NextAttemptAt, got[0].UpdatedAt)
+	}
+
+	// Not yet due, so nothing is retried.
+	ch.fail = false
+	outbox.deliverDue(ctx)
+	if len(ch.received()) != 0 {
+		t.Fatalf("retried before backoff elapsed")
+	}
+
+	makeDue()
+	outbox.deliverDue(ctx)
+	events := ch.received()
+	if len(events) != 1 || events[0].Duration != 90*time.Second {
+		t.Fatalf("after retry: %+v", events)
+	}
+	if p, ok := events[0].Payload.(notifications.AgentDonePayload); !ok || p.ConversationTitle != "first" {
+		t.Errorf("payload not restored: %#v", events[0].Payload)
+	}
+	if got := history(); got[0].Status != "sent" || got[0].Attempts != 2 {
+		t.Errorf("after retry: %+v", got[0])
+	}
+
+	// A burst of subagent completions is held back, then sent as one digest.
+	for _, title := range []string{"a", "b", "c"} {
+		server.notifDispatcher.Dispatch(ctx, notifications.Event{
+			Type:      notifications.EventSubagentDone,
+			Timestamp: time.Now(),
+			Payload:   notifications.SubagentDonePayload{ConversationTitle: title},
+			Subagent:  true,
+		})
+	}
+	delete(outbox.lastSent, "notif-outbox")
+	outbox.deliverDue(ctx)
+	if len(ch.received()) != 1 {
+		t.Fatalf("subagent completions sent before the digest window")
+	}
+	makeDue()
+	outbox.deliverDue(ctx)
+	events = ch.received()
+	if len(events) != 2 || events[1].Type != notifications.EventDigest {
+		t.Fatalf("want one digest, got %+v", events)
+	}
+	summary, _ := notifications.Describe(events[1])
+	if summary.Title != "3 subagents finished" || summary.Body != "• Subagent finished: a\n• Subagent finished: b\n• Subagent finished: c" {
+		t.Errorf("digest summary = %+v", summary)
+	}
+	got = history()
+	if len(got) != 4 {
+		t.Fatalf("history has %d entries, want 4", len(got))
+	}
+	for _, d := range got {
+		if d.Status != "sent" {
+			t.Errorf("delivery %d status %q", d.DeliveryID, d.Status)
+		}
+	}
+
+	// Sends to a channel are rate-limited.
+	server.notifDispatcher.Dispatch(ctx, notifications.Event{Type: notifications.EventAgentError, Timestamp: time.Now()})
+	outbox.deliverDue(ctx)
+	if len(ch.received()) != 2 {
+		t.Errorf("rate limit not applied")
+	}
+	makeDue()
+	outbox.deliverDue(ctx)
+
+	// Events that can be replied to are each sent on their own, with their
+	// reply, rather than coalesced.
+	for _, id := range []string{"a1", "a2"} {
+		server.notifDispatcher.Dispatch(ctx, notifications.Event{
+			Type:           notifications.EventToolApprovalNeeded,
+			ConversationID: "c-1",
+			Timestamp:      time.Now(),
+			Payload:        notifications.ToolApprovalPayload{ToolName: "bash", Input: "make " + id, ApprovalID: id},
+			Reply:          &notifications.Reply{Token: "token-" + id, Actions: []notifications.ReplyAction{notifications.ReplyApprove}},
+		})
+	}
+	makeDue()
+	outbox.deliverDue(ctx)
+	events = ch.received()[3:]
+	if len(events) != 2 {
+		t.Fatalf("want two approval requests, got %+v", events)
+	}
+	for i, e := range events {
+		if e.Type != notifications.EventToolApprovalNeeded || e.Reply == nil || e.Reply.Token != "token-a"+strconv.Itoa(i+1) {
+			t.Errorf("approval request %d = %+v", i, e)
+		}
+	}
+}
+
+func TestNotificationRetryDelay(t *testing.T) {
+	for attempts, want := range map[int64]time.Duration{
+		1: 10 * time.Second,
+		2: 20 * time.Second,
+		4: 80 * time.Second,
+		7: 10 * time.Minute,
+	} {
+		if got := notificationRetryDelay(attempts); got != want {
+			t.Errorf("notificationRetryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
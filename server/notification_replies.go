@@ -0,0 +1,436 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/server/notifications"
+)
+
+const (
+	// replyTokenTTL is how long a notification can be replied to.
+	replyTokenTTL = 24 * time.Hour
+	// replySecretSetting stores the key reply tokens are signed with. It is
+	// generated on first use and never returned by the settings API.
+	replySecretSetting = "notification_reply_secret"
+	// publicURLSetting is the URL the server is reachable at from wherever
+	// notifications are read, used to build reply links.
+	publicURLSetting = "public_url"
+	// maxReplyBody limits the size of reply requests, including raw emails.
+	maxReplyBody = 1 << 20
+)
+
+var errApprovalNotPending = errors.New("approval is no longer pending")
+
+// replyActions lists the replies offered for each event type. Events of
+// other types cannot be replied to.
+var replyActions = map[notifications.EventType][]notifications.ReplyAction{
+	notifications.EventAgentDone:         {notifications.ReplyMessage},
+	notifications.EventAgentError:        {notifications.ReplyMessage},
+	notifications.EventToolRunning:       {notifications.ReplyMessage},
+	notifications.EventContextNearlyFull: {notifications.ReplyMessage},
+	notifications.EventToolApprovalNeeded: {
+		notifications.ReplyApprove,
+		notifications.ReplyDeny,
+		notifications.ReplyMessage,
+	},
+}
+
+// toolApprovals tracks tool calls refused by a permission check, which the
+// user may approve or deny, and the calls they approved and the agent has
+// not yet run.
+type toolApprovals struct {
+	mu       sync.Mutex
+	pending  map[string]toolCallKey
+	approved map[toolCallKey]bool
+}
+
+type toolCallKey struct {
+	toolName string
+	input    string
+}
+
+// request records a refused tool call and returns its approval ID. Asking
+// again for the same call returns the same ID.
+func (a *toolApprovals) request(toolName, input string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := toolCallKey{toolName, input}
+	for id, k := range a.pending {
+		if k == key {
+			return id
+		}
+	}
+	if a.pending == nil {
+		a.pending = make(map[string]toolCallKey)
+	}
+	id := uuid.New().String()[:8]
+	a.pending[id] = key
+	return id
+}
+
+// resolve answers the pending approval with the given ID, returning the
+// tool call it was for.
+func (a *toolApprovals) resolve(id string, approve bool) (toolCallKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key, ok := a.pending[id]
+	if !ok {
+		return toolCallKey{}, errApprovalNotPending
+	}
+	delete(a.pending, id)
+	if approve {
+		if a.approved == nil {
+			a.approved = make(map[toolCallKey]bool)
+		}
+		a.approved[key] = true
+	}
+	return key, nil
+}
+
+// pendingCall returns the tool call awaiting the approval with the given ID.
+func (a *toolApprovals) pendingCall(id string) (toolCallKey, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key, ok := a.pending[id]
+	return key, ok
+}
+
+// unresolve undoes resolve, for an answer that could not be passed on to
+// the agent.
+func (a *toolApprovals) unresolve(id string, key toolCallKey) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.approved, key)
+	if a.pending == nil {
+		a.pending = make(map[string]toolCallKey)
+	}
+	a.pending[id] = key
+}
+
+// isApproved reports whether the user approved this exact tool call. An
+// approval covers a single call, so it is used up when this reports true.
+func (a *toolApprovals) isApproved(toolName, input string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := toolCallKey{toolName, input}
+	if !a.approved[key] {
+		return false
+	}
+	delete(a.approved, key)
+	return true
+}
+
+// replySecret returns the key reply tokens are signed with, creating it on
+// first use.
+func (s *Server) replySecret(ctx context.Context) ([]byte, error) {
+	s.replySecretMu.Lock()
+	defer s.replySecretMu.Unlock()
+	if s.replySecretKey != nil {
+		return s.replySecretKey, nil
+	}
+	value, err := s.db.GetSetting(ctx, replySecretSetting)
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		value = hex.EncodeToString(b)
+		if err := s.db.SetSetting(ctx, replySecretSetting, value); err != nil {
+			return nil, err
+		}
+	}
+	s.replySecretKey = []byte(value)
+	return s.replySecretKey, nil
+}
+
+// attachReply sets event.Reply for events that can be answered from a
+// notification. Subagent events are never answerable.
+func (s *Server) attachReply(ctx context.Context, event *notifications.Event) {
+	actions := replyActions[event.Type]
+	if len(actions) == 0 || event.Subagent || event.ConversationID == "" {
+		return
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		s.logger.Warn("Failed to generate notification reply token", "error", err)
+		return
+	}
+	claims := notifications.ReplyClaims{
+		ID:             hex.EncodeToString(id),
+		ConversationID: event.ConversationID,
+		Expires:        time.Now().Add(replyTokenTTL).Unix(),
+	}
+	if p, ok := event.Payload.(notifications.ToolApprovalPayload); ok {
+		claims.ApprovalID = p.ApprovalID
+	}
+	if claims.ApprovalID == "" {
+		actions = []notifications.ReplyAction{notifications.ReplyMessage}
+	}
+	secret, err := s.replySecret(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to load notification reply secret", "error", err)
+		return
+	}
+	reply := &notifications.Reply{
+		Token:   notifications.SignReplyToken(secret, claims),
+		Actions: actions,
+	}
+	if base, err := s.db.GetSetting(ctx, publicURLSetting); err == nil && base != "" {
+		reply.URL = strings.TrimSuffix(base, "/") + "/notifications/reply?token=" + url.QueryEscape(reply.Token)
+	}
+	event.Reply = reply
+}
+
+// handleNotificationReply handles POST /notifications/reply, the answer to a
+// notification. The reply token, from the "token" query parameter or body
+// field, authenticates the request. The body is JSON {"action", "message"},
+// a form with those fields, or plain text to post as a message.
+func (s *Server) handleNotificationReply(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxReplyBody)
+	var req struct {
+		Token   string                    `json:"token"`
+		Action  notifications.ReplyAction `json:"action"`
+		Message string                    `json:"message"`
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		req.Token = r.PostFormValue("token")
+		req.Action = notifications.ReplyAction(r.PostFormValue("action"))
+		req.Message = r.PostFormValue("message")
+	default:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		req.Message = string(body)
+	}
+	if token := r.URL.Query().Get("token"); token != "" {
+		req.Token = token
+	}
+	if req.Action == "" {
+		req.Action = notifications.ReplyMessage
+	}
+
+	claims, err := s.verifyReplyToken(r.Context(), req.Token)
+	if err != nil {
+		s.writeReplyError(w, err)
+		return
+	}
+	if err := s.applyReply(r.Context(), claims, req.Action, req.Message); err != nil {
+		s.writeReplyError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleNotificationEmailReply handles POST /notifications/email-reply, which
+// takes a raw RFC 822 reply to a notification email. It stands in for a mail
+// server: a local MTA or mailbox poller delivers replies here.
+func (s *Server) handleNotificationEmailReply(w http.ResponseWriter, r *http.Request) {
+	reply, err := notifications.ParseEmailReply(http.MaxBytesReader(w, r.Body, maxReplyBody))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid email: %v", err), http.StatusBadRequest)
+		return
+	}
+	claims, err := s.verifyReplyToken(r.Context(), reply.Token)
+	if err != nil {
+		s.writeReplyError(w, err)
+		return
+	}
+	// A reply to an approval request that starts with "approve" or "deny"
+	// answers it; anything else is a message.
+	action, message := notifications.ReplyMessage, reply.Text
+	if claims.ApprovalID != "" {
+		action, message = notifications.ParseApprovalReply(reply.Text)
+	}
+	if err := s.applyReply(r.Context(), claims, action, message); err != nil {
+		s.writeReplyError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+type replyError struct {
+	code int
+	err  error
+}
+
+func (e *replyError) Error() string { return e.err.Error() }
+
+func (s *Server) writeReplyError(w http.ResponseWriter, err error) {
+	var re *replyError
+	if errors.As(err, &re) {
+		http.Error(w, re.Error(), re.code)
+		return
+	}
+	s.logger.Error("Failed to apply notification reply", "error", err)
+	http.Error(w, fmt.Sprintf("Failed to apply reply: %v", err), http.StatusInternalServerError)
+}
+
+// verifyReplyToken checks a reply token and returns its claims.
+func (s *Server) verifyReplyToken(ctx context.Context, token string) (notifications.ReplyClaims, error) {
+	secret, err := s.replySecret(ctx)
+	if err != nil {
+		return notifications.ReplyClaims{}, err
+	}
+	claims, err := notifications.VerifyReplyToken(secret, token, time.Now())
+	if err != nil {
+		return notifications.ReplyClaims{}, &replyError{http.StatusUnauthorized, err}
+	}
+	return claims, nil
+}
+
+// applyReply carries out a verified reply: posting message to the
+// conversation, or answering the pending approval the token was issued for.
+// The token is used up only once the reply has been checked, and given back
+// if it cannot be carried out, so a reply that fails can be tried again.
+func (s *Server) applyReply(ctx context.Context, claims notifications.ReplyClaims, action notifications.ReplyAction, message string) error {
+	if !claims.Allows(action) {
+		return &replyError{http.StatusForbidden, fmt.Errorf("reply action %q is not allowed", action)}
+	}
+	message = strings.TrimSpace(message)
+	if action == notifications.ReplyMessage && message == "" {
+		return &replyError{http.StatusBadRequest, errors.New("message is required")}
+	}
+
+	var approvals *toolApprovals
+	var call toolCallKey
+	approve := action == notifications.ReplyApprove
+	if approve || action == notifications.ReplyDeny {
+		s.mu.Lock()
+		manager := s.activeConversations[claims.ConversationID]
+		s.mu.Unlock()
+		if manager == nil {
+			return &replyError{http.StatusConflict, errApprovalNotPending}
+		}
+		var ok bool
+		if call, ok = manager.approvals.pendingCall(claims.ApprovalID); !ok {
+			return &replyError{http.StatusConflict, errApprovalNotPending}
+		}
+		approvals = &manager.approvals
+		answer := fmt.Sprintf("I approved this %s call, so you can now run it: %s", call.toolName, call.input)
+		if !approve {
+			answer = fmt.Sprintf("I denied this %s call. Do not run it: %s", call.toolName, call.input)
+		}
+		if message != "" {
+			answer += "\n\n" + message
+		}
+		message = answer
+	}
+	target, err := s.replyTarget(ctx, claims.ConversationID)
+	if err != nil {
+		return err
+	}
+
+	first, err := s.db.UseReplyToken(ctx, claims.ID, claims.Expires)
+	if err != nil {
+		return err
+	}
+	if !first {
+		return &replyError{http.StatusConflict, notifications.ErrUsedReplyToken}
+	}
+	release := func() {
+		if err := s.db.ReleaseReplyToken(context.WithoutCancel(ctx), claims.ID); err != nil {
+			s.logger.Warn("Failed to release notification reply token", "error", err)
+		}
+	}
+	if approvals != nil {
+		// The approval may have been answered some other way meanwhile.
+		if _, err := approvals.resolve(claims.ApprovalID, approve); err != nil {
+			release()
+			return &replyError{http.StatusConflict, err}
+		}
+	}
+	if err := s.postUserMessage(ctx, target, message); err != nil {
+		if approvals != nil {
+			approvals.unresolve(claims.ApprovalID, call)
+		}
+		release()
+		return err
+	}
+	return nil
+}
+
+// messageTarget is a conversation ready to be sent a user message.
+type messageTarget struct {
+	conversationID string
+	modelID        string
+	service        llm.Service
+	manager        *ConversationManager
+}
+
+// replyTarget looks up the conversation a reply posts to, failing if a
+// message could not be sent to it.
+func (s *Server) replyTarget(ctx context.Context, conversationID string) (*messageTarget, error) {
+	conv, err := s.db.GetConversationByID(ctx, conversationID)
+	if err != nil {
+		return nil, &replyError{http.StatusNotFound, fmt.Errorf("conversation not found: %w", err)}
+	}
+	modelID := s.defaultModel
+	if conv.Model != nil && *conv.Model != "" {
+		modelID = *conv.Model
+	}
+	llmService, err := s.llmManager.GetService(modelID)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported model %s: %w", modelID, err)
+	}
+	manager, err := s.getOrCreateConversationManager(ctx, conversationID, "")
+	if err != nil {
+		return nil, fmt.Errorf("get conversation manager: %w", err)
+	}
+	return &messageTarget{conversationID: conversationID, modelID: modelID, service: llmService, manager: manager}, nil
+}
+
+// postUserMessage adds a user message to the target conversation and starts
+// a turn, as if the user had sent it from the UI.
+func (s *Server) postUserMessage(ctx context.Context, target *messageTarget, text string) error {
+	job, err := s.jobs.StartJob(ctx, StartJobParams{
+		ConversationID: target.conversationID,
+		Kind:           JobKindTurn,
+		ModelID:        target.modelID,
+		Input: map[string]any{
+			"message": text,
+			"model":   target.modelID,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("start turn job: %w", err)
+	}
+	userMessage := llm.Message{
+		Role:    llm.MessageRoleUser,
+		Content: []llm.Content{{Type: llm.ContentTypeText, Text: text}},
+	}
+	if _, err := target.manager.AcceptUserMessage(ctx, target.service, target.modelID, userMessage); err != nil {
+		if finishErr := s.markJobFailed(ctx, job.JobID, err); finishErr != nil {
+			return finishErr
+		}
+		return fmt.Errorf("accept user message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"shelley.exe.dev/db"
+	"shelley.exe.dev/db/generated"
+	"shelley.exe.dev/server/notifications"
+)
+
+func TestNotificationReplies(t *testing.T) {
+	server, database, _ := newTestServer(t)
+	ctx := context.Background()
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+
+	model := "predictable"
+	conv, err := database.CreateConversation(ctx, nil, true, nil, &model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := server.getOrCreateConversationManager(ctx, conv.ConversationID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetSetting(ctx, publicURLSetting, "https://shelley.example.com/"); err != nil {
+		t.Fatal(err)
+	}
+
+	// replyFor returns the reply attached to an event for the conversation.
+	replyFor := func(eventType notifications.EventType, payload any) *notifications.Reply {
+		t.Helper()
+		event := notifications.Event{Type: eventType, ConversationID: conv.ConversationID, Payload: payload}
+		server.attachReply(ctx, &event)
+		if event.Reply == nil {
+			t.Fatalf("no reply attached to %s", eventType)
+		}
+		return event.Reply
+	}
+	post := func(target, contentType, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	replyPath := func(reply *notifications.Reply) string {
+		t.Helper()
+		u, err := url.Parse(reply.URL)
+		if err != nil || u.Host != "shelley.example.com" {
+			t.Fatalf("reply URL %q", reply.URL)
+		}
+		return u.RequestURI()
+	}
+	waitForUserMessage := func(text string) {
+		t.Helper()
+		waitFor(t, 5*time.Second, func() bool {
+			var messages []generated.Message
+			database.Queries(ctx, func(q *generated.Queries) error {
+				var err error
+				messages, err = q.ListMessages(ctx, conv.ConversationID)
+				return err
+			})
+			for _, m := range messages {
+				if m.Type == string(db.MessageTypeUser) && m.LlmData != nil && strings.Contains(*m.LlmData, text) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	done := replyFor(notifications.EventAgentDone, nil)
+	if len(done.Actions) != 1 || done.Actions[0] != notifications.ReplyMessage {
+		t.Errorf("agent_done reply actions = %v", done.Actions)
+	}
+	if w := post(replyPath(done), "text/plain", "Now add tests"); w.Code != http.StatusOK {
+		t.Fatalf("message reply: %d %s", w.Code, w.Body.String())
+	}
+	waitForUserMessage("Now add tests")
+	if w := post(replyPath(done), "text/plain", "And docs"); w.Code != http.StatusConflict {
+		t.Errorf("reusing a message token: %d", w.Code)
+	}
+
+	if w := post(replyPath(done), "application/json", `{"action":"approve"}`); w.Code != http.StatusForbidden {
+		t.Errorf("approve with a message-only token: %d", w.Code)
+	}
+	if w := post("/notifications/reply?token=forged.token", "text/plain", "hi"); w.Code != http.StatusUnauthorized {
+		t.Errorf("forged token: %d", w.Code)
+	}
+
+	approvalID := manager.approvals.request("bash", "make deploy")
+	approval := replyFor(notifications.EventToolApprovalNeeded, notifications.ToolApprovalPayload{ToolName: "bash", Input: "make deploy", ApprovalID: approvalID})
+	if w := post(replyPath(approval), "application/json", `{"action":"approve"}`); w.Code != http.StatusOK {
+		t.Fatalf("approve: %d %s", w.Code, w.Body.String())
+	}
+	if !manager.approvals.isApproved("bash", "make deploy") {
+		t.Error("command not approved")
+	}
+	if manager.approvals.isApproved("bash", "make deploy") {
+		t.Error("one approval allowed the command twice")
+	}
+	waitForUserMessage("I approved this bash call")
+	if w := post(replyPath(approval), "application/json", `{"action":"deny"}`); w.Code != http.StatusConflict {
+		t.Errorf("answering an approval twice: %d", w.Code)
+	}
+
+	// An emailed "deny" answers an approval request.
+	approvalID = manager.approvals.request("bash", "rm -rf /")
+	approval = replyFor(notifications.EventToolApprovalNeeded, notifications.ToolApprovalPayload{ToolName: "bash", Input: "rm -rf /", ApprovalID: approvalID})
+	email := "Subject: Re: Approval needed\r\n\r\nDeny\r\nNever do that.\r\n\r\n> " + notifications.EmailReplyMarker(approval.Token) + "\r\n"
+	if w := post("/notifications/email-reply", "message/rfc822", email); w.Code != http.StatusOK {
+		t.Fatalf("email reply: %d %s", w.Code, w.Body.String())
+	}
+	if manager.approvals.isApproved("bash", "rm -rf /") {
+		t.Error("denied command approved")
+	}
+	waitForUserMessage("Never do that.")
+
+	// A reply that cannot be carried out yet leaves its token usable.
+	approvalID = manager.approvals.request("bash", "make release")
+	approval = replyFor(notifications.EventToolApprovalNeeded, notifications.ToolApprovalPayload{ToolName: "bash", Input: "make release", ApprovalID: approvalID})
+	server.mu.Lock()
+	delete(server.activeConversations, conv.ConversationID)
+	server.mu.Unlock()
+	if w := post(replyPath(approval), "application/json", `{"action":"approve"}`); w.Code != http.StatusConflict {
+		t.Fatalf("approving for an inactive conversation: %d %s", w.Code, w.Body.String())
+	}
+	server.mu.Lock()
+	server.activeConversations[conv.ConversationID] = manager
+	server.mu.Unlock()
+	if w := post(replyPath(approval), "application/json", `{"action":"approve"}`); w.Code != http.StatusOK {
+		t.Fatalf("retrying an approval: %d %s", w.Code, w.Body.String())
+	}
+	if !manager.approvals.isApproved("bash", "make release") {
+		t.Error("command not approved on retry")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/settings", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if strings.Contains(w.Body.String(), replySecretSetting) {
+		t.Error("settings API exposes the reply secret")
+	}
+}
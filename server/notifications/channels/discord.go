@@ -119,6 +119,19 @@ func formatDiscordMessage(event notifications.Event) *discordMessage {
 		return &discordMessage{Embeds: []discordEmbed{embed}}
 
 	default:
-		return nil
+		summary, ok := notifications.Describe(event)
+		if !ok {
+			return nil
+		}
+		embed := discordEmbed{
+			Title:       summary.Title,
+			Description: summary.Body,
+			Color:       0x3b82f6, // blue
+			Timestamp:   event.Timestamp.Format(time.RFC3339),
+		}
+		if summary.Urgent {
+			embed.Color = 0xf59e0b // amber
+		}
+		return &discordMessage{Embeds: []discordEmbed{embed}}
 	}
 }
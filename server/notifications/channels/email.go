@@ -8,6 +8,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 
 	"shelley.exe.dev/server/notifications"
@@ -46,6 +47,7 @@ func (e *email) Send(ctx context.Context, event notifications.Event) error {
 	if subject == "" {
 		return nil
 	}
+	body += emailReplyFooter(event.Reply)
 
 	payload, err := json.Marshal(map[string]string{
 		"to":      e.to,
@@ -114,6 +116,27 @@ func formatEmailMessage(event notifications.Event) (subject, body string) {
 		return subject, body
 
 	default:
-		return "", ""
+		summary, ok := notifications.Describe(event)
+		if !ok {
+			return "", ""
+		}
+		body = fmt.Sprintf("Time: %s", event.Timestamp.Format(time.RFC822))
+		if summary.Body != "" {
+			body += "\n\n" + summary.Body
+		}
+		return summary.Title, body
+	}
+}
+
+// emailReplyFooter explains how to answer the notification by replying to
+// the email. The marker line lets the server match the reply to its token.
+func emailReplyFooter(reply *notifications.Reply) string {
+	if reply == nil {
+		return ""
+	}
+	footer := "\n\n--\nReply to this email to send a message to the agent."
+	if slices.Contains(reply.Actions, notifications.ReplyApprove) {
+		footer += ` Start your reply with "approve" or "deny" to answer the approval request.`
 	}
+	return footer + "\n" + notifications.EmailReplyMarker(reply.Token)
 }
@@ -8,6 +8,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 
 	"shelley.exe.dev/server/notifications"
@@ -84,11 +85,59 @@ type ntfy struct {
 func (n *ntfy) Name() string { return "ntfy" }
 
 type ntfyMessage struct {
-	Topic    string   `json:"topic"`
-	Title    string   `json:"title"`
-	Message  string   `json:"message"`
-	Priority int      `json:"priority"`
-	Tags     []string `json:"tags"`
+	Topic    string       `json:"topic"`
+	Title    string       `json:"title"`
+	Message  string       `json:"message"`
+	Priority int          `json:"priority"`
+	Tags     []string     `json:"tags"`
+	Actions  []ntfyAction `json:"actions,omitempty"`
+}
+
+// ntfyAction is an ntfy "http" action button, which the ntfy app sends
+// directly without opening a browser.
+type ntfyAction struct {
+	Action  string            `json:"action"`
+	Label   string            `json:"label"`
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+	Clear   bool              `json:"clear"`
+}
+
+// ntfyReplyButtons are the buttons offered for each reply action. ntfy
+// cannot collect text, so replying with a message sends a fixed one.
+var ntfyReplyButtons = []struct {
+	action notifications.ReplyAction
+	label  string
+	body   string
+}{
+	{notifications.ReplyApprove, "Approve", `{"action":"approve"}`},
+	{notifications.ReplyDeny, "Deny", `{"action":"deny"}`},
+	{notifications.ReplyMessage, "Continue", `{"action":"message","message":"Continue."}`},
+}
+
+// ntfyReplyActions returns buttons answering an event, if it can be replied to.
+func ntfyReplyActions(reply *notifications.Reply) []ntfyAction {
+	if reply == nil || reply.URL == "" {
+		return nil
+	}
+	var actions []ntfyAction
+	for _, b := range ntfyReplyButtons {
+		if !slices.Contains(reply.Actions, b.action) {
+			continue
+		}
+		actions = append(actions, ntfyAction{
+			Action:  "http",
+			Label:   b.label,
+			URL:     reply.URL,
+			Method:  http.MethodPost,
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    b.body,
+			Clear:   true,
+		})
+	}
+	return actions
 }
 
 func (n *ntfy) Send(ctx context.Context, event notifications.Event) error {
@@ -96,6 +145,7 @@ func (n *ntfy) Send(ctx context.Context, event notifications.Event) error {
 	if msg == nil {
 		return nil
 	}
+	msg.Actions = ntfyReplyActions(event.Reply)
 
 	body, err := json.Marshal(msg)
 	if err != nil {
@@ -175,6 +225,21 @@ func (n *ntfy) formatMessage(event notifications.Event) *ntfyMessage {
 		return msg
 
 	default:
-		return nil
+		summary, ok := notifications.Describe(event)
+		if !ok {
+			return nil
+		}
+		msg := &ntfyMessage{
+			Topic:    n.topic,
+			Title:    summary.Title,
+			Message:  summary.Body,
+			Priority: n.donePriority,
+			Tags:     []string{"information_source"},
+		}
+		if summary.Urgent {
+			msg.Priority = n.errorPriority
+			msg.Tags = []string{"warning"}
+		}
+		return msg
 	}
 }
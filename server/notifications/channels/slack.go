@@ -0,0 +1,152 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"shelley.exe.dev/server/notifications"
+)
+
+func init() {
+	notifications.Register("slack", func(config map[string]any, logger *slog.Logger) (notifications.Channel, error) {
+		url, ok := config["webhook_url"].(string)
+		if !ok || url == "" {
+			return nil, fmt.Errorf("slack channel requires \"webhook_url\"")
+		}
+		return newSlack(url), nil
+	})
+}
+
+type slack struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func newSlack(webhookURL string) *slack {
+	return &slack{
+		webhookURL: webhookURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (s *slack) Name() string { return "slack" }
+
+func (s *slack) Send(ctx context.Context, event notifications.Event) error {
+	msg := formatSlackMessage(event)
+	if msg == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal slack payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send slack webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("slack webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type slackMessage struct {
+	// Text is the fallback shown in notifications and clients without blocks.
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks,omitempty"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackEscape escapes the characters Slack treats as markup in mrkdwn text.
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func formatSlackMessage(event notifications.Event) *slackMessage {
+	var title, detail string
+	var footer []string
+	switch event.Type {
+	case notifications.EventAgentDone:
+		title = ":white_check_mark: Agent finished"
+		if p, ok := event.Payload.(notifications.AgentDonePayload); ok {
+			if p.ConversationTitle != "" {
+				title += ": " + slackEscape.Replace(p.ConversationTitle)
+			}
+			if p.Model != "" {
+				footer = append(footer, fmt.Sprintf("Model: `%s`", slackEscape.Replace(p.Model)))
+			}
+			detail = slackEscape.Replace(p.FinalResponse)
+		}
+		if event.Duration > 0 {
+			footer = append(footer, "Took "+event.Duration.Round(time.Second).String())
+		}
+
+	case notifications.EventAgentError:
+		title = ":x: Agent error"
+		if p, ok := event.Payload.(notifications.AgentErrorPayload); ok {
+			detail = slackEscape.Replace(p.ErrorMessage)
+		}
+
+	default:
+		summary, ok := notifications.Describe(event)
+		if !ok {
+			return nil
+		}
+		title = ":information_source: " + slackEscape.Replace(summary.Title)
+		if summary.Urgent {
+			title = ":warning: " + slackEscape.Replace(summary.Title)
+		}
+		// Describe already includes the directory in the body.
+		return newSlackMessage(title, slackEscape.Replace(summary.Body), nil)
+	}
+
+	if event.Cwd != "" {
+		footer = append(footer, fmt.Sprintf("`%s`", slackEscape.Replace(event.Cwd)))
+	}
+
+	return newSlackMessage(title, detail, footer)
+}
+
+func newSlackMessage(title, detail string, footer []string) *slackMessage {
+	msg := &slackMessage{Text: title}
+	msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "*" + title + "*"}})
+	if detail != "" {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: detail}})
+	}
+	if len(footer) > 0 {
+		block := slackBlock{Type: "context"}
+		for _, c := range footer {
+			block.Elements = append(block.Elements, slackText{Type: "mrkdwn", Text: c})
+		}
+		msg.Blocks = append(msg.Blocks, block)
+	}
+	return msg
+}
@@ -0,0 +1,188 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"shelley.exe.dev/server/notifications"
+)
+
+// webhookSignatureHeader carries the hex HMAC-SHA256 of the request body,
+// keyed by the channel's secret, as "sha256=<hex>".
+const webhookSignatureHeader = "X-Shelley-Signature"
+
+func init() {
+	notifications.Register("webhook", func(config map[string]any, logger *slog.Logger) (notifications.Channel, error) {
+		url, _ := config["url"].(string)
+		if url == "" {
+			return nil, fmt.Errorf("webhook channel requires \"url\"")
+		}
+
+		method, _ := config["method"].(string)
+		if method == "" {
+			method = http.MethodPost
+		}
+		method = strings.ToUpper(method)
+		if method != http.MethodPost && method != http.MethodPut {
+			return nil, fmt.Errorf("webhook channel: invalid method %q", method)
+		}
+
+		headers, err := parseWebhookHeaders(config["headers"])
+		if err != nil {
+			return nil, err
+		}
+
+		var tmpl *template.Template
+		if text, _ := config["body_template"].(string); strings.TrimSpace(text) != "" {
+			tmpl, err = template.New("body").Funcs(webhookTemplateFuncs).Option("missingkey=zero").Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("webhook channel: invalid body_template: %w", err)
+			}
+		}
+
+		contentType, _ := config["content_type"].(string)
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		secret, _ := config["secret"].(string)
+
+		return &webhook{
+			url:         url,
+			method:      method,
+			headers:     headers,
+			contentType: contentType,
+			template:    tmpl,
+			secret:      secret,
+			client: &http.Client{
+				Timeout: 10 * time.Second,
+			},
+		}, nil
+	})
+}
+
+var webhookTemplateFuncs = template.FuncMap{
+	// json renders a value as JSON, for embedding strings safely in JSON bodies.
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// parseWebhookHeaders accepts a JSON object of strings or "Name: value" lines.
+func parseWebhookHeaders(v any) (http.Header, error) {
+	headers := http.Header{}
+	switch v := v.(type) {
+	case nil:
+	case map[string]any:
+		for name, value := range v {
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("webhook channel: header %q must be a string", name)
+			}
+			headers.Set(name, s)
+		}
+	case string:
+		for _, line := range strings.Split(v, "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(line, ":")
+			if !ok || strings.TrimSpace(name) == "" {
+				return nil, fmt.Errorf("webhook channel: invalid header line %q, want \"Name: value\"", line)
+			}
+			headers.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	default:
+		return nil, fmt.Errorf("webhook channel: \"headers\" must be an object or \"Name: value\" lines")
+	}
+	return headers, nil
+}
+
+type webhook struct {
+	url         string
+	method      string
+	headers     http.Header
+	contentType string
+	template    *template.Template
+	secret      string
+	client      *http.Client
+}
+
+func (wh *webhook) Name() string { return "webhook" }
+
+// webhookData is the default JSON body and the data passed to body templates.
+type webhookData struct {
+	notifications.Event
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	// Title and Text are a plain-text rendering of the event (see notifications.Describe).
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text,omitempty"`
+}
+
+func (wh *webhook) Send(ctx context.Context, event notifications.Event) error {
+	body, err := wh.formatBody(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, wh.method, wh.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", wh.contentType)
+	req.Header.Set("X-Shelley-Event", string(event.Type))
+	for name, values := range wh.headers {
+		req.Header[name] = values
+	}
+	if wh.secret != "" {
+		req.Header.Set(webhookSignatureHeader, "sha256="+signWebhookBody(wh.secret, body))
+	}
+
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (wh *webhook) formatBody(event notifications.Event) ([]byte, error) {
+	data := webhookData{Event: event, DurationSeconds: event.Duration.Seconds()}
+	if summary, ok := notifications.Describe(event); ok {
+		data.Title, data.Text = summary.Title, summary.Body
+	}
+	if wh.template == nil {
+		body, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("marshal webhook payload: %w", err)
+		}
+		return body, nil
+	}
+	var buf bytes.Buffer
+	if err := wh.template.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("render webhook body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,199 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"shelley.exe.dev/server/notifications"
+)
+
+type capturedRequest struct {
+	method string
+	header http.Header
+	body   string
+}
+
+func captureServer(t *testing.T) (*httptest.Server, *capturedRequest) {
+	t.Helper()
+	var got capturedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = capturedRequest{method: r.Method, header: r.Header.Clone(), body: string(b)}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+var testDoneEvent = notifications.Event{
+	Type:           notifications.EventAgentDone,
+	ConversationID: "c-1",
+	Timestamp:      time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	Cwd:            "/work/api",
+	Duration:       90 * time.Second,
+	Payload: notifications.AgentDonePayload{
+		Model:             "claude",
+		ConversationTitle: `say "hi"`,
+		FinalResponse:     "Done.",
+	},
+}
+
+func TestWebhookDefaultBodyAndSignature(t *testing.T) {
+	srv, got := captureServer(t)
+	ch, err := notifications.CreateFromConfig(map[string]any{
+		"type":    "webhook",
+		"url":     srv.URL,
+		"secret":  "s3cret",
+		"headers": "Authorization: Bearer abc\nX-Team: core",
+	}, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.Send(context.Background(), testDoneEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.method != http.MethodPost || got.header.Get("Authorization") != "Bearer abc" || got.header.Get("X-Team") != "core" {
+		t.Errorf("unexpected request: %s %v", got.method, got.header)
+	}
+	if got.header.Get("X-Shelley-Event") != "agent_done" {
+		t.Errorf("X-Shelley-Event = %q", got.header.Get("X-Shelley-Event"))
+	}
+	if want := "sha256=" + signWebhookBody("s3cret", []byte(got.body)); got.header.Get(webhookSignatureHeader) != want {
+		t.Errorf("signature = %q, want %q", got.header.Get(webhookSignatureHeader), want)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal([]byte(got.body), &body); err != nil {
+		t.Fatalf("body is not JSON: %v\n%s", err, got.body)
+	}
+	if body["type"] != "agent_done" || body["cwd"] != "/work/api" || body["duration_seconds"] != float64(90) {
+		t.Errorf("unexpected body: %s", got.body)
+	}
+}
+
+func TestWebhookBodyTemplate(t *testing.T) {
+	srv, got := captureServer(t)
+	ch, err := notifications.CreateFromConfig(map[string]any{
+		"type":          "webhook",
+		"url":           srv.URL,
+		"method":        "put",
+		"content_type":  "text/plain",
+		"body_template": `{"title": {{json .Payload.ConversationTitle}}, "took": {{.DurationSeconds}}}`,
+	}, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.Send(context.Background(), testDoneEvent); err != nil {
+		t.Fatal(err)
+	}
+	if got.method != http.MethodPut || got.header.Get("Content-Type") != "text/plain" {
+		t.Errorf("unexpected request: %s %v", got.method, got.header)
+	}
+	if want := `{"title": "say \"hi\"", "took": 90}`; got.body != want {
+		t.Errorf("body = %s, want %s", got.body, want)
+	}
+	if got.header.Get(webhookSignatureHeader) != "" {
+		t.Error("unexpected signature without a secret")
+	}
+}
+
+func TestWebhookConfigErrors(t *testing.T) {
+	for _, config := range []map[string]any{
+		{"type": "webhook"},
+		{"type": "webhook", "url": "http://x", "method": "DELETE"},
+		{"type": "webhook", "url": "http://x", "headers": "no colon"},
+		{"type": "webhook", "url": "http://x", "body_template": "{{.Type"},
+		{"type": "webhook", "url": "http://x", "min_duration": "later"},
+	} {
+		if _, err := notifications.CreateFromConfig(config, slog.Default()); err == nil {
+			t.Errorf("CreateFromConfig(%v) succeeded, want error", config)
+		}
+	}
+}
+
+func TestSlackMessage(t *testing.T) {
+	srv, got := captureServer(t)
+	ch, err := notifications.CreateFromConfig(map[string]any{"type": "slack", "webhook_url": srv.URL}, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := testDoneEvent
+	event.Payload = notifications.AgentDonePayload{ConversationTitle: "fix <bug>", FinalResponse: "Done & dusted."}
+	if err := ch.Send(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg slackMessage
+	if err := json.Unmarshal([]byte(got.body), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Text != ":white_check_mark: Agent finished: fix &lt;bug&gt;" {
+		t.Errorf("text = %q", msg.Text)
+	}
+	if len(msg.Blocks) != 3 || msg.Blocks[1].Text.Text != "Done &amp; dusted." {
+		t.Fatalf("unexpected blocks: %s", got.body)
+	}
+	if footer := msg.Blocks[2].Elements; len(footer) != 2 || !strings.Contains(footer[0].Text, "1m30s") {
+		t.Errorf("unexpected context block: %+v", footer)
+	}
+}
+
+func TestReplyRendering(t *testing.T) {
+	event := notifications.Event{
+		Type:           notifications.EventToolApprovalNeeded,
+		ConversationID: "c-1",
+		Payload:        notifications.ToolApprovalPayload{ToolName: "bash", Input: "rm -rf build", ApprovalID: "a-1"},
+		Reply: &notifications.Reply{
+			URL:     "https://shelley.example.com/notifications/reply?token=tok",
+			Token:   "tok",
+			Actions: []notifications.ReplyAction{notifications.ReplyApprove, notifications.ReplyDeny, notifications.ReplyMessage},
+		},
+	}
+
+	actions := ntfyReplyActions(event.Reply)
+	var labels []string
+	for _, a := range actions {
+		labels = append(labels, a.Label)
+		if a.URL != event.Reply.URL || a.Method != http.MethodPost {
+			t.Errorf("action %+v does not post to the reply URL", a)
+		}
+	}
+	if strings.Join(labels, ",") != "Approve,Deny,Continue" {
+		t.Errorf("ntfy buttons = %v", labels)
+	}
+	noURL := *event.Reply
+	noURL.URL = ""
+	if ntfyReplyActions(&noURL) != nil {
+		t.Error("ntfy buttons offered without a reply URL")
+	}
+
+	footer := emailReplyFooter(event.Reply)
+	if !strings.Contains(footer, `"approve" or "deny"`) || !strings.HasSuffix(footer, "\n"+notifications.EmailReplyMarker("tok")) {
+		t.Errorf("email footer = %q", footer)
+	}
+
+	srv, got := captureServer(t)
+	ch, err := notifications.CreateFromConfig(map[string]any{"type": "webhook", "url": srv.URL, "replies": "true"}, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.Send(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Reply notifications.Reply `json:"reply"`
+	}
+	if err := json.Unmarshal([]byte(got.body), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Reply.Token != "tok" || len(body.Reply.Actions) != 3 {
+		t.Errorf("webhook reply = %+v", body.Reply)
+	}
+}
@@ -0,0 +1,157 @@
+package notifications
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Summary is a channel-neutral, plain-text rendering of an event.
+type Summary struct {
+	Title string
+	Body  string
+	// Urgent marks events that need the user's attention, such as errors.
+	Urgent bool
+}
+
+// Describe renders event as a title and body that channels can format in
+// their own style. It returns false for event types it does not know.
+func Describe(event Event) (Summary, bool) {
+	var s Summary
+	var lines []string
+	switch event.Type {
+	case EventAgentDone:
+		s.Title = "Agent finished"
+		if p, ok := event.Payload.(AgentDonePayload); ok {
+			s.Title = withTitle(s.Title, p.ConversationTitle)
+			if p.Model != "" {
+				lines = append(lines, "Model: "+p.Model)
+			}
+			lines = append(lines, p.FinalResponse)
+		}
+
+	case EventAgentError:
+		s.Title = "Agent error"
+		s.Urgent = true
+		if p, ok := event.Payload.(AgentErrorPayload); ok {
+			lines = append(lines, p.ErrorMessage)
+		}
+
+	case EventToolApprovalNeeded:
+		s.Title = "Approval needed"
+		s.Urgent = true
+		if p, ok := event.Payload.(ToolApprovalPayload); ok {
+			s.Title = fmt.Sprintf("Approval needed for %s", p.ToolName)
+			lines = append(lines, p.Input, p.Reason)
+		}
+
+	case EventToolRunning:
+		s.Title = "Tool still running"
+		if p, ok := event.Payload.(ToolRunningPayload); ok {
+			elapsed := time.Duration(p.ElapsedSeconds) * time.Second
+			s.Title = fmt.Sprintf("%s still running after %s", p.ToolName, elapsed)
+			lines = append(lines, p.Input)
+		}
+
+	case EventSubagentDone:
+		s.Title = "Subagent finished"
+		if p, ok := event.Payload.(SubagentDonePayload); ok {
+			s.Title = withTitle(s.Title, p.ConversationTitle)
+			lines = append(lines, p.FinalResponse)
+		}
+
+	case EventDistillDone:
+		s.Title = "Distillation finished"
+		if p, ok := event.Payload.(DistillDonePayload); ok {
+			s.Title = withTitle(s.Title, p.ConversationTitle)
+			if p.SourceTitle != "" {
+				lines = append(lines, "Distilled from "+p.SourceTitle)
+			}
+		}
+
+	case EventContextNearlyFull:
+		s.Title = "Context window nearly full"
+		s.Urgent = true
+		if p, ok := event.Payload.(ContextNearlyFullPayload); ok {
+			s.Title = fmt.Sprintf("Context window %d%% full", p.Percent())
+			lines = append(lines, fmt.Sprintf("%d of %d tokens used. Consider distilling the conversation.", p.UsedTokens, p.WindowTokens))
+		}
+
+	case EventUpgradeDone:
+		s.Title = "Shelley upgraded"
+		if p, ok := event.Payload.(UpgradeDonePayload); ok {
+			s.Title = fmt.Sprintf("Shelley upgraded to %s", p.ToVersion)
+			if p.FromVersion != "" {
+				lines = append(lines, "Previous version: "+p.FromVersion)
+			}
+		}
+
+	case EventGitCommit:
+		s.Title = "Agent created a commit"
+		if p, ok := event.Payload.(GitCommitPayload); ok {
+			if p.Subject != "" {
+				s.Title = withTitle("Agent committed", p.Subject)
+			}
+			if p.Commit != "" {
+				lines = append(lines, "Commit "+p.Commit)
+			}
+		}
+
+	case EventDigest:
+		s.Title = "Notification digest"
+		if p, ok := event.Payload.(DigestPayload); ok {
+			s.Title = digestTitle(p)
+			for _, e := range p.Events {
+				summary, ok := Describe(e)
+				if !ok {
+					continue
+				}
+				s.Urgent = s.Urgent || summary.Urgent
+				lines = append(lines, "• "+summary.Title)
+			}
+		}
+
+	default:
+		return Summary{}, false
+	}
+
+	if event.Cwd != "" && event.Type != EventAgentDone && event.Type != EventAgentError {
+		lines = append(lines, "Directory: "+event.Cwd)
+	}
+	var nonEmpty []string
+	for _, line := range lines {
+		if line != "" {
+			nonEmpty = append(nonEmpty, line)
+		}
+	}
+	s.Body = strings.Join(nonEmpty, "\n")
+	return s, true
+}
+
+func withTitle(prefix, title string) string {
+	if title == "" {
+		return prefix
+	}
+	return prefix + ": " + title
+}
+
+// digestNouns describe several events of one type, for digest titles.
+var digestNouns = map[EventType]string{
+	EventAgentDone:          "agents finished",
+	EventAgentError:         "agent errors",
+	EventToolApprovalNeeded: "approvals needed",
+	EventToolRunning:        "tools still running",
+	EventSubagentDone:       "subagents finished",
+	EventDistillDone:        "distillations finished",
+	EventContextNearlyFull:  "context windows nearly full",
+	EventUpgradeDone:        "upgrades",
+	EventGitCommit:          "commits by the agent",
+}
+
+func digestTitle(p DigestPayload) string {
+	noun, ok := digestNouns[p.Type]
+	if !ok {
+		noun = "notifications"
+	}
+	return fmt.Sprintf("%d %s", len(p.Events), noun)
+}
@@ -6,10 +6,17 @@ import (
 	"sync"
 )
 
+// Outbox stores events for later delivery, so that failed sends can be
+// retried and bursts of events coalesced.
+type Outbox interface {
+	Enqueue(ctx context.Context, channelID string, event Event) error
+}
+
 // Dispatcher routes notification events to registered backend channels.
 type Dispatcher struct {
 	mu       sync.RWMutex
 	channels []Channel
+	outbox   Outbox
 	logger   *slog.Logger
 }
 
@@ -32,6 +39,15 @@ func (d *Dispatcher) ReplaceChannels(channels []Channel) {
 	d.channels = channels
 }
 
+// SetOutbox makes the dispatcher queue events for channels with an ID in
+// outbox instead of sending them directly. Channels without an ID, such as
+// those added with Register, are always sent to directly.
+func (d *Dispatcher) SetOutbox(outbox Outbox) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.outbox = outbox
+}
+
 // Channels returns a snapshot of current registered channels.
 func (d *Dispatcher) Channels() []Channel {
 	d.mu.RLock()
@@ -41,14 +57,42 @@ func (d *Dispatcher) Channels() []Channel {
 	return result
 }
 
-// Dispatch sends an event to all registered backend channels.
-// It does not block on individual channel failures.
+// Channel returns the registered channel with the given ID, if any.
+func (d *Dispatcher) Channel(id string) (*FilteredChannel, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, ch := range d.channels {
+		if fc, ok := ch.(*FilteredChannel); ok && fc.ID == id {
+			return fc, true
+		}
+	}
+	return nil, false
+}
+
+// Dispatch sends an event to all registered backend channels whose filter
+// matches it, or queues it in the outbox for channels that have an ID. It
+// does not block on individual channel failures.
 func (d *Dispatcher) Dispatch(ctx context.Context, event Event) {
 	d.mu.RLock()
 	channels := d.channels
+	outbox := d.outbox
 	d.mu.RUnlock()
 
 	for _, ch := range channels {
+		if !filterFor(ch).Match(event) {
+			continue
+		}
+		if fc, ok := ch.(*FilteredChannel); ok && fc.ID != "" && outbox != nil {
+			err := outbox.Enqueue(ctx, fc.ID, event)
+			if err == nil {
+				continue
+			}
+			d.logger.Warn("failed to queue notification, sending directly",
+				"channel", ch.Name(),
+				"event", string(event.Type),
+				"error", err,
+			)
+		}
 		if err := ch.Send(ctx, event); err != nil {
 			d.logger.Warn("notification channel failed",
 				"channel", ch.Name(),
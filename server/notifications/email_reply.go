@@ -0,0 +1,143 @@
+package notifications
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// emailReplyMarkerPrefix starts the line that carries the reply token in
+// notification emails, so that it survives in the quoted text of replies.
+const emailReplyMarkerPrefix = "shelley-reply:"
+
+var emailReplyMarkerRE = regexp.MustCompile(regexp.QuoteMeta(emailReplyMarkerPrefix) + `([A-Za-z0-9_-]+\.[A-Za-z0-9_-]+)`)
+
+// EmailReplyMarker returns the line to include in a notification email so
+// that replies to it can be matched with token.
+func EmailReplyMarker(token string) string {
+	return emailReplyMarkerPrefix + token
+}
+
+// EmailReply is a reply to a notification email.
+type EmailReply struct {
+	Token string
+	// Text is the new text of the reply, without the quoted original.
+	Text string
+}
+
+// ParseEmailReply reads a raw RFC 822 email replying to a notification and
+// extracts its reply token and the text the user wrote.
+func ParseEmailReply(r io.Reader) (EmailReply, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return EmailReply{}, err
+	}
+	body, err := plainTextBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return EmailReply{}, err
+	}
+
+	m := emailReplyMarkerRE.FindStringSubmatch(body)
+	if m == nil {
+		m = emailReplyMarkerRE.FindStringSubmatch(msg.Header.Get("Subject"))
+	}
+	if m == nil {
+		return EmailReply{}, errors.New("no reply token found")
+	}
+	return EmailReply{Token: m[1], Text: replyText(body)}, nil
+}
+
+// plainTextBody returns the decoded text/plain content of a message body,
+// looking inside multipart bodies for the first text/plain part.
+func plainTextBody(contentType, encoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err != nil {
+				return "", errors.New("no text/plain part")
+			}
+			text, err := plainTextBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err == nil {
+				return text, nil
+			}
+		}
+	}
+	if mediaType != "text/plain" {
+		return "", errors.New("no text/plain part")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &lineSkipper{r: body})
+	}
+	b, err := io.ReadAll(body)
+	return string(b), err
+}
+
+// lineSkipper drops line breaks, which base64 bodies are wrapped with.
+type lineSkipper struct{ r io.Reader }
+
+func (l *lineSkipper) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	j := 0
+	for _, c := range p[:n] {
+		if c != '\r' && c != '\n' {
+			p[j] = c
+			j++
+		}
+	}
+	return j, err
+}
+
+// replyText returns the lines of an email body above the quoted original.
+func replyText(body string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") || strings.Contains(trimmed, emailReplyMarkerPrefix) {
+			break
+		}
+		// "On <date>, <someone> wrote:" introduces the quote in most clients.
+		if strings.HasPrefix(trimmed, "On ") && strings.HasSuffix(trimmed, "wrote:") {
+			break
+		}
+		if trimmed == "-----Original Message-----" {
+			break
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// ParseApprovalReply interprets a free-text answer to an approval request.
+// Text starting with "approve" or "yes" approves, and "deny" or "no" denies;
+// the rest of the text is returned as a message. Anything else is a plain
+// message.
+func ParseApprovalReply(text string) (ReplyAction, string) {
+	first, rest, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	word, more, _ := strings.Cut(strings.TrimSpace(first), " ")
+	word = strings.ToLower(strings.TrimRight(word, ".,!:;"))
+	var action ReplyAction
+	switch word {
+	case "approve", "approved", "yes", "y":
+		action = ReplyApprove
+	case "deny", "denied", "no", "n":
+		action = ReplyDeny
+	default:
+		return ReplyMessage, text
+	}
+	return action, strings.TrimSpace(more + "\n" + rest)
+}
@@ -0,0 +1,71 @@
+package notifications
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// storedEvent is the JSON form of an Event in the outbox. Unlike the
+// Event's own JSON, it keeps the duration.
+type storedEvent struct {
+	Event
+	Payload  json.RawMessage `json:"payload,omitempty"`
+	Duration int64           `json:"duration_ns,omitempty"`
+}
+
+// payloadDecoders decode the payload of each event type to its Go type.
+var payloadDecoders = map[EventType]func(json.RawMessage) (any, error){
+	EventAgentDone:          decodePayload[AgentDonePayload],
+	EventAgentError:         decodePayload[AgentErrorPayload],
+	EventToolApprovalNeeded: decodePayload[ToolApprovalPayload],
+	EventToolRunning:        decodePayload[ToolRunningPayload],
+	EventSubagentDone:       decodePayload[SubagentDonePayload],
+	EventDistillDone:        decodePayload[DistillDonePayload],
+	EventContextNearlyFull:  decodePayload[ContextNearlyFullPayload],
+	EventUpgradeDone:        decodePayload[UpgradeDonePayload],
+	EventGitCommit:          decodePayload[GitCommitPayload],
+}
+
+func decodePayload[T any](data json.RawMessage) (any, error) {
+	var p T
+	err := json.Unmarshal(data, &p)
+	return p, err
+}
+
+// MarshalEvent encodes event for storage. UnmarshalEvent reverses it,
+// restoring the payload's type.
+func MarshalEvent(event Event) ([]byte, error) {
+	stored := storedEvent{Event: event, Duration: int64(event.Duration)}
+	if event.Payload != nil {
+		payload, err := json.Marshal(event.Payload)
+		if err != nil {
+			return nil, err
+		}
+		stored.Payload = payload
+	}
+	return json.Marshal(stored)
+}
+
+// UnmarshalEvent decodes an event encoded by MarshalEvent. Payloads of
+// unknown event types are left as generic JSON values.
+func UnmarshalEvent(data []byte) (Event, error) {
+	var stored storedEvent
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return Event{}, err
+	}
+	event := stored.Event
+	event.Duration = time.Duration(stored.Duration)
+	if len(stored.Payload) == 0 {
+		return event, nil
+	}
+	decode, ok := payloadDecoders[event.Type]
+	if !ok {
+		decode = decodePayload[any]
+	}
+	payload, err := decode(stored.Payload)
+	if err != nil {
+		return Event{}, err
+	}
+	event.Payload = payload
+	return event, nil
+}
@@ -0,0 +1,43 @@
+package notifications
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshalEventRoundTrip(t *testing.T) {
+	events := []Event{
+		{
+			Type:           EventAgentDone,
+			ConversationID: "c-1",
+			Timestamp:      time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+			Payload:        AgentDonePayload{Model: "m", ConversationTitle: "t", FinalResponse: "done"},
+			Cwd:            "/work",
+			Duration:       3 * time.Minute,
+		},
+		{Type: EventContextNearlyFull, Payload: ContextNearlyFullPayload{UsedTokens: 9, WindowTokens: 10}, Subagent: true},
+		{Type: EventUpgradeDone},
+	}
+	for _, event := range events {
+		data, err := MarshalEvent(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := UnmarshalEvent(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, event) {
+			t.Errorf("round trip of %s:\n got %#v\nwant %#v", event.Type, got, event)
+		}
+	}
+
+	got, err := UnmarshalEvent([]byte(`{"type":"future_event","payload":{"x":1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := got.Payload.(map[string]any); !ok || p["x"] != float64(1) {
+		t.Errorf("unknown payload = %#v", got.Payload)
+	}
+}
@@ -6,16 +6,50 @@ import "time"
 type EventType string
 
 const (
-	EventAgentDone  EventType = "agent_done"
-	EventAgentError EventType = "agent_error"
+	EventAgentDone          EventType = "agent_done"
+	EventAgentError         EventType = "agent_error"
+	EventToolApprovalNeeded EventType = "tool_approval_needed"
+	EventToolRunning        EventType = "tool_running"
+	EventSubagentDone       EventType = "subagent_done"
+	EventDistillDone        EventType = "distill_done"
+	EventContextNearlyFull  EventType = "context_nearly_full"
+	EventUpgradeDone        EventType = "upgrade_done"
+	EventGitCommit          EventType = "git_commit"
+
+	// EventDigest combines several events of one type that were delivered
+	// together. It is produced by the outbox, never dispatched directly, so
+	// it is not in EventTypes.
+	EventDigest EventType = "digest"
 )
 
+// EventTypes lists every event type, in the order they are shown to users.
+var EventTypes = []EventType{
+	EventAgentDone,
+	EventAgentError,
+	EventToolApprovalNeeded,
+	EventToolRunning,
+	EventSubagentDone,
+	EventDistillDone,
+	EventContextNearlyFull,
+	EventUpgradeDone,
+	EventGitCommit,
+}
+
 // Event is a notification event generated by the system.
 type Event struct {
 	Type           EventType `json:"type"`
 	ConversationID string    `json:"conversation_id"`
 	Timestamp      time.Time `json:"timestamp"`
 	Payload        any       `json:"payload,omitempty"`
+
+	// Cwd is the working directory of the conversation, if known.
+	Cwd string `json:"cwd,omitempty"`
+	// Subagent is set for events from subagent conversations.
+	Subagent bool `json:"subagent,omitempty"`
+	// Duration is how long the agent worked on the turn that produced the event.
+	Duration time.Duration `json:"-"`
+	// Reply, if set, lets the recipient answer the event.
+	Reply *Reply `json:"reply,omitempty"`
 }
 
 // AgentDonePayload is the payload for EventAgentDone.
@@ -29,3 +63,71 @@ type AgentDonePayload struct {
 type AgentErrorPayload struct {
 	ErrorMessage string `json:"error_message"`
 }
+
+// ToolApprovalPayload is the payload for EventToolApprovalNeeded, sent when a
+// tool call was refused by a permission check and needs the user to allow it.
+type ToolApprovalPayload struct {
+	ToolName string `json:"tool_name"`
+	Input    string `json:"input,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	// ApprovalID identifies the pending approval, which can be answered
+	// through the event's Reply.
+	ApprovalID string `json:"approval_id,omitempty"`
+}
+
+// ToolRunningPayload is the payload for EventToolRunning, sent once when a
+// tool call has been running longer than the server's threshold.
+type ToolRunningPayload struct {
+	ToolName       string `json:"tool_name"`
+	Input          string `json:"input,omitempty"`
+	ElapsedSeconds int    `json:"elapsed_seconds"`
+}
+
+// SubagentDonePayload is the payload for EventSubagentDone.
+type SubagentDonePayload struct {
+	ParentConversationID string `json:"parent_conversation_id"`
+	Model                string `json:"model,omitempty"`
+	ConversationTitle    string `json:"conversation_title,omitempty"`
+	FinalResponse        string `json:"final_response,omitempty"`
+}
+
+// DistillDonePayload is the payload for EventDistillDone. The event's
+// ConversationID is the new, distilled conversation.
+type DistillDonePayload struct {
+	SourceTitle       string `json:"source_title,omitempty"`
+	ConversationTitle string `json:"conversation_title,omitempty"`
+}
+
+// ContextNearlyFullPayload is the payload for EventContextNearlyFull.
+type ContextNearlyFullPayload struct {
+	Model        string `json:"model,omitempty"`
+	UsedTokens   uint64 `json:"used_tokens"`
+	WindowTokens uint64 `json:"window_tokens"`
+}
+
+// Percent returns the share of the context window in use, from 0 to 100.
+func (p ContextNearlyFullPayload) Percent() int {
+	if p.WindowTokens == 0 {
+		return 0
+	}
+	return int(p.UsedTokens * 100 / p.WindowTokens)
+}
+
+// UpgradeDonePayload is the payload for EventUpgradeDone.
+type UpgradeDonePayload struct {
+	FromVersion string `json:"from_version,omitempty"`
+	ToVersion   string `json:"to_version"`
+}
+
+// GitCommitPayload is the payload for EventGitCommit.
+type GitCommitPayload struct {
+	Commit  string `json:"commit,omitempty"`
+	Subject string `json:"subject,omitempty"`
+	Command string `json:"command"`
+}
+
+// DigestPayload is the payload for EventDigest.
+type DigestPayload struct {
+	Type   EventType `json:"type"`
+	Events []Event   `json:"events"`
+}
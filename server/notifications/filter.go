@@ -0,0 +1,192 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Filter decides which events a channel receives. The zero Filter accepts
+// every event except those from subagent conversations.
+type Filter struct {
+	// Events limits delivery to these event types. Empty means all types.
+	Events []EventType
+	// Conversations limits delivery to conversations whose ID or title
+	// matches one of these glob patterns. Empty means all conversations.
+	Conversations []string
+	// CwdGlobs limits delivery to conversations whose working directory
+	// matches one of these glob patterns. A pattern ending in "/**" also
+	// matches everything below that directory. Empty means any directory.
+	CwdGlobs []string
+	// MinDuration drops agent_done events for turns shorter than this.
+	MinDuration time.Duration
+	// IncludeSubagents delivers events from subagent conversations too.
+	IncludeSubagents bool
+}
+
+// ParseFilter reads filter settings from a channel config map. The keys are
+// "events", "conversations" and "cwd_globs" (a list, or a comma or newline
+// separated string), "min_duration" (a Go duration like "30s", or a number of
+// seconds) and "include_subagents" (a bool or "true"/"false").
+func ParseFilter(config map[string]any) (Filter, error) {
+	var f Filter
+	for _, e := range stringList(config["events"]) {
+		f.Events = append(f.Events, EventType(e))
+	}
+	f.Conversations = stringList(config["conversations"])
+	f.CwdGlobs = stringList(config["cwd_globs"])
+	for _, pattern := range slices.Concat(f.Conversations, f.CwdGlobs) {
+		if _, err := filepath.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+			return Filter{}, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+	}
+
+	switch v := config["min_duration"].(type) {
+	case nil:
+	case float64:
+		f.MinDuration = time.Duration(v * float64(time.Second))
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return Filter{}, fmt.Errorf("invalid min_duration %q: %w", v, err)
+			}
+			f.MinDuration = d
+		}
+	default:
+		return Filter{}, fmt.Errorf("invalid min_duration %v", v)
+	}
+
+	f.IncludeSubagents = boolValue(config["include_subagents"])
+	return f, nil
+}
+
+// boolValue accepts a JSON bool or the string "true".
+func boolValue(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// stringList accepts a JSON array of strings or a comma or newline separated string.
+func stringList(v any) []string {
+	var items []string
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				items = append(items, s)
+			}
+		}
+	case []string:
+		items = v
+	case string:
+		items = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == '\n' })
+	}
+	var result []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// Match reports whether event passes the filter.
+func (f Filter) Match(event Event) bool {
+	if event.Subagent && !f.IncludeSubagents {
+		return false
+	}
+	if len(f.Events) > 0 && !slices.Contains(f.Events, event.Type) {
+		return false
+	}
+	if len(f.Conversations) > 0 {
+		title := ""
+		if p, ok := event.Payload.(AgentDonePayload); ok {
+			title = p.ConversationTitle
+		}
+		if !matchAny(f.Conversations, event.ConversationID) && (title == "" || !matchAny(f.Conversations, title)) {
+			return false
+		}
+	}
+	if len(f.CwdGlobs) > 0 && (event.Cwd == "" || !matchAny(f.CwdGlobs, event.Cwd)) {
+		return false
+	}
+	if f.MinDuration > 0 && event.Type == EventAgentDone && event.Duration < f.MinDuration {
+		return false
+	}
+	return true
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, pattern := range patterns {
+		if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+			// Match the directory itself or any of its ancestors.
+			for p := s; ; p = filepath.Dir(p) {
+				if ok, _ := filepath.Match(dir, p); ok {
+					return true
+				}
+				if p == filepath.Dir(p) {
+					break
+				}
+			}
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// FilteredChannel pairs a Channel with the Filter the Dispatcher applies to it.
+// Sending directly, as the channel test endpoint does, bypasses the filter.
+type FilteredChannel struct {
+	Channel
+	Filter Filter
+	// ID is the channel's database ID. Events for channels with an ID go
+	// through the dispatcher's Outbox, when it has one.
+	ID string
+	// AllowReplies passes events' Reply on to the channel. Otherwise it is
+	// removed, so the channel offers no way to answer.
+	AllowReplies bool
+}
+
+// Send sends event to the channel, removing its Reply, and those of the
+// events in a digest, unless replies are allowed.
+func (fc *FilteredChannel) Send(ctx context.Context, event Event) error {
+	if !fc.AllowReplies {
+		event = withoutReplies(event)
+	}
+	return fc.Channel.Send(ctx, event)
+}
+
+// withoutReplies returns a copy of event without replies. The digest's
+// events are copied too, since other channels share them.
+func withoutReplies(event Event) Event {
+	event.Reply = nil
+	if p, ok := event.Payload.(DigestPayload); ok {
+		events := make([]Event, len(p.Events))
+		for i, e := range p.Events {
+			events[i] = withoutReplies(e)
+		}
+		p.Events = events
+		event.Payload = p
+	}
+	return event
+}
+
+// filterFor returns the filter the dispatcher applies to ch.
+func filterFor(ch Channel) Filter {
+	if fc, ok := ch.(*FilteredChannel); ok {
+		return fc.Filter
+	}
+	return Filter{}
+}
@@ -0,0 +1,121 @@
+package notifications
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestFilterMatch(t *testing.T) {
+	done := Event{
+		Type:           EventAgentDone,
+		ConversationID: "c-123",
+		Cwd:            "/home/me/work/api",
+		Duration:       90 * time.Second,
+		Payload:        AgentDonePayload{ConversationTitle: "deploy-api"},
+	}
+	subagent := done
+	subagent.Subagent = true
+	quick := done
+	quick.Duration = 5 * time.Second
+	failed := Event{Type: EventAgentError, ConversationID: "c-456", Cwd: "/tmp/scratch"}
+
+	tests := []struct {
+		name   string
+		config map[string]any
+		event  Event
+		want   bool
+	}{
+		{"zero filter", nil, done, true},
+		{"zero filter drops subagents", nil, subagent, false},
+		{"include subagents", map[string]any{"include_subagents": "true"}, subagent, true},
+		{"event type", map[string]any{"events": "agent_error"}, done, false},
+		{"event type list", map[string]any{"events": []any{"agent_done", "agent_error"}}, failed, true},
+		{"conversation by title", map[string]any{"conversations": "deploy-*"}, done, true},
+		{"conversation by id", map[string]any{"conversations": "c-4*"}, failed, true},
+		{"conversation mismatch", map[string]any{"conversations": "other"}, done, false},
+		{"cwd subtree", map[string]any{"cwd_globs": "/home/me/work/**"}, done, true},
+		{"cwd glob", map[string]any{"cwd_globs": "/home/*/work/api"}, done, true},
+		{"cwd glob subtree", map[string]any{"cwd_globs": "/home/*/**"}, done, true},
+		{"cwd mismatch", map[string]any{"cwd_globs": "/home/me/work/**, /srv/**"}, failed, false},
+		{"long turn", map[string]any{"min_duration": "1m"}, done, true},
+		{"short turn", map[string]any{"min_duration": "1m"}, quick, false},
+		{"min duration in seconds", map[string]any{"min_duration": float64(10)}, quick, false},
+		{"min duration ignores errors", map[string]any{"min_duration": "1m"}, failed, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseFilter(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Match(tt.event); got != tt.want {
+				t.Errorf("Match() = %v, want %v (filter %+v)", got, tt.want, f)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, config := range []map[string]any{
+		{"min_duration": "soon"},
+		{"min_duration": true},
+		{"cwd_globs": "/home/[/**"},
+	} {
+		if _, err := ParseFilter(config); err == nil {
+			t.Errorf("ParseFilter(%v) succeeded, want error", config)
+		}
+	}
+}
+
+type recordingChannel struct {
+	events []Event
+}
+
+func (r *recordingChannel) Name() string { return "recorder" }
+
+func (r *recordingChannel) Send(_ context.Context, event Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestDispatchAppliesFilters(t *testing.T) {
+	all := &recordingChannel{}
+	errorsOnly := &recordingChannel{}
+	d := NewDispatcher(slog.Default())
+	d.Register(all)
+	d.Register(&FilteredChannel{Channel: errorsOnly, Filter: Filter{Events: []EventType{EventAgentError}}})
+
+	d.Dispatch(context.Background(), Event{Type: EventAgentDone})
+	d.Dispatch(context.Background(), Event{Type: EventAgentError})
+	d.Dispatch(context.Background(), Event{Type: EventAgentDone, Subagent: true})
+
+	if len(all.events) != 2 {
+		t.Errorf("unfiltered channel got %d events, want 2", len(all.events))
+	}
+	if len(errorsOnly.events) != 1 || errorsOnly.events[0].Type != EventAgentError {
+		t.Errorf("filtered channel got %+v", errorsOnly.events)
+	}
+}
+
+func TestDescribeCoversEventTypes(t *testing.T) {
+	for _, eventType := range EventTypes {
+		summary, ok := Describe(Event{Type: eventType})
+		if !ok || summary.Title == "" {
+			t.Errorf("Describe(%s) = %+v, %v", eventType, summary, ok)
+		}
+	}
+	if _, ok := Describe(Event{Type: "unknown"}); ok {
+		t.Error("Describe accepted an unknown event type")
+	}
+
+	summary, _ := Describe(Event{
+		Type:    EventContextNearlyFull,
+		Cwd:     "/work",
+		Payload: ContextNearlyFullPayload{UsedTokens: 180, WindowTokens: 200},
+	})
+	if summary.Title != "Context window 90% full" || !summary.Urgent || summary.Body != "180 of 200 tokens used. Consider distilling the conversation.\nDirectory: /work" {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
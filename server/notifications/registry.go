@@ -39,6 +39,9 @@ func RegisteredTypes() []string {
 
 // CreateFromConfig creates a Channel from a config map by looking up
 // the "type" field in the registry and calling the corresponding factory.
+// The channel is wrapped in a FilteredChannel carrying the filter settings
+// from the same config map (see ParseFilter) and whether it may offer
+// replies ("replies").
 func CreateFromConfig(config map[string]any, logger *slog.Logger) (Channel, error) {
 	typeName, ok := config["type"].(string)
 	if !ok || typeName == "" {
@@ -53,5 +56,13 @@ func CreateFromConfig(config map[string]any, logger *slog.Logger) (Channel, erro
 		return nil, fmt.Errorf("unknown notification channel type: %q", typeName)
 	}
 
-	return factory(config, logger)
+	ch, err := factory(config, logger)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := ParseFilter(config)
+	if err != nil {
+		return nil, fmt.Errorf("%s channel: %w", typeName, err)
+	}
+	return &FilteredChannel{Channel: ch, Filter: filter, AllowReplies: boolValue(config["replies"])}, nil
 }
@@ -0,0 +1,96 @@
+package notifications
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ReplyAction is something the recipient of a notification can do in reply.
+type ReplyAction string
+
+const (
+	// ReplyMessage posts a user message to the conversation.
+	ReplyMessage ReplyAction = "message"
+	// ReplyApprove and ReplyDeny answer a pending tool approval.
+	ReplyApprove ReplyAction = "approve"
+	ReplyDeny    ReplyAction = "deny"
+)
+
+// Reply describes how to answer an event. Channels that support replies
+// render it as buttons or instructions; the answer is POSTed to URL as JSON
+// {"action": ..., "message": ...}, or carries Token some other way. Only
+// the first answer is accepted.
+type Reply struct {
+	// URL is the server's reply endpoint with the token in its query. It is
+	// empty when the server does not know its public URL.
+	URL     string        `json:"url,omitempty"`
+	Token   string        `json:"token"`
+	Actions []ReplyAction `json:"actions"`
+}
+
+// ReplyClaims are the contents of a reply token.
+type ReplyClaims struct {
+	// ID is random. The server records it when the token is used, so that
+	// each token answers only once.
+	ID             string `json:"n"`
+	ConversationID string `json:"c"`
+	// ApprovalID is the pending approval the token may answer, if any.
+	ApprovalID string `json:"a,omitempty"`
+	Expires    int64  `json:"e"`
+}
+
+// Allows reports whether the token permits action.
+func (c ReplyClaims) Allows(action ReplyAction) bool {
+	switch action {
+	case ReplyMessage:
+		return true
+	case ReplyApprove, ReplyDeny:
+		return c.ApprovalID != ""
+	}
+	return false
+}
+
+var (
+	ErrInvalidReplyToken = errors.New("invalid reply token")
+	ErrExpiredReplyToken = errors.New("reply token has expired")
+	ErrUsedReplyToken    = errors.New("reply token has already been used")
+)
+
+// SignReplyToken encodes claims as a token authenticated with secret.
+func SignReplyToken(secret []byte, claims ReplyClaims) string {
+	data, _ := json.Marshal(claims)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + replySignature(secret, payload)
+}
+
+// VerifyReplyToken checks a token made by SignReplyToken and returns its
+// claims if it is authentic and has not expired at now.
+func VerifyReplyToken(secret []byte, token string, now time.Time) (ReplyClaims, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(replySignature(secret, payload))) {
+		return ReplyClaims{}, ErrInvalidReplyToken
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return ReplyClaims{}, ErrInvalidReplyToken
+	}
+	var claims ReplyClaims
+	if err := json.Unmarshal(data, &claims); err != nil || claims.ID == "" || claims.ConversationID == "" {
+		return ReplyClaims{}, ErrInvalidReplyToken
+	}
+	if now.Unix() >= claims.Expires {
+		return ReplyClaims{}, ErrExpiredReplyToken
+	}
+	return claims, nil
+}
+
+func replySignature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
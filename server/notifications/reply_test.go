@@ -0,0 +1,138 @@
+package notifications
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplyToken(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1700000000, 0)
+	claims := ReplyClaims{ID: "r-1", ConversationID: "c-1", ApprovalID: "a-1", Expires: now.Add(time.Hour).Unix()}
+	token := SignReplyToken(secret, claims)
+
+	got, err := VerifyReplyToken(secret, token, now)
+	if err != nil || got != claims {
+		t.Fatalf("VerifyReplyToken = %+v, %v", got, err)
+	}
+	if !got.Allows(ReplyApprove) || (ReplyClaims{ConversationID: "c-1"}).Allows(ReplyDeny) {
+		t.Error("Allows does not follow ApprovalID")
+	}
+	if _, err := VerifyReplyToken(secret, token, now.Add(2*time.Hour)); err != ErrExpiredReplyToken {
+		t.Errorf("expired token: %v", err)
+	}
+	if _, err := VerifyReplyToken([]byte("other"), token, now); err != ErrInvalidReplyToken {
+		t.Errorf("wrong secret: %v", err)
+	}
+	forged := SignReplyToken([]byte("other"), claims)
+	payload, _, _ := strings.Cut(forged, ".")
+	_, sig, _ := strings.Cut(token, ".")
+	if _, err := VerifyReplyToken(secret, payload+"x."+sig, now); err != ErrInvalidReplyToken {
+		t.Errorf("tampered token: %v", err)
+	}
+	claims.ID = ""
+	if _, err := VerifyReplyToken(secret, SignReplyToken(secret, claims), now); err != ErrInvalidReplyToken {
+		t.Errorf("token without an ID: %v", err)
+	}
+}
+
+func TestFilteredChannelReplies(t *testing.T) {
+	event := Event{Type: EventAgentDone, Reply: &Reply{Token: "t"}}
+	for _, allow := range []bool{false, true} {
+		rec := &recordingChannel{}
+		fc := &FilteredChannel{Channel: rec, AllowReplies: allow}
+		if err := fc.Send(context.Background(), event); err != nil {
+			t.Fatal(err)
+		}
+		if got := rec.events[0].Reply != nil; got != allow {
+			t.Errorf("AllowReplies=%v: reply passed on = %v", allow, got)
+		}
+	}
+
+	digest := Event{Type: EventDigest, Payload: DigestPayload{Type: EventAgentDone, Events: []Event{event, event}}}
+	rec := &recordingChannel{}
+	if err := (&FilteredChannel{Channel: rec}).Send(context.Background(), digest); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range rec.events[0].Payload.(DigestPayload).Events {
+		if e.Reply != nil {
+			t.Error("reply passed on inside a digest")
+		}
+	}
+	if digest.Payload.(DigestPayload).Events[0].Reply == nil {
+		t.Error("digest's events modified")
+	}
+}
+
+func TestParseEmailReply(t *testing.T) {
+	token := SignReplyToken([]byte("k"), ReplyClaims{ConversationID: "c-1", Expires: 1})
+	plain := "From: me@example.com\r\n" +
+		"Subject: Re: Agent finished\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"Please also update the changelog.\r\n" +
+		"\r\n" +
+		"On Mon, 1 Jun 2025 at 12:00, Shelley <shelley@example.com> wrote:\r\n" +
+		"> Agent finished\r\n" +
+		"> " + EmailReplyMarker(token) + "\r\n"
+
+	multipart := "From: me@example.com\r\n" +
+		"Subject: Re: Approval needed for bash\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/alternative; boundary=b1\r\n" +
+		"\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"Approve, but be caref=\r\nul.\r\n" +
+		"> " + EmailReplyMarker(token) + "\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/html\r\n" +
+		"\r\n" +
+		"<p>Approve</p>\r\n" +
+		"--b1--\r\n"
+
+	for name, tt := range map[string]struct {
+		raw  string
+		text string
+	}{
+		"plain":     {plain, "Please also update the changelog."},
+		"multipart": {multipart, "Approve, but be careful."},
+	} {
+		t.Run(name, func(t *testing.T) {
+			reply, err := ParseEmailReply(strings.NewReader(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reply.Token != token || reply.Text != tt.text {
+				t.Errorf("got %+v, want token %q and text %q", reply, token, tt.text)
+			}
+		})
+	}
+
+	if _, err := ParseEmailReply(strings.NewReader("Subject: hi\r\n\r\nno token here\r\n")); err == nil {
+		t.Error("parsed an email without a token")
+	}
+}
+
+func TestParseApprovalReply(t *testing.T) {
+	tests := []struct {
+		text    string
+		action  ReplyAction
+		message string
+	}{
+		{"Approve", ReplyApprove, ""},
+		{"yes, but be careful", ReplyApprove, "but be careful"},
+		{"Deny.\nUse the staging database instead.", ReplyDeny, "Use the staging database instead."},
+		{"What does this command do?", ReplyMessage, "What does this command do?"},
+	}
+	for _, tt := range tests {
+		action, message := ParseApprovalReply(tt.text)
+		if action != tt.action || message != tt.message {
+			t.Errorf("ParseApprovalReply(%q) = %q, %q; want %q, %q", tt.text, action, message, tt.action, tt.message)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"shelley.exe.dev/claudetool/sandbox"
+)
+
+// setConversationSandbox stores a conversation's sandbox settings and
+// applies them to its running loop, if any.
+func (s *Server) setConversationSandbox(ctx context.Context, conversationID string, settings sandbox.Settings) error {
+	var encoded *string
+	if settings.Enabled {
+		data, err := json.Marshal(settings)
+		if err != nil {
+			return err
+		}
+		str := string(data)
+		encoded = &str
+	}
+	if err := s.db.UpdateConversationSandbox(ctx, conversationID, encoded); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	manager := s.activeConversations[conversationID]
+	s.mu.Unlock()
+	if manager != nil {
+		manager.SetSandboxSettings(settings)
+	}
+	return nil
+}
+
+// handleConversationSandbox handles GET and POST /conversation/<id>/sandbox,
+// which read and change the sandbox for the conversation's bash commands.
+func (s *Server) handleConversationSandbox(w http.ResponseWriter, r *http.Request, conversationID string) {
+	ctx := r.Context()
+	conversation, err := s.db.GetConversationByID(ctx, conversationID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Conversation not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to get conversation: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var settings sandbox.Settings
+	switch r.Method {
+	case http.MethodGet:
+		if conversation.Sandbox != nil {
+			if err := json.Unmarshal([]byte(*conversation.Sandbox), &settings); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid stored sandbox settings: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if err := settings.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.setConversationSandbox(ctx, conversationID, settings); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to update sandbox: %v", err), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
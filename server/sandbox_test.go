@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"shelley.exe.dev/claudetool/sandbox"
+)
+
+func TestConversationSandbox(t *testing.T) {
+	h := NewTestHarness(t)
+	ctx := context.Background()
+	conv, err := h.db.CreateConversation(ctx, nil, true, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create conversation: %v", err)
+	}
+	id := conv.ConversationID
+
+	do := func(method, body string) (*httptest.ResponseRecorder, sandbox.Settings) {
+		t.Helper()
+		req := httptest.NewRequest(method, "/conversation/"+id+"/sandbox", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.server.handleConversationSandbox(w, req, id)
+		var settings sandbox.Settings
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &settings); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+		}
+		return w, settings
+	}
+
+	if w, settings := do(http.MethodGet, ""); w.Code != http.StatusOK || settings.Enabled {
+		t.Fatalf("new conversation: status %d, settings %+v", w.Code, settings)
+	}
+
+	manager, err := h.server.getOrCreateConversationManager(ctx, id, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, _ := do(http.MethodPost, `{"enabled":true,"block_network":true,"memory_mb":1024}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST: status %d: %s", w.Code, w.Body)
+	}
+	want := sandbox.Settings{Enabled: true, BlockNetwork: true, MemoryMB: 1024}
+	if got := manager.SandboxSettings(); got.Enabled != want.Enabled || got.BlockNetwork != want.BlockNetwork || got.MemoryMB != want.MemoryMB {
+		t.Errorf("active manager settings = %+v, want %+v", got, want)
+	}
+	if _, settings := do(http.MethodGet, ""); !settings.BlockNetwork || settings.MemoryMB != 1024 {
+		t.Errorf("stored settings = %+v", settings)
+	}
+
+	// A conversation loaded later picks up the stored settings.
+	fresh := NewConversationManager(id, h.db, nil, h.server.toolSetConfig, nil, nil, "")
+	if err := fresh.Hydrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !fresh.SandboxSettings().BlockNetwork {
+		t.Errorf("hydrated settings = %+v", fresh.SandboxSettings())
+	}
+
+	if w, _ := do(http.MethodPost, `{"enabled":true,"writable_paths":["relative"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("relative writable path: status %d", w.Code)
+	}
+
+	if w, _ := do(http.MethodPost, `{"enabled":false}`); w.Code != http.StatusOK {
+		t.Fatalf("disable: status %d", w.Code)
+	}
+	stored, err := h.db.GetConversationByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Sandbox != nil || manager.SandboxSettings().Enabled {
+		t.Errorf("sandbox still enabled: stored %v, active %+v", stored.Sandbox, manager.SandboxSettings())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/conversation/missing/sandbox", nil)
+	w = httptest.NewRecorder()
+	h.server.handleConversationSandbox(w, req, "missing")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("missing conversation: status %d", w.Code)
+	}
+}
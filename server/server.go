@@ -21,91 +21,61 @@ import (
 	"tailscale.com/util/singleflight"
 
 	"shelley.exe.dev/claudetool"
+	"shelley.exe.dev/claudetool/sandbox"
 	"shelley.exe.dev/db"
 	"shelley.exe.dev/db/generated"
+	"shelley.exe.dev/gitstate"
 	"shelley.exe.dev/llm"
 	"shelley.exe.dev/models"
 	"shelley.exe.dev/server/notifications"
+	"shelley.exe.dev/shelleyapi"
 	"shelley.exe.dev/ui"
 )
 
-// APIMessage is the message format sent to clients
-// TODO: We could maybe omit llm_data when display_data is available
-type APIMessage struct {
-	MessageID      string    `json:"message_id"`
-	ConversationID string    `json:"conversation_id"`
-	SequenceID     int64     `json:"sequence_id"`
-	Type           string    `json:"type"`
-	LlmData        *string   `json:"llm_data,omitempty"`
-	UserData       *string   `json:"user_data,omitempty"`
-	UsageData      *string   `json:"usage_data,omitempty"`
-	CreatedAt      time.Time `json:"created_at"`
-	DisplayData    *string   `json:"display_data,omitempty"`
-	EndOfTurn      *bool     `json:"end_of_turn,omitempty"`
-}
-
-// ConversationState represents the current state of a conversation.
-// This is broadcast to all subscribers whenever the state changes.
-type ConversationState struct {
-	ConversationID string `json:"conversation_id"`
-	Working        bool   `json:"working"`
-	Model          string `json:"model,omitempty"`
-}
-
-// ConversationWithState combines a conversation with its working state.
-type ConversationWithState struct {
-	generated.Conversation
-	Working         bool   `json:"working"`
-	GitRepoRoot     string `json:"git_repo_root,omitempty"`
-	GitWorktreeRoot string `json:"git_worktree_root,omitempty"`
-	GitCommit       string `json:"git_commit,omitempty"`
-	GitSubject      string `json:"git_subject,omitempty"`
-	SubagentCount   int64  `json:"subagent_count"`
-}
-
-// StreamResponse represents the response format for conversation streaming
-type StreamResponse struct {
-	Messages          []APIMessage                   `json:"messages"`
-	Conversation      generated.Conversation         `json:"conversation"`
-	Runtime           *generated.ConversationRuntime `json:"runtime,omitempty"`
-	LastEventID       int64                          `json:"last_event_id,omitempty"`
-	ConversationState *ConversationState             `json:"conversation_state,omitempty"`
-	ContextWindowSize uint64                         `json:"context_window_size,omitempty"`
-	// ConversationListUpdate is set when another conversation in the list changed
-	ConversationListUpdate *ConversationListUpdate `json:"conversation_list_update,omitempty"`
-	// Heartbeat indicates this is a heartbeat message (no new data, just keeping connection alive)
-	Heartbeat bool `json:"heartbeat,omitempty"`
-	// NotificationEvent is set when a notification-worthy event occurs (e.g. agent finished).
-	NotificationEvent *notifications.Event `json:"notification_event,omitempty"`
-	// StreamingText is a delta of text content being streamed from the LLM.
-	StreamingText string `json:"streaming_text,omitempty"`
-	// StreamingThinking is a delta of thinking/reasoning content being streamed.
-	StreamingThinking string `json:"streaming_thinking,omitempty"`
-}
-
-func (sr *StreamResponse) UnmarshalJSON(data []byte) error {
-	type alias StreamResponse
-	var direct alias
-	if err := json.Unmarshal(data, &direct); err == nil && (direct.Conversation.ConversationID != "" || direct.Messages != nil || direct.Heartbeat || direct.ConversationState != nil || direct.NotificationEvent != nil || direct.ConversationListUpdate != nil || direct.StreamingText != "" || direct.StreamingThinking != "") {
-		*sr = StreamResponse(direct)
-		return nil
-	}
-
-	var envelope StreamEventEnvelopeV1
-	if err := json.Unmarshal(data, &envelope); err != nil {
-		return err
-	}
-	if len(envelope.Payload) == 0 {
-		*sr = StreamResponse{}
-		return nil
-	}
-	var payload alias
-	if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
-		return err
-	}
-	*sr = StreamResponse(payload)
-	return nil
-}
+// API types shared with clients. They are defined in package shelleyapi so
+// Go programs can use them without importing the server.
+type (
+	APIMessage                 = shelleyapi.APIMessage
+	ConversationState          = shelleyapi.ConversationState
+	ConversationWithState      = shelleyapi.ConversationWithState
+	StreamResponse             = shelleyapi.StreamResponse
+	ConversationListUpdate     = shelleyapi.ConversationListUpdate
+	StreamEventEnvelopeV1      = shelleyapi.StreamEventEnvelopeV1
+	ChatRequest                = shelleyapi.ChatRequest
+	RenameRequest              = shelleyapi.RenameRequest
+	ForkConversationRequest    = shelleyapi.ForkConversationRequest
+	DistillConversationRequest = shelleyapi.DistillConversationRequest
+	ModelInfo                  = shelleyapi.ModelInfo
+	ModelAPI                   = shelleyapi.ModelAPI
+	CreateModelRequest         = shelleyapi.CreateModelRequest
+	UpdateModelRequest         = shelleyapi.UpdateModelRequest
+	DuplicateModelRequest      = shelleyapi.DuplicateModelRequest
+	TestModelRequest           = shelleyapi.TestModelRequest
+	SkillAPI                   = shelleyapi.SkillAPI
+	SkillsResponse             = shelleyapi.SkillsResponse
+	SkillRequest               = shelleyapi.SkillRequest
+	InstallSkillRequest        = shelleyapi.InstallSkillRequest
+	GitDiffInfo                = shelleyapi.GitDiffInfo
+	GitFileInfo                = shelleyapi.GitFileInfo
+	GitFileDiff                = shelleyapi.GitFileDiff
+	DirectoryEntry             = shelleyapi.DirectoryEntry
+	ListDirectoryResponse      = shelleyapi.ListDirectoryResponse
+
+	NotificationChannelAPI           = shelleyapi.NotificationChannelAPI
+	CreateNotificationChannelRequest = shelleyapi.CreateNotificationChannelRequest
+	UpdateNotificationChannelRequest = shelleyapi.UpdateNotificationChannelRequest
+	ConfigField                      = shelleyapi.ConfigField
+	ChannelTypeInfo                  = shelleyapi.ChannelTypeInfo
+	TestNotificationChannelResponse  = shelleyapi.TestNotificationChannelResponse
+	NotificationDeliveryAPI          = shelleyapi.NotificationDeliveryAPI
+	AuthStatus                       = shelleyapi.AuthStatus
+	APITokenAPI                      = shelleyapi.APITokenAPI
+	CreateAPITokenRequest            = shelleyapi.CreateAPITokenRequest
+	CodexAuthStatusResponse          = shelleyapi.CodexAuthStatusResponse
+	CodexPkceStartResponse           = shelleyapi.CodexPkceStartResponse
+	CodexPkceCompleteRequest         = shelleyapi.CodexPkceCompleteRequest
+	CommitInfo                       = shelleyapi.CommitInfo
+)
 
 // LLMProvider is an interface for getting LLM services
 type LLMProvider interface {
@@ -127,6 +97,8 @@ func NewLLMServiceManager(cfg *LLMConfig) LLMProvider {
 		Gateway:         cfg.Gateway,
 		Logger:          cfg.Logger,
 		DB:              cfg.DB,
+		Pricing:         cfg.Pricing,
+		Redactor:        cfg.Redactor,
 	}
 
 	manager, err := models.NewManager(modelConfig)
@@ -235,15 +207,6 @@ func calculateContextWindowSizeFromMsg(msg *generated.Message) uint64 {
 	return usage.ContextWindowUsed()
 }
 
-// ConversationListUpdate represents an update to the conversation list
-type ConversationListUpdate struct {
-	Type            string                  `json:"type"` // "update", "delete"
-	Conversation    *generated.Conversation `json:"conversation,omitempty"`
-	ConversationID  string                  `json:"conversation_id,omitempty"` // For deletes
-	GitRepoRoot     string                  `json:"git_repo_root,omitempty"`
-	GitWorktreeRoot string                  `json:"git_worktree_root,omitempty"`
-}
-
 // Server manages the HTTP API and active conversations
 type Server struct {
 	db                   *db.DB
@@ -266,6 +229,9 @@ type Server struct {
 	eventLog             *EventLogService
 	jobs                 *JobService
 	notifDispatcher      *notifications.Dispatcher
+	notifOutbox          *notificationOutbox
+	replySecretMu        sync.Mutex
+	replySecretKey       []byte        // Signs notification reply tokens; see replySecret
 	shutdownCh           chan struct{} // Signals background routines to stop
 	listenPort           int           // TCP port the server is listening on
 }
@@ -297,6 +263,9 @@ func NewServer(database *db.DB, llmManager LLMProvider, toolSetConfig claudetool
 		panic(fmt.Errorf("failed to reconcile interrupted jobs: %w", err))
 	}
 
+	s.notifOutbox = newNotificationOutbox(database, s.notifDispatcher, logger)
+	s.notifDispatcher.SetOutbox(s.notifOutbox)
+
 	// Set up subagent support
 	s.toolSetConfig.SubagentRunner = NewSubagentRunner(s)
 	s.toolSetConfig.SubagentDB = &db.SubagentDBAdapter{DB: database}
@@ -350,9 +319,26 @@ func (s *Server) RegisterRoutes(mux *http.ServeMux) {
 	mux.Handle("/api/notification-channels/", http.HandlerFunc(s.handleNotificationChannel))
 	mux.Handle("/api/notification-channel-types", http.HandlerFunc(s.handleNotificationChannelTypes))
 
+	// Notification replies, authenticated by their reply token rather than
+	// the session, so they live outside /api/
+	mux.Handle("POST /notifications/reply", http.HandlerFunc(s.handleNotificationReply))
+	mux.Handle("POST /notifications/email-reply", http.HandlerFunc(s.handleNotificationEmailReply))
+
+	// Built-in authentication
+	mux.Handle("/login", http.HandlerFunc(s.handleLogin))
+	mux.Handle("POST /logout", http.HandlerFunc(s.handleLogout))
+	mux.Handle("GET /api/auth/status", http.HandlerFunc(s.handleAuthStatus))
+	mux.Handle("/api/auth/tokens", http.HandlerFunc(s.handleAPITokens))
+	mux.Handle("/api/auth/tokens/", http.HandlerFunc(s.handleAPIToken))
+
 	// Models API (dynamic list refresh)
 	mux.Handle("/api/models", http.HandlerFunc(s.handleModels))
+
+	// Skills API
+	mux.Handle("/api/skills", http.HandlerFunc(s.handleSkills))
+	mux.Handle("/api/skills/", http.HandlerFunc(s.handleSkill))
 	mux.Handle("/api/jobs/", http.StripPrefix("/api/jobs", s.jobsMux()))
+	mux.Handle("GET /api/usage", gzipHandler(http.HandlerFunc(s.handleUsage)))
 
 	// Codex OAuth
 	mux.Handle("/api/codex-auth/status", http.HandlerFunc(s.handleCodexAuthStatus))
@@ -369,6 +355,9 @@ func (s *Server) RegisterRoutes(mux *http.ServeMux) {
 	mux.Handle("GET /settings", http.HandlerFunc(s.handleGetSettings))
 	mux.Handle("POST /settings", http.HandlerFunc(s.handleSetSetting))
 
+	// Prometheus metrics
+	mux.Handle("GET /metrics", http.HandlerFunc(s.handleMetrics))
+
 	// Debug endpoints
 	mux.Handle("GET /debug/conversations", http.HandlerFunc(s.handleDebugConversationsPage))
 	mux.Handle("GET /debug/llm_requests", http.HandlerFunc(s.handleDebugLLMRequests))
@@ -437,22 +426,6 @@ func (s *Server) handleValidateCwd(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// DirectoryEntry represents a single directory entry for the directory picker
-type DirectoryEntry struct {
-	Name           string `json:"name"`
-	IsDir          bool   `json:"is_dir"`
-	GitHeadSubject string `json:"git_head_subject,omitempty"`
-}
-
-// ListDirectoryResponse is the response from the list-directory endpoint
-type ListDirectoryResponse struct {
-	Path            string           `json:"path"`
-	Parent          string           `json:"parent"`
-	Entries         []DirectoryEntry `json:"entries"`
-	GitHeadSubject  string           `json:"git_head_subject,omitempty"`
-	GitWorktreeRoot string           `json:"git_worktree_root,omitempty"`
-}
-
 // handleListDirectory lists the contents of a directory for the directory picker
 func (s *Server) handleListDirectory(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -611,8 +584,7 @@ func gitInfoForCwd(cwd string) (repoRoot, worktreeRoot string) {
 // getGitHeadSubject returns the subject line of HEAD commit for a git repository.
 // Returns empty string if unable to get the subject.
 func getGitHeadSubject(repoPath string) string {
-	cmd := exec.Command("git", "log", "-1", "--format=%s")
-	cmd.Dir = repoPath
+	cmd := gitstate.Command(repoPath, "log", "-1", "--format=%s")
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
@@ -624,8 +596,7 @@ func getGitHeadSubject(repoPath string) string {
 // a git worktree (not the main repo itself). Returns "" otherwise.
 func getGitWorktreeRoot(repoPath string) string {
 	// Get the worktree's git dir and the common (main repo) git dir
-	cmd := exec.Command("git", "rev-parse", "--git-dir", "--git-common-dir")
-	cmd.Dir = repoPath
+	cmd := gitstate.Command(repoPath, "rev-parse", "--git-dir", "--git-common-dir")
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
@@ -756,11 +727,17 @@ func (s *Server) getOrCreateConversationManagerWithConfig(ctx context.Context, c
 			return s.recordMessage(ctx, conversationID, message, usage)
 		}
 
-		onStateChange := func(state ConversationState) {
-			s.publishConversationState(state)
+		onStateChange := func(state ConversationState, turnDuration time.Duration) {
+			s.publishConversationState(state, turnDuration)
 		}
 
 		manager := NewConversationManager(conversationID, s.db, s.logger, toolSetConfig, recordMessage, onStateChange, s.systemPromptTemplate)
+		manager.notify = func(eventType notifications.EventType, payload any) {
+			s.notifyConversationEvent(conversationID, eventType, payload)
+		}
+		manager.traceContext = func(ctx context.Context) context.Context {
+			return s.jobs.TraceContext(ctx, conversationID)
+		}
 		if userEmail != "" {
 			manager.userEmail = userEmail
 		}
@@ -875,7 +852,7 @@ func (s *Server) recordMessage(ctx context.Context, conversationID string, messa
 	apiMessages := toAPIMessages([]generated.Message{*createdMsg})
 	streamData := StreamResponse{
 		Messages:          apiMessages,
-		Conversation:      conversation,
+		Conversation:      shelleyapi.Conversation(conversation),
 		ContextWindowSize: calculateContextWindowSizeFromMsg(createdMsg),
 	}
 	event, err := s.eventLog.Append(ctx, conversationID, activeJobID, &createdMsg.MessageID, eventTypeMessageCreated, streamData)
@@ -894,6 +871,9 @@ func (s *Server) recordMessage(ctx context.Context, conversationID string, messa
 		mgr.Touch()
 	}
 	s.mu.Unlock()
+	if ok {
+		s.observeMessageForNotifications(ctx, mgr, conversation, message, usage)
+	}
 
 	// Notify subscribers with only the new message - use WithoutCancel because
 	// the HTTP request context may be cancelled after the handler returns, but
@@ -1013,8 +993,8 @@ func (s *Server) notifySubscribers(ctx context.Context, conversationID string, e
 	// doesn't race with notifySubscribersNewMessage which uses Publish with sequence IDs.
 	streamData := StreamResponse{
 		Messages:     nil, // No new messages, just conversation update
-		Conversation: conversation,
-		Runtime:      runtime,
+		Conversation: shelleyapi.Conversation(conversation),
+		Runtime:      (*shelleyapi.ConversationRuntime)(runtime),
 		LastEventID:  runtime.LastEventID,
 	}
 	envelope := mustTransientStreamEvent(conversationID, runtime.ActiveJobID, eventTypeConversationUpdated, streamData)
@@ -1026,7 +1006,7 @@ func (s *Server) notifySubscribers(ctx context.Context, conversationID string, e
 	// Also notify conversation list subscribers (e.g., slug change)
 	s.publishConversationListUpdate(ConversationListUpdate{
 		Type:         "update",
-		Conversation: &conversation,
+		Conversation: (*shelleyapi.Conversation)(&conversation),
 	})
 }
 
@@ -1066,8 +1046,8 @@ func (s *Server) notifySubscribersNewMessage(ctx context.Context, conversationID
 	// Publish only the new message
 	streamData := StreamResponse{
 		Messages:     apiMessages,
-		Conversation: conversation,
-		Runtime:      runtime,
+		Conversation: shelleyapi.Conversation(conversation),
+		Runtime:      (*shelleyapi.ConversationRuntime)(runtime),
 		LastEventID:  runtime.LastEventID,
 		// ContextWindowSize: 0 for messages without usage data (user/tool messages).
 		// With omitempty, 0 is omitted from JSON, so the UI keeps its cached value.
@@ -1081,7 +1061,7 @@ func (s *Server) notifySubscribersNewMessage(ctx context.Context, conversationID
 	// Also notify conversation list subscribers about the update (updated_at changed)
 	s.publishConversationListUpdate(ConversationListUpdate{
 		Type:         "update",
-		Conversation: &conversation,
+		Conversation: (*shelleyapi.Conversation)(&conversation),
 	})
 }
 
@@ -1112,7 +1092,7 @@ func (s *Server) broadcastMessageUpdate(ctx context.Context, conversationID stri
 	apiMessages := toAPIMessages([]generated.Message{*updatedMsg})
 	streamData := StreamResponse{
 		Messages:     apiMessages,
-		Conversation: conversation,
+		Conversation: shelleyapi.Conversation(conversation),
 	}
 	event, err := s.eventLog.Append(ctx, conversationID, runtime.ActiveJobID, &updatedMsg.MessageID, eventTypeMessageUpdated, streamData)
 	if err != nil {
@@ -1124,7 +1104,7 @@ func (s *Server) broadcastMessageUpdate(ctx context.Context, conversationID stri
 	}
 
 	manager.SetConversation(conversation)
-	streamData.Runtime = runtime
+	streamData.Runtime = (*shelleyapi.ConversationRuntime)(runtime)
 	streamData.LastEventID = runtime.LastEventID
 	envelope := mustTransientStreamEvent(conversationID, runtime.ActiveJobID, eventTypeMessageUpdated, streamData)
 	envelope.EventID = event.EventID
@@ -1132,7 +1112,7 @@ func (s *Server) broadcastMessageUpdate(ctx context.Context, conversationID stri
 
 	s.publishConversationListUpdate(ConversationListUpdate{
 		Type:         "update",
-		Conversation: &conversation,
+		Conversation: (*shelleyapi.Conversation)(&conversation),
 	})
 	return nil
 }
@@ -1167,20 +1147,19 @@ func (s *Server) publishConversationListUpdate(update ConversationListUpdate) {
 
 // publishConversationState broadcasts a conversation state update to ALL active
 // conversation streams. This allows clients to see the working state of other conversations.
-func (s *Server) publishConversationState(state ConversationState) {
+// turnDuration is how long the agent worked, when it stops working.
+func (s *Server) publishConversationState(state ConversationState, turnDuration time.Duration) {
 	// When the agent finishes working, emit a notification event.
-	// Skip notifications for subagent conversations — they're internal
-	// and would just be noise for the user.
+	// Subagent conversations are internal and would just be noise, so
+	// channels drop their events unless configured to include them.
 	var notifEvent *notifications.Event
 	if !state.Working {
 		conv, convErr := s.db.GetConversationByID(context.Background(), state.ConversationID)
 		isSubagent := convErr == nil && conv.ParentConversationID != nil
 
-		payload := notifications.AgentDonePayload{
-			Model: state.Model,
-		}
+		var title, finalResponse string
 		if convErr == nil && conv.Slug != nil {
-			payload.ConversationTitle = *conv.Slug
+			title = *conv.Slug
 		}
 		if msg, err := s.db.GetLatestMessage(context.Background(), state.ConversationID); err == nil && msg.Type == string(db.MessageTypeAgent) && msg.LlmData != nil {
 			var llmMsg llm.Message
@@ -1194,19 +1173,37 @@ func (s *Server) publishConversationState(state ConversationState) {
 				if len(text) > 255 {
 					text = text[:255] + "..."
 				}
-				payload.FinalResponse = text
+				finalResponse = text
 			}
 		}
 		event := notifications.Event{
 			Type:           notifications.EventAgentDone,
 			ConversationID: state.ConversationID,
 			Timestamp:      time.Now(),
-			Payload:        payload,
+			Payload: notifications.AgentDonePayload{
+				Model:             state.Model,
+				ConversationTitle: title,
+				FinalResponse:     finalResponse,
+			},
+			Subagent: isSubagent,
+			Duration: turnDuration,
+		}
+		if isSubagent {
+			event.Type = notifications.EventSubagentDone
+			event.Payload = notifications.SubagentDonePayload{
+				ParentConversationID: *conv.ParentConversationID,
+				Model:                state.Model,
+				ConversationTitle:    title,
+				FinalResponse:        finalResponse,
+			}
 		}
-		if !isSubagent {
-			s.notifDispatcher.Dispatch(context.Background(), event)
+		if convErr == nil && conv.Cwd != nil {
+			event.Cwd = *conv.Cwd
 		}
-		// Still set notifEvent so the SSE stream broadcasts it to the UI.
+		dispatched := event
+		s.attachReply(context.Background(), &dispatched)
+		s.notifDispatcher.Dispatch(context.Background(), dispatched)
+		// Also set notifEvent so the SSE stream broadcasts it to the UI.
 		notifEvent = &event
 	}
 
@@ -1232,12 +1229,29 @@ func (s *Server) broadcastConversationStateUpdateWithNotification(state Conversa
 		manager.subpub.Broadcast(mustTransientStreamEvent(state.ConversationID, nil, eventTypeConversationStateChanged, streamData))
 		if notifEvent != nil {
 			manager.subpub.Broadcast(mustTransientStreamEvent(state.ConversationID, nil, eventTypeNotificationCreated, StreamResponse{
-				NotificationEvent: notifEvent,
+				NotificationEvent: apiNotificationEvent(*notifEvent),
 			}))
 		}
 	}
 }
 
+// broadcastNotificationEvent sends a notification event to ALL active
+// conversation streams so the UI's local notification handlers see it.
+func (s *Server) broadcastNotificationEvent(event notifications.Event) {
+	s.mu.Lock()
+	managers := make([]*ConversationManager, 0, len(s.activeConversations))
+	for _, manager := range s.activeConversations {
+		managers = append(managers, manager)
+	}
+	s.mu.Unlock()
+
+	for _, manager := range managers {
+		manager.subpub.Broadcast(mustTransientStreamEvent(event.ConversationID, nil, eventTypeNotificationCreated, StreamResponse{
+			NotificationEvent: apiNotificationEvent(event),
+		}))
+	}
+}
+
 // IsAgentWorking returns whether the agent is currently working on the given conversation.
 // Returns false if the conversation doesn't have an active manager.
 func (s *Server) IsAgentWorking(conversationID string) bool {
@@ -1291,8 +1305,8 @@ func (s *Server) StartWithListener(listener net.Listener) error {
 }
 
 // StartWithListeners starts the HTTP server on the given TCP listener and optionally
-// also on a Unix socket. The TCP listener gets full middleware (CSRF, requireHeader, logger).
-// The Unix socket listener gets only the logger middleware (no CSRF, no requireHeader)
+// also on a Unix socket. The TCP listener gets full middleware (auth, CSRF, requireHeader, logger).
+// The Unix socket listener gets only the logger middleware (no auth, CSRF or requireHeader)
 // since it is local and trusted.
 func (s *Server) StartWithListeners(tcpListener net.Listener, socketPath string) error {
 	// Set up shared mux with routes
@@ -1300,8 +1314,12 @@ func (s *Server) StartWithListeners(tcpListener net.Listener, socketPath string)
 	s.RegisterRoutes(mux)
 
 	// TCP handler: full middleware (applied in reverse order: last added = first executed)
-	tcpHandler := LoggerMiddleware(s.logger)(mux)
+	tcpHandler := s.AuthMiddleware(mux)
+	tcpHandler = LoggerMiddleware(s.logger)(tcpHandler)
 	cop := http.NewCrossOriginProtection()
+	// Replies carry their own token, so they may come from other origins.
+	cop.AddInsecureBypassPattern("POST /notifications/reply")
+	cop.AddInsecureBypassPattern("POST /notifications/email-reply")
 	tcpHandler = cop.Handler(tcpHandler)
 	if s.requireHeader != "" {
 		tcpHandler = RequireHeaderMiddleware(s.requireHeader)(tcpHandler)
@@ -1323,9 +1341,17 @@ func (s *Server) StartWithListeners(tcpListener net.Listener, socketPath string)
 	// Start auto-upgrade routine
 	go s.autoUpgradeRoutine()
 
+	// Refresh skills in running conversations when skill files change
+	go s.watchSkillsRoutine()
+
+	// Deliver queued notifications, including any left from before a restart
+	go s.notifOutbox.run(s.shutdownCh)
+
 	// Get actual port from listener
 	actualPort := tcpListener.Addr().(*net.TCPAddr).Port
 	s.listenPort = actualPort
+	// Sandboxed bash commands must not reach the server to lift their sandbox.
+	sandbox.ProtectServer("", actualPort)
 
 	// Start TCP server in goroutine
 	serverErrCh := make(chan error, 2)
@@ -1360,6 +1386,8 @@ func (s *Server) StartWithListeners(tcpListener net.Listener, socketPath string)
 			return err
 		}
 
+		sandbox.ProtectServer(actualSocketPath, 0)
+
 		// Make socket accessible to the current user only
 		if err := os.Chmod(actualSocketPath, 0o600); err != nil {
 			s.logger.Warn("Failed to chmod socket", "path", actualSocketPath, "error", err)
@@ -1523,6 +1551,17 @@ func (s *Server) performUpgradeAndRestart(ctx context.Context, versionInfo *Vers
 
 	s.logger.Info("Auto-upgrade complete, restarting")
 
+	// Channels loaded from the database queue the notification in the outbox,
+	// which delivers it once the new version starts.
+	s.notifDispatcher.Dispatch(ctx, notifications.Event{
+		Type:      notifications.EventUpgradeDone,
+		Timestamp: time.Now(),
+		Payload: notifications.UpgradeDonePayload{
+			FromVersion: versionInfo.CurrentTag,
+			ToVersion:   versionInfo.LatestTag,
+		},
+	})
+
 	// Exit to trigger restart (systemd will restart us)
 	time.Sleep(100 * time.Millisecond)
 	os.Exit(0)
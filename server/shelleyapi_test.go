@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/server/notifications"
+	"shelley.exe.dev/shelleyapi"
+)
+
+// TestShelleyAPIClient drives a conversation through the Go client package
+// against the real routes.
+func TestShelleyAPIClient(t *testing.T) {
+	server, _, _ := newTestServer(t)
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+	httpServer := httptest.NewServer(mux)
+	defer httpServer.Close()
+
+	c, err := shelleyapi.New(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models, err := c.Models(ctx)
+	if err != nil {
+		t.Fatalf("Models: %v", err)
+	}
+	if len(models) == 0 {
+		t.Fatal("expected at least one model")
+	}
+
+	created, err := c.NewConversation(ctx, shelleyapi.ChatRequest{Message: "echo: first", Model: "predictable", Cwd: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewConversation: %v", err)
+	}
+	conversationID := created.ConversationID
+
+	stream := c.Stream(ctx, conversationID, 0)
+	defer stream.Close()
+	if got := waitForTurnEnd(t, stream); got != "first" {
+		t.Errorf("expected echoed %q, got %q", "first", got)
+	}
+
+	// A second stream resuming from the first one's position sees only the
+	// next turn.
+	if err := c.Chat(ctx, conversationID, shelleyapi.ChatRequest{Message: "echo: second", Model: "predictable"}); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	resumed := c.Stream(ctx, conversationID, stream.LastEventID())
+	defer resumed.Close()
+	if got := waitForTurnEnd(t, resumed); got != "second" {
+		t.Errorf("expected echoed %q, got %q", "second", got)
+	}
+
+	snapshot, err := c.GetConversation(ctx, conversationID)
+	if err != nil {
+		t.Fatalf("GetConversation: %v", err)
+	}
+	if len(snapshot.Messages) < 4 {
+		t.Errorf("expected at least 4 messages, got %d", len(snapshot.Messages))
+	}
+	if snapshot.LastEventID < resumed.LastEventID() {
+		t.Errorf("snapshot LastEventID %d is behind the stream's %d", snapshot.LastEventID, resumed.LastEventID())
+	}
+
+	conversations, err := c.ListConversations(ctx, shelleyapi.ListOptions{})
+	if err != nil {
+		t.Fatalf("ListConversations: %v", err)
+	}
+	if len(conversations) != 1 || conversations[0].ConversationID != conversationID {
+		t.Errorf("expected the conversation to be listed, got %+v", conversations)
+	}
+
+	if _, err := c.GetConversation(ctx, "no-such-conversation"); !shelleyapi.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+// TestShelleyAPIClientAdmin manages notification channels and API tokens
+// through the Go client package.
+func TestShelleyAPIClientAdmin(t *testing.T) {
+	server, _, _ := newTestServer(t)
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+	httpServer := httptest.NewServer(mux)
+	defer httpServer.Close()
+
+	c, err := shelleyapi.New(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	types, err := c.NotificationChannelTypes(ctx)
+	if err != nil {
+		t.Fatalf("NotificationChannelTypes: %v", err)
+	}
+	if len(types) == 0 {
+		t.Error("expected at least one channel type")
+	}
+
+	channel, err := c.CreateNotificationChannel(ctx, shelleyapi.CreateNotificationChannelRequest{
+		ChannelType: "outbox-test",
+		DisplayName: "Client",
+		Enabled:     true,
+		Config:      map[string]any{},
+	})
+	if err != nil {
+		t.Fatalf("CreateNotificationChannel: %v", err)
+	}
+	updated, err := c.UpdateNotificationChannel(ctx, channel.ChannelID, shelleyapi.UpdateNotificationChannelRequest{
+		DisplayName: "Renamed",
+		Config:      map[string]any{},
+	})
+	if err != nil {
+		t.Fatalf("UpdateNotificationChannel: %v", err)
+	}
+	if updated.DisplayName != "Renamed" || updated.Enabled {
+		t.Errorf("unexpected updated channel %+v", updated)
+	}
+	channels, err := c.NotificationChannels(ctx)
+	if err != nil {
+		t.Fatalf("NotificationChannels: %v", err)
+	}
+	if len(channels) != 1 || channels[0].ChannelID != channel.ChannelID {
+		t.Errorf("expected the channel to be listed, got %+v", channels)
+	}
+	deliveries, err := c.NotificationDeliveries(ctx, channel.ChannelID, 10)
+	if err != nil {
+		t.Fatalf("NotificationDeliveries: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Errorf("expected no deliveries, got %+v", deliveries)
+	}
+	if err := c.DeleteNotificationChannel(ctx, channel.ChannelID); err != nil {
+		t.Fatalf("DeleteNotificationChannel: %v", err)
+	}
+	if _, err := c.GetNotificationChannel(ctx, channel.ChannelID); !shelleyapi.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	token, err := c.CreateAPIToken(ctx, shelleyapi.CreateAPITokenRequest{Name: "ci", Scope: "read", ExpiresIn: "1d"})
+	if err != nil {
+		t.Fatalf("CreateAPIToken: %v", err)
+	}
+	if token.Token == "" || token.ExpiresAt == nil {
+		t.Errorf("expected a secret and an expiry, got %+v", token)
+	}
+	tokens, err := c.APITokens(ctx)
+	if err != nil {
+		t.Fatalf("APITokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].TokenID != token.TokenID || tokens[0].Token != "" {
+		t.Errorf("expected the token to be listed without its secret, got %+v", tokens)
+	}
+	if err := c.RevokeAPIToken(ctx, token.TokenID); err != nil {
+		t.Fatalf("RevokeAPIToken: %v", err)
+	}
+	status, err := c.AuthStatus(ctx)
+	if err != nil {
+		t.Fatalf("AuthStatus: %v", err)
+	}
+	if status.Enabled {
+		t.Errorf("expected authentication to be off, got %+v", status)
+	}
+}
+
+// waitForTurnEnd reads stream events until an agent message ends the turn
+// and returns that message's text.
+func waitForTurnEnd(t *testing.T, stream *shelleyapi.Stream) string {
+	t.Helper()
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatalf("stream: %v", err)
+		}
+		for _, msg := range event.Data.Messages {
+			if !msg.EndsTurn() {
+				continue
+			}
+			llmMsg, err := msg.LLMMessage()
+			if err != nil {
+				t.Fatalf("decoding message: %v", err)
+			}
+			var text string
+			for _, c := range llmMsg.Content {
+				if c.Type == llm.ContentTypeText {
+					text += c.Text
+				}
+			}
+			return text
+		}
+	}
+}
+
+// TestAPINotificationEvent checks that notification events keep their JSON
+// form on conversation streams.
+func TestAPINotificationEvent(t *testing.T) {
+	event := notifications.Event{
+		Type:           notifications.EventToolApprovalNeeded,
+		ConversationID: "c1",
+		Timestamp:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Payload:        notifications.ToolRunningPayload{ToolName: "bash", Input: "make", ElapsedSeconds: 300},
+		Cwd:            "/src",
+		Subagent:       true,
+		Duration:       time.Minute,
+		Reply:          &notifications.Reply{Token: "token", Actions: []notifications.ReplyAction{notifications.ReplyApprove, notifications.ReplyDeny}},
+	}
+	want, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(apiNotificationEvent(event))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,328 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"shelley.exe.dev/gitstate"
+	"shelley.exe.dev/shelleyapi"
+	"shelley.exe.dev/skills"
+)
+
+// skillsPollInterval is how often the skill directories are checked for changes.
+const skillsPollInterval = 2 * time.Second
+
+func skillFromRequest(r SkillRequest) skills.Skill {
+	return skills.Skill{
+		Name:          r.Name,
+		Description:   r.Description,
+		License:       r.License,
+		Compatibility: r.Compatibility,
+		AllowedTools:  r.AllowedTools,
+		Metadata:      r.Metadata,
+	}
+}
+
+func toSkillAPI(c skills.Candidate, source, userDir string) SkillAPI {
+	api := SkillAPI{
+		Skill:    shelleyapi.Skill(c.Skill),
+		Dir:      c.Skill.Dir(),
+		Source:   source,
+		Editable: filepath.Dir(c.Skill.Dir()) == userDir,
+	}
+	if c.Err != nil {
+		api.Error = c.Err.Error()
+	}
+	return api
+}
+
+// inspectSkills lists the user skills and, if cwd is set, the skills in the
+// project's .skills directories.
+func inspectSkills(cwd string) (SkillsResponse, error) {
+	userDir, err := skills.UserDir()
+	if err != nil {
+		return SkillsResponse{}, err
+	}
+	resp := SkillsResponse{Skills: []SkillAPI{}, UserDir: userDir}
+	for _, c := range skills.Inspect(skills.DefaultDirs()) {
+		resp.Skills = append(resp.Skills, toSkillAPI(c, "user", userDir))
+	}
+	if cwd != "" {
+		dirs := skills.ProjectSkillsDirs(cwd, gitstate.GetGitState(cwd).Worktree)
+		for _, c := range skills.Inspect(dirs) {
+			resp.Skills = append(resp.Skills, toSkillAPI(c, "project", userDir))
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) handleSkills(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleListSkills(w, r)
+	case http.MethodPost:
+		s.handleCreateSkill(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleListSkills(w http.ResponseWriter, r *http.Request) {
+	resp, err := inspectSkills(r.URL.Query().Get("cwd"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list skills: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleCreateSkill(w http.ResponseWriter, r *http.Request) {
+	var req SkillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	userDir, err := skills.UserDir()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to locate skills directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if _, ok := findSkill(userDir, req.Name); ok {
+		http.Error(w, fmt.Sprintf("Skill %q already exists", req.Name), http.StatusConflict)
+		return
+	}
+	s.writeSkill(w, userDir, req, http.StatusCreated)
+}
+
+func (s *Server) handleSkill(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/skills/")
+	if name == "" || strings.Contains(name, "/") {
+		http.Error(w, "Invalid skill name", http.StatusBadRequest)
+		return
+	}
+	if name == "install" && r.Method == http.MethodPost {
+		s.handleInstallSkill(w, r)
+		return
+	}
+
+	userDir, err := skills.UserDir()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to locate skills directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.handleGetSkill(w, r, userDir, name)
+	case http.MethodPut:
+		s.handleUpdateSkill(w, r, userDir, name)
+	case http.MethodDelete:
+		s.handleDeleteSkill(w, r, userDir, name)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// findSkill looks up a skill by directory name in dir.
+func findSkill(dir, name string) (skills.Candidate, bool) {
+	for _, c := range skills.Inspect([]string{dir}) {
+		if filepath.Base(c.Skill.Dir()) == name {
+			return c, true
+		}
+	}
+	return skills.Candidate{}, false
+}
+
+func (s *Server) handleGetSkill(w http.ResponseWriter, r *http.Request, userDir, name string) {
+	for _, c := range skills.Inspect(skills.DefaultDirs()) {
+		if filepath.Base(c.Skill.Dir()) != name {
+			continue
+		}
+		api := toSkillAPI(c, "user", userDir)
+		if c.Err == nil {
+			body, err := c.Skill.Body()
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Failed to read skill: %v", err), http.StatusInternalServerError)
+				return
+			}
+			api.Body = body
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api)
+		return
+	}
+	http.Error(w, "Skill not found", http.StatusNotFound)
+}
+
+func (s *Server) handleUpdateSkill(w http.ResponseWriter, r *http.Request, userDir, name string) {
+	var req SkillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		req.Name = name
+	}
+	if req.Name != name {
+		http.Error(w, "Skills can't be renamed; create a new skill instead", http.StatusBadRequest)
+		return
+	}
+	if _, ok := findSkill(userDir, name); !ok {
+		http.Error(w, fmt.Sprintf("Skill %q not found in %s", name, userDir), http.StatusNotFound)
+		return
+	}
+	s.writeSkill(w, userDir, req, http.StatusOK)
+}
+
+func (s *Server) writeSkill(w http.ResponseWriter, userDir string, req SkillRequest, status int) {
+	skill, err := skills.Write(userDir, skillFromRequest(req), req.Body)
+	if err != nil {
+		var verr *skills.ValidationError
+		if errors.As(err, &verr) {
+			http.Error(w, verr.Message, http.StatusBadRequest)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to write skill: %v", err), http.StatusInternalServerError)
+		return
+	}
+	go s.refreshSkills()
+
+	api := toSkillAPI(skills.Candidate{Skill: skill}, "user", userDir)
+	api.Body = strings.TrimSpace(req.Body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(api)
+}
+
+func (s *Server) handleDeleteSkill(w http.ResponseWriter, r *http.Request, userDir, name string) {
+	if err := skills.Remove(userDir, name); err != nil {
+		var verr *skills.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			http.Error(w, verr.Message, http.StatusBadRequest)
+		case errors.Is(err, fs.ErrNotExist):
+			http.Error(w, fmt.Sprintf("Skill %q not found in %s", name, userDir), http.StatusNotFound)
+		default:
+			http.Error(w, fmt.Sprintf("Failed to delete skill: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+	go s.refreshSkills()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleInstallSkill(w http.ResponseWriter, r *http.Request) {
+	var req InstallSkillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !filepath.IsAbs(req.Source) {
+		http.Error(w, "source must be an absolute path", http.StatusBadRequest)
+		return
+	}
+	userDir, err := skills.UserDir()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to locate skills directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	skill, err := skills.Install(userDir, req.Source, req.Replace)
+	if err != nil {
+		var verr *skills.ValidationError
+		switch {
+		case errors.Is(err, skills.ErrExists):
+			http.Error(w, err.Error()+"; set replace to overwrite it", http.StatusConflict)
+		case errors.As(err, &verr), errors.Is(err, fs.ErrNotExist):
+			http.Error(w, fmt.Sprintf("Failed to install skill: %v", err), http.StatusBadRequest)
+		default:
+			http.Error(w, fmt.Sprintf("Failed to install skill: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+	go s.refreshSkills()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toSkillAPI(skills.Candidate{Skill: skill}, "user", userDir))
+}
+
+// refreshSkills rediscovers skills for every running conversation.
+func (s *Server) refreshSkills() {
+	s.mu.Lock()
+	managers := make([]*ConversationManager, 0, len(s.activeConversations))
+	for _, manager := range s.activeConversations {
+		managers = append(managers, manager)
+	}
+	s.mu.Unlock()
+
+	for _, manager := range managers {
+		manager.refreshSkills()
+	}
+}
+
+// skillWatchDirs returns the directories watched for skill changes: the user
+// skill directories and the .skills directories of running conversations.
+// Skills elsewhere in a project tree are picked up when a conversation starts.
+func (s *Server) skillWatchDirs() []string {
+	dirs := skills.DefaultDirs()
+	if userDir, err := skills.UserDir(); err == nil {
+		dirs = append(dirs, userDir)
+	}
+
+	s.mu.Lock()
+	cwds := make([]string, 0, len(s.activeConversations))
+	for _, manager := range s.activeConversations {
+		manager.mu.Lock()
+		cwds = append(cwds, manager.cwd)
+		manager.mu.Unlock()
+	}
+	s.mu.Unlock()
+
+	// Walking up to / rather than the git root may watch a few extra
+	// directories, but avoids running git on every poll.
+	for _, cwd := range cwds {
+		if cwd != "" {
+			dirs = append(dirs, skills.ProjectSkillsDirs(cwd, "")...)
+		}
+	}
+
+	seen := make(map[string]bool)
+	unique := dirs[:0]
+	for _, dir := range dirs {
+		if !seen[dir] {
+			seen[dir] = true
+			unique = append(unique, dir)
+		}
+	}
+	return unique
+}
+
+// watchSkillsRoutine polls the skill directories and refreshes running
+// conversations when skills are added, removed or edited.
+func (s *Server) watchSkillsRoutine() {
+	ticker := time.NewTicker(skillsPollInterval)
+	defer ticker.Stop()
+
+	last := skills.Fingerprint(s.skillWatchDirs())
+	for {
+		select {
+		case <-ticker.C:
+			fp := skills.Fingerprint(s.skillWatchDirs())
+			if fp != last {
+				last = fp
+				s.logger.Info("Skills changed, refreshing running conversations")
+				s.refreshSkills()
+			}
+		case <-s.shutdownCh:
+			return
+		}
+	}
+}
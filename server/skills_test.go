@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shelley.exe.dev/claudetool"
+	"shelley.exe.dev/skills"
+)
+
+func doSkillRequest(t *testing.T, handler http.HandlerFunc, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func TestSkillsAPI(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	server, _, _ := newTestServer(t)
+	userDir := filepath.Join(home, ".config", "shelley")
+
+	// Create
+	w := doSkillRequest(t, server.handleSkills, http.MethodPost, "/api/skills",
+		`{"name":"release","description":"Release checklist.","allowed_tools":"Bash(git:*)","body":"1. Tag."}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	var created SkillAPI
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Path != filepath.Join(userDir, "release", "SKILL.md") || !created.Editable || created.AllowedTools != "Bash(git:*)" {
+		t.Errorf("unexpected created skill: %+v", created)
+	}
+
+	w = doSkillRequest(t, server.handleSkills, http.MethodPost, "/api/skills", `{"name":"release","description":"Again."}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("duplicate create: %d", w.Code)
+	}
+	w = doSkillRequest(t, server.handleSkills, http.MethodPost, "/api/skills", `{"name":"Bad Name","description":"x"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid create: %d", w.Code)
+	}
+
+	// An invalid skill written by hand shows up with its error.
+	badDir := filepath.Join(userDir, "broken")
+	os.MkdirAll(badDir, 0o755)
+	os.WriteFile(filepath.Join(badDir, "SKILL.md"), []byte("---\nname: broken\n---\n"), 0o644)
+
+	// List
+	w = doSkillRequest(t, server.handleSkills, http.MethodGet, "/api/skills", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: %d %s", w.Code, w.Body.String())
+	}
+	var list SkillsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if list.UserDir != userDir || len(list.Skills) != 2 {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+	errs := map[string]string{}
+	for _, s := range list.Skills {
+		errs[s.Name] = s.Error
+	}
+	if errs["release"] != "" || !strings.Contains(errs["broken"], "name and description are required") {
+		t.Errorf("unexpected validation errors: %v", errs)
+	}
+
+	// Get includes the body
+	w = doSkillRequest(t, server.handleSkill, http.MethodGet, "/api/skills/release", "")
+	var got SkillAPI
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if w.Code != http.StatusOK || got.Body != "1. Tag." {
+		t.Errorf("get: %d %+v", w.Code, got)
+	}
+
+	// Update
+	w = doSkillRequest(t, server.handleSkill, http.MethodPut, "/api/skills/release",
+		`{"description":"Updated checklist.","body":"1. Tag.\n2. Publish."}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body.String())
+	}
+	content, _ := os.ReadFile(filepath.Join(userDir, "release", "SKILL.md"))
+	if !strings.Contains(string(content), "description: Updated checklist.") || !strings.Contains(string(content), "2. Publish.") {
+		t.Errorf("SKILL.md not updated:\n%s", content)
+	}
+	w = doSkillRequest(t, server.handleSkill, http.MethodPut, "/api/skills/missing", `{"description":"x"}`)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("update missing: %d", w.Code)
+	}
+
+	// Install from a directory
+	src := filepath.Join(t.TempDir(), "deploy")
+	os.MkdirAll(src, 0o755)
+	os.WriteFile(filepath.Join(src, "SKILL.md"), []byte("---\nname: deploy\ndescription: Deploy it.\n---\n"), 0o644)
+	w = doSkillRequest(t, server.handleSkill, http.MethodPost, "/api/skills/install", `{"source":"`+src+`"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("install: %d %s", w.Code, w.Body.String())
+	}
+	w = doSkillRequest(t, server.handleSkill, http.MethodPost, "/api/skills/install", `{"source":"`+src+`"}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("reinstall without replace: %d", w.Code)
+	}
+	w = doSkillRequest(t, server.handleSkill, http.MethodPost, "/api/skills/install", `{"source":"relative/path"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("relative install: %d", w.Code)
+	}
+
+	// Delete
+	w = doSkillRequest(t, server.handleSkill, http.MethodDelete, "/api/skills/deploy", "")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(userDir, "deploy")); !os.IsNotExist(err) {
+		t.Errorf("deploy skill still on disk: %v", err)
+	}
+	w = doSkillRequest(t, server.handleSkill, http.MethodDelete, "/api/skills/deploy", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("delete missing: %d", w.Code)
+	}
+}
+
+func TestRefreshSkillsUpdatesRunningConversations(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	server, _, _ := newTestServer(t)
+
+	cwd := t.TempDir()
+	toolSet := claudetool.NewToolSet(context.Background(), claudetool.ToolSetConfig{WorkingDir: cwd})
+	defer toolSet.Cleanup()
+	manager := &ConversationManager{cwd: cwd, toolSet: toolSet}
+	server.mu.Lock()
+	server.activeConversations["c1"] = manager
+	server.mu.Unlock()
+
+	hasSkillTool := func() bool {
+		for _, tool := range toolSet.Tools() {
+			if tool.Name == "activate_skill" {
+				return true
+			}
+		}
+		return false
+	}
+	if hasSkillTool() {
+		t.Fatal("activate_skill offered before any skill exists")
+	}
+
+	before := skills.Fingerprint(server.skillWatchDirs())
+	w := doSkillRequest(t, server.handleSkills, http.MethodPost, "/api/skills", `{"name":"notes","description":"Take notes."}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body.String())
+	}
+	if skills.Fingerprint(server.skillWatchDirs()) == before {
+		t.Error("watched fingerprint did not change after creating a skill")
+	}
+
+	server.refreshSkills()
+	if !hasSkillTool() {
+		t.Error("activate_skill not offered after refresh")
+	}
+}
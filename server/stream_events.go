@@ -6,26 +6,17 @@ import (
 	"time"
 
 	"shelley.exe.dev/db/generated"
+	"shelley.exe.dev/shelleyapi"
 )
 
-const streamEventVersionV1 = 1
+const streamEventVersionV1 = shelleyapi.StreamEventVersionV1
 
 const (
-	eventTypeHeartbeat           = "heartbeat"
-	eventTypeStreamTextDelta     = "stream.text.delta"
-	eventTypeStreamThinkingDelta = "stream.thinking.delta"
+	eventTypeHeartbeat           = shelleyapi.EventTypeHeartbeat
+	eventTypeStreamTextDelta     = shelleyapi.EventTypeStreamTextDelta
+	eventTypeStreamThinkingDelta = shelleyapi.EventTypeStreamThinkingDelta
 )
 
-type StreamEventEnvelopeV1 struct {
-	Version        int             `json:"version"`
-	EventID        int64           `json:"event_id,omitempty"`
-	ConversationID string          `json:"conversation_id"`
-	JobID          *string         `json:"job_id,omitempty"`
-	Type           string          `json:"type"`
-	CreatedAt      time.Time       `json:"created_at"`
-	Payload        json.RawMessage `json:"payload,omitempty"`
-}
-
 type streamJobPayload struct {
 	Job generated.JobRun `json:"job"`
 }
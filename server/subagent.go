@@ -11,6 +11,7 @@ import (
 	"shelley.exe.dev/claudetool"
 	"shelley.exe.dev/db/generated"
 	"shelley.exe.dev/llm"
+	"shelley.exe.dev/shelleyapi"
 )
 
 // SubagentRunner implements claudetool.SubagentRunner.
@@ -379,7 +380,7 @@ func (r *SubagentRunner) notifySubagentConversation(ctx context.Context, convers
 	// Publish the subagent conversation to all active streams
 	s.publishConversationListUpdate(ConversationListUpdate{
 		Type:         "update",
-		Conversation: &conv,
+		Conversation: (*shelleyapi.Conversation)(&conv),
 	})
 
 	s.logger.Debug("Notified UI about subagent conversation",
@@ -12,6 +12,7 @@ import (
 	"text/template"
 	"time"
 
+	"shelley.exe.dev/gitstate"
 	"shelley.exe.dev/skills"
 )
 
@@ -207,10 +208,7 @@ func collectSystemData(workingDir string) (*SystemPromptData, error) {
 
 func collectGitInfo(dir string) (*GitInfo, error) {
 	// Find git root
-	rootCmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	if dir != "" {
-		rootCmd.Dir = dir
-	}
+	rootCmd := gitstate.Command(dir, "rev-parse", "--show-toplevel")
 	rootOutput, err := rootCmd.Output()
 	if err != nil {
 		return nil, err
@@ -370,34 +368,9 @@ func isExeDev() bool {
 }
 
 // collectSkills discovers skills from default directories, project .skills dirs,
-// and the project tree.
+// and the project tree, and renders them for the system prompt.
 func collectSkills(workingDir, gitRoot string) string {
-	// Start with default directories (user-level skills)
-	dirs := skills.DefaultDirs()
-
-	// Add .skills directories found in the project tree
-	dirs = append(dirs, skills.ProjectSkillsDirs(workingDir, gitRoot)...)
-
-	// Discover skills from all directories
-	foundSkills := skills.Discover(dirs)
-
-	// Also discover skills anywhere in the project tree
-	treeSkills := skills.DiscoverInTree(workingDir, gitRoot)
-
-	// Merge, avoiding duplicates by path
-	seen := make(map[string]bool)
-	for _, s := range foundSkills {
-		seen[s.Path] = true
-	}
-	for _, s := range treeSkills {
-		if !seen[s.Path] {
-			foundSkills = append(foundSkills, s)
-			seen[s.Path] = true
-		}
-	}
-
-	// Generate XML
-	return skills.ToPromptXML(foundSkills)
+	return skills.ToPromptXML(skills.Collect(workingDir, gitRoot))
 }
 
 func isSudoAvailable() bool {
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans sends spans to an in-memory exporter for the rest of the test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return exporter
+}
+
+func TestTurnSpans(t *testing.T) {
+	exporter := recordSpans(t)
+	h := NewTestHarness(t)
+	h.NewConversation("bash: echo traced", t.TempDir())
+	h.WaitToolResult()
+	h.WaitResponse()
+
+	// The job's span ends when its last message is recorded.
+	var spans tracetest.SpanStubs
+	var job *tracetest.SpanStub
+	deadline := time.Now().Add(5 * time.Second)
+	for job == nil {
+		spans = exporter.GetSpans()
+		for i := range spans {
+			if spans[i].Name == "job turn" {
+				job = &spans[i]
+			}
+		}
+		if job == nil && time.Now().After(deadline) {
+			t.Fatalf("No job span among %d spans", len(spans))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	counts := map[string]int{}
+	for _, s := range spans {
+		if s.Name == "job turn" {
+			continue
+		}
+		counts[s.Name]++
+		if s.Parent.SpanID() != job.SpanContext.SpanID() {
+			t.Errorf("span %q is not a child of the job span", s.Name)
+		}
+	}
+	if counts["llm request"] != 2 || counts["tool bash"] != 1 {
+		t.Errorf("Unexpected spans %v", counts)
+	}
+}
+
+func TestSubagentJobSpanLinksParent(t *testing.T) {
+	exporter := recordSpans(t)
+	h := NewTestHarness(t)
+	ctx := t.Context()
+	jobs := h.server.jobs
+
+	parentConv, err := h.db.CreateConversation(ctx, nil, true, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	childConv, err := h.db.CreateConversation(ctx, nil, false, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := jobs.StartJob(ctx, StartJobParams{ConversationID: parentConv.ConversationID, Kind: JobKindTurn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := jobs.StartJob(ctx, StartJobParams{ConversationID: childConv.ConversationID, Kind: JobKindSubagent, ParentJobID: &parent.JobID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, jobID := range []string{child.JobID, parent.JobID} {
+		if _, err := jobs.FinishJob(ctx, FinishJobParams{JobID: jobID, Status: JobStatusSucceeded}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 || spans[0].Name != "job subagent" || spans[1].Name != "job turn" {
+		t.Fatalf("Unexpected spans %v", spans)
+	}
+	sub, turn := spans[0], spans[1]
+	if sub.Parent.SpanID() != turn.SpanContext.SpanID() {
+		t.Error("subagent job span is not a child of its parent job's span")
+	}
+	if len(sub.Links) != 1 || sub.Links[0].SpanContext.SpanID() != turn.SpanContext.SpanID() {
+		t.Errorf("subagent job span links %v, want the parent job", sub.Links)
+	}
+}
+
+func TestFailedJobSpanOmitsError(t *testing.T) {
+	exporter := recordSpans(t)
+	h := NewTestHarness(t)
+	ctx := t.Context()
+
+	conv, err := h.db.CreateConversation(ctx, nil, true, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := h.server.jobs.StartJob(ctx, StartJobParams{ConversationID: conv.ConversationID, Kind: JobKindTurn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = h.server.jobs.FinishJob(ctx, FinishJobParams{
+		JobID:        job.JobID,
+		Status:       JobStatusFailed,
+		ErrorPayload: map[string]string{"error": "cat: password=hunter2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != codes.Error || spans[0].Status.Description != "failed" {
+		t.Errorf("Unexpected spans %v", spans)
+	}
+}
@@ -55,6 +55,10 @@ func TestUploadEndpoint(t *testing.T) {
 		t.Fatal("response missing 'path' field")
 	}
 
+	if got := response["media_type"]; got != "image/png" {
+		t.Errorf("expected media_type image/png, got %q", got)
+	}
+
 	// Verify the path is in the screenshot directory
 	if !strings.HasPrefix(path, browse.ScreenshotDir) {
 		t.Errorf("expected path to start with %s, got %s", browse.ScreenshotDir, path)
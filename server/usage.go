@@ -0,0 +1,402 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"shelley.exe.dev/db/generated"
+	"shelley.exe.dev/gitstate"
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/shelleyapi"
+)
+
+// defaultUsagePeriod is how far back GET /api/usage looks without a since parameter.
+const defaultUsagePeriod = 7 * 24 * time.Hour
+
+var usageDimensions = []string{
+	shelleyapi.UsageByDay,
+	shelleyapi.UsageByModel,
+	shelleyapi.UsageByConversation,
+	shelleyapi.UsageByCwd,
+	shelleyapi.UsageByRepo,
+	shelleyapi.UsageByKind,
+	shelleyapi.UsageByTool,
+}
+
+// usageQuery is a parsed GET /api/usage request.
+type usageQuery struct {
+	since, until time.Time
+	groupBy      []string
+	csv          bool
+
+	model, cwd, repo, kind, conversationID string
+
+	// repos caches the repository root of each cwd.
+	repos map[string]string
+}
+
+// parseUsageTime parses a date or an RFC 3339 time. A date means the start
+// of that day in UTC, or with endOfDay, the end of it.
+func parseUsageTime(s string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func parseUsageQuery(values url.Values, now time.Time) (*usageQuery, error) {
+	q := &usageQuery{
+		until:          now,
+		model:          values.Get("model"),
+		cwd:            values.Get("cwd"),
+		repo:           values.Get("repo"),
+		kind:           values.Get("kind"),
+		conversationID: values.Get("conversation"),
+		repos:          make(map[string]string),
+	}
+	var err error
+	if s := values.Get("until"); s != "" {
+		if q.until, err = parseUsageTime(s, true); err != nil {
+			return nil, fmt.Errorf("invalid until: %q", s)
+		}
+	}
+	q.since = q.until.Add(-defaultUsagePeriod)
+	if s := values.Get("since"); s != "" {
+		if q.since, err = parseUsageTime(s, false); err != nil {
+			return nil, fmt.Errorf("invalid since: %q", s)
+		}
+	}
+	if !q.since.Before(q.until) {
+		return nil, fmt.Errorf("since must be before until")
+	}
+	q.since, q.until = q.since.UTC(), q.until.UTC()
+
+	for _, dim := range strings.Split(values.Get("group_by"), ",") {
+		dim = strings.TrimSpace(dim)
+		if dim == "" || slices.Contains(q.groupBy, dim) {
+			continue
+		}
+		if !slices.Contains(usageDimensions, dim) {
+			return nil, fmt.Errorf("invalid group_by %q (want %s)", dim, strings.Join(usageDimensions, ", "))
+		}
+		q.groupBy = append(q.groupBy, dim)
+	}
+
+	if q.kind != "" && q.kind != string(JobKindTurn) && q.kind != string(JobKindSubagent) {
+		return nil, fmt.Errorf("invalid kind %q (want turn or subagent)", q.kind)
+	}
+	switch format := values.Get("format"); format {
+	case "", "json":
+	case "csv":
+		q.csv = true
+	default:
+		return nil, fmt.Errorf("invalid format %q (want json or csv)", format)
+	}
+	return q, nil
+}
+
+func (q *usageQuery) groupedBy(dim string) bool {
+	return slices.Contains(q.groupBy, dim)
+}
+
+// repoRoot returns the repository root of cwd, or cwd itself outside a
+// repository or if it no longer exists.
+func (q *usageQuery) repoRoot(cwd string) string {
+	if cwd == "" {
+		return ""
+	}
+	root, ok := q.repos[cwd]
+	if !ok {
+		root = gitstate.RepoRoot(cwd)
+		if root == "" {
+			root = cwd
+		}
+		q.repos[cwd] = root
+	}
+	return root
+}
+
+// key returns the group that usage by the given conversation at the given
+// time falls in, or false if the query's filters exclude it. Subagent
+// conversations have a parent; all other usage counts as the agent's turns.
+func (q *usageQuery) key(conversationID string, parentID, cwd, model *string, at time.Time, tool string) (shelleyapi.UsageRow, bool) {
+	kind := string(JobKindTurn)
+	if parentID != nil {
+		kind = string(JobKindSubagent)
+	}
+	k := shelleyapi.UsageRow{
+		Day:            at.UTC().Format(time.DateOnly),
+		Model:          derefOr(model, ""),
+		ConversationID: conversationID,
+		Cwd:            derefOr(cwd, ""),
+		Kind:           kind,
+		Tool:           tool,
+	}
+	if (q.model != "" && k.Model != q.model) ||
+		(q.cwd != "" && k.Cwd != q.cwd) ||
+		(q.kind != "" && k.Kind != q.kind) ||
+		(q.conversationID != "" && k.ConversationID != q.conversationID) {
+		return k, false
+	}
+	if q.repo != "" || q.groupedBy(shelleyapi.UsageByRepo) {
+		k.Repo = q.repoRoot(k.Cwd)
+		if q.repo != "" && k.Repo != q.repo {
+			return k, false
+		}
+	}
+
+	for _, dim := range usageDimensions {
+		if q.groupedBy(dim) {
+			continue
+		}
+		switch dim {
+		case shelleyapi.UsageByDay:
+			k.Day = ""
+		case shelleyapi.UsageByModel:
+			k.Model = ""
+		case shelleyapi.UsageByConversation:
+			k.ConversationID = ""
+		case shelleyapi.UsageByCwd:
+			k.Cwd = ""
+		case shelleyapi.UsageByRepo:
+			k.Repo = ""
+		case shelleyapi.UsageByKind:
+			k.Kind = ""
+		case shelleyapi.UsageByTool:
+			k.Tool = ""
+		}
+	}
+	return k, true
+}
+
+// usageGroup accumulates one row of a rollup.
+type usageGroup struct {
+	row          shelleyapi.UsageRow
+	turnDuration time.Duration
+}
+
+func (g *usageGroup) finish() shelleyapi.UsageRow {
+	row := g.row
+	if input := row.InputTokens + row.CacheReadInputTokens + row.CacheCreationInputTokens; input > 0 {
+		row.CacheHitRatio = float64(row.CacheReadInputTokens) / float64(input)
+	}
+	if row.Turns > 0 {
+		row.AvgTurnLatencySeconds = g.turnDuration.Seconds() / float64(row.Turns)
+		row.AvgTurnCostUSD = row.CostUSD / float64(row.Turns)
+	}
+	return row
+}
+
+// usageRollup groups usage by the dimensions of a query. Usage cannot be
+// split by tool, so when grouped by tool, rows count only tool calls; the
+// total counts everything.
+type usageRollup struct {
+	q      *usageQuery
+	groups map[shelleyapi.UsageRow]*usageGroup
+	total  usageGroup
+}
+
+func (r *usageRollup) add(key shelleyapi.UsageRow, isToolCall bool, fn func(*usageGroup)) {
+	fn(&r.total)
+	if r.q.groupedBy(shelleyapi.UsageByTool) && !isToolCall {
+		return
+	}
+	g := r.groups[key]
+	if g == nil {
+		g = &usageGroup{row: key}
+		r.groups[key] = g
+	}
+	fn(g)
+}
+
+// rows returns the rollup's rows, by day if grouped by day and then by
+// cost and tool calls, largest first.
+func (r *usageRollup) rows() []shelleyapi.UsageRow {
+	rows := make([]shelleyapi.UsageRow, 0, len(r.groups))
+	for _, g := range r.groups {
+		rows = append(rows, g.finish())
+	}
+	slices.SortFunc(rows, func(a, b shelleyapi.UsageRow) int {
+		return cmp.Or(
+			cmp.Compare(a.Day, b.Day),
+			cmp.Compare(b.CostUSD, a.CostUSD),
+			cmp.Compare(b.ToolCalls, a.ToolCalls),
+			cmp.Compare(a.Model, b.Model),
+			cmp.Compare(a.Repo, b.Repo),
+			cmp.Compare(a.Cwd, b.Cwd),
+			cmp.Compare(a.ConversationID, b.ConversationID),
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(a.Tool, b.Tool),
+		)
+	})
+	return rows
+}
+
+// usage rolls up the token usage recorded on agent messages, the duration
+// of finished turn and subagent jobs, and the tool calls in agent messages.
+func (s *Server) usage(ctx context.Context, q *usageQuery) (*shelleyapi.UsageResponse, error) {
+	var (
+		messages  []generated.ListUsageMessagesRow
+		toolCalls []generated.ListUsageToolCallsRow
+		jobs      []generated.ListUsageJobRunsRow
+	)
+	err := s.db.Queries(ctx, func(gq *generated.Queries) error {
+		var err error
+		messages, err = gq.ListUsageMessages(ctx, generated.ListUsageMessagesParams{Since: q.since, Until: q.until})
+		if err != nil {
+			return err
+		}
+		toolCalls, err = gq.ListUsageToolCalls(ctx, generated.ListUsageToolCallsParams{Since: q.since, Until: q.until})
+		if err != nil {
+			return err
+		}
+		jobs, err = gq.ListUsageJobRuns(ctx, generated.ListUsageJobRunsParams{Since: q.since, Until: q.until})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rollup := &usageRollup{q: q, groups: make(map[shelleyapi.UsageRow]*usageGroup)}
+	for _, m := range messages {
+		var usage llm.Usage
+		if err := json.Unmarshal([]byte(*m.UsageData), &usage); err != nil || usage.IsZero() {
+			continue
+		}
+		key, ok := q.key(m.ConversationID, m.ParentConversationID, m.Cwd, m.Model, m.CreatedAt, "")
+		if !ok {
+			continue
+		}
+		rollup.add(key, false, func(g *usageGroup) {
+			g.row.Responses++
+			g.row.InputTokens += usage.InputTokens
+			g.row.CacheCreationInputTokens += usage.CacheCreationInputTokens
+			g.row.CacheReadInputTokens += usage.CacheReadInputTokens
+			g.row.OutputTokens += usage.OutputTokens
+			g.row.ReasoningTokens += usage.ReasoningTokens
+			g.row.CostUSD += usage.CostUSD
+		})
+	}
+	for _, c := range toolCalls {
+		key, ok := q.key(c.ConversationID, c.ParentConversationID, c.Cwd, c.Model, c.CreatedAt, c.ToolName)
+		if !ok {
+			continue
+		}
+		rollup.add(key, true, func(g *usageGroup) {
+			g.row.ToolCalls++
+		})
+	}
+	for _, j := range jobs {
+		key, ok := q.key(j.ConversationID, j.ParentConversationID, j.Cwd, j.Model, j.CreatedAt, "")
+		if !ok {
+			continue
+		}
+		rollup.add(key, false, func(g *usageGroup) {
+			g.row.Turns++
+			g.turnDuration += j.FinishedAt.Sub(*j.StartedAt)
+		})
+	}
+
+	return &shelleyapi.UsageResponse{
+		Since:   q.since,
+		Until:   q.until,
+		GroupBy: q.groupBy,
+		Rows:    rollup.rows(),
+		Total:   rollup.total.finish(),
+	}, nil
+}
+
+// handleUsage rolls up usage over a period. Query parameters:
+//
+//	since, until  a date (YYYY-MM-DD, UTC, inclusive) or RFC 3339 time;
+//	              the default is the last seven days
+//	group_by      comma-separated dimensions: day, model, conversation, cwd,
+//	              repo, kind, tool
+//	model, cwd, repo, kind, conversation
+//	              keep only matching usage
+//	format        json (default) or csv
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	q, err := parseUsageQuery(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	usage, err := s.usage(r.Context(), q)
+	if err != nil {
+		s.logger.Error("Failed to roll up usage", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if q.csv {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="shelley-usage.csv"`)
+		writeUsageCSV(w, usage)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+// writeUsageCSV writes a rollup's rows with a header, one column per
+// dimension grouped by and then one per measure.
+func writeUsageCSV(w http.ResponseWriter, usage *shelleyapi.UsageResponse) {
+	cw := csv.NewWriter(w)
+	header := append(slices.Clone(usage.GroupBy),
+		"responses", "input_tokens", "cache_creation_input_tokens", "cache_read_input_tokens",
+		"output_tokens", "reasoning_tokens", "cost_usd", "cache_hit_ratio",
+		"turns", "avg_turn_latency_seconds", "avg_turn_cost_usd", "tool_calls")
+	cw.Write(header)
+
+	formatUint := func(n uint64) string { return strconv.FormatUint(n, 10) }
+	formatFloat := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	for _, row := range usage.Rows {
+		var record []string
+		for _, dim := range usage.GroupBy {
+			switch dim {
+			case shelleyapi.UsageByDay:
+				record = append(record, row.Day)
+			case shelleyapi.UsageByModel:
+				record = append(record, row.Model)
+			case shelleyapi.UsageByConversation:
+				record = append(record, row.ConversationID)
+			case shelleyapi.UsageByCwd:
+				record = append(record, row.Cwd)
+			case shelleyapi.UsageByRepo:
+				record = append(record, row.Repo)
+			case shelleyapi.UsageByKind:
+				record = append(record, row.Kind)
+			case shelleyapi.UsageByTool:
+				record = append(record, row.Tool)
+			}
+		}
+		record = append(record,
+			strconv.FormatInt(row.Responses, 10),
+			formatUint(row.InputTokens),
+			formatUint(row.CacheCreationInputTokens),
+			formatUint(row.CacheReadInputTokens),
+			formatUint(row.OutputTokens),
+			formatUint(row.ReasoningTokens),
+			formatFloat(row.CostUSD),
+			formatFloat(row.CacheHitRatio),
+			strconv.FormatInt(row.Turns, 10),
+			formatFloat(row.AvgTurnLatencySeconds),
+			formatFloat(row.AvgTurnCostUSD),
+			strconv.FormatInt(row.ToolCalls, 10),
+		)
+		cw.Write(record)
+	}
+	cw.Flush()
+}
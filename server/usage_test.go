@@ -0,0 +1,183 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shelley.exe.dev/db"
+	"shelley.exe.dev/llm"
+	"shelley.exe.dev/shelleyapi"
+)
+
+// seedUsage records a turn in a conversation in a git repository and a run
+// of one of its subagents, each with an LLM response calling bash.
+func seedUsage(t *testing.T, h *TestHarness) (repo, cwd string) {
+	t.Helper()
+	ctx := t.Context()
+	repo = t.TempDir()
+	if out, err := exec.Command("git", "init", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	repo, err := filepath.EvalSymlinks(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd = filepath.Join(repo, "sub")
+	if err := os.Mkdir(cwd, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	model := "predictable"
+	parent, err := h.db.CreateConversation(ctx, nil, true, &cwd, &model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := h.db.CreateSubagentConversation(ctx, "helper", parent.ConversationID, &cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.db.UpdateConversationModel(ctx, child.ConversationID, "helper"); err != nil {
+		t.Fatal(err)
+	}
+
+	record := func(conversationID string, kind JobKind, usage llm.Usage) {
+		job, err := h.server.jobs.StartJob(ctx, StartJobParams{ConversationID: conversationID, Kind: kind})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = h.db.CreateMessage(ctx, db.CreateMessageParams{
+			ConversationID: conversationID,
+			Type:           db.MessageTypeAgent,
+			LLMData: llm.Message{Role: llm.MessageRoleAssistant, Content: []llm.Content{
+				llm.StringContent("running it"),
+				{Type: llm.ContentTypeToolUse, ID: "t1", ToolName: "bash", ToolInput: json.RawMessage(`{"command":"true"}`)},
+			}},
+			UsageData: usage,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := h.server.jobs.FinishJob(ctx, FinishJobParams{JobID: job.JobID, Status: JobStatusSucceeded}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record(parent.ConversationID, JobKindTurn, llm.Usage{InputTokens: 100, CacheReadInputTokens: 300, OutputTokens: 50, CostUSD: 0.5, Model: "predictable-v1"})
+	record(child.ConversationID, JobKindSubagent, llm.Usage{InputTokens: 200, OutputTokens: 20, CostUSD: 0.25, Model: "helper-v1"})
+	return repo, cwd
+}
+
+func getUsage(t *testing.T, h *TestHarness, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	h.server.RegisterRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/usage"+query, nil))
+	return rec
+}
+
+func decodeUsage(t *testing.T, h *TestHarness, query string) shelleyapi.UsageResponse {
+	t.Helper()
+	rec := getUsage(t, h, query)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/usage%s: %d %s", query, rec.Code, rec.Body.String())
+	}
+	var usage shelleyapi.UsageResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &usage); err != nil {
+		t.Fatal(err)
+	}
+	return usage
+}
+
+func TestUsage(t *testing.T) {
+	h := NewTestHarness(t)
+	repo, cwd := seedUsage(t, h)
+
+	usage := decodeUsage(t, h, "?group_by=kind")
+	if len(usage.Rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %+v", usage.Rows)
+	}
+	turn, sub := usage.Rows[0], usage.Rows[1]
+	if turn.Kind != "turn" || turn.CostUSD != 0.5 || turn.Responses != 1 || turn.Turns != 1 || turn.ToolCalls != 1 {
+		t.Errorf("Unexpected turn row %+v", turn)
+	}
+	if turn.CacheHitRatio != 0.75 || turn.AvgTurnCostUSD != 0.5 {
+		t.Errorf("turn cache hit ratio %v, average cost %v", turn.CacheHitRatio, turn.AvgTurnCostUSD)
+	}
+	if sub.Kind != "subagent" || sub.CostUSD != 0.25 || sub.InputTokens != 200 || sub.Turns != 1 {
+		t.Errorf("Unexpected subagent row %+v", sub)
+	}
+	if usage.Total.CostUSD != 0.75 || usage.Total.Turns != 2 || usage.Total.ToolCalls != 2 {
+		t.Errorf("Unexpected total %+v", usage.Total)
+	}
+
+	usage = decodeUsage(t, h, "?group_by=model,repo&repo="+repo)
+	if len(usage.Rows) != 2 || usage.Rows[1].Model != "helper" || usage.Rows[1].Turns != 1 ||
+		usage.Rows[1].Repo != repo || usage.Rows[1].Cwd != "" {
+		t.Errorf("Unexpected rows %+v", usage.Rows)
+	}
+	usage = decodeUsage(t, h, "?model=helper&kind=subagent")
+	if len(usage.Rows) != 1 || usage.Rows[0].CostUSD != 0.25 || usage.Rows[0].Turns != 1 {
+		t.Errorf("Unexpected rows %+v", usage.Rows)
+	}
+
+	usage = decodeUsage(t, h, "?group_by=tool&cwd="+cwd)
+	if len(usage.Rows) != 1 || usage.Rows[0].Tool != "bash" || usage.Rows[0].ToolCalls != 2 || usage.Rows[0].CostUSD != 0 {
+		t.Errorf("Unexpected tool rows %+v", usage.Rows)
+	}
+	if usage.Total.CostUSD != 0.75 {
+		t.Errorf("Tool rollup total %+v", usage.Total)
+	}
+
+	usage = decodeUsage(t, h, "?until=2000-01-01")
+	if len(usage.Rows) != 0 || usage.Total.Responses != 0 {
+		t.Errorf("Expected no usage before 2000, got %+v", usage.Rows)
+	}
+}
+
+func TestUsageCSV(t *testing.T) {
+	h := NewTestHarness(t)
+	seedUsage(t, h)
+
+	rec := getUsage(t, h, "?group_by=day,kind&format=csv")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Unexpected Content-Type %q", ct)
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %q", records)
+	}
+	if records[0][0] != "day" || records[0][1] != "kind" || records[0][8] != "cost_usd" {
+		t.Errorf("Unexpected header %q", records[0])
+	}
+	if records[1][1] != "turn" || records[1][8] != "0.5" {
+		t.Errorf("Unexpected row %q", records[1])
+	}
+}
+
+func TestUsageBadRequest(t *testing.T) {
+	h := NewTestHarness(t)
+	for _, query := range []string{
+		"?group_by=weather",
+		"?since=yesterday",
+		"?since=2026-02-01&until=2026-01-01",
+		"?kind=distill",
+		"?format=xml",
+	} {
+		if rec := getUsage(t, h, query); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /api/usage%s: got %d, want 400", query, rec.Code)
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/fynelabs/selfupdate"
 
+	"shelley.exe.dev/shelleyapi"
 	"shelley.exe.dev/version"
 )
 
@@ -35,32 +36,11 @@ type VersionChecker struct {
 	branch      string
 }
 
-// VersionInfo contains version check results.
+// VersionInfo contains version check results and the release they
+// describe.
 type VersionInfo struct {
-	CurrentVersion      string       `json:"current_version"`
-	CurrentTag          string       `json:"current_tag,omitempty"`
-	CurrentCommit       string       `json:"current_commit,omitempty"`
-	CurrentCommitTime   string       `json:"current_commit_time,omitempty"`
-	LatestVersion       string       `json:"latest_version,omitempty"`
-	LatestTag           string       `json:"latest_tag,omitempty"`
-	PublishedAt         time.Time    `json:"published_at,omitempty"`
-	HasUpdate           bool         `json:"has_update"`    // True if minor version is newer (for showing upgrade button)
-	ShouldNotify        bool         `json:"should_notify"` // True if should show red dot (newer + 5 days old)
-	DownloadURL         string       `json:"download_url,omitempty"`
-	ExecutablePath      string       `json:"executable_path,omitempty"`
-	Commits             []CommitInfo `json:"commits,omitempty"`
-	CheckedAt           time.Time    `json:"checked_at"`
-	Error               string       `json:"error,omitempty"`
-	RunningUnderSystemd bool         `json:"running_under_systemd"` // True if INVOCATION_ID env var is set (systemd)
-	ReleaseInfo         *ReleaseInfo `json:"-"`                     // Internal, not exposed to JSON
-}
-
-// CommitInfo represents a commit in the changelog.
-type CommitInfo struct {
-	SHA     string    `json:"sha"`
-	Message string    `json:"message"`
-	Author  string    `json:"author"`
-	Date    time.Time `json:"date"`
+	shelleyapi.VersionInfo
+	ReleaseInfo *ReleaseInfo `json:"-"`
 }
 
 // ReleaseInfo represents release metadata.
@@ -128,14 +108,14 @@ func (vc *VersionChecker) staticMetadataURL() string {
 func (vc *VersionChecker) Check(ctx context.Context, forceRefresh bool) (*VersionInfo, error) {
 	if vc.skipCheck {
 		info := version.GetInfo()
-		return &VersionInfo{
+		return &VersionInfo{VersionInfo: shelleyapi.VersionInfo{
 			CurrentVersion:      info.Version,
 			CurrentTag:          info.Tag,
 			CurrentCommit:       info.Commit,
 			HasUpdate:           false,
 			CheckedAt:           time.Now(),
 			RunningUnderSystemd: os.Getenv("INVOCATION_ID") != "",
-		}, nil
+		}}, nil
 	}
 
 	vc.mu.Lock()
@@ -150,7 +130,7 @@ func (vc *VersionChecker) Check(ctx context.Context, forceRefresh bool) (*Versio
 	if err != nil {
 		// On error, return current version info with error
 		currentInfo := version.GetInfo()
-		return &VersionInfo{
+		return &VersionInfo{VersionInfo: shelleyapi.VersionInfo{
 			CurrentVersion:      currentInfo.Version,
 			CurrentTag:          currentInfo.Tag,
 			CurrentCommit:       currentInfo.Commit,
@@ -158,7 +138,7 @@ func (vc *VersionChecker) Check(ctx context.Context, forceRefresh bool) (*Versio
 			CheckedAt:           time.Now(),
 			Error:               err.Error(),
 			RunningUnderSystemd: os.Getenv("INVOCATION_ID") != "",
-		}, nil
+		}}, nil
 	}
 
 	vc.cachedInfo = info
@@ -170,7 +150,7 @@ func (vc *VersionChecker) Check(ctx context.Context, forceRefresh bool) (*Versio
 func (vc *VersionChecker) fetchVersionInfo(ctx context.Context) (*VersionInfo, error) {
 	currentInfo := version.GetInfo()
 	execPath, _ := os.Executable()
-	info := &VersionInfo{
+	info := &VersionInfo{VersionInfo: shelleyapi.VersionInfo{
 		CurrentVersion:      currentInfo.Version,
 		CurrentTag:          currentInfo.Tag,
 		CurrentCommit:       currentInfo.Commit,
@@ -178,7 +158,7 @@ func (vc *VersionChecker) fetchVersionInfo(ctx context.Context) (*VersionInfo, e
 		ExecutablePath:      execPath,
 		CheckedAt:           time.Now(),
 		RunningUnderSystemd: os.Getenv("INVOCATION_ID") != "",
-	}
+	}}
 
 	// Fetch latest release from static metadata
 	latestRelease, err := vc.fetchLatestRelease(ctx)
@@ -0,0 +1,121 @@
+package shelleyapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// AuthStatus reports whether built-in authentication is on and, if it is,
+// how the client is authenticated.
+func (c *Client) AuthStatus(ctx context.Context) (*AuthStatus, error) {
+	var status AuthStatus
+	if err := c.do(ctx, http.MethodGet, "/api/auth/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// APITokens lists the API tokens. Their secrets are never returned.
+func (c *Client) APITokens(ctx context.Context) ([]APITokenAPI, error) {
+	var tokens []APITokenAPI
+	if err := c.do(ctx, http.MethodGet, "/api/auth/tokens", nil, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CreateAPIToken creates an API token. The returned token's Token is its
+// secret, which cannot be retrieved again.
+func (c *Client) CreateAPIToken(ctx context.Context, req CreateAPITokenRequest) (*APITokenAPI, error) {
+	var token APITokenAPI
+	if err := c.do(ctx, http.MethodPost, "/api/auth/tokens", req, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeAPIToken deletes an API token.
+func (c *Client) RevokeAPIToken(ctx context.Context, tokenID string) error {
+	return c.do(ctx, http.MethodDelete, "/api/auth/tokens/"+url.PathEscape(tokenID), nil, nil)
+}
+
+// CodexAuthStatus reports whether the server is signed in to Codex.
+func (c *Client) CodexAuthStatus(ctx context.Context) (*CodexAuthStatusResponse, error) {
+	var status CodexAuthStatusResponse
+	if err := c.do(ctx, http.MethodGet, "/api/codex-auth/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// StartCodexAuth begins signing in to Codex. Open AuthURL in a browser and
+// pass the URL it redirects to to CompleteCodexAuth.
+func (c *Client) StartCodexAuth(ctx context.Context) (*CodexPkceStartResponse, error) {
+	var resp CodexPkceStartResponse
+	if err := c.do(ctx, http.MethodPost, "/api/codex-auth/pkce/start", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CompleteCodexAuth finishes signing in to Codex.
+func (c *Client) CompleteCodexAuth(ctx context.Context, req CodexPkceCompleteRequest) (*CodexAuthStatusResponse, error) {
+	var status CodexAuthStatusResponse
+	if err := c.do(ctx, http.MethodPost, "/api/codex-auth/pkce/complete", req, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// CodexLogout signs the server out of Codex.
+func (c *Client) CodexLogout(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/api/codex-auth/logout", nil, nil)
+}
+
+// CheckVersion reports whether a newer release is available. The result
+// is cached by the server unless refresh is set.
+func (c *Client) CheckVersion(ctx context.Context, refresh bool) (*VersionInfo, error) {
+	path := "/version-check"
+	if refresh {
+		path += query("refresh", "true")
+	}
+	var info VersionInfo
+	if err := c.do(ctx, http.MethodGet, path, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Changelog lists the commits between two release tags.
+func (c *Client) Changelog(ctx context.Context, currentTag, latestTag string) ([]CommitInfo, error) {
+	var commits []CommitInfo
+	if err := c.do(ctx, http.MethodGet, "/version-changelog"+query("current", currentTag, "latest", latestTag), nil, &commits); err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// Upgrade replaces the server's binary with the latest release. With
+// restart, the server then exits, expecting to be restarted by systemd or
+// similar.
+func (c *Client) Upgrade(ctx context.Context, restart bool) (*StatusResponse, error) {
+	path := "/upgrade"
+	if restart {
+		path += query("restart", "true")
+	}
+	var resp StatusResponse
+	if err := c.do(ctx, http.MethodPost, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Exit stops the server, expecting systemd or similar to restart it.
+func (c *Client) Exit(ctx context.Context) (*StatusResponse, error) {
+	var resp StatusResponse
+	if err := c.do(ctx, http.MethodPost, "/exit", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,232 @@
+// Package shelleyapi is a Go client for the Shelley HTTP API.
+//
+// Its request and response types are the ones the server encodes, so
+// programs using it see exactly what the web UI sees. A Client talks to a
+// server over its Unix socket or over HTTP(S):
+//
+//	c, err := shelleyapi.New("unix://" + shelleyapi.DefaultSocketPath())
+//	resp, err := c.NewConversation(ctx, shelleyapi.ChatRequest{Message: "hello"})
+//	stream := c.Stream(ctx, resp.ConversationID, 0)
+//	defer stream.Close()
+//	for {
+//		event, err := stream.Next()
+//		...
+//	}
+//
+// Routes meant for browsers (login and logout, the terminal websocket,
+// /debug) are not wrapped, nor is /metrics, which is for Prometheus. Neither
+// are /notifications/reply and /notifications/email-reply: chat services
+// and mail relays call them with a notification's reply token, not with
+// the credentials a Client holds.
+package shelleyapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSocketPath returns the default Unix socket path (~/.config/shelley/shelley.sock).
+func DefaultSocketPath() string {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "/tmp"
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "shelley", "shelley.sock")
+}
+
+// Client calls the API of one Shelley server. It is safe for concurrent use
+// once configured.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	header     http.Header
+}
+
+// New returns a client for the server at serverURL, which is either
+// unix:///path/to/socket or an http:// or https:// URL.
+func New(serverURL string) (*Client, error) {
+	c := &Client{header: make(http.Header)}
+	switch {
+	case strings.HasPrefix(serverURL, "unix://"):
+		sockPath := strings.TrimPrefix(serverURL, "unix://")
+		if sockPath == "" {
+			return nil, fmt.Errorf("unix:// URL must include a socket path")
+		}
+		c.httpClient = &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", sockPath)
+			},
+		}}
+		c.baseURL = "http://localhost"
+	case strings.HasPrefix(serverURL, "http://"), strings.HasPrefix(serverURL, "https://"):
+		c.httpClient = &http.Client{}
+		c.baseURL = strings.TrimSuffix(serverURL, "/")
+	default:
+		return nil, fmt.Errorf("unsupported URL scheme: %s (use unix://, http://, or https://)", serverURL)
+	}
+	return c, nil
+}
+
+// NewWithHTTPClient returns a client that sends requests for the server at
+// baseURL through httpClient, for transports New does not support.
+func NewWithHTTPClient(baseURL string, httpClient *http.Client) *Client {
+	return &Client{httpClient: httpClient, baseURL: strings.TrimSuffix(baseURL, "/"), header: make(http.Header)}
+}
+
+// SetHeader sets a header sent with every request.
+func (c *Client) SetHeader(name, value string) {
+	c.header.Set(name, value)
+}
+
+// SetToken authenticates requests with an API token, for servers with
+// authentication enabled. The Unix socket needs none.
+func (c *Client) SetToken(token string) {
+	c.SetHeader("Authorization", "Bearer "+token)
+}
+
+// Error is a non-success response from the server.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the server.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// errorMessage extracts the message from an error body, which is either
+// plain text from http.Error or a JSON object with an "error" field.
+func errorMessage(body []byte) string {
+	var obj struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &obj) == nil && obj.Error != "" {
+		return obj.Error
+	}
+	return strings.TrimSpace(string(body))
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range c.header {
+		req.Header[name] = values
+	}
+	if method != http.MethodGet {
+		req.Header.Set("X-Shelley-Request", "1")
+	}
+	return req, nil
+}
+
+// send sends a request and returns the response if it succeeded.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return nil, &Error{StatusCode: resp.StatusCode, Message: errorMessage(body)}
+	}
+	return resp, nil
+}
+
+// do sends in, if non-nil, as the JSON request body and decodes the JSON
+// response into out, if non-nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: decoding response: %w", method, path, err)
+	}
+	return nil
+}
+
+// Version returns the server's build information.
+func (c *Client) Version(ctx context.Context) (*BuildInfo, error) {
+	var info BuildInfo
+	if err := c.do(ctx, http.MethodGet, "/version", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Settings returns the server's settings.
+func (c *Client) Settings(ctx context.Context) (map[string]string, error) {
+	var settings map[string]string
+	if err := c.do(ctx, http.MethodGet, "/settings", nil, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetSetting changes one of the server's settings.
+func (c *Client) SetSetting(ctx context.Context, key, value string) error {
+	req := struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}{key, value}
+	return c.do(ctx, http.MethodPost, "/settings", req, nil)
+}
+
+// query encodes non-empty parameters as a query string.
+func query(params ...string) string {
+	v := url.Values{}
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i+1] != "" {
+			v.Set(params[i], params[i+1])
+		}
+	}
+	if len(v) == 0 {
+		return ""
+	}
+	return "?" + v.Encode()
+}
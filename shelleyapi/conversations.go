@@ -0,0 +1,197 @@
+package shelleyapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListOptions selects conversations to list.
+type ListOptions struct {
+	// Limit is the maximum number to return; the server default is 5000.
+	Limit  int
+	Offset int
+	// Query filters by slug.
+	Query string
+	// SearchContent also matches Query against message text. It does not
+	// apply to archived conversations.
+	SearchContent bool
+}
+
+func (o ListOptions) query() string {
+	var limit, offset, content string
+	if o.Limit > 0 {
+		limit = strconv.Itoa(o.Limit)
+	}
+	if o.Offset > 0 {
+		offset = strconv.Itoa(o.Offset)
+	}
+	if o.SearchContent {
+		content = "true"
+	}
+	return query("limit", limit, "offset", offset, "q", o.Query, "search_content", content)
+}
+
+func conversationPath(conversationID, suffix string) string {
+	return "/api/conversation/" + url.PathEscape(conversationID) + suffix
+}
+
+// ListConversations lists active conversations, most recently updated first.
+func (c *Client) ListConversations(ctx context.Context, opts ListOptions) ([]ConversationWithState, error) {
+	var conversations []ConversationWithState
+	if err := c.do(ctx, http.MethodGet, "/api/conversations"+opts.query(), nil, &conversations); err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// ListArchivedConversations lists archived conversations.
+func (c *Client) ListArchivedConversations(ctx context.Context, opts ListOptions) ([]Conversation, error) {
+	var conversations []Conversation
+	if err := c.do(ctx, http.MethodGet, "/api/conversations/archived"+opts.query(), nil, &conversations); err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// GetConversation returns a conversation and all of its messages.
+func (c *Client) GetConversation(ctx context.Context, conversationID string) (*StreamResponse, error) {
+	var resp StreamResponse
+	if err := c.do(ctx, http.MethodGet, conversationPath(conversationID, ""), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetConversationBySlug looks up a conversation by its slug.
+func (c *Client) GetConversationBySlug(ctx context.Context, slug string) (*Conversation, error) {
+	var conversation Conversation
+	if err := c.do(ctx, http.MethodGet, "/api/conversation-by-slug/"+url.PathEscape(slug), nil, &conversation); err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// NewConversation starts a conversation with req's message. The agent works
+// in the background; use Stream to follow it.
+func (c *Client) NewConversation(ctx context.Context, req ChatRequest) (*NewConversationResponse, error) {
+	var resp NewConversationResponse
+	if err := c.do(ctx, http.MethodPost, "/api/conversations/new", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Chat sends a message to an existing conversation. The agent works in the
+// background; use Stream to follow it.
+func (c *Client) Chat(ctx context.Context, conversationID string, req ChatRequest) error {
+	return c.do(ctx, http.MethodPost, conversationPath(conversationID, "/chat"), req, nil)
+}
+
+// CancelConversation stops the agent's current turn.
+func (c *Client) CancelConversation(ctx context.Context, conversationID string) error {
+	return c.do(ctx, http.MethodPost, conversationPath(conversationID, "/cancel"), nil, nil)
+}
+
+// ArchiveConversation archives a conversation.
+func (c *Client) ArchiveConversation(ctx context.Context, conversationID string) (*Conversation, error) {
+	var conversation Conversation
+	if err := c.do(ctx, http.MethodPost, conversationPath(conversationID, "/archive"), nil, &conversation); err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// UnarchiveConversation restores an archived conversation.
+func (c *Client) UnarchiveConversation(ctx context.Context, conversationID string) (*Conversation, error) {
+	var conversation Conversation
+	if err := c.do(ctx, http.MethodPost, conversationPath(conversationID, "/unarchive"), nil, &conversation); err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// DeleteConversation deletes a conversation and its messages.
+func (c *Client) DeleteConversation(ctx context.Context, conversationID string) error {
+	return c.do(ctx, http.MethodPost, conversationPath(conversationID, "/delete"), nil, nil)
+}
+
+// RenameConversation changes a conversation's slug. The server sanitizes it
+// the same way as generated slugs.
+func (c *Client) RenameConversation(ctx context.Context, conversationID, slug string) (*Conversation, error) {
+	var conversation Conversation
+	if err := c.do(ctx, http.MethodPost, conversationPath(conversationID, "/rename"), RenameRequest{Slug: slug}, &conversation); err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// ForkConversation copies a conversation into a new one that can be
+// continued independently. A SequenceID in req stops the copy at that
+// message.
+func (c *Client) ForkConversation(ctx context.Context, conversationID string, req ForkConversationRequest) (*Conversation, error) {
+	var conversation Conversation
+	if err := c.do(ctx, http.MethodPost, conversationPath(conversationID, "/fork"), req, &conversation); err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// DistillConversation starts a new conversation from a summary of an
+// existing one. The summary is written in the background.
+func (c *Client) DistillConversation(ctx context.Context, req DistillConversationRequest) (*NewConversationResponse, error) {
+	var resp NewConversationResponse
+	if err := c.do(ctx, http.MethodPost, "/api/conversations/distill", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ConversationSandbox returns the sandbox confining a conversation's bash
+// commands.
+func (c *Client) ConversationSandbox(ctx context.Context, conversationID string) (*SandboxSettings, error) {
+	var settings SandboxSettings
+	if err := c.do(ctx, http.MethodGet, conversationPath(conversationID, "/sandbox"), nil, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SetConversationSandbox changes the sandbox confining a conversation's bash
+// commands. It needs an admin token on servers with authentication enabled.
+func (c *Client) SetConversationSandbox(ctx context.Context, conversationID string, settings SandboxSettings) (*SandboxSettings, error) {
+	var stored SandboxSettings
+	if err := c.do(ctx, http.MethodPost, conversationPath(conversationID, "/sandbox"), settings, &stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// Subagents lists the subagent conversations started by a conversation.
+func (c *Client) Subagents(ctx context.Context, conversationID string) ([]Conversation, error) {
+	var conversations []Conversation
+	if err := c.do(ctx, http.MethodGet, conversationPath(conversationID, "/subagents"), nil, &conversations); err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// ConversationJobs lists a conversation's most recent jobs, such as agent
+// turns and distillations.
+func (c *Client) ConversationJobs(ctx context.Context, conversationID string) ([]JobRun, error) {
+	var jobs []JobRun
+	if err := c.do(ctx, http.MethodGet, conversationPath(conversationID, "/jobs"), nil, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// GetJob returns a job.
+func (c *Client) GetJob(ctx context.Context, jobID string) (*JobRun, error) {
+	var job JobRun
+	if err := c.do(ctx, http.MethodGet, "/api/jobs/"+url.PathEscape(jobID), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,29 @@
+package shelleyapi
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestDependencies checks that the package stays usable without the server:
+// it may import only the llm package from this module.
+func TestDependencies(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	out, err := exec.Command("go", "list", "-deps", ".").Output()
+	if err != nil {
+		t.Fatalf("go list: %v", err)
+	}
+	allowed := map[string]bool{
+		"shelley.exe.dev/shelleyapi":  true,
+		"shelley.exe.dev/llm":         true,
+		"shelley.exe.dev/llm/docutil": true,
+	}
+	for _, pkg := range strings.Fields(string(out)) {
+		if strings.HasPrefix(pkg, "shelley.exe.dev/") && !allowed[pkg] {
+			t.Errorf("shelleyapi depends on %s", pkg)
+		}
+	}
+}
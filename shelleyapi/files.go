@@ -0,0 +1,174 @@
+package shelleyapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitDiffs lists the working changes and recent commits of the repository
+// containing cwd.
+func (c *Client) GitDiffs(ctx context.Context, cwd string) (*GitDiffsResponse, error) {
+	var resp GitDiffsResponse
+	if err := c.do(ctx, http.MethodGet, "/api/git/diffs"+query("cwd", cwd), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GitDiffFiles lists the files changed by a diff from GitDiffs.
+func (c *Client) GitDiffFiles(ctx context.Context, cwd, diffID string) ([]GitFileInfo, error) {
+	var files []GitFileInfo
+	path := "/api/git/diffs/" + url.PathEscape(diffID) + "/files" + query("cwd", cwd)
+	if err := c.do(ctx, http.MethodGet, path, nil, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// GitFileDiff returns the old and new contents of a file changed by a diff.
+func (c *Client) GitFileDiff(ctx context.Context, cwd, diffID, filePath string) (*GitFileDiff, error) {
+	segments := strings.Split(filePath, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	var diff GitFileDiff
+	path := "/api/git/file-diff/" + url.PathEscape(diffID) + "/" + strings.Join(segments, "/") + query("cwd", cwd)
+	if err := c.do(ctx, http.MethodGet, path, nil, &diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// CreateGitWorktree creates a worktree on a new branch next to the
+// repository containing cwd and returns its path.
+func (c *Client) CreateGitWorktree(ctx context.Context, cwd string) (string, error) {
+	req := struct {
+		Cwd string `json:"cwd"`
+	}{cwd}
+	var resp struct {
+		Path string `json:"path"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/git/create-worktree", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.Path, nil
+}
+
+// The directory endpoints report failures as {"error": ...} with status 200.
+type errorField struct {
+	Error string `json:"error"`
+}
+
+func (e errorField) err() error {
+	if e.Error == "" {
+		return nil
+	}
+	return &Error{StatusCode: http.StatusOK, Message: e.Error}
+}
+
+// ValidateCwd checks that path is a directory on the server's machine that
+// can be used as a conversation's working directory.
+func (c *Client) ValidateCwd(ctx context.Context, path string) error {
+	var resp errorField
+	if err := c.do(ctx, http.MethodGet, "/api/validate-cwd"+query("path", path), nil, &resp); err != nil {
+		return err
+	}
+	return resp.err()
+}
+
+// ListDirectory lists a directory on the server's machine; an empty path
+// lists the home directory.
+func (c *Client) ListDirectory(ctx context.Context, path string) (*ListDirectoryResponse, error) {
+	var resp struct {
+		ListDirectoryResponse
+		errorField
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/list-directory"+query("path", path), nil, &resp); err != nil {
+		return nil, err
+	}
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+	return &resp.ListDirectoryResponse, nil
+}
+
+// CreateDirectory creates a directory, whose parent must exist, on the
+// server's machine and returns its cleaned path.
+func (c *Client) CreateDirectory(ctx context.Context, path string) (string, error) {
+	req := struct {
+		Path string `json:"path"`
+	}{path}
+	var resp struct {
+		Path string `json:"path"`
+		errorField
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/create-directory", req, &resp); err != nil {
+		return "", err
+	}
+	if err := resp.err(); err != nil {
+		return "", err
+	}
+	return resp.Path, nil
+}
+
+// Upload saves a file, such as an image to attach to a message, on the
+// server's machine.
+func (c *Client) Upload(ctx context.Context, filename string, r io.Reader) (*UploadResponse, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return nil, err
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/upload", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var upload UploadResponse
+	if err := json.NewDecoder(resp.Body).Decode(&upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// ReadFile opens a screenshot, upload or other file the server serves to the
+// UI. The caller must close it.
+func (c *Client) ReadFile(ctx context.Context, path string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/read"+query("path", path), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// WriteFile replaces the contents of a file inside a git repository on the
+// server's machine.
+func (c *Client) WriteFile(ctx context.Context, path, content string) error {
+	req := struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	}{path, content}
+	return c.do(ctx, http.MethodPost, "/api/write-file", req, nil)
+}
@@ -0,0 +1,137 @@
+package shelleyapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Models lists the models conversations can use.
+func (c *Client) Models(ctx context.Context) ([]ModelInfo, error) {
+	var models []ModelInfo
+	if err := c.do(ctx, http.MethodGet, "/api/models", nil, &models); err != nil {
+		return nil, err
+	}
+	return models, nil
+}
+
+func customModelPath(modelID, suffix string) string {
+	return "/api/custom-models/" + url.PathEscape(modelID) + suffix
+}
+
+// CustomModels lists the models configured through the API. API keys are
+// never returned.
+func (c *Client) CustomModels(ctx context.Context) ([]ModelAPI, error) {
+	var models []ModelAPI
+	if err := c.do(ctx, http.MethodGet, "/api/custom-models", nil, &models); err != nil {
+		return nil, err
+	}
+	return models, nil
+}
+
+// GetCustomModel returns a custom model.
+func (c *Client) GetCustomModel(ctx context.Context, modelID string) (*ModelAPI, error) {
+	var model ModelAPI
+	if err := c.do(ctx, http.MethodGet, customModelPath(modelID, ""), nil, &model); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// CreateCustomModel adds a model.
+func (c *Client) CreateCustomModel(ctx context.Context, req CreateModelRequest) (*ModelAPI, error) {
+	var model ModelAPI
+	if err := c.do(ctx, http.MethodPost, "/api/custom-models", req, &model); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// UpdateCustomModel changes a model. An empty APIKey keeps the stored one.
+func (c *Client) UpdateCustomModel(ctx context.Context, modelID string, req UpdateModelRequest) (*ModelAPI, error) {
+	var model ModelAPI
+	if err := c.do(ctx, http.MethodPut, customModelPath(modelID, ""), req, &model); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// DuplicateCustomModel copies a model, including its API key.
+func (c *Client) DuplicateCustomModel(ctx context.Context, modelID string, req DuplicateModelRequest) (*ModelAPI, error) {
+	var model ModelAPI
+	if err := c.do(ctx, http.MethodPost, customModelPath(modelID, "/duplicate"), req, &model); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// DeleteCustomModel deletes a model.
+func (c *Client) DeleteCustomModel(ctx context.Context, modelID string) error {
+	return c.do(ctx, http.MethodDelete, customModelPath(modelID, ""), nil, nil)
+}
+
+// TestCustomModel sends a short request to a model to check its settings.
+// A model that fails the test is reported in the response, not as an error.
+func (c *Client) TestCustomModel(ctx context.Context, req TestModelRequest) (*TestModelResponse, error) {
+	var resp TestModelResponse
+	if err := c.do(ctx, http.MethodPost, "/api/custom-models-test", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func skillPath(name string) string {
+	return "/api/skills/" + url.PathEscape(name)
+}
+
+// Skills lists the user's skills and, if cwd is set, the project skills
+// found from it.
+func (c *Client) Skills(ctx context.Context, cwd string) (*SkillsResponse, error) {
+	var resp SkillsResponse
+	if err := c.do(ctx, http.MethodGet, "/api/skills"+query("cwd", cwd), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetSkill returns a user skill, including its body.
+func (c *Client) GetSkill(ctx context.Context, name string) (*SkillAPI, error) {
+	var skill SkillAPI
+	if err := c.do(ctx, http.MethodGet, skillPath(name), nil, &skill); err != nil {
+		return nil, err
+	}
+	return &skill, nil
+}
+
+// CreateSkill writes a new user skill.
+func (c *Client) CreateSkill(ctx context.Context, req SkillRequest) (*SkillAPI, error) {
+	var skill SkillAPI
+	if err := c.do(ctx, http.MethodPost, "/api/skills", req, &skill); err != nil {
+		return nil, err
+	}
+	return &skill, nil
+}
+
+// UpdateSkill rewrites a user skill.
+func (c *Client) UpdateSkill(ctx context.Context, name string, req SkillRequest) (*SkillAPI, error) {
+	var skill SkillAPI
+	if err := c.do(ctx, http.MethodPut, skillPath(name), req, &skill); err != nil {
+		return nil, err
+	}
+	return &skill, nil
+}
+
+// DeleteSkill deletes a user skill.
+func (c *Client) DeleteSkill(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, skillPath(name), nil, nil)
+}
+
+// InstallSkill copies a skill directory or archive on the server's machine
+// into the user skills.
+func (c *Client) InstallSkill(ctx context.Context, req InstallSkillRequest) (*SkillAPI, error) {
+	var skill SkillAPI
+	if err := c.do(ctx, http.MethodPost, "/api/skills/install", req, &skill); err != nil {
+		return nil, err
+	}
+	return &skill, nil
+}
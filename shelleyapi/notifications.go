@@ -0,0 +1,87 @@
+package shelleyapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func notificationChannelPath(channelID, suffix string) string {
+	return "/api/notification-channels/" + url.PathEscape(channelID) + suffix
+}
+
+// NotificationChannels lists the notification channels.
+func (c *Client) NotificationChannels(ctx context.Context) ([]NotificationChannelAPI, error) {
+	var channels []NotificationChannelAPI
+	if err := c.do(ctx, http.MethodGet, "/api/notification-channels", nil, &channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// GetNotificationChannel returns a notification channel.
+func (c *Client) GetNotificationChannel(ctx context.Context, channelID string) (*NotificationChannelAPI, error) {
+	var channel NotificationChannelAPI
+	if err := c.do(ctx, http.MethodGet, notificationChannelPath(channelID, ""), nil, &channel); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// CreateNotificationChannel adds a notification channel.
+func (c *Client) CreateNotificationChannel(ctx context.Context, req CreateNotificationChannelRequest) (*NotificationChannelAPI, error) {
+	var channel NotificationChannelAPI
+	if err := c.do(ctx, http.MethodPost, "/api/notification-channels", req, &channel); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// UpdateNotificationChannel changes a notification channel.
+func (c *Client) UpdateNotificationChannel(ctx context.Context, channelID string, req UpdateNotificationChannelRequest) (*NotificationChannelAPI, error) {
+	var channel NotificationChannelAPI
+	if err := c.do(ctx, http.MethodPut, notificationChannelPath(channelID, ""), req, &channel); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// DeleteNotificationChannel deletes a notification channel.
+func (c *Client) DeleteNotificationChannel(ctx context.Context, channelID string) error {
+	return c.do(ctx, http.MethodDelete, notificationChannelPath(channelID, ""), nil, nil)
+}
+
+// TestNotificationChannel sends a test notification through a channel. A
+// channel that fails the test is reported in the response, not as an error.
+func (c *Client) TestNotificationChannel(ctx context.Context, channelID string) (*TestNotificationChannelResponse, error) {
+	var resp TestNotificationChannelResponse
+	if err := c.do(ctx, http.MethodPost, notificationChannelPath(channelID, "/test"), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// NotificationDeliveries returns a channel's most recent deliveries, newest
+// first. A limit of 0 uses the server's default.
+func (c *Client) NotificationDeliveries(ctx context.Context, channelID string, limit int) ([]NotificationDeliveryAPI, error) {
+	path := notificationChannelPath(channelID, "/deliveries")
+	if limit > 0 {
+		path += query("limit", strconv.Itoa(limit))
+	}
+	var deliveries []NotificationDeliveryAPI
+	if err := c.do(ctx, http.MethodGet, path, nil, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// NotificationChannelTypes lists the channel types the server supports and
+// their settings.
+func (c *Client) NotificationChannelTypes(ctx context.Context) ([]ChannelTypeInfo, error) {
+	var types []ChannelTypeInfo
+	if err := c.do(ctx, http.MethodGet, "/api/notification-channel-types", nil, &types); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
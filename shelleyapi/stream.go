@@ -0,0 +1,181 @@
+package shelleyapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxStreamRetries is how many times in a row a Stream tries to reconnect
+// before giving up.
+const maxStreamRetries = 8
+
+// Event is one event from a conversation stream.
+type Event struct {
+	StreamEventEnvelopeV1
+	// Data is the decoded payload of conversation, message and heartbeat
+	// events.
+	Data StreamResponse
+	// Job is set for job events.
+	Job *JobRun
+}
+
+// Stream follows a conversation's events. If the connection drops, it
+// reconnects and resumes after the last event it returned, so persisted
+// events are neither missed nor repeated; transient events such as
+// streaming text deltas sent while it was disconnected are lost.
+type Stream struct {
+	c              *Client
+	ctx            context.Context
+	cancel         context.CancelFunc
+	conversationID string
+	lastEventID    int64
+
+	resp    *http.Response
+	scanner *bufio.Scanner
+	retries int
+}
+
+// Stream starts following a conversation's events after lastEventID. With
+// lastEventID 0 the conversation's whole event history is replayed first.
+// Use StreamResponse.LastEventID from GetConversation to see only new events.
+func (c *Client) Stream(ctx context.Context, conversationID string, lastEventID int64) *Stream {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Stream{c: c, ctx: ctx, cancel: cancel, conversationID: conversationID, lastEventID: lastEventID}
+}
+
+// LastEventID returns the ID of the last persisted event Next returned.
+func (s *Stream) LastEventID() int64 {
+	return s.lastEventID
+}
+
+// Close stops the stream.
+func (s *Stream) Close() error {
+	s.cancel()
+	if s.resp != nil {
+		s.resp.Body.Close()
+		s.resp = nil
+	}
+	return nil
+}
+
+// Next waits for the next event. It returns an error when the stream is
+// closed, its context is done, the server rejects it, or reconnecting keeps
+// failing.
+func (s *Stream) Next() (*Event, error) {
+	for {
+		if s.resp == nil {
+			if err := s.connect(); err != nil {
+				return nil, err
+			}
+		}
+		for s.scanner.Scan() {
+			data, ok := strings.CutPrefix(s.scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			event, err := decodeEvent([]byte(data))
+			if err != nil {
+				return nil, err
+			}
+			if event.EventID != 0 {
+				if event.EventID <= s.lastEventID {
+					continue
+				}
+				s.lastEventID = event.EventID
+			}
+			s.retries = 0
+			return event, nil
+		}
+		// The connection ended; reconnect after a delay.
+		err := s.scanner.Err()
+		s.resp.Body.Close()
+		s.resp = nil
+		if ctxErr := s.ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		s.retries++
+		if s.retries > maxStreamRetries {
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+}
+
+// connect opens the event stream, retrying with backoff while the server is
+// unreachable or failing.
+func (s *Stream) connect() error {
+	for {
+		if s.retries > 0 {
+			delay := min(250*time.Millisecond<<(s.retries-1), 10*time.Second)
+			select {
+			case <-s.ctx.Done():
+				return s.ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+		resp, err := s.open()
+		if err == nil {
+			s.resp = resp
+			s.scanner = bufio.NewScanner(resp.Body)
+			s.scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+			return nil
+		}
+		if ctxErr := s.ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode < 500 {
+			return err
+		}
+		s.retries++
+		if s.retries > maxStreamRetries {
+			return err
+		}
+	}
+}
+
+func (s *Stream) open() (*http.Response, error) {
+	path := conversationPath(s.conversationID, "/stream")
+	if s.lastEventID > 0 {
+		path += "?last_event_id=" + strconv.FormatInt(s.lastEventID, 10)
+	}
+	req, err := s.c.newRequest(s.ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	return s.c.send(req)
+}
+
+func decodeEvent(data []byte) (*Event, error) {
+	var event Event
+	if err := json.Unmarshal(data, &event.StreamEventEnvelopeV1); err != nil {
+		return nil, err
+	}
+	if len(event.Payload) == 0 {
+		return &event, nil
+	}
+	if strings.HasPrefix(event.Type, "job.") {
+		var payload struct {
+			Job JobRun `json:"job"`
+		}
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, err
+		}
+		event.Job = &payload.Job
+		return &event, nil
+	}
+	if err := json.Unmarshal(event.Payload, &event.Data); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
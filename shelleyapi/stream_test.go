@@ -0,0 +1,133 @@
+package shelleyapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestStreamResumes checks that a stream reconnects after the server drops it
+// and asks for events after the last one it returned.
+func TestStreamResumes(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventID := r.URL.Query().Get("last_event_id")
+		mu.Lock()
+		requested = append(requested, lastEventID)
+		mu.Unlock()
+		after, _ := strconv.ParseInt(lastEventID, 10, 64)
+		w.Header().Set("Content-Type", "text/event-stream")
+		// Send two events per connection, repeating one already seen to
+		// check that duplicates are dropped, then hang up.
+		for id := max(after, 1); id <= after+2; id++ {
+			payload, _ := json.Marshal(StreamResponse{Messages: []APIMessage{{SequenceID: id}}})
+			data, _ := json.Marshal(StreamEventEnvelopeV1{
+				Version: StreamEventVersionV1,
+				Type:    EventTypeMessageCreated,
+				EventID: id,
+				Payload: payload,
+			})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream := c.Stream(ctx, "c1", 0)
+	defer stream.Close()
+
+	for want := int64(1); want <= 5; want++ {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if event.EventID != want || len(event.Data.Messages) != 1 || event.Data.Messages[0].SequenceID != want {
+			t.Fatalf("expected event %d, got %d (payload %+v)", want, event.EventID, event.Data)
+		}
+	}
+	if stream.LastEventID() != 5 {
+		t.Errorf("expected LastEventID 5, got %d", stream.LastEventID())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"", "2", "4"}
+	if fmt.Sprint(requested) != fmt.Sprint(want) {
+		t.Errorf("expected requests after %q, got %q", want, requested)
+	}
+}
+
+// TestStreamRejected checks that a stream gives up on client errors.
+func TestStreamRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := c.Stream(context.Background(), "missing", 0)
+	defer stream.Close()
+	if _, err := stream.Next(); !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "shelley.sock")
+	ln, err := net.Listen("unix", sockPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/settings" || r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"theme": "dark"})
+	}))
+	srv.Listener = ln
+	srv.Start()
+	defer srv.Close()
+
+	c, err := New("unix://" + sockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetToken("tok")
+	settings, err := c.Settings(context.Background())
+	if err != nil {
+		t.Fatalf("Settings: %v", err)
+	}
+	if settings["theme"] != "dark" {
+		t.Errorf("unexpected settings %v", settings)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	for _, tc := range []struct{ body, want string }{
+		{`{"error":"bad model"}`, "bad model"},
+		{"Conversation not found\n", "Conversation not found"},
+	} {
+		if got := errorMessage([]byte(tc.body)); got != tc.want {
+			t.Errorf("errorMessage(%q) = %q, want %q", tc.body, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,656 @@
+package shelleyapi
+
+import (
+	"encoding/json"
+	"time"
+
+	"shelley.exe.dev/llm"
+)
+
+// APIMessage is the message format sent to clients
+// TODO: We could maybe omit llm_data when display_data is available
+type APIMessage struct {
+	MessageID      string    `json:"message_id"`
+	ConversationID string    `json:"conversation_id"`
+	SequenceID     int64     `json:"sequence_id"`
+	Type           string    `json:"type"`
+	LlmData        *string   `json:"llm_data,omitempty"`
+	UserData       *string   `json:"user_data,omitempty"`
+	UsageData      *string   `json:"usage_data,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	DisplayData    *string   `json:"display_data,omitempty"`
+	EndOfTurn      *bool     `json:"end_of_turn,omitempty"`
+}
+
+// LLMMessage decodes the message as sent to or received from the model.
+// It returns nil if the message has no LLM data.
+func (m APIMessage) LLMMessage() (*llm.Message, error) {
+	if m.LlmData == nil {
+		return nil, nil
+	}
+	var msg llm.Message
+	if err := json.Unmarshal([]byte(*m.LlmData), &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// EndsTurn reports whether the message is the last one of an agent turn.
+func (m APIMessage) EndsTurn() bool {
+	return (m.Type == "agent" || m.Type == "error") && m.EndOfTurn != nil && *m.EndOfTurn
+}
+
+// ConversationState represents the current state of a conversation.
+// This is broadcast to all subscribers whenever the state changes.
+type ConversationState struct {
+	ConversationID string `json:"conversation_id"`
+	Working        bool   `json:"working"`
+	Model          string `json:"model,omitempty"`
+}
+
+// Conversation is a conversation as stored by the server.
+type Conversation struct {
+	ConversationID       string    `json:"conversation_id"`
+	Slug                 *string   `json:"slug"`
+	UserInitiated        bool      `json:"user_initiated"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+	Cwd                  *string   `json:"cwd"`
+	Archived             bool      `json:"archived"`
+	ParentConversationID *string   `json:"parent_conversation_id"`
+	Model                *string   `json:"model"`
+	Sandbox              *string   `json:"sandbox"`
+}
+
+// ConversationRuntime is the persisted runtime state of a conversation.
+type ConversationRuntime struct {
+	ConversationID string    `json:"conversation_id"`
+	Working        bool      `json:"working"`
+	ActiveJobID    *string   `json:"active_job_id"`
+	LastEventID    int64     `json:"last_event_id"`
+	CurrentModelID *string   `json:"current_model_id"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// JobRun is a unit of agent work on a conversation, such as a turn or a
+// subagent run.
+type JobRun struct {
+	JobID            string     `json:"job_id"`
+	ConversationID   string     `json:"conversation_id"`
+	ParentJobID      *string    `json:"parent_job_id"`
+	Kind             string     `json:"kind"`
+	Status           string     `json:"status"`
+	TriggerMessageID *string    `json:"trigger_message_id"`
+	ModelID          *string    `json:"model_id"`
+	TimeoutSeconds   *int64     `json:"timeout_seconds"`
+	InputJson        string     `json:"input_json"`
+	OutputJson       *string    `json:"output_json"`
+	ErrorJson        *string    `json:"error_json"`
+	AttemptCount     int64      `json:"attempt_count"`
+	CreatedAt        time.Time  `json:"created_at"`
+	StartedAt        *time.Time `json:"started_at"`
+	FinishedAt       *time.Time `json:"finished_at"`
+}
+
+// ConversationWithState combines a conversation with its working state.
+type ConversationWithState struct {
+	Conversation
+	Working         bool   `json:"working"`
+	GitRepoRoot     string `json:"git_repo_root,omitempty"`
+	GitWorktreeRoot string `json:"git_worktree_root,omitempty"`
+	GitCommit       string `json:"git_commit,omitempty"`
+	GitSubject      string `json:"git_subject,omitempty"`
+	SubagentCount   int64  `json:"subagent_count"`
+}
+
+// StreamResponse represents the response format for conversation streaming
+type StreamResponse struct {
+	Messages          []APIMessage         `json:"messages"`
+	Conversation      Conversation         `json:"conversation"`
+	Runtime           *ConversationRuntime `json:"runtime,omitempty"`
+	LastEventID       int64                `json:"last_event_id,omitempty"`
+	ConversationState *ConversationState   `json:"conversation_state,omitempty"`
+	ContextWindowSize uint64               `json:"context_window_size,omitempty"`
+	// ConversationListUpdate is set when another conversation in the list changed
+	ConversationListUpdate *ConversationListUpdate `json:"conversation_list_update,omitempty"`
+	// Heartbeat indicates this is a heartbeat message (no new data, just keeping connection alive)
+	Heartbeat bool `json:"heartbeat,omitempty"`
+	// NotificationEvent is set when a notification-worthy event occurs (e.g. agent finished).
+	NotificationEvent *NotificationEvent `json:"notification_event,omitempty"`
+	// StreamingText is a delta of text content being streamed from the LLM.
+	StreamingText string `json:"streaming_text,omitempty"`
+	// StreamingThinking is a delta of thinking/reasoning content being streamed.
+	StreamingThinking string `json:"streaming_thinking,omitempty"`
+}
+
+func (sr *StreamResponse) UnmarshalJSON(data []byte) error {
+	type alias StreamResponse
+	var direct alias
+	if err := json.Unmarshal(data, &direct); err == nil && (direct.Conversation.ConversationID != "" || direct.Messages != nil || direct.Heartbeat || direct.ConversationState != nil || direct.NotificationEvent != nil || direct.ConversationListUpdate != nil || direct.StreamingText != "" || direct.StreamingThinking != "") {
+		*sr = StreamResponse(direct)
+		return nil
+	}
+
+	var envelope StreamEventEnvelopeV1
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	if len(envelope.Payload) == 0 {
+		*sr = StreamResponse{}
+		return nil
+	}
+	var payload alias
+	if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+		return err
+	}
+	*sr = StreamResponse(payload)
+	return nil
+}
+
+// ConversationListUpdate represents an update to the conversation list
+type ConversationListUpdate struct {
+	Type            string        `json:"type"` // "update", "delete"
+	Conversation    *Conversation `json:"conversation,omitempty"`
+	ConversationID  string        `json:"conversation_id,omitempty"` // For deletes
+	GitRepoRoot     string        `json:"git_repo_root,omitempty"`
+	GitWorktreeRoot string        `json:"git_worktree_root,omitempty"`
+}
+
+// NotificationEvent is a notification-worthy event, such as the agent
+// finishing a turn. Type names the event ("agent_done", "tool_approval_needed"
+// and so on) and determines the shape of Payload.
+type NotificationEvent struct {
+	Type           string          `json:"type"`
+	ConversationID string          `json:"conversation_id"`
+	Timestamp      time.Time       `json:"timestamp"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	// Cwd is the working directory of the conversation, if known.
+	Cwd string `json:"cwd,omitempty"`
+	// Subagent is set for events from subagent conversations.
+	Subagent bool `json:"subagent,omitempty"`
+	// Reply, if set, lets the recipient answer the event.
+	Reply *NotificationReply `json:"reply,omitempty"`
+}
+
+// NotificationReply describes how to answer a NotificationEvent: POST
+// {"action": ..., "message": ...} to URL, whose query carries Token.
+type NotificationReply struct {
+	URL     string   `json:"url,omitempty"`
+	Token   string   `json:"token"`
+	Actions []string `json:"actions"`
+}
+
+// StreamEventVersionV1 is the version of StreamEventEnvelopeV1.
+const StreamEventVersionV1 = 1
+
+// Stream event types. Events with an EventID are stored in the
+// conversation's event log and replayed to streams resuming after them;
+// heartbeats and streaming deltas are transient.
+const (
+	EventTypeConversationUpdated      = "conversation.updated"
+	EventTypeConversationStateChanged = "conversation.state.changed"
+	EventTypeMessageCreated           = "message.created"
+	EventTypeMessageUpdated           = "message.updated"
+	EventTypeJobCreated               = "job.created"
+	EventTypeJobUpdated               = "job.updated"
+	EventTypeNotificationCreated      = "notification.created"
+	EventTypeHeartbeat                = "heartbeat"
+	EventTypeStreamTextDelta          = "stream.text.delta"
+	EventTypeStreamThinkingDelta      = "stream.thinking.delta"
+)
+
+// StreamEventEnvelopeV1 is one event on a conversation stream. Payload is a
+// StreamResponse, or a {"job": ...} object for job events.
+type StreamEventEnvelopeV1 struct {
+	Version        int             `json:"version"`
+	EventID        int64           `json:"event_id,omitempty"`
+	ConversationID string          `json:"conversation_id"`
+	JobID          *string         `json:"job_id,omitempty"`
+	Type           string          `json:"type"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+}
+
+// ChatRequest represents a chat message from the user
+type ChatRequest struct {
+	Message string `json:"message"`
+	Model   string `json:"model,omitempty"`
+	Cwd     string `json:"cwd,omitempty"`
+	// Sandbox, when starting a new conversation, confines its bash commands.
+	Sandbox *SandboxSettings `json:"sandbox,omitempty"`
+}
+
+// SandboxSettings confine a conversation's bash commands.
+type SandboxSettings struct {
+	Enabled bool `json:"enabled"`
+	// BlockNetwork runs commands without network access.
+	BlockNetwork bool `json:"block_network,omitempty"`
+	// WritablePaths are directories commands may write to in addition to
+	// the repository root, the temp directories and the user's standard
+	// caches.
+	WritablePaths []string `json:"writable_paths,omitempty"`
+	// CPUSeconds limits the CPU time of each process.
+	CPUSeconds int `json:"cpu_seconds,omitempty"`
+	// MemoryMB limits the memory of a command and its children.
+	MemoryMB int `json:"memory_mb,omitempty"`
+	// MaxProcesses limits the number of processes a command may run at once.
+	MaxProcesses int `json:"max_processes,omitempty"`
+}
+
+// ForkConversationRequest selects how much of a conversation to fork.
+type ForkConversationRequest struct {
+	// SequenceID is the last message to copy; 0 copies all of them.
+	SequenceID int64 `json:"sequence_id,omitempty"`
+}
+
+// RenameRequest represents a request to rename a conversation
+type RenameRequest struct {
+	Slug string `json:"slug"`
+}
+
+// DistillConversationRequest represents the request to distill a conversation
+type DistillConversationRequest struct {
+	SourceConversationID string `json:"source_conversation_id"`
+	Model                string `json:"model,omitempty"`
+	Cwd                  string `json:"cwd,omitempty"`
+}
+
+// NewConversationResponse is returned when a conversation is created, by
+// POST /api/conversations/new and /api/conversations/distill.
+type NewConversationResponse struct {
+	Status         string `json:"status"`
+	ConversationID string `json:"conversation_id"`
+}
+
+// StatusResponse is returned by endpoints that only report success.
+type StatusResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// ModelInfo represents a model in the API response
+type ModelInfo struct {
+	ID               string `json:"id"`
+	DisplayName      string `json:"display_name,omitempty"`
+	Source           string `json:"source,omitempty"` // Human-readable source (e.g., "exe.dev gateway", "$ANTHROPIC_API_KEY")
+	Ready            bool   `json:"ready"`
+	MaxContextTokens int    `json:"max_context_tokens,omitempty"`
+}
+
+// ModelAPI is the API representation of a model
+type ModelAPI struct {
+	ModelID      string       `json:"model_id"`
+	DisplayName  string       `json:"display_name"`
+	ProviderType string       `json:"provider_type"`
+	Endpoint     string       `json:"endpoint"`
+	APIKey       string       `json:"-"`
+	HasAPIKey    bool         `json:"has_api_key"`
+	ModelName    string       `json:"model_name"`
+	MaxTokens    int64        `json:"max_tokens"`
+	Tags         string       `json:"tags"`              // Comma-separated tags (e.g., "slug" for slug generation)
+	Pricing      *llm.Pricing `json:"pricing,omitempty"` // USD per million tokens; nil if unknown
+	// Capabilities are the effective capabilities: the stored ones, or the provider defaults.
+	Capabilities llm.Capabilities `json:"capabilities"`
+}
+
+// CreateModelRequest is the request body for creating a model
+type CreateModelRequest struct {
+	DisplayName  string       `json:"display_name"`
+	ProviderType string       `json:"provider_type"`
+	Endpoint     string       `json:"endpoint"`
+	APIKey       string       `json:"api_key"`
+	ModelName    string       `json:"model_name"`
+	MaxTokens    int64        `json:"max_tokens"`
+	Tags         string       `json:"tags"` // Comma-separated tags
+	Pricing      *llm.Pricing `json:"pricing,omitempty"`
+	// Capabilities overrides the provider defaults (see models.DefaultCapabilities);
+	// fields a JSON object omits keep their defaults.
+	Capabilities *llm.Capabilities `json:"capabilities,omitempty"`
+}
+
+// UpdateModelRequest is the request body for updating a model
+type UpdateModelRequest struct {
+	DisplayName  string       `json:"display_name"`
+	ProviderType string       `json:"provider_type"`
+	Endpoint     string       `json:"endpoint"`
+	APIKey       string       `json:"api_key"` // Empty string means keep existing
+	ModelName    string       `json:"model_name"`
+	MaxTokens    int64        `json:"max_tokens"`
+	Tags         string       `json:"tags"`              // Comma-separated tags
+	Pricing      *llm.Pricing `json:"pricing,omitempty"` // Omitted keeps existing; null or zero prices clear it
+	// Capabilities overrides the stored capabilities, or the provider
+	// defaults if none are stored; fields a JSON object omits keep their
+	// values. Omitted, the stored capabilities are kept; null restores the
+	// provider defaults.
+	Capabilities *llm.Capabilities `json:"capabilities,omitempty"`
+}
+
+// DuplicateModelRequest allows overriding fields when duplicating
+type DuplicateModelRequest struct {
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// TestModelRequest is the request body for testing a model
+type TestModelRequest struct {
+	ModelID      string `json:"model_id,omitempty"` // If provided, use stored API key
+	ProviderType string `json:"provider_type"`
+	Endpoint     string `json:"endpoint"`
+	APIKey       string `json:"api_key"`
+	ModelName    string `json:"model_name"`
+}
+
+// TestModelResponse reports whether a model answered a test request.
+type TestModelResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// SkillAPI is the API representation of a discovered skill.
+type SkillAPI struct {
+	Skill
+	Dir string `json:"dir"`
+	// Source is "user" for skills in the user skill directories and
+	// "project" for skills in a project's .skills directories.
+	Source string `json:"source"`
+	// Editable is true for skills in the user skill directory, which the API manages.
+	Editable bool `json:"editable"`
+	// Error explains why the skill is invalid; invalid skills are not offered to the agent.
+	Error string `json:"error,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+// Skill is the metadata of a skill, from the frontmatter of its SKILL.md.
+type Skill struct {
+	Name          string            `json:"name"`
+	Description   string            `json:"description"`
+	License       string            `json:"license,omitempty"`
+	Compatibility string            `json:"compatibility,omitempty"`
+	AllowedTools  string            `json:"allowed_tools,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Path          string            `json:"path"` // Path to SKILL.md file
+}
+
+// SkillsResponse is the response body for listing skills.
+type SkillsResponse struct {
+	Skills  []SkillAPI `json:"skills"`
+	UserDir string     `json:"user_dir"`
+}
+
+// SkillRequest is the request body for creating or updating a skill.
+type SkillRequest struct {
+	Name          string            `json:"name"`
+	Description   string            `json:"description"`
+	License       string            `json:"license,omitempty"`
+	Compatibility string            `json:"compatibility,omitempty"`
+	AllowedTools  string            `json:"allowed_tools,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Body          string            `json:"body"`
+}
+
+// InstallSkillRequest is the request body for installing a skill.
+type InstallSkillRequest struct {
+	// Source is an absolute path to a skill directory or a .tar, .tar.gz or .tgz archive.
+	Source string `json:"source"`
+	// Replace overwrites an installed skill with the same name.
+	Replace bool `json:"replace"`
+}
+
+// GitDiffInfo represents a commit or working changes
+type GitDiffInfo struct {
+	ID         string    `json:"id"`
+	Message    string    `json:"message"`
+	Author     string    `json:"author"`
+	Timestamp  time.Time `json:"timestamp"`
+	FilesCount int       `json:"filesCount"`
+	Additions  int       `json:"additions"`
+	Deletions  int       `json:"deletions"`
+}
+
+// GitDiffsResponse lists the working changes and recent commits of a repository.
+type GitDiffsResponse struct {
+	Diffs   []GitDiffInfo `json:"diffs"`
+	GitRoot string        `json:"gitRoot"`
+}
+
+// GitFileInfo represents a file in a diff
+type GitFileInfo struct {
+	Path        string `json:"path"`
+	Status      string `json:"status"` // added, modified, deleted
+	Additions   int    `json:"additions"`
+	Deletions   int    `json:"deletions"`
+	IsGenerated bool   `json:"isGenerated"`
+}
+
+// GitFileDiff represents the content of a file diff
+type GitFileDiff struct {
+	Path       string `json:"path"`
+	OldContent string `json:"oldContent"`
+	NewContent string `json:"newContent"`
+}
+
+// DirectoryEntry represents a single directory entry for the directory picker
+type DirectoryEntry struct {
+	Name           string `json:"name"`
+	IsDir          bool   `json:"is_dir"`
+	GitHeadSubject string `json:"git_head_subject,omitempty"`
+}
+
+// ListDirectoryResponse is the response from the list-directory endpoint
+type ListDirectoryResponse struct {
+	Path            string           `json:"path"`
+	Parent          string           `json:"parent"`
+	Entries         []DirectoryEntry `json:"entries"`
+	GitHeadSubject  string           `json:"git_head_subject,omitempty"`
+	GitWorktreeRoot string           `json:"git_worktree_root,omitempty"`
+}
+
+// UploadResponse describes a file saved by POST /api/upload.
+type UploadResponse struct {
+	Path      string `json:"path"`
+	MediaType string `json:"media_type"`
+}
+
+// Usage dimensions for UsageOptions.GroupBy. Token usage cannot be split by
+// tool, so rows grouped by UsageByTool count only ToolCalls.
+const (
+	UsageByDay          = "day"
+	UsageByModel        = "model"
+	UsageByConversation = "conversation"
+	UsageByCwd          = "cwd"
+	UsageByRepo         = "repo"
+	UsageByKind         = "kind"
+	UsageByTool         = "tool"
+)
+
+// UsageRow is one group of a usage rollup. Of Day through Tool, only the
+// dimensions the rollup is grouped by are set.
+type UsageRow struct {
+	// Day is a UTC date, YYYY-MM-DD.
+	Day            string `json:"day,omitempty"`
+	Model          string `json:"model,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	Cwd            string `json:"cwd,omitempty"`
+	// Repo is the root of the git repository containing Cwd, shared by its
+	// worktrees, or Cwd itself outside a repository.
+	Repo string `json:"repo,omitempty"`
+	// Kind is "turn" for the agent's own work and "subagent" for work done
+	// in subagent conversations.
+	Kind string `json:"kind,omitempty"`
+	Tool string `json:"tool,omitempty"`
+
+	// Responses is the number of LLM responses.
+	Responses                int64   `json:"responses"`
+	InputTokens              uint64  `json:"input_tokens"`
+	CacheCreationInputTokens uint64  `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     uint64  `json:"cache_read_input_tokens"`
+	OutputTokens             uint64  `json:"output_tokens"`
+	ReasoningTokens          uint64  `json:"reasoning_tokens"`
+	CostUSD                  float64 `json:"cost_usd"`
+	// CacheHitRatio is the share of input tokens read from the prompt cache.
+	CacheHitRatio float64 `json:"cache_hit_ratio"`
+
+	// Turns is the number of finished turns and subagent runs.
+	Turns                 int64   `json:"turns"`
+	AvgTurnLatencySeconds float64 `json:"avg_turn_latency_seconds"`
+	AvgTurnCostUSD        float64 `json:"avg_turn_cost_usd"`
+
+	// ToolCalls is the number of tool calls the model made.
+	ToolCalls int64 `json:"tool_calls"`
+}
+
+// UsageResponse is a usage rollup from GET /api/usage.
+type UsageResponse struct {
+	Since   time.Time  `json:"since"`
+	Until   time.Time  `json:"until"`
+	GroupBy []string   `json:"group_by"`
+	Rows    []UsageRow `json:"rows"`
+	// Total sums all rows.
+	Total UsageRow `json:"total"`
+}
+
+// NotificationChannelAPI is a notification channel as returned by the API.
+type NotificationChannelAPI struct {
+	ChannelID   string `json:"channel_id"`
+	ChannelType string `json:"channel_type"`
+	DisplayName string `json:"display_name"`
+	Enabled     bool   `json:"enabled"`
+	Config      any    `json:"config"`
+}
+
+// CreateNotificationChannelRequest is the body of POST /api/notification-channels.
+type CreateNotificationChannelRequest struct {
+	ChannelType string `json:"channel_type"`
+	DisplayName string `json:"display_name"`
+	Enabled     bool   `json:"enabled"`
+	Config      any    `json:"config"`
+}
+
+// UpdateNotificationChannelRequest is the body of PUT /api/notification-channels/{id}.
+type UpdateNotificationChannelRequest struct {
+	DisplayName string `json:"display_name"`
+	Enabled     bool   `json:"enabled"`
+	Config      any    `json:"config"`
+}
+
+// ConfigField describes one setting of a notification channel type.
+type ConfigField struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Placeholder string   `json:"placeholder,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+// ChannelTypeInfo describes a notification channel type and its settings.
+type ChannelTypeInfo struct {
+	Type         string        `json:"type"`
+	Label        string        `json:"label"`
+	ConfigFields []ConfigField `json:"config_fields"`
+}
+
+// TestNotificationChannelResponse reports whether a channel accepted a
+// test notification.
+type TestNotificationChannelResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// NotificationDeliveryAPI is one entry in a channel's delivery history.
+type NotificationDeliveryAPI struct {
+	DeliveryID     int64     `json:"delivery_id"`
+	EventType      string    `json:"event_type"`
+	ConversationID *string   `json:"conversation_id,omitempty"`
+	Title          string    `json:"title,omitempty"`
+	Status         string    `json:"status"`
+	Attempts       int64     `json:"attempts"`
+	LastError      *string   `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// AuthStatus is returned by GET /api/auth/status. Authenticated and Scope
+// are only set when authentication is enabled.
+type AuthStatus struct {
+	Enabled       bool   `json:"enabled"`
+	Authenticated bool   `json:"authenticated,omitempty"`
+	Scope         string `json:"scope,omitempty"`
+}
+
+// APITokenAPI is an API token as returned by the API, without its hash.
+type APITokenAPI struct {
+	TokenID    string     `json:"token_id"`
+	Name       string     `json:"name"`
+	Scope      string     `json:"scope"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Token is the secret, only returned when the token is created.
+	Token string `json:"token,omitempty"`
+}
+
+// CreateAPITokenRequest is the body of POST /api/auth/tokens.
+type CreateAPITokenRequest struct {
+	Name string `json:"name"`
+	// Scope is "read", "chat" or "admin".
+	Scope string `json:"scope"`
+	// ExpiresIn is a lifetime such as "90d" or "12h"; empty never expires.
+	ExpiresIn string `json:"expires_in,omitempty"`
+}
+
+// CodexAuthStatusResponse is the response for GET /api/codex-auth/status
+type CodexAuthStatusResponse struct {
+	Authenticated bool    `json:"authenticated"`
+	AccountID     *string `json:"account_id,omitempty"`
+	ExpiresAt     *int64  `json:"expires_at,omitempty"`
+}
+
+// CodexPkceStartResponse is the response for POST /api/codex-auth/pkce/start
+type CodexPkceStartResponse struct {
+	AuthURL string `json:"auth_url"`
+	State   string `json:"state"`
+}
+
+// CodexPkceCompleteRequest is the request for POST /api/codex-auth/pkce/complete
+type CodexPkceCompleteRequest struct {
+	CallbackURL string `json:"callback_url"`
+}
+
+// BuildInfo describes the build of the server, as returned by GET /version.
+type BuildInfo struct {
+	Version    string `json:"version,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+	CommitTime string `json:"commit_time,omitempty"`
+	Modified   bool   `json:"modified,omitempty"`
+}
+
+// VersionInfo contains version check results.
+type VersionInfo struct {
+	CurrentVersion      string       `json:"current_version"`
+	CurrentTag          string       `json:"current_tag,omitempty"`
+	CurrentCommit       string       `json:"current_commit,omitempty"`
+	CurrentCommitTime   string       `json:"current_commit_time,omitempty"`
+	LatestVersion       string       `json:"latest_version,omitempty"`
+	LatestTag           string       `json:"latest_tag,omitempty"`
+	PublishedAt         time.Time    `json:"published_at,omitempty"`
+	HasUpdate           bool         `json:"has_update"`    // True if minor version is newer (for showing upgrade button)
+	ShouldNotify        bool         `json:"should_notify"` // True if should show red dot (newer + 5 days old)
+	DownloadURL         string       `json:"download_url,omitempty"`
+	ExecutablePath      string       `json:"executable_path,omitempty"`
+	Commits             []CommitInfo `json:"commits,omitempty"`
+	CheckedAt           time.Time    `json:"checked_at"`
+	Error               string       `json:"error,omitempty"`
+	RunningUnderSystemd bool         `json:"running_under_systemd"` // True if INVOCATION_ID env var is set (systemd)
+}
+
+// CommitInfo represents a commit in the changelog.
+type CommitInfo struct {
+	SHA     string    `json:"sha"`
+	Message string    `json:"message"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+}
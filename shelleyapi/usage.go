@@ -0,0 +1,71 @@
+package shelleyapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// UsageOptions selects the usage to roll up.
+type UsageOptions struct {
+	// Since and Until bound the period; the server defaults to the seven
+	// days up to now.
+	Since, Until time.Time
+	// GroupBy lists the dimensions to group by, such as UsageByDay and
+	// UsageByModel. With none, there is
+	// at most one row.
+	GroupBy []string
+
+	// The remaining fields, if set, keep only matching usage.
+	Model          string
+	Cwd            string
+	Repo           string
+	Kind           string
+	ConversationID string
+}
+
+func (o UsageOptions) query(format string) string {
+	var since, until string
+	if !o.Since.IsZero() {
+		since = o.Since.Format(time.RFC3339)
+	}
+	if !o.Until.IsZero() {
+		until = o.Until.Format(time.RFC3339)
+	}
+	return query(
+		"since", since,
+		"until", until,
+		"group_by", strings.Join(o.GroupBy, ","),
+		"model", o.Model,
+		"cwd", o.Cwd,
+		"repo", o.Repo,
+		"kind", o.Kind,
+		"conversation", o.ConversationID,
+		"format", format,
+	)
+}
+
+// Usage rolls up token usage, cost, turn latency and tool calls.
+func (c *Client) Usage(ctx context.Context, opts UsageOptions) (*UsageResponse, error) {
+	var usage UsageResponse
+	if err := c.do(ctx, http.MethodGet, "/api/usage"+opts.query(""), nil, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// UsageCSV returns the rollup Usage would as CSV, with a header row. The
+// caller must close it.
+func (c *Client) UsageCSV(ctx context.Context, opts UsageOptions) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/usage"+opts.query("csv"), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
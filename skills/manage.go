@@ -0,0 +1,368 @@
+package skills
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrExists is returned when installing or creating a skill that already exists.
+var ErrExists = errors.New("skill already exists")
+
+// maxInstallSize limits the total size of files extracted from a skill archive.
+const maxInstallSize = 100 << 20
+
+// UserDir returns the directory where user-managed skills are written,
+// ~/.config/shelley. It is one of the DefaultDirs once it exists.
+func UserDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "shelley"), nil
+}
+
+// Validate checks the frontmatter fields of s against the spec.
+func Validate(s Skill) error {
+	if err := validateName(s.Name); err != nil {
+		return err
+	}
+	if s.Description == "" {
+		return &ValidationError{Message: "description is required"}
+	}
+	if len(s.Description) > MaxDescriptionLength {
+		return &ValidationError{Message: "description exceeds maximum length"}
+	}
+	if len(s.Compatibility) > MaxCompatibilityLength {
+		return &ValidationError{Message: "compatibility exceeds maximum length"}
+	}
+	fields := map[string]string{
+		"description":   s.Description,
+		"license":       s.License,
+		"compatibility": s.Compatibility,
+		"allowed-tools": s.AllowedTools,
+	}
+	for k, v := range s.Metadata {
+		fields["metadata "+k] = v
+		if k == "" || strings.ContainsAny(k, ":\n") {
+			return &ValidationError{Message: fmt.Sprintf("invalid metadata key %q", k)}
+		}
+	}
+	for field, v := range fields {
+		if strings.ContainsAny(v, "\r\n") {
+			return &ValidationError{Message: field + " must be a single line"}
+		}
+	}
+	return nil
+}
+
+// Format renders s and body as SKILL.md content.
+func Format(s Skill, body string) string {
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	writeField := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&sb, "%s: %s\n", key, quoteYAML(value))
+		}
+	}
+	writeField("name", s.Name)
+	writeField("description", s.Description)
+	writeField("license", s.License)
+	writeField("compatibility", s.Compatibility)
+	writeField("allowed-tools", s.AllowedTools)
+	if len(s.Metadata) > 0 {
+		keys := make([]string, 0, len(s.Metadata))
+		for k := range s.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteString("metadata:\n")
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "  %s: %s\n", k, quoteYAML(s.Metadata[k]))
+		}
+	}
+	sb.WriteString("---\n\n")
+	if body = strings.TrimSpace(body); body != "" {
+		sb.WriteString(body)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// quoteYAML quotes a value if the simple parser would otherwise change it.
+func quoteYAML(s string) string {
+	if s == "" || s != strings.TrimSpace(s) || strings.ContainsAny(s[:1], `"'[{>|*&!%@#`+"`") || strings.HasSuffix(s, `"`) || strings.HasSuffix(s, "'") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// Write creates or replaces the skill s.Name in dir with the given body.
+// Other files in the skill directory, such as scripts, are left alone.
+// It returns the skill as parsed back from disk.
+func Write(dir string, s Skill, body string) (Skill, error) {
+	if err := Validate(s); err != nil {
+		return Skill{}, err
+	}
+	skillDir := filepath.Join(dir, s.Name)
+	if err := os.MkdirAll(skillDir, 0o755); err != nil {
+		return Skill{}, err
+	}
+	path := findSkillMD(skillDir)
+	if path == "" {
+		path = filepath.Join(skillDir, "SKILL.md")
+	}
+	if err := os.WriteFile(path, []byte(Format(s, body)), 0o644); err != nil {
+		return Skill{}, err
+	}
+	return Parse(path)
+}
+
+// Remove deletes the skill named name from dir, including its bundled files.
+func Remove(dir, name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	skillDir := filepath.Join(dir, name)
+	if findSkillMD(skillDir) == "" {
+		return fmt.Errorf("skill %q not found in %s: %w", name, dir, fs.ErrNotExist)
+	}
+	return os.RemoveAll(skillDir)
+}
+
+// Install copies the skill at source into dir and returns it. source is a
+// skill directory or a .tar, .tar.gz or .tgz archive with the skill at its
+// root or in a single top-level directory. An existing skill with the same
+// name is replaced only if replace is set; otherwise Install returns ErrExists.
+func Install(dir, source string, replace bool) (Skill, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return Skill{}, err
+	}
+
+	root := source
+	if !info.IsDir() {
+		tmp, err := os.MkdirTemp("", "shelley-skill-")
+		if err != nil {
+			return Skill{}, err
+		}
+		defer os.RemoveAll(tmp)
+		if err := extractArchive(source, tmp); err != nil {
+			return Skill{}, fmt.Errorf("failed to extract %s: %w", source, err)
+		}
+		root = tmp
+	}
+
+	skillDir, err := locateSkillDir(root)
+	if err != nil {
+		return Skill{}, err
+	}
+	skill, err := Parse(findSkillMD(skillDir))
+	if err != nil {
+		return Skill{}, err
+	}
+
+	dest := filepath.Join(dir, skill.Name)
+	if _, err := os.Stat(dest); err == nil && !replace {
+		return Skill{}, fmt.Errorf("%w: %s", ErrExists, dest)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Skill{}, err
+	}
+
+	// Copy next to the destination first so a failed copy leaves any
+	// existing skill untouched.
+	staging, err := os.MkdirTemp(dir, "."+skill.Name+"-")
+	if err != nil {
+		return Skill{}, err
+	}
+	defer os.RemoveAll(staging)
+	if err := copyTree(skillDir, staging); err != nil {
+		return Skill{}, err
+	}
+	if err := os.Chmod(staging, 0o755); err != nil {
+		return Skill{}, err
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return Skill{}, err
+	}
+	if err := os.Rename(staging, dest); err != nil {
+		return Skill{}, err
+	}
+	return Parse(findSkillMD(dest))
+}
+
+// locateSkillDir returns root if it holds a SKILL.md, or its only subdirectory if that does.
+func locateSkillDir(root string) (string, error) {
+	if findSkillMD(root) != "" {
+		return root, nil
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return "", err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			dirs = append(dirs, filepath.Join(root, e.Name()))
+		}
+	}
+	if len(dirs) == 1 && findSkillMD(dirs[0]) != "" {
+		return dirs[0], nil
+	}
+	return "", &ValidationError{Message: "no SKILL.md found at the top level of the skill"}
+}
+
+// copyTree copies the regular files and directories under src into dst.
+// Symlinks and other special files are skipped.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// extractArchive unpacks a tar or gzipped tar archive into dst. Entries that
+// would land outside dst, links, and special files are rejected or skipped.
+func extractArchive(path, dst string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case strings.HasSuffix(lower, ".tar"):
+	default:
+		return fmt.Errorf("unsupported archive type; use a directory, .tar, .tar.gz or .tgz")
+	}
+
+	tr := tar.NewReader(r)
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %q is outside the skill directory", hdr.Name)
+		}
+		target := filepath.Join(dst, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			total += hdr.Size
+			if total > maxInstallSize {
+				return fmt.Errorf("archive is larger than %d MB", maxInstallSize>>20)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fs.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.CopyN(out, tr, hdr.Size); err != nil {
+				out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Fingerprint summarizes the skills under dirs (each SKILL.md and the files in
+// its scripts directory) so callers can poll for changes cheaply. It changes
+// whenever a skill is added, removed or edited. Other files in dirs, which
+// may change often, are ignored.
+func Fingerprint(dirs []string) string {
+	h := sha256.New()
+	stat := func(path string) {
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(h, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	for _, dir := range dirs {
+		dir = expandPath(dir)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			skillDir := filepath.Join(dir, entry.Name())
+			skillMD := findSkillMD(skillDir)
+			if skillMD == "" {
+				continue
+			}
+			stat(skillMD)
+			scriptsDir := filepath.Join(skillDir, ScriptsDir)
+			scripts, _ := os.ReadDir(scriptsDir)
+			for _, script := range scripts {
+				stat(filepath.Join(scriptsDir, script.Name()))
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,216 @@
+package skills
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, "SKILL.md"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("good", "---\nname: good\ndescription: Works.\n---\n")
+	write("renamed", "---\nname: other\ndescription: Wrong directory.\n---\n")
+	write("broken", "no frontmatter")
+
+	candidates := Inspect([]string{dir})
+	if len(candidates) != 3 {
+		t.Fatalf("expected 3 candidates, got %d", len(candidates))
+	}
+	byName := make(map[string]Candidate)
+	for _, c := range candidates {
+		byName[c.Skill.Name] = c
+	}
+
+	if c := byName["good"]; c.Err != nil || c.Skill.Description != "Works." {
+		t.Errorf("good skill: %+v", c)
+	}
+	var verr *ValidationError
+	if c := byName["renamed"]; !errors.As(c.Err, &verr) || !strings.Contains(verr.Message, `does not match directory "renamed"`) {
+		t.Errorf("renamed skill error = %v", c.Err)
+	}
+	if c := byName["broken"]; c.Err == nil || c.Skill.Path != filepath.Join(dir, "broken", "SKILL.md") {
+		t.Errorf("broken skill: %+v", c)
+	}
+}
+
+func TestWriteAndRemove(t *testing.T) {
+	dir := t.TempDir()
+	in := Skill{
+		Name:         "release",
+		Description:  "Release checklist: tag, build, publish.",
+		License:      "MIT",
+		AllowedTools: "Bash(git:*) Read",
+		Metadata:     map[string]string{"version": "1.0", "author": "#team"},
+	}
+	got, err := Write(dir, in, "\n# Release\n\n1. Tag.\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in.Path = filepath.Join(dir, "release", "SKILL.md")
+	if !reflect.DeepEqual(got, in) {
+		t.Errorf("Write() = %+v, want %+v", got, in)
+	}
+	body, err := got.Body()
+	if err != nil || body != "# Release\n\n1. Tag." {
+		t.Errorf("Body() = %q, %v", body, err)
+	}
+
+	if _, err := Write(dir, Skill{Name: "Bad Name", Description: "x"}, ""); err == nil {
+		t.Error("expected invalid name to be rejected")
+	}
+	if _, err := Write(dir, Skill{Name: "multi", Description: "two\nlines"}, ""); err == nil {
+		t.Error("expected multi-line description to be rejected")
+	}
+
+	if err := Remove(dir, "release"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "release")); !os.IsNotExist(err) {
+		t.Errorf("skill directory still exists: %v", err)
+	}
+	if err := Remove(dir, "release"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Remove() of missing skill = %v", err)
+	}
+}
+
+// makeSkillSource creates a skill directory with a script, for installing.
+func makeSkillSource(t *testing.T, name string) string {
+	t.Helper()
+	src := filepath.Join(t.TempDir(), "src-"+name)
+	if err := os.MkdirAll(filepath.Join(src, ScriptsDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\nname: " + name + "\ndescription: Installed skill.\n---\n\nDo it.\n"
+	if err := os.WriteFile(filepath.Join(src, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, ScriptsDir, "run"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
+
+func TestInstallFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	src := makeSkillSource(t, "deploy")
+
+	skill, err := Install(dir, src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skill.Path != filepath.Join(dir, "deploy", "SKILL.md") {
+		t.Errorf("installed at %s", skill.Path)
+	}
+	info, err := os.Stat(filepath.Join(dir, "deploy", ScriptsDir, "run"))
+	if err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("script not copied with its mode: %v", err)
+	}
+
+	if _, err := Install(dir, src, false); !errors.Is(err, ErrExists) {
+		t.Errorf("second Install() = %v, want ErrExists", err)
+	}
+	if _, err := Install(dir, src, true); err != nil {
+		t.Errorf("Install() with replace: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected only the installed skill in %s, got %d entries", dir, len(entries))
+	}
+}
+
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstallFromArchive(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(t.TempDir(), "lint.tar.gz")
+	writeTarGz(t, archive, map[string]string{
+		"lint-1.0/SKILL.md":        "---\nname: lint\ndescription: Lint things.\n---\n",
+		"lint-1.0/reference/rules": "rules",
+	})
+
+	skill, err := Install(dir, archive, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skill.Name != "lint" {
+		t.Errorf("installed %q", skill.Name)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "lint", "reference", "rules")); err != nil {
+		t.Errorf("bundled file missing: %v", err)
+	}
+
+	evil := filepath.Join(t.TempDir(), "evil.tgz")
+	writeTarGz(t, evil, map[string]string{"../escape": "x"})
+	if _, err := Install(dir, evil, false); err == nil || !strings.Contains(err.Error(), "outside the skill directory") {
+		t.Errorf("expected path traversal to be rejected, got %v", err)
+	}
+
+	zip := filepath.Join(t.TempDir(), "skill.zip")
+	os.WriteFile(zip, []byte("PK"), 0o644)
+	if _, err := Install(dir, zip, false); err == nil {
+		t.Error("expected unsupported archive to be rejected")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	before := Fingerprint([]string{dir})
+
+	// Unrelated files don't count.
+	os.WriteFile(filepath.Join(dir, "shelley.db"), []byte("data"), 0o644)
+	if Fingerprint([]string{dir}) != before {
+		t.Error("fingerprint changed for a non-skill file")
+	}
+
+	if _, err := Write(dir, Skill{Name: "watch", Description: "Watched."}, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	added := Fingerprint([]string{dir})
+	if added == before {
+		t.Error("fingerprint did not change when a skill was added")
+	}
+
+	os.MkdirAll(filepath.Join(dir, "watch", ScriptsDir), 0o755)
+	os.WriteFile(filepath.Join(dir, "watch", ScriptsDir, "go"), []byte("#!/bin/sh\n"), 0o755)
+	if Fingerprint([]string{dir}) == added {
+		t.Error("fingerprint did not change when a script was added")
+	}
+}
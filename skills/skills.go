@@ -4,6 +4,7 @@ package skills
 
 import (
 	"context"
+	"fmt"
 	"html"
 	"os"
 	"path/filepath"
@@ -31,8 +32,30 @@ type Skill struct {
 
 // Discover finds all skills in the given directories.
 // It scans each directory for subdirectories containing SKILL.md files.
+// Invalid skills are skipped; use Inspect to see why.
 func Discover(dirs []string) []Skill {
 	var skills []Skill
+	for _, c := range Inspect(dirs) {
+		if c.Err == nil {
+			skills = append(skills, c.Skill)
+		}
+	}
+	return skills
+}
+
+// Candidate is a skill directory found by Inspect.
+type Candidate struct {
+	// Skill is the parsed skill. If Err is set, only Name (the directory
+	// name) and Path are filled in.
+	Skill Skill
+	// Err explains why the skill is invalid, usually a *ValidationError.
+	Err error
+}
+
+// Inspect finds every subdirectory of dirs containing a SKILL.md file and
+// parses it, keeping invalid skills along with the reason they are invalid.
+func Inspect(dirs []string) []Candidate {
+	var candidates []Candidate
 	seen := make(map[string]bool)
 
 	for _, dir := range dirs {
@@ -66,20 +89,17 @@ func Discover(dirs []string) []Skill {
 			seen[absPath] = true
 
 			skill, err := Parse(skillMD)
-			if err != nil {
-				continue // Skip invalid skills
+			if err == nil && skill.Name != entry.Name() {
+				err = &ValidationError{Message: fmt.Sprintf("name %q does not match directory %q", skill.Name, entry.Name())}
 			}
-
-			// Validate name matches directory
-			if skill.Name != entry.Name() {
-				continue
+			if err != nil {
+				skill = Skill{Name: entry.Name(), Path: skillMD}
 			}
-
-			skills = append(skills, skill)
+			candidates = append(candidates, Candidate{Skill: skill, Err: err})
 		}
 	}
 
-	return skills
+	return candidates
 }
 
 // findSkillMD looks for SKILL.md or skill.md in a directory.
@@ -153,6 +173,24 @@ func Parse(path string) (Skill, error) {
 	return skill, nil
 }
 
+// Dir returns the skill's directory, which holds SKILL.md and any bundled resources.
+func (s Skill) Dir() string {
+	return filepath.Dir(s.Path)
+}
+
+// Body reads the skill's SKILL.md and returns the instructions that follow the frontmatter.
+func (s Skill) Body() (string, error) {
+	content, err := os.ReadFile(s.Path)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.SplitN(string(content), "---", 3)
+	if len(parts) < 3 {
+		return "", &ValidationError{Message: "SKILL.md frontmatter not properly closed with ---"}
+	}
+	return strings.TrimSpace(parts[2]), nil
+}
+
 // ValidationError represents a skill validation error.
 type ValidationError struct {
 	Message string
@@ -383,6 +421,27 @@ func ProjectSkillsDirs(workingDir, gitRoot string) []string {
 	return dirs
 }
 
+// Collect discovers skills from the default directories, the .skills
+// directories between workingDir and gitRoot, and the project tree.
+// Skills found in more than one place are returned once.
+func Collect(workingDir, gitRoot string) []Skill {
+	dirs := DefaultDirs()
+	dirs = append(dirs, ProjectSkillsDirs(workingDir, gitRoot)...)
+	found := Discover(dirs)
+
+	seen := make(map[string]bool)
+	for _, s := range found {
+		seen[s.Path] = true
+	}
+	for _, s := range DiscoverInTree(workingDir, gitRoot) {
+		if !seen[s.Path] {
+			found = append(found, s)
+			seen[s.Path] = true
+		}
+	}
+	return found
+}
+
 // DiscoverInTree finds all skills by walking the directory tree looking for SKILL.md files.
 // If gitRoot is provided, it searches from gitRoot. Otherwise, it searches from workingDir downward.
 func DiscoverInTree(workingDir, gitRoot string) []Skill {
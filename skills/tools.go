@@ -0,0 +1,130 @@
+package skills
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ToolPermission is one entry of a skill's allowed-tools field,
+// e.g. "Read" or "Bash(git:*)".
+type ToolPermission struct {
+	Tool string `json:"tool"`
+	// Pattern restricts how the tool may be used, e.g. "git:*" for Bash.
+	// Empty means any use of the tool is allowed.
+	Pattern string `json:"pattern,omitempty"`
+}
+
+func (p ToolPermission) String() string {
+	if p.Pattern == "" {
+		return p.Tool
+	}
+	return p.Tool + "(" + p.Pattern + ")"
+}
+
+// ParseAllowedTools parses an allowed-tools value. Entries are separated by
+// spaces or commas; spaces inside parentheses belong to the pattern, so
+// "Bash(git status) Read" yields two entries.
+func ParseAllowedTools(s string) []ToolPermission {
+	var perms []ToolPermission
+	var cur strings.Builder
+	depth := 0
+
+	flush := func() {
+		entry := strings.TrimSpace(cur.String())
+		cur.Reset()
+		if entry == "" {
+			return
+		}
+		perm := ToolPermission{Tool: entry}
+		if open := strings.IndexByte(entry, '('); open > 0 && strings.HasSuffix(entry, ")") {
+			perm.Tool = entry[:open]
+			perm.Pattern = strings.TrimSpace(entry[open+1 : len(entry)-1])
+		}
+		perms = append(perms, perm)
+	}
+
+	for _, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0 && (r == ',' || r == ' ' || r == '\t' || r == '\n'):
+			flush()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	flush()
+	return perms
+}
+
+// Permissions returns the parsed allowed-tools of the skill.
+// An empty result means the skill does not restrict tools.
+func (s Skill) Permissions() []ToolPermission {
+	return ParseAllowedTools(s.AllowedTools)
+}
+
+// ScriptsDir is the skill subdirectory holding executable scripts.
+const ScriptsDir = "scripts"
+
+// scriptInterpreters maps script extensions to the interpreter that runs
+// them when the file is not executable itself.
+var scriptInterpreters = map[string]string{
+	".sh":   "bash",
+	".bash": "bash",
+	".py":   "python3",
+	".js":   "node",
+	".rb":   "ruby",
+}
+
+// Script is an executable file bundled in a skill's scripts directory.
+type Script struct {
+	Name string `json:"name"` // file name, e.g. "bump-version.sh"
+	Path string `json:"path"`
+	// Interpreter runs the script when it is not executable, e.g. "python3".
+	Interpreter string `json:"interpreter,omitempty"`
+}
+
+// Command returns the program and leading arguments that run the script.
+func (sc Script) Command() []string {
+	if sc.Interpreter != "" {
+		return []string{sc.Interpreter, sc.Path}
+	}
+	return []string{sc.Path}
+}
+
+// Scripts lists the runnable files in the skill's scripts directory in name
+// order. A file is runnable if it is executable or has a known script
+// extension. Subdirectories and hidden files are ignored.
+func (s Skill) Scripts() []Script {
+	dir := filepath.Join(s.Dir(), ScriptsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var scripts []Script
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		script := Script{Name: name, Path: path}
+		if info.Mode().Perm()&0o111 == 0 {
+			interp, ok := scriptInterpreters[strings.ToLower(filepath.Ext(name))]
+			if !ok {
+				continue
+			}
+			script.Interpreter = interp
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts
+}
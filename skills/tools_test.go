@@ -0,0 +1,106 @@
+package skills
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseAllowedTools(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []ToolPermission
+	}{
+		{"", nil},
+		{"Read", []ToolPermission{{Tool: "Read"}}},
+		{"Bash(git:*) Bash(jq:*) Read", []ToolPermission{
+			{Tool: "Bash", Pattern: "git:*"},
+			{Tool: "Bash", Pattern: "jq:*"},
+			{Tool: "Read"},
+		}},
+		{"Read, Grep,Glob", []ToolPermission{{Tool: "Read"}, {Tool: "Grep"}, {Tool: "Glob"}}},
+		{"Bash(git status) patch", []ToolPermission{
+			{Tool: "Bash", Pattern: "git status"},
+			{Tool: "patch"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ParseAllowedTools(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAllowedTools(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolPermissionString(t *testing.T) {
+	if got := (ToolPermission{Tool: "Bash", Pattern: "git:*"}).String(); got != "Bash(git:*)" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (ToolPermission{Tool: "Read"}).String(); got != "Read" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestSkillBody(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "SKILL.md")
+	content := "---\nname: release\ndescription: Release checklist.\n---\n\n# Steps\n\n1. Tag --- then push.\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	skill, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := skill.Body()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Steps\n\n1. Tag --- then push."; body != want {
+		t.Errorf("Body() = %q, want %q", body, want)
+	}
+	if skill.Dir() != dir {
+		t.Errorf("Dir() = %q, want %q", skill.Dir(), dir)
+	}
+}
+
+func TestSkillScripts(t *testing.T) {
+	dir := t.TempDir()
+	scriptsDir := filepath.Join(dir, ScriptsDir)
+	if err := os.MkdirAll(filepath.Join(scriptsDir, "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]os.FileMode{
+		"bump":      0o755,
+		"check.py":  0o644,
+		"notes.txt": 0o644,
+		".hidden":   0o755,
+	}
+	for name, mode := range files {
+		if err := os.WriteFile(filepath.Join(scriptsDir, name), []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	skill := Skill{Name: "release", Path: filepath.Join(dir, "SKILL.md")}
+	got := skill.Scripts()
+	want := []Script{
+		{Name: "bump", Path: filepath.Join(scriptsDir, "bump")},
+		{Name: "check.py", Path: filepath.Join(scriptsDir, "check.py"), Interpreter: "python3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Scripts() = %+v, want %+v", got, want)
+	}
+	if cmd := got[1].Command(); !reflect.DeepEqual(cmd, []string{"python3", want[1].Path}) {
+		t.Errorf("Command() = %q", cmd)
+	}
+
+	if scripts := (Skill{Path: filepath.Join(t.TempDir(), "SKILL.md")}).Scripts(); scripts != nil {
+		t.Errorf("expected no scripts, got %+v", scripts)
+	}
+}
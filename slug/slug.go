@@ -116,6 +116,26 @@ func hasTag(tags, tag string) bool {
 	return false
 }
 
+// slugResponse is the structured reply requested from the LLM.
+type slugResponse struct {
+	Slug string `json:"slug"`
+}
+
+var slugResponseSchema = &llm.ResponseSchema{
+	Name:        "conversation_slug",
+	Description: "A short slug for the conversation.",
+	Schema: llm.MustSchema(`{
+  "type": "object",
+  "required": ["slug"],
+  "properties": {
+    "slug": {
+      "type": "string",
+      "description": "2-6 lowercase words separated by hyphens"
+    }
+  }
+}`),
+}
+
 // callSlugLLM calls an LLM service to generate a slug from a user message.
 func callSlugLLM(ctx context.Context, llmService llm.Service, userMessage string) (string, error) {
 	slugPrompt := fmt.Sprintf(`Generate a short, descriptive slug (2-6 words, lowercase, hyphen-separated) for a conversation that starts with this user message:
@@ -138,14 +158,22 @@ Respond with only the slug, nothing else.`, userMessage)
 	}
 
 	request := &llm.Request{
-		Messages: []llm.Message{message},
+		Messages:       []llm.Message{message},
+		ResponseSchema: slugResponseSchema,
 	}
 
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	response, err := llmService.Do(ctxWithTimeout, request)
-	if err != nil {
+	out, response, err := llm.DoJSON[slugResponse](ctxWithTimeout, llmService, request)
+	structured := err == nil
+	if response == nil && ctxWithTimeout.Err() == nil {
+		// Some endpoints reject a response schema outright; ask again
+		// without one.
+		request.ResponseSchema = nil
+		response, err = llmService.Do(ctxWithTimeout, request)
+	}
+	if response == nil {
 		return "", fmt.Errorf("failed to generate slug: %w", err)
 	}
 
@@ -153,7 +181,12 @@ Respond with only the slug, nothing else.`, userMessage)
 		return "", fmt.Errorf("empty response from LLM")
 	}
 
-	slug := strings.TrimSpace(response.Content[0].Text)
+	slug := out.Slug
+	if !structured {
+		// Not every provider honors the schema (e.g. some OpenAI-compatible
+		// endpoints); fall back to treating the reply as the bare slug.
+		slug = strings.TrimSpace(response.Content[0].Text)
+	}
 	slug = Sanitize(slug)
 	if slug == "" {
 		return "", fmt.Errorf("generated slug is empty after sanitization")
@@ -321,6 +321,52 @@ func (m *MockLLMServiceEmptyResponse) MaxImageDimension() int {
 	return 0
 }
 
+// MockLLMServiceNoSchema provides a mock LLM service that rejects requests
+// with a response schema, like endpoints without structured output.
+type MockLLMServiceNoSchema struct {
+	MockLLMService
+}
+
+func (m *MockLLMServiceNoSchema) Do(ctx context.Context, req *llm.Request) (*llm.Response, error) {
+	if req.ResponseSchema != nil {
+		return nil, fmt.Errorf("400 Bad Request: response_format json_schema is not supported")
+	}
+	return m.MockLLMService.Do(ctx, req)
+}
+
+// MockLLMProviderNoSchema provides a mock LLM provider whose service rejects response schemas
+type MockLLMProviderNoSchema struct{}
+
+func (m *MockLLMProviderNoSchema) GetService(modelID string) (llm.Service, error) {
+	return &MockLLMServiceNoSchema{MockLLMService{ResponseText: "fix-login-bug"}}, nil
+}
+
+func (m *MockLLMProviderNoSchema) GetAvailableModels() []string {
+	return []string{"mock"}
+}
+
+func (m *MockLLMProviderNoSchema) GetModelInfo(modelID string) *models.ModelInfo {
+	return nil
+}
+
+// TestGenerateSlug_SchemaRejected tests that a slug is still generated when
+// the endpoint rejects the response schema
+func TestGenerateSlug_SchemaRejected(t *testing.T) {
+	mockLLM := &MockLLMProviderNoSchema{}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+	}))
+
+	slug, err := generateSlugText(context.Background(), mockLLM, logger, "Test message", "test-model")
+	if err != nil {
+		t.Fatalf("generateSlugText: %v", err)
+	}
+	if slug != "fix-login-bug" {
+		t.Errorf("slug = %q, want %q", slug, "fix-login-bug")
+	}
+}
+
 // TestGenerateSlug_SanitizationError tests error handling when slug is empty after sanitization
 func TestGenerateSlug_SanitizationError(t *testing.T) {
 	// Mock LLM that returns only special characters that get sanitized away
@@ -535,3 +581,26 @@ func (m *mockFallbackProvider) GetAvailableModels() []string {
 func (m *mockFallbackProvider) GetModelInfo(modelID string) *models.ModelInfo {
 	return m.modelInfo[modelID]
 }
+
+func TestCallSlugLLM_StructuredResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"json", `{"slug":"fix-login-bug"}`, "fix-login-bug"},
+		{"fenced json", "```json\n{\"slug\":\"Fix Login Bug\"}\n```", "fix-login-bug"},
+		{"plain text fallback", "fix-login-bug", "fix-login-bug"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := callSlugLLM(context.Background(), &MockLLMService{ResponseText: tt.response}, "my login is broken")
+			if err != nil {
+				t.Fatalf("callSlugLLM() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("callSlugLLM() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
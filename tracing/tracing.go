@@ -0,0 +1,105 @@
+// Package tracing sets up OpenTelemetry tracing for Shelley.
+//
+// Packages create spans with Tracer. Until Setup installs a provider with an
+// exporter, the global OpenTelemetry provider is a no-op and spans cost
+// next to nothing.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"shelley.exe.dev/version"
+)
+
+// Tracer returns the tracer Shelley's spans are created with.
+func Tracer() trace.Tracer {
+	return otel.Tracer("shelley.exe.dev")
+}
+
+// Config says where to export spans.
+type Config struct {
+	// File, if set, receives finished spans as JSON, one object per line,
+	// so traces can be inspected without a collector.
+	File string
+	// OTLP exports spans over OTLP/HTTP to the endpoint named by the
+	// standard OTEL_EXPORTER_OTLP_ENDPOINT or
+	// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT environment variables, which also
+	// configure headers, TLS and so on.
+	OTLP bool
+}
+
+// ConfigFromEnv returns a Config exporting to the file at path, if
+// non-empty, and over OTLP if an OTLP endpoint is set in the environment.
+func ConfigFromEnv(path string) Config {
+	return Config{
+		File: path,
+		OTLP: os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "",
+	}
+}
+
+// Setup installs a global tracer provider exporting to the destinations in
+// cfg. If cfg names none, it does nothing. The returned function flushes
+// pending spans and stops exporting; it is never nil.
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	noop := func(context.Context) error { return nil }
+	if cfg.File == "" && !cfg.OTLP {
+		return noop, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", "shelley"),
+			attribute.String("service.version", version.GetInfo().Commit),
+		),
+		resource.WithFromEnv(), // OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES win
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return noop, fmt.Errorf("tracing resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	var file *os.File
+	if cfg.File != "" {
+		file, err = os.OpenFile(cfg.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return noop, fmt.Errorf("trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return noop, fmt.Errorf("trace file exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	if cfg.OTLP {
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			if file != nil {
+				file.Close()
+			}
+			return noop, fmt.Errorf("OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			err = errors.Join(err, file.Close())
+		}
+		return err
+	}, nil
+}
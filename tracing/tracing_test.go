@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupFile(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	cfg := ConfigFromEnv(path)
+	if cfg.OTLP {
+		t.Fatal("OTLP enabled without an endpoint")
+	}
+	shutdown, err := Setup(t.Context(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, parent := Tracer().Start(t.Context(), "parent")
+	_, child := Tracer().Start(ctx, "child")
+	child.End()
+	parent.End()
+	if err := shutdown(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	type span struct {
+		Name        string
+		SpanContext struct{ SpanID string }
+		Parent      struct{ SpanID string }
+	}
+	spans := map[string]span{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s span
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			t.Fatalf("line is not a JSON span: %v\n%s", err, scanner.Text())
+		}
+		spans[s.Name] = s
+	}
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %v", spans)
+	}
+	if spans["child"].Parent.SpanID != spans["parent"].SpanContext.SpanID {
+		t.Errorf("child's parent is %s, want %s", spans["child"].Parent.SpanID, spans["parent"].SpanContext.SpanID)
+	}
+}
+
+func TestSetupNothing(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	shutdown, err := Setup(t.Context(), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if otel.GetTracerProvider() != prev {
+		t.Error("Setup without exporters replaced the tracer provider")
+	}
+}
@@ -4,6 +4,16 @@ import { LLMContent } from "../types";
 // Display data from the bash tool backend
 interface BashDisplayData {
   workingDir: string;
+  sandbox?: SandboxReport;
+}
+
+// How the command was confined, when the conversation has a sandbox
+interface SandboxReport {
+  filesystem: string;
+  writable?: string[];
+  network: string;
+  limits?: string[];
+  warnings?: string[];
 }
 
 interface BashToolProps {
@@ -78,6 +88,11 @@ function BashTool({
               in {displayData.workingDir}
             </span>
           )}
+          {displayData?.sandbox && (
+            <span className="bash-tool-sandbox" title={`network ${displayData.sandbox.network}`}>
+              sandboxed
+            </span>
+          )}
           {isComplete && isCancelled && <span className="bash-tool-cancelled">✗ cancelled</span>}
           {isComplete && hasError && !isCancelled && <span className="bash-tool-error">✗</span>}
           {isComplete && !hasError && <span className="bash-tool-success">✓</span>}
@@ -117,6 +132,21 @@ function BashTool({
               <pre className="bash-tool-code bash-tool-code-cwd">{displayData.workingDir}</pre>
             </div>
           )}
+          {displayData?.sandbox && (
+            <div className="bash-tool-section">
+              <div className="bash-tool-label">Sandbox:</div>
+              <pre className="bash-tool-code bash-tool-code-cwd">
+                {[
+                  `${displayData.sandbox.filesystem}, network ${displayData.sandbox.network}`,
+                  `writable: ${(displayData.sandbox.writable ?? []).join(", ") || "(none)"}`,
+                  ...(displayData.sandbox.limits?.length
+                    ? [`limits: ${displayData.sandbox.limits.join(", ")}`]
+                    : []),
+                  ...(displayData.sandbox.warnings ?? []).map((w) => `warning: ${w}`),
+                ].join("\n")}
+              </pre>
+            </div>
+          )}
           <div className="bash-tool-section">
             <div className="bash-tool-label">Command:</div>
             <pre className="bash-tool-code">{command}</pre>
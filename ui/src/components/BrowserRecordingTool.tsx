@@ -0,0 +1,185 @@
+import React, { useState } from "react";
+import { LLMContent } from "../types";
+
+interface BrowserRecordingToolProps {
+  toolInput?: unknown;
+  isRunning?: boolean;
+  toolResult?: LLMContent[];
+  hasError?: boolean;
+  executionTime?: string;
+  display?: unknown;
+}
+
+interface RecordingStep {
+  index: number;
+  action: string;
+  summary: string;
+  error?: string;
+  screenshot_url?: string;
+}
+
+interface RecordingDisplay {
+  type: "browser_recording";
+  name: string;
+  path: string;
+  steps: RecordingStep[];
+}
+
+function getRecordingDisplay(display: unknown): RecordingDisplay | null {
+  if (
+    typeof display === "object" &&
+    display !== null &&
+    (display as { type?: unknown }).type === "browser_recording" &&
+    Array.isArray((display as { steps?: unknown }).steps)
+  ) {
+    return display as RecordingDisplay;
+  }
+  return null;
+}
+
+function BrowserRecordingTool({
+  toolInput,
+  isRunning,
+  toolResult,
+  hasError,
+  executionTime,
+  display,
+}: BrowserRecordingToolProps) {
+  const recording = getRecordingDisplay(display);
+  // Show the timeline straight away when a recording is stopped
+  const [isExpanded, setIsExpanded] = useState(recording !== null);
+
+  const input =
+    typeof toolInput === "object" && toolInput !== null
+      ? (toolInput as { action?: string; name?: string; selector?: string; text?: string })
+      : {};
+
+  const action = input.action || "";
+
+  const output =
+    toolResult && toolResult.length > 0 && toolResult[0].Text ? toolResult[0].Text : "";
+
+  const isComplete = !isRunning && toolResult !== undefined;
+
+  let summary = action || "recording";
+  if (recording) {
+    summary = `${recording.name} (${recording.steps.length} steps)`;
+  } else if (action === "start" && input.name) {
+    summary = `start: ${input.name}`;
+  } else if (action === "assert") {
+    summary = `assert ${input.selector || input.name || ""}`.trim();
+  }
+
+  return (
+    <div className="tool" data-testid={isComplete ? "tool-call-completed" : "tool-call-running"}>
+      <div className="tool-header" onClick={() => setIsExpanded(!isExpanded)}>
+        <div className="tool-summary">
+          <span className={`tool-emoji ${isRunning ? "running" : ""}`}>🎬</span>
+          <span className="tool-command">{summary}</span>
+          {isComplete && hasError && <span className="tool-error">✗</span>}
+          {isComplete && !hasError && <span className="tool-success">✓</span>}
+        </div>
+        <button
+          className="tool-toggle"
+          aria-label={isExpanded ? "Collapse" : "Expand"}
+          aria-expanded={isExpanded}
+        >
+          <svg
+            width="12"
+            height="12"
+            viewBox="0 0 12 12"
+            fill="none"
+            xmlns="http://www.w3.org/2000/svg"
+            style={{
+              transform: isExpanded ? "rotate(90deg)" : "rotate(0deg)",
+              transition: "transform 0.2s",
+            }}
+          >
+            <path
+              d="M4.5 3L7.5 6L4.5 9"
+              stroke="currentColor"
+              strokeWidth="1.5"
+              strokeLinecap="round"
+              strokeLinejoin="round"
+            />
+          </svg>
+        </button>
+      </div>
+
+      {isExpanded && (
+        <div className="tool-details">
+          {recording ? (
+            <div className="tool-section">
+              <div className="tool-label">
+                Timeline:
+                {executionTime && <span className="tool-time">{executionTime}</span>}
+              </div>
+              <ol style={{ listStyle: "none", margin: 0, padding: 0 }}>
+                {recording.steps.map((step) => (
+                  <li
+                    key={step.index}
+                    style={{
+                      display: "flex",
+                      gap: "0.75rem",
+                      alignItems: "flex-start",
+                      padding: "0.5rem 0",
+                      borderBottom: "1px solid var(--border)",
+                    }}
+                  >
+                    <span style={{ color: "var(--text-secondary)", minWidth: "1.5rem" }}>
+                      {step.index}.
+                    </span>
+                    <div style={{ flex: 1, minWidth: 0 }}>
+                      <div>
+                        <strong>{step.action}</strong>{" "}
+                        <code style={{ wordBreak: "break-all" }}>{step.summary}</code>
+                      </div>
+                      {step.error && <pre className="tool-code error">{step.error}</pre>}
+                    </div>
+                    {step.screenshot_url && (
+                      <a href={step.screenshot_url} target="_blank" rel="noopener noreferrer">
+                        <img
+                          src={step.screenshot_url}
+                          alt={`Step ${step.index}: ${step.action}`}
+                          style={{
+                            width: "160px",
+                            height: "auto",
+                            border: "1px solid var(--border)",
+                            borderRadius: "0.25rem",
+                          }}
+                        />
+                      </a>
+                    )}
+                  </li>
+                ))}
+              </ol>
+              <div className="tool-label" style={{ marginTop: "0.5rem" }}>
+                Session log:
+              </div>
+              <pre className="tool-code">{recording.path}</pre>
+            </div>
+          ) : (
+            <>
+              <div className="tool-section">
+                <div className="tool-label">Action:</div>
+                <pre className="tool-code">{action || "(none)"}</pre>
+              </div>
+
+              {isComplete && output && (
+                <div className="tool-section">
+                  <div className="tool-label">
+                    Output{hasError ? " (Error)" : ""}:
+                    {executionTime && <span className="tool-time">{executionTime}</span>}
+                  </div>
+                  <pre className={`tool-code ${hasError ? "error" : ""}`}>{output}</pre>
+                </div>
+              )}
+            </>
+          )}
+        </div>
+      )}
+    </div>
+  );
+}
+
+export default BrowserRecordingTool;
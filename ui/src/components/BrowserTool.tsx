@@ -4,6 +4,7 @@ import BrowserNavigateTool from "./BrowserNavigateTool";
 import BrowserEvalTool from "./BrowserEvalTool";
 import BrowserResizeTool from "./BrowserResizeTool";
 import BrowserConsoleLogsTool from "./BrowserConsoleLogsTool";
+import ReadImageTool from "./ReadImageTool";
 import ScreenshotTool from "./ScreenshotTool";
 import GenericTool from "./GenericTool";
 
@@ -40,6 +41,8 @@ function BrowserTool(props: BrowserToolProps) {
       return <BrowserResizeTool {...props} />;
     case "screenshot":
       return <ScreenshotTool {...props} />;
+    case "visual_diff":
+      return <ReadImageTool {...props} />;
     case "console_logs":
       return <BrowserConsoleLogsTool toolName="browser_recent_console_logs" {...props} />;
     case "clear_console_logs":
@@ -342,7 +342,8 @@ const Message = React.memo(function Message({
   // Based on llm/llm.go constants (iota continues across types in same const block):
   // MessageRoleUser = 0, MessageRoleAssistant = 1,
   // ContentTypeText = 2, ContentTypeThinking = 3, ContentTypeRedactedThinking = 4,
-  // ContentTypeToolUse = 5, ContentTypeToolResult = 6
+  // ContentTypeToolUse = 5, ContentTypeToolResult = 6,
+  // ContentTypeServerToolUse = 7, ContentTypeServerToolResult = 8
   const getContentType = (type: number): string => {
     switch (type) {
       case 0:
@@ -359,6 +360,10 @@ const Message = React.memo(function Message({
         return "tool_use";
       case 6:
         return "tool_result";
+      case 7:
+        return "server_tool_use";
+      case 8:
+        return "server_tool_result";
       default:
         return "unknown";
     }
@@ -500,8 +505,8 @@ const Message = React.memo(function Message({
   const toolUseMap: Record<string, { name: string; input: unknown }> = {};
   if (llmMessage && llmMessage.Content) {
     llmMessage.Content.forEach((content) => {
-      if (content.Type === 5 && content.ID && content.ToolName) {
-        // tool_use
+      if ((content.Type === 5 || content.Type === 7) && content.ID && content.ToolName) {
+        // tool_use or server_tool_use
         toolUseMap[content.ID] = {
           name: content.ToolName,
           input: content.ToolInput,
@@ -560,7 +565,7 @@ const Message = React.memo(function Message({
           content.ToolName ||
           "Unknown Tool";
         const toolInput = toolInfo && typeof toolInfo === "object" ? toolInfo.input : undefined;
-        return renderToolCall({
+        const toolCall = renderToolCall({
           toolName: rawToolName,
           toolInput,
           isRunning: false,
@@ -570,6 +575,32 @@ const Message = React.memo(function Message({
           display: content.Display,
           onCommentTextChange,
         });
+        const redactions = content.Redactions || [];
+        if (redactions.length === 0) return toolCall;
+        return (
+          <>
+            {toolCall}
+            <div
+              className="tool-redaction-badge"
+              title={`Redacted before storing or sending to the model: ${[...new Set(redactions)].join(", ")}`}
+            >
+              🔒 {redactions.length} secret{redactions.length === 1 ? "" : "s"} redacted
+            </div>
+          </>
+        );
+      }
+      case "server_tool_result": {
+        // Provider-executed tools (web search, code execution) finish before the
+        // response arrives, so only the result is shown, with its summary as output.
+        const toolInfo = content.ToolUseID ? toolUseMap[content.ToolUseID] : undefined;
+        return renderToolCall({
+          toolName: content.ToolName,
+          toolInput: toolInfo?.input,
+          isRunning: false,
+          toolResult: [{ ID: "", Type: 2, Text: content.Text }],
+          hasError: content.ToolError,
+          display: content.Display,
+        });
       }
       case "redacted_thinking": {
         const summary = usage?.reasoning_tokens
@@ -850,7 +881,7 @@ const Message = React.memo(function Message({
   const meaningfulContent =
     llmMessage?.Content?.filter((c) => {
       const contentType = c.Type;
-      // Filter out redacted thinking (4), tool_use (5), tool_result (6), and empty text content
+      // Filter out redacted thinking (4), tool_use (5), tool_result (6), server_tool_use (7), and empty text content
       // Keep thinking (3) if it has content
       if (contentType === 3) {
         return !!(c.Thinking || c.Text);
@@ -859,6 +890,7 @@ const Message = React.memo(function Message({
         contentType !== 4 &&
         contentType !== 5 &&
         contentType !== 6 &&
+        contentType !== 7 &&
         (c.Text?.trim() || contentType !== 2)
       ); // 4 = redacted_thinking, 5 = tool_use, 6 = tool_result, 2 = text
     }) || [];
@@ -9,6 +9,8 @@ import {
   TestCustomModelRequest,
   CodexAuthStatus,
   ProviderType,
+  ModelPricing,
+  ModelCapabilities,
 } from "../services/api";
 
 interface ModelsModalProps {
@@ -67,8 +69,24 @@ interface FormData {
   model_name: string;
   max_tokens: number;
   tags: string; // Comma-separated tags
+  // Prices in USD per million tokens; empty means unset
+  price_input: string;
+  price_output: string;
+  price_cache_read: string;
+  price_cache_write: string;
+  pricing?: ModelPricing; // Original pricing, so fields the form doesn't edit are kept
+  capabilities?: ModelCapabilities; // Unset until edited, meaning the provider defaults
 }
 
+// Shown for new models until the user edits a capability (mirrors llm.DefaultCapabilities)
+const defaultCapabilities: ModelCapabilities = {
+  tools: true,
+  images: true,
+  reasoning: false,
+  simplified_patch: true,
+  parallel_tool_calls: true,
+};
+
 const emptyForm: FormData = {
   display_name: "",
   provider_type: "anthropic",
@@ -78,8 +96,30 @@ const emptyForm: FormData = {
   model_name: "",
   max_tokens: 200000,
   tags: "",
+  price_input: "",
+  price_output: "",
+  price_cache_read: "",
+  price_cache_write: "",
 };
 
+function priceString(price: number | undefined): string {
+  return price ? String(price) : "";
+}
+
+// formPricing builds the pricing to save from the form, or null if no prices are set.
+function formPricing(form: FormData): ModelPricing | null {
+  const prices = {
+    input: parseFloat(form.price_input) || 0,
+    output: parseFloat(form.price_output) || 0,
+    cache_read: parseFloat(form.price_cache_read) || 0,
+    cache_write: parseFloat(form.price_cache_write) || 0,
+  };
+  if (!prices.input && !prices.output && !prices.cache_read && !prices.cache_write) {
+    return null;
+  }
+  return { ...form.pricing, ...prices };
+}
+
 // Codex models that become available after login
 const CODEX_MODELS = [
   { name: "GPT-5.4", model_name: "gpt-5.4" },
@@ -383,6 +423,8 @@ function ModelsModal({ isOpen, onClose, onModelsChanged }: ModelsModalProps) {
         model_name: form.model_name,
         max_tokens: form.max_tokens,
         tags: form.tags,
+        pricing: formPricing(form),
+        capabilities: form.capabilities,
       };
 
       if (editingModelId) {
@@ -413,6 +455,12 @@ function ModelsModal({ isOpen, onClose, onModelsChanged }: ModelsModalProps) {
       model_name: model.model_name,
       max_tokens: model.max_tokens,
       tags: model.tags,
+      price_input: priceString(model.pricing?.input),
+      price_output: priceString(model.pricing?.output),
+      price_cache_read: priceString(model.pricing?.cache_read),
+      price_cache_write: priceString(model.pricing?.cache_write),
+      pricing: model.pricing,
+      capabilities: model.capabilities,
     });
     setShowForm(true);
     setTestResult(null);
@@ -601,6 +649,82 @@ function ModelsModal({ isOpen, onClose, onModelsChanged }: ModelsModalProps) {
               />
             </div>
 
+            {/* Pricing */}
+            <div className="form-group">
+              <label>{t("pricePerMillionTokens")}</label>
+              <div className="form-price-grid">
+                {(
+                  [
+                    ["price_input", "priceInput"],
+                    ["price_output", "priceOutput"],
+                    ["price_cache_read", "priceCacheRead"],
+                    ["price_cache_write", "priceCacheWrite"],
+                  ] as const
+                ).map(([field, label]) => (
+                  <input
+                    key={field}
+                    type="number"
+                    min="0"
+                    step="any"
+                    value={form[field]}
+                    onChange={(e) => setForm((prev) => ({ ...prev, [field]: e.target.value }))}
+                    placeholder={t(label)}
+                    aria-label={t(label)}
+                    className="form-input"
+                  />
+                ))}
+              </div>
+            </div>
+
+            {/* Capabilities */}
+            <div className="form-group">
+              <label>{t("capabilities")}</label>
+              {(
+                [
+                  ["tools", "capabilityTools"],
+                  ["images", "capabilityImages"],
+                  ["simplified_patch", "capabilitySimplifiedPatch"],
+                  ["parallel_tool_calls", "capabilityParallelToolCalls"],
+                ] as const
+              ).map(([field, label]) => (
+                <div className="form-checkbox" key={field}>
+                  <label>
+                    <input
+                      type="checkbox"
+                      checked={(form.capabilities ?? defaultCapabilities)[field]}
+                      onChange={(e) =>
+                        setForm((prev) => ({
+                          ...prev,
+                          capabilities: {
+                            ...(prev.capabilities ?? defaultCapabilities),
+                            [field]: e.target.checked,
+                          },
+                        }))
+                      }
+                    />
+                    {t(label)}
+                  </label>
+                </div>
+              ))}
+              <input
+                type="number"
+                min="0"
+                value={(form.capabilities ?? defaultCapabilities).max_tools || ""}
+                onChange={(e) =>
+                  setForm((prev) => ({
+                    ...prev,
+                    capabilities: {
+                      ...(prev.capabilities ?? defaultCapabilities),
+                      max_tools: parseInt(e.target.value) || 0,
+                    },
+                  }))
+                }
+                placeholder={t("maxTools")}
+                aria-label={t("maxTools")}
+                className="form-input"
+              />
+            </div>
+
             {/* Tags */}
             <div className="form-group">
               <label>
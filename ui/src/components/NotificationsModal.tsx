@@ -2,7 +2,12 @@ import React, { useState, useEffect, useCallback } from "react";
 import Modal from "./Modal";
 import { useI18n } from "../i18n";
 import ConfigFieldInput from "./ConfigFieldInput";
-import { notificationChannelsApi, NotificationChannelAPI, ChannelTypeInfo } from "../services/api";
+import {
+  notificationChannelsApi,
+  NotificationChannelAPI,
+  NotificationDelivery,
+  ChannelTypeInfo,
+} from "../services/api";
 import {
   getBrowserNotificationState,
   requestBrowserNotificationPermission,
@@ -51,6 +56,9 @@ function NotificationsModal({ isOpen, onClose }: NotificationsModalProps) {
   const [testing, setTesting] = useState(false);
   const [testResult, setTestResult] = useState<{ success: boolean; message: string } | null>(null);
 
+  // Delivery history of the channel being edited
+  const [deliveries, setDeliveries] = useState<NotificationDelivery[]>([]);
+
   const channelTypes = getChannelTypes();
 
   const loadChannels = useCallback(async () => {
@@ -90,6 +98,16 @@ function NotificationsModal({ isOpen, onClose }: NotificationsModalProps) {
     setEditingChannelId(ch.channel_id);
     setTestResult(null);
     setShowForm(true);
+    loadDeliveries(ch.channel_id);
+  };
+
+  const loadDeliveries = async (channelId: string) => {
+    setDeliveries([]);
+    try {
+      setDeliveries(await notificationChannelsApi.getDeliveries(channelId));
+    } catch {
+      // History is informational; the form works without it.
+    }
   };
 
   const defaultConfigFor = (typeName: string): Record<string, string> => {
@@ -257,6 +275,51 @@ function NotificationsModal({ isOpen, onClose }: NotificationsModalProps) {
           </div>
         )}
 
+        {editingChannelId && (
+          <div className="form-group">
+            <label>{t("recentDeliveries")}</label>
+            {deliveries.length === 0 ? (
+              <div style={{ fontSize: "0.75rem", color: "var(--text-secondary)" }}>
+                {t("noDeliveriesYet")}
+              </div>
+            ) : (
+              <div style={{ maxHeight: "12rem", overflowY: "auto", fontSize: "0.75rem" }}>
+                {deliveries.map((d) => (
+                  <div
+                    key={d.delivery_id}
+                    style={{ display: "flex", gap: "0.5rem", padding: "0.25rem 0" }}
+                    title={d.last_error || undefined}
+                  >
+                    <span
+                      style={{
+                        flexShrink: 0,
+                        width: "3.5rem",
+                        color:
+                          d.status === "sent"
+                            ? "var(--success-text)"
+                            : d.status === "failed"
+                              ? "var(--error-text)"
+                              : "var(--text-secondary)",
+                      }}
+                    >
+                      {d.status}
+                    </span>
+                    <span style={{ flexShrink: 0, color: "var(--text-secondary)" }}>
+                      {new Date(d.created_at).toLocaleString()}
+                    </span>
+                    <span
+                      style={{ flex: 1, minWidth: 0, overflow: "hidden", textOverflow: "ellipsis" }}
+                    >
+                      {d.title || d.event_type}
+                      {d.last_error && ` — ${d.last_error}`}
+                    </span>
+                  </div>
+                ))}
+              </div>
+            )}
+          </div>
+        )}
+
         <div className="form-actions">
           <button className="btn btn-secondary" onClick={handleCancel}>
             {t("cancel")}
@@ -8,6 +8,7 @@ import BrowserEvalTool from "./BrowserEvalTool";
 import BrowserNavigateTool from "./BrowserNavigateTool";
 import BrowserNetworkTool from "./BrowserNetworkTool";
 import BrowserProfileTool from "./BrowserProfileTool";
+import BrowserRecordingTool from "./BrowserRecordingTool";
 import BrowserResizeTool from "./BrowserResizeTool";
 import BrowserTool from "./BrowserTool";
 import ChangeDirTool from "./ChangeDirTool";
@@ -38,6 +39,7 @@ export const TOOL_COMPONENTS: Record<string, React.ComponentType<any>> = {
   browser_network: BrowserNetworkTool,
   browser_accessibility: BrowserAccessibilityTool,
   browser_profile: BrowserProfileTool,
+  browser_recording: BrowserRecordingTool,
   browser_take_screenshot: ScreenshotTool,
   browser_navigate: BrowserNavigateTool,
   browser_eval: BrowserEvalTool,
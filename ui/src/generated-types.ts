@@ -78,6 +78,8 @@ export interface NotificationEventForTS {
 	conversation_id: string;
 	timestamp: string;
 	payload?: unknown;
+	cwd?: string;
+	subagent?: boolean;
 }
 
 export interface StreamResponseForTS {
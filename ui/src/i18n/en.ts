@@ -127,6 +127,17 @@ export const en: TranslationKeys = {
   apiKey: "API Key",
   enterApiKey: "Enter API key",
   maxContextTokens: "Max Context Tokens",
+  pricePerMillionTokens: "Price per Million Tokens, USD (optional)",
+  priceInput: "Input",
+  priceOutput: "Output",
+  priceCacheRead: "Cache read",
+  priceCacheWrite: "Cache write",
+  capabilities: "Capabilities",
+  capabilityTools: "Tool use",
+  capabilityImages: "Image input",
+  capabilitySimplifiedPatch: "Simplified patch tool",
+  capabilityParallelToolCalls: "Parallel tool calls",
+  maxTools: "Max tools (empty for no limit)",
   tags: "Tags",
   tagsPlaceholder: "comma-separated, e.g., slug, cheap",
   tagsTooltip:
@@ -160,6 +171,8 @@ export const en: TranslationKeys = {
   denied: "Denied",
   noServerChannelsConfigured: "No server channels configured",
   addOne: "Add one",
+  recentDeliveries: "Recent deliveries",
+  noDeliveriesYet: "Nothing sent yet",
   edit: "Edit",
 
   // Diff Viewer
@@ -127,6 +127,17 @@ export const es: TranslationKeys = {
   apiKey: "Clave de API",
   enterApiKey: "Ingrese la clave de API",
   maxContextTokens: "Tokens de contexto máximos",
+  pricePerMillionTokens: "Precio por millón de tokens, USD (opcional)",
+  priceInput: "Entrada",
+  priceOutput: "Salida",
+  priceCacheRead: "Lectura de caché",
+  priceCacheWrite: "Escritura de caché",
+  capabilities: "Capacidades",
+  capabilityTools: "Uso de herramientas",
+  capabilityImages: "Entrada de imágenes",
+  capabilitySimplifiedPatch: "Herramienta de parche simplificada",
+  capabilityParallelToolCalls: "Llamadas a herramientas en paralelo",
+  maxTools: "Máximo de herramientas (vacío para sin límite)",
   tags: "Etiquetas",
   tagsPlaceholder: "separadas por comas, ej., slug, cheap",
   tagsTooltip:
@@ -160,6 +171,8 @@ export const es: TranslationKeys = {
   denied: "Denegado",
   noServerChannelsConfigured: "No hay canales de servidor configurados",
   addOne: "Agregar uno",
+  recentDeliveries: "Entregas recientes",
+  noDeliveriesYet: "Aún no se ha enviado nada",
   edit: "Editar",
 
   // Diff Viewer
@@ -128,6 +128,17 @@ export const fr: TranslationKeys = {
   apiKey: "Clé API",
   enterApiKey: "Saisir la clé API",
   maxContextTokens: "Nombre maximum de tokens de contexte",
+  pricePerMillionTokens: "Prix par million de tokens, USD (facultatif)",
+  priceInput: "Entrée",
+  priceOutput: "Sortie",
+  priceCacheRead: "Lecture du cache",
+  priceCacheWrite: "Écriture du cache",
+  capabilities: "Capacités",
+  capabilityTools: "Utilisation d'outils",
+  capabilityImages: "Entrée d'images",
+  capabilitySimplifiedPatch: "Outil de patch simplifié",
+  capabilityParallelToolCalls: "Appels d'outils en parallèle",
+  maxTools: "Nombre maximum d'outils (vide pour aucune limite)",
   tags: "Étiquettes",
   tagsPlaceholder: "séparées par des virgules, ex : slug, cheap",
   tagsTooltip:
@@ -161,6 +172,8 @@ export const fr: TranslationKeys = {
   denied: "Refusé",
   noServerChannelsConfigured: "Aucun canal serveur configuré",
   addOne: "En ajouter un",
+  recentDeliveries: "Envois récents",
+  noDeliveriesYet: "Rien n'a encore été envoyé",
   edit: "Modifier",
 
   // Diff Viewer
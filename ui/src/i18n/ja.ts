@@ -127,6 +127,17 @@ export const ja: TranslationKeys = {
   apiKey: "APIキー",
   enterApiKey: "APIキーを入力",
   maxContextTokens: "最大コンテキストトークン数",
+  pricePerMillionTokens: "100万トークンあたりの価格（USD、任意）",
+  priceInput: "入力",
+  priceOutput: "出力",
+  priceCacheRead: "キャッシュ読み取り",
+  priceCacheWrite: "キャッシュ書き込み",
+  capabilities: "機能",
+  capabilityTools: "ツール使用",
+  capabilityImages: "画像入力",
+  capabilitySimplifiedPatch: "簡易パッチツール",
+  capabilityParallelToolCalls: "並列ツール呼び出し",
+  maxTools: "最大ツール数（空欄で無制限）",
   tags: "タグ",
   tagsPlaceholder: "カンマ区切り、例: slug, cheap",
   tagsTooltip:
@@ -160,6 +171,8 @@ export const ja: TranslationKeys = {
   denied: "拒否されました",
   noServerChannelsConfigured: "サーバーチャンネルが設定されていません",
   addOne: "追加する",
+  recentDeliveries: "最近の配信",
+  noDeliveriesYet: "まだ送信されていません",
   edit: "編集",
 
   // Diff Viewer
@@ -127,6 +127,17 @@ export const ru: TranslationKeys = {
   apiKey: "API-ключ",
   enterApiKey: "Введите API-ключ",
   maxContextTokens: "Макс. токенов контекста",
+  pricePerMillionTokens: "Цена за миллион токенов, USD (необязательно)",
+  priceInput: "Ввод",
+  priceOutput: "Вывод",
+  priceCacheRead: "Чтение кэша",
+  priceCacheWrite: "Запись в кэш",
+  capabilities: "Возможности",
+  capabilityTools: "Использование инструментов",
+  capabilityImages: "Ввод изображений",
+  capabilitySimplifiedPatch: "Упрощённый инструмент патчей",
+  capabilityParallelToolCalls: "Параллельные вызовы инструментов",
+  maxTools: "Макс. инструментов (пусто — без ограничений)",
   tags: "Теги",
   tagsPlaceholder: "через запятую, напр., slug, cheap",
   tagsTooltip:
@@ -160,6 +171,8 @@ export const ru: TranslationKeys = {
   denied: "Запрещено",
   noServerChannelsConfigured: "Серверные каналы не настроены",
   addOne: "Добавить",
+  recentDeliveries: "Последние отправки",
+  noDeliveriesYet: "Пока ничего не отправлено",
   edit: "Редактировать",
 
   // Diff Viewer
@@ -127,6 +127,17 @@ export interface TranslationKeys {
   apiKey: string;
   enterApiKey: string;
   maxContextTokens: string;
+  pricePerMillionTokens: string;
+  priceInput: string;
+  priceOutput: string;
+  priceCacheRead: string;
+  priceCacheWrite: string;
+  capabilities: string;
+  capabilityTools: string;
+  capabilityImages: string;
+  capabilitySimplifiedPatch: string;
+  capabilityParallelToolCalls: string;
+  maxTools: string;
   tags: string;
   tagsPlaceholder: string;
   tagsTooltip: string;
@@ -158,6 +169,8 @@ export interface TranslationKeys {
   denied: string;
   noServerChannelsConfigured: string;
   addOne: string;
+  recentDeliveries: string;
+  noDeliveriesYet: string;
   edit: string;
 
   // Diff Viewer
@@ -127,6 +127,17 @@ export const upgoer5: TranslationKeys = {
   apiKey: "Key",
   enterApiKey: "Put in your key",
   maxContextTokens: "Most words it can hold in its head",
+  pricePerMillionTokens: "Money for each thousand thousand words (if you want)",
+  priceInput: "Words in",
+  priceOutput: "Words out",
+  priceCacheRead: "Words it remembers",
+  priceCacheWrite: "Words it stores to remember",
+  capabilities: "What it can do",
+  capabilityTools: "Can use tools",
+  capabilityImages: "Can look at pictures",
+  capabilitySimplifiedPatch: "Easy way to change files",
+  capabilityParallelToolCalls: "Can use many tools at once",
+  maxTools: "Most tools it can have (leave empty for no top)",
   tags: "Marks",
   tagsPlaceholder: "put a small low mark between each one, like: fast, big",
   tagsTooltip:
@@ -160,6 +171,8 @@ export const upgoer5: TranslationKeys = {
   denied: "Said No",
   noServerChannelsConfigured: "No ways to hear from the far computer set up",
   addOne: "Add one",
+  recentDeliveries: "Things sent lately",
+  noDeliveriesYet: "Nothing sent yet",
   edit: "Change",
 
   // Diff Viewer
@@ -411,6 +411,32 @@ export interface CustomModel {
   model_name: string;
   max_tokens: number;
   tags: string; // Comma-separated tags (e.g., "slug" for slug generation)
+  pricing?: ModelPricing;
+  capabilities: ModelCapabilities; // Stored capabilities, or the provider defaults
+}
+
+// What a model can handle; drives which tools and tool schemas it is offered.
+export interface ModelCapabilities {
+  tools: boolean;
+  images: boolean;
+  reasoning: boolean;
+  simplified_patch: boolean;
+  parallel_tool_calls: boolean;
+  max_image_dimension?: number;
+  max_tools?: number; // 0 or unset means no limit
+}
+
+// Model prices in USD per million tokens. Cache prices default to input.
+export interface ModelPrices {
+  input: number;
+  output: number;
+  cache_read?: number;
+  cache_write?: number;
+  reasoning?: number;
+}
+
+export interface ModelPricing extends ModelPrices {
+  tiers?: (ModelPrices & { above_input_tokens: number })[];
 }
 
 export interface CreateCustomModelRequest {
@@ -421,6 +447,8 @@ export interface CreateCustomModelRequest {
   model_name: string;
   max_tokens: number;
   tags: string; // Comma-separated tags
+  pricing?: ModelPricing | null; // On update, unset keeps the stored pricing and null clears it
+  capabilities?: ModelCapabilities; // Unset means the provider defaults
 }
 
 export interface TestCustomModelRequest {
@@ -548,6 +576,19 @@ export interface ChannelTypeInfo {
   }[];
 }
 
+export interface NotificationDelivery {
+  delivery_id: number;
+  event_type: string;
+  conversation_id?: string;
+  title?: string;
+  status: "pending" | "sent" | "failed";
+  attempts: number;
+  last_error?: string;
+  next_attempt_at: string;
+  created_at: string;
+  updated_at: string;
+}
+
 class NotificationChannelsApi {
   private baseUrl = "/api";
 
@@ -605,6 +646,14 @@ class NotificationChannelsApi {
     await this.throwIfNotOk(response, "Failed to test notification channel");
     return response.json();
   }
+
+  async getDeliveries(channelId: string): Promise<NotificationDelivery[]> {
+    const response = await fetch(
+      `${this.baseUrl}/notification-channels/${channelId}/deliveries?limit=20`,
+    );
+    await this.throwIfNotOk(response, "Failed to get notification deliveries");
+    return response.json();
+  }
 }
 
 export const notificationChannelsApi = new NotificationChannelsApi();
@@ -18,5 +18,17 @@ export function browserNotificationHandler(event: NotificationEvent): void {
         tag: "shelley-agent-error",
       });
       break;
+    case "tool_approval_needed":
+      new Notification("Shelley", {
+        body: "Agent needs approval",
+        tag: "shelley-tool-approval",
+      });
+      break;
+    case "context_nearly_full":
+      new Notification("Shelley", {
+        body: "Context window nearly full",
+        tag: "shelley-context-full",
+      });
+      break;
   }
 }
@@ -36,7 +36,7 @@ export interface LLMMessage {
 
 export interface LLMContent {
   ID: string;
-  Type: number; // 2 = text, 3 = thinking, 4 = redacted_thinking, 5 = tool_use, 6 = tool_result
+  Type: number; // 2 = text, 3 = thinking, 4 = redacted_thinking, 5 = tool_use, 6 = tool_result, 7 = server_tool_use, 8 = server_tool_result
   Text?: string;
   ToolName?: string;
   ToolInput?: unknown;
@@ -44,6 +44,7 @@ export interface LLMContent {
   ToolError?: boolean;
   // Other fields from Go struct
   MediaType?: string;
+  Title?: string;
   Thinking?: string;
   ThinkingSummary?: string;
   Data?: string;
@@ -52,6 +53,8 @@ export interface LLMContent {
   ToolUseStartTime?: string | null;
   ToolUseEndTime?: string | null;
   Display?: unknown;
+  Redactions?: string[]; // kinds of secrets redacted from a tool result
+  Raw?: unknown; // server tool block in the provider's own encoding
   Cache?: boolean;
 }
 
@@ -70,7 +73,16 @@ export interface ChatRequest {
   cwd?: string;
 }
 // Notification event types
-export type NotificationEventType = "agent_done" | "agent_error";
+export type NotificationEventType =
+  | "agent_done"
+  | "agent_error"
+  | "tool_approval_needed"
+  | "tool_running"
+  | "subagent_done"
+  | "distill_done"
+  | "context_nearly_full"
+  | "upgrade_done"
+  | "git_commit";
 
 export interface NotificationEvent extends Omit<NotificationEventForTS, "type"> {
   type: NotificationEventType;